@@ -119,12 +119,16 @@ func (h *AgentTaskHandler) HandleTask(ctx context.Context, params TaskSendParams
 	}
 
 	// Run the agent loop synchronously
-	var finalContent string
+	var (
+		finalContent string
+		citations    []agent.Citation
+	)
 	err = runner.Run(ctx, agent.NewInput(userMessage), func(evt agent.Event) error {
 		// Capture the final response
 		if evt.Type() == agent.EventTypeComplete {
 			data := evt.Data().(*agent.CompleteData)
 			finalContent = data.Message
+			citations = data.Citations
 		}
 		return nil
 	})
@@ -143,7 +147,7 @@ func (h *AgentTaskHandler) HandleTask(ctx context.Context, params TaskSendParams
 		Timestamp: time.Now(),
 	}
 	task.Artifacts = []Artifact{{
-		Parts: []Part{{Type: "text", Text: finalContent}},
+		Parts: completeParts(finalContent, citations),
 		Index: 0,
 	}}
 	h.store.Set(task)
@@ -183,7 +187,10 @@ func (h *AgentTaskHandler) HandleTaskSubscribe(ctx context.Context, params TaskS
 		return nil
 	}
 
-	var finalContent string
+	var (
+		finalContent string
+		citations    []agent.Citation
+	)
 	err = runner.Run(ctx, agent.NewInput(userMessage), func(evt agent.Event) error {
 		// Stream intermediate events
 		switch evt.Type() {
@@ -218,6 +225,7 @@ func (h *AgentTaskHandler) HandleTaskSubscribe(ctx context.Context, params TaskS
 		case agent.EventTypeComplete:
 			data := evt.Data().(*agent.CompleteData)
 			finalContent = data.Message
+			citations = data.Citations
 		}
 		return nil
 	})
@@ -239,7 +247,7 @@ func (h *AgentTaskHandler) HandleTaskSubscribe(ctx context.Context, params TaskS
 	events <- TaskArtifactUpdateEvent{
 		ID: task.ID,
 		Artifact: Artifact{
-			Parts: []Part{{Type: "text", Text: finalContent}},
+			Parts: completeParts(finalContent, citations),
 			Index: 0,
 		},
 	}
@@ -250,7 +258,7 @@ func (h *AgentTaskHandler) HandleTaskSubscribe(ctx context.Context, params TaskS
 		Timestamp: time.Now(),
 	}
 	task.Artifacts = []Artifact{{
-		Parts: []Part{{Type: "text", Text: finalContent}},
+		Parts: completeParts(finalContent, citations),
 		Index: 0,
 	}}
 	h.store.Set(task)
@@ -313,6 +321,28 @@ func (h *AgentTaskHandler) initTask(params TaskSendParams) *Task {
 	return task
 }
 
+// completeParts returns the parts of the final artifact, the sources cited by
+// the agent being attached to the text part metadata
+func completeParts(content string, citations []agent.Citation) []Part {
+	part := Part{Type: "text", Text: content}
+
+	if len(citations) > 0 {
+		cited := make([]map[string]any, 0, len(citations))
+		for _, c := range citations {
+			cited = append(cited, map[string]any{
+				"url":        c.URL,
+				"title":      c.Title,
+				"content":    c.Content,
+				"startIndex": c.StartIndex,
+				"endIndex":   c.EndIndex,
+			})
+		}
+		part.Metadata = map[string]any{"citations": cited}
+	}
+
+	return []Part{part}
+}
+
 // partsToText extracts text from message parts
 func partsToText(parts []Part) string {
 	var sb strings.Builder
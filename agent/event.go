@@ -102,6 +102,9 @@ type ErrorData struct {
 // CompleteData represents the data for a EventTypeComplete event
 type CompleteData struct {
 	Message string
+	// Citations lists the sources cited by the model responses of the run,
+	// when the model returns them (e.g. search-enabled models).
+	Citations []Citation
 }
 
 // BudgetExceededData represents the data for a EventTypeBudgetExceeded event
@@ -122,6 +125,19 @@ type ReasoningData struct {
 	ReasoningDetails []ReasoningDetail
 }
 
+// Citation mirrors llm.Citation but lives in the agent package so callers of
+// the agent API do not need to import the llm package just for events.
+// StartIndex and EndIndex locate the citation in the final message, in
+// characters as llm.Citation does; both are zero for the sources cited by
+// the previous responses of the run.
+type Citation struct {
+	URL        string
+	Title      string
+	Content    string
+	StartIndex int
+	EndIndex   int
+}
+
 // ReasoningDetail mirrors llm.ReasoningDetail but lives in the agent package
 // so callers of the agent API do not need to import the llm package just for events.
 type ReasoningDetail struct {
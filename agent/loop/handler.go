@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -41,6 +42,10 @@ func (h *Handler) Handle(ctx context.Context, input agent.Input, emit agent.Emit
 	// Kept local to Handle so each invocation starts fresh.
 	finalInstructionInjected := false
 
+	// Sources cited by the model along the run, reported on completion with
+	// the citations of the final message.
+	var citations []llm.Citation
+
 	// 1b. Forced planning step: expose only TodoWrite with tool_choice=required so
 	// the model MUST write a structured plan before taking any action.
 	// This is skipped when ForcePlanningStep is false or when no tools exist.
@@ -64,9 +69,7 @@ func (h *Handler) Handle(ctx context.Context, input agent.Input, emit agent.Emit
 		// WithMessages call: llm.WithMessages replaces (not appends) opts.Messages, so
 		// passing it as a separate option would silently drop it.
 		budgetMessage := h.buildBudgetMessage(iteration)
-		callMessages := make([]llm.Message, 0, len(messages)+1)
-		callMessages = append(callMessages, budgetMessage)
-		callMessages = append(callMessages, messages...)
+		callMessages := h.withBudgetMessage(messages, budgetMessage)
 
 		// 2c. Call the LLM (streaming when supported, non-streaming otherwise)
 		slog.DebugContext(ctx, "calling LLM", slog.Int("iteration", iteration), slog.Int("remaining", h.options.MaxIterations-iteration))
@@ -85,6 +88,11 @@ func (h *Handler) Handle(ctx context.Context, input agent.Input, emit agent.Emit
 			return errors.WithStack(err)
 		}
 
+		// The citations of the previous turns are the sources of this one
+		// if it is the final one
+		sources := citations
+		citations = append(slices.Clip(citations), result.citations...)
+
 		// 2d. Append the assistant message to the history only when there are no tool calls.
 		if result.content != "" && len(result.toolCalls) == 0 {
 			messages = append(messages, llm.NewMessage(llm.RoleAssistant, result.content))
@@ -111,12 +119,11 @@ func (h *Handler) Handle(ctx context.Context, input agent.Input, emit agent.Emit
 			// If a response schema is configured, make a synthesis LLM call with the schema
 			// to ensure the final output is valid structured JSON.
 			if h.options.ResponseSchema != nil {
-				synthMessages := make([]llm.Message, 0, len(messages)+2)
-				synthMessages = append(synthMessages, budgetMessage)
-				synthMessages = append(synthMessages, messages...)
+				history := messages
 				if result.content != "" {
-					synthMessages = append(synthMessages, llm.NewMessage(llm.RoleAssistant, result.content))
+					history = append(slices.Clone(messages), llm.NewMessage(llm.RoleAssistant, result.content))
 				}
+				synthMessages := h.withBudgetMessage(history, budgetMessage)
 
 				synthOpts := []llm.ChatCompletionOptionFunc{
 					llm.WithMessages(synthMessages...),
@@ -128,12 +135,16 @@ func (h *Handler) Handle(ctx context.Context, input agent.Input, emit agent.Emit
 
 				synthResult, synthErr := h.doLLMCall(ctx, synthOpts, func(e agent.Event) error { return nil })
 				if synthErr == nil && synthResult.content != "" {
+					// The replaced answer is a source of the synthesis
 					result.content = synthResult.content
+					result.citations = synthResult.citations
+					sources = citations
 				}
 			}
 
 			if err := emit(agent.NewEvent(agent.EventTypeComplete, &agent.CompleteData{
-				Message: result.content,
+				Message:   result.content,
+				Citations: toAgentCitations(finalCitations(sources, result.citations)),
 			})); err != nil {
 				return errors.WithStack(err)
 			}
@@ -220,7 +231,7 @@ func (h *Handler) Handle(ctx context.Context, input agent.Input, emit agent.Emit
 	}
 
 	// 5. Generate a summary of what was accomplished within the iteration budget.
-	return h.generateBudgetExceededSummary(ctx, messages, emit)
+	return h.generateBudgetExceededSummary(ctx, messages, citations, emit)
 }
 
 // maxFinalInstructionIterations bounds how many extra tool-enabled LLM calls the agent
@@ -302,7 +313,7 @@ func (h *Handler) runFinalInstructionStep(ctx context.Context, allTools []llm.To
 
 // generateBudgetExceededSummary makes a final LLM call to summarize what was accomplished
 // within the iteration budget when the agent exceeds its maximum iterations.
-func (h *Handler) generateBudgetExceededSummary(ctx context.Context, messages []llm.Message, emit agent.EmitFunc) error {
+func (h *Handler) generateBudgetExceededSummary(ctx context.Context, messages []llm.Message, citations []llm.Citation, emit agent.EmitFunc) error {
 	slog.DebugContext(ctx, "generating budget exceeded summary")
 
 	summaryPrompt := `You have exceeded your iteration budget and cannot continue using tools.
@@ -336,7 +347,8 @@ Be specific about progress made, any obstacles encountered, and recommendations
 	}
 
 	if err := emit(agent.NewEvent(agent.EventTypeComplete, &agent.CompleteData{
-		Message: summary,
+		Message:   summary,
+		Citations: toAgentCitations(finalCitations(citations, llm.CitationsOf(res.Message()))),
 	})); err != nil {
 		return errors.WithStack(err)
 	}
@@ -446,6 +458,22 @@ func (h *Handler) requiresApproval(toolName string) bool {
 	return h.options.ApprovalRequired[toolName]
 }
 
+// withBudgetMessage adds the iteration budget message to the messages of a
+// call. It comes first, unless the prompt cache is planned: the message
+// changing at each iteration, it then follows the history so as not to
+// invalidate the cached prefix.
+func (h *Handler) withBudgetMessage(messages []llm.Message, budgetMessage llm.Message) []llm.Message {
+	callMessages := make([]llm.Message, 0, len(messages)+1)
+
+	if h.options.CachePlanner != nil {
+		callMessages = append(callMessages, messages...)
+		return append(callMessages, budgetMessage)
+	}
+
+	callMessages = append(callMessages, budgetMessage)
+	return append(callMessages, messages...)
+}
+
 // buildInitialMessages creates the initial message list
 func (h *Handler) buildInitialMessages(input agent.Input) []llm.Message {
 	messages := make([]llm.Message, 0, 2)
@@ -579,6 +607,8 @@ type llmTurnResult struct {
 	toolCalls        []llm.ToolCall
 	reasoning        string
 	reasoningDetails []llm.ReasoningDetail
+	citations        []llm.Citation
+	usage            llm.ChatCompletionUsage
 }
 
 // streamToolCallAcc accumulates incremental tool call data from streaming chunks.
@@ -592,6 +622,24 @@ type streamToolCallAcc struct {
 // In streaming mode, text deltas are emitted as EventTypeTextDelta events.
 // Reasoning events are emitted in both modes via emitReasoningIfPresent.
 func (h *Handler) doLLMCall(ctx context.Context, completionOpts []llm.ChatCompletionOptionFunc, emit agent.EmitFunc) (*llmTurnResult, error) {
+	planner := h.options.CachePlanner
+	if planner == nil {
+		return h.doClientLLMCall(ctx, completionOpts, emit)
+	}
+
+	result, err := h.doClientLLMCall(ctx, append(slices.Clone(completionOpts), planner.Option()), emit)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	planner.Record(ctx, result.usage)
+
+	return result, nil
+}
+
+// doClientLLMCall dispatches the call to the streaming or non streaming API
+// of the client.
+func (h *Handler) doClientLLMCall(ctx context.Context, completionOpts []llm.ChatCompletionOptionFunc, emit agent.EmitFunc) (*llmTurnResult, error) {
 	if sc, ok := h.options.Client.(llm.ChatCompletionStreamingClient); ok {
 		return h.doStreamingLLMCall(ctx, sc, completionOpts, emit)
 	}
@@ -607,9 +655,11 @@ func (h *Handler) doNonStreamingLLMCall(ctx context.Context, completionOpts []ll
 
 	result := &llmTurnResult{
 		toolCalls: res.ToolCalls(),
+		usage:     res.Usage(),
 	}
 	if res.Message() != nil {
 		result.content = res.Message().Content()
+		result.citations = llm.CitationsOf(res.Message())
 	}
 	if rr, ok := res.(llm.ReasoningChatCompletionResponse); ok {
 		result.reasoning = rr.Reasoning()
@@ -634,10 +684,12 @@ func (h *Handler) doStreamingLLMCall(ctx context.Context, sc llm.ChatCompletionS
 		contentBuf    strings.Builder
 		reasoningBuf  strings.Builder
 		reasoningDets []llm.ReasoningDetail
+		citations     []llm.Citation
 		toolCallAccs  = make(map[int]*streamToolCallAcc)
 		// Once a tool call delta is received, stop emitting text deltas to avoid
 		// displaying raw JSON arguments that some providers stream via content.
 		seenToolCallDelta bool
+		usage             llm.ChatCompletionUsage
 	)
 
 	for chunk := range ch {
@@ -645,6 +697,7 @@ func (h *Handler) doStreamingLLMCall(ctx context.Context, sc llm.ChatCompletionS
 			return nil, errors.WithStack(chunk.Error())
 		}
 		if chunk.IsComplete() {
+			usage = chunk.Usage()
 			break
 		}
 		delta := chunk.Delta()
@@ -688,6 +741,11 @@ func (h *Handler) doStreamingLLMCall(ctx context.Context, sc llm.ChatCompletionS
 			}
 			reasoningDets = append(reasoningDets, rsd.ReasoningDetails()...)
 		}
+
+		// Cited sources
+		if ad, ok := delta.(llm.AnnotatedStreamDelta); ok {
+			citations = append(citations, ad.Citations()...)
+		}
 	}
 
 	// Reconstruct tool calls in index order.
@@ -708,6 +766,8 @@ func (h *Handler) doStreamingLLMCall(ctx context.Context, sc llm.ChatCompletionS
 		toolCalls:        toolCalls,
 		reasoning:        reasoningBuf.String(),
 		reasoningDetails: reasoningDets,
+		citations:        citations,
+		usage:            usage,
 	}
 
 	if err := h.emitReasoningIfPresent(result, emit); err != nil {
@@ -740,6 +800,53 @@ func (h *Handler) emitReasoningIfPresent(result *llmTurnResult, emit agent.EmitF
 	}))
 }
 
+// finalCitations returns the sources cited along the run, once per URL,
+// followed by the citations of the final message. Only the latter keep their
+// offsets, the ones of the sources pointing into other responses.
+func finalCitations(sources []llm.Citation, final []llm.Citation) []llm.Citation {
+	seen := make(map[string]bool, len(sources)+len(final))
+	for _, c := range final {
+		seen[c.URL] = true
+	}
+
+	merged := make([]llm.Citation, 0, len(sources)+len(final))
+	for _, c := range sources {
+		if seen[c.URL] {
+			continue
+		}
+		seen[c.URL] = true
+
+		c.StartIndex, c.EndIndex = 0, 0
+		merged = append(merged, c)
+	}
+
+	for _, c := range final {
+		if !slices.Contains(merged, c) {
+			merged = append(merged, c)
+		}
+	}
+
+	return merged
+}
+
+// toAgentCitations converts the citations to their agent events mirror.
+func toAgentCitations(citations []llm.Citation) []agent.Citation {
+	if len(citations) == 0 {
+		return nil
+	}
+	agentCitations := make([]agent.Citation, 0, len(citations))
+	for _, c := range citations {
+		agentCitations = append(agentCitations, agent.Citation{
+			URL:        c.URL,
+			Title:      c.Title,
+			Content:    c.Content,
+			StartIndex: c.StartIndex,
+			EndIndex:   c.EndIndex,
+		})
+	}
+	return agentCitations
+}
+
 // makeToolCallsMessage builds the assistant tool calls message, preserving reasoning
 // blocks for models (e.g. Claude) that require them across turns.
 func (h *Handler) makeToolCallsMessage(result *llmTurnResult) llm.Message {
@@ -3,11 +3,13 @@ package loop
 import (
 	"context"
 	"errors"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/bornholm/genai/agent"
 	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/cacheplan"
 )
 
 // MockChatCompletionClient implements llm.ChatCompletionClient for testing
@@ -184,6 +186,123 @@ func TestHandler_SingleToolCall(t *testing.T) {
 	}
 }
 
+func TestHandler_CompleteCarriesCitations(t *testing.T) {
+	// Test: the sources cited along the run are reported on completion
+	searching, _ := llm.WithCitations(llm.NewMessage(llm.RoleAssistant, ""), []llm.Citation{{URL: "https://go.dev/doc", Title: "Go docs"}})
+	answer, _ := llm.WithCitations(llm.NewMessage(llm.RoleAssistant, "Go 1.25 is out."), []llm.Citation{{URL: "https://go.dev/blog", StartIndex: 0, EndIndex: 15}})
+
+	client := &MockChatCompletionClient{
+		responses: []MockResponse{
+			{
+				Message:   searching,
+				ToolCalls: []llm.ToolCall{&MockToolCall{id: "1", name: "test_tool", parameters: map[string]any{}}},
+			},
+			{
+				Message: answer,
+			},
+		},
+	}
+
+	tool := &MockTool{
+		name:        "test_tool",
+		description: "A test tool",
+		execute: func(ctx context.Context, params map[string]any) (llm.ToolResult, error) {
+			return llm.NewToolResult("tool result"), nil
+		},
+	}
+
+	handler, err := NewHandler(
+		WithClient(client),
+		WithSystemPrompt("You are a research assistant."),
+		WithTools(tool),
+	)
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+
+	var complete *agent.CompleteData
+	err = handler.Handle(context.Background(), agent.NewInput("What's new in Go?"), func(evt agent.Event) error {
+		if data, ok := evt.Data().(*agent.CompleteData); ok {
+			complete = data
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if complete == nil {
+		t.Fatal("expected a Complete event")
+	}
+
+	if len(complete.Citations) != 2 || complete.Citations[0].Title != "Go docs" || complete.Citations[1].URL != "https://go.dev/blog" || complete.Citations[1].EndIndex != 15 {
+		t.Errorf("unexpected citations %+v", complete.Citations)
+	}
+}
+
+func TestHandler_CompleteDeduplicatesCitations(t *testing.T) {
+	// Test: the sources repeated along the run are reported once, without the
+	// offsets pointing into the intermediate responses
+	searching, _ := llm.WithCitations(llm.NewMessage(llm.RoleAssistant, "Looking at the docs"), []llm.Citation{
+		{URL: "https://go.dev/doc", Title: "Go docs", StartIndex: 11, EndIndex: 19},
+		{URL: "https://go.dev/blog"},
+	})
+	answer, _ := llm.WithCitations(llm.NewMessage(llm.RoleAssistant, "Go 1.25 is out."), []llm.Citation{
+		{URL: "https://go.dev/blog", StartIndex: 0, EndIndex: 15},
+	})
+
+	toolCall := []llm.ToolCall{&MockToolCall{id: "1", name: "test_tool", parameters: map[string]any{}}}
+
+	client := &MockChatCompletionClient{
+		responses: []MockResponse{
+			{Message: searching, ToolCalls: toolCall},
+			{Message: searching, ToolCalls: toolCall},
+			{Message: answer},
+		},
+	}
+
+	tool := &MockTool{
+		name:        "test_tool",
+		description: "A test tool",
+		execute: func(ctx context.Context, params map[string]any) (llm.ToolResult, error) {
+			return llm.NewToolResult("tool result"), nil
+		},
+	}
+
+	handler, err := NewHandler(
+		WithClient(client),
+		WithSystemPrompt("You are a research assistant."),
+		WithTools(tool),
+	)
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+
+	var complete *agent.CompleteData
+	err = handler.Handle(context.Background(), agent.NewInput("What's new in Go?"), func(evt agent.Event) error {
+		if data, ok := evt.Data().(*agent.CompleteData); ok {
+			complete = data
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if complete == nil {
+		t.Fatal("expected a Complete event")
+	}
+
+	want := []agent.Citation{
+		{URL: "https://go.dev/doc", Title: "Go docs"},
+		{URL: "https://go.dev/blog", StartIndex: 0, EndIndex: 15},
+	}
+
+	if !slices.Equal(complete.Citations, want) {
+		t.Errorf("citations = %+v, want %+v", complete.Citations, want)
+	}
+}
+
 func TestHandler_ToolErrorRecovery(t *testing.T) {
 	// Test: LLM calls a tool → tool returns error → error becomes tool result → LLM adjusts and completes
 	client := &MockChatCompletionClient{
@@ -1017,3 +1136,52 @@ func TestHandler_AllDoneTodoAllowsCompletion(t *testing.T) {
 		t.Errorf("expected 2 LLM calls, got %d", client.callCount)
 	}
 }
+
+func TestHandler_CachePlanning(t *testing.T) {
+	// Test: WithCachePlanning set → the system prompt and the user message carry a
+	// cache breakpoint, the iteration budget message following them.
+	client := &MockChatCompletionClient{
+		responses: []MockResponse{
+			{
+				Message: llm.NewMessage(llm.RoleAssistant, "Hello, I can help you with that."),
+			},
+		},
+	}
+
+	handler, err := NewHandler(
+		WithClient(client),
+		WithSystemPrompt("You are a helpful assistant."),
+		WithCachePlanning(cacheplan.NewPlanner(cacheplan.WithLimits(cacheplan.Limits{MaxBreakpoints: 4}))),
+	)
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+
+	err = handler.Handle(context.Background(), agent.NewInput("Hi"), func(evt agent.Event) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if len(client.capturedMessages) != 1 {
+		t.Fatalf("expected 1 LLM call, got %d", len(client.capturedMessages))
+	}
+
+	messages := client.capturedMessages[0]
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(messages))
+	}
+
+	// The budget message changes at each iteration and follows the history
+	if !strings.Contains(messages[2].Content(), "Iteration 1 of") {
+		t.Errorf("expected the budget message last, got '%s'", messages[2].Content())
+	}
+
+	for i, m := range messages[:2] {
+		cm, ok := m.(llm.CacheControlMessage)
+		if !ok || cm.CacheControl() == nil {
+			t.Errorf("expected message %d (%s) to carry a cache breakpoint", i, m.Role())
+		}
+	}
+}
@@ -2,6 +2,7 @@ package loop
 
 import (
 	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/cacheplan"
 )
 
 // WithReasoningOptions enables reasoning tokens for each LLM call in the loop.
@@ -65,6 +66,12 @@ type Options struct {
 	// chance to perform a final action (e.g. ensure it exported its report) before the
 	// loop reports completion. The injection is one-shot to avoid an infinite loop.
 	FinalInstruction string
+	// CachePlanner places prompt cache breakpoints on the calls of the loop,
+	// so that the growing conversation is not billed in full at each
+	// iteration. The iteration budget message, which changes at each
+	// iteration, then follows the history instead of preceding it. Nil
+	// disables the planning.
+	CachePlanner *cacheplan.Planner
 }
 
 // OptionFunc is a function that configures the loop handler
@@ -190,6 +197,15 @@ func WithFinalInstruction(instruction string) OptionFunc {
 	}
 }
 
+// WithCachePlanning enables the placement of prompt cache breakpoints by the
+// planner, whose Stats report the cache hit rate of the loop.
+// See Options.CachePlanner for details.
+func WithCachePlanning(planner *cacheplan.Planner) OptionFunc {
+	return func(o *Options) {
+		o.CachePlanner = planner
+	}
+}
+
 func NewOptions(funcs ...OptionFunc) *Options {
 	opts := &Options{
 		MaxIterations:       DefaultMaxIterations,
@@ -16,8 +16,19 @@ func RenderSystemPrompt(tmpl string, data map[string]any) (string, error) {
 	return prompt.Template(tmpl, data)
 }
 
-// RenderSystemPromptFromFS renders a system prompt from the embedded filesystem
+// RenderSystemPromptFromFS renders a system prompt from the embedded filesystem.
+// A "prompt:name@version" reference is resolved in prompt.DefaultRegistry
+// instead.
 func RenderSystemPromptFromFS(filename string, data map[string]any) (string, error) {
+	if prompt.IsReference(filename) {
+		p, err := prompt.DefaultRegistry.Get(filename)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		return p.RenderString(data)
+	}
+
 	return prompt.FromFS(&prompts, "prompts/"+filename, data)
 }
 
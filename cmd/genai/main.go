@@ -4,6 +4,7 @@ import (
 	"github.com/bornholm/genai/internal/command"
 	"github.com/bornholm/genai/internal/command/agent"
 	"github.com/bornholm/genai/internal/command/llm"
+	"github.com/bornholm/genai/internal/command/models"
 	proxyCommand "github.com/bornholm/genai/internal/command/proxy"
 
 	// Import all provider implementations
@@ -20,5 +21,6 @@ func main() {
 		llm.Root(),
 		agent.Root(),
 		proxyCommand.Root(),
+		models.Root(),
 	)
 }
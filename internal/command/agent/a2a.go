@@ -148,7 +148,7 @@ func A2A() *cli.Command {
 				}
 			}
 
-			llmClient, err := common.NewResilientClient(ctx, cfg.envPrefix, cfg.envFile, tokenLimitOpts)
+			llmClient, err := cfg.newClient(ctx, tokenLimitOpts)
 			if err != nil {
 				return errors.Wrap(err, "failed to create LLM client")
 			}
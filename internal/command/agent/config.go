@@ -1,12 +1,15 @@
 package agent
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"strings"
 
+	"github.com/bornholm/genai/internal/command/common"
 	"github.com/bornholm/genai/internal/command/config"
 	"github.com/bornholm/genai/llm"
+	providerconfig "github.com/bornholm/genai/llm/provider/config"
 	"github.com/joho/godotenv"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
@@ -16,6 +19,8 @@ import (
 type baseConfig struct {
 	envPrefix           string
 	envFile             string
+	client              string
+	clients             *providerconfig.Config
 	chatCompletionLimit int
 	embeddingsLimit     int
 	mcpURLs             []string
@@ -135,6 +140,15 @@ func loadBaseConfig(r *cfgResolver) baseConfig {
 		}
 		return ""
 	})
+	cfg.client = r.string("client", func(c *config.Config) string {
+		if c.LLM != nil {
+			return c.LLM.Client
+		}
+		return ""
+	})
+	if r.yamlCfg != nil {
+		cfg.clients = r.yamlCfg.Clients
+	}
 	cfg.chatCompletionLimit = r.int("token-limit-chat-completion", func(c *config.Config) int {
 		if c.LLM != nil {
 			return c.LLM.TokenLimitChatCompletion
@@ -196,6 +210,19 @@ func loadBaseConfig(r *cfgResolver) baseConfig {
 	return cfg
 }
 
+// newClient crée le client LLM : le client nommé du fichier de configuration
+// s'il en déclare, sinon le client résolu depuis les variables d'environnement.
+func (c *baseConfig) newClient(ctx context.Context, tokenLimitOpts *common.TokenLimitOptions) (llm.Client, error) {
+	if c.clients == nil {
+		if c.client != "" {
+			return nil, errors.Errorf("client '%s' requested but no client is defined in the configuration file", c.client)
+		}
+		return common.NewResilientClient(ctx, c.envPrefix, c.envFile, tokenLimitOpts)
+	}
+
+	return common.NewConfiguredClient(ctx, c.clients, c.client, tokenLimitOpts)
+}
+
 // getReasoningOptions construit les options de raisonnement à partir des valeurs résolues.
 func getReasoningOptions(effort string, maxTokens int) *llm.ReasoningOptions {
 	opts := &llm.ReasoningOptions{}
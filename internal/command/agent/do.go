@@ -2,14 +2,9 @@ package agent
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"log/slog"
-	"mime"
-	"os"
-	"path/filepath"
 	"slices"
-	"strings"
 	"time"
 
 	"github.com/bornholm/genai/a2a"
@@ -182,7 +177,7 @@ func Do() *cli.Command {
 				}
 			}
 
-			client, err := common.NewResilientClient(ctx, cfg.envPrefix, cfg.envFile, tokenLimitOpts)
+			client, err := cfg.newClient(ctx, tokenLimitOpts)
 			if err != nil {
 				return errors.Wrap(err, "failed to create llm client")
 			}
@@ -326,7 +321,7 @@ func Do() *cli.Command {
 						if data.Message != "" {
 							result = data.Message
 						}
-						slog.InfoContext(ctx, "agent completed", slog.String("message", result))
+						slog.InfoContext(ctx, "agent completed", slog.String("message", result), slog.Int("citations", len(data.Citations)))
 					case agent.EventTypeToolCallStart:
 						data := evt.Data().(*agent.ToolCallStartData)
 						slog.InfoContext(ctx, "tool call started", slog.String("name", data.Name), slog.Any("params", data.Parameters))
@@ -363,6 +358,8 @@ func Do() *cli.Command {
 							// terminal is not left without any final output.
 							if !streamed {
 								fmt.Println(RenderEvent(evt))
+							} else if sources := renderCitations(data.Citations); sources != "" {
+								fmt.Print(sources)
 							}
 						}
 					default:
@@ -401,53 +398,12 @@ func processAttachments(paths []string) ([]llm.Attachment, error) {
 		if path == "" {
 			continue
 		}
-		attachment, err := fileToAttachment(path)
+		loaded, err := llm.AttachmentFromFile(path)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to process attachment '%s'", path)
 		}
-		attachments = append(attachments, attachment)
+		attachments = append(attachments, loaded...)
 	}
 
 	return attachments, nil
 }
-
-func fileToAttachment(path string) (llm.Attachment, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-
-	mimeType := detectMimeType(path)
-	attachmentType := detectAttachmentType(mimeType)
-
-	base64Data := base64.StdEncoding.EncodeToString(data)
-
-	attachment, err := llm.NewBase64Attachment(attachmentType, mimeType, base64Data)
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-
-	return attachment, nil
-}
-
-func detectMimeType(path string) string {
-	ext := filepath.Ext(path)
-	mimeType := mime.TypeByExtension(ext)
-	if mimeType == "" {
-		return "application/octet-stream"
-	}
-	return strings.SplitN(mimeType, ";", 2)[0]
-}
-
-func detectAttachmentType(mimeType string) llm.AttachmentType {
-	if strings.HasPrefix(mimeType, "image/") {
-		return llm.AttachmentTypeImage
-	}
-	if strings.HasPrefix(mimeType, "audio/") {
-		return llm.AttachmentTypeAudio
-	}
-	if strings.HasPrefix(mimeType, "video/") {
-		return llm.AttachmentTypeVideo
-	}
-	return llm.AttachmentTypeDocument
-}
@@ -117,7 +117,33 @@ func renderComplete(data *agent.CompleteData) string {
 	header := titleStyle.Render("✓ Task Complete")
 	divider := subtleStyle.Render(strings.Repeat("─", 60))
 
-	return fmt.Sprintf("%s %s\n%s\n\n%s\n", timestamp, header, divider, data.Message)
+	return fmt.Sprintf("%s %s\n%s\n\n%s\n%s", timestamp, header, divider, data.Message, renderCitations(data.Citations))
+}
+
+// renderCitations lists the sources cited by the agent, once per URL
+func renderCitations(citations []agent.Citation) string {
+	if len(citations) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n" + subtleStyle.Render("Sources:") + "\n")
+
+	seen := make(map[string]struct{}, len(citations))
+	for _, c := range citations {
+		if _, exists := seen[c.URL]; exists {
+			continue
+		}
+		seen[c.URL] = struct{}{}
+
+		title := c.Title
+		if title == "" {
+			title = c.URL
+		}
+		fmt.Fprintf(&sb, "  [%d] %s %s\n", len(seen), title, subtleStyle.Render(c.URL))
+	}
+
+	return sb.String()
 }
 
 func renderToolCallStart(data *agent.ToolCallStartData) string {
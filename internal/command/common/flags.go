@@ -107,6 +107,7 @@ func init() {
 	DoFlags = append(DoFlags, OutputFlags...)
 	DoFlags = append(DoFlags, SchemaFlags...)
 	DoFlags = append(DoFlags, ConfigFlags...)
+	DoFlags = append(DoFlags, PromptRegistryFlags...)
 }
 
 // GenerateFlags contains all flags for the "llm generate" command.
@@ -124,7 +125,7 @@ func init() {
 		// System prompt
 		&cli.StringFlag{
 			Name:    "system",
-			Usage:   "System prompt (text format, or @file to load from file)",
+			Usage:   "System prompt (text format, @file to load from file, or prompt:name@version registry reference)",
 			EnvVars: []string{"GENAI_SYSTEM_PROMPT"},
 		},
 		&cli.StringFlag{
@@ -135,7 +136,7 @@ func init() {
 		// User prompt
 		&cli.StringFlag{
 			Name:     "prompt",
-			Usage:    "User prompt (text format, or @file to load from file)",
+			Usage:    "User prompt (text format, @file to load from file, or prompt:name@version registry reference)",
 			EnvVars:  []string{"GENAI_USER_PROMPT"},
 			Required: true,
 		},
@@ -167,7 +168,7 @@ func init() {
 		// System prompt
 		&cli.StringFlag{
 			Name:    "system",
-			Usage:   "System prompt (text format, or @file to load from file)",
+			Usage:   "System prompt (text format, @file to load from file, or prompt:name@version registry reference)",
 			EnvVars: []string{"GENAI_SYSTEM_PROMPT"},
 		},
 		&cli.StringFlag{
@@ -272,6 +273,11 @@ var LLMClientFlags = []cli.Flag{
 		EnvVars: []string{"GENAI_TOKEN_LIMIT_EMBEDDINGS"},
 		Value:   DefaultTokenLimitEmbeddings,
 	},
+	&cli.StringFlag{
+		Name:    "client",
+		Usage:   "Name of the client to use, among the clients defined in the configuration file (default: the configured default client)",
+		EnvVars: []string{"GENAI_CLIENT"},
+	},
 }
 
 // MCPFlags returns the CLI flags for MCP server configuration.
@@ -328,6 +334,16 @@ var ConfigFlags = []cli.Flag{
 	},
 }
 
+// PromptRegistryFlags returns the CLI flags for the prompt registry.
+// Include these in any command accepting "prompt:name@version" references.
+var PromptRegistryFlags = []cli.Flag{
+	&cli.StringSliceFlag{
+		Name:    "prompt-dir",
+		Usage:   "Directory of .prompt files to resolve \"prompt:name@version\" references from (can be specified multiple times)",
+		EnvVars: []string{"GENAI_PROMPT_DIR"},
+	},
+}
+
 // SchemaFlags returns the CLI flags for JSON schema configuration.
 // Include these in any command that supports structured responses.
 var SchemaFlags = []cli.Flag{
@@ -6,6 +6,7 @@ import (
 
 	"github.com/bornholm/genai/llm"
 	"github.com/bornholm/genai/llm/provider"
+	providerconfig "github.com/bornholm/genai/llm/provider/config"
 	"github.com/bornholm/genai/llm/provider/env"
 	"github.com/bornholm/genai/llm/ratelimit"
 	"github.com/bornholm/genai/llm/retry"
@@ -61,3 +62,22 @@ func NewResilientClient(ctx context.Context, envPrefix string, envFile string, t
 
 	return client, nil
 }
+
+// NewConfiguredClient creates the named client defined in the configuration
+// file, with its configured wrappers and optional token limiting. An empty
+// name selects the default client of the configuration.
+func NewConfiguredClient(ctx context.Context, clients *providerconfig.Config, name string, tokenLimitOpts *TokenLimitOptions) (llm.Client, error) {
+	client, err := clients.Create(ctx, name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if tokenLimitOpts != nil {
+		client = tokenlimit.NewClient(client,
+			tokenlimit.WithChatCompletionLimit(tokenLimitOpts.ChatCompletionTokens, tokenLimitOpts.ChatCompletionInterval),
+			tokenlimit.WithEmbeddingsLimit(tokenLimitOpts.EmbeddingsTokens, tokenLimitOpts.EmbeddingsInterval),
+		)
+	}
+
+	return client, nil
+}
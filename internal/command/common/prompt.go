@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"strings"
+	"sync"
 
 	llmPrompt "github.com/bornholm/genai/llm/prompt"
 	"github.com/pkg/errors"
@@ -17,6 +18,15 @@ func GetPromptFromContext(ctx *cli.Context, promptParam, dataParam string) (stri
 }
 
 func GetPrompt(ctx *cli.Context, prompt string, rawData string) (string, error) {
+	if llmPrompt.IsReference(prompt) {
+		data, err := ParsePromptData(rawData)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		return renderRegisteredPrompt(ctx, prompt, data)
+	}
+
 	// Check if promptText starts with "@" (file path)
 	if strings.HasPrefix(prompt, "@") {
 		filePath := prompt[1:] // Remove the "@" prefix
@@ -27,30 +37,90 @@ func GetPrompt(ctx *cli.Context, prompt string, rawData string) (string, error)
 		prompt = string(content)
 	}
 
-	if rawData == "" {
+	templateData, err := ParsePromptData(rawData)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if templateData == nil {
 		return prompt, nil
 	}
 
+	processed, err := llmPrompt.Template(prompt, templateData)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to process prompt template")
+	}
+
+	return processed, nil
+}
+
+// ParsePromptData parses prompt template data given as JSON, or as @file to
+// load it from a file. It returns nil if no data is given.
+func ParsePromptData(rawData string) (any, error) {
+	if rawData == "" {
+		return nil, nil
+	}
+
 	// Check if dataInput starts with "@" (file path)
 	if strings.HasPrefix(rawData, "@") {
 		filePath := rawData[1:] // Remove the "@" prefix
 		content, err := os.ReadFile(filePath)
 		if err != nil {
-			return "", errors.Wrapf(err, "failed to read data file: %s", filePath)
+			return nil, errors.Wrapf(err, "failed to read data file: %s", filePath)
 		}
 		rawData = string(content)
 	}
 
 	if rawData == "" {
-		return prompt, nil
+		return nil, nil
 	}
 
 	var templateData any
 	if err := json.Unmarshal([]byte(rawData), &templateData); err != nil {
-		return "", errors.Wrap(err, "failed to parse data JSON")
+		return nil, errors.Wrap(err, "failed to parse data JSON")
 	}
 
-	processed, err := llmPrompt.Template(prompt, templateData)
+	return templateData, nil
+}
+
+var (
+	loadedPromptDirs     = map[string]struct{}{}
+	loadedPromptDirsLock sync.Mutex
+)
+
+// GetRegisteredPrompt resolves a "prompt:name@version" reference, after loading
+// the directories given with --prompt-dir in the default prompt registry
+func GetRegisteredPrompt(ctx *cli.Context, ref string) (*llmPrompt.Prompt, error) {
+	loadedPromptDirsLock.Lock()
+	defer loadedPromptDirsLock.Unlock()
+
+	for _, dir := range ctx.StringSlice("prompt-dir") {
+		if _, loaded := loadedPromptDirs[dir]; loaded {
+			continue
+		}
+
+		if err := llmPrompt.DefaultRegistry.LoadDir(dir); err != nil {
+			return nil, errors.Wrapf(err, "failed to load prompt directory: %s", dir)
+		}
+
+		loadedPromptDirs[dir] = struct{}{}
+	}
+
+	p, err := llmPrompt.DefaultRegistry.Get(ref)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return p, nil
+}
+
+func renderRegisteredPrompt(ctx *cli.Context, ref string, data any) (string, error) {
+	p, err := GetRegisteredPrompt(ctx, ref)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	processed, err := p.RenderString(data)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to process prompt template")
 	}
@@ -63,6 +133,10 @@ func GetPromptWithData(ctx *cli.Context, prompt string, data any) (string, error
 		return "", nil
 	}
 
+	if llmPrompt.IsReference(prompt) {
+		return renderRegisteredPrompt(ctx, prompt, data)
+	}
+
 	// Check if promptText starts with "@" (file path)
 	if strings.HasPrefix(prompt, "@") {
 		filePath := prompt[1:]
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 
+	providerconfig "github.com/bornholm/genai/llm/provider/config"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 )
@@ -12,6 +13,10 @@ type Config struct {
 	Agent *AgentConfig `yaml:"agent"`
 	Do    *DoConfig    `yaml:"do"`
 	A2A   *A2AConfig   `yaml:"a2a"`
+
+	// Clients contient les clients nommés déclarés par les clés "default" et
+	// "clients" du fichier.
+	Clients *providerconfig.Config `yaml:"-"`
 }
 
 // LLMConfig regroupe la configuration du client LLM.
@@ -20,6 +25,8 @@ type LLMConfig struct {
 	EnvPrefix                string `yaml:"envPrefix"`
 	TokenLimitChatCompletion int    `yaml:"tokenLimitChatCompletion"`
 	TokenLimitEmbeddings     int    `yaml:"tokenLimitEmbeddings"`
+	// Client est le nom du client à utiliser parmi les clients nommés.
+	Client string `yaml:"client"`
 }
 
 // AgentConfig contient les paramètres partagés entre les commandes do et a2a.
@@ -82,6 +89,15 @@ func Load(path string) (*Config, error) {
 
 	interpolateConfig(&cfg)
 
+	clients, err := providerconfig.Parse(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse clients configuration")
+	}
+
+	if len(clients.Clients) > 0 {
+		cfg.Clients = clients
+	}
+
 	return &cfg, nil
 }
 
@@ -89,6 +105,7 @@ func interpolateConfig(cfg *Config) {
 	if cfg.LLM != nil {
 		cfg.LLM.EnvFile = Interpolate(cfg.LLM.EnvFile)
 		cfg.LLM.EnvPrefix = Interpolate(cfg.LLM.EnvPrefix)
+		cfg.LLM.Client = Interpolate(cfg.LLM.Client)
 	}
 
 	if cfg.Agent != nil {
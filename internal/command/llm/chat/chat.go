@@ -19,10 +19,10 @@ func Chat() *cli.Command {
 	return &cli.Command{
 		Name:  "chat",
 		Usage: "Start an interactive chat session",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:     "system",
-				Usage:    "System prompt (text format, or @file to load from file)",
+				Usage:    "System prompt (text format, @file to load from file, or prompt:name@version registry reference)",
 				EnvVars:  []string{"GENAI_SYSTEM_PROMPT"},
 				Required: false,
 			},
@@ -83,7 +83,7 @@ func Chat() *cli.Command {
 				EnvVars: []string{"GENAI_TOKEN_LIMIT_EMBEDDINGS"},
 				Value:   20000000,
 			},
-		},
+		}, common.PromptRegistryFlags...),
 		Action: func(cliCtx *cli.Context) error {
 			ctx := cliCtx.Context
 
@@ -128,8 +128,8 @@ func Chat() *cli.Command {
 
 			// Get system prompt if provided
 			var systemPrompt string
-			if cliCtx.Count("system") != 0 {
-				systemPrompt, err = common.GetPrompt(cliCtx, "system", "system-data")
+			if cliCtx.IsSet("system") {
+				systemPrompt, err = common.GetPromptFromContext(cliCtx, "system", "system-data")
 				if err != nil {
 					return errors.Wrap(err, "failed to process system prompt")
 				}
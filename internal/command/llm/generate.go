@@ -1,17 +1,12 @@
 package llm
 
 import (
-	"encoding/base64"
-	"fmt"
 	"log/slog"
-	"mime"
-	"os"
-	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/bornholm/genai/internal/command/common"
 	"github.com/bornholm/genai/llm"
+	llmPrompt "github.com/bornholm/genai/llm/prompt"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
@@ -21,10 +16,10 @@ func Generate() *cli.Command {
 		Name:    "generate",
 		Aliases: []string{"gen"},
 		Usage:   "Generate chat completion",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:     "system",
-				Usage:    "System prompt (text format, or @file to load from file)",
+				Usage:    "System prompt (text format, @file to load from file, or prompt:name@version registry reference)",
 				EnvVars:  []string{"GENAI_SYSTEM_PROMPT"},
 				Required: false,
 			},
@@ -35,7 +30,7 @@ func Generate() *cli.Command {
 			},
 			&cli.StringFlag{
 				Name:     "prompt",
-				Usage:    "User prompt (text format, or @file to load from file)",
+				Usage:    "User prompt (text format, @file to load from file, or prompt:name@version registry reference)",
 				EnvVars:  []string{"GENAI_USER_PROMPT"},
 				Required: true,
 			},
@@ -91,7 +86,7 @@ func Generate() *cli.Command {
 				EnvVars: []string{"GENAI_TOKEN_LIMIT_EMBEDDINGS"},
 				Value:   20000000,
 			},
-		},
+		}, common.PromptRegistryFlags...),
 		Action: func(cliCtx *cli.Context) error {
 			ctx := cliCtx.Context
 
@@ -117,15 +112,22 @@ func Generate() *cli.Command {
 			}
 
 			// Build messages
-			messages, err := buildMessages(cliCtx)
+			messages, userPrompt, err := buildMessages(cliCtx)
 			if err != nil {
 				return errors.Wrap(err, "failed to build messages")
 			}
 
+			// Registered prompts may provide the temperature and response
+			// schema, explicit flags take precedence
+			temperature := cliCtx.Float64("temperature")
+			if userPrompt != nil && userPrompt.Metadata.Temperature != nil && !cliCtx.IsSet("temperature") {
+				temperature = *userPrompt.Metadata.Temperature
+			}
+
 			// Build chat completion options
 			opts := []llm.ChatCompletionOptionFunc{
 				llm.WithMessages(messages...),
-				llm.WithTemperature(cliCtx.Float64("temperature")),
+				llm.WithTemperature(temperature),
 			}
 
 			responseSchema, err := common.GetResponseSchema(cliCtx, "schema")
@@ -135,6 +137,8 @@ func Generate() *cli.Command {
 
 			if responseSchema != nil {
 				opts = append(opts, llm.WithJSONResponse(responseSchema))
+			} else if userPrompt != nil && userPrompt.ResponseSchema() != nil {
+				opts = append(opts, llm.WithJSONResponse(userPrompt.ResponseSchema()))
 			}
 
 			// Generate completion
@@ -160,92 +164,100 @@ func Generate() *cli.Command {
 	}
 }
 
-// buildMessages constructs the message list from CLI flags
-func buildMessages(cliCtx *cli.Context) ([]llm.Message, error) {
+// buildMessages constructs the message list from CLI flags. When the user
+// prompt is a registry reference, the registered prompt is also returned.
+func buildMessages(cliCtx *cli.Context) ([]llm.Message, *llmPrompt.Prompt, error) {
 	var messages []llm.Message
 
 	// Add system message if provided
-	if cliCtx.Count("system") != 0 {
-		processedSystem, err := common.GetPrompt(cliCtx, "system", "system-data")
+	if cliCtx.IsSet("system") {
+		processedSystem, err := common.GetPromptFromContext(cliCtx, "system", "system-data")
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to process system prompt")
+			return nil, nil, errors.Wrap(err, "failed to process system prompt")
 		}
 		messages = append(messages, llm.NewMessage(llm.RoleSystem, processedSystem))
 	}
 
-	// Process user prompt
-	processedUser, err := common.GetPrompt(cliCtx, "prompt", "prompt-data")
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to process user prompt")
-	}
+	var attachments []llm.Attachment
 
 	// Handle file attachments
 	files := cliCtx.StringSlice("file")
 	if len(files) > 0 {
-		attachments, err := processFileAttachments(files)
+		var err error
+		attachments, err = processFileAttachments(files)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to process file attachments")
+			return nil, nil, errors.Wrap(err, "failed to process file attachments")
 		}
-		messages = append(messages, llm.NewMultimodalMessage(llm.RoleUser, processedUser, attachments...))
-	} else {
-		messages = append(messages, llm.NewMessage(llm.RoleUser, processedUser))
 	}
 
-	return messages, nil
-}
+	// Registered prompts may render several messages
+	if ref := cliCtx.String("prompt"); llmPrompt.IsReference(ref) {
+		userPrompt, err := common.GetRegisteredPrompt(cliCtx, ref)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to process user prompt")
+		}
 
-// processFileAttachments processes file paths into attachments
-func processFileAttachments(filePaths []string) ([]llm.Attachment, error) {
-	var attachments []llm.Attachment
+		data, err := common.ParsePromptData(cliCtx.String("prompt-data"))
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to process user prompt")
+		}
 
-	for _, filePath := range filePaths {
-		attachment, err := createAttachmentFromFile(filePath)
+		rendered, err := userPrompt.Render(data)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to process file: %s", filePath)
+			return nil, nil, errors.Wrap(err, "failed to process user prompt")
 		}
-		attachments = append(attachments, attachment)
-	}
 
-	return attachments, nil
-}
+		messages = append(messages, rendered...)
 
-// createAttachmentFromFile creates an attachment from a file path
-func createAttachmentFromFile(filePath string) (llm.Attachment, error) {
-	// Read file content
-	content, err := os.ReadFile(filePath)
+		if len(attachments) > 0 {
+			messages = attachToLastUserMessage(messages, attachments)
+		}
+
+		return messages, userPrompt, nil
+	}
+
+	// Process user prompt
+	processedUser, err := common.GetPromptFromContext(cliCtx, "prompt", "prompt-data")
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to read file")
+		return nil, nil, errors.Wrap(err, "failed to process user prompt")
 	}
 
-	// Determine MIME type
-	mimeType := mime.TypeByExtension(filepath.Ext(filePath))
-	if mimeType == "" {
-		// Fallback to application/octet-stream for unknown types
-		mimeType = "application/octet-stream"
+	if len(attachments) > 0 {
+		messages = append(messages, llm.NewMultimodalMessage(llm.RoleUser, processedUser, attachments...))
+	} else {
+		messages = append(messages, llm.NewMessage(llm.RoleUser, processedUser))
 	}
 
-	// Encode content as base64
-	encodedContent := base64.StdEncoding.EncodeToString(content)
-	dataURL := fmt.Sprintf("data:%s;base64,%s", mimeType, encodedContent)
-
-	// Determine attachment type based on MIME type
-	var attachmentType llm.AttachmentType
-	switch {
-	case strings.HasPrefix(mimeType, "image/"):
-		attachmentType = llm.AttachmentTypeImage
-	case strings.HasPrefix(mimeType, "audio/"):
-		attachmentType = llm.AttachmentTypeAudio
-	case strings.HasPrefix(mimeType, "video/"):
-		attachmentType = llm.AttachmentTypeVideo
-	default:
-		attachmentType = llm.AttachmentTypeDocument
+	return messages, nil, nil
+}
+
+// attachToLastUserMessage adds the attachments to the last user message,
+// or to a new one if there is none
+func attachToLastUserMessage(messages []llm.Message, attachments []llm.Attachment) []llm.Message {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role() != llm.RoleUser {
+			continue
+		}
+
+		messages[i] = llm.NewMultimodalMessage(llm.RoleUser, messages[i].Content(), attachments...)
+		return messages
 	}
 
-	// Create attachment
-	attachment, err := llm.NewBase64Attachment(attachmentType, mimeType, dataURL)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create attachment")
+	return append(messages, llm.NewMultimodalMessage(llm.RoleUser, "", attachments...))
+}
+
+// processFileAttachments processes file paths into attachments, images
+// being downscaled and multi-page ones split
+func processFileAttachments(filePaths []string) ([]llm.Attachment, error) {
+	var attachments []llm.Attachment
+
+	for _, filePath := range filePaths {
+		loaded, err := llm.AttachmentFromFile(filePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to process file: %s", filePath)
+		}
+		attachments = append(attachments, loaded...)
 	}
 
-	return attachment, nil
+	return attachments, nil
 }
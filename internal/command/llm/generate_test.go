@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/bornholm/genai/llm/provider/openai"
+	"github.com/urfave/cli/v2"
+)
+
+const greeterPrompt = `---
+name: greeter
+version: 1.0.0
+---
+You greet {{ .Name }}.
+`
+
+func TestGenerate_SystemPrompt(t *testing.T) {
+	promptDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(promptDir, "greeter.prompt"), []byte(greeterPrompt), 0644); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		system string
+	}{
+		{
+			name:   "text",
+			args:   []string{"--system", "You are terse."},
+			system: "You are terse.",
+		},
+		{
+			name:   "template",
+			args:   []string{"--system", "You greet {{ .Name }}.", "--system-data", `{"Name":"Ada"}`},
+			system: "You greet Ada.",
+		},
+		{
+			name:   "registry reference",
+			args:   []string{"--prompt-dir", promptDir, "--system", "prompt:greeter@1.0.0", "--system-data", `{"Name":"Ada"}`},
+			system: "You greet Ada.",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			messages := runGenerate(t, append(tc.args, "--prompt", "Hello")...)
+
+			if len(messages) != 2 {
+				t.Fatalf("expected a system and a user message, got %+v", messages)
+			}
+
+			if messages[0].Role != "system" || messages[0].Content != tc.system {
+				t.Errorf("system message = %+v, want %q", messages[0], tc.system)
+			}
+
+			if messages[1].Role != "user" || messages[1].Content != "Hello" {
+				t.Errorf("user message = %+v, want %q", messages[1], "Hello")
+			}
+		})
+	}
+}
+
+type capturedMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// runGenerate runs the generate command against a local OpenAI compatible
+// server and returns the messages it received
+func runGenerate(t *testing.T, args ...string) []capturedMessage {
+	t.Helper()
+
+	var request struct {
+		Messages []capturedMessage `json:"messages"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hi"}}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("GENAITEST_CHAT_COMPLETION_PROVIDER", "openai")
+	t.Setenv("GENAITEST_CHAT_COMPLETION_OPENAI_BASE_URL", server.URL)
+	t.Setenv("GENAITEST_CHAT_COMPLETION_OPENAI_API_KEY", "test")
+	t.Setenv("GENAITEST_CHAT_COMPLETION_OPENAI_MODEL", "test")
+
+	app := &cli.App{
+		Commands: []*cli.Command{Generate()},
+	}
+
+	base := []string{
+		"genai", "generate",
+		"--env-prefix", "GENAITEST_",
+		"--env-file", filepath.Join(t.TempDir(), ".env"),
+		"--output", filepath.Join(t.TempDir(), "output"),
+	}
+
+	if err := app.RunContext(context.Background(), append(base, args...)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	return request.Messages
+}
@@ -1,12 +1,17 @@
 package llm
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/bornholm/genai/internal/command/common"
 	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/longaudio"
+	"github.com/bornholm/genai/llm/transcript"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
@@ -45,6 +50,29 @@ func Transcribe() *cli.Command {
 				Usage:   "Sampling temperature (0.0 to 1.0)",
 				EnvVars: []string{"GENAI_TRANSCRIBE_TEMPERATURE"},
 			},
+			&cli.StringFlag{
+				Name:    "format",
+				Usage:   fmt.Sprintf("Output format (%s), timestamps being requested for every format but text", formatList()),
+				EnvVars: []string{"GENAI_TRANSCRIBE_FORMAT"},
+				Value:   string(transcript.FormatText),
+			},
+			&cli.BoolFlag{
+				Name:    "diarize",
+				Usage:   "Identify the speakers (not supported by all providers)",
+				EnvVars: []string{"GENAI_TRANSCRIBE_DIARIZE"},
+			},
+			&cli.DurationFlag{
+				Name:    "max-chunk-duration",
+				Usage:   "Maximum duration of the chunks long WAV, MP3 and OGG audio is split in",
+				EnvVars: []string{"GENAI_TRANSCRIBE_MAX_CHUNK_DURATION"},
+				Value:   10 * time.Minute,
+			},
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Usage:   "Number of chunks transcribed at the same time",
+				EnvVars: []string{"GENAI_TRANSCRIBE_CONCURRENCY"},
+				Value:   4,
+			},
 			&cli.StringFlag{
 				Name:      "env-file",
 				Usage:     "Environment file path",
@@ -68,6 +96,11 @@ func Transcribe() *cli.Command {
 		Action: func(cliCtx *cli.Context) error {
 			ctx := cliCtx.Context
 
+			format := transcript.Format(cliCtx.String("format"))
+			if !slices.Contains(transcript.Formats, format) {
+				return errors.Errorf("unknown format '%s', expected one of %s", format, formatList())
+			}
+
 			envPrefix := cliCtx.String("env-prefix")
 			envFile := cliCtx.String("env-file")
 
@@ -76,6 +109,11 @@ func Transcribe() *cli.Command {
 				return errors.Wrap(err, "failed to create llm client")
 			}
 
+			client = longaudio.NewClient(client,
+				longaudio.WithMaxChunkDuration(cliCtx.Duration("max-chunk-duration")),
+				longaudio.WithConcurrency(cliCtx.Int("concurrency")),
+			)
+
 			audio, err := os.ReadFile(cliCtx.String("file"))
 			if err != nil {
 				return errors.Wrap(err, "failed to read audio file")
@@ -99,13 +137,26 @@ func Transcribe() *cli.Command {
 				opts = append(opts, llm.WithTranscriptionTemperature(cliCtx.Float64("temperature")))
 			}
 
+			if format != transcript.FormatText {
+				opts = append(opts, llm.WithTranscriptionTimestamps())
+			}
+
+			if cliCtx.Bool("diarize") {
+				opts = append(opts, llm.WithTranscriptionDiarization())
+			}
+
 			before := time.Now()
 			response, err := client.Transcription(ctx, audio, opts...)
 			if err != nil {
 				return errors.Wrap(err, "failed to transcribe audio")
 			}
 
-			if err := common.WriteToOutput(cliCtx, "output", response.Text(), false); err != nil {
+			var output strings.Builder
+			if err := transcript.Write(&output, format, response); err != nil {
+				return errors.Wrapf(err, "failed to format transcription as %s", format)
+			}
+
+			if err := common.WriteToOutput(cliCtx, "output", output.String(), false); err != nil {
 				return errors.Wrap(err, "failed to write to output")
 			}
 
@@ -129,3 +180,11 @@ func Transcribe() *cli.Command {
 		},
 	}
 }
+
+func formatList() string {
+	formats := make([]string, len(transcript.Formats))
+	for i, f := range transcript.Formats {
+		formats[i] = string(f)
+	}
+	return strings.Join(formats, ", ")
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bornholm/genai/llm/provider/yzma/models"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func Root() *cli.Command {
+	return &cli.Command{
+		Name:  "models",
+		Usage: "Local models (yzma) related commands",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "dir",
+				Usage:   "Models cache directory",
+				EnvVars: []string{"GENAI_MODELS_DIR"},
+				Value:   models.DefaultDir(),
+			},
+			&cli.StringFlag{
+				Name:    "hf-token",
+				Usage:   "Hugging Face access token, for gated and private repositories",
+				EnvVars: []string{"HF_TOKEN"},
+			},
+			&cli.StringFlag{
+				Name:    "hf-endpoint",
+				Usage:   "Hugging Face Hub base URL",
+				EnvVars: []string{"HF_ENDPOINT"},
+				Value:   models.DefaultEndpoint,
+			},
+		},
+		Subcommands: []*cli.Command{
+			Pull(),
+			List(),
+			Remove(),
+		},
+	}
+}
+
+func Pull() *cli.Command {
+	return &cli.Command{
+		Name:      "pull",
+		Usage:     "Download models to the cache",
+		ArgsUsage: "<owner>/<repo>[@<revision>]/<file>|<url>...",
+		Action: func(cliCtx *cli.Context) error {
+			ctx := cliCtx.Context
+
+			if cliCtx.NArg() == 0 {
+				return errors.New("at least one model reference is required")
+			}
+
+			progress := &progressPrinter{}
+			manager := newManager(cliCtx, models.WithProgress(progress.Print))
+
+			for _, ref := range cliCtx.Args().Slice() {
+				model, err := manager.Pull(ctx, ref)
+				progress.Done()
+				if err != nil {
+					return errors.Wrapf(err, "failed to pull model '%s'", ref)
+				}
+
+				fmt.Println(model.Path)
+			}
+
+			return nil
+		},
+	}
+}
+
+func List() *cli.Command {
+	return &cli.Command{
+		Name:    "ls",
+		Aliases: []string{"list"},
+		Usage:   "List the models of the cache",
+		Action: func(cliCtx *cli.Context) error {
+			ctx := cliCtx.Context
+
+			list, err := newManager(cliCtx).List(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to list models")
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSIZE\tSHA256\tPULLED\tSOURCE")
+
+			for _, model := range list {
+				sum := model.SHA256
+				if len(sum) > 12 {
+					sum = sum[:12]
+				}
+
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", model.Name, formatSize(model.Size), orDash(sum), model.PulledAt.Local().Format(time.DateTime), orDash(model.Source))
+			}
+
+			return errors.WithStack(w.Flush())
+		},
+	}
+}
+
+func Remove() *cli.Command {
+	return &cli.Command{
+		Name:      "rm",
+		Aliases:   []string{"remove"},
+		Usage:     "Remove models from the cache",
+		ArgsUsage: "<name>...",
+		Action: func(cliCtx *cli.Context) error {
+			ctx := cliCtx.Context
+
+			if cliCtx.NArg() == 0 {
+				return errors.New("at least one model name is required")
+			}
+
+			manager := newManager(cliCtx)
+
+			for _, name := range cliCtx.Args().Slice() {
+				if err := manager.Remove(ctx, name); err != nil {
+					return errors.Wrapf(err, "failed to remove model '%s'", name)
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+func newManager(cliCtx *cli.Context, funcs ...models.OptionFunc) *models.Manager {
+	funcs = append([]models.OptionFunc{
+		models.WithDir(cliCtx.String("dir")),
+		models.WithToken(cliCtx.String("hf-token")),
+		models.WithEndpoint(cliCtx.String("hf-endpoint")),
+	}, funcs...)
+
+	return models.NewManager(funcs...)
+}
+
+// progressPrinter prints the progress of the downloads on stderr, at most
+// every 200ms
+type progressPrinter struct {
+	printed    time.Time
+	downloaded int64
+	total      int64
+}
+
+func (p *progressPrinter) Print(downloaded int64, total int64) {
+	p.downloaded, p.total = downloaded, total
+
+	if time.Since(p.printed) < 200*time.Millisecond {
+		return
+	}
+
+	p.printed = time.Now()
+	p.print()
+}
+
+func (p *progressPrinter) print() {
+	downloaded, total := p.downloaded, p.total
+
+	if total > 0 {
+		fmt.Fprintf(os.Stderr, "\r%s / %s (%.1f%%)", formatSize(downloaded), formatSize(total), float64(downloaded)*100/float64(total))
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "\r%s", formatSize(downloaded))
+}
+
+func (p *progressPrinter) Done() {
+	if !p.printed.IsZero() {
+		p.print()
+		fmt.Fprintln(os.Stderr)
+	}
+
+	*p = progressPrinter{}
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
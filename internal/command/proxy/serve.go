@@ -1,10 +1,15 @@
 package proxy
 
 import (
+	"context"
 	"strings"
 
 	"github.com/bornholm/genai/internal/command/common"
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/provider"
+	providerconfig "github.com/bornholm/genai/llm/provider/config"
 	"github.com/bornholm/genai/proxy"
+	"github.com/bornholm/genai/proxy/hooks/attachment"
 	"github.com/bornholm/genai/proxy/hooks/filter"
 	"github.com/bornholm/genai/proxy/hooks/logging"
 	"github.com/bornholm/genai/proxy/hooks/router"
@@ -48,7 +53,7 @@ func Serve() *cli.Command {
 			},
 			&cli.StringSliceFlag{
 				Name:    "proxy-route",
-				Usage:   "Route mapping: model=provider:actual_model (repeatable)",
+				Usage:   "Route mapping: model=client:actual_model, client being a client of the configuration file, or model=actual_model to use the default backend (repeatable)",
 				EnvVars: []string{"PROXY_ROUTES"},
 			},
 			&cli.StringSliceFlag{
@@ -61,6 +66,11 @@ func Serve() *cli.Command {
 				Usage:   "Comma-separated keywords to block in requests",
 				EnvVars: []string{"PROXY_FILTER_KEYWORDS"},
 			},
+			&cli.BoolFlag{
+				Name:    "proxy-preprocess-images",
+				Usage:   "Downscale and re-encode attached images to fit provider limits, splitting multi-page ones",
+				EnvVars: []string{"PROXY_PREPROCESS_IMAGES"},
+			},
 			&cli.IntFlag{
 				Name:    "proxy-quota-daily-tokens",
 				Usage:   "Maximum total tokens per user per day (0 = disabled)",
@@ -85,6 +95,18 @@ func Serve() *cli.Command {
 				EnvVars: []string{"GENAI_LLM_ENV_PREFIX"},
 				Value:   "GENAI_",
 			},
+			&cli.StringFlag{
+				Name:      "config",
+				Aliases:   []string{"c"},
+				Usage:     "Configuration file defining named clients (YAML or JSON)",
+				EnvVars:   []string{"GENAI_CONFIG"},
+				TakesFile: true,
+			},
+			&cli.StringFlag{
+				Name:    "client",
+				Usage:   "Name of the configured client used as default backend (default: the configured default client)",
+				EnvVars: []string{"GENAI_CLIENT"},
+			},
 		},
 		Action: func(cliCtx *cli.Context) error {
 			ctx := cliCtx.Context
@@ -146,28 +168,58 @@ func Serve() *cli.Command {
 				}
 			}
 
+			// Attachment preprocessor (pre-request, priority 30)
+			if cliCtx.Bool("proxy-preprocess-images") {
+				opts = append(opts, proxy.WithHook(attachment.NewPreprocessor(30)))
+			}
+
+			// Named clients
+			var clients *providerconfig.Config
+			if configPath := cliCtx.String("config"); configPath != "" {
+				loaded, err := providerconfig.Load(configPath)
+				if err != nil {
+					return errors.Wrap(err, "failed to load config file")
+				}
+				loaded.Register(namedClients)
+				clients = loaded
+			}
+
+			// Default backend, shared by the routes not targeting a named client
+			var defaultClient llm.Client
+			if clientName := cliCtx.String("client"); clientName != "" || (clients != nil && clients.Default != "") {
+				if clientName == "" {
+					clientName = clients.Default
+				}
+				client, err := namedClients.Get(ctx, clientName)
+				if err != nil {
+					return errors.Wrap(err, "could not create default client")
+				}
+				defaultClient = client
+			} else {
+				envPrefix := cliCtx.String("env-prefix")
+				envFile := cliCtx.String("env-file")
+				client, err := common.NewResilientClient(ctx, envPrefix, envFile, nil)
+				if err == nil {
+					defaultClient = client
+				}
+			}
+
 			// Static router (priority 50)
 			staticRoutes := parseStaticRoutes(cliCtx.StringSlice("proxy-route"))
 			if len(staticRoutes) > 0 {
-				// Build per-model clients from env configuration
 				routeMap := make(map[string]router.Route, len(staticRoutes))
 				for proxyModel, target := range staticRoutes {
-					envPrefix := cliCtx.String("env-prefix")
-					envFile := cliCtx.String("env-file")
-					client, err := common.NewResilientClient(ctx, envPrefix, envFile, nil)
+					route, err := resolveRoute(ctx, target, defaultClient)
 					if err != nil {
 						return errors.Wrapf(err, "could not create client for route %s→%s", proxyModel, target)
 					}
-					routeMap[proxyModel] = router.Route{Client: client, Model: target}
+					routeMap[proxyModel] = route
 				}
 				opts = append(opts, proxy.WithHook(router.NewStaticRouter(routeMap, 50)))
 			}
 
 			// Default fallback client
-			envPrefix := cliCtx.String("env-prefix")
-			envFile := cliCtx.String("env-file")
-			defaultClient, err := common.NewResilientClient(ctx, envPrefix, envFile, nil)
-			if err == nil {
+			if defaultClient != nil {
 				opts = append(opts, proxy.WithDefaultClient(defaultClient))
 			}
 
@@ -177,6 +229,28 @@ func Serve() *cli.Command {
 	}
 }
 
+// namedClients holds the clients of the configuration file, created once and
+// shared by the routes targeting them
+var namedClients = provider.NamedClients()
+
+// resolveRoute resolves a "client:actual_model" route target to the named
+// client, other targets being served by the default backend.
+func resolveRoute(ctx context.Context, target string, defaultClient llm.Client) (router.Route, error) {
+	if name, model, found := strings.Cut(target, ":"); found && namedClients.Has(name) {
+		client, err := namedClients.Get(ctx, name)
+		if err != nil {
+			return router.Route{}, errors.WithStack(err)
+		}
+		return router.Route{Client: client, Model: model}, nil
+	}
+
+	if defaultClient == nil {
+		return router.Route{}, errors.New("no default backend configured")
+	}
+
+	return router.Route{Client: defaultClient, Model: target}, nil
+}
+
 // parseStaticRoutes parses "--proxy-route model=actual_model" flags.
 func parseStaticRoutes(args []string) map[string]string {
 	routes := make(map[string]string)
@@ -0,0 +1,453 @@
+package anonymize
+
+import (
+	"context"
+	"slices"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/messageutil"
+	"github.com/pkg/errors"
+)
+
+// Client replaces the personal data of the messages (emails, phone numbers,
+// IBANs, names...) by placeholders before sending them to the wrapped
+// client, and restores the original values in its responses, tool call
+// arguments included: tools executed by an agent receive the real values.
+//
+// Each call has its own mapping, unless one is shared with WithMapping.
+// Placeholders being numbered in order of appearance, a conversation growing
+// from one call to the other keeps the same placeholders either way.
+// Reasoning blocks, possibly signed by the provider, are left untouched.
+type Client struct {
+	client llm.Client
+	opts   *Options
+}
+
+// ChatCompletion implements llm.Client.
+func (c *Client) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	mapping := c.mapping(ctx)
+
+	res, err := c.client.ChatCompletion(ctx, c.anonymize(funcs, mapping)...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return restoreResponse(res, mapping), nil
+}
+
+// ChatCompletionStream implements llm.Client.
+func (c *Client) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	mapping := c.mapping(ctx)
+
+	stream, err := c.client.ChatCompletionStream(ctx, c.anonymize(funcs, mapping)...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if mapping.Len() == 0 {
+		return stream, nil
+	}
+
+	out := make(chan llm.StreamChunk)
+
+	go func() {
+		defer close(out)
+		restoreStream(ctx, mapping, stream, out)
+	}()
+
+	return out, nil
+}
+
+// Embeddings implements llm.Client.
+func (c *Client) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	mapping := c.mapping(ctx)
+
+	anonymized := make([]string, len(inputs))
+	for i, input := range inputs {
+		anonymized[i] = Anonymize(input, mapping, c.opts.Recognizers...)
+	}
+
+	return c.client.Embeddings(ctx, anonymized, funcs...)
+}
+
+// Transcription implements llm.Client.
+func (c *Client) Transcription(ctx context.Context, audio []byte, funcs ...llm.TranscriptionOptionFunc) (llm.TranscriptionResponse, error) {
+	return c.client.Transcription(ctx, audio, funcs...)
+}
+
+// ImageGeneration implements llm.ImageGenerationClient.
+func (c *Client) ImageGeneration(ctx context.Context, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return llm.DelegateImageGeneration(ctx, c.client, prompt, funcs...)
+}
+
+// ImageEdit implements llm.ImageEditClient.
+func (c *Client) ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return llm.DelegateImageEdit(ctx, c.client, image, prompt, funcs...)
+}
+
+// ImageVariation implements llm.ImageEditClient.
+func (c *Client) ImageVariation(ctx context.Context, image []byte, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return llm.DelegateImageVariation(ctx, c.client, image, funcs...)
+}
+
+// Complete implements llm.CompletionClient. The prompt and the suffix are
+// anonymized, the completed text restored.
+func (c *Client) Complete(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	mapping := c.mapping(ctx)
+
+	prompt = Anonymize(prompt, mapping, c.opts.Recognizers...)
+	suffix = Anonymize(suffix, mapping, c.opts.Recognizers...)
+
+	res, err := llm.DelegateComplete(ctx, c.client, prompt, suffix, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if mapping.Len() == 0 {
+		return res, nil
+	}
+
+	return llm.NewCompletionResponse(mapping.Restore(res.Text()), res.FinishReason(), res.Usage()), nil
+}
+
+// CompleteStream implements llm.CompletionClient.
+func (c *Client) CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	mapping := c.mapping(ctx)
+
+	prompt = Anonymize(prompt, mapping, c.opts.Recognizers...)
+	suffix = Anonymize(suffix, mapping, c.opts.Recognizers...)
+
+	stream, err := llm.DelegateCompleteStream(ctx, c.client, prompt, suffix, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if mapping.Len() == 0 {
+		return stream, nil
+	}
+
+	out := make(chan llm.StreamChunk)
+
+	go func() {
+		defer close(out)
+		restoreStream(ctx, mapping, stream, out)
+	}()
+
+	return out, nil
+}
+
+// ValidateAttachment implements llm.AttachmentValidator.
+func (c *Client) ValidateAttachment(attachment llm.Attachment) error {
+	return llm.DelegateValidateAttachment(c.client, attachment)
+}
+
+func (c *Client) mapping(ctx context.Context) *Mapping {
+	if mapping, err := ContextMapping(ctx); err == nil && mapping != nil {
+		return mapping
+	}
+
+	return NewMapping()
+}
+
+// anonymize returns the options with the anonymized messages
+func (c *Client) anonymize(funcs []llm.ChatCompletionOptionFunc, mapping *Mapping) []llm.ChatCompletionOptionFunc {
+	opts := llm.NewChatCompletionOptions(funcs...)
+
+	messages := make([]llm.Message, len(opts.Messages))
+	for i, message := range opts.Messages {
+		messages[i] = c.anonymizeMessage(message, mapping)
+	}
+
+	if mapping.Len() == 0 {
+		return funcs
+	}
+
+	return append(slices.Clone(funcs), llm.WithMessages(messages...))
+}
+
+func (c *Client) anonymizeMessage(message llm.Message, mapping *Mapping) llm.Message {
+	anonymize := func(text string) string {
+		return Anonymize(text, mapping, c.opts.Recognizers...)
+	}
+
+	switch m := message.(type) {
+	case llm.ToolCallsMessage:
+		return withMessageMetadata(withToolCalls(m, transformToolCalls(m.ToolCalls(), anonymize)), m)
+
+	case llm.ReasoningMessage:
+		content := anonymize(m.Content())
+		if content == m.Content() {
+			return m
+		}
+		return withMessageMetadata(llm.NewAssistantReasoningMessage(content, m.Reasoning(), m.ReasoningDetails()), m)
+
+	default:
+		content := anonymize(m.Content())
+		if content == m.Content() {
+			return m
+		}
+		return messageutil.WithContentAndAttachments(m, content, m.Attachments())
+	}
+}
+
+// withMessageMetadata returns the rewritten message with the cache hint and
+// the citations of the original one, as messageutil.WithContentAndAttachments
+// keeps them
+func withMessageMetadata(rewritten llm.Message, original llm.Message) llm.Message {
+	if cm, ok := original.(llm.CacheControlMessage); ok && cm.CacheControl() != nil {
+		rewritten, _ = llm.WithCacheControl(rewritten, cm.CacheControl())
+	}
+
+	if citations := llm.CitationsOf(original); len(citations) > 0 {
+		rewritten, _ = llm.WithCitations(rewritten, citations)
+	}
+
+	return rewritten
+}
+
+// restoreResponse returns the response with the original values of the
+// placeholders
+func restoreResponse(res llm.ChatCompletionResponse, mapping *Mapping) llm.ChatCompletionResponse {
+	if mapping.Len() == 0 {
+		return res
+	}
+
+	toolCalls := transformToolCalls(res.ToolCalls(), mapping.Restore)
+	message := restoreMessage(res.Message(), mapping)
+
+	var restored *llm.BaseChatCompletionResponse
+	if reasoning, ok := res.(llm.ReasoningChatCompletionResponse); ok {
+		restored = llm.NewChatCompletionResponseWithReasoning(message, res.Usage(), reasoning.Reasoning(), reasoning.ReasoningDetails(), toolCalls...)
+	} else {
+		restored = llm.NewChatCompletionResponse(message, res.Usage(), toolCalls...)
+	}
+
+	cr, ok := res.(llm.CandidatesResponse)
+	if !ok {
+		return restored
+	}
+
+	candidates := make([]llm.Candidate, 0, len(cr.Candidates()))
+	for _, c := range cr.Candidates() {
+		candidates = append(candidates, llm.NewCandidate(
+			c.Index(),
+			restoreMessage(c.Message(), mapping),
+			c.FinishReason(),
+			c.Logprobs(),
+			transformToolCalls(c.ToolCalls(), mapping.Restore)...,
+		))
+	}
+
+	return llm.NewCandidatesResponse(restored, candidates...)
+}
+
+// restoreMessage returns the message with the original values of the
+// placeholders, keeping its citations
+func restoreMessage(message llm.Message, mapping *Mapping) llm.Message {
+	var restored llm.Message
+
+	switch m := message.(type) {
+	case llm.ToolCallsMessage:
+		restored = withToolCalls(m, transformToolCalls(m.ToolCalls(), mapping.Restore))
+	case llm.ReasoningMessage:
+		restored = llm.NewAssistantReasoningMessage(mapping.Restore(m.Content()), m.Reasoning(), m.ReasoningDetails())
+	default:
+		restored = llm.NewMessage(m.Role(), mapping.Restore(m.Content()))
+	}
+
+	if citations := llm.CitationsOf(message); len(citations) > 0 {
+		restored, _ = llm.WithCitations(restored, citations)
+	}
+
+	return restored
+}
+
+// transformToolCalls applies the function to the strings of the tool call
+// arguments
+func transformToolCalls(toolCalls []llm.ToolCall, fn func(text string) string) []llm.ToolCall {
+	if toolCalls == nil {
+		return nil
+	}
+
+	transformed := make([]llm.ToolCall, len(toolCalls))
+	for i, tc := range toolCalls {
+		var parameters string
+		switch p := tc.Parameters().(type) {
+		case string:
+			parameters = transformJSON(p, fn)
+		default:
+			data, err := marshalJSON(transformValue(p, fn))
+			if err != nil {
+				transformed[i] = tc
+				continue
+			}
+			parameters = string(data)
+		}
+
+		transformed[i] = llm.NewToolCall(tc.ID(), tc.Name(), parameters)
+	}
+
+	return transformed
+}
+
+func withToolCalls(message llm.ToolCallsMessage, toolCalls []llm.ToolCall) llm.Message {
+	if reasoning, ok := message.(llm.ReasoningMessage); ok {
+		return llm.NewReasoningToolCallsMessage(reasoning.Reasoning(), reasoning.ReasoningDetails(), toolCalls...)
+	}
+
+	return llm.NewToolCallsMessage(toolCalls...)
+}
+
+// restoreStream forwards the chunks with the original values of the
+// placeholders, possibly split across deltas. Tool call arguments being
+// JSON, their values are escaped.
+func restoreStream(ctx context.Context, mapping *Mapping, stream <-chan llm.StreamChunk, out chan<- llm.StreamChunk) {
+	var (
+		content   = newStreamRestorer(mapping, nil)
+		arguments = map[int]*streamRestorer{}
+		role      = llm.RoleAssistant
+	)
+
+	send := func(chunk llm.StreamChunk) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case out <- chunk:
+			return true
+		}
+	}
+
+	// flush emits the text held back, before the end of the stream
+	flush := func() bool {
+		text := content.Flush()
+
+		var toolCalls []llm.ToolCallDelta
+		for index, restorer := range arguments {
+			if rest := restorer.Flush(); rest != "" {
+				toolCalls = append(toolCalls, llm.NewToolCallDelta(index, "", "", rest))
+			}
+		}
+
+		if text == "" && len(toolCalls) == 0 {
+			return true
+		}
+
+		slices.SortFunc(toolCalls, func(a, b llm.ToolCallDelta) int { return a.Index() - b.Index() })
+
+		return send(llm.NewStreamChunk(llm.NewStreamDelta(role, text, toolCalls...)))
+	}
+
+	defer func() {
+		// Release the producer of an interrupted stream
+		go func() {
+			for range stream {
+			}
+		}()
+	}()
+
+	for {
+		var (
+			chunk llm.StreamChunk
+			ok    bool
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case chunk, ok = <-stream:
+		}
+
+		if !ok {
+			flush()
+			return
+		}
+
+		delta := chunk.Delta()
+		if chunk.Type() != llm.StreamChunkTypeDelta || delta == nil {
+			if chunk.Type() == llm.StreamChunkTypeComplete || chunk.Type() == llm.StreamChunkTypeError {
+				if !flush() {
+					return
+				}
+			}
+
+			if !send(chunk) {
+				return
+			}
+			continue
+		}
+
+		if delta.Role() != "" {
+			role = delta.Role()
+		}
+
+		if !send(restoreDelta(chunk, content, arguments, mapping)) {
+			return
+		}
+	}
+}
+
+func restoreDelta(chunk llm.StreamChunk, content *streamRestorer, arguments map[int]*streamRestorer, mapping *Mapping) llm.StreamChunk {
+	delta := chunk.Delta()
+
+	text := content.Write(delta.Content())
+	changed := text != delta.Content()
+
+	toolCalls := make([]llm.ToolCallDelta, len(delta.ToolCalls()))
+	for i, tc := range delta.ToolCalls() {
+		restorer, exists := arguments[tc.Index()]
+		if !exists {
+			restorer = newStreamRestorer(mapping, escapeJSON)
+			arguments[tc.Index()] = restorer
+		}
+
+		parameters := restorer.Write(tc.ParametersDelta())
+		if parameters == tc.ParametersDelta() {
+			toolCalls[i] = tc
+			continue
+		}
+
+		toolCalls[i] = llm.NewToolCallDelta(tc.Index(), tc.ID(), tc.Name(), parameters)
+		changed = true
+	}
+
+	if !changed {
+		return chunk
+	}
+
+	if audio, ok := delta.(audioStreamDelta); ok && audio.AudioData() != "" {
+		return llm.NewStreamChunk(llm.NewAudioStreamDelta(delta.Role(), text, audio.AudioData(), audio.Transcript(), toolCalls...))
+	}
+
+	var restored llm.StreamDelta
+	if reasoning, ok := delta.(llm.ReasoningStreamDelta); ok {
+		restored = llm.NewReasoningStreamDelta(delta.Role(), text, reasoning.Reasoning(), reasoning.ReasoningDetails(), toolCalls...)
+	} else {
+		restored = llm.NewStreamDelta(delta.Role(), text, toolCalls...)
+	}
+
+	if ad, ok := delta.(llm.AnnotatedStreamDelta); ok && len(ad.Citations()) > 0 {
+		restored, _ = llm.WithStreamDeltaCitations(restored, ad.Citations())
+	}
+
+	return llm.NewStreamChunk(restored)
+}
+
+type audioStreamDelta interface {
+	AudioData() string
+	Transcript() string
+}
+
+func NewClient(client llm.Client, funcs ...OptionFunc) *Client {
+	return &Client{
+		client: client,
+		opts:   NewOptions(funcs...),
+	}
+}
+
+var (
+	_ llm.Client                = &Client{}
+	_ llm.ImageGenerationClient = &Client{}
+	_ llm.ImageEditClient       = &Client{}
+	_ llm.CompletionClient      = &Client{}
+	_ llm.AttachmentValidator   = &Client{}
+)
@@ -0,0 +1,247 @@
+package anonymize
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+)
+
+// mockClient records the messages it receives and answers with a text and
+// tool calls built from them.
+type mockClient struct {
+	messages  []llm.Message
+	answer    func(messages []llm.Message) string
+	arguments func(messages []llm.Message) string
+	citations []llm.Citation
+}
+
+func (m *mockClient) ChatCompletion(_ context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	m.messages = llm.NewChatCompletionOptions(funcs...).Messages
+
+	var toolCalls []llm.ToolCall
+	if m.arguments != nil {
+		toolCalls = append(toolCalls, llm.NewToolCall("call-1", "send_email", m.arguments(m.messages)))
+	}
+
+	message, _ := llm.WithCitations(llm.NewMessage(llm.RoleAssistant, m.answer(m.messages)), m.citations)
+
+	return llm.NewChatCompletionResponse(message, nil, toolCalls...), nil
+}
+
+// ChatCompletionStream streams the answer and the tool call arguments by
+// chunks of 3 bytes, splitting the placeholders.
+func (m *mockClient) ChatCompletionStream(_ context.Context, funcs ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	m.messages = llm.NewChatCompletionOptions(funcs...).Messages
+
+	answer := m.answer(m.messages)
+	arguments := ""
+	if m.arguments != nil {
+		arguments = m.arguments(m.messages)
+	}
+
+	chunks := make(chan llm.StreamChunk, len(answer)+len(arguments)+1)
+	for i := 0; i < len(answer); i += 3 {
+		chunks <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, answer[i:min(i+3, len(answer))]))
+	}
+	for i := 0; i < len(arguments); i += 3 {
+		chunks <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, "", llm.NewToolCallDelta(0, "", "", arguments[i:min(i+3, len(arguments))])))
+	}
+	chunks <- llm.NewCompleteStreamChunk(nil)
+	close(chunks)
+
+	return chunks, nil
+}
+
+func (m *mockClient) Embeddings(_ context.Context, _ []string, _ ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	return nil, nil
+}
+
+func (m *mockClient) Transcription(_ context.Context, _ []byte, _ ...llm.TranscriptionOptionFunc) (llm.TranscriptionResponse, error) {
+	return nil, nil
+}
+
+// echo answers with the content of the last message
+func echo(messages []llm.Message) string {
+	return "You said: " + messages[len(messages)-1].Content()
+}
+
+func TestClient_ChatCompletion(t *testing.T) {
+	mock := &mockClient{
+		answer: echo,
+		arguments: func(messages []llm.Message) string {
+			return `{"to": "<EMAIL_1>", "body": "Hello <NAME_1>"}`
+		},
+	}
+
+	client := NewClient(mock, WithNames("Jane Doe"))
+
+	input := "I am Jane Doe, reach me at jane.doe@example.com or +33 6 12 34 56 78. IBAN: FR76 3000 6000 0112 3456 7890 189"
+
+	res, err := client.ChatCompletion(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, input)))
+	if err != nil {
+		t.Fatalf("ChatCompletion: %+v", err)
+	}
+
+	sent := mock.messages[0].Content()
+	expected := "I am <NAME_1>, reach me at <EMAIL_1> or <PHONE_1>. IBAN: <IBAN_1>"
+	if sent != expected {
+		t.Errorf("sent %q, expected %q", sent, expected)
+	}
+
+	if got := res.Message().Content(); got != "You said: "+input {
+		t.Errorf("response not restored: %q", got)
+	}
+
+	var arguments map[string]string
+	if err := json.Unmarshal([]byte(res.ToolCalls()[0].Parameters().(string)), &arguments); err != nil {
+		t.Fatalf("invalid arguments: %v", err)
+	}
+	if arguments["to"] != "jane.doe@example.com" || arguments["body"] != "Hello Jane Doe" {
+		t.Errorf("tool call arguments not restored: %+v", arguments)
+	}
+}
+
+func TestClient_KeepsCitations(t *testing.T) {
+	mock := &mockClient{
+		answer:    echo,
+		citations: []llm.Citation{{URL: "https://example.com", Title: "Example"}},
+	}
+
+	client := NewClient(mock, WithNames("Jane Doe"))
+
+	res, err := client.ChatCompletion(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, "Who is Jane Doe?")))
+	if err != nil {
+		t.Fatalf("ChatCompletion: %+v", err)
+	}
+
+	if got := res.Message().Content(); got != "You said: Who is Jane Doe?" {
+		t.Errorf("response not restored: %q", got)
+	}
+
+	if citations := llm.CitationsOf(res.Message()); len(citations) != 1 || citations[0].URL != "https://example.com" {
+		t.Errorf("citations not kept: %+v", citations)
+	}
+}
+
+func TestClient_AnonymizesHistory(t *testing.T) {
+	mock := &mockClient{answer: echo}
+	client := NewClient(mock)
+
+	_, err := client.ChatCompletion(context.Background(), llm.WithMessages(
+		llm.NewMessage(llm.RoleUser, "Write to bob@example.com"),
+		llm.NewToolCallsMessage(llm.NewToolCall("call-1", "send_email", `{"to": "bob@example.com"}`)),
+		llm.NewToolMessage("call-1", llm.NewToolResult("sent to bob@example.com")),
+	))
+	if err != nil {
+		t.Fatalf("ChatCompletion: %+v", err)
+	}
+
+	toolCalls := mock.messages[1].(llm.ToolCallsMessage).ToolCalls()
+	if got := toolCalls[0].Parameters(); got != `{"to":"<EMAIL_1>"}` {
+		t.Errorf("tool call arguments not anonymized: %v", got)
+	}
+
+	toolMessage, ok := mock.messages[2].(llm.ToolMessage)
+	if !ok || toolMessage.ID() != "call-1" || toolMessage.Content() != "sent to <EMAIL_1>" {
+		t.Errorf("unexpected tool message %+v", mock.messages[2])
+	}
+}
+
+func TestClient_KeepsToolCallsMetadata(t *testing.T) {
+	mock := &mockClient{answer: echo}
+	client := NewClient(mock)
+
+	cc := &llm.CacheControl{Type: "ephemeral"}
+	citations := []llm.Citation{{URL: "https://example.com", Title: "Example"}}
+
+	toolCalls, _ := llm.WithCacheControl(llm.NewToolCallsMessage(llm.NewToolCall("call-1", "send_email", `{"to": "bob@example.com"}`)), cc)
+	toolCalls, _ = llm.WithCitations(toolCalls, citations)
+
+	_, err := client.ChatCompletion(context.Background(), llm.WithMessages(
+		llm.NewMessage(llm.RoleUser, "Write to bob@example.com"),
+		toolCalls,
+		llm.NewToolMessage("call-1", llm.NewToolResult("sent")),
+	))
+	if err != nil {
+		t.Fatalf("ChatCompletion: %+v", err)
+	}
+
+	anonymized := mock.messages[1]
+
+	if got := anonymized.(llm.ToolCallsMessage).ToolCalls()[0].Parameters(); got != `{"to":"<EMAIL_1>"}` {
+		t.Errorf("tool call arguments not anonymized: %v", got)
+	}
+
+	if cm, ok := anonymized.(llm.CacheControlMessage); !ok || cm.CacheControl() != cc {
+		t.Errorf("cache hint not kept: %+v", anonymized)
+	}
+
+	if got := llm.CitationsOf(anonymized); len(got) != 1 || got[0].URL != "https://example.com" {
+		t.Errorf("citations not kept: %+v", got)
+	}
+}
+
+func TestClient_ChatCompletionStream(t *testing.T) {
+	mock := &mockClient{
+		answer: echo,
+		arguments: func(messages []llm.Message) string {
+			return `{"to": "<NAME_1>"}`
+		},
+	}
+
+	client := NewClient(mock, WithNames(`Jane "JD" Doe`))
+
+	input := `Contact Jane "JD" Doe now`
+
+	stream, err := client.ChatCompletionStream(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, input)))
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %+v", err)
+	}
+
+	var text, arguments strings.Builder
+	for chunk := range stream {
+		if chunk.Type() != llm.StreamChunkTypeDelta {
+			continue
+		}
+		text.WriteString(chunk.Delta().Content())
+		for _, tc := range chunk.Delta().ToolCalls() {
+			arguments.WriteString(tc.ParametersDelta())
+		}
+	}
+
+	if got := text.String(); got != "You said: "+input {
+		t.Errorf("streamed text not restored: %q", got)
+	}
+
+	var parsed map[string]string
+	if err := json.Unmarshal([]byte(arguments.String()), &parsed); err != nil {
+		t.Fatalf("invalid streamed arguments %q: %v", arguments.String(), err)
+	}
+	if parsed["to"] != `Jane "JD" Doe` {
+		t.Errorf("streamed arguments not restored: %+v", parsed)
+	}
+}
+
+func TestClient_SharedMapping(t *testing.T) {
+	mock := &mockClient{answer: echo}
+	client := NewClient(mock)
+
+	mapping := NewMapping()
+	ctx := WithMapping(context.Background(), mapping)
+
+	for _, input := range []string{"a@example.com", "b@example.com", "a@example.com"} {
+		if _, err := client.ChatCompletion(ctx, llm.WithMessages(llm.NewMessage(llm.RoleUser, input))); err != nil {
+			t.Fatalf("ChatCompletion: %+v", err)
+		}
+	}
+
+	if got := mock.messages[0].Content(); got != "<EMAIL_1>" {
+		t.Errorf("expected the shared placeholder, got %q", got)
+	}
+	if mapping.Len() != 2 {
+		t.Errorf("expected 2 mapped values, got %d", mapping.Len())
+	}
+}
@@ -0,0 +1,20 @@
+package anonymize
+
+import "github.com/bornholm/genai/llm/context"
+
+type contextKey string
+
+const contextKeyMapping contextKey = "mapping"
+
+// ContextMapping returns the mapping shared by the calls made with the
+// context
+func ContextMapping(ctx context.Context) (*Mapping, error) {
+	return context.Value[*Mapping](ctx, contextKeyMapping)
+}
+
+// WithMapping shares the mapping between the calls made with the context,
+// such as the turns of an agent, placeholders staying the same from one call
+// to the other. Each call has its own mapping otherwise.
+func WithMapping(ctx context.Context, mapping *Mapping) context.Context {
+	return context.WithValue(ctx, contextKeyMapping, mapping)
+}
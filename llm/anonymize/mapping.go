@@ -0,0 +1,137 @@
+package anonymize
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// placeholderPattern matches the placeholders, such as <EMAIL_1>
+var placeholderPattern = regexp.MustCompile(`<([A-Z][A-Z0-9_]*)_(\d+)>`)
+
+// Mapping associates the anonymized values to their placeholders. The same
+// value is always replaced by the same placeholder, numbered by kind in
+// order of appearance.
+type Mapping struct {
+	mutex        sync.RWMutex
+	placeholders map[string]string
+	values       map[string]string
+	counters     map[string]int
+}
+
+// Placeholder returns the placeholder of the value, creating it if need be.
+// The kind is normalized to match placeholderPattern.
+func (m *Mapping) Placeholder(kind string, value string) string {
+	kind = normalizeKind(kind)
+	key := kind + "\x00" + value
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if placeholder, exists := m.placeholders[key]; exists {
+		return placeholder
+	}
+
+	m.counters[kind]++
+	placeholder := fmt.Sprintf("<%s_%d>", kind, m.counters[kind])
+
+	m.placeholders[key] = placeholder
+	m.values[placeholder] = value
+
+	return placeholder
+}
+
+// normalizeKind upper-cases the kind and replaces the characters that cannot
+// appear in a placeholder by underscores, "customer-id" giving "CUSTOMER_ID"
+func normalizeKind(kind string) string {
+	kind = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		default:
+			return '_'
+		}
+	}, kind)
+
+	// Placeholders start with a letter
+	switch {
+	case kind == "":
+		return "ENTITY"
+	case kind[0] < 'A' || kind[0] > 'Z':
+		return "ENTITY_" + kind
+	default:
+		return kind
+	}
+}
+
+// Value returns the original value of the placeholder
+func (m *Mapping) Value(placeholder string) (string, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	value, exists := m.values[placeholder]
+
+	return value, exists
+}
+
+// Len returns the number of anonymized values
+func (m *Mapping) Len() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return len(m.values)
+}
+
+// Restore replaces the known placeholders of the text by their values
+func (m *Mapping) Restore(text string) string {
+	return m.restore(text, func(value string) string { return value })
+}
+
+func (m *Mapping) restore(text string, escape func(value string) string) string {
+	if !strings.Contains(text, "<") {
+		return text
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		value, exists := m.Value(placeholder)
+		if !exists {
+			return placeholder
+		}
+		return escape(value)
+	})
+}
+
+func NewMapping() *Mapping {
+	return &Mapping{
+		placeholders: make(map[string]string),
+		values:       make(map[string]string),
+		counters:     make(map[string]int),
+	}
+}
+
+// Anonymize replaces the entities found by the recognizers by their
+// placeholders in the mapping
+func Anonymize(text string, mapping *Mapping, recognizers ...Recognizer) string {
+	matches := recognize(text, recognizers)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var (
+		sb     strings.Builder
+		cursor int
+	)
+
+	for _, m := range matches {
+		sb.WriteString(text[cursor:m.Start])
+		sb.WriteString(mapping.Placeholder(m.Kind, text[m.Start:m.End]))
+		cursor = m.End
+	}
+
+	sb.WriteString(text[cursor:])
+
+	return sb.String()
+}
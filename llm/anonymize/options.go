@@ -0,0 +1,42 @@
+package anonymize
+
+type Options struct {
+	// Recognizers find the entities to anonymize. When matches overlap, the
+	// earliest then longest one wins.
+	Recognizers []Recognizer
+}
+
+type OptionFunc func(opts *Options)
+
+func NewOptions(funcs ...OptionFunc) *Options {
+	opts := &Options{
+		Recognizers: DefaultRecognizers(),
+	}
+
+	for _, fn := range funcs {
+		fn(opts)
+	}
+
+	return opts
+}
+
+// WithRecognizers replaces the recognizers
+func WithRecognizers(recognizers ...Recognizer) OptionFunc {
+	return func(opts *Options) {
+		opts.Recognizers = recognizers
+	}
+}
+
+// WithNames anonymizes the given names, in addition to the other entities
+func WithNames(names ...string) OptionFunc {
+	return func(opts *Options) {
+		opts.Recognizers = append(opts.Recognizers, NewNameRecognizer(names...))
+	}
+}
+
+// WithRecognizer appends a recognizer
+func WithRecognizer(recognizer Recognizer) OptionFunc {
+	return func(opts *Options) {
+		opts.Recognizers = append(opts.Recognizers, recognizer)
+	}
+}
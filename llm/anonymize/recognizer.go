@@ -0,0 +1,283 @@
+package anonymize
+
+import (
+	"cmp"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kinds of the entities found by the built-in recognizers, used in the
+// placeholders
+const (
+	KindEmail = "EMAIL"
+	KindPhone = "PHONE"
+	KindIBAN  = "IBAN"
+	KindName  = "NAME"
+)
+
+// Match is an entity found in a text, at the byte range [Start, End)
+type Match struct {
+	Kind  string
+	Start int
+	End   int
+}
+
+// Recognizer finds the entities to anonymize in a text
+type Recognizer interface {
+	Recognize(text string) []Match
+}
+
+type RecognizerFunc func(text string) []Match
+
+// Recognize implements Recognizer.
+func (fn RecognizerFunc) Recognize(text string) []Match {
+	return fn(text)
+}
+
+// RegexpRecognizer finds the entities matching a pattern, optionally checked
+// by a validation function
+type RegexpRecognizer struct {
+	kind    string
+	pattern *regexp.Regexp
+	valid   func(value string) bool
+	// words tells if the matches must stand as whole words, regardless of
+	// the script: the \b of regexp only knows of ASCII word characters
+	words bool
+}
+
+// Recognize implements Recognizer.
+func (r *RegexpRecognizer) Recognize(text string) []Match {
+	if r.words {
+		return r.recognizeWords(text)
+	}
+
+	var matches []Match
+
+	for _, loc := range r.pattern.FindAllStringIndex(text, -1) {
+		if r.valid != nil && !r.valid(text[loc[0]:loc[1]]) {
+			continue
+		}
+
+		matches = append(matches, Match{Kind: r.kind, Start: loc[0], End: loc[1]})
+	}
+
+	return matches
+}
+
+// recognizeWords finds the matches standing as whole words. When the match
+// found at a position is part of a longer word, the shorter matches at the
+// same position are tried in turn: "Jane" stands as a word in "Jane Doever"
+// although "Jane Doe" does not.
+func (r *RegexpRecognizer) recognizeWords(text string) []Match {
+	var matches []Match
+
+	for offset := 0; offset < len(text); {
+		loc := r.pattern.FindStringIndex(text[offset:])
+		if loc == nil {
+			break
+		}
+
+		start, end := offset+loc[0], offset+loc[1]
+
+		for end > start && !r.isWordMatch(text, start, end) {
+			_, size := utf8.DecodeLastRuneInString(text[start:end])
+
+			shorter := r.pattern.FindStringIndex(text[start : end-size])
+			if shorter == nil || shorter[0] != 0 {
+				end = start
+				break
+			}
+
+			end = start + shorter[1]
+		}
+
+		if end > start {
+			matches = append(matches, Match{Kind: r.kind, Start: start, End: end})
+			offset = end
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(text[start:])
+		offset = start + max(size, 1)
+	}
+
+	return matches
+}
+
+func (r *RegexpRecognizer) isWordMatch(text string, start, end int) bool {
+	return isWholeWord(text, start, end) && (r.valid == nil || r.valid(text[start:end]))
+}
+
+// isWholeWord tells if text[start:end] is not part of a longer word. A side
+// made of a non-word character, as the end of "J.D.", needs no boundary.
+func isWholeWord(text string, start, end int) bool {
+	if first, _ := utf8.DecodeRuneInString(text[start:end]); isWordRune(first) && start > 0 {
+		if before, _ := utf8.DecodeLastRuneInString(text[:start]); isWordRune(before) {
+			return false
+		}
+	}
+
+	if last, _ := utf8.DecodeLastRuneInString(text[start:end]); isWordRune(last) && end < len(text) {
+		if after, _ := utf8.DecodeRuneInString(text[end:]); isWordRune(after) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// NewRegexpRecognizer creates a recognizer of custom entities. The kind,
+// upper-cased, names the placeholders.
+func NewRegexpRecognizer(kind string, pattern *regexp.Regexp) *RegexpRecognizer {
+	return &RegexpRecognizer{
+		kind:    strings.ToUpper(kind),
+		pattern: pattern,
+	}
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`(?:\+|\b)\d[\d .()-]{6,}\d\b`)
+	datePattern  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	ibanPattern  = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,4})?\b`)
+)
+
+// NewEmailRecognizer creates a recognizer of email addresses
+func NewEmailRecognizer() *RegexpRecognizer {
+	return &RegexpRecognizer{kind: KindEmail, pattern: emailPattern}
+}
+
+// NewPhoneRecognizer creates a recognizer of phone numbers, national or
+// international, holding 8 to 15 digits. ISO dates are ignored.
+func NewPhoneRecognizer() *RegexpRecognizer {
+	return &RegexpRecognizer{
+		kind:    KindPhone,
+		pattern: phonePattern,
+		valid: func(value string) bool {
+			if datePattern.MatchString(value) {
+				return false
+			}
+			digits := 0
+			for _, r := range value {
+				if r >= '0' && r <= '9' {
+					digits++
+				}
+			}
+			return digits >= 8 && digits <= 15
+		},
+	}
+}
+
+// NewIBANRecognizer creates a recognizer of IBANs, whose check digits are
+// verified
+func NewIBANRecognizer() *RegexpRecognizer {
+	return &RegexpRecognizer{
+		kind:    KindIBAN,
+		pattern: ibanPattern,
+		valid:   validIBAN,
+	}
+}
+
+// validIBAN verifies the ISO 13616 checksum: the IBAN, its first four
+// characters moved to the end and its letters converted to numbers, is 1
+// modulo 97.
+func validIBAN(value string) bool {
+	iban := strings.ReplaceAll(value, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	var digits strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		default:
+			return false
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return false
+	}
+
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// NewNameRecognizer creates a recognizer of the given names, such as the
+// customers of a conversation, regardless of the case. Free-form person
+// names require a named-entity recognition model, to be plugged with a
+// RecognizerFunc.
+func NewNameRecognizer(names ...string) *RegexpRecognizer {
+	quoted := make([]string, 0, len(names))
+	for _, n := range names {
+		if n = strings.TrimSpace(n); n != "" {
+			quoted = append(quoted, regexp.QuoteMeta(n))
+		}
+	}
+
+	// Longest names first, so that full names win over their parts
+	slices.SortFunc(quoted, func(a, b string) int {
+		return cmp.Compare(len(b), len(a))
+	})
+
+	pattern := `$^`
+	if len(quoted) > 0 {
+		pattern = `(?i)(?:` + strings.Join(quoted, "|") + `)`
+	}
+
+	return &RegexpRecognizer{
+		kind:    KindName,
+		pattern: regexp.MustCompile(pattern),
+		words:   true,
+	}
+}
+
+// DefaultRecognizers recognize emails, IBANs and phone numbers
+func DefaultRecognizers() []Recognizer {
+	return []Recognizer{
+		NewEmailRecognizer(),
+		NewIBANRecognizer(),
+		NewPhoneRecognizer(),
+	}
+}
+
+// recognize returns the matches of the recognizers, sorted and without
+// overlaps: the first and then longest match wins.
+func recognize(text string, recognizers []Recognizer) []Match {
+	var matches []Match
+	for _, r := range recognizers {
+		matches = append(matches, r.Recognize(text)...)
+	}
+
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		if c := cmp.Compare(a.Start, b.Start); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.End, a.End)
+	})
+
+	kept := matches[:0]
+	end := 0
+	for _, m := range matches {
+		if m.Start < end || m.End <= m.Start {
+			continue
+		}
+		kept = append(kept, m)
+		end = m.End
+	}
+
+	return kept
+}
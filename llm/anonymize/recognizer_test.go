@@ -0,0 +1,121 @@
+package anonymize
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAnonymize(t *testing.T) {
+	recognizers := append(DefaultRecognizers(), NewRegexpRecognizer("customer_id", regexp.MustCompile(`\bC-\d{6}\b`)))
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"email", "Mail john@example.org and john@example.org", "Mail <EMAIL_1> and <EMAIL_1>"},
+		{"phones", "Call 06 12 34 56 78 or (555) 123-4567", "Call <PHONE_1> or (<PHONE_2>"},
+		{"short numbers", "Order 1234 of 2024-05-01", "Order 1234 of 2024-05-01"},
+		{"valid iban", "IBAN GB82 WEST 1234 5698 7654 32", "IBAN <IBAN_1>"},
+		{"invalid iban", "IBAN GB00WEST12345698765432", "IBAN GB00WEST12345698765432"},
+		{"custom", "Customer C-123456", "Customer <CUSTOMER_ID_1>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping := NewMapping()
+
+			got := Anonymize(tt.text, mapping, recognizers...)
+			if got != tt.expected {
+				t.Errorf("Anonymize() = %q, expected %q", got, tt.expected)
+			}
+
+			if restored := mapping.Restore(got); restored != tt.text {
+				t.Errorf("Restore() = %q, expected %q", restored, tt.text)
+			}
+		})
+	}
+}
+
+func TestAnonymize_NormalizesKinds(t *testing.T) {
+	recognizers := []Recognizer{
+		NewRegexpRecognizer("customer-id", regexp.MustCompile(`\bC-\d{6}\b`)),
+		RecognizerFunc(func(text string) []Match {
+			start := strings.Index(text, "Élodie")
+			if start < 0 {
+				return nil
+			}
+			return []Match{{Kind: "person", Start: start, End: start + len("Élodie")}}
+		}),
+	}
+
+	mapping := NewMapping()
+
+	text := "Élodie is customer C-123456"
+
+	got := Anonymize(text, mapping, recognizers...)
+	if expected := "<PERSON_1> is customer <CUSTOMER_ID_1>"; got != expected {
+		t.Errorf("Anonymize() = %q, expected %q", got, expected)
+	}
+
+	if restored := mapping.Restore(got); restored != text {
+		t.Errorf("Restore() = %q, expected %q", restored, text)
+	}
+
+	restorer := newStreamRestorer(mapping, nil)
+
+	var streamed string
+	for _, piece := range []string{"<PER", "SON_1> is customer <CUSTOMER", "_ID_1>"} {
+		streamed += restorer.Write(piece)
+	}
+	streamed += restorer.Flush()
+
+	if streamed != text {
+		t.Errorf("streamed %q, expected %q", streamed, text)
+	}
+}
+
+func TestNameRecognizer(t *testing.T) {
+	recognizer := NewNameRecognizer("Élodie", "José", "Jane", "Jane Doe", "J.D.")
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"accented names", "Élodie et José", "<NAME_1> et <NAME_2>"},
+		{"case", "ÉLODIE et josé", "<NAME_1> et <NAME_2>"},
+		{"parts of words", "Mélodie et Joséphine", "Mélodie et Joséphine"},
+		{"longest name", "Jane Doe, Jane", "<NAME_1>, <NAME_2>"},
+		{"shorter name", "Jane Doever", "<NAME_1> Doever"},
+		{"non-word sides", "Signed J.D.", "Signed <NAME_1>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping := NewMapping()
+
+			if got := Anonymize(tt.text, mapping, recognizer); got != tt.expected {
+				t.Errorf("Anonymize() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStreamRestorer(t *testing.T) {
+	mapping := NewMapping()
+	mapping.Placeholder(KindEmail, "a@example.com")
+
+	restorer := newStreamRestorer(mapping, nil)
+
+	var got string
+	for _, piece := range []string{"to <EM", "AIL", "_1> and <", "b> <EMAIL_2", ">", " <EMA"} {
+		got += restorer.Write(piece)
+	}
+	got += restorer.Flush()
+
+	if expected := "to a@example.com and <b> <EMAIL_2> <EMA"; got != expected {
+		t.Errorf("restored %q, expected %q", got, expected)
+	}
+}
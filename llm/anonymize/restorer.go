@@ -0,0 +1,123 @@
+package anonymize
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// maxPlaceholderLength bounds the text held back by a streamRestorer
+const maxPlaceholderLength = 64
+
+var placeholderPrefixPattern = regexp.MustCompile(`^<[A-Z0-9_]*$`)
+
+// streamRestorer restores the placeholders of a text received in pieces,
+// such as streamed deltas. The end of a piece which may be the beginning of
+// a placeholder is held back until the next piece tells.
+type streamRestorer struct {
+	mapping *Mapping
+	escape  func(value string) string
+	pending string
+}
+
+// Write returns the restored text which can be emitted so far
+func (r *streamRestorer) Write(piece string) string {
+	text := r.pending + piece
+
+	hold := len(text)
+	if i := strings.LastIndexByte(text, '<'); i >= 0 {
+		tail := text[i:]
+		if len(tail) < maxPlaceholderLength && placeholderPrefixPattern.MatchString(tail) {
+			hold = i
+		}
+	}
+
+	r.pending = text[hold:]
+
+	return r.mapping.restore(text[:hold], r.escape)
+}
+
+// Flush returns the text held back
+func (r *streamRestorer) Flush() string {
+	text := r.pending
+	r.pending = ""
+
+	return r.mapping.restore(text, r.escape)
+}
+
+func newStreamRestorer(mapping *Mapping, escape func(value string) string) *streamRestorer {
+	if escape == nil {
+		escape = func(value string) string { return value }
+	}
+
+	return &streamRestorer{
+		mapping: mapping,
+		escape:  escape,
+	}
+}
+
+// escapeJSON escapes the value to be inserted in a JSON string
+func escapeJSON(value string) string {
+	data, err := marshalJSON(value)
+	if err != nil {
+		return value
+	}
+
+	return string(data[1 : len(data)-1])
+}
+
+// transformJSON applies the function to the strings of a JSON document,
+// keys excepted. Other texts are transformed as a whole.
+func transformJSON(raw string, fn func(text string) string) string {
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+
+	var document any
+	if err := decoder.Decode(&document); err != nil || decoder.More() {
+		return fn(raw)
+	}
+
+	data, err := marshalJSON(transformValue(document, fn))
+	if err != nil {
+		return fn(raw)
+	}
+
+	return string(data)
+}
+
+func transformValue(value any, fn func(text string) string) any {
+	switch v := value.(type) {
+	case string:
+		return fn(v)
+	case map[string]any:
+		transformed := make(map[string]any, len(v))
+		for key, item := range v {
+			transformed[key] = transformValue(item, fn)
+		}
+		return transformed
+	case []any:
+		transformed := make([]any, len(v))
+		for i, item := range v {
+			transformed[i] = transformValue(item, fn)
+		}
+		return transformed
+	default:
+		return v
+	}
+}
+
+// marshalJSON encodes the value without escaping HTML characters, the
+// placeholders being delimited by angle brackets
+func marshalJSON(value any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
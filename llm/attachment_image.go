@@ -0,0 +1,525 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"slices"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/draw"
+
+	// Decoders of the image types re-encoded for providers
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// ImageLimits are the constraints a provider puts on input images
+type ImageLimits struct {
+	// MaxWidth and MaxHeight cap the dimensions in pixels, 0 for no limit
+	MaxWidth  int
+	MaxHeight int
+	// MaxPixels caps width × height, 0 for no limit
+	MaxPixels int
+	// MaxBytes caps the encoded size, 0 for no limit
+	MaxBytes int
+	// MimeTypes are the image types accepted as is, others being re-encoded
+	// as PNG or JPEG
+	MimeTypes []string
+}
+
+// DefaultImageLimits fit every supported provider, Anthropic models
+// (5 MB per image) being the most constrained
+var DefaultImageLimits = ImageLimits{
+	MaxWidth:  2048,
+	MaxHeight: 2048,
+	MaxBytes:  5 << 20,
+	MimeTypes: []string{"image/png", "image/jpeg", "image/webp", "image/gif"},
+}
+
+// minImageSide is the size below which images are not shrunk anymore to fit
+// the byte limit
+const minImageSide = 64
+
+// maxDecodedPixels caps the pixels decoded from an image, read from its
+// headers before decoding it, so that a small file declaring huge dimensions
+// cannot exhaust the memory. For GIF images, it caps the pixels of all the
+// frames together.
+const maxDecodedPixels = 64 << 20
+
+// jpegQualities are tried in turn before shrinking an image to fit the byte
+// limit
+var jpegQualities = []int{85, 70, 55}
+
+type preparedImage struct {
+	data     []byte
+	mimeType string
+}
+
+func isDecodableImage(mimeType string) bool {
+	switch mimeType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp", "image/tiff", "image/bmp":
+		return true
+	default:
+		return false
+	}
+}
+
+// prepareImage returns the image as is when the provider accepts it, and
+// otherwise decodes it, splits its pages or frames, downscales and
+// re-encodes them.
+func prepareImage(data []byte, mimeType string, limits ImageLimits, maxFrames int) ([]preparedImage, error) {
+	if fitsImageLimits(data, mimeType, limits) {
+		return []preparedImage{{data: data, mimeType: mimeType}}, nil
+	}
+
+	var (
+		frames []image.Image
+		err    error
+	)
+
+	switch mimeType {
+	case "image/tiff":
+		frames, err = tiffPages(data, maxFrames)
+	case "image/gif":
+		frames, err = gifFrames(data, maxFrames)
+	default:
+		var config image.Config
+		if config, _, err = image.DecodeConfig(bytes.NewReader(data)); err == nil {
+			if err = checkDecodedPixels(config.Width, config.Height); err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			var img image.Image
+			img, _, err = image.Decode(bytes.NewReader(data))
+			frames = []image.Image{img}
+		}
+	}
+	if err != nil {
+		var attachmentErr *AttachmentError
+		if errors.As(err, &attachmentErr) {
+			return nil, errors.WithStack(err)
+		}
+
+		return nil, NewAttachmentErrorWithCause("format", "data", fmt.Sprintf("could not decode %s image", mimeType), err)
+	}
+
+	// Lossy sources stay lossy, others are kept lossless when the limits
+	// allow it
+	lossless := mimeType != "image/jpeg" && mimeType != "image/webp"
+
+	prepared := make([]preparedImage, 0, len(frames))
+	for _, frame := range frames {
+		img, err := encodeImage(fitImage(frame, limits), lossless, limits.MaxBytes)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		prepared = append(prepared, img)
+	}
+
+	return prepared, nil
+}
+
+// fitsImageLimits tells if the image can be sent untouched: a single image
+// of an accepted type, within the limits.
+func fitsImageLimits(data []byte, mimeType string, limits ImageLimits) bool {
+	if !slices.Contains(limits.MimeTypes, mimeType) {
+		return false
+	}
+
+	if limits.MaxBytes > 0 && len(data) > limits.MaxBytes {
+		return false
+	}
+
+	if mimeType == "image/gif" {
+		frames, err := gifFrameBounds(data)
+		if err != nil || len(frames) > 1 {
+			return false
+		}
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+
+	return imageScale(config.Width, config.Height, limits) >= 1
+}
+
+// imageScale returns the factor to apply to the dimensions to fit the
+// limits, 1 or more when they already do
+func imageScale(width, height int, limits ImageLimits) float64 {
+	scale := math.Inf(1)
+
+	if limits.MaxWidth > 0 {
+		scale = min(scale, float64(limits.MaxWidth)/float64(width))
+	}
+	if limits.MaxHeight > 0 {
+		scale = min(scale, float64(limits.MaxHeight)/float64(height))
+	}
+	if limits.MaxPixels > 0 {
+		scale = min(scale, math.Sqrt(float64(limits.MaxPixels)/float64(width*height)))
+	}
+
+	return scale
+}
+
+func fitImage(img image.Image, limits ImageLimits) image.Image {
+	bounds := img.Bounds()
+
+	scale := imageScale(bounds.Dx(), bounds.Dy(), limits)
+	if scale >= 1 {
+		return img
+	}
+
+	return scaleImage(img, scale)
+}
+
+func scaleImage(img image.Image, scale float64) image.Image {
+	bounds := img.Bounds()
+	width := max(int(float64(bounds.Dx())*scale), 1)
+	height := max(int(float64(bounds.Dy())*scale), 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+	return dst
+}
+
+// encodeImage encodes the image as PNG when lossless and as JPEG otherwise,
+// lowering the JPEG quality then shrinking the image until it fits maxBytes.
+// Opaque images fall back to JPEG when PNG is too large.
+func encodeImage(img image.Image, lossless bool, maxBytes int) (preparedImage, error) {
+	fits := func(data []byte) bool {
+		return maxBytes <= 0 || len(data) <= maxBytes
+	}
+
+	for {
+		if lossless {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, img); err != nil {
+				return preparedImage{}, errors.WithStack(err)
+			}
+
+			if fits(buf.Bytes()) {
+				return preparedImage{data: buf.Bytes(), mimeType: "image/png"}, nil
+			}
+
+			if isOpaque(img) {
+				lossless = false
+				continue
+			}
+		} else {
+			opaque := flattenImage(img)
+			for _, quality := range jpegQualities {
+				var buf bytes.Buffer
+				if err := jpeg.Encode(&buf, opaque, &jpeg.Options{Quality: quality}); err != nil {
+					return preparedImage{}, errors.WithStack(err)
+				}
+
+				if fits(buf.Bytes()) {
+					return preparedImage{data: buf.Bytes(), mimeType: "image/jpeg"}, nil
+				}
+			}
+		}
+
+		bounds := img.Bounds()
+		if bounds.Dx() <= minImageSide || bounds.Dy() <= minImageSide {
+			return preparedImage{}, NewAttachmentError("format", "size", fmt.Sprintf("image cannot be compressed below %d bytes", maxBytes))
+		}
+
+		img = scaleImage(img, 0.75)
+	}
+}
+
+func isOpaque(img image.Image) bool {
+	opaque, ok := img.(interface{ Opaque() bool })
+	return ok && opaque.Opaque()
+}
+
+// flattenImage draws the image over a white background, JPEG having no
+// transparency
+func flattenImage(img image.Image) image.Image {
+	if isOpaque(img) {
+		return img
+	}
+
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Over)
+
+	return dst
+}
+
+// sampleFrames returns the indices of at most maxFrames frames out of count,
+// evenly spread
+func sampleFrames(count int, maxFrames int) []int {
+	if maxFrames <= 0 || count <= maxFrames {
+		maxFrames = count
+	}
+
+	indices := make([]int, 0, maxFrames)
+	for i := range maxFrames {
+		indices = append(indices, i*count/maxFrames)
+	}
+
+	return indices
+}
+
+// tiffPages decodes the pages of a multi-page TIFF. The decoder only reads
+// the first image file directory (IFD): each page is decoded from a copy of
+// the file whose header points to the IFD of the page, offsets within the
+// file being absolute.
+func tiffPages(data []byte, maxFrames int) ([]image.Image, error) {
+	if len(data) < 8 {
+		return nil, errors.New("truncated tiff header")
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if string(data[:2]) == "MM" {
+		order = binary.BigEndian
+	}
+
+	var (
+		offsets []uint32
+		seen    = map[uint32]struct{}{}
+	)
+
+	for offset := order.Uint32(data[4:8]); offset != 0; {
+		if _, exists := seen[offset]; exists || int(offset)+2 > len(data) {
+			break
+		}
+
+		seen[offset] = struct{}{}
+		offsets = append(offsets, offset)
+
+		entries := int(order.Uint16(data[offset:]))
+		next := int(offset) + 2 + entries*12
+		if next+4 > len(data) {
+			break
+		}
+
+		offset = order.Uint32(data[next:])
+	}
+
+	if len(offsets) == 0 {
+		return nil, errors.New("no tiff image file directory found")
+	}
+
+	selected := sampleFrames(len(offsets), maxFrames)
+	page := bytes.Clone(data)
+
+	// The decoded pages are held together until they are fitted: their
+	// dimensions are checked before any of them is decoded
+	var pixels int
+	for _, i := range selected {
+		order.PutUint32(page[4:8], offsets[i])
+
+		config, err := tiff.DecodeConfig(bytes.NewReader(page))
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decode tiff page %d", i+1)
+		}
+
+		if err := checkDecodedPixels(config.Width, config.Height); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		pixels += config.Width * config.Height
+		if pixels > maxDecodedPixels {
+			return nil, NewAttachmentError("format", "size", fmt.Sprintf("tiff pages exceed %d pixels", maxDecodedPixels))
+		}
+	}
+
+	pages := make([]image.Image, 0, len(selected))
+	for _, i := range selected {
+		order.PutUint32(page[4:8], offsets[i])
+
+		img, err := tiff.Decode(bytes.NewReader(page))
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decode tiff page %d", i+1)
+		}
+
+		pages = append(pages, img)
+	}
+
+	return pages, nil
+}
+
+// gifFrames renders the frames of an animated GIF, each frame being drawn
+// over the previous ones according to their disposal method.
+func gifFrames(data []byte, maxFrames int) ([]image.Image, error) {
+	config, err := gif.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := checkDecodedPixels(config.Width, config.Height); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Every frame is decoded, and lies within the logical screen
+	frameBounds, err := gifFrameBounds(data)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var pixels int
+	for _, b := range frameBounds {
+		pixels += b.Dx() * b.Dy()
+		if pixels > maxDecodedPixels {
+			return nil, NewAttachmentError("format", "size", fmt.Sprintf("gif frames exceed %d pixels", maxDecodedPixels))
+		}
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(g.Image) == 0 {
+		return nil, errors.New("gif without frames")
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		bounds = g.Image[0].Bounds()
+	}
+
+	selected := sampleFrames(len(g.Image), maxFrames)
+	frames := make([]image.Image, 0, len(selected))
+
+	canvas := image.NewRGBA(bounds)
+
+	for i, frame := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		if slices.Contains(selected, i) {
+			rendered := image.NewRGBA(bounds)
+			copy(rendered.Pix, canvas.Pix)
+			frames = append(frames, rendered)
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return frames, nil
+}
+
+// checkDecodedPixels rejects the dimensions exceeding maxDecodedPixels
+func checkDecodedPixels(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+
+	if width > maxDecodedPixels/height {
+		return NewAttachmentError("format", "size", fmt.Sprintf("image of %dx%d pixels exceeds %d pixels", width, height, maxDecodedPixels))
+	}
+
+	return nil
+}
+
+// gifFrameBounds returns the bounds of the frames of a GIF image, read from
+// their descriptors without decoding them
+func gifFrameBounds(data []byte) ([]image.Rectangle, error) {
+	// Header and logical screen descriptor
+	if len(data) < 13 {
+		return nil, errors.New("truncated gif header")
+	}
+
+	offset := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		offset += 3 << ((flags & 0x07) + 1)
+	}
+
+	// skipSubBlocks returns the offset following the data sub-blocks
+	// starting at the offset
+	skipSubBlocks := func(offset int) (int, error) {
+		for {
+			if offset >= len(data) {
+				return 0, errors.New("truncated gif data block")
+			}
+
+			size := int(data[offset])
+			offset++
+
+			if size == 0 {
+				return offset, nil
+			}
+
+			offset += size
+		}
+	}
+
+	var (
+		bounds []image.Rectangle
+		err    error
+	)
+
+	for offset < len(data) {
+		switch data[offset] {
+		case 0x21: // Extension
+			if offset+2 > len(data) {
+				return nil, errors.New("truncated gif extension")
+			}
+
+			if offset, err = skipSubBlocks(offset + 2); err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+		case 0x2C: // Image descriptor
+			if offset+11 > len(data) {
+				return nil, errors.New("truncated gif image descriptor")
+			}
+
+			left := int(binary.LittleEndian.Uint16(data[offset+1:]))
+			top := int(binary.LittleEndian.Uint16(data[offset+3:]))
+			width := int(binary.LittleEndian.Uint16(data[offset+5:]))
+			height := int(binary.LittleEndian.Uint16(data[offset+7:]))
+
+			bounds = append(bounds, image.Rect(left, top, left+width, top+height))
+
+			flags := data[offset+9]
+			offset += 10
+			if flags&0x80 != 0 {
+				offset += 3 << ((flags & 0x07) + 1)
+			}
+
+			// LZW minimum code size, then the image data
+			if offset, err = skipSubBlocks(offset + 1); err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+		case 0x3B: // Trailer
+			return bounds, nil
+
+		default:
+			return nil, errors.Errorf("unexpected gif block 0x%02x", data[offset])
+		}
+	}
+
+	return bounds, nil
+}
@@ -0,0 +1,402 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AttachmentOptions configures the attachment loaders
+type AttachmentOptions struct {
+	// MimeType overrides content sniffing
+	MimeType string
+	// ImageLimits are the constraints images are downscaled and re-encoded
+	// to fit. Nil disables image preprocessing.
+	ImageLimits *ImageLimits
+	// MaxFrames caps the number of images extracted from a multi-page TIFF
+	// or an animated GIF, frames being sampled evenly beyond it
+	MaxFrames int
+	// MaxSize caps the number of bytes read from the source
+	MaxSize int64
+	// HTTPClient is used by AttachmentFromURL
+	HTTPClient *http.Client
+}
+
+type AttachmentOptionFunc func(opts *AttachmentOptions)
+
+func NewAttachmentOptions(funcs ...AttachmentOptionFunc) *AttachmentOptions {
+	limits := DefaultImageLimits
+	opts := &AttachmentOptions{
+		ImageLimits: &limits,
+		MaxFrames:   10,
+		MaxSize:     50 << 20,
+		HTTPClient:  http.DefaultClient,
+	}
+	for _, fn := range funcs {
+		fn(opts)
+	}
+	return opts
+}
+
+// WithAttachmentMimeType sets the MIME type instead of sniffing it
+func WithAttachmentMimeType(mimeType string) AttachmentOptionFunc {
+	return func(opts *AttachmentOptions) {
+		opts.MimeType = mimeType
+	}
+}
+
+// WithImageLimits sets the limits images are preprocessed to fit,
+// DefaultImageLimits otherwise
+func WithImageLimits(limits ImageLimits) AttachmentOptionFunc {
+	return func(opts *AttachmentOptions) {
+		opts.ImageLimits = &limits
+	}
+}
+
+// WithoutImagePreprocessing keeps images as is, multi-page ones included
+func WithoutImagePreprocessing() AttachmentOptionFunc {
+	return func(opts *AttachmentOptions) {
+		opts.ImageLimits = nil
+	}
+}
+
+func WithMaxFrames(maxFrames int) AttachmentOptionFunc {
+	return func(opts *AttachmentOptions) {
+		opts.MaxFrames = maxFrames
+	}
+}
+
+func WithAttachmentMaxSize(maxSize int64) AttachmentOptionFunc {
+	return func(opts *AttachmentOptions) {
+		opts.MaxSize = maxSize
+	}
+}
+
+func WithAttachmentHTTPClient(client *http.Client) AttachmentOptionFunc {
+	return func(opts *AttachmentOptions) {
+		opts.HTTPClient = client
+	}
+}
+
+// AttachmentFromFile loads a file as attachments. Multi-page images yield
+// one attachment per page, other files a single one.
+func AttachmentFromFile(filename string, funcs ...AttachmentOptionFunc) ([]Attachment, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer file.Close()
+
+	opts := NewAttachmentOptions(funcs...)
+
+	data, err := readAttachmentData(file, opts.MaxSize)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read '%s'", filename)
+	}
+
+	return newAttachments(data, filepath.Base(filename), "", opts)
+}
+
+// AttachmentFromReader loads the content of the reader as attachments, its
+// type being sniffed.
+func AttachmentFromReader(r io.Reader, funcs ...AttachmentOptionFunc) ([]Attachment, error) {
+	opts := NewAttachmentOptions(funcs...)
+
+	data, err := readAttachmentData(r, opts.MaxSize)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return newAttachments(data, "", "", opts)
+}
+
+// AttachmentFromURL downloads the resource as attachments. The declared
+// Content-Type is only used when sniffing fails.
+func AttachmentFromURL(ctx context.Context, rawURL string, funcs ...AttachmentOptionFunc) ([]Attachment, error) {
+	opts := NewAttachmentOptions(funcs...)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res, err := opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, errors.Errorf("could not download '%s': unexpected status %d", rawURL, res.StatusCode)
+	}
+
+	data, err := readAttachmentData(res.Body, opts.MaxSize)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not download '%s'", rawURL)
+	}
+
+	var filename string
+	if parsed, err := url.Parse(rawURL); err == nil {
+		filename = path.Base(parsed.Path)
+	}
+
+	declared, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+
+	return newAttachments(data, filename, declared, opts)
+}
+
+// PreprocessAttachment fits a base64 image attachment to the image limits,
+// splitting multi-page images. Other attachments are returned as is.
+func PreprocessAttachment(attachment Attachment, funcs ...AttachmentOptionFunc) ([]Attachment, error) {
+	if attachment.Type() != AttachmentTypeImage || attachment.Source() != AttachmentSourceBase64 {
+		return []Attachment{attachment}, nil
+	}
+
+	opts := NewAttachmentOptions(funcs...)
+	if opts.ImageLimits == nil {
+		return []Attachment{attachment}, nil
+	}
+
+	encoded := attachment.Data()
+	if strings.HasPrefix(encoded, "data:") {
+		_, encoded, _ = strings.Cut(encoded, ",")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, NewAttachmentErrorWithCause("format", "data", "invalid base64 encoding", err)
+	}
+
+	if mimeType := DetectMimeType(data, ""); !strings.HasPrefix(mimeType, "image/") {
+		return nil, NewAttachmentError("format", "data", fmt.Sprintf("image attachment holds %s data", mimeType))
+	}
+
+	attachments, err := newAttachments(data, "", attachment.MimeType(), opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Keep the original attachment when it already fits
+	if len(attachments) == 1 && attachments[0].MimeType() == attachment.MimeType() && attachments[0].Data() == encoded {
+		return []Attachment{attachment}, nil
+	}
+
+	return attachments, nil
+}
+
+func readAttachmentData(r io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return io.ReadAll(r)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, NewAttachmentError("format", "size", fmt.Sprintf("attachment exceeds the maximum size of %d bytes", maxSize))
+	}
+
+	return data, nil
+}
+
+func newAttachments(data []byte, filename string, declared string, opts *AttachmentOptions) ([]Attachment, error) {
+	if len(data) == 0 {
+		return nil, ErrEmptyData
+	}
+
+	mimeType := opts.MimeType
+	if mimeType == "" {
+		mimeType = DetectMimeType(data, filename)
+	}
+	if mimeType == mimeTypeUnknown && declared != "" {
+		mimeType = declared
+	}
+
+	attachmentType, supported := attachmentTypeOf(mimeType)
+	if !supported {
+		return nil, NewAttachmentError("format", "mime_type", fmt.Sprintf("unsupported attachment type: %s", mimeType))
+	}
+
+	if attachmentType != AttachmentTypeImage || opts.ImageLimits == nil {
+		attachment, err := NewBase64Attachment(attachmentType, mimeType, base64.StdEncoding.EncodeToString(data))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return []Attachment{attachment}, nil
+	}
+
+	images, err := prepareImage(data, mimeType, *opts.ImageLimits, opts.MaxFrames)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	attachments := make([]Attachment, 0, len(images))
+	for _, img := range images {
+		attachment, err := NewBase64Attachment(AttachmentTypeImage, img.mimeType, base64.StdEncoding.EncodeToString(img.data))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+const mimeTypeUnknown = "application/octet-stream"
+
+// documentMimeTypes are the document types accepted besides text/*
+var documentMimeTypes = []string{
+	"application/pdf",
+	"application/json",
+	"application/xml",
+	"application/rtf",
+	"application/msword",
+	"application/epub+zip",
+	"application/vnd.ms-excel",
+	"application/vnd.ms-powerpoint",
+	"application/vnd.openxmlformats-officedocument.",
+	"application/vnd.oasis.opendocument.",
+}
+
+// attachmentTypeOf maps a MIME type to an attachment type, telling if the
+// loaders support it
+func attachmentTypeOf(mimeType string) (AttachmentType, bool) {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return AttachmentTypeImage, isDecodableImage(mimeType)
+	case strings.HasPrefix(mimeType, "audio/"):
+		return AttachmentTypeAudio, true
+	case strings.HasPrefix(mimeType, "video/"):
+		return AttachmentTypeVideo, true
+	case strings.HasPrefix(mimeType, "text/"):
+		return AttachmentTypeDocument, true
+	}
+
+	for _, prefix := range documentMimeTypes {
+		if strings.HasPrefix(mimeType, prefix) {
+			return AttachmentTypeDocument, true
+		}
+	}
+
+	return AttachmentTypeDocument, false
+}
+
+// extensionMimeTypes completes mime.TypeByExtension, whose table depends on
+// the system
+var extensionMimeTypes = map[string]string{
+	".md":   "text/markdown",
+	".csv":  "text/csv",
+	".txt":  "text/plain",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".odt":  "application/vnd.oasis.opendocument.text",
+	".ods":  "application/vnd.oasis.opendocument.spreadsheet",
+	".odp":  "application/vnd.oasis.opendocument.presentation",
+	".epub": "application/epub+zip",
+	".doc":  "application/msword",
+	".rtf":  "application/rtf",
+	".weba": "audio/webm",
+}
+
+// MimeTypeExtension returns the usual file extension of the MIME type, dot
+// included, or "" when unknown
+func MimeTypeExtension(mimeType string) string {
+	for ext, t := range extensionMimeTypes {
+		if t == mimeType {
+			return ext
+		}
+	}
+
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+
+	return ""
+}
+
+// DetectMimeType sniffs the MIME type of the data, the file name refining
+// generic results (text, zip containers). Returns "application/octet-stream"
+// when unrecognised.
+func DetectMimeType(data []byte, filename string) string {
+	if mediaType := DetectImageMediaType(data); mediaType != "" {
+		return mediaType
+	}
+
+	switch {
+	case len(data) >= 4 && (string(data[:4]) == "II*\x00" || string(data[:4]) == "MM\x00*"):
+		return "image/tiff"
+	case len(data) >= 2 && string(data[:2]) == "BM":
+		return "image/bmp"
+	}
+
+	byExtension := ""
+	if ext := strings.ToLower(filepath.Ext(filename)); ext != "" {
+		byExtension = extensionMimeTypes[ext]
+		if byExtension == "" {
+			byExtension, _, _ = mime.ParseMediaType(mime.TypeByExtension(ext))
+		}
+	}
+
+	if format := DetectAudioFormat(data); format != "" {
+		return audioMimeType(format, data, byExtension)
+	}
+
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+
+	switch sniffed {
+	case mimeTypeUnknown, "application/zip", "text/plain", "text/xml":
+		if byExtension != "" {
+			return byExtension
+		}
+	}
+
+	if sniffed == "" {
+		return mimeTypeUnknown
+	}
+
+	return sniffed
+}
+
+func audioMimeType(format AudioFormat, data []byte, byExtension string) string {
+	switch format {
+	case AudioFormatMP3:
+		return "audio/mpeg"
+	case AudioFormatWAV:
+		return "audio/wav"
+	case AudioFormatFLAC:
+		return "audio/flac"
+	case AudioFormatOGG:
+		return "audio/ogg"
+	case AudioFormatAAC:
+		return "audio/aac"
+	case AudioFormatM4A:
+		// MP4 containers hold audio only with the M4A/M4B brands
+		if brand := data[8:12]; bytes.Equal(brand, []byte("M4A ")) || bytes.Equal(brand, []byte("M4B ")) {
+			return "audio/mp4"
+		}
+		return "video/mp4"
+	case AudioFormatWEBM:
+		if byExtension == "audio/webm" {
+			return byExtension
+		}
+		return "video/webm"
+	}
+
+	return mimeTypeUnknown
+}
@@ -0,0 +1,395 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// testTIFF builds an uncompressed grayscale TIFF whose pages are filled with
+// the given levels.
+func testTIFF(width, height int, levels ...byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("II*\x00")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(8))
+
+	for i, level := range levels {
+		pixels := buf.Len() + tiffIFDSize
+
+		next := uint32(0)
+		if i < len(levels)-1 {
+			next = uint32(pixels + width*height)
+		}
+
+		writeTIFFIFD(&buf, width, height, uint32(pixels), next)
+		buf.Write(bytes.Repeat([]byte{level}, width*height))
+	}
+
+	return buf.Bytes()
+}
+
+// testTIFFHeaders builds a TIFF whose pages declare the dimensions without
+// carrying their pixels.
+func testTIFFHeaders(width, height, pages int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("II*\x00")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(8))
+
+	for i := range pages {
+		next := uint32(0)
+		if i < pages-1 {
+			next = uint32(buf.Len() + tiffIFDSize)
+		}
+
+		writeTIFFIFD(&buf, width, height, 8, next)
+	}
+
+	return buf.Bytes()
+}
+
+const tiffIFDSize = 2 + 9*12 + 4
+
+// writeTIFFIFD writes the image file directory of a grayscale page stored
+// in a single strip
+func writeTIFFIFD(buf *bytes.Buffer, width, height int, strip, next uint32) {
+	entries := [][3]uint32{
+		{256, 3, uint32(width)},
+		{257, 3, uint32(height)},
+		{258, 3, 8},
+		{259, 3, 1},
+		{262, 3, 1},
+		{273, 4, strip},
+		{277, 3, 1},
+		{278, 3, uint32(height)},
+		{279, 4, uint32(width * height)},
+	}
+
+	_ = binary.Write(buf, binary.LittleEndian, uint16(len(entries)))
+	for _, e := range entries {
+		_ = binary.Write(buf, binary.LittleEndian, uint16(e[0]))
+		_ = binary.Write(buf, binary.LittleEndian, uint16(e[1]))
+		_ = binary.Write(buf, binary.LittleEndian, uint32(1))
+		_ = binary.Write(buf, binary.LittleEndian, e[2])
+	}
+	_ = binary.Write(buf, binary.LittleEndian, next)
+}
+
+func decodeAttachment(t *testing.T, attachment Attachment) image.Image {
+	t.Helper()
+
+	data, err := base64.StdEncoding.DecodeString(attachment.Data())
+	if err != nil {
+		t.Fatalf("invalid base64: %v", err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("could not decode %s attachment: %v", attachment.MimeType(), err)
+	}
+
+	return img
+}
+
+func TestDetectMimeType(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		filename string
+		expected string
+	}{
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0d"), "", "image/png"},
+		{"tiff", testTIFF(2, 2, 0), "", "image/tiff"},
+		{"pdf", []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n"), "", "application/pdf"},
+		{"markdown", []byte("# Title\n\nSome text"), "notes.md", "text/markdown"},
+		{"plain text", []byte("Some text"), "", "text/plain"},
+		{"docx", []byte("PK\x03\x04\x14\x00\x06\x00"), "report.docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"wav", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), "", "audio/wav"},
+		{"unknown", []byte{0x00, 0x01, 0x02, 0x03, 0xfe, 0xff}, "", "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectMimeType(tt.data, tt.filename); got != tt.expected {
+				t.Errorf("DetectMimeType() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAttachmentFromReader_KeepsFittingImages(t *testing.T) {
+	data := testPNG(t, 64, 32)
+
+	attachments, err := AttachmentFromReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("AttachmentFromReader: %+v", err)
+	}
+
+	if len(attachments) != 1 || attachments[0].Type() != AttachmentTypeImage || attachments[0].MimeType() != "image/png" {
+		t.Fatalf("unexpected attachments %+v", attachments)
+	}
+	if attachments[0].Data() != base64.StdEncoding.EncodeToString(data) {
+		t.Error("an image within the limits should be left untouched")
+	}
+}
+
+func TestAttachmentFromReader_DownscalesImages(t *testing.T) {
+	data := testPNG(t, 600, 200)
+
+	attachments, err := AttachmentFromReader(bytes.NewReader(data), WithImageLimits(ImageLimits{
+		MaxWidth:  300,
+		MaxHeight: 300,
+		MimeTypes: []string{"image/png"},
+	}))
+	if err != nil {
+		t.Fatalf("AttachmentFromReader: %+v", err)
+	}
+
+	bounds := decodeAttachment(t, attachments[0]).Bounds()
+	if bounds.Dx() != 300 || bounds.Dy() != 100 {
+		t.Errorf("image resized to %dx%d, expected 300x100", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestAttachmentFromReader_FitsByteLimit(t *testing.T) {
+	// Noise compresses badly, forcing a lossy re-encoding
+	img := image.NewRGBA(image.Rect(0, 0, 512, 512))
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(rnd.IntN(256))
+		if i%4 == 3 {
+			img.Pix[i] = 255
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	const maxBytes = 50_000
+
+	attachments, err := AttachmentFromReader(&buf, WithImageLimits(ImageLimits{
+		MaxBytes:  maxBytes,
+		MimeTypes: []string{"image/png", "image/jpeg"},
+	}))
+	if err != nil {
+		t.Fatalf("AttachmentFromReader: %+v", err)
+	}
+
+	data, _ := base64.StdEncoding.DecodeString(attachments[0].Data())
+	if len(data) > maxBytes {
+		t.Errorf("image of %d bytes exceeds the limit", len(data))
+	}
+	if attachments[0].MimeType() != "image/jpeg" {
+		t.Errorf("opaque image re-encoded as %s, expected image/jpeg", attachments[0].MimeType())
+	}
+}
+
+func TestAttachmentFromReader_SplitsTIFFPages(t *testing.T) {
+	attachments, err := AttachmentFromReader(bytes.NewReader(testTIFF(8, 4, 10, 200)))
+	if err != nil {
+		t.Fatalf("AttachmentFromReader: %+v", err)
+	}
+
+	if len(attachments) != 2 {
+		t.Fatalf("expected 2 pages, got %d", len(attachments))
+	}
+
+	for i, level := range []uint8{10, 200} {
+		if attachments[i].MimeType() != "image/png" {
+			t.Errorf("page %d re-encoded as %s, expected image/png", i, attachments[i].MimeType())
+		}
+
+		gray := color.GrayModel.Convert(decodeAttachment(t, attachments[i]).At(0, 0)).(color.Gray)
+		if gray.Y != level {
+			t.Errorf("page %d has level %d, expected %d", i, gray.Y, level)
+		}
+	}
+}
+
+func TestAttachmentFromReader_SplitsGIFFrames(t *testing.T) {
+	palette := color.Palette{color.Black, color.White, color.RGBA{R: 255, A: 255}}
+
+	anim := &gif.GIF{}
+	for i := range 3 {
+		frame := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+		for p := range frame.Pix {
+			frame.Pix[p] = uint8(i)
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("gif.EncodeAll: %v", err)
+	}
+
+	attachments, err := AttachmentFromReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("AttachmentFromReader: %+v", err)
+	}
+	if len(attachments) != 3 {
+		t.Fatalf("expected 3 frames, got %d", len(attachments))
+	}
+
+	r, _, _, _ := decodeAttachment(t, attachments[2]).At(0, 0).RGBA()
+	if r>>8 != 255 {
+		t.Errorf("last frame not rendered, red = %d", r>>8)
+	}
+
+	attachments, err = AttachmentFromReader(bytes.NewReader(buf.Bytes()), WithMaxFrames(2))
+	if err != nil {
+		t.Fatalf("AttachmentFromReader: %+v", err)
+	}
+	if len(attachments) != 2 {
+		t.Errorf("expected 2 sampled frames, got %d", len(attachments))
+	}
+}
+
+// Small files can declare huge dimensions: they must be rejected from their
+// headers, before being decoded.
+func TestAttachmentFromReader_RejectsHugeImages(t *testing.T) {
+	// PNG whose header declares 50000x50000 pixels
+	var hugePNG bytes.Buffer
+	hugePNG.WriteString("\x89PNG\r\n\x1a\n")
+	ihdr := make([]byte, 0, 17)
+	ihdr = append(ihdr, "IHDR"...)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, 50000)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, 50000)
+	ihdr = append(ihdr, 8, 2, 0, 0, 0)
+	_ = binary.Write(&hugePNG, binary.BigEndian, uint32(len(ihdr)-4))
+	hugePNG.Write(ihdr)
+	_ = binary.Write(&hugePNG, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+
+	// GIF whose logical screen and only frame declare 60000x60000 pixels
+	var hugeGIF bytes.Buffer
+	hugeGIF.WriteString("GIF89a")
+	_ = binary.Write(&hugeGIF, binary.LittleEndian, [2]uint16{60000, 60000})
+	hugeGIF.Write([]byte{0x00, 0x00, 0x00})
+	hugeGIF.WriteByte(0x2C)
+	_ = binary.Write(&hugeGIF, binary.LittleEndian, [4]uint16{0, 0, 60000, 60000})
+	hugeGIF.Write([]byte{0x00, 0x02, 0x02, 0x4C, 0x01, 0x00, 0x3B})
+
+	// TIFF whose pages each fit the cap, but not together
+	hugeTIFF := testTIFFHeaders(5000, 5000, 3)
+
+	for name, data := range map[string][]byte{"png": hugePNG.Bytes(), "gif": hugeGIF.Bytes(), "tiff": hugeTIFF} {
+		t.Run(name, func(t *testing.T) {
+			_, err := AttachmentFromReader(bytes.NewReader(data))
+
+			var attachmentErr *AttachmentError
+			if !errors.As(err, &attachmentErr) || attachmentErr.Field != "size" {
+				t.Fatalf("expected a size AttachmentError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAttachmentFromReader_RejectsUnsupportedTypes(t *testing.T) {
+	_, err := AttachmentFromReader(bytes.NewReader([]byte{0x00, 0x01, 0x02, 0x03, 0xfe, 0xff}))
+
+	var attachmentErr *AttachmentError
+	if !errors.As(err, &attachmentErr) || attachmentErr.Field != "mime_type" {
+		t.Fatalf("expected a mime_type AttachmentError, got %v", err)
+	}
+}
+
+func TestAttachmentFromFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(filename, []byte("# Notes\n\n- item"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	attachments, err := AttachmentFromFile(filename)
+	if err != nil {
+		t.Fatalf("AttachmentFromFile: %+v", err)
+	}
+
+	if len(attachments) != 1 || attachments[0].Type() != AttachmentTypeDocument || attachments[0].MimeType() != "text/markdown" {
+		t.Fatalf("unexpected attachment %s %s", attachments[0].Type(), attachments[0].MimeType())
+	}
+}
+
+func TestAttachmentFromURL(t *testing.T) {
+	data := testPNG(t, 16, 16)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/image" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// Wrong declared type, sniffing wins
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	attachments, err := AttachmentFromURL(context.Background(), server.URL+"/image")
+	if err != nil {
+		t.Fatalf("AttachmentFromURL: %+v", err)
+	}
+	if len(attachments) != 1 || attachments[0].MimeType() != "image/png" {
+		t.Fatalf("unexpected attachments %+v", attachments)
+	}
+
+	if _, err := AttachmentFromURL(context.Background(), server.URL+"/missing"); err == nil {
+		t.Error("expected an error on a missing resource")
+	}
+}
+
+func TestPreprocessAttachment(t *testing.T) {
+	data := testPNG(t, 400, 100)
+
+	attachment, err := NewImageAttachment("image/png", "data:image/png;base64,"+base64.StdEncoding.EncodeToString(data), false)
+	if err != nil {
+		t.Fatalf("NewImageAttachment: %+v", err)
+	}
+
+	attachments, err := PreprocessAttachment(attachment, WithImageLimits(ImageLimits{MaxPixels: 10_000, MimeTypes: []string{"image/png"}}))
+	if err != nil {
+		t.Fatalf("PreprocessAttachment: %+v", err)
+	}
+
+	bounds := decodeAttachment(t, attachments[0]).Bounds()
+	if bounds.Dx()*bounds.Dy() > 10_000 {
+		t.Errorf("image of %dx%d exceeds the pixel limit", bounds.Dx(), bounds.Dy())
+	}
+
+	document, _ := NewDocumentAttachment("text/plain", base64.StdEncoding.EncodeToString([]byte("text")), false)
+	if kept, err := PreprocessAttachment(document); err != nil || len(kept) != 1 || kept[0] != Attachment(document) {
+		t.Errorf("non image attachments should be returned as is")
+	}
+}
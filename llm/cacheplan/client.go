@@ -0,0 +1,131 @@
+package cacheplan
+
+import (
+	"context"
+	"slices"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+// Client places the prompt cache breakpoints of the chat completions sent to
+// the wrapped client with a Planner, which tracks their cache hit rate.
+type Client struct {
+	client  llm.Client
+	planner *Planner
+}
+
+// ChatCompletion implements llm.Client.
+func (c *Client) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	res, err := c.client.ChatCompletion(ctx, c.plan(funcs)...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	c.planner.Record(ctx, res.Usage())
+
+	return res, nil
+}
+
+// ChatCompletionStream implements llm.Client.
+func (c *Client) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	stream, err := c.client.ChatCompletionStream(ctx, c.plan(funcs)...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	out := make(chan llm.StreamChunk)
+
+	go func() {
+		defer close(out)
+
+		for chunk := range stream {
+			if chunk.IsComplete() {
+				c.planner.Record(ctx, chunk.Usage())
+			}
+
+			select {
+			case <-ctx.Done():
+				// Release the producer of the interrupted stream
+				go func() {
+					for range stream {
+					}
+				}()
+				return
+			case out <- chunk:
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Embeddings implements llm.Client.
+func (c *Client) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	return c.client.Embeddings(ctx, inputs, funcs...)
+}
+
+// Transcription implements llm.Client.
+func (c *Client) Transcription(ctx context.Context, audio []byte, funcs ...llm.TranscriptionOptionFunc) (llm.TranscriptionResponse, error) {
+	return c.client.Transcription(ctx, audio, funcs...)
+}
+
+// ImageGeneration implements llm.ImageGenerationClient.
+func (c *Client) ImageGeneration(ctx context.Context, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return llm.DelegateImageGeneration(ctx, c.client, prompt, funcs...)
+}
+
+// ImageEdit implements llm.ImageEditClient.
+func (c *Client) ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return llm.DelegateImageEdit(ctx, c.client, image, prompt, funcs...)
+}
+
+// ImageVariation implements llm.ImageEditClient.
+func (c *Client) ImageVariation(ctx context.Context, image []byte, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return llm.DelegateImageVariation(ctx, c.client, image, funcs...)
+}
+
+// Complete implements llm.CompletionClient.
+func (c *Client) Complete(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	return llm.DelegateComplete(ctx, c.client, prompt, suffix, funcs...)
+}
+
+// CompleteStream implements llm.CompletionClient.
+func (c *Client) CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	return llm.DelegateCompleteStream(ctx, c.client, prompt, suffix, funcs...)
+}
+
+// ValidateAttachment implements llm.AttachmentValidator.
+func (c *Client) ValidateAttachment(attachment llm.Attachment) error {
+	return llm.DelegateValidateAttachment(c.client, attachment)
+}
+
+// Stats returns the cache statistics of the chat completions
+func (c *Client) Stats() Stats {
+	return c.planner.Stats()
+}
+
+func (c *Client) plan(funcs []llm.ChatCompletionOptionFunc) []llm.ChatCompletionOptionFunc {
+	return append(slices.Clone(funcs), c.planner.Option())
+}
+
+func NewClient(client llm.Client, funcs ...OptionFunc) *Client {
+	return NewClientWithPlanner(client, NewPlanner(funcs...))
+}
+
+// NewClientWithPlanner creates a client placing the breakpoints with the
+// planner, which may be shared with other clients or an agent loop
+func NewClientWithPlanner(client llm.Client, planner *Planner) *Client {
+	return &Client{
+		client:  client,
+		planner: planner,
+	}
+}
+
+var (
+	_ llm.Client                = &Client{}
+	_ llm.ImageGenerationClient = &Client{}
+	_ llm.ImageEditClient       = &Client{}
+	_ llm.CompletionClient      = &Client{}
+	_ llm.AttachmentValidator   = &Client{}
+)
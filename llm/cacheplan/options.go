@@ -0,0 +1,69 @@
+package cacheplan
+
+import "github.com/bornholm/genai/llm"
+
+// Limits are the constraints of a provider on the cache breakpoints
+type Limits struct {
+	// MaxBreakpoints is the number of breakpoints accepted in a request
+	MaxBreakpoints int
+	// MinPrefixTokens is the size under which a prefix is not cached, a
+	// breakpoint ending a smaller one being wasted
+	MinPrefixTokens int
+}
+
+// AnthropicLimits are the limits of the Anthropic models, accepting four
+// breakpoints and caching prefixes of at least 1024 tokens
+var AnthropicLimits = Limits{
+	MaxBreakpoints:  4,
+	MinPrefixTokens: 1024,
+}
+
+type Options struct {
+	Limits Limits
+	// CacheControl is the hint attached to the messages ending a cached
+	// prefix
+	CacheControl   *llm.CacheControl
+	TokenEstimator func(string) int
+}
+
+type OptionFunc func(opts *Options)
+
+func NewOptions(funcs ...OptionFunc) *Options {
+	opts := &Options{
+		Limits:         AnthropicLimits,
+		CacheControl:   &llm.CacheControl{Type: "ephemeral"},
+		TokenEstimator: defaultTokenEstimator,
+	}
+
+	for _, fn := range funcs {
+		fn(opts)
+	}
+
+	return opts
+}
+
+// WithLimits sets the limits of the provider
+func WithLimits(limits Limits) OptionFunc {
+	return func(opts *Options) {
+		opts.Limits = limits
+	}
+}
+
+// WithCacheControl sets the hint attached to the breakpoints, e.g. to
+// request a longer TTL
+func WithCacheControl(cc *llm.CacheControl) OptionFunc {
+	return func(opts *Options) {
+		opts.CacheControl = cc
+	}
+}
+
+// WithTokenEstimator sets the function estimating the size of the prefixes
+func WithTokenEstimator(estimator func(string) int) OptionFunc {
+	return func(opts *Options) {
+		opts.TokenEstimator = estimator
+	}
+}
+
+func defaultTokenEstimator(s string) int {
+	return len(s) / 4
+}
@@ -0,0 +1,215 @@
+package cacheplan
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/bornholm/genai/llm"
+)
+
+// Planner places the prompt cache breakpoints of the calls and tracks their
+// cache hit rate.
+//
+// The breakpoints go, within the limits of the provider, on:
+//
+//   - the system prompt, the cached prefix also covering the tool
+//     definitions which precede it;
+//   - the last message of the history, which prefixes the next call of a
+//     conversation or an agent loop;
+//   - the last message preceding the latest answer of the model, where the
+//     previous call placed its own, so that its cache is read.
+//
+// Breakpoints already set by the caller count against the limits.
+type Planner struct {
+	opts *Options
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Stats are the cache statistics of the calls
+type Stats struct {
+	Calls        int64
+	PromptTokens int64
+	CachedTokens int64
+}
+
+// HitRate returns the fraction of the prompt tokens served from the cache
+func (s Stats) HitRate() float64 {
+	if s.PromptTokens == 0 {
+		return 0
+	}
+
+	return float64(s.CachedTokens) / float64(s.PromptTokens)
+}
+
+// Plan returns the messages with the cache breakpoints
+func (p *Planner) Plan(messages []llm.Message, tools []llm.Tool) []llm.Message {
+	budget := p.opts.Limits.MaxBreakpoints
+	for _, m := range messages {
+		if cm, ok := m.(llm.CacheControlMessage); ok && cm.CacheControl() != nil {
+			budget--
+		}
+	}
+
+	if budget <= 0 {
+		return messages
+	}
+
+	// Estimated size of the prefix ending with each message, the tool
+	// definitions coming first
+	prefix := make([]int, len(messages))
+	size := p.toolsTokens(tools)
+	for i, m := range messages {
+		size += p.opts.TokenEstimator(m.Content())
+		prefix[i] = size
+	}
+
+	// The system prompt is the leading run of system messages; the system
+	// messages following the history (reminders, budgets...) change from a
+	// call to the next and are left out of the cached prefix.
+	systemEnd := 0
+	for systemEnd < len(messages) && messages[systemEnd].Role() == llm.RoleSystem {
+		systemEnd++
+	}
+
+	historyEnd := len(messages)
+	for historyEnd > systemEnd && messages[historyEnd-1].Role() == llm.RoleSystem {
+		historyEnd--
+	}
+
+	lastAnswer := -1
+	for i, m := range messages[:historyEnd] {
+		if m.Role() == llm.RoleAssistant || m.Role() == llm.RoleToolCalls {
+			lastAnswer = i
+		}
+	}
+
+	// Without system prompt, the first message ends the prefix of the tool
+	// definitions
+	first := p.lastCacheable(messages, systemEnd)
+	if systemEnd == 0 && len(tools) > 0 {
+		first = p.firstCacheable(messages)
+	}
+
+	candidates := []int{
+		first,
+		p.lastCacheable(messages, historyEnd),
+		p.lastCacheable(messages, lastAnswer),
+	}
+
+	planned := slices.Clone(messages)
+	var placed []int
+
+	for _, i := range candidates {
+		if budget == 0 {
+			break
+		}
+
+		if i < 0 || slices.Contains(placed, i) || prefix[i] < p.opts.Limits.MinPrefixTokens {
+			continue
+		}
+
+		message, ok := llm.WithCacheControl(messages[i], p.opts.CacheControl)
+		if !ok {
+			continue
+		}
+
+		planned[i] = message
+		placed = append(placed, i)
+		budget--
+	}
+
+	return planned
+}
+
+// Option returns the chat completion option placing the breakpoints, to be
+// given after the messages and tools
+func (p *Planner) Option() llm.ChatCompletionOptionFunc {
+	return func(opts *llm.ChatCompletionOptions) {
+		opts.Messages = p.Plan(opts.Messages, opts.Tools)
+	}
+}
+
+// Record adds the usage of a call to the statistics
+func (p *Planner) Record(ctx context.Context, usage llm.ChatCompletionUsage) {
+	if usage == nil {
+		return
+	}
+
+	var cached int64
+	if cu, ok := usage.(interface{ CachedTokens() int64 }); ok {
+		cached = cu.CachedTokens()
+	}
+
+	p.mu.Lock()
+	p.stats.Calls++
+	p.stats.PromptTokens += usage.PromptTokens()
+	p.stats.CachedTokens += cached
+	stats := p.stats
+	p.mu.Unlock()
+
+	slog.DebugContext(ctx, "prompt cache usage",
+		slog.Int64("prompt_tokens", usage.PromptTokens()),
+		slog.Int64("cached_tokens", cached),
+		slog.Float64("hit_rate", stats.HitRate()),
+	)
+}
+
+// Stats returns the cache statistics of the calls recorded so far
+func (p *Planner) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.stats
+}
+
+// lastCacheable returns the index of the last message before end able to
+// carry a breakpoint, -1 if none
+func (p *Planner) lastCacheable(messages []llm.Message, end int) int {
+	for i := end - 1; i >= 0; i-- {
+		if cacheable(messages[i]) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// cacheable reports whether the message can carry a breakpoint. The
+// providers only forward the hints of text contents, messages with
+// attachments or without content are skipped.
+func cacheable(m llm.Message) bool {
+	return m.Role() != llm.RoleToolCalls && m.Content() != "" && len(m.Attachments()) == 0
+}
+
+// firstCacheable returns the index of the first message able to carry a
+// breakpoint, -1 if none
+func (p *Planner) firstCacheable(messages []llm.Message) int {
+	for i, m := range messages {
+		if cacheable(m) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (p *Planner) toolsTokens(tools []llm.Tool) int {
+	var tokens int
+	for _, t := range tools {
+		parameters, _ := json.Marshal(t.Parameters())
+		tokens += p.opts.TokenEstimator(t.Name() + t.Description() + string(parameters))
+	}
+
+	return tokens
+}
+
+func NewPlanner(funcs ...OptionFunc) *Planner {
+	return &Planner{
+		opts: NewOptions(funcs...),
+	}
+}
@@ -0,0 +1,200 @@
+package cacheplan
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bornholm/genai/llm"
+)
+
+func breakpoints(messages []llm.Message) []int {
+	var indexes []int
+	for i, m := range messages {
+		if cm, ok := m.(llm.CacheControlMessage); ok && cm.CacheControl() != nil {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func TestPlan(t *testing.T) {
+	toolCall := llm.NewToolCall("1", "search", `{}`)
+
+	agentLoop := []llm.Message{
+		llm.NewMessage(llm.RoleSystem, "You are an agent."),
+		llm.NewMessage(llm.RoleUser, "Find the answer."),
+		llm.NewToolCallsMessage(toolCall),
+		llm.NewToolMessage("1", llm.NewToolResult("first result")),
+		llm.NewToolCallsMessage(toolCall),
+		llm.NewToolMessage("1", llm.NewToolResult("second result")),
+	}
+
+	type testCase struct {
+		Name     string
+		Messages []llm.Message
+		Tools    []llm.Tool
+		Limits   Limits
+		Expected []int
+	}
+
+	testCases := []testCase{
+		{
+			Name:     "agent loop",
+			Messages: agentLoop,
+			Limits:   Limits{MaxBreakpoints: 4},
+			Expected: []int{0, 3, 5},
+		},
+		{
+			Name: "trailing system messages",
+			Messages: append(slices.Clone(agentLoop),
+				llm.NewMessage(llm.RoleSystem, "Iteration 3 of 10."),
+			),
+			Limits:   Limits{MaxBreakpoints: 4},
+			Expected: []int{0, 3, 5},
+		},
+		{
+			Name:     "limited breakpoints",
+			Messages: agentLoop,
+			Limits:   Limits{MaxBreakpoints: 2},
+			Expected: []int{0, 5},
+		},
+		{
+			Name: "caller breakpoints count against the limits",
+			Messages: []llm.Message{
+				llm.NewMessageWithCacheControl(llm.RoleSystem, "You are an agent.", &llm.CacheControl{Type: "ephemeral"}),
+				llm.NewMessage(llm.RoleUser, "Hello"),
+			},
+			Limits:   Limits{MaxBreakpoints: 1},
+			Expected: []int{0},
+		},
+		{
+			Name: "small prefixes are not cached",
+			Messages: []llm.Message{
+				llm.NewMessage(llm.RoleSystem, "Short."),
+				llm.NewMessage(llm.RoleUser, "This message is long enough to reach the minimum size."),
+			},
+			Limits:   Limits{MaxBreakpoints: 4, MinPrefixTokens: 10},
+			Expected: []int{1},
+		},
+		{
+			Name: "tool definitions without system prompt",
+			Messages: []llm.Message{
+				llm.NewMessage(llm.RoleUser, "Hello"),
+				llm.NewMessage(llm.RoleAssistant, "Hi"),
+				llm.NewMessage(llm.RoleUser, "Search"),
+			},
+			Tools:    []llm.Tool{llm.NewFuncTool("search", "Search the web", map[string]any{"type": "object"}, nil)},
+			Limits:   Limits{MaxBreakpoints: 4},
+			Expected: []int{0, 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			planner := NewPlanner(WithLimits(tc.Limits))
+
+			planned := planner.Plan(tc.Messages, tc.Tools)
+
+			got := breakpoints(planned)
+			if len(got) != len(tc.Expected) {
+				t.Fatalf("expected breakpoints %v, got %v", tc.Expected, got)
+			}
+			for i := range got {
+				if got[i] != tc.Expected[i] {
+					t.Fatalf("expected breakpoints %v, got %v", tc.Expected, got)
+				}
+			}
+
+			if _, ok := planned[len(planned)-1].(llm.ToolMessage); tc.Name == "agent loop" && !ok {
+				t.Errorf("expected the tool message to keep its type, got %T", planned[len(planned)-1])
+			}
+
+			if before := breakpoints(tc.Messages); len(before) > 0 && before[0] != 0 {
+				t.Errorf("expected the original messages to be left untouched")
+			}
+		})
+	}
+}
+
+type usageClient struct {
+	llm.Client
+	messages []llm.Message
+}
+
+func (c *usageClient) ChatCompletion(_ context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	c.messages = llm.NewChatCompletionOptions(funcs...).Messages
+	return llm.NewChatCompletionResponse(llm.NewMessage(llm.RoleAssistant, "ok"), llm.NewChatCompletionUsageWithCache(1000, 10, 1010, 750)), nil
+}
+
+func TestClient(t *testing.T) {
+	inner := &usageClient{}
+	client := NewClient(inner, WithLimits(Limits{MaxBreakpoints: 4}))
+
+	for range 2 {
+		_, err := client.ChatCompletion(context.Background(), llm.WithMessages(
+			llm.NewMessage(llm.RoleSystem, "You are an assistant."),
+			llm.NewMessage(llm.RoleUser, "Hello"),
+		))
+		if err != nil {
+			t.Fatalf("ChatCompletion: %+v", err)
+		}
+	}
+
+	if got := breakpoints(inner.messages); len(got) != 2 {
+		t.Errorf("expected 2 breakpoints, got %v", got)
+	}
+
+	stats := client.Stats()
+	if stats.Calls != 2 || stats.PromptTokens != 2000 || stats.CachedTokens != 1500 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if stats.HitRate() != 0.75 {
+		t.Errorf("expected a hit rate of 0.75, got %v", stats.HitRate())
+	}
+}
+
+// streamClient streams chunks until done, the end of the stream being
+// signaled
+type streamClient struct {
+	llm.Client
+	done chan struct{}
+}
+
+func (c *streamClient) ChatCompletionStream(_ context.Context, funcs ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	stream := make(chan llm.StreamChunk)
+
+	go func() {
+		defer close(c.done)
+		defer close(stream)
+
+		for range 3 {
+			stream <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, "ok"))
+		}
+	}()
+
+	return stream, nil
+}
+
+func TestClient_StreamCanceled(t *testing.T) {
+	inner := &streamClient{done: make(chan struct{})}
+	client := NewClient(inner)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := client.ChatCompletionStream(ctx, llm.WithMessages(llm.NewMessage(llm.RoleUser, "Hello")))
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %+v", err)
+	}
+
+	<-stream
+	cancel()
+
+	// The producer of the interrupted stream is released
+	select {
+	case <-inner.done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the inner stream to be drained")
+	}
+}
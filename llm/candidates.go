@@ -0,0 +1,146 @@
+package llm
+
+// FinishReason tells why the model stopped generating a candidate
+type FinishReason string
+
+const (
+	FinishReasonStop          FinishReason = "stop"
+	FinishReasonLength        FinishReason = "length"
+	FinishReasonToolCalls     FinishReason = "tool_calls"
+	FinishReasonContentFilter FinishReason = "content_filter"
+)
+
+// TokenLogprob is the log-probability of a generated token.
+type TokenLogprob struct {
+	// Token is the text of the token
+	Token string
+	// Logprob is the natural logarithm of the probability of the token
+	Logprob float64
+	// TopLogprobs are the most likely tokens at this position, most likely
+	// first, when requested with a positive topK (see [WithLogprobs]).
+	TopLogprobs []TopLogprob
+}
+
+// TopLogprob is one of the most likely tokens at a position
+type TopLogprob struct {
+	Token   string
+	Logprob float64
+}
+
+// Candidate is one of the choices generated for a chat completion.
+type Candidate interface {
+	// Index is the position of the candidate among the choices
+	Index() int
+	Message() Message
+	ToolCalls() []ToolCall
+	FinishReason() FinishReason
+	// Logprobs returns the log-probabilities of the generated tokens, nil
+	// unless requested with [WithLogprobs].
+	Logprobs() []TokenLogprob
+}
+
+// CandidatesResponse extends ChatCompletionResponse with every generated
+// choice. Providers supporting [WithCandidates] or [WithLogprobs] implement
+// it; the response itself carries the first candidate. Callers can
+// type-assert to access the candidates, or use [CandidatesOf].
+type CandidatesResponse interface {
+	ChatCompletionResponse
+	Candidates() []Candidate
+}
+
+// BaseCandidate provides a base implementation of Candidate
+type BaseCandidate struct {
+	index        int
+	message      Message
+	toolCalls    []ToolCall
+	finishReason FinishReason
+	logprobs     []TokenLogprob
+}
+
+// Index implements Candidate
+func (c *BaseCandidate) Index() int {
+	return c.index
+}
+
+// Message implements Candidate
+func (c *BaseCandidate) Message() Message {
+	return c.message
+}
+
+// ToolCalls implements Candidate
+func (c *BaseCandidate) ToolCalls() []ToolCall {
+	return c.toolCalls
+}
+
+// FinishReason implements Candidate
+func (c *BaseCandidate) FinishReason() FinishReason {
+	return c.finishReason
+}
+
+// Logprobs implements Candidate
+func (c *BaseCandidate) Logprobs() []TokenLogprob {
+	return c.logprobs
+}
+
+var _ Candidate = &BaseCandidate{}
+
+func NewCandidate(index int, message Message, finishReason FinishReason, logprobs []TokenLogprob, toolCalls ...ToolCall) *BaseCandidate {
+	return &BaseCandidate{
+		index:        index,
+		message:      message,
+		toolCalls:    toolCalls,
+		finishReason: finishReason,
+		logprobs:     logprobs,
+	}
+}
+
+// BaseCandidatesResponse is a chat completion response carrying all the
+// generated candidates
+type BaseCandidatesResponse struct {
+	*BaseChatCompletionResponse
+	candidates []Candidate
+}
+
+// Candidates implements CandidatesResponse
+func (r *BaseCandidatesResponse) Candidates() []Candidate {
+	return r.candidates
+}
+
+var (
+	_ CandidatesResponse              = &BaseCandidatesResponse{}
+	_ ReasoningChatCompletionResponse = &BaseCandidatesResponse{}
+)
+
+// NewCandidatesResponse creates a response carrying the candidates, res being
+// the response built from the first one.
+func NewCandidatesResponse(res *BaseChatCompletionResponse, candidates ...Candidate) *BaseCandidatesResponse {
+	return &BaseCandidatesResponse{
+		BaseChatCompletionResponse: res,
+		candidates:                 candidates,
+	}
+}
+
+// CandidatesOf returns the candidates of the response. A response not
+// implementing CandidatesResponse is its own single candidate.
+func CandidatesOf(res ChatCompletionResponse) []Candidate {
+	if cr, ok := res.(CandidatesResponse); ok && len(cr.Candidates()) > 0 {
+		return cr.Candidates()
+	}
+
+	finishReason := FinishReasonStop
+	if len(res.ToolCalls()) > 0 {
+		finishReason = FinishReasonToolCalls
+	}
+
+	return []Candidate{NewCandidate(0, res.Message(), finishReason, nil, res.ToolCalls()...)}
+}
+
+// SumLogprobs returns the log-probability of the whole sequence of tokens,
+// the usual confidence score of a candidate.
+func SumLogprobs(logprobs []TokenLogprob) float64 {
+	var sum float64
+	for _, lp := range logprobs {
+		sum += lp.Logprob
+	}
+	return sum
+}
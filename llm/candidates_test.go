@@ -0,0 +1,53 @@
+package llm
+
+import "testing"
+
+func TestCandidatesOf(t *testing.T) {
+	usage := NewChatCompletionUsage(1, 1, 2)
+
+	t.Run("a plain response is its own candidate", func(t *testing.T) {
+		res := NewChatCompletionResponse(NewMessage(RoleAssistant, "hello"), usage, NewToolCall("1", "search", "{}"))
+
+		candidates := CandidatesOf(res)
+		if len(candidates) != 1 {
+			t.Fatalf("expected 1 candidate, got %d", len(candidates))
+		}
+		if candidates[0].Message().Content() != "hello" {
+			t.Errorf("unexpected content %q", candidates[0].Message().Content())
+		}
+		if candidates[0].FinishReason() != FinishReasonToolCalls {
+			t.Errorf("expected finish reason %q, got %q", FinishReasonToolCalls, candidates[0].FinishReason())
+		}
+	})
+
+	t.Run("a candidates response exposes its candidates", func(t *testing.T) {
+		first := NewCandidate(0, NewMessage(RoleAssistant, "a"), FinishReasonStop, []TokenLogprob{{Token: "a", Logprob: -0.5}, {Token: ".", Logprob: -0.25}})
+		second := NewCandidate(1, NewMessage(RoleAssistant, "b"), FinishReasonLength, nil)
+		res := NewCandidatesResponse(NewChatCompletionResponse(first.Message(), usage), first, second)
+
+		candidates := CandidatesOf(res)
+		if len(candidates) != 2 {
+			t.Fatalf("expected 2 candidates, got %d", len(candidates))
+		}
+		if candidates[1].FinishReason() != FinishReasonLength {
+			t.Errorf("expected finish reason %q, got %q", FinishReasonLength, candidates[1].FinishReason())
+		}
+		if sum := SumLogprobs(candidates[0].Logprobs()); sum != -0.75 {
+			t.Errorf("expected a sum of -0.75, got %v", sum)
+		}
+	})
+}
+
+func TestCandidatesOptionsValidate(t *testing.T) {
+	messages := WithMessages(NewMessage(RoleUser, "hi"))
+
+	if err := NewChatCompletionOptions(messages, WithLogprobs(5), WithCandidates(3)).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := NewChatCompletionOptions(messages, WithLogprobs(21)).Validate(); err == nil {
+		t.Error("expected an error for more than 20 top logprobs")
+	}
+	if err := NewChatCompletionOptions(messages, WithCandidates(-1)).Validate(); err == nil {
+		t.Error("expected an error for a negative number of candidates")
+	}
+}
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// The clients wrapping another [Client] (retry, rate limiting, hooks...)
+// implement the optional interfaces — [ImageGenerationClient],
+// [ImageEditClient], [CompletionClient] and [AttachmentValidator] — whatever
+// the client they wrap, so that type assertions survive any stack of
+// wrappers. Like provider.Client, they fail with [ErrUnavailable] when the
+// wrapped client lacks the capability.
+//
+// The Delegate* functions below implement this forwarding.
+
+// DelegateImageGeneration generates images with the client, failing with
+// [ErrUnavailable] when it does not implement [ImageGenerationClient].
+func DelegateImageGeneration(ctx context.Context, client any, prompt string, funcs ...ImageGenerationOptionFunc) (ImageGenerationResponse, error) {
+	generator, ok := client.(ImageGenerationClient)
+	if !ok {
+		return nil, errors.WithStack(ErrUnavailable)
+	}
+
+	return generator.ImageGeneration(ctx, prompt, funcs...)
+}
+
+// DelegateImageEdit edits the image with the client, failing with
+// [ErrUnavailable] when it does not implement [ImageEditClient].
+func DelegateImageEdit(ctx context.Context, client any, image []byte, prompt string, funcs ...ImageGenerationOptionFunc) (ImageGenerationResponse, error) {
+	editor, ok := client.(ImageEditClient)
+	if !ok {
+		return nil, errors.WithStack(ErrUnavailable)
+	}
+
+	return editor.ImageEdit(ctx, image, prompt, funcs...)
+}
+
+// DelegateImageVariation creates variations of the image with the client,
+// failing with [ErrUnavailable] when it does not implement [ImageEditClient].
+func DelegateImageVariation(ctx context.Context, client any, image []byte, funcs ...ImageGenerationOptionFunc) (ImageGenerationResponse, error) {
+	editor, ok := client.(ImageEditClient)
+	if !ok {
+		return nil, errors.WithStack(ErrUnavailable)
+	}
+
+	return editor.ImageVariation(ctx, image, funcs...)
+}
+
+// DelegateValidateAttachment validates the attachment with the client. Like
+// provider.Client, attachments are left to the provider when the client does
+// not implement [AttachmentValidator].
+func DelegateValidateAttachment(client any, attachment Attachment) error {
+	validator, ok := client.(AttachmentValidator)
+	if !ok {
+		return nil
+	}
+
+	return validator.ValidateAttachment(attachment)
+}
+
+// DelegateComplete completes the text with the client, failing with
+// [ErrUnavailable] when it does not implement [CompletionClient].
+func DelegateComplete(ctx context.Context, client any, prompt string, suffix string, funcs ...CompletionOptionFunc) (CompletionResponse, error) {
+	completer, ok := client.(CompletionClient)
+	if !ok {
+		return nil, errors.WithStack(ErrUnavailable)
+	}
+
+	return completer.Complete(ctx, prompt, suffix, funcs...)
+}
+
+// DelegateCompleteStream streams the completion of the text with the client,
+// failing with [ErrUnavailable] when it does not implement
+// [CompletionClient].
+func DelegateCompleteStream(ctx context.Context, client any, prompt string, suffix string, funcs ...CompletionOptionFunc) (<-chan StreamChunk, error) {
+	completer, ok := client.(CompletionClient)
+	if !ok {
+		return nil, errors.WithStack(ErrUnavailable)
+	}
+
+	return completer.CompleteStream(ctx, prompt, suffix, funcs...)
+}
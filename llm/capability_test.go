@@ -0,0 +1,249 @@
+package llm_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bornholm/genai/extract"
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/anonymize"
+	"github.com/bornholm/genai/llm/cacheplan"
+	"github.com/bornholm/genai/llm/circuitbreaker"
+	"github.com/bornholm/genai/llm/docextract"
+	"github.com/bornholm/genai/llm/guard"
+	"github.com/bornholm/genai/llm/hook"
+	"github.com/bornholm/genai/llm/longaudio"
+	"github.com/bornholm/genai/llm/ratelimit"
+	"github.com/bornholm/genai/llm/retry"
+	"github.com/bornholm/genai/llm/tokenlimit"
+	"github.com/pkg/errors"
+)
+
+// capableClient implements llm.Client and every optional interface
+type capableClient struct {
+	calls []string
+}
+
+func (c *capableClient) ChatCompletion(_ context.Context, _ ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	c.calls = append(c.calls, "chat")
+	return llm.NewChatCompletionResponse(llm.NewMessage(llm.RoleAssistant, "ok"), llm.NewChatCompletionUsageWithCost(1, 1, 2, 0, 0.5, "USD")), nil
+}
+
+func (c *capableClient) ChatCompletionStream(_ context.Context, _ ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	stream := make(chan llm.StreamChunk, 1)
+	stream <- llm.NewCompleteStreamChunk(nil)
+	close(stream)
+	return stream, nil
+}
+
+func (c *capableClient) Embeddings(_ context.Context, _ []string, _ ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	return nil, errors.WithStack(llm.ErrUnavailable)
+}
+
+func (c *capableClient) Transcription(_ context.Context, _ []byte, _ ...llm.TranscriptionOptionFunc) (llm.TranscriptionResponse, error) {
+	return nil, errors.WithStack(llm.ErrUnavailable)
+}
+
+func (c *capableClient) ImageGeneration(_ context.Context, prompt string, _ ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	c.calls = append(c.calls, "generation:"+prompt)
+	return llm.NewImageGenerationResponse([]llm.GeneratedImage{llm.NewGeneratedImage([]byte("image"), "image/png", "")}, nil), nil
+}
+
+func (c *capableClient) ImageEdit(_ context.Context, _ []byte, prompt string, _ ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	c.calls = append(c.calls, "edit:"+prompt)
+	return llm.NewImageGenerationResponse(nil, nil), nil
+}
+
+func (c *capableClient) ImageVariation(_ context.Context, _ []byte, _ ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	c.calls = append(c.calls, "variation")
+	return llm.NewImageGenerationResponse(nil, nil), nil
+}
+
+func (c *capableClient) Complete(_ context.Context, prompt string, suffix string, _ ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	c.calls = append(c.calls, "complete:"+prompt+suffix)
+	return llm.NewCompletionResponse("b", llm.FinishReasonStop, nil), nil
+}
+
+func (c *capableClient) CompleteStream(_ context.Context, prompt string, suffix string, _ ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	c.calls = append(c.calls, "stream:"+prompt+suffix)
+	stream := make(chan llm.StreamChunk, 2)
+	stream <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, "b"))
+	stream <- llm.NewCompleteStreamChunk(nil)
+	close(stream)
+	return stream, nil
+}
+
+func (c *capableClient) ValidateAttachment(attachment llm.Attachment) error {
+	if attachment.Type() != llm.AttachmentTypeImage {
+		return errors.New("only images")
+	}
+	return nil
+}
+
+// basicClient only implements llm.Client
+type basicClient struct {
+	llm.Client
+}
+
+type stubExtractor struct{}
+
+func (stubExtractor) Text(_ context.Context, _ ...extract.TextOptionFunc) (extract.TextResponse, error) {
+	return nil, errors.WithStack(llm.ErrUnavailable)
+}
+
+type wrapper struct {
+	name string
+	wrap func(client llm.Client) llm.Client
+}
+
+var wrappers = []wrapper{
+	{"retry", func(client llm.Client) llm.Client { return retry.NewClient(client, time.Millisecond, 1) }},
+	{"ratelimit", func(client llm.Client) llm.Client {
+		return ratelimit.NewClient(client, ratelimit.WithChatLimit(time.Millisecond, 10), ratelimit.WithImageLimit(time.Millisecond, 10))
+	}},
+	{"tokenlimit", func(client llm.Client) llm.Client { return tokenlimit.NewClient(client) }},
+	{"circuitbreaker", func(client llm.Client) llm.Client { return circuitbreaker.NewClient(client, 3, time.Second) }},
+	{"hook", func(client llm.Client) llm.Client { return hook.NewClient(client) }},
+	{"guard", func(client llm.Client) llm.Client { return guard.NewClient(client) }},
+	{"anonymize", func(client llm.Client) llm.Client { return anonymize.NewClient(client) }},
+	{"longaudio", func(client llm.Client) llm.Client { return longaudio.NewClient(client) }},
+	{"docextract", func(client llm.Client) llm.Client { return docextract.NewClient(client, stubExtractor{}) }},
+	{"cacheplan", func(client llm.Client) llm.Client { return cacheplan.NewClient(client) }},
+}
+
+// stacks returns each wrapper alone, then all of them in order and in
+// reverse order
+func stacks() map[string]func(client llm.Client) llm.Client {
+	stacks := map[string]func(client llm.Client) llm.Client{}
+
+	for _, w := range wrappers {
+		stacks[w.name] = w.wrap
+	}
+
+	stacks["all"] = func(client llm.Client) llm.Client {
+		for _, w := range wrappers {
+			client = w.wrap(client)
+		}
+		return client
+	}
+
+	stacks["all-reversed"] = func(client llm.Client) llm.Client {
+		for i := len(wrappers) - 1; i >= 0; i-- {
+			client = wrappers[i].wrap(client)
+		}
+		return client
+	}
+
+	return stacks
+}
+
+func TestWrappersPreserveCapabilities(t *testing.T) {
+	ctx := context.Background()
+
+	for name, stack := range stacks() {
+		t.Run(name, func(t *testing.T) {
+			inner := &capableClient{}
+			client := stack(inner)
+
+			generator, ok := client.(llm.ImageGenerationClient)
+			if !ok {
+				t.Fatalf("%T does not implement llm.ImageGenerationClient", client)
+			}
+
+			res, err := generator.ImageGeneration(ctx, "a cat")
+			if err != nil {
+				t.Fatalf("ImageGeneration: %+v", err)
+			}
+			if len(res.Images()) != 1 {
+				t.Errorf("expected 1 image, got %d", len(res.Images()))
+			}
+
+			editor, ok := client.(llm.ImageEditClient)
+			if !ok {
+				t.Fatalf("%T does not implement llm.ImageEditClient", client)
+			}
+			if _, err := editor.ImageEdit(ctx, []byte("image"), "a dog"); err != nil {
+				t.Fatalf("ImageEdit: %+v", err)
+			}
+			if _, err := editor.ImageVariation(ctx, []byte("image")); err != nil {
+				t.Fatalf("ImageVariation: %+v", err)
+			}
+
+			completer, ok := client.(llm.CompletionClient)
+			if !ok {
+				t.Fatalf("%T does not implement llm.CompletionClient", client)
+			}
+			completion, err := completer.Complete(ctx, "a", "c")
+			if err != nil {
+				t.Fatalf("Complete: %+v", err)
+			}
+			if completion.Text() != "b" {
+				t.Errorf("expected completion 'b', got '%s'", completion.Text())
+			}
+			stream, err := completer.CompleteStream(ctx, "a", "c")
+			if err != nil {
+				t.Fatalf("CompleteStream: %+v", err)
+			}
+			var streamed string
+			for chunk := range stream {
+				if err := chunk.Error(); err != nil {
+					t.Fatalf("CompleteStream: %+v", err)
+				}
+				if chunk.Delta() != nil {
+					streamed += chunk.Delta().Content()
+				}
+			}
+			if streamed != "b" {
+				t.Errorf("expected streamed completion 'b', got '%s'", streamed)
+			}
+
+			if got, expected := fmt.Sprint(inner.calls), "[generation:a cat edit:a dog variation complete:ac stream:ac]"; got != expected {
+				t.Errorf("calls %s, expected %s", got, expected)
+			}
+
+			validator, ok := client.(llm.AttachmentValidator)
+			if !ok {
+				t.Fatalf("%T does not implement llm.AttachmentValidator", client)
+			}
+			audio, err := llm.NewAudioAttachment("audio/wav", "AAAA", false)
+			if err != nil {
+				t.Fatalf("NewAudioAttachment: %+v", err)
+			}
+			if err := validator.ValidateAttachment(audio); err == nil {
+				t.Errorf("expected the wrapped validator to reject the audio attachment")
+			}
+
+			chat, err := client.ChatCompletion(ctx, llm.WithMessages(llm.NewMessage(llm.RoleUser, "hello")))
+			if err != nil {
+				t.Fatalf("ChatCompletion: %+v", err)
+			}
+			usage, ok := chat.Usage().(llm.CostReportingUsage)
+			if !ok {
+				t.Fatalf("usage %T does not implement llm.CostReportingUsage", chat.Usage())
+			}
+			if cost, _, ok := usage.Cost(); !ok || cost != 0.5 {
+				t.Errorf("expected a cost of 0.5, got %v", cost)
+			}
+		})
+	}
+}
+
+func TestWrappersReportUnavailableCapabilities(t *testing.T) {
+	for name, stack := range stacks() {
+		t.Run(name, func(t *testing.T) {
+			client := stack(&basicClient{&capableClient{}})
+
+			_, err := client.(llm.ImageGenerationClient).ImageGeneration(context.Background(), "a cat")
+			if !errors.Is(err, llm.ErrUnavailable) {
+				t.Errorf("expected llm.ErrUnavailable, got %v", err)
+			}
+
+			_, err = client.(llm.CompletionClient).CompleteStream(context.Background(), "a", "c")
+			if !errors.Is(err, llm.ErrUnavailable) {
+				t.Errorf("expected llm.ErrUnavailable, got %v", err)
+			}
+		})
+	}
+}
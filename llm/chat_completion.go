@@ -30,6 +30,9 @@ type AudioOutputConfig struct {
 }
 
 type ChatCompletionOptions struct {
+	// Model overrides the model configured on the client for this call.
+	// Empty means the client's model.
+	Model               string
 	Messages            []Message
 	Tools               []Tool
 	ToolChoice          ToolChoice
@@ -42,6 +45,26 @@ type ChatCompletionOptions struct {
 	Modalities          []string
 	Audio               *AudioOutputConfig
 	SessionID           string
+	// Logprobs requests the log-probabilities of the generated tokens, with
+	// the TopLogprobs most likely alternatives at each position.
+	Logprobs    bool
+	TopLogprobs int
+	// Candidates is the number of choices to generate. Zero means one.
+	Candidates int
+	// Sampling controls, nil or empty meaning the provider's default. See
+	// [SamplingSetting] for the settings a provider may not honour.
+	TopP             *float64
+	TopK             *int
+	MinP             *float64
+	Stop             []string
+	FrequencyPenalty *float64
+	PresencePenalty  *float64
+	// LogitBias maps token IDs, in the tokenizer of the model, to a bias
+	// between -100 and 100 added to their logits.
+	LogitBias map[int]float64
+	// Strictness tells how providers handle the sampling settings they cannot
+	// honour. Empty means StrictnessWarn.
+	Strictness Strictness
 	// ExtraFields carries arbitrary provider-specific key/values to inject
 	// verbatim into the request body (e.g. MiniMax's "reasoning_split").
 	// Providers that support it merge these into the outgoing JSON payload.
@@ -56,6 +79,15 @@ func (opts *ChatCompletionOptions) Validate() error {
 	if opts.MaxCompletionTokens != nil && *opts.MaxCompletionTokens <= 0 {
 		return NewValidationError("max_completion_tokens", "max completion tokens must be positive")
 	}
+	if opts.TopLogprobs < 0 || opts.TopLogprobs > 20 {
+		return NewValidationError("top_logprobs", "top logprobs must be between 0 and 20")
+	}
+	if opts.Candidates < 0 {
+		return NewValidationError("candidates", "candidates must be positive")
+	}
+	if err := opts.validateSampling(); err != nil {
+		return err
+	}
 	if len(opts.Messages) == 0 {
 		return NewValidationError("messages", "at least one message is required")
 	}
@@ -105,6 +137,14 @@ func NewChatCompletionOptions(funcs ...ChatCompletionOptionFunc) *ChatCompletion
 
 type ChatCompletionOptionFunc func(opts *ChatCompletionOptions)
 
+// WithModel overrides the model configured on the client for this call.
+// Providers bound to a single local model, such as yzma, ignore it.
+func WithModel(model string) ChatCompletionOptionFunc {
+	return func(opts *ChatCompletionOptions) {
+		opts.Model = model
+	}
+}
+
 func WithToolChoice(choice ToolChoice) ChatCompletionOptionFunc {
 	return func(opts *ChatCompletionOptions) {
 		opts.ToolChoice = choice
@@ -153,6 +193,24 @@ func WithMessages(messages ...Message) ChatCompletionOptionFunc {
 	}
 }
 
+// WithLogprobs requests the log-probabilities of the generated tokens, with
+// the topK most likely alternatives at each position (0 to 20). They are
+// exposed by the responses implementing [CandidatesResponse].
+func WithLogprobs(topK int) ChatCompletionOptionFunc {
+	return func(opts *ChatCompletionOptions) {
+		opts.Logprobs = true
+		opts.TopLogprobs = topK
+	}
+}
+
+// WithCandidates requests n choices, exposed by the responses implementing
+// [CandidatesResponse]. Streaming calls only generate the first one.
+func WithCandidates(n int) ChatCompletionOptionFunc {
+	return func(opts *ChatCompletionOptions) {
+		opts.Candidates = n
+	}
+}
+
 func WithSessionID(id string) ChatCompletionOptionFunc {
 	return func(opts *ChatCompletionOptions) {
 		opts.SessionID = id
@@ -161,7 +219,10 @@ func WithSessionID(id string) ChatCompletionOptionFunc {
 
 // WithExtraFields adds arbitrary provider-specific key/values that are injected
 // verbatim into the request body. Keys already present in ExtraFields are
-// overwritten. Only providers that opt in (openai, mistral) forward them.
+// overwritten. Only providers that opt in (openai, mistral, openrouter)
+// forward them.
+// The sampling controls have their own options, honoured by every provider
+// (see [WithTopP] and the following).
 func WithExtraFields(fields map[string]any) ChatCompletionOptionFunc {
 	return func(opts *ChatCompletionOptions) {
 		if len(fields) == 0 {
@@ -278,6 +339,7 @@ type BaseMessage struct {
 	role         Role
 	content      string
 	cacheControl *CacheControl
+	citations    []Citation
 }
 
 // Content implements Message.
@@ -320,6 +382,36 @@ func NewMessageWithCacheControl(role Role, content string, cc *CacheControl) *Ba
 	}
 }
 
+// WithCacheControl returns a copy of the message carrying the cache hint,
+// whatever its kind (tool result, reasoning...). It reports false and
+// returns the message as is when its type cannot carry one.
+func WithCacheControl(message Message, cc *CacheControl) (Message, bool) {
+	switch m := message.(type) {
+	case *BaseMessage:
+		clone := *m
+		clone.cacheControl = cc
+		return &clone, true
+	case *MultimodalMessage:
+		clone := *m
+		clone.cacheControl = cc
+		return &clone, true
+	case *BaseToolMessage:
+		clone := *m
+		clone.cacheControl = cc
+		return &clone, true
+	case *BaseToolCallsMessage:
+		clone := *m
+		clone.cacheControl = cc
+		return &clone, true
+	case *BaseAssistantReasoningMessage:
+		clone := *m
+		clone.cacheControl = cc
+		return &clone, true
+	default:
+		return message, false
+	}
+}
+
 // MultimodalMessage represents a message with both text content and attachments
 type MultimodalMessage struct {
 	BaseMessage
@@ -477,6 +569,15 @@ type CostReportingUsage interface {
 	Cost() (amount float64, currency string, ok bool)
 }
 
+// ImageReportingUsage is satisfied by usage objects from providers that report
+// the prompt tokens spent on input images (e.g. yzma). Callers can type-assert
+// ChatCompletionUsage to this interface to access it.
+type ImageReportingUsage interface {
+	// ImageTokens returns the number of prompt tokens of the input images,
+	// included in PromptTokens.
+	ImageTokens() int64
+}
+
 type BaseChatCompletionResponse struct {
 	message          Message
 	toolCalls        []ToolCall
@@ -539,6 +640,7 @@ type BaseChatCompletionUsage struct {
 	promptTokens     int64
 	completionTokens int64
 	cachedTokens     int64
+	imageTokens      int64
 	cost             *float64
 	costCurrency     string
 }
@@ -563,6 +665,11 @@ func (u *BaseChatCompletionUsage) CachedTokens() int64 {
 	return u.cachedTokens
 }
 
+// ImageTokens implements ImageReportingUsage.
+func (u *BaseChatCompletionUsage) ImageTokens() int64 {
+	return u.imageTokens
+}
+
 // Cost implements CostReportingUsage.
 func (u *BaseChatCompletionUsage) Cost() (amount float64, currency string, ok bool) {
 	if u.cost == nil {
@@ -602,7 +709,20 @@ func NewChatCompletionUsageWithCost(promptTokens, completionTokens, totalTokens,
 	}
 }
 
+// NewChatCompletionUsageWithImages creates a usage that also carries the
+// number of prompt tokens of the input images, for providers that report it
+// (e.g. yzma). Use ImageReportingUsage to retrieve it.
+func NewChatCompletionUsageWithImages(promptTokens, completionTokens, totalTokens, imageTokens int64) *BaseChatCompletionUsage {
+	return &BaseChatCompletionUsage{
+		promptTokens:     promptTokens,
+		completionTokens: completionTokens,
+		totalTokens:      totalTokens,
+		imageTokens:      imageTokens,
+	}
+}
+
 var _ CostReportingUsage = &BaseChatCompletionUsage{}
+var _ ImageReportingUsage = &BaseChatCompletionUsage{}
 
 var _ ChatCompletionUsage = &BaseChatCompletionUsage{}
 
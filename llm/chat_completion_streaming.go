@@ -99,6 +99,7 @@ type BaseStreamDelta struct {
 	reasoningDetails []ReasoningDetail
 	audioData        string
 	transcript       string
+	citations        []Citation
 }
 
 // Role implements StreamDelta
@@ -245,6 +246,7 @@ type StreamingUsageTracker struct {
 	completionTokens int64
 	totalTokens      int64
 	cachedTokens     int64
+	imageTokens      int64
 	cost             *float64
 	costCurrency     string
 }
@@ -259,6 +261,9 @@ func (t *StreamingUsageTracker) Update(chunk StreamChunk) {
 		if cu, ok := usage.(cachedUsage); ok {
 			t.cachedTokens = cu.CachedTokens()
 		}
+		if iu, ok := usage.(ImageReportingUsage); ok {
+			t.imageTokens = iu.ImageTokens()
+		}
 		if cr, ok := usage.(CostReportingUsage); ok {
 			if amount, currency, ok := cr.Cost(); ok {
 				t.cost = &amount
@@ -270,10 +275,12 @@ func (t *StreamingUsageTracker) Update(chunk StreamChunk) {
 
 // Usage returns the current usage as a ChatCompletionUsage
 func (t *StreamingUsageTracker) Usage() ChatCompletionUsage {
+	usage := NewChatCompletionUsageWithCache(t.promptTokens, t.completionTokens, t.totalTokens, t.cachedTokens)
 	if t.cost != nil {
-		return NewChatCompletionUsageWithCost(t.promptTokens, t.completionTokens, t.totalTokens, t.cachedTokens, *t.cost, t.costCurrency)
+		usage = NewChatCompletionUsageWithCost(t.promptTokens, t.completionTokens, t.totalTokens, t.cachedTokens, *t.cost, t.costCurrency)
 	}
-	return NewChatCompletionUsageWithCache(t.promptTokens, t.completionTokens, t.totalTokens, t.cachedTokens)
+	usage.imageTokens = t.imageTokens
+	return usage
 }
 
 // NewStreamingUsageTracker creates a new streaming usage tracker
@@ -130,6 +130,18 @@ func TestStreamingUsageTracker(t *testing.T) {
 	if updatedUsage.CompletionTokens() != 20 {
 		t.Errorf("expected completion tokens 20, got %d", updatedUsage.CompletionTokens())
 	}
+
+	// Test keeping the image tokens
+	tracker.Update(NewCompleteStreamChunk(NewChatCompletionUsageWithImages(300, 20, 320, 256)))
+
+	imageUsage, ok := tracker.Usage().(ImageReportingUsage)
+	if !ok {
+		t.Fatalf("expected usage to implement ImageReportingUsage")
+	}
+
+	if imageUsage.ImageTokens() != 256 {
+		t.Errorf("expected image tokens 256, got %d", imageUsage.ImageTokens())
+	}
 }
 
 // MockStreamingClient for testing
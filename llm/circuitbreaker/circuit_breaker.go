@@ -149,4 +149,86 @@ func (c *Client) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatComp
 	return stream, errors.WithStack(err)
 }
 
-var _ llm.Client = &Client{}
+// ImageGeneration implements llm.ImageGenerationClient with circuit breaker protection
+func (c *Client) ImageGeneration(ctx context.Context, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return c.executeImage(func() (llm.ImageGenerationResponse, error) {
+		return llm.DelegateImageGeneration(ctx, c.client, prompt, funcs...)
+	})
+}
+
+// ImageEdit implements llm.ImageEditClient with circuit breaker protection
+func (c *Client) ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return c.executeImage(func() (llm.ImageGenerationResponse, error) {
+		return llm.DelegateImageEdit(ctx, c.client, image, prompt, funcs...)
+	})
+}
+
+// ImageVariation implements llm.ImageEditClient with circuit breaker protection
+func (c *Client) ImageVariation(ctx context.Context, image []byte, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return c.executeImage(func() (llm.ImageGenerationResponse, error) {
+		return llm.DelegateImageVariation(ctx, c.client, image, funcs...)
+	})
+}
+
+// Complete implements llm.CompletionClient with circuit breaker protection
+func (c *Client) Complete(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	var response llm.CompletionResponse
+	var err error
+
+	breakerErr := c.breaker.Execute(func() error {
+		response, err = llm.DelegateComplete(ctx, c.client, prompt, suffix, funcs...)
+		return errors.WithStack(err)
+	})
+
+	if breakerErr != nil {
+		return nil, errors.WithStack(breakerErr)
+	}
+
+	return response, errors.WithStack(err)
+}
+
+// CompleteStream implements llm.CompletionClient with circuit breaker protection
+func (c *Client) CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	var stream <-chan llm.StreamChunk
+	var err error
+
+	breakerErr := c.breaker.Execute(func() error {
+		stream, err = llm.DelegateCompleteStream(ctx, c.client, prompt, suffix, funcs...)
+		return errors.WithStack(err)
+	})
+
+	if breakerErr != nil {
+		return nil, errors.WithStack(breakerErr)
+	}
+
+	return stream, errors.WithStack(err)
+}
+
+func (c *Client) executeImage(fn func() (llm.ImageGenerationResponse, error)) (llm.ImageGenerationResponse, error) {
+	var response llm.ImageGenerationResponse
+	var err error
+
+	breakerErr := c.breaker.Execute(func() error {
+		response, err = fn()
+		return errors.WithStack(err)
+	})
+
+	if breakerErr != nil {
+		return nil, errors.WithStack(breakerErr)
+	}
+
+	return response, errors.WithStack(err)
+}
+
+// ValidateAttachment implements llm.AttachmentValidator
+func (c *Client) ValidateAttachment(attachment llm.Attachment) error {
+	return llm.DelegateValidateAttachment(c.client, attachment)
+}
+
+var (
+	_ llm.Client                = &Client{}
+	_ llm.ImageGenerationClient = &Client{}
+	_ llm.ImageEditClient       = &Client{}
+	_ llm.CompletionClient      = &Client{}
+	_ llm.AttachmentValidator   = &Client{}
+)
@@ -0,0 +1,97 @@
+package llm
+
+// Citation is a source cited by a message, typically a web page found by a
+// search-enabled model. StartIndex and EndIndex delimit the cited span in
+// the content of the message; both are zero when the provider only lists
+// the source. They count characters (Unicode code points), as the providers
+// return them, not bytes: the span is []rune(content)[StartIndex:EndIndex].
+type Citation struct {
+	URL   string
+	Title string
+	// Content is the excerpt of the source backing the citation, when the
+	// provider returns it.
+	Content    string
+	StartIndex int
+	EndIndex   int
+}
+
+// AnnotatedMessage is implemented by messages carrying the sources cited by
+// their content. Providers returning annotations (OpenRouter, OpenAI search
+// models, Perplexity...) attach them to the response message; callers can
+// type-assert to access them, or use [CitationsOf].
+type AnnotatedMessage interface {
+	Message
+	Citations() []Citation
+}
+
+// AnnotatedStreamDelta extends StreamDelta with the citations received in a
+// chunk. Callers accumulate them across the chunks of a stream.
+type AnnotatedStreamDelta interface {
+	StreamDelta
+	Citations() []Citation
+}
+
+// Citations implements AnnotatedMessage.
+func (b *BaseMessage) Citations() []Citation {
+	return b.citations
+}
+
+// Citations implements AnnotatedStreamDelta.
+func (d *BaseStreamDelta) Citations() []Citation {
+	return d.citations
+}
+
+var (
+	_ AnnotatedMessage     = &BaseMessage{}
+	_ AnnotatedStreamDelta = &BaseStreamDelta{}
+)
+
+// CitationsOf returns the citations of the message, if any.
+func CitationsOf(message Message) []Citation {
+	if am, ok := message.(AnnotatedMessage); ok {
+		return am.Citations()
+	}
+
+	return nil
+}
+
+// WithCitations returns a copy of the message carrying the citations,
+// whatever its kind (tool calls, reasoning...). It reports false and
+// returns the message as is when its type cannot carry them.
+func WithCitations(message Message, citations []Citation) (Message, bool) {
+	switch m := message.(type) {
+	case *BaseMessage:
+		clone := *m
+		clone.citations = citations
+		return &clone, true
+	case *MultimodalMessage:
+		clone := *m
+		clone.citations = citations
+		return &clone, true
+	case *BaseToolCallsMessage:
+		clone := *m
+		clone.citations = citations
+		return &clone, true
+	case *BaseAssistantReasoningMessage:
+		clone := *m
+		clone.citations = citations
+		return &clone, true
+	default:
+		return message, false
+	}
+}
+
+// WithStreamDeltaCitations returns a copy of the delta carrying the
+// citations. It reports false and returns the delta as is when its type
+// cannot carry them.
+func WithStreamDeltaCitations(delta StreamDelta, citations []Citation) (StreamDelta, bool) {
+	d, ok := delta.(*BaseStreamDelta)
+	if !ok {
+		return delta, false
+	}
+
+	clone := *d
+	clone.citations = citations
+
+	return &clone, true
+}
@@ -0,0 +1,44 @@
+package llm
+
+import "testing"
+
+func TestWithCitations(t *testing.T) {
+	citations := []Citation{{URL: "https://go.dev/blog", Title: "The Go Blog", StartIndex: 0, EndIndex: 7}}
+
+	t.Run("a plain message carries no citations", func(t *testing.T) {
+		if got := CitationsOf(NewMessage(RoleAssistant, "Go 1.25")); len(got) != 0 {
+			t.Errorf("expected no citations, got %+v", got)
+		}
+	})
+
+	t.Run("a reasoning message keeps its reasoning", func(t *testing.T) {
+		message := NewAssistantReasoningMessage("Go 1.25", "searching", nil)
+
+		annotated, ok := WithCitations(message, citations)
+		if !ok {
+			t.Fatal("expected the message to carry citations")
+		}
+
+		if rm, ok := annotated.(ReasoningMessage); !ok || rm.Reasoning() != "searching" {
+			t.Errorf("expected the reasoning to be kept, got %+v", annotated)
+		}
+		if got := CitationsOf(annotated); len(got) != 1 || got[0].URL != "https://go.dev/blog" {
+			t.Errorf("unexpected citations %+v", got)
+		}
+		if len(message.Citations()) != 0 {
+			t.Errorf("expected the original message to be left untouched")
+		}
+	})
+
+	t.Run("a stream delta carries citations", func(t *testing.T) {
+		delta, ok := WithStreamDeltaCitations(NewReasoningStreamDelta(RoleAssistant, "Go", "searching", nil), citations)
+		if !ok {
+			t.Fatal("expected the delta to carry citations")
+		}
+
+		ad, ok := delta.(AnnotatedStreamDelta)
+		if !ok || len(ad.Citations()) != 1 || delta.Content() != "Go" {
+			t.Errorf("unexpected delta %+v", delta)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package llm
+
+import "context"
+
+// CompletionClient completes raw text, without chat template: the prompt is
+// continued as is, or the gap between the prompt and a suffix is filled in
+// (fill-in-the-middle, as code editors do).
+//
+// Like [ImageGenerationClient], it is an optional interface discovered with
+// a type assertion:
+//
+//	if completer, ok := client.(llm.CompletionClient); ok {
+//	    // ...
+//	}
+type CompletionClient interface {
+	// Complete returns the text following the prompt and, when the suffix is
+	// not empty, preceding the suffix.
+	Complete(ctx context.Context, prompt string, suffix string, funcs ...CompletionOptionFunc) (CompletionResponse, error)
+	// CompleteStream streams the completion as content deltas, the last
+	// chunk being a complete chunk carrying the usage.
+	CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...CompletionOptionFunc) (<-chan StreamChunk, error)
+}
+
+// CompletionOptions gathers the options of the raw text completions. Nil or
+// empty values mean the provider's default.
+type CompletionOptions struct {
+	// Model overrides the model configured on the client for this call.
+	// Empty means the client's model.
+	Model       string
+	MaxTokens   *int
+	Temperature *float64
+	TopP        *float64
+	Stop        []string
+	Seed        *int
+	// ExtraFields carries arbitrary provider-specific key/values to inject
+	// verbatim into the request body.
+	ExtraFields map[string]any
+}
+
+type CompletionOptionFunc func(opts *CompletionOptions)
+
+func NewCompletionOptions(funcs ...CompletionOptionFunc) *CompletionOptions {
+	opts := &CompletionOptions{}
+	for _, fn := range funcs {
+		fn(opts)
+	}
+	return opts
+}
+
+// WithCompletionModel overrides the model configured on the client for this
+// call
+func WithCompletionModel(model string) CompletionOptionFunc {
+	return func(opts *CompletionOptions) {
+		opts.Model = model
+	}
+}
+
+func WithCompletionMaxTokens(maxTokens int) CompletionOptionFunc {
+	return func(opts *CompletionOptions) {
+		opts.MaxTokens = &maxTokens
+	}
+}
+
+func WithCompletionTemperature(temperature float64) CompletionOptionFunc {
+	return func(opts *CompletionOptions) {
+		opts.Temperature = &temperature
+	}
+}
+
+func WithCompletionTopP(p float64) CompletionOptionFunc {
+	return func(opts *CompletionOptions) {
+		opts.TopP = &p
+	}
+}
+
+// WithCompletionStop stops the completion at the first of the sequences
+func WithCompletionStop(sequences ...string) CompletionOptionFunc {
+	return func(opts *CompletionOptions) {
+		opts.Stop = sequences
+	}
+}
+
+func WithCompletionSeed(seed int) CompletionOptionFunc {
+	return func(opts *CompletionOptions) {
+		opts.Seed = &seed
+	}
+}
+
+func WithCompletionExtraFields(fields map[string]any) CompletionOptionFunc {
+	return func(opts *CompletionOptions) {
+		opts.ExtraFields = fields
+	}
+}
+
+// CompletionResponse is the result of a raw text completion
+type CompletionResponse interface {
+	// Text is the generated text, without the prompt nor the suffix.
+	Text() string
+	// FinishReason tells why the generation stopped, empty when the provider
+	// does not report it.
+	FinishReason() FinishReason
+	Usage() ChatCompletionUsage
+}
+
+type BaseCompletionResponse struct {
+	text         string
+	finishReason FinishReason
+	usage        ChatCompletionUsage
+}
+
+// Text implements CompletionResponse.
+func (r *BaseCompletionResponse) Text() string {
+	return r.text
+}
+
+// FinishReason implements CompletionResponse.
+func (r *BaseCompletionResponse) FinishReason() FinishReason {
+	return r.finishReason
+}
+
+// Usage implements CompletionResponse.
+func (r *BaseCompletionResponse) Usage() ChatCompletionUsage {
+	return r.usage
+}
+
+func NewCompletionResponse(text string, finishReason FinishReason, usage ChatCompletionUsage) *BaseCompletionResponse {
+	return &BaseCompletionResponse{
+		text:         text,
+		finishReason: finishReason,
+		usage:        usage,
+	}
+}
+
+var _ CompletionResponse = &BaseCompletionResponse{}
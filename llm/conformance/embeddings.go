@@ -2,10 +2,10 @@ package conformance
 
 import (
 	"context"
-	"math"
 	"testing"
 
 	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/vectorstore"
 )
 
 func testEmbeddings(t *testing.T, client any) {
@@ -44,24 +44,11 @@ func testEmbeddings(t *testing.T, client any) {
 			t.Fatalf("expected 3 embeddings, got %d", len(res.Embeddings()))
 		}
 
-		simCatKitten := cosineSimilarity(res.Embeddings()[0], res.Embeddings()[1])
-		simCatAuto := cosineSimilarity(res.Embeddings()[0], res.Embeddings()[2])
+		simCatKitten := vectorstore.Cosine(res.Embeddings()[0], res.Embeddings()[1])
+		simCatAuto := vectorstore.Cosine(res.Embeddings()[0], res.Embeddings()[2])
 
 		if simCatKitten <= simCatAuto {
 			t.Errorf("expected sim(cat,kitten)=%.4f > sim(cat,automobile)=%.4f", simCatKitten, simCatAuto)
 		}
 	})
 }
-
-func cosineSimilarity(a, b []float64) float64 {
-	var dot, normA, normB float64
-	for i := range a {
-		dot += a[i] * b[i]
-		normA += a[i] * a[i]
-		normB += b[i] * b[i]
-	}
-	if normA == 0 || normB == 0 {
-		return 0
-	}
-	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
-}
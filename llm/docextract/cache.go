@@ -0,0 +1,77 @@
+package docextract
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Cache stores the text extracted from documents, keyed by the SHA-256 of
+// their content.
+type Cache interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key string, text string) error
+}
+
+// MemoryCache is an in-memory Cache evicting the least recently used
+// documents beyond its capacity.
+type MemoryCache struct {
+	mutex    sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type memoryCacheEntry struct {
+	key  string
+	text string
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(ctx context.Context, key string) (string, bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		return "", false, nil
+	}
+
+	c.order.MoveToFront(element)
+
+	return element.Value.(*memoryCacheEntry).text, true, nil
+}
+
+// Set implements Cache.
+func (c *MemoryCache) Set(ctx context.Context, key string, text string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, exists := c.entries[key]; exists {
+		element.Value.(*memoryCacheEntry).text = text
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryCacheEntry{key: key, text: text})
+
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+
+	return nil
+}
+
+// NewMemoryCache creates a MemoryCache holding at most capacity documents,
+// without limit when capacity is 0.
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+var _ Cache = &MemoryCache{}
@@ -0,0 +1,311 @@
+package docextract
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bornholm/genai/extract"
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/messageutil"
+	"github.com/pkg/errors"
+)
+
+// Client replaces the document attachments the wrapped client cannot send
+// natively by their text, extracted with an extract.Client (Mistral OCR,
+// Marker...). Other attachments are passed through untouched.
+type Client struct {
+	client    llm.Client
+	extractor extract.Client
+	opts      *Options
+}
+
+// ChatCompletion implements llm.Client.
+func (c *Client) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	funcs, err := c.rewrite(ctx, funcs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c.client.ChatCompletion(ctx, funcs...)
+}
+
+// ChatCompletionStream implements llm.Client.
+func (c *Client) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	funcs, err := c.rewrite(ctx, funcs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c.client.ChatCompletionStream(ctx, funcs...)
+}
+
+// Embeddings implements llm.Client.
+func (c *Client) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	return c.client.Embeddings(ctx, inputs, funcs...)
+}
+
+// Transcription implements llm.Client.
+func (c *Client) Transcription(ctx context.Context, audio []byte, funcs ...llm.TranscriptionOptionFunc) (llm.TranscriptionResponse, error) {
+	return c.client.Transcription(ctx, audio, funcs...)
+}
+
+// ImageGeneration implements llm.ImageGenerationClient.
+func (c *Client) ImageGeneration(ctx context.Context, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return llm.DelegateImageGeneration(ctx, c.client, prompt, funcs...)
+}
+
+// ImageEdit implements llm.ImageEditClient.
+func (c *Client) ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return llm.DelegateImageEdit(ctx, c.client, image, prompt, funcs...)
+}
+
+// ImageVariation implements llm.ImageEditClient.
+func (c *Client) ImageVariation(ctx context.Context, image []byte, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return llm.DelegateImageVariation(ctx, c.client, image, funcs...)
+}
+
+// Complete implements llm.CompletionClient.
+func (c *Client) Complete(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	return llm.DelegateComplete(ctx, c.client, prompt, suffix, funcs...)
+}
+
+// CompleteStream implements llm.CompletionClient.
+func (c *Client) CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	return llm.DelegateCompleteStream(ctx, c.client, prompt, suffix, funcs...)
+}
+
+// ValidateAttachment implements llm.AttachmentValidator. Documents are
+// accepted, as they are extracted when the wrapped client does not support
+// them.
+func (c *Client) ValidateAttachment(attachment llm.Attachment) error {
+	if attachment.Type() == llm.AttachmentTypeDocument {
+		return nil
+	}
+
+	return llm.DelegateValidateAttachment(c.client, attachment)
+}
+
+// rewrite returns the options with the unsupported documents of the messages
+// replaced by their extracted text, or the options as is when there are none.
+func (c *Client) rewrite(ctx context.Context, funcs []llm.ChatCompletionOptionFunc) ([]llm.ChatCompletionOptionFunc, error) {
+	opts := llm.NewChatCompletionOptions(funcs...)
+
+	var (
+		messages = make([]llm.Message, len(opts.Messages))
+		changed  bool
+	)
+
+	for i, message := range opts.Messages {
+		rewritten, extracted, err := c.rewriteMessage(ctx, message)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not extract documents of message %d", i)
+		}
+
+		messages[i] = rewritten
+		changed = changed || extracted
+	}
+
+	if !changed {
+		return funcs, nil
+	}
+
+	return append(append([]llm.ChatCompletionOptionFunc{}, funcs...), llm.WithMessages(messages...)), nil
+}
+
+// rewriteMessage appends the text of the unsupported documents to the message
+// content, reporting whether any was extracted.
+func (c *Client) rewriteMessage(ctx context.Context, message llm.Message) (llm.Message, bool, error) {
+	attachments := message.Attachments()
+	if len(attachments) == 0 {
+		return message, false, nil
+	}
+
+	var (
+		kept      = make([]llm.Attachment, 0, len(attachments))
+		documents []string
+	)
+
+	for _, attachment := range attachments {
+		if attachment.Type() != llm.AttachmentTypeDocument || c.validate(attachment) == nil {
+			kept = append(kept, attachment)
+			continue
+		}
+
+		text, err := c.extract(ctx, attachment)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "could not extract %s document", attachment.MimeType())
+		}
+
+		documents = append(documents, fmt.Sprintf("<document index=\"%d\" type=\"%s\">\n%s\n</document>", len(documents)+1, attachment.MimeType(), strings.TrimSpace(text)))
+	}
+
+	if len(documents) == 0 {
+		return message, false, nil
+	}
+
+	content := strings.Join(documents, "\n\n")
+	if message.Content() != "" {
+		content = message.Content() + "\n\n" + content
+	}
+
+	return messageutil.WithContentAndAttachments(message, content, kept), true, nil
+}
+
+// validate tells if the wrapped client accepts the attachment natively
+func (c *Client) validate(attachment llm.Attachment) error {
+	validator := c.opts.Validator
+	if validator == nil {
+		validator, _ = c.client.(llm.AttachmentValidator)
+	}
+
+	if validator != nil {
+		return validator.ValidateAttachment(attachment)
+	}
+
+	if !strings.HasPrefix(strings.ToLower(attachment.MimeType()), "text/") {
+		return llm.NewAttachmentError("provider", "mime_type", fmt.Sprintf("unsupported document MIME type: %s", attachment.MimeType()))
+	}
+
+	return nil
+}
+
+// extract returns the text of the document, from the cache when the same
+// document has already been extracted.
+func (c *Client) extract(ctx context.Context, attachment llm.Attachment) (string, error) {
+	data, err := c.documentData(ctx, attachment)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	hash := sha256.Sum256(data)
+	key := hex.EncodeToString(hash[:])
+
+	if c.opts.Cache != nil {
+		text, found, err := c.opts.Cache.Get(ctx, key)
+		if err != nil {
+			return "", errors.Wrap(err, "could not read cache")
+		}
+
+		if found {
+			return text, nil
+		}
+	}
+
+	res, err := c.extractor.Text(ctx,
+		extract.WithReader(bytes.NewReader(data)),
+		extract.WithFilename("document"+llm.MimeTypeExtension(attachment.MimeType())),
+	)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	output, err := io.ReadAll(res.Output())
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	text := string(output)
+
+	if c.opts.Cache != nil {
+		if err := c.opts.Cache.Set(ctx, key, text); err != nil {
+			return "", errors.Wrap(err, "could not write cache")
+		}
+	}
+
+	return text, nil
+}
+
+func (c *Client) documentData(ctx context.Context, attachment llm.Attachment) ([]byte, error) {
+	switch attachment.Source() {
+	case llm.AttachmentSourceBase64:
+		encoded := attachment.Data()
+		if strings.HasPrefix(encoded, "data:") {
+			_, encoded, _ = strings.Cut(encoded, ",")
+		}
+
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, llm.NewAttachmentErrorWithCause("format", "data", "invalid base64 encoding", err)
+		}
+
+		return data, nil
+
+	case llm.AttachmentSourceURL:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, attachment.Data(), nil)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		res, err := c.opts.HTTPClient.Do(req)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		defer res.Body.Close()
+
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return nil, errors.Errorf("could not download '%s': unexpected status %d", attachment.Data(), res.StatusCode)
+		}
+
+		if c.opts.MaxSize > 0 && res.ContentLength > c.opts.MaxSize {
+			return nil, errDocumentTooLarge(c.opts.MaxSize)
+		}
+
+		data, err := readDocument(res.Body, c.opts.MaxSize)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not download '%s'", attachment.Data())
+		}
+
+		return data, nil
+
+	default:
+		return nil, errors.Errorf("unsupported attachment source: %s", attachment.Source())
+	}
+}
+
+// readDocument reads the document, failing when it exceeds the maximum size
+func readDocument(r io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return io.ReadAll(r)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, errDocumentTooLarge(maxSize)
+	}
+
+	return data, nil
+}
+
+func errDocumentTooLarge(maxSize int64) error {
+	return llm.NewAttachmentError("format", "size", fmt.Sprintf("document exceeds the maximum size of %d bytes", maxSize))
+}
+
+// NewClient wraps the client, extracting the documents it does not support
+// with the extractor.
+func NewClient(client llm.Client, extractor extract.Client, funcs ...OptionFunc) *Client {
+	return &Client{
+		client:    client,
+		extractor: extractor,
+		opts:      NewOptions(funcs...),
+	}
+}
+
+var (
+	_ llm.Client                = &Client{}
+	_ llm.ImageGenerationClient = &Client{}
+	_ llm.ImageEditClient       = &Client{}
+	_ llm.CompletionClient      = &Client{}
+	_ llm.AttachmentValidator   = &Client{}
+)
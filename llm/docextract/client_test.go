@@ -0,0 +1,221 @@
+package docextract
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/genai/extract"
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+type mockClient struct {
+	messages []llm.Message
+}
+
+func (m *mockClient) ChatCompletion(_ context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	m.messages = llm.NewChatCompletionOptions(funcs...).Messages
+	return llm.NewChatCompletionResponse(llm.NewMessage(llm.RoleAssistant, "ok"), nil), nil
+}
+
+func (m *mockClient) ChatCompletionStream(_ context.Context, _ ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	return nil, nil
+}
+
+func (m *mockClient) Embeddings(_ context.Context, _ []string, _ ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	return nil, nil
+}
+
+func (m *mockClient) Transcription(_ context.Context, _ []byte, _ ...llm.TranscriptionOptionFunc) (llm.TranscriptionResponse, error) {
+	return nil, nil
+}
+
+type mockTextResponse struct {
+	output string
+}
+
+func (r *mockTextResponse) Output() io.Reader {
+	return strings.NewReader(r.output)
+}
+
+func (r *mockTextResponse) Format() extract.TextFormat {
+	return extract.TextFormatMarkdown
+}
+
+type mockExtractor struct {
+	calls     int
+	filenames []string
+}
+
+// Text answers with the document content, upper-cased.
+func (m *mockExtractor) Text(_ context.Context, funcs ...extract.TextOptionFunc) (extract.TextResponse, error) {
+	opts, err := extract.NewTextOptions(funcs...)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(opts.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	m.calls++
+	m.filenames = append(m.filenames, opts.Filename)
+
+	return &mockTextResponse{output: "# " + strings.ToUpper(string(data))}, nil
+}
+
+type rejectAll struct{}
+
+func (rejectAll) ValidateAttachment(attachment llm.Attachment) error {
+	return llm.NewAttachmentError("provider", "type", "rejected")
+}
+
+func testAttachment(t *testing.T, attachmentType llm.AttachmentType, mimeType string, data string) llm.Attachment {
+	t.Helper()
+
+	attachment, err := llm.NewBase64Attachment(attachmentType, mimeType, base64.StdEncoding.EncodeToString([]byte(data)))
+	if err != nil {
+		t.Fatalf("NewBase64Attachment: %+v", err)
+	}
+
+	return attachment
+}
+
+func TestClient_ExtractsUnsupportedDocuments(t *testing.T) {
+	mock := &mockClient{}
+	extractor := &mockExtractor{}
+	client := NewClient(mock, extractor)
+
+	pdf := testAttachment(t, llm.AttachmentTypeDocument, "application/pdf", "quarterly report")
+	image := testAttachment(t, llm.AttachmentTypeImage, "image/png", "png")
+
+	for range 2 {
+		_, err := client.ChatCompletion(context.Background(), llm.WithMessages(
+			llm.NewMultimodalMessage(llm.RoleUser, "Summarize", pdf, image),
+		))
+		if err != nil {
+			t.Fatalf("ChatCompletion: %+v", err)
+		}
+	}
+
+	if extractor.calls != 1 {
+		t.Errorf("expected the second extraction to be cached, got %d calls", extractor.calls)
+	}
+	if extractor.filenames[0] != "document.pdf" {
+		t.Errorf("unexpected filename %q", extractor.filenames[0])
+	}
+
+	message := mock.messages[0]
+
+	expected := "Summarize\n\n<document index=\"1\" type=\"application/pdf\">\n# QUARTERLY REPORT\n</document>"
+	if message.Content() != expected {
+		t.Errorf("content %q, expected %q", message.Content(), expected)
+	}
+
+	if attachments := message.Attachments(); len(attachments) != 1 || attachments[0] != image {
+		t.Errorf("expected the image to be kept, got %+v", attachments)
+	}
+}
+
+func TestClient_KeepsSupportedDocuments(t *testing.T) {
+	mock := &mockClient{}
+	extractor := &mockExtractor{}
+	client := NewClient(mock, extractor)
+
+	message := llm.NewMultimodalMessage(llm.RoleUser, "Read this",
+		testAttachment(t, llm.AttachmentTypeDocument, "text/plain", "notes"),
+	)
+
+	if _, err := client.ChatCompletion(context.Background(), llm.WithMessages(message)); err != nil {
+		t.Fatalf("ChatCompletion: %+v", err)
+	}
+
+	if extractor.calls != 0 {
+		t.Errorf("text documents should not be extracted, got %d calls", extractor.calls)
+	}
+	if mock.messages[0] != llm.Message(message) {
+		t.Error("the message should be passed through untouched")
+	}
+}
+
+func TestClient_Validator(t *testing.T) {
+	mock := &mockClient{}
+	extractor := &mockExtractor{}
+	client := NewClient(mock, extractor, WithValidator(rejectAll{}), WithCache(nil))
+
+	message := llm.NewToolMessage("call-1", llm.NewToolResult("",
+		testAttachment(t, llm.AttachmentTypeDocument, "text/markdown", "notes"),
+	))
+
+	if _, err := client.ChatCompletion(context.Background(), llm.WithMessages(message)); err != nil {
+		t.Fatalf("ChatCompletion: %+v", err)
+	}
+
+	toolMessage, ok := mock.messages[0].(llm.ToolMessage)
+	if !ok || toolMessage.ID() != "call-1" {
+		t.Fatalf("expected the tool message to keep its identifier, got %+v", mock.messages[0])
+	}
+
+	if !strings.Contains(toolMessage.Content(), "# NOTES") || len(toolMessage.Attachments()) != 0 {
+		t.Errorf("unexpected tool message content %q", toolMessage.Content())
+	}
+}
+
+func TestClient_URLDocumentMaxSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		if r.URL.Path == "/chunked.pdf" {
+			// Without a Content-Length, the body is read up to the limit
+			w.(http.Flusher).Flush()
+		}
+		io.WriteString(w, "quarterly report")
+	}))
+	defer server.Close()
+
+	for _, path := range []string{"/report.pdf", "/chunked.pdf"} {
+		t.Run(path, func(t *testing.T) {
+			pdf, err := llm.NewURLAttachment(llm.AttachmentTypeDocument, "application/pdf", server.URL+path)
+			if err != nil {
+				t.Fatalf("NewURLAttachment: %+v", err)
+			}
+
+			messages := llm.WithMessages(llm.NewMultimodalMessage(llm.RoleUser, "Summarize", pdf))
+
+			client := NewClient(&mockClient{}, &mockExtractor{}, WithMaxSize(8))
+
+			var attachmentErr *llm.AttachmentError
+			if _, err := client.ChatCompletion(context.Background(), messages); !errors.As(err, &attachmentErr) || attachmentErr.Field != "size" {
+				t.Errorf("expected a size error, got %v", err)
+			}
+
+			client = NewClient(&mockClient{}, &mockExtractor{}, WithMaxSize(16))
+
+			if _, err := client.ChatCompletion(context.Background(), messages); err != nil {
+				t.Errorf("ChatCompletion: %+v", err)
+			}
+		})
+	}
+}
+
+func TestMemoryCache_Evicts(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(2)
+
+	_ = cache.Set(ctx, "a", "A")
+	_ = cache.Set(ctx, "b", "B")
+	_, _, _ = cache.Get(ctx, "a")
+	_ = cache.Set(ctx, "c", "C")
+
+	if _, found, _ := cache.Get(ctx, "b"); found {
+		t.Error("least recently used entry should have been evicted")
+	}
+	if text, found, _ := cache.Get(ctx, "a"); !found || text != "A" {
+		t.Error("recently used entry should have been kept")
+	}
+}
@@ -0,0 +1,63 @@
+package docextract
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bornholm/genai/llm"
+)
+
+type Options struct {
+	// Validator tells which attachments the wrapped client accepts. When
+	// nil, the client is used if it implements llm.AttachmentValidator,
+	// otherwise only text documents are considered supported.
+	Validator llm.AttachmentValidator
+	// Cache stores the extracted documents. Nil disables caching.
+	Cache Cache
+	// HTTPClient downloads the documents attached by URL. The default one
+	// times out after a minute.
+	HTTPClient *http.Client
+	// MaxSize caps the number of bytes of the documents downloaded. Zero
+	// disables the limit.
+	MaxSize int64
+}
+
+type OptionFunc func(opts *Options)
+
+func NewOptions(funcs ...OptionFunc) *Options {
+	opts := &Options{
+		Cache:      NewMemoryCache(100),
+		HTTPClient: &http.Client{Timeout: time.Minute},
+		MaxSize:    50 << 20,
+	}
+
+	for _, fn := range funcs {
+		fn(opts)
+	}
+
+	return opts
+}
+
+func WithValidator(validator llm.AttachmentValidator) OptionFunc {
+	return func(opts *Options) {
+		opts.Validator = validator
+	}
+}
+
+func WithCache(cache Cache) OptionFunc {
+	return func(opts *Options) {
+		opts.Cache = cache
+	}
+}
+
+func WithHTTPClient(client *http.Client) OptionFunc {
+	return func(opts *Options) {
+		opts.HTTPClient = client
+	}
+}
+
+func WithMaxSize(maxSize int64) OptionFunc {
+	return func(opts *Options) {
+		opts.MaxSize = maxSize
+	}
+}
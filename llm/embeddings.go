@@ -7,6 +7,9 @@ type EmbeddingsClient interface {
 }
 
 type EmbeddingsOptions struct {
+	// Model overrides the model configured on the client for this call.
+	// Empty means the client's model.
+	Model      string
 	Dimensions *int
 }
 
@@ -20,6 +23,14 @@ func NewEmbeddingsOptions(funcs ...EmbeddingsOptionFunc) *EmbeddingsOptions {
 
 type EmbeddingsOptionFunc func(opts *EmbeddingsOptions)
 
+// WithEmbeddingsModel overrides the model configured on the client for this
+// call
+func WithEmbeddingsModel(model string) EmbeddingsOptionFunc {
+	return func(opts *EmbeddingsOptions) {
+		opts.Model = model
+	}
+}
+
 func WithDimensions(dimensions int) EmbeddingsOptionFunc {
 	return func(opts *EmbeddingsOptions) {
 		opts.Dimensions = &dimensions
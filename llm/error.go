@@ -10,6 +10,9 @@ var (
 	ErrUnavailable = errors.New("unavailable")
 	ErrNoMessage   = errors.New("no message")
 	ErrRateLimit   = errors.New("rate limit")
+	// ErrUnsupportedSetting is returned when a provider cannot honour a
+	// sampling setting and the strictness is StrictnessFail.
+	ErrUnsupportedSetting = errors.New("unsupported setting")
 )
 
 // HTTPError is returned by providers when the upstream API responds with a
@@ -0,0 +1,28 @@
+package guard
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+var ErrBlocked = errors.New("blocked by guard")
+
+// ViolationError is returned when a violation blocks a call
+type ViolationError struct {
+	// Stage is either "input" or "output"
+	Stage     string
+	Violation Violation
+}
+
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("%s rejected by validator '%s': %s", e.Stage, e.Violation.Validator, e.Violation.Message)
+}
+
+func (e *ViolationError) Unwrap() error {
+	return ErrBlocked
+}
+
+func newViolationError(stage string, violation Violation) error {
+	return errors.WithStack(&ViolationError{Stage: stage, Violation: violation})
+}
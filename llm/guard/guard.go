@@ -0,0 +1,538 @@
+package guard
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/hook"
+	"github.com/bornholm/genai/llm/messageutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	stageInput  = "input"
+	stageOutput = "output"
+)
+
+// Guard runs validators on the messages sent to a client and on its
+// responses. It implements the chat completion hooks of the llm/hook
+// package, retries being sent to the client it guards.
+type Guard struct {
+	client llm.Client
+	opts   *Options
+}
+
+// BeforeChatCompletion implements hook.BeforeChatCompletionHook.
+func (g *Guard) BeforeChatCompletion(ctx context.Context, funcs []llm.ChatCompletionOptionFunc) (context.Context, []llm.ChatCompletionOptionFunc, error) {
+	funcs, err := g.checkInput(ctx, funcs)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	return ctx, funcs, nil
+}
+
+// AfterChatCompletion implements hook.AfterChatCompletionHook.
+func (g *Guard) AfterChatCompletion(ctx context.Context, funcs []llm.ChatCompletionOptionFunc, res llm.ChatCompletionResponse) (llm.ChatCompletionResponse, error) {
+	for attempt := 0; ; attempt++ {
+		content := res.Message().Content()
+		if skipOutput(content, len(res.ToolCalls()) > 0) {
+			return g.withContent(ctx, res, content)
+		}
+
+		checked, violation, err := g.check(ctx, stageOutput, g.opts.Output, content, checkMode{retryable: attempt < g.opts.MaxRetries, redactable: true})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if violation == nil {
+			return g.withContent(ctx, res, checked)
+		}
+
+		res, err = g.client.ChatCompletion(ctx, withFeedback(funcs, res.Message(), violation)...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not retry after violation of validator '%s'", violation.Validator)
+		}
+	}
+}
+
+// BeforeChatCompletionStream implements hook.BeforeChatCompletionStreamHook.
+// The returned context is canceled once the guarded stream ends, releasing
+// the provider when a violation interrupts it.
+func (g *Guard) BeforeChatCompletionStream(ctx context.Context, funcs []llm.ChatCompletionOptionFunc) (context.Context, []llm.ChatCompletionOptionFunc, error) {
+	funcs, err := g.checkInput(ctx, funcs)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	return context.WithValue(ctx, cancelKey{}, cancel), funcs, nil
+}
+
+type cancelKey struct{}
+
+// ChatCompletionStreamError implements hook.ChatCompletionStreamErrorHook.
+// It releases the context of a stream which failed to start.
+func (g *Guard) ChatCompletionStreamError(ctx context.Context, funcs []llm.ChatCompletionOptionFunc, err error) {
+	if cancel, ok := ctx.Value(cancelKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
+
+// AfterChatCompletionStream implements hook.AfterChatCompletionStreamHook.
+func (g *Guard) AfterChatCompletionStream(ctx context.Context, funcs []llm.ChatCompletionOptionFunc, stream <-chan llm.StreamChunk) (<-chan llm.StreamChunk, error) {
+	out := make(chan llm.StreamChunk)
+
+	go func() {
+		defer close(out)
+
+		if cancel, ok := ctx.Value(cancelKey{}).(context.CancelFunc); ok {
+			defer cancel()
+		}
+
+		switch g.opts.StreamMode {
+		case StreamModeIncremental:
+			stream = g.scanStream(ctx, stream, out)
+		default:
+			stream = g.bufferStream(ctx, funcs, stream, out)
+		}
+
+		// Release the producer of an interrupted stream
+		go drain(stream)
+	}()
+
+	return out, nil
+}
+
+// bufferStream holds the chunks back until the stream is complete, then
+// validates the text and forwards the chunks, possibly redacted, or retries.
+// It returns the last stream read.
+func (g *Guard) bufferStream(ctx context.Context, funcs []llm.ChatCompletionOptionFunc, stream <-chan llm.StreamChunk, out chan<- llm.StreamChunk) <-chan llm.StreamChunk {
+	for attempt := 0; ; attempt++ {
+		buffered, failed := collect(ctx, stream)
+
+		text, toolCalls := streamContent(buffered)
+		if failed || skipOutput(text, toolCalls) {
+			forward(ctx, out, buffered)
+			return stream
+		}
+
+		checked, violation, err := g.check(ctx, stageOutput, g.opts.Output, text, checkMode{retryable: attempt < g.opts.MaxRetries, redactable: true})
+		if err != nil {
+			send(ctx, out, llm.NewErrorStreamChunk(errors.WithStack(err)))
+			return stream
+		}
+
+		if violation == nil {
+			if checked != text {
+				buffered = redactStream(buffered, checked)
+			}
+			forward(ctx, out, buffered)
+			return stream
+		}
+
+		answer := llm.NewMessage(llm.RoleAssistant, text)
+
+		next, err := g.client.ChatCompletionStream(ctx, withFeedback(funcs, answer, violation)...)
+		if err != nil {
+			send(ctx, out, llm.NewErrorStreamChunk(errors.Wrapf(err, "could not retry after violation of validator '%s'", violation.Validator)))
+			return stream
+		}
+
+		go drain(stream)
+		stream = next
+	}
+}
+
+// scanStream forwards the chunks as they come, running the partial
+// validators on the text received so far and the others once the stream is
+// complete. A violation interrupts the stream with an error chunk. It returns
+// the stream, to be drained.
+func (g *Guard) scanStream(ctx context.Context, stream <-chan llm.StreamChunk, out chan<- llm.StreamChunk) <-chan llm.StreamChunk {
+	var (
+		partial, final []Rule
+		text           strings.Builder
+		toolCalls      bool
+		mode           = checkMode{logged: map[string]struct{}{}}
+	)
+
+	for _, rule := range g.opts.Output {
+		if validatesPartial(rule.Validator) {
+			partial = append(partial, rule)
+		} else {
+			final = append(final, rule)
+		}
+	}
+
+	for {
+		var (
+			chunk llm.StreamChunk
+			ok    bool
+		)
+
+		select {
+		case <-ctx.Done():
+			return stream
+		case chunk, ok = <-stream:
+		}
+
+		if !ok || chunk.Type() == llm.StreamChunkTypeComplete {
+			if !skipOutput(text.String(), toolCalls) {
+				if _, _, err := g.check(ctx, stageOutput, final, text.String(), mode); err != nil {
+					send(ctx, out, llm.NewErrorStreamChunk(errors.WithStack(err)))
+					return stream
+				}
+			}
+
+			if !ok {
+				return stream
+			}
+		}
+
+		if delta := chunk.Delta(); chunk.Type() == llm.StreamChunkTypeDelta && delta != nil {
+			toolCalls = toolCalls || len(delta.ToolCalls()) > 0
+
+			if delta.Content() != "" {
+				text.WriteString(delta.Content())
+
+				if _, _, err := g.check(ctx, stageOutput, partial, text.String(), mode); err != nil {
+					send(ctx, out, llm.NewErrorStreamChunk(errors.WithStack(err)))
+					return stream
+				}
+			}
+		}
+
+		if !send(ctx, out, chunk) {
+			return stream
+		}
+	}
+}
+
+// checkInput validates the content of the messages of the guarded roles,
+// returning the options with the redacted messages if any.
+func (g *Guard) checkInput(ctx context.Context, funcs []llm.ChatCompletionOptionFunc) ([]llm.ChatCompletionOptionFunc, error) {
+	if len(g.opts.Input) == 0 {
+		return funcs, nil
+	}
+
+	opts := llm.NewChatCompletionOptions(funcs...)
+
+	var (
+		messages = slices.Clone(opts.Messages)
+		changed  bool
+	)
+
+	for i, message := range messages {
+		if !slices.Contains(g.opts.InputRoles, message.Role()) || message.Content() == "" {
+			continue
+		}
+
+		checked, _, err := g.check(ctx, stageInput, g.opts.Input, message.Content(), checkMode{redactable: true})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if checked != message.Content() {
+			messages[i] = messageutil.WithContentAndAttachments(message, checked, message.Attachments())
+			changed = true
+		}
+	}
+
+	if !changed {
+		return funcs, nil
+	}
+
+	return append(slices.Clone(funcs), llm.WithMessages(messages...)), nil
+}
+
+type checkMode struct {
+	// retryable tells if a violation may be retried, blocking otherwise
+	retryable bool
+	// redactable tells if a violation may be redacted, blocking otherwise
+	redactable bool
+	// logged records the validators whose violation was already logged, to
+	// log them once when scanning a stream
+	logged map[string]struct{}
+}
+
+// check runs the rules in order on the text and applies their actions. It
+// returns the text, redacted if need be, and the violation to retry.
+func (g *Guard) check(ctx context.Context, stage string, rules []Rule, text string, mode checkMode) (string, *Violation, error) {
+	for _, rule := range rules {
+		violation, err := rule.Validator.Validate(ctx, text)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "validator '%s' failed", rule.Validator.Name())
+		}
+
+		if violation == nil {
+			continue
+		}
+
+		if violation.Validator == "" {
+			violation.Validator = rule.Validator.Name()
+		}
+
+		switch {
+		case rule.Action == ActionLog:
+			if _, exists := mode.logged[violation.Validator]; exists {
+				continue
+			}
+			if mode.logged != nil {
+				mode.logged[violation.Validator] = struct{}{}
+			}
+
+			slog.WarnContext(ctx, "guard violation", slog.String("stage", stage), slog.String("validator", violation.Validator), slog.String("message", violation.Message))
+
+		case rule.Action == ActionRedact && mode.redactable && len(violation.Spans) > 0:
+			text = redact(text, violation.Spans, g.opts.RedactionMask)
+
+		case rule.Action == ActionRetry && mode.retryable:
+			return text, violation, nil
+
+		default:
+			return "", nil, newViolationError(stage, *violation)
+		}
+	}
+
+	return text, nil, nil
+}
+
+// skipOutput tells if a response is a bare tool call, whose empty content is
+// not an answer to validate
+func skipOutput(content string, toolCalls bool) bool {
+	return toolCalls && strings.TrimSpace(content) == ""
+}
+
+const feedbackPrompt = "Your previous answer was rejected: %s. Please answer again, fixing this issue."
+
+// withFeedback returns the options of a new attempt, the rejected answer and
+// the violation being appended to the conversation.
+func withFeedback(funcs []llm.ChatCompletionOptionFunc, answer llm.Message, violation *Violation) []llm.ChatCompletionOptionFunc {
+	opts := llm.NewChatCompletionOptions(funcs...)
+
+	messages := append(slices.Clone(opts.Messages),
+		llm.NewMessage(llm.RoleAssistant, answer.Content()),
+		llm.NewMessage(llm.RoleUser, fmt.Sprintf(feedbackPrompt, violation.Message)),
+	)
+
+	return append(slices.Clone(funcs), llm.WithMessages(messages...))
+}
+
+// withContent returns the response with the checked content, keeping its
+// reasoning, tool calls and citations. The output rules are run on the
+// other candidates, which are redacted, or dropped when a rule blocks them.
+// The candidates whose content is rewritten lose the log-probabilities of
+// the replaced tokens.
+func (g *Guard) withContent(ctx context.Context, res llm.ChatCompletionResponse, content string) (llm.ChatCompletionResponse, error) {
+	original := res.Message().Content()
+
+	cr, hasCandidates := res.(llm.CandidatesResponse)
+	if content == original && !hasCandidates {
+		return res, nil
+	}
+
+	message := res.Message()
+	if content != original {
+		message = withMessageContent(message, content)
+	}
+
+	var updated *llm.BaseChatCompletionResponse
+	if reasoning, ok := res.(llm.ReasoningChatCompletionResponse); ok {
+		updated = llm.NewChatCompletionResponseWithReasoning(message, res.Usage(), reasoning.Reasoning(), reasoning.ReasoningDetails(), res.ToolCalls()...)
+	} else {
+		updated = llm.NewChatCompletionResponse(message, res.Usage(), res.ToolCalls()...)
+	}
+
+	if !hasCandidates {
+		return updated, nil
+	}
+
+	candidates := make([]llm.Candidate, 0, len(cr.Candidates()))
+	for _, c := range cr.Candidates() {
+		candidateContent := c.Message().Content()
+
+		var checked string
+		switch {
+		case candidateContent == original:
+			// The candidate of the response message, already checked
+			checked = content
+
+		case skipOutput(candidateContent, len(c.ToolCalls()) > 0):
+			checked = candidateContent
+
+		default:
+			var err error
+			checked, _, err = g.check(ctx, stageOutput, g.opts.Output, candidateContent, checkMode{redactable: true})
+			if err != nil {
+				var violationErr *ViolationError
+				if errors.As(err, &violationErr) {
+					continue
+				}
+				return nil, errors.WithStack(err)
+			}
+		}
+
+		if checked != candidateContent {
+			c = llm.NewCandidate(c.Index(), withMessageContent(c.Message(), checked), c.FinishReason(), nil, c.ToolCalls()...)
+		}
+
+		candidates = append(candidates, c)
+	}
+
+	return llm.NewCandidatesResponse(updated, candidates...), nil
+}
+
+// withMessageContent returns the message with the rewritten content, keeping
+// its reasoning and citations. The citation offsets, which no longer match
+// the content, are cleared.
+func withMessageContent(message llm.Message, content string) llm.Message {
+	var updated llm.Message = llm.NewMessage(message.Role(), content)
+	if reasoning, ok := message.(llm.ReasoningMessage); ok {
+		updated = llm.NewAssistantReasoningMessage(content, reasoning.Reasoning(), reasoning.ReasoningDetails())
+	}
+
+	if citations := llm.CitationsOf(message); len(citations) > 0 {
+		citations = slices.Clone(citations)
+		for i := range citations {
+			citations[i].StartIndex, citations[i].EndIndex = 0, 0
+		}
+		updated, _ = llm.WithCitations(updated, citations)
+	}
+
+	return updated
+}
+
+// collect reads the stream until it is complete or fails, reporting whether
+// it failed.
+func collect(ctx context.Context, stream <-chan llm.StreamChunk) ([]llm.StreamChunk, bool) {
+	var chunks []llm.StreamChunk
+
+	for {
+		select {
+		case <-ctx.Done():
+			return append(chunks, llm.NewErrorStreamChunk(errors.WithStack(ctx.Err()))), true
+
+		case chunk, ok := <-stream:
+			if !ok {
+				return chunks, false
+			}
+
+			chunks = append(chunks, chunk)
+
+			switch chunk.Type() {
+			case llm.StreamChunkTypeError:
+				return chunks, true
+			case llm.StreamChunkTypeComplete:
+				return chunks, false
+			}
+		}
+	}
+}
+
+// streamContent returns the text of the chunks and whether they carry tool
+// calls
+func streamContent(chunks []llm.StreamChunk) (string, bool) {
+	var (
+		text      strings.Builder
+		toolCalls bool
+	)
+
+	for _, chunk := range chunks {
+		delta := chunk.Delta()
+		if chunk.Type() != llm.StreamChunkTypeDelta || delta == nil {
+			continue
+		}
+
+		text.WriteString(delta.Content())
+		toolCalls = toolCalls || len(delta.ToolCalls()) > 0
+	}
+
+	return text.String(), toolCalls
+}
+
+// redactStream replaces the content of the chunks by the redacted text,
+// carried by the first content delta.
+func redactStream(chunks []llm.StreamChunk, text string) []llm.StreamChunk {
+	redacted := make([]llm.StreamChunk, 0, len(chunks))
+	pending := true
+
+	for _, chunk := range chunks {
+		delta := chunk.Delta()
+		if chunk.Type() != llm.StreamChunkTypeDelta || delta == nil || delta.Content() == "" {
+			redacted = append(redacted, chunk)
+			continue
+		}
+
+		content := ""
+		if pending {
+			content, pending = text, false
+		}
+
+		reasoning, hasReasoning := delta.(llm.ReasoningStreamDelta)
+		hasReasoning = hasReasoning && (reasoning.Reasoning() != "" || len(reasoning.ReasoningDetails()) > 0)
+
+		switch {
+		case hasReasoning:
+			redacted = append(redacted, llm.NewStreamChunk(llm.NewReasoningStreamDelta(delta.Role(), content, reasoning.Reasoning(), reasoning.ReasoningDetails(), delta.ToolCalls()...)))
+		case content != "" || len(delta.ToolCalls()) > 0:
+			redacted = append(redacted, llm.NewStreamChunk(llm.NewStreamDelta(delta.Role(), content, delta.ToolCalls()...)))
+		}
+	}
+
+	return redacted
+}
+
+func drain(stream <-chan llm.StreamChunk) {
+	for range stream {
+	}
+}
+
+func forward(ctx context.Context, out chan<- llm.StreamChunk, chunks []llm.StreamChunk) {
+	for _, chunk := range chunks {
+		if !send(ctx, out, chunk) {
+			return
+		}
+	}
+}
+
+func send(ctx context.Context, out chan<- llm.StreamChunk, chunk llm.StreamChunk) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case out <- chunk:
+		return true
+	}
+}
+
+// NewGuard creates a guard whose retries are sent to the client
+func NewGuard(client llm.Client, funcs ...OptionFunc) *Guard {
+	return &Guard{
+		client: client,
+		opts:   NewOptions(funcs...),
+	}
+}
+
+// NewClient wraps the client with a guard
+func NewClient(client llm.Client, funcs ...OptionFunc) *hook.Client {
+	g := NewGuard(client, funcs...)
+
+	return hook.NewClient(client,
+		hook.WithBeforeChatCompletion(g),
+		hook.WithAfterChatCompletion(g),
+		hook.WithBeforeChatCompletionStream(g),
+		hook.WithAfterChatCompletionStream(g),
+	)
+}
+
+var (
+	_ hook.BeforeChatCompletionHook       = &Guard{}
+	_ hook.AfterChatCompletionHook        = &Guard{}
+	_ hook.BeforeChatCompletionStreamHook = &Guard{}
+	_ hook.AfterChatCompletionStreamHook  = &Guard{}
+	_ hook.ChatCompletionStreamErrorHook  = &Guard{}
+)
@@ -0,0 +1,315 @@
+package guard
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+// mockClient answers with its responses in turn, as a single message or as a
+// stream of one chunk per word.
+type mockClient struct {
+	responses []string
+	calls     [][]llm.Message
+}
+
+func (m *mockClient) next(funcs []llm.ChatCompletionOptionFunc) string {
+	m.calls = append(m.calls, llm.NewChatCompletionOptions(funcs...).Messages)
+	return m.responses[min(len(m.calls), len(m.responses))-1]
+}
+
+func (m *mockClient) ChatCompletion(_ context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	return llm.NewChatCompletionResponse(llm.NewMessage(llm.RoleAssistant, m.next(funcs)), nil), nil
+}
+
+func (m *mockClient) ChatCompletionStream(_ context.Context, funcs ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	words := strings.SplitAfter(m.next(funcs), " ")
+
+	chunks := make(chan llm.StreamChunk, len(words)+1)
+	for _, w := range words {
+		chunks <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, w))
+	}
+	chunks <- llm.NewCompleteStreamChunk(nil)
+	close(chunks)
+
+	return chunks, nil
+}
+
+func (m *mockClient) Embeddings(_ context.Context, _ []string, _ ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	return nil, nil
+}
+
+func (m *mockClient) Transcription(_ context.Context, _ []byte, _ ...llm.TranscriptionOptionFunc) (llm.TranscriptionResponse, error) {
+	return nil, nil
+}
+
+func readStream(t *testing.T, stream <-chan llm.StreamChunk) (string, error) {
+	t.Helper()
+
+	var text strings.Builder
+	for chunk := range stream {
+		switch chunk.Type() {
+		case llm.StreamChunkTypeError:
+			return text.String(), chunk.Error()
+		case llm.StreamChunkTypeDelta:
+			text.WriteString(chunk.Delta().Content())
+		}
+	}
+
+	return text.String(), nil
+}
+
+var secretPattern = regexp.MustCompile(`sk-[a-z0-9]+`)
+
+func TestClient_BlocksInput(t *testing.T) {
+	mock := &mockClient{responses: []string{"ok"}}
+	client := NewClient(mock, WithInput(NewDenylistValidator("password"), ActionBlock))
+
+	_, err := client.ChatCompletion(context.Background(), llm.WithMessages(
+		llm.NewMessage(llm.RoleUser, "What is the admin Password?"),
+	))
+
+	var violationErr *ViolationError
+	if !errors.As(err, &violationErr) || violationErr.Stage != stageInput || !errors.Is(err, ErrBlocked) {
+		t.Fatalf("expected an input violation, got %v", err)
+	}
+	if len(mock.calls) != 0 {
+		t.Error("a blocked input should not reach the client")
+	}
+}
+
+func TestClient_RedactsInput(t *testing.T) {
+	mock := &mockClient{responses: []string{"ok"}}
+	client := NewClient(mock, WithInput(NewRegexpValidator(secretPattern), ActionRedact))
+
+	system := llm.NewMessage(llm.RoleSystem, "key sk-system")
+
+	_, err := client.ChatCompletion(context.Background(), llm.WithMessages(
+		system,
+		llm.NewMessage(llm.RoleUser, "my key is sk-abc123, keep it"),
+	))
+	if err != nil {
+		t.Fatalf("ChatCompletion: %+v", err)
+	}
+
+	messages := mock.calls[0]
+	if messages[0] != llm.Message(system) {
+		t.Error("messages of other roles should be left untouched")
+	}
+	if got := messages[1].Content(); got != "my key is [REDACTED], keep it" {
+		t.Errorf("unexpected redacted content %q", got)
+	}
+}
+
+func TestClient_RetriesWithFeedback(t *testing.T) {
+	validator, err := NewJSONSchemaValidator(map[string]any{
+		"type":     "object",
+		"required": []string{"name"},
+	})
+	if err != nil {
+		t.Fatalf("NewJSONSchemaValidator: %+v", err)
+	}
+
+	mock := &mockClient{responses: []string{"Sure! Here it is", "```json\n{\"name\": \"genai\"}\n```"}}
+	client := NewClient(mock, WithOutput(validator, ActionRetry))
+
+	res, err := client.ChatCompletion(context.Background(), llm.WithMessages(
+		llm.NewMessage(llm.RoleUser, "Give me a JSON object"),
+	))
+	if err != nil {
+		t.Fatalf("ChatCompletion: %+v", err)
+	}
+
+	if len(mock.calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(mock.calls))
+	}
+	if !strings.Contains(res.Message().Content(), "genai") {
+		t.Errorf("unexpected response %q", res.Message().Content())
+	}
+
+	retry := mock.calls[1]
+	if len(retry) != 3 || retry[1].Content() != "Sure! Here it is" || !strings.Contains(retry[2].Content(), "not a valid JSON document") {
+		t.Errorf("unexpected retry conversation %+v", retry)
+	}
+}
+
+func TestClient_BlocksOnceRetriesExhausted(t *testing.T) {
+	mock := &mockClient{responses: []string{"this answer is way too long"}}
+	client := NewClient(mock, WithOutput(NewMaxLengthValidator(10), ActionRetry), WithMaxRetries(1))
+
+	_, err := client.ChatCompletion(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, "Hi")))
+	if !errors.Is(err, ErrBlocked) {
+		t.Fatalf("expected the call to be blocked, got %v", err)
+	}
+	if len(mock.calls) != 2 {
+		t.Errorf("expected 2 calls, got %d", len(mock.calls))
+	}
+}
+
+func TestClient_StreamBuffer(t *testing.T) {
+	mock := &mockClient{responses: []string{"the key is sk-abc123 indeed"}}
+	client := NewClient(mock, WithOutput(NewRegexpValidator(secretPattern), ActionRedact))
+
+	stream, err := client.ChatCompletionStream(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, "Hi")))
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %+v", err)
+	}
+
+	text, err := readStream(t, stream)
+	if err != nil {
+		t.Fatalf("stream failed: %+v", err)
+	}
+	if text != "the key is [REDACTED] indeed" {
+		t.Errorf("unexpected streamed text %q", text)
+	}
+}
+
+// failingStreamClient fails to start the streams, recording their context
+type failingStreamClient struct {
+	mockClient
+	ctx context.Context
+}
+
+func (f *failingStreamClient) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	f.ctx = ctx
+	return nil, errors.New("unavailable")
+}
+
+func TestClient_StreamFailureReleasesContext(t *testing.T) {
+	failing := &failingStreamClient{}
+	client := NewClient(failing, WithOutput(NewMaxLengthValidator(10), ActionBlock))
+
+	if _, err := client.ChatCompletionStream(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, "Hi"))); err == nil {
+		t.Fatal("expected the stream to fail")
+	}
+
+	if failing.ctx == nil || !errors.Is(failing.ctx.Err(), context.Canceled) {
+		t.Errorf("expected the context of the failed stream to be canceled")
+	}
+}
+
+func TestClient_StreamBufferRetries(t *testing.T) {
+	mock := &mockClient{responses: []string{"this answer is way too long", "short"}}
+	client := NewClient(mock, WithOutput(NewMaxLengthValidator(10), ActionRetry))
+
+	stream, err := client.ChatCompletionStream(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, "Hi")))
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %+v", err)
+	}
+
+	text, err := readStream(t, stream)
+	if err != nil {
+		t.Fatalf("stream failed: %+v", err)
+	}
+	if text != "short" {
+		t.Errorf("unexpected streamed text %q", text)
+	}
+}
+
+func TestClient_StreamIncremental(t *testing.T) {
+	mock := &mockClient{responses: []string{"one two forbidden three four"}}
+	client := NewClient(mock,
+		WithOutput(NewDenylistValidator("forbidden"), ActionBlock),
+		WithStreamMode(StreamModeIncremental),
+	)
+
+	stream, err := client.ChatCompletionStream(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, "Hi")))
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %+v", err)
+	}
+
+	text, err := readStream(t, stream)
+	if !errors.Is(err, ErrBlocked) {
+		t.Fatalf("expected the stream to be interrupted, got %v", err)
+	}
+	if text != "one two " {
+		t.Errorf("expected the text preceding the violation to be forwarded, got %q", text)
+	}
+}
+
+func TestClient_StreamIncrementalFinalValidators(t *testing.T) {
+	mock := &mockClient{responses: []string{"not json"}}
+
+	validator, err := NewJSONSchemaValidator(map[string]any{"type": "object"})
+	if err != nil {
+		t.Fatalf("NewJSONSchemaValidator: %+v", err)
+	}
+
+	client := NewClient(mock, WithOutput(validator, ActionBlock), WithStreamMode(StreamModeIncremental))
+
+	stream, err := client.ChatCompletionStream(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, "Hi")))
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %+v", err)
+	}
+
+	text, err := readStream(t, stream)
+	if !errors.Is(err, ErrBlocked) || text != "not json" {
+		t.Fatalf("expected the complete text then an error, got %q, %v", text, err)
+	}
+}
+
+func TestGuard_ChecksCandidates(t *testing.T) {
+	citations := []llm.Citation{{URL: "https://example.com", Title: "Example", StartIndex: 11, EndIndex: 20}}
+
+	message, _ := llm.WithCitations(llm.NewMessage(llm.RoleAssistant, "the key is sk-abc123"), citations)
+	leaking := llm.NewMessage(llm.RoleAssistant, "another key is sk-def456")
+	blocked := llm.NewMessage(llm.RoleAssistant, "the password is hunter2")
+	clean := llm.NewMessage(llm.RoleAssistant, "no key")
+	logprobs := []llm.TokenLogprob{{Token: "sk-abc123"}}
+
+	res := llm.NewCandidatesResponse(
+		llm.NewChatCompletionResponse(message, nil),
+		llm.NewCandidate(0, message, llm.FinishReasonStop, logprobs),
+		llm.NewCandidate(1, leaking, llm.FinishReasonStop, logprobs),
+		llm.NewCandidate(2, blocked, llm.FinishReasonStop, logprobs),
+		llm.NewCandidate(3, clean, llm.FinishReasonStop, []llm.TokenLogprob{{Token: "no"}}),
+	)
+
+	guard := NewGuard(&mockClient{},
+		WithOutput(NewRegexpValidator(secretPattern), ActionRedact),
+		WithOutput(NewDenylistValidator("password"), ActionBlock),
+	)
+
+	checked, err := guard.AfterChatCompletion(context.Background(), nil, res)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if got := checked.Message().Content(); got != "the key is [REDACTED]" {
+		t.Errorf("unexpected content %q", got)
+	}
+
+	// The citations are kept, without their offsets into the replaced content
+	if got := llm.CitationsOf(checked.Message()); len(got) != 1 || got[0].URL != "https://example.com" || got[0].StartIndex != 0 || got[0].EndIndex != 0 {
+		t.Errorf("expected the citations to be kept without offsets, got %+v", got)
+	}
+
+	candidates := llm.CandidatesOf(checked)
+
+	var contents []string
+	for _, c := range candidates {
+		contents = append(contents, c.Message().Content())
+		if secretPattern.MatchString(c.Message().Content()) || strings.Contains(c.Message().Content(), "password") {
+			t.Errorf("candidate %d was not validated: %q", c.Index(), c.Message().Content())
+		}
+		for _, lp := range c.Logprobs() {
+			if secretPattern.MatchString(lp.Token) {
+				t.Errorf("candidate %d leaks a redacted token in its log-probabilities", c.Index())
+			}
+		}
+	}
+
+	want := []string{"the key is [REDACTED]", "another key is [REDACTED]", "no key"}
+	if !slices.Equal(contents, want) {
+		t.Fatalf("candidates = %q, want %q", contents, want)
+	}
+
+	if len(candidates[2].Logprobs()) != 1 {
+		t.Errorf("expected the unchanged candidate to keep its log-probabilities")
+	}
+}
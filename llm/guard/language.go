@@ -0,0 +1,62 @@
+package guard
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// stopwords are frequent words of each language, too short to be
+// distinctive alone but telling on a whole text
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "of", "to", "in", "that", "it", "with", "for", "this", "was", "you", "not", "be", "have", "on"},
+	"fr": {"le", "la", "les", "et", "est", "sont", "des", "du", "un", "une", "que", "qui", "dans", "pour", "pas", "avec", "ce", "sur", "au"},
+	"es": {"el", "la", "los", "las", "y", "es", "son", "de", "del", "que", "en", "un", "una", "por", "con", "para", "no", "se", "lo"},
+	"de": {"der", "die", "das", "und", "ist", "sind", "ein", "eine", "nicht", "mit", "von", "zu", "den", "auf", "für", "sich", "dem", "ich"},
+	"it": {"il", "lo", "la", "gli", "le", "e", "è", "sono", "di", "che", "un", "una", "per", "con", "non", "del", "della", "nel"},
+	"pt": {"o", "a", "os", "as", "e", "é", "são", "de", "do", "da", "que", "em", "um", "uma", "para", "com", "não", "no", "na"},
+}
+
+// minLanguageHits is the number of stopwords required to tell the language
+// of a text
+const minLanguageHits = 3
+
+// DetectLanguage is a lightweight detector counting the stopwords of the
+// text for English, French, Spanish, German, Italian and Portuguese. It
+// returns an empty string for short texts or other languages.
+func DetectLanguage(text string) string {
+	counts := make(map[string]int, len(stopwords))
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	for _, word := range words {
+		for language, list := range stopwords {
+			if slices.Contains(list, word) {
+				counts[language]++
+			}
+		}
+	}
+
+	var (
+		best      string
+		bestCount int
+		tie       bool
+	)
+
+	for language, count := range counts {
+		switch {
+		case count > bestCount:
+			best, bestCount, tie = language, count, false
+		case count == bestCount:
+			tie = true
+		}
+	}
+
+	if bestCount < minLanguageHits || tie {
+		return ""
+	}
+
+	return best
+}
@@ -0,0 +1,113 @@
+package guard
+
+import (
+	"github.com/bornholm/genai/llm"
+)
+
+// Action is what the guard does when a validator reports a violation
+type Action string
+
+const (
+	// ActionBlock fails the call with a *ViolationError
+	ActionBlock Action = "block"
+	// ActionRedact replaces the spans of the violation by the redaction mask,
+	// blocking when the violation has no spans
+	ActionRedact Action = "redact"
+	// ActionRetry asks the model to answer again, the violation being given
+	// as feedback, and blocks once the retries are exhausted. Input
+	// violations cannot be retried and block.
+	ActionRetry Action = "retry"
+	// ActionLog logs the violation and lets the call go on
+	ActionLog Action = "log"
+)
+
+// StreamMode tells how streamed responses are validated
+type StreamMode string
+
+const (
+	// StreamModeBuffer holds the chunks back until the stream is complete
+	// and validated. Every action is supported.
+	StreamModeBuffer StreamMode = "buffer"
+	// StreamModeIncremental forwards the chunks as they come, partial
+	// validators scanning the text received so far and the others running
+	// once the stream is complete. The forwarded text cannot be taken back:
+	// violations can only be logged or interrupt the stream with an error
+	// chunk, redact and retry actions blocking.
+	StreamModeIncremental StreamMode = "incremental"
+)
+
+// Rule binds a validator to an action
+type Rule struct {
+	Validator Validator
+	Action    Action
+}
+
+type Options struct {
+	// Input rules validate the content of the messages sent to the model,
+	// in order
+	Input []Rule
+	// Output rules validate the content of the model responses, in order
+	Output []Rule
+	// InputRoles are the roles of the validated messages
+	InputRoles []llm.Role
+	// MaxRetries caps the new attempts of ActionRetry rules
+	MaxRetries int
+	// RedactionMask replaces the redacted spans
+	RedactionMask string
+	StreamMode    StreamMode
+}
+
+type OptionFunc func(opts *Options)
+
+func NewOptions(funcs ...OptionFunc) *Options {
+	opts := &Options{
+		InputRoles:    []llm.Role{llm.RoleUser},
+		MaxRetries:    2,
+		RedactionMask: "[REDACTED]",
+		StreamMode:    StreamModeBuffer,
+	}
+
+	for _, fn := range funcs {
+		fn(opts)
+	}
+
+	return opts
+}
+
+// WithInput appends a rule validating the messages sent to the model
+func WithInput(validator Validator, action Action) OptionFunc {
+	return func(opts *Options) {
+		opts.Input = append(opts.Input, Rule{Validator: validator, Action: action})
+	}
+}
+
+// WithOutput appends a rule validating the model responses
+func WithOutput(validator Validator, action Action) OptionFunc {
+	return func(opts *Options) {
+		opts.Output = append(opts.Output, Rule{Validator: validator, Action: action})
+	}
+}
+
+func WithInputRoles(roles ...llm.Role) OptionFunc {
+	return func(opts *Options) {
+		opts.InputRoles = roles
+	}
+}
+
+func WithMaxRetries(maxRetries int) OptionFunc {
+	return func(opts *Options) {
+		opts.MaxRetries = maxRetries
+	}
+}
+
+func WithRedactionMask(mask string) OptionFunc {
+	return func(opts *Options) {
+		opts.RedactionMask = mask
+	}
+}
+
+func WithStreamMode(mode StreamMode) OptionFunc {
+	return func(opts *Options) {
+		opts.StreamMode = mode
+	}
+}
@@ -0,0 +1,111 @@
+package guard
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// Span is a byte range [Start, End) of a validated text
+type Span struct {
+	Start int
+	End   int
+}
+
+// Violation describes why a text was rejected by a validator
+type Violation struct {
+	// Validator is the name of the validator reporting the violation
+	Validator string
+	// Message explains the violation. It is sent back to the model as
+	// feedback when the rule action is ActionRetry.
+	Message string
+	// Spans locate the offending parts of the text, replaced when the rule
+	// action is ActionRedact. A violation without spans cannot be redacted
+	// and blocks the call instead.
+	Spans []Span
+}
+
+// Validator checks a text, the content of a message or of a response.
+type Validator interface {
+	Name() string
+	// Validate returns a violation when the text is rejected, nil otherwise.
+	// The error is reserved to failures of the validator itself.
+	Validate(ctx context.Context, text string) (*Violation, error)
+}
+
+// PartialValidator is implemented by validators able to judge a partial
+// text, whose violations cannot be lifted by the remaining of the text. They
+// are run on each chunk of a stream scanned incrementally, the others once
+// the stream is complete.
+type PartialValidator interface {
+	Validator
+	ValidatesPartial() bool
+}
+
+// ValidatorFunc adapts a function to the Validator interface
+type ValidatorFunc func(ctx context.Context, text string) (*Violation, error)
+
+type funcValidator struct {
+	name string
+	fn   ValidatorFunc
+}
+
+// Name implements Validator.
+func (v *funcValidator) Name() string {
+	return v.name
+}
+
+// Validate implements Validator.
+func (v *funcValidator) Validate(ctx context.Context, text string) (*Violation, error) {
+	return v.fn(ctx, text)
+}
+
+// NewValidatorFunc creates a named validator from a function
+func NewValidatorFunc(name string, fn ValidatorFunc) Validator {
+	return &funcValidator{name: name, fn: fn}
+}
+
+func validatesPartial(validator Validator) bool {
+	partial, ok := validator.(PartialValidator)
+	return ok && partial.ValidatesPartial()
+}
+
+// redact replaces the spans of the text by the mask. Overlapping spans are
+// merged.
+func redact(text string, spans []Span, mask string) string {
+	var (
+		redacted []byte
+		cursor   int
+	)
+
+	for i, span := range sortSpans(spans) {
+		start := min(span.Start, len(text))
+		end := min(span.End, len(text))
+
+		// Extend the previous redaction over an overlapping span
+		if i > 0 && start < cursor {
+			cursor = max(cursor, end)
+			continue
+		}
+
+		if end <= start {
+			continue
+		}
+
+		redacted = append(redacted, text[cursor:start]...)
+		redacted = append(redacted, mask...)
+		cursor = end
+	}
+
+	redacted = append(redacted, text[cursor:]...)
+
+	return string(redacted)
+}
+
+func sortSpans(spans []Span) []Span {
+	sorted := slices.Clone(spans)
+	slices.SortFunc(sorted, func(a, b Span) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+	return sorted
+}
@@ -0,0 +1,352 @@
+package guard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/pkg/errors"
+)
+
+// RegexpValidator rejects texts matching any of its patterns
+type RegexpValidator struct {
+	name     string
+	patterns []*regexp.Regexp
+}
+
+// Name implements Validator.
+func (v *RegexpValidator) Name() string {
+	return v.name
+}
+
+// Validate implements Validator.
+func (v *RegexpValidator) Validate(ctx context.Context, text string) (*Violation, error) {
+	var spans []Span
+
+	for _, pattern := range v.patterns {
+		for _, match := range pattern.FindAllStringIndex(text, -1) {
+			spans = append(spans, Span{Start: match[0], End: match[1]})
+		}
+	}
+
+	if len(spans) == 0 {
+		return nil, nil
+	}
+
+	return &Violation{
+		Validator: v.name,
+		Message:   fmt.Sprintf("the text contains %d forbidden expression(s)", len(spans)),
+		Spans:     spans,
+	}, nil
+}
+
+// ValidatesPartial implements PartialValidator: a match found in a partial
+// text stays in the complete one.
+func (v *RegexpValidator) ValidatesPartial() bool {
+	return true
+}
+
+// NewRegexpValidator creates a validator rejecting the texts matching any of
+// the patterns
+func NewRegexpValidator(patterns ...*regexp.Regexp) *RegexpValidator {
+	return &RegexpValidator{
+		name:     "regexp",
+		patterns: patterns,
+	}
+}
+
+// NewDenylistValidator creates a validator rejecting the texts containing any
+// of the words, regardless of the case. The empty words are ignored.
+func NewDenylistValidator(words ...string) *RegexpValidator {
+	alternatives := make([]string, 0, len(words))
+	for _, w := range words {
+		if w == "" {
+			continue
+		}
+
+		// A word starting or ending with a non-word character, as "c++"
+		// or ".env", has no word boundary on that side
+		alternative := regexp.QuoteMeta(w)
+		if isWordChar(w[0]) {
+			alternative = `\b` + alternative
+		}
+		if isWordChar(w[len(w)-1]) {
+			alternative += `\b`
+		}
+
+		alternatives = append(alternatives, alternative)
+	}
+
+	validator := &RegexpValidator{name: "denylist"}
+
+	if len(alternatives) > 0 {
+		validator.patterns = []*regexp.Regexp{regexp.MustCompile(`(?i)(?:` + strings.Join(alternatives, "|") + `)`)}
+	}
+
+	return validator
+}
+
+// isWordChar tells if the byte is a word character of the \b assertion of
+// the regexp package, which only considers ASCII
+func isWordChar(b byte) bool {
+	return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+var _ PartialValidator = &RegexpValidator{}
+
+// MaxLengthValidator rejects texts longer than a number of characters. The
+// violation spans the exceeding characters, redaction truncating the text.
+type MaxLengthValidator struct {
+	max int
+}
+
+// Name implements Validator.
+func (v *MaxLengthValidator) Name() string {
+	return "max_length"
+}
+
+// Validate implements Validator.
+func (v *MaxLengthValidator) Validate(ctx context.Context, text string) (*Violation, error) {
+	length := utf8.RuneCountInString(text)
+	if length <= v.max {
+		return nil, nil
+	}
+
+	// Byte offset of the first exceeding character
+	offset := 0
+	for i := 0; i < v.max; i++ {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+
+	return &Violation{
+		Validator: v.Name(),
+		Message:   fmt.Sprintf("the text is %d characters long, exceeding the limit of %d characters", length, v.max),
+		Spans:     []Span{{Start: offset, End: len(text)}},
+	}, nil
+}
+
+// ValidatesPartial implements PartialValidator.
+func (v *MaxLengthValidator) ValidatesPartial() bool {
+	return true
+}
+
+func NewMaxLengthValidator(max int) *MaxLengthValidator {
+	return &MaxLengthValidator{max: max}
+}
+
+var _ PartialValidator = &MaxLengthValidator{}
+
+// JSONSchemaValidator rejects texts which are not a JSON document conforming
+// to a schema. Markdown code fences around the document are ignored.
+type JSONSchemaValidator struct {
+	schema *jsonschema.Resolved
+}
+
+// Name implements Validator.
+func (v *JSONSchemaValidator) Name() string {
+	return "json_schema"
+}
+
+// Validate implements Validator.
+func (v *JSONSchemaValidator) Validate(ctx context.Context, text string) (*Violation, error) {
+	var instance any
+	if err := json.Unmarshal([]byte(trimCodeFence(text)), &instance); err != nil {
+		return &Violation{
+			Validator: v.Name(),
+			Message:   fmt.Sprintf("the answer is not a valid JSON document: %s", err),
+		}, nil
+	}
+
+	if err := v.schema.Validate(instance); err != nil {
+		return &Violation{
+			Validator: v.Name(),
+			Message:   fmt.Sprintf("the answer does not conform to the expected JSON schema: %s", err),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// NewJSONSchemaValidator creates a validator checking the texts against the
+// JSON schema, as given to llm.NewResponseSchema.
+func NewJSONSchemaValidator(schema any) (*JSONSchemaValidator, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var s jsonschema.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, errors.Wrap(err, "invalid json schema")
+	}
+
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid json schema")
+	}
+
+	return &JSONSchemaValidator{schema: resolved}, nil
+}
+
+var _ Validator = &JSONSchemaValidator{}
+
+func trimCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+
+	if rest, found := strings.CutPrefix(text, "```"); found {
+		// Skip the language tag
+		if _, after, found := strings.Cut(rest, "\n"); found {
+			rest = after
+		}
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+	}
+
+	return text
+}
+
+// LanguageDetector returns the ISO 639-1 code of the language of a text, or
+// an empty string when it cannot tell.
+type LanguageDetector interface {
+	DetectLanguage(ctx context.Context, text string) (string, error)
+}
+
+type LanguageDetectorFunc func(ctx context.Context, text string) (string, error)
+
+// DetectLanguage implements LanguageDetector.
+func (fn LanguageDetectorFunc) DetectLanguage(ctx context.Context, text string) (string, error) {
+	return fn(ctx, text)
+}
+
+// LanguageValidator rejects texts written in a language other than the
+// allowed ones. Texts whose language cannot be detected are accepted.
+type LanguageValidator struct {
+	detector  LanguageDetector
+	languages []string
+}
+
+// Name implements Validator.
+func (v *LanguageValidator) Name() string {
+	return "language"
+}
+
+// Validate implements Validator.
+func (v *LanguageValidator) Validate(ctx context.Context, text string) (*Violation, error) {
+	language, err := v.detector.DetectLanguage(ctx, text)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not detect language")
+	}
+
+	if language == "" || slices.Contains(v.languages, language) {
+		return nil, nil
+	}
+
+	return &Violation{
+		Validator: v.Name(),
+		Message:   fmt.Sprintf("the text is written in '%s' instead of %s", language, strings.Join(v.languages, ", ")),
+	}, nil
+}
+
+// NewLanguageValidator creates a validator accepting the given languages, as
+// ISO 639-1 codes. A nil detector uses DetectLanguage.
+func NewLanguageValidator(detector LanguageDetector, languages ...string) *LanguageValidator {
+	if detector == nil {
+		detector = LanguageDetectorFunc(func(ctx context.Context, text string) (string, error) {
+			return DetectLanguage(text), nil
+		})
+	}
+
+	return &LanguageValidator{
+		detector:  detector,
+		languages: languages,
+	}
+}
+
+var _ Validator = &LanguageValidator{}
+
+// JudgeValidator asks a model whether texts meet criteria (LLM-as-judge)
+type JudgeValidator struct {
+	client   llm.ChatCompletionClient
+	criteria string
+	funcs    []llm.ChatCompletionOptionFunc
+}
+
+// Name implements Validator.
+func (v *JudgeValidator) Name() string {
+	return "judge"
+}
+
+const judgeSystemPrompt = `You are a strict reviewer. You check whether the text given by the user meets the following criteria:
+
+%s
+
+Answer with a JSON object: {"valid": true} when the text meets every criterion, {"valid": false, "reason": "<short explanation>"} otherwise.`
+
+var judgeSchema = llm.NewResponseSchema("verdict", "Verdict of the review", map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"valid":  map[string]any{"type": "boolean"},
+		"reason": map[string]any{"type": "string"},
+	},
+	"required": []string{"valid"},
+})
+
+// Validate implements Validator.
+func (v *JudgeValidator) Validate(ctx context.Context, text string) (*Violation, error) {
+	funcs := append([]llm.ChatCompletionOptionFunc{
+		llm.WithJSONResponse(judgeSchema),
+		llm.WithTemperature(0),
+	}, v.funcs...)
+
+	funcs = append(funcs, llm.WithMessages(
+		llm.NewMessage(llm.RoleSystem, fmt.Sprintf(judgeSystemPrompt, v.criteria)),
+		llm.NewMessage(llm.RoleUser, text),
+	))
+
+	res, err := v.client.ChatCompletion(ctx, funcs...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not judge text")
+	}
+
+	var verdict struct {
+		Valid  bool   `json:"valid"`
+		Reason string `json:"reason"`
+	}
+
+	if err := json.Unmarshal([]byte(trimCodeFence(res.Message().Content())), &verdict); err != nil {
+		return nil, errors.Wrapf(err, "invalid verdict '%s'", res.Message().Content())
+	}
+
+	if verdict.Valid {
+		return nil, nil
+	}
+
+	message := verdict.Reason
+	if message == "" {
+		message = "the text does not meet the criteria"
+	}
+
+	return &Violation{
+		Validator: v.Name(),
+		Message:   message,
+	}, nil
+}
+
+// NewJudgeValidator creates a validator asking the client whether texts meet
+// the criteria, described in natural language. The options are applied to
+// each judgment, to select a model for example.
+func NewJudgeValidator(client llm.ChatCompletionClient, criteria string, funcs ...llm.ChatCompletionOptionFunc) *JudgeValidator {
+	return &JudgeValidator{
+		client:   client,
+		criteria: criteria,
+		funcs:    funcs,
+	}
+}
+
+var _ Validator = &JudgeValidator{}
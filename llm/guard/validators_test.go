@@ -0,0 +1,110 @@
+package guard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+)
+
+func TestRedact(t *testing.T) {
+	got := redact("abcdefghij", []Span{{Start: 6, End: 8}, {Start: 1, End: 3}, {Start: 2, End: 4}}, "*")
+	if got != "a*ef*ij" {
+		t.Errorf("redact() = %q", got)
+	}
+}
+
+func TestMaxLengthValidator(t *testing.T) {
+	validator := NewMaxLengthValidator(4)
+
+	violation, err := validator.Validate(context.Background(), "héllo wörld")
+	if err != nil || violation == nil {
+		t.Fatalf("expected a violation, got %v, %v", violation, err)
+	}
+
+	if got := redact("héllo wörld", violation.Spans, ""); got != "héll" {
+		t.Errorf("truncated text %q, expected %q", got, "héll")
+	}
+
+	if violation, _ := validator.Validate(context.Background(), "héll"); violation != nil {
+		t.Errorf("unexpected violation %+v", violation)
+	}
+}
+
+func TestDenylistValidator(t *testing.T) {
+	validator := NewDenylistValidator("password", "", "c++", ".env", "été")
+
+	for _, tc := range []struct {
+		text  string
+		match string
+	}{
+		{"the Password is secret", "Password"},
+		{"no passwords here", ""},
+		{"written in C++ mostly", "C++"},
+		{"read the .env file", ".env"},
+		{"tout l'été", "été"},
+		{"nothing to see", ""},
+	} {
+		violation, err := validator.Validate(context.Background(), tc.text)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		var match string
+		if violation != nil {
+			match = tc.text[violation.Spans[0].Start:violation.Spans[0].End]
+		}
+
+		if match != tc.match {
+			t.Errorf("%q: matched %q, expected %q", tc.text, match, tc.match)
+		}
+	}
+
+	// Without words, nothing is rejected
+	if violation, _ := NewDenylistValidator("", "").Validate(context.Background(), "anything"); violation != nil {
+		t.Errorf("unexpected violation %+v", violation)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"The cat is sleeping on the sofa and it is happy with this.", "en"},
+		{"Le chat dort sur le canapé et il est content avec les enfants.", "fr"},
+		{"Der Hund ist nicht mit dem Ball auf der Wiese.", "de"},
+		{"Ok", ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.expected {
+			t.Errorf("DetectLanguage(%q) = %q, expected %q", tt.text, got, tt.expected)
+		}
+	}
+}
+
+type judgeClient struct {
+	verdict string
+}
+
+func (c *judgeClient) ChatCompletion(_ context.Context, _ ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	return llm.NewChatCompletionResponse(llm.NewMessage(llm.RoleAssistant, c.verdict), nil), nil
+}
+
+func TestJudgeValidator(t *testing.T) {
+	validator := NewJudgeValidator(&judgeClient{verdict: `{"valid": false, "reason": "the answer is rude"}`}, "The answer must be polite.")
+
+	violation, err := validator.Validate(context.Background(), "Go away.")
+	if err != nil {
+		t.Fatalf("Validate: %+v", err)
+	}
+	if violation == nil || violation.Message != "the answer is rude" {
+		t.Fatalf("unexpected violation %+v", violation)
+	}
+
+	validator = NewJudgeValidator(&judgeClient{verdict: `{"valid": true}`}, "The answer must be polite.")
+	if violation, err := validator.Validate(context.Background(), "Hello!"); err != nil || violation != nil {
+		t.Errorf("expected no violation, got %+v, %v", violation, err)
+	}
+}
@@ -37,6 +37,13 @@ func (fn BeforeChatCompletionStreamFunc) BeforeChatCompletionStream(ctx context.
 	return fn(ctx, funcs)
 }
 
+// ChatCompletionStreamErrorHook can be implemented by a
+// BeforeChatCompletionStreamHook to release what it holds in the context when
+// the stream fails to start, the after hooks not being called then.
+type ChatCompletionStreamErrorHook interface {
+	ChatCompletionStreamError(ctx context.Context, funcs []llm.ChatCompletionOptionFunc, err error)
+}
+
 type AfterChatCompletionStreamHook interface {
 	AfterChatCompletionStream(ctx context.Context, funcs []llm.ChatCompletionOptionFunc, stream <-chan llm.StreamChunk) (<-chan llm.StreamChunk, error)
 }
@@ -92,6 +99,26 @@ func (fn AfterTranscriptionFunc) AfterTranscription(ctx context.Context, audio [
 	return fn(ctx, audio, funcs, res)
 }
 
+type BeforeImageGenerationHook interface {
+	BeforeImageGeneration(ctx context.Context, prompt string, funcs []llm.ImageGenerationOptionFunc) (context.Context, string, []llm.ImageGenerationOptionFunc, error)
+}
+
+type BeforeImageGenerationFunc func(ctx context.Context, prompt string, funcs []llm.ImageGenerationOptionFunc) (context.Context, string, []llm.ImageGenerationOptionFunc, error)
+
+func (fn BeforeImageGenerationFunc) BeforeImageGeneration(ctx context.Context, prompt string, funcs []llm.ImageGenerationOptionFunc) (context.Context, string, []llm.ImageGenerationOptionFunc, error) {
+	return fn(ctx, prompt, funcs)
+}
+
+type AfterImageGenerationHook interface {
+	AfterImageGeneration(ctx context.Context, prompt string, funcs []llm.ImageGenerationOptionFunc, res llm.ImageGenerationResponse) (llm.ImageGenerationResponse, error)
+}
+
+type AfterImageGenerationFunc func(ctx context.Context, prompt string, funcs []llm.ImageGenerationOptionFunc, res llm.ImageGenerationResponse) (llm.ImageGenerationResponse, error)
+
+func (fn AfterImageGenerationFunc) AfterImageGeneration(ctx context.Context, prompt string, funcs []llm.ImageGenerationOptionFunc, res llm.ImageGenerationResponse) (llm.ImageGenerationResponse, error) {
+	return fn(ctx, prompt, funcs, res)
+}
+
 type Client struct {
 	client llm.Client
 
@@ -105,6 +132,9 @@ type Client struct {
 
 	beforeTranscription BeforeTranscriptionHook
 	afterTranscription  AfterTranscriptionHook
+
+	beforeImageGeneration BeforeImageGenerationHook
+	afterImageGeneration  AfterImageGenerationHook
 }
 
 type Options struct {
@@ -116,6 +146,8 @@ type Options struct {
 	AfterEmbeddings            AfterEmbeddingsHook
 	BeforeTranscription        BeforeTranscriptionHook
 	AfterTranscription         AfterTranscriptionHook
+	BeforeImageGeneration      BeforeImageGenerationHook
+	AfterImageGeneration       AfterImageGenerationHook
 }
 
 type OptionFunc func(opts *Options)
@@ -208,6 +240,30 @@ func WithAfterChatCompletionStreamFunc(fn AfterChatCompletionStreamFunc) OptionF
 	return WithAfterChatCompletionStream(AfterChatCompletionStreamHook(fn))
 }
 
+// WithBeforeImageGeneration sets a hook called before image generations,
+// edits and variations
+func WithBeforeImageGeneration(hook BeforeImageGenerationHook) OptionFunc {
+	return func(opts *Options) {
+		opts.BeforeImageGeneration = hook
+	}
+}
+
+func WithBeforeImageGenerationFunc(fn BeforeImageGenerationFunc) OptionFunc {
+	return WithBeforeImageGeneration(BeforeImageGenerationHook(fn))
+}
+
+// WithAfterImageGeneration sets a hook called after image generations, edits
+// and variations
+func WithAfterImageGeneration(hook AfterImageGenerationHook) OptionFunc {
+	return func(opts *Options) {
+		opts.AfterImageGeneration = hook
+	}
+}
+
+func WithAfterImageGenerationFunc(fn AfterImageGenerationFunc) OptionFunc {
+	return WithAfterImageGeneration(AfterImageGenerationHook(fn))
+}
+
 // ChatCompletion implements llm.Client.
 func (c *Client) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
 	var err error
@@ -299,6 +355,10 @@ func (c *Client) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatComp
 
 	stream, err := c.client.ChatCompletionStream(ctx, funcs...)
 	if err != nil {
+		if h, ok := c.beforeChatCompletionStream.(ChatCompletionStreamErrorHook); ok {
+			h.ChatCompletionStreamError(ctx, funcs, err)
+		}
+
 		return nil, errors.WithStack(err)
 	}
 
@@ -312,6 +372,69 @@ func (c *Client) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatComp
 	return stream, nil
 }
 
+// ImageGeneration implements llm.ImageGenerationClient.
+func (c *Client) ImageGeneration(ctx context.Context, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return c.imageGeneration(ctx, prompt, funcs, func(ctx context.Context, prompt string, funcs []llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+		return llm.DelegateImageGeneration(ctx, c.client, prompt, funcs...)
+	})
+}
+
+// ImageEdit implements llm.ImageEditClient.
+func (c *Client) ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return c.imageGeneration(ctx, prompt, funcs, func(ctx context.Context, prompt string, funcs []llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+		return llm.DelegateImageEdit(ctx, c.client, image, prompt, funcs...)
+	})
+}
+
+// ImageVariation implements llm.ImageEditClient. The hooks receive an empty
+// prompt.
+func (c *Client) ImageVariation(ctx context.Context, image []byte, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return c.imageGeneration(ctx, "", funcs, func(ctx context.Context, _ string, funcs []llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+		return llm.DelegateImageVariation(ctx, c.client, image, funcs...)
+	})
+}
+
+// Complete implements llm.CompletionClient. No hook applies to the raw
+// text completions.
+func (c *Client) Complete(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	return llm.DelegateComplete(ctx, c.client, prompt, suffix, funcs...)
+}
+
+// CompleteStream implements llm.CompletionClient.
+func (c *Client) CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	return llm.DelegateCompleteStream(ctx, c.client, prompt, suffix, funcs...)
+}
+
+func (c *Client) imageGeneration(ctx context.Context, prompt string, funcs []llm.ImageGenerationOptionFunc, generate func(ctx context.Context, prompt string, funcs []llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error)) (llm.ImageGenerationResponse, error) {
+	var err error
+
+	if c.beforeImageGeneration != nil {
+		ctx, prompt, funcs, err = c.beforeImageGeneration.BeforeImageGeneration(ctx, prompt, funcs)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	res, err := generate(ctx, prompt, funcs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if c.afterImageGeneration != nil {
+		res, err = c.afterImageGeneration.AfterImageGeneration(ctx, prompt, funcs, res)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return res, nil
+}
+
+// ValidateAttachment implements llm.AttachmentValidator.
+func (c *Client) ValidateAttachment(attachment llm.Attachment) error {
+	return llm.DelegateValidateAttachment(c.client, attachment)
+}
+
 func NewClient(client llm.Client, funcs ...OptionFunc) *Client {
 	opts := NewOptions(funcs...)
 	return &Client{
@@ -324,7 +447,15 @@ func NewClient(client llm.Client, funcs ...OptionFunc) *Client {
 		afterEmbeddings:            opts.AfterEmbeddings,
 		beforeTranscription:        opts.BeforeTranscription,
 		afterTranscription:         opts.AfterTranscription,
+		beforeImageGeneration:      opts.BeforeImageGeneration,
+		afterImageGeneration:       opts.AfterImageGeneration,
 	}
 }
 
-var _ llm.Client = &Client{}
+var (
+	_ llm.Client                = &Client{}
+	_ llm.ImageGenerationClient = &Client{}
+	_ llm.ImageEditClient       = &Client{}
+	_ llm.CompletionClient      = &Client{}
+	_ llm.AttachmentValidator   = &Client{}
+)
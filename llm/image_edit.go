@@ -0,0 +1,19 @@
+package llm
+
+import "context"
+
+// ImageEditClient edits existing images: inpainting guided by a prompt and an
+// optional mask (see [WithImageMask]), and prompt-less variations.
+//
+// Like [ImageGenerationClient], it is an optional interface discovered with a
+// type assertion:
+//
+//	if editor, ok := client.(llm.ImageEditClient); ok {
+//	    // ...
+//	}
+type ImageEditClient interface {
+	// ImageEdit returns the source image modified as described by the prompt.
+	ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...ImageGenerationOptionFunc) (ImageGenerationResponse, error)
+	// ImageVariation returns variations of the source image.
+	ImageVariation(ctx context.Context, image []byte, funcs ...ImageGenerationOptionFunc) (ImageGenerationResponse, error)
+}
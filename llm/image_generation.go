@@ -23,6 +23,9 @@ type ImageGenerationClient interface {
 // interface. Size and AspectRatio express the same intent in the two
 // dialects found in the wild — providers map one to the other when they can.
 type ImageGenerationOptions struct {
+	// Model overrides the model configured on the client for this call.
+	// Empty means the client's model.
+	Model string
 	// Count is the number of images requested. Zero means one.
 	Count int
 	// Size is a pixel size such as "1024x1024" (OpenAI dialect).
@@ -31,6 +34,9 @@ type ImageGenerationOptions struct {
 	AspectRatio string
 	// Quality is a provider-specific quality level, e.g. "standard" or "hd".
 	Quality string
+	// Mask marks the area of the source image to edit, its transparent
+	// pixels standing for the editable area. Only used by ImageEdit.
+	Mask []byte
 }
 
 func NewImageGenerationOptions(funcs ...ImageGenerationOptionFunc) *ImageGenerationOptions {
@@ -43,6 +49,13 @@ func NewImageGenerationOptions(funcs ...ImageGenerationOptionFunc) *ImageGenerat
 
 type ImageGenerationOptionFunc func(opts *ImageGenerationOptions)
 
+// WithImageModel overrides the model configured on the client for this call
+func WithImageModel(model string) ImageGenerationOptionFunc {
+	return func(opts *ImageGenerationOptions) {
+		opts.Model = model
+	}
+}
+
 func WithImageCount(count int) ImageGenerationOptionFunc {
 	return func(opts *ImageGenerationOptions) {
 		opts.Count = count
@@ -67,6 +80,14 @@ func WithImageQuality(quality string) ImageGenerationOptionFunc {
 	}
 }
 
+// WithImageMask sets the mask restricting an ImageEdit to the transparent
+// area of the mask (inpainting)
+func WithImageMask(mask []byte) ImageGenerationOptionFunc {
+	return func(opts *ImageGenerationOptions) {
+		opts.Mask = mask
+	}
+}
+
 // ImageGenerationResponse carries the generated images.
 type ImageGenerationResponse interface {
 	Images() []GeneratedImage
@@ -89,6 +110,46 @@ type GeneratedImage interface {
 	RevisedPrompt() string
 }
 
+// ImageFormat is the form under which the provider returned an image.
+type ImageFormat string
+
+const (
+	// ImageFormatBase64 is an image returned inline, base64 encoded.
+	ImageFormatBase64 ImageFormat = "b64_json"
+	// ImageFormatURL is an image returned as a URL, fetched by the provider
+	// implementation before returning.
+	ImageFormatURL ImageFormat = "url"
+)
+
+// ImageSource is implemented by generated images reporting how the provider
+// returned them. URL is only set for ImageFormatURL images; such URLs are
+// usually short-lived.
+type ImageSource interface {
+	Format() ImageFormat
+	URL() string
+}
+
+// ImageFormatOf returns the format under which img was returned, assuming
+// base64 when the image does not report it.
+func ImageFormatOf(img GeneratedImage) ImageFormat {
+	if source, ok := img.(ImageSource); ok && source.Format() != "" {
+		return source.Format()
+	}
+	return ImageFormatBase64
+}
+
+// RevisedPrompts returns the non-empty revised prompts of the response
+// images, in order.
+func RevisedPrompts(res ImageGenerationResponse) []string {
+	prompts := make([]string, 0, len(res.Images()))
+	for _, img := range res.Images() {
+		if revised := img.RevisedPrompt(); revised != "" {
+			prompts = append(prompts, revised)
+		}
+	}
+	return prompts
+}
+
 type ImageGenerationUsage interface {
 	InputTokens() int64
 	OutputTokens() int64
@@ -99,6 +160,8 @@ type BaseGeneratedImage struct {
 	data          []byte
 	mediaType     string
 	revisedPrompt string
+	format        ImageFormat
+	url           string
 }
 
 // Data implements GeneratedImage.
@@ -110,11 +173,26 @@ func (i *BaseGeneratedImage) MediaType() string { return i.mediaType }
 // RevisedPrompt implements GeneratedImage.
 func (i *BaseGeneratedImage) RevisedPrompt() string { return i.revisedPrompt }
 
+// Format implements ImageSource.
+func (i *BaseGeneratedImage) Format() ImageFormat { return i.format }
+
+// URL implements ImageSource.
+func (i *BaseGeneratedImage) URL() string { return i.url }
+
 func NewGeneratedImage(data []byte, mediaType, revisedPrompt string) *BaseGeneratedImage {
-	return &BaseGeneratedImage{data: data, mediaType: mediaType, revisedPrompt: revisedPrompt}
+	return &BaseGeneratedImage{data: data, mediaType: mediaType, revisedPrompt: revisedPrompt, format: ImageFormatBase64}
+}
+
+// NewGeneratedImageFromURL builds an image the provider returned as a URL,
+// data being the bytes fetched from it.
+func NewGeneratedImageFromURL(data []byte, mediaType, revisedPrompt, url string) *BaseGeneratedImage {
+	return &BaseGeneratedImage{data: data, mediaType: mediaType, revisedPrompt: revisedPrompt, format: ImageFormatURL, url: url}
 }
 
-var _ GeneratedImage = &BaseGeneratedImage{}
+var (
+	_ GeneratedImage = &BaseGeneratedImage{}
+	_ ImageSource    = &BaseGeneratedImage{}
+)
 
 type BaseImageGenerationUsage struct {
 	inputTokens  int64
@@ -0,0 +1,291 @@
+package longaudio
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+// Client transcribes audio exceeding the provider limits by splitting it in
+// chunks, transcribed concurrently then stitched back together. Audio within
+// the limits is passed through untouched.
+type Client struct {
+	client llm.Client
+	opts   *Options
+}
+
+// ChatCompletion implements llm.Client.
+func (c *Client) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	return c.client.ChatCompletion(ctx, funcs...)
+}
+
+// ChatCompletionStream implements llm.Client.
+func (c *Client) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	return c.client.ChatCompletionStream(ctx, funcs...)
+}
+
+// Embeddings implements llm.Client.
+func (c *Client) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	return c.client.Embeddings(ctx, inputs, funcs...)
+}
+
+// Transcription implements llm.Client.
+func (c *Client) Transcription(ctx context.Context, audio []byte, funcs ...llm.TranscriptionOptionFunc) (llm.TranscriptionResponse, error) {
+	opts := llm.NewTranscriptionOptions(funcs...)
+
+	format := opts.Format
+	if format == "" {
+		format = llm.DetectAudioFormat(audio)
+	}
+
+	chunks, err := split(audio, format, c.opts)
+	if err != nil {
+		// Leave audio the wrapper cannot split to the provider as long as it
+		// may accept it
+		if errors.Is(err, ErrUnsupportedFormat) && !exceeds(len(audio), c.opts.MaxChunkSize) {
+			return c.client.Transcription(ctx, audio, funcs...)
+		}
+
+		return nil, errors.Wrap(err, "could not split audio")
+	}
+
+	if len(chunks) == 1 {
+		return c.client.Transcription(ctx, audio, funcs...)
+	}
+
+	responses, err := c.transcribe(ctx, chunks, format, opts, funcs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return stitch(chunks, responses), nil
+}
+
+// ImageGeneration implements llm.ImageGenerationClient.
+func (c *Client) ImageGeneration(ctx context.Context, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return llm.DelegateImageGeneration(ctx, c.client, prompt, funcs...)
+}
+
+// ImageEdit implements llm.ImageEditClient.
+func (c *Client) ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return llm.DelegateImageEdit(ctx, c.client, image, prompt, funcs...)
+}
+
+// ImageVariation implements llm.ImageEditClient.
+func (c *Client) ImageVariation(ctx context.Context, image []byte, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return llm.DelegateImageVariation(ctx, c.client, image, funcs...)
+}
+
+// Complete implements llm.CompletionClient.
+func (c *Client) Complete(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	return llm.DelegateComplete(ctx, c.client, prompt, suffix, funcs...)
+}
+
+// CompleteStream implements llm.CompletionClient.
+func (c *Client) CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	return llm.DelegateCompleteStream(ctx, c.client, prompt, suffix, funcs...)
+}
+
+// ValidateAttachment implements llm.AttachmentValidator.
+func (c *Client) ValidateAttachment(attachment llm.Attachment) error {
+	return llm.DelegateValidateAttachment(c.client, attachment)
+}
+
+// transcribe distributes the chunks in contiguous runs, one per worker. Each
+// run is transcribed sequentially, the tail of a chunk transcription serving
+// as prompt for the next one.
+func (c *Client) transcribe(ctx context.Context, chunks []Chunk, format llm.AudioFormat, opts *llm.TranscriptionOptions, funcs []llm.TranscriptionOptionFunc) ([]llm.TranscriptionResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := min(max(c.opts.Concurrency, 1), len(chunks))
+	responses := make([]llm.TranscriptionResponse, len(chunks))
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	for worker := range workers {
+		from := worker * len(chunks) / workers
+		to := (worker + 1) * len(chunks) / workers
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var previous string
+			for i := from; i < to; i++ {
+				chunkFuncs := append(append([]llm.TranscriptionOptionFunc{}, funcs...), llm.WithAudioFormat(format))
+				if prompt := c.prompt(opts.Prompt, previous); prompt != "" {
+					chunkFuncs = append(chunkFuncs, llm.WithTranscriptionPrompt(prompt))
+				}
+
+				res, err := c.client.Transcription(ctx, chunks[i].Data, chunkFuncs...)
+				if err != nil {
+					once.Do(func() {
+						firstErr = errors.Wrapf(err, "could not transcribe chunk %d/%d at %s", i+1, len(chunks), chunks[i].Offset)
+						cancel()
+					})
+					return
+				}
+
+				responses[i] = res
+				previous = res.Text()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return responses, nil
+}
+
+// prompt appends the tail of the previous transcription to the prompt given
+// by the caller.
+func (c *Client) prompt(prompt string, previous string) string {
+	if c.opts.PromptTail <= 0 || previous == "" {
+		return prompt
+	}
+
+	text := strings.TrimSpace(previous)
+	tail := text
+	if len(text) > c.opts.PromptTail {
+		cut := len(text) - c.opts.PromptTail
+		tail = text[cut:]
+		// Do not start on a partial word, or a partial UTF-8 sequence
+		if i := strings.IndexAny(tail, " \n\t"); i >= 0 && !strings.ContainsAny(text[cut-1:cut], " \n\t") {
+			tail = tail[i+1:]
+		}
+		tail = strings.ToValidUTF8(tail, "")
+	}
+
+	return strings.TrimSpace(prompt + " " + tail)
+}
+
+// stitch joins the chunk transcriptions, shifting their timestamps by the
+// chunk offsets.
+func stitch(chunks []Chunk, responses []llm.TranscriptionResponse) llm.TranscriptionResponse {
+	var (
+		texts    = make([]string, 0, len(responses))
+		language string
+		segments []llm.TranscriptionSegment
+		words    []llm.TranscriptionWord
+		timed    bool
+	)
+
+	for i, res := range responses {
+		if text := strings.TrimSpace(res.Text()); text != "" {
+			texts = append(texts, text)
+		}
+
+		if language == "" {
+			language = res.Language()
+		}
+
+		timedRes, ok := res.(llm.TimedTranscriptionResponse)
+		if !ok {
+			continue
+		}
+
+		timed = true
+		offset := chunks[i].Offset
+
+		for _, s := range timedRes.Segments() {
+			s.Start += offset
+			s.End += offset
+			segments = append(segments, s)
+		}
+
+		for _, w := range timedRes.Words() {
+			w.Start += offset
+			w.End += offset
+			words = append(words, w)
+		}
+	}
+
+	text := strings.Join(texts, " ")
+	usage := sumUsage(responses)
+
+	if timed {
+		return llm.NewTimedTranscriptionResponse(text, language, usage, segments, words)
+	}
+
+	return llm.NewTranscriptionResponse(text, language, usage)
+}
+
+// sumUsage adds up the usage of the chunks. The cost is only reported when
+// every chunk reported it.
+func sumUsage(responses []llm.TranscriptionResponse) llm.TranscriptionUsage {
+	var (
+		inputTokens, outputTokens, totalTokens int64
+		audioSeconds                           float64
+		cost                                   float64
+		currency                               string
+		costReported                           = true
+		reported                               bool
+	)
+
+	for _, res := range responses {
+		usage := res.Usage()
+		if usage == nil {
+			costReported = false
+			continue
+		}
+
+		reported = true
+		inputTokens += usage.InputTokens()
+		outputTokens += usage.OutputTokens()
+		totalTokens += usage.TotalTokens()
+
+		audioSeconds += usage.AudioSeconds()
+
+		costUsage, ok := usage.(llm.CostReportingUsage)
+		if !ok {
+			costReported = false
+			continue
+		}
+
+		amount, cur, ok := costUsage.Cost()
+		if !ok {
+			costReported = false
+			continue
+		}
+
+		cost += amount
+		currency = cur
+	}
+
+	if !reported {
+		return nil
+	}
+
+	if costReported {
+		return llm.NewTranscriptionUsageWithCost(inputTokens, outputTokens, totalTokens, audioSeconds, cost, currency)
+	}
+
+	return llm.NewTranscriptionUsage(inputTokens, outputTokens, totalTokens, audioSeconds)
+}
+
+func NewClient(client llm.Client, funcs ...OptionFunc) *Client {
+	return &Client{
+		client: client,
+		opts:   NewOptions(funcs...),
+	}
+}
+
+var (
+	_ llm.Client                = &Client{}
+	_ llm.ImageGenerationClient = &Client{}
+	_ llm.ImageEditClient       = &Client{}
+	_ llm.CompletionClient      = &Client{}
+	_ llm.AttachmentValidator   = &Client{}
+)
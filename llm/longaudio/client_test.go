@@ -0,0 +1,133 @@
+package longaudio
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bornholm/genai/llm"
+)
+
+type mockTranscriptionClient struct {
+	mu      sync.Mutex
+	prompts map[time.Duration]string
+	calls   int
+}
+
+func (m *mockTranscriptionClient) ChatCompletion(_ context.Context, _ ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	return nil, nil
+}
+
+func (m *mockTranscriptionClient) ChatCompletionStream(_ context.Context, _ ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	return nil, nil
+}
+
+func (m *mockTranscriptionClient) Embeddings(_ context.Context, _ []string, _ ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	return nil, nil
+}
+
+// Transcription answers with the duration of the audio, as a single segment.
+func (m *mockTranscriptionClient) Transcription(_ context.Context, audio []byte, funcs ...llm.TranscriptionOptionFunc) (llm.TranscriptionResponse, error) {
+	opts := llm.NewTranscriptionOptions(funcs...)
+	if opts.Format != llm.AudioFormatWAV {
+		return nil, fmt.Errorf("unexpected format %q", opts.Format)
+	}
+
+	s, err := parseWAV(audio)
+	if err != nil {
+		return nil, err
+	}
+	last := s.units[len(s.units)-1]
+	duration := last.start + last.duration
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.prompts == nil {
+		m.prompts = map[time.Duration]string{}
+	}
+	m.prompts[duration] = opts.Prompt
+
+	text := fmt.Sprintf("chunk of %s.", duration)
+	usage := llm.NewTranscriptionUsageWithCost(1, 2, 3, duration.Seconds(), 0.01, "USD")
+
+	return llm.NewTimedTranscriptionResponse(text, "en", usage, []llm.TranscriptionSegment{
+		{Start: 0, End: duration, Text: text},
+	}, nil), nil
+}
+
+func TestClient_Transcription(t *testing.T) {
+	audio := testWAV(45*time.Second, 0, 0)
+
+	mock := &mockTranscriptionClient{}
+	client := NewClient(mock, WithMaxChunkDuration(20*time.Second), WithConcurrency(1))
+
+	res, err := client.Transcription(context.Background(), audio, llm.WithTranscriptionPrompt("Meeting."))
+	if err != nil {
+		t.Fatalf("Transcription: %+v", err)
+	}
+
+	if mock.calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", mock.calls)
+	}
+
+	if got, expected := res.Text(), "chunk of 20s. chunk of 20s. chunk of 5s."; got != expected {
+		t.Errorf("text %q, expected %q", got, expected)
+	}
+
+	// Chunks are transcribed sequentially with a single worker, each one
+	// prompted with the tail of the previous one
+	if got := mock.prompts[5*time.Second]; got != "Meeting. chunk of 20s." {
+		t.Errorf("prompt of the last chunk %q", got)
+	}
+
+	timed, ok := res.(llm.TimedTranscriptionResponse)
+	if !ok {
+		t.Fatalf("expected a timed response, got %T", res)
+	}
+
+	segments := timed.Segments()
+	if len(segments) != 3 {
+		t.Fatalf("expected 3 segments, got %d", len(segments))
+	}
+	if segments[2].Start != 40*time.Second || segments[2].End != 45*time.Second {
+		t.Errorf("last segment not offset: %+v", segments[2])
+	}
+
+	usage := res.Usage()
+	if usage.TotalTokens() != 9 || usage.AudioSeconds() != 45 {
+		t.Errorf("usage not summed: %d tokens, %gs", usage.TotalTokens(), usage.AudioSeconds())
+	}
+	if cost, _, ok := usage.(llm.CostReportingUsage).Cost(); !ok || cost < 0.029 || cost > 0.031 {
+		t.Errorf("cost %g (reported: %v)", cost, ok)
+	}
+}
+
+func TestClient_TranscriptionPassthrough(t *testing.T) {
+	audio := testWAV(5*time.Second, 0, 0)
+
+	mock := &mockTranscriptionClient{}
+	client := NewClient(mock, WithMaxChunkDuration(20*time.Second))
+
+	res, err := client.Transcription(context.Background(), audio, llm.WithAudioFormat(llm.AudioFormatWAV))
+	if err != nil {
+		t.Fatalf("Transcription: %+v", err)
+	}
+
+	if mock.calls != 1 || res.Text() != "chunk of 5s." {
+		t.Errorf("audio within the limits should be passed through, got %d calls", mock.calls)
+	}
+}
+
+func TestPromptTail(t *testing.T) {
+	client := NewClient(nil, WithPromptTail(12))
+
+	if got := client.prompt("", "the quick brown fox jumps"); got != "fox jumps" {
+		t.Errorf("tail %q, expected %q", got, "fox jumps")
+	}
+	if got := client.prompt("Glossary.", ""); got != "Glossary." {
+		t.Errorf("prompt %q, expected the caller prompt", got)
+	}
+}
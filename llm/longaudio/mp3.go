@@ -0,0 +1,177 @@
+package longaudio
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MPEG audio versions as encoded in the frame header
+const (
+	mpegVersion25 = 0
+	mpegVersion2  = 2
+	mpegVersion1  = 3
+)
+
+// MPEG audio layers as encoded in the frame header
+const (
+	mpegLayer3 = 1
+	mpegLayer2 = 2
+	mpegLayer1 = 3
+)
+
+// Bitrates in kbit/s, indexed by [version 1 or not][layer][bitrate index]
+var mpegBitrates = [2][4][16]int{
+	{ // MPEG 2 & 2.5
+		mpegLayer3: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		mpegLayer2: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		mpegLayer1: {0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+	},
+	{ // MPEG 1
+		mpegLayer3: {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		mpegLayer2: {0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		mpegLayer1: {0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+	},
+}
+
+// Sample rates in Hz, indexed by [version][sample rate index]
+var mpegSampleRates = [4][3]int{
+	mpegVersion25: {11025, 12000, 8000},
+	mpegVersion2:  {22050, 24000, 16000},
+	mpegVersion1:  {44100, 48000, 32000},
+}
+
+type mpegFrame struct {
+	offset  int
+	size    int
+	samples int
+	rate    int
+	bitrate int
+}
+
+// parseMP3 splits an MPEG audio stream on its frames. Frames are
+// independent enough for a decoder to start on any of them, at the cost of
+// a few milliseconds of bit reservoir. MP3 cannot be analysed without
+// decoding it: the bitrate of VBR frames, lower on silence, stands for the
+// loudness, leaving CBR streams cut at the limit.
+func parseMP3(audio []byte) (*stream, error) {
+	offset := id3v2Size(audio)
+
+	frames := make([]mpegFrame, 0, len(audio)/400)
+	for offset+4 <= len(audio) {
+		frame, ok := parseMPEGFrame(audio[offset:])
+		if !ok || offset+frame.size > len(audio) {
+			// Resynchronise on the next frame header, skipping garbage or a
+			// trailing ID3v1 tag
+			offset++
+			continue
+		}
+
+		frame.offset = offset
+		frames = append(frames, frame)
+		offset += frame.size
+	}
+
+	// The first frame of VBR files usually is a Xing/Info/VBRI header
+	// describing the whole stream, wrong for a chunk of it.
+	if len(frames) > 0 && isVBRHeader(audio[frames[0].offset:frames[0].offset+frames[0].size]) {
+		frames = frames[1:]
+	}
+
+	if len(frames) == 0 {
+		return nil, errors.Wrap(ErrMalformedAudio, "no MPEG audio frame found")
+	}
+
+	units := make([]unit, 0, len(frames))
+
+	var start time.Duration
+	for _, f := range frames {
+		duration := time.Duration(f.samples) * time.Second / time.Duration(f.rate)
+		units = append(units, unit{
+			size:     f.size,
+			start:    start,
+			duration: duration,
+			loudness: float64(f.bitrate),
+			cuttable: true,
+		})
+		start += duration
+	}
+
+	return &stream{
+		units: units,
+		assemble: func(from, to int) []byte {
+			start := frames[from].offset
+			end := frames[to-1].offset + frames[to-1].size
+			return bytes.Clone(audio[start:end])
+		},
+	}, nil
+}
+
+func parseMPEGFrame(b []byte) (mpegFrame, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return mpegFrame{}, false
+	}
+
+	var (
+		version      = int(b[1]>>3) & 0x03
+		layer        = int(b[1]>>1) & 0x03
+		bitrateIndex = int(b[2] >> 4)
+		rateIndex    = int(b[2]>>2) & 0x03
+		padding      = int(b[2]>>1) & 0x01
+	)
+
+	if version == 1 || layer == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mpegFrame{}, false
+	}
+
+	v1 := 0
+	if version == mpegVersion1 {
+		v1 = 1
+	}
+
+	bitrate := mpegBitrates[v1][layer][bitrateIndex] * 1000
+	rate := mpegSampleRates[version][rateIndex]
+
+	var samples, size int
+	switch layer {
+	case mpegLayer1:
+		samples = 384
+		size = (12*bitrate/rate + padding) * 4
+	case mpegLayer2:
+		samples = 1152
+		size = 144*bitrate/rate + padding
+	case mpegLayer3:
+		samples = 1152
+		if version != mpegVersion1 {
+			samples = 576
+		}
+		size = samples/8*bitrate/rate + padding
+	}
+
+	return mpegFrame{size: size, samples: samples, rate: rate, bitrate: bitrate}, true
+}
+
+// id3v2Size returns the size of the ID3v2 tag heading the audio, if any.
+func id3v2Size(audio []byte) int {
+	if len(audio) < 10 || !bytes.HasPrefix(audio, []byte("ID3")) {
+		return 0
+	}
+
+	size := int(audio[6]&0x7F)<<21 | int(audio[7]&0x7F)<<14 | int(audio[8]&0x7F)<<7 | int(audio[9]&0x7F)
+	size += 10
+
+	// Footer flag
+	if audio[5]&0x10 != 0 {
+		size += 10
+	}
+
+	return min(size, len(audio))
+}
+
+func isVBRHeader(frame []byte) bool {
+	// The tag follows the side information, whose size depends on the
+	// version and channel mode: searching the first bytes covers them all.
+	head := frame[:min(len(frame), 64)]
+	return bytes.Contains(head, []byte("Xing")) || bytes.Contains(head, []byte("Info")) || bytes.Contains(head, []byte("VBRI"))
+}
@@ -0,0 +1,198 @@
+package longaudio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	oggHeaderSize = 27
+
+	oggFlagContinued = 0x01
+	oggFlagBOS       = 0x02
+	oggFlagEOS       = 0x04
+
+	// oggNoGranule marks pages on which no packet ends.
+	oggNoGranule = ^uint64(0)
+)
+
+type oggPage struct {
+	data        []byte
+	granule     uint64
+	flags       byte
+	serial      uint32
+	headerBytes int
+}
+
+// parseOGG splits a single Vorbis or Opus logical stream on its pages. The
+// header pages are repeated at the start of each chunk, pages are renumbered
+// and their checksums recomputed so that every chunk is a valid stream.
+// Loudness is not estimated, chunks are cut at the limit.
+func parseOGG(audio []byte) (*stream, error) {
+	pages := make([]oggPage, 0, len(audio)/4096)
+
+	for offset := 0; offset < len(audio); {
+		page, err := parseOGGPage(audio[offset:])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if len(pages) > 0 && page.serial != pages[0].serial {
+			return nil, errors.Wrap(ErrUnsupportedFormat, "multiplexed ogg streams cannot be split")
+		}
+
+		pages = append(pages, page)
+		offset += len(page.data)
+	}
+
+	if len(pages) == 0 {
+		return nil, errors.Wrap(ErrMalformedAudio, "no ogg page found")
+	}
+
+	rate, err := oggSampleRate(pages[0])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Header pages only carry codec setup packets, with a null granule
+	// position.
+	headers := 0
+	for headers < len(pages) && (pages[headers].granule == 0 || pages[headers].granule == oggNoGranule) {
+		headers++
+	}
+
+	overhead := 0
+	for _, p := range pages[:headers] {
+		overhead += len(p.data)
+	}
+
+	audioPages := pages[headers:]
+	units := make([]unit, 0, len(audioPages))
+
+	var previous uint64
+	for _, p := range audioPages {
+		var start, duration time.Duration
+
+		start = oggDuration(previous, rate)
+		if p.granule != oggNoGranule && p.granule > previous {
+			duration = oggDuration(p.granule, rate) - start
+			previous = p.granule
+		}
+
+		units = append(units, unit{
+			size:     len(p.data),
+			start:    start,
+			duration: duration,
+			// A page continuing a packet cannot be decoded without the
+			// previous one
+			cuttable: p.flags&oggFlagContinued == 0,
+		})
+	}
+
+	return &stream{
+		units:    units,
+		overhead: overhead,
+		assemble: func(from, to int) []byte {
+			var buf bytes.Buffer
+
+			chunk := append(append([]oggPage{}, pages[:headers]...), audioPages[from:to]...)
+			for i, p := range chunk {
+				flags := p.flags &^ oggFlagEOS
+				if i == len(chunk)-1 {
+					flags |= oggFlagEOS
+				}
+				buf.Write(p.rewrite(uint32(i), flags))
+			}
+
+			return buf.Bytes()
+		},
+	}, nil
+}
+
+func parseOGGPage(b []byte) (oggPage, error) {
+	if len(b) < oggHeaderSize || !bytes.HasPrefix(b, []byte("OggS")) {
+		return oggPage{}, errors.Wrap(ErrMalformedAudio, "missing ogg page capture pattern")
+	}
+
+	segments := int(b[26])
+	headerBytes := oggHeaderSize + segments
+	if len(b) < headerBytes {
+		return oggPage{}, errors.Wrap(ErrMalformedAudio, "truncated ogg page header")
+	}
+
+	size := headerBytes
+	for _, lacing := range b[oggHeaderSize:headerBytes] {
+		size += int(lacing)
+	}
+	if len(b) < size {
+		return oggPage{}, errors.Wrap(ErrMalformedAudio, "truncated ogg page")
+	}
+
+	return oggPage{
+		data:        b[:size],
+		flags:       b[5],
+		granule:     binary.LittleEndian.Uint64(b[6:14]),
+		serial:      binary.LittleEndian.Uint32(b[14:18]),
+		headerBytes: headerBytes,
+	}, nil
+}
+
+// rewrite returns a copy of the page with the given sequence number and
+// flags, and its checksum updated.
+func (p oggPage) rewrite(sequence uint32, flags byte) []byte {
+	page := bytes.Clone(p.data)
+	page[5] = flags
+	binary.LittleEndian.PutUint32(page[18:22], sequence)
+	binary.LittleEndian.PutUint32(page[22:26], 0)
+	binary.LittleEndian.PutUint32(page[22:26], oggChecksum(page))
+	return page
+}
+
+func oggSampleRate(first oggPage) (int, error) {
+	packet := first.data[first.headerBytes:]
+
+	switch {
+	// Opus granule positions always count 48 kHz samples
+	case bytes.HasPrefix(packet, []byte("OpusHead")):
+		return 48000, nil
+	case len(packet) >= 16 && bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		if rate := int(binary.LittleEndian.Uint32(packet[12:16])); rate > 0 {
+			return rate, nil
+		}
+	}
+
+	return 0, errors.Wrap(ErrUnsupportedFormat, "only vorbis and opus ogg streams can be split")
+}
+
+func oggDuration(granule uint64, rate int) time.Duration {
+	return time.Duration(granule/uint64(rate))*time.Second + time.Duration(granule%uint64(rate))*time.Second/time.Duration(rate)
+}
+
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for range 8 {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// oggChecksum computes the page CRC (polynomial 0x04C11DB7, no reflection),
+// the checksum field being zeroed.
+func oggChecksum(page []byte) uint32 {
+	var crc uint32
+	for _, b := range page {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
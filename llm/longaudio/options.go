@@ -0,0 +1,72 @@
+package longaudio
+
+import (
+	"time"
+)
+
+type Options struct {
+	// MaxChunkDuration caps the duration of each chunk sent to the provider.
+	MaxChunkDuration time.Duration
+	// MaxChunkSize caps the size in bytes of each chunk, headers included.
+	// OpenAI rejects uploads above 25 MB.
+	MaxChunkSize int
+	// SilenceWindow is how far before a chunk limit the splitter looks for
+	// silence to cut at.
+	SilenceWindow time.Duration
+	// Concurrency is the number of chunks transcribed at the same time.
+	Concurrency int
+	// PromptTail is the number of characters of the previous chunk
+	// transcription passed as prompt to the next one. Zero disables it.
+	PromptTail int
+}
+
+type OptionFunc func(opts *Options)
+
+func NewOptions(funcs ...OptionFunc) *Options {
+	opts := &Options{
+		MaxChunkDuration: 10 * time.Minute,
+		MaxChunkSize:     24 << 20,
+		SilenceWindow:    30 * time.Second,
+		Concurrency:      4,
+		PromptTail:       200,
+	}
+
+	for _, fn := range funcs {
+		fn(opts)
+	}
+
+	return opts
+}
+
+func WithMaxChunkDuration(duration time.Duration) OptionFunc {
+	return func(opts *Options) {
+		opts.MaxChunkDuration = duration
+	}
+}
+
+func WithMaxChunkSize(size int) OptionFunc {
+	return func(opts *Options) {
+		opts.MaxChunkSize = size
+	}
+}
+
+func WithSilenceWindow(window time.Duration) OptionFunc {
+	return func(opts *Options) {
+		opts.SilenceWindow = window
+	}
+}
+
+// WithConcurrency sets the number of chunks transcribed at the same time.
+// Chunks are distributed in contiguous runs, each run being transcribed
+// sequentially so that prompt continuity is only lost between runs.
+func WithConcurrency(concurrency int) OptionFunc {
+	return func(opts *Options) {
+		opts.Concurrency = concurrency
+	}
+}
+
+func WithPromptTail(length int) OptionFunc {
+	return func(opts *Options) {
+		opts.PromptTail = length
+	}
+}
@@ -0,0 +1,152 @@
+package longaudio
+
+import (
+	"math"
+	"time"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported audio format")
+	ErrMalformedAudio    = errors.New("malformed audio")
+)
+
+// Chunk is a self-contained piece of the original audio, playable on its
+// own.
+type Chunk struct {
+	Data []byte
+	// Offset is the position of the chunk in the original audio.
+	Offset time.Duration
+	// Duration is the duration of the chunk.
+	Duration time.Duration
+}
+
+// Split cuts the audio in chunks satisfying the maximum duration and size
+// of the options, at frame boundaries and preferably on silence. WAV, MP3
+// and OGG (Vorbis or Opus) audio are supported.
+func Split(audio []byte, format llm.AudioFormat, funcs ...OptionFunc) ([]Chunk, error) {
+	return split(audio, format, NewOptions(funcs...))
+}
+
+func split(audio []byte, format llm.AudioFormat, opts *Options) ([]Chunk, error) {
+	if format == "" {
+		format = llm.DetectAudioFormat(audio)
+	}
+
+	var (
+		stream *stream
+		err    error
+	)
+
+	switch format {
+	case llm.AudioFormatWAV:
+		stream, err = parseWAV(audio)
+	case llm.AudioFormatMP3:
+		stream, err = parseMP3(audio)
+	case llm.AudioFormatOGG:
+		stream, err = parseOGG(audio)
+	default:
+		return nil, errors.Wrapf(ErrUnsupportedFormat, "cannot split '%s' audio", format)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(stream.units) == 0 {
+		return nil, errors.Wrap(ErrMalformedAudio, "no audio frame found")
+	}
+
+	ranges := plan(stream.units, stream.overhead, opts)
+
+	chunks := make([]Chunk, 0, len(ranges))
+	for _, r := range ranges {
+		first, last := stream.units[r[0]], stream.units[r[1]-1]
+		chunks = append(chunks, Chunk{
+			Data:     stream.assemble(r[0], r[1]),
+			Offset:   first.start,
+			Duration: last.start + last.duration - first.start,
+		})
+	}
+
+	return chunks, nil
+}
+
+// stream is the parsed form of an audio file: a sequence of units (sample
+// windows, frames or pages) and a function rebuilding a playable file from a
+// range of them.
+type stream struct {
+	units []unit
+	// overhead is the size of the headers added to each chunk.
+	overhead int
+	assemble func(from, to int) []byte
+}
+
+type unit struct {
+	size     int
+	start    time.Duration
+	duration time.Duration
+	// loudness estimates the signal level of the unit. Chunks preferably
+	// start on the quietest unit near their limit.
+	loudness float64
+	// cuttable tells if a chunk can start on this unit.
+	cuttable bool
+}
+
+// plan groups units in [from, to) ranges respecting the limits of the
+// options. A range ending before the limit is cut on the quietest cuttable
+// unit within the silence window, the latest one on ties.
+func plan(units []unit, overhead int, opts *Options) [][2]int {
+	ranges := make([][2]int, 0)
+
+	from := 0
+	for from < len(units) {
+		var (
+			duration time.Duration
+			size     = overhead
+			to       = from
+		)
+
+		for to < len(units) {
+			u := units[to]
+			if to > from && (exceeds(duration+u.duration, opts.MaxChunkDuration) || exceeds(size+u.size, opts.MaxChunkSize)) {
+				break
+			}
+			duration += u.duration
+			size += u.size
+			to++
+		}
+
+		if to == len(units) {
+			ranges = append(ranges, [2]int{from, to})
+			break
+		}
+
+		cut := to
+		quietest := math.Inf(1)
+		windowStart := units[to].start - opts.SilenceWindow
+		for i := to; i > from; i-- {
+			u := units[i]
+			if !u.cuttable {
+				continue
+			}
+			if u.start < windowStart && !math.IsInf(quietest, 1) {
+				break
+			}
+			if u.loudness < quietest {
+				quietest = u.loudness
+				cut = i
+			}
+		}
+
+		ranges = append(ranges, [2]int{from, cut})
+		from = cut
+	}
+
+	return ranges
+}
+
+func exceeds[T int | time.Duration](value, limit T) bool {
+	return limit > 0 && value > limit
+}
@@ -0,0 +1,241 @@
+package longaudio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+const testSampleRate = 8000
+
+// testWAV generates a 16-bit mono tone, silent between the given bounds.
+func testWAV(duration time.Duration, silenceFrom, silenceTo time.Duration) []byte {
+	frames := int(duration.Seconds() * testSampleRate)
+	data := make([]byte, frames*2)
+
+	for i := range frames {
+		at := time.Duration(i) * time.Second / testSampleRate
+		var sample int16
+		if at < silenceFrom || at >= silenceTo {
+			sample = int16(8000 * math.Sin(2*math.Pi*440*at.Seconds()))
+		}
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(sample))
+	}
+
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint16(format[0:2], wavFormatPCM)
+	binary.LittleEndian.PutUint16(format[2:4], 1)
+	binary.LittleEndian.PutUint32(format[4:8], testSampleRate)
+	binary.LittleEndian.PutUint32(format[8:12], testSampleRate*2)
+	binary.LittleEndian.PutUint16(format[12:14], 2)
+	binary.LittleEndian.PutUint16(format[14:16], 16)
+
+	return wavFile(format, data)
+}
+
+func TestSplit_WAVPrefersSilence(t *testing.T) {
+	audio := testWAV(60*time.Second, 17*time.Second, 18*time.Second)
+
+	chunks, err := Split(audio, llm.AudioFormatWAV, WithMaxChunkDuration(20*time.Second), WithSilenceWindow(5*time.Second))
+	if err != nil {
+		t.Fatalf("Split: %+v", err)
+	}
+
+	if len(chunks) < 3 {
+		t.Fatalf("expected at least 3 chunks, got %d", len(chunks))
+	}
+
+	if d := chunks[0].Duration; d < 17*time.Second || d >= 18*time.Second {
+		t.Errorf("first chunk should end in the silence, got %s", d)
+	}
+
+	var (
+		data  []byte
+		total time.Duration
+	)
+	for i, c := range chunks {
+		if c.Offset != total {
+			t.Errorf("chunk %d: offset %s, expected %s", i, c.Offset, total)
+		}
+		if c.Duration > 20*time.Second {
+			t.Errorf("chunk %d: duration %s exceeds the limit", i, c.Duration)
+		}
+
+		s, err := parseWAV(c.Data)
+		if err != nil {
+			t.Fatalf("chunk %d is not a valid WAV file: %+v", i, err)
+		}
+		data = append(data, s.assemble(0, len(s.units))[44:]...)
+
+		total += c.Duration
+	}
+
+	if total != 60*time.Second {
+		t.Errorf("total duration %s, expected 1m0s", total)
+	}
+	if !bytes.Equal(data, audio[44:]) {
+		t.Error("chunks do not add up to the original samples")
+	}
+}
+
+func TestSplit_WAVMaxChunkSize(t *testing.T) {
+	audio := testWAV(10*time.Second, 0, 0)
+
+	chunks, err := Split(audio, llm.AudioFormatWAV, WithMaxChunkSize(50_000))
+	if err != nil {
+		t.Fatalf("Split: %+v", err)
+	}
+
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if len(c.Data) > 50_000 {
+			t.Errorf("chunk %d: %d bytes exceeds the limit", i, len(c.Data))
+		}
+	}
+}
+
+// mpegFrameBytes returns an MPEG 1 layer III frame at 44.1 kHz.
+func mpegFrameBytes(bitrateIndex byte) []byte {
+	frame, _ := parseMPEGFrame([]byte{0xFF, 0xFB, bitrateIndex << 4, 0x00})
+	b := make([]byte, frame.size)
+	copy(b, []byte{0xFF, 0xFB, bitrateIndex << 4, 0x00})
+	return b
+}
+
+func TestSplit_MP3(t *testing.T) {
+	var audio bytes.Buffer
+
+	// ID3v2 tag of 20 bytes
+	audio.Write([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 10})
+	audio.Write(make([]byte, 10))
+
+	// ~52s at 128 kbit/s with a quiet 32 kbit/s frame around 17s
+	var frames [][]byte
+	for i := range 2000 {
+		index := byte(9)
+		if i == 660 {
+			index = 1
+		}
+		frames = append(frames, mpegFrameBytes(index))
+		audio.Write(frames[i])
+	}
+
+	chunks, err := Split(audio.Bytes(), "", WithMaxChunkDuration(20*time.Second), WithSilenceWindow(5*time.Second))
+	if err != nil {
+		t.Fatalf("Split: %+v", err)
+	}
+
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+
+	if !bytes.Equal(chunks[1].Data[:len(frames[660])], frames[660]) {
+		t.Error("second chunk should start on the quiet frame")
+	}
+
+	var data []byte
+	for _, c := range chunks {
+		data = append(data, c.Data...)
+	}
+	if !bytes.Equal(data, audio.Bytes()[20:]) {
+		t.Error("chunks do not add up to the original frames")
+	}
+}
+
+func oggTestPage(flags byte, granule uint64, sequence uint32, body []byte) []byte {
+	page := make([]byte, oggHeaderSize, oggHeaderSize+1+len(body))
+	copy(page, "OggS")
+	page[5] = flags
+	binary.LittleEndian.PutUint64(page[6:14], granule)
+	binary.LittleEndian.PutUint32(page[14:18], 42)
+	binary.LittleEndian.PutUint32(page[18:22], sequence)
+	page[26] = 1
+	page = append(page, byte(len(body)))
+	page = append(page, body...)
+	binary.LittleEndian.PutUint32(page[22:26], oggChecksum(page))
+	return page
+}
+
+func TestSplit_OGG(t *testing.T) {
+	var audio bytes.Buffer
+
+	audio.Write(oggTestPage(oggFlagBOS, 0, 0, []byte("OpusHead\x01\x01\x38\x01\x80\xbb\x00\x00\x00\x00\x00")))
+	audio.Write(oggTestPage(0, 0, 1, []byte("OpusTags")))
+
+	// One page per second, the 10th one continuing a packet
+	for i := range 30 {
+		flags := byte(0)
+		if i == 10 {
+			flags = oggFlagContinued
+		}
+		audio.Write(oggTestPage(flags, uint64(i+1)*48000, uint32(i+2), bytes.Repeat([]byte{byte(i)}, 100)))
+	}
+
+	chunks, err := Split(audio.Bytes(), llm.AudioFormatOGG, WithMaxChunkDuration(10*time.Second))
+	if err != nil {
+		t.Fatalf("Split: %+v", err)
+	}
+
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d", len(chunks))
+	}
+
+	// The page continuing a packet stays with the previous one
+	if chunks[0].Duration != 9*time.Second {
+		t.Errorf("first chunk duration %s, expected 9s", chunks[0].Duration)
+	}
+
+	for i, c := range chunks {
+		var (
+			offset   int
+			sequence uint32
+		)
+		for offset < len(c.Data) {
+			page, err := parseOGGPage(c.Data[offset:])
+			if err != nil {
+				t.Fatalf("chunk %d: %+v", i, err)
+			}
+
+			checksum := binary.LittleEndian.Uint32(page.data[22:26])
+			binary.LittleEndian.PutUint32(page.data[22:26], 0)
+			if oggChecksum(page.data) != checksum {
+				t.Errorf("chunk %d: invalid checksum on page %d", i, sequence)
+			}
+			if got := binary.LittleEndian.Uint32(page.data[18:22]); got != sequence {
+				t.Errorf("chunk %d: sequence %d, expected %d", i, got, sequence)
+			}
+			if sequence == 0 && !bytes.HasPrefix(page.data[page.headerBytes:], []byte("OpusHead")) {
+				t.Errorf("chunk %d does not start with the stream headers", i)
+			}
+
+			offset += len(page.data)
+			sequence++
+
+			if eos := page.flags&oggFlagEOS != 0; eos != (offset == len(c.Data)) {
+				t.Errorf("chunk %d: unexpected end of stream flag on page %d", i, sequence-1)
+			}
+		}
+	}
+}
+
+func TestOGGChecksum(t *testing.T) {
+	// CRC-32 with polynomial 0x04C11DB7, no reflection, no initial or final
+	// xor, of the standard check input
+	if got := oggChecksum([]byte("123456789")); got != 0x89A1897F {
+		t.Errorf("checksum %#x, expected 0x89a1897f", got)
+	}
+}
+
+func TestSplit_UnsupportedFormat(t *testing.T) {
+	_, err := Split([]byte("fLaC\x00\x00\x00\x22\x00\x00\x00\x00"), "")
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
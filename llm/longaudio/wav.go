@@ -0,0 +1,180 @@
+package longaudio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+
+	// wavWindow is the duration of the sample windows the data is cut in.
+	wavWindow = 100 * time.Millisecond
+)
+
+// parseWAV splits the data chunk of a RIFF/WAVE file in windows of whole
+// sample frames. Each chunk gets its own header, copied from the original
+// fmt chunk.
+func parseWAV(audio []byte) (*stream, error) {
+	if len(audio) < 12 || !bytes.Equal(audio[0:4], []byte("RIFF")) || !bytes.Equal(audio[8:12], []byte("WAVE")) {
+		return nil, errors.Wrap(ErrMalformedAudio, "missing RIFF/WAVE header")
+	}
+
+	var (
+		format []byte
+		data   []byte
+	)
+
+	for offset := 12; offset+8 <= len(audio); {
+		id := string(audio[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(audio[offset+4 : offset+8]))
+		body := offset + 8
+
+		// Streaming encoders leave the size of the data chunk unset
+		if size < 0 || body+size > len(audio) {
+			size = len(audio) - body
+		}
+
+		switch id {
+		case "fmt ":
+			format = audio[body : body+size]
+		case "data":
+			data = audio[body : body+size]
+		}
+
+		offset = body + size + size%2
+	}
+
+	if len(format) < 16 {
+		return nil, errors.Wrap(ErrMalformedAudio, "missing fmt chunk")
+	}
+	if data == nil {
+		return nil, errors.Wrap(ErrMalformedAudio, "missing data chunk")
+	}
+
+	var (
+		encoding      = binary.LittleEndian.Uint16(format[0:2])
+		sampleRate    = int(binary.LittleEndian.Uint32(format[4:8]))
+		blockAlign    = int(binary.LittleEndian.Uint16(format[12:14]))
+		bitsPerSample = int(binary.LittleEndian.Uint16(format[14:16]))
+	)
+
+	if encoding == wavFormatExtensible && len(format) >= 26 {
+		encoding = binary.LittleEndian.Uint16(format[24:26])
+	}
+
+	if sampleRate <= 0 || blockAlign <= 0 {
+		return nil, errors.Wrap(ErrMalformedAudio, "invalid fmt chunk")
+	}
+
+	framesPerWindow := max(sampleRate*int(wavWindow/time.Millisecond)/1000, 1)
+	windowSize := framesPerWindow * blockAlign
+
+	// Offsets of the windows, kept for reassembly
+	offsets := make([]int, 0, len(data)/windowSize+1)
+	units := make([]unit, 0, cap(offsets))
+
+	// A trailing partial sample frame is dropped
+	usable := len(data) - len(data)%blockAlign
+
+	for offset := 0; offset < usable; offset += windowSize {
+		end := min(offset+windowSize, usable)
+
+		offsets = append(offsets, offset)
+		units = append(units, unit{
+			size:     end - offset,
+			start:    wavDuration(offset/blockAlign, sampleRate),
+			duration: wavDuration((end-offset)/blockAlign, sampleRate),
+			loudness: wavLoudness(data[offset:end], encoding, bitsPerSample),
+			cuttable: true,
+		})
+	}
+
+	header := 12 + 8 + len(format) + len(format)%2 + 8
+
+	return &stream{
+		units:    units,
+		overhead: header,
+		assemble: func(from, to int) []byte {
+			start := offsets[from]
+			end := offsets[to-1] + units[to-1].size
+			return wavFile(format, data[start:end])
+		},
+	}, nil
+}
+
+func wavDuration(frames int, sampleRate int) time.Duration {
+	return time.Duration(frames) * time.Second / time.Duration(sampleRate)
+}
+
+func wavFile(format []byte, data []byte) []byte {
+	var buf bytes.Buffer
+
+	formatSize := len(format) + len(format)%2
+
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(4+8+formatSize+8+len(data)+len(data)%2))
+	buf.WriteString("WAVE")
+
+	buf.WriteString("fmt ")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(format)))
+	buf.Write(format)
+	if len(format)%2 == 1 {
+		buf.WriteByte(0)
+	}
+
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+
+	return buf.Bytes()
+}
+
+// wavLoudness returns the mean absolute amplitude of the samples, scaled to
+// 16 bits. Encodings it cannot read all score 0, leaving the cut at the
+// limit.
+func wavLoudness(data []byte, encoding uint16, bitsPerSample int) float64 {
+	sampleSize := bitsPerSample / 8
+	if sampleSize == 0 || len(data) < sampleSize {
+		return 0
+	}
+
+	var sample func(b []byte) float64
+
+	switch {
+	case encoding == wavFormatPCM && bitsPerSample == 8:
+		sample = func(b []byte) float64 { return float64(int(b[0])-128) * 256 }
+	case encoding == wavFormatPCM && bitsPerSample == 16:
+		sample = func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) }
+	case encoding == wavFormatPCM && bitsPerSample == 24:
+		sample = func(b []byte) float64 { return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 16) }
+	case encoding == wavFormatPCM && bitsPerSample == 32:
+		sample = func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b)) >> 16) }
+	case encoding == wavFormatFloat && bitsPerSample == 32:
+		sample = func(b []byte) float64 {
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) * math.MaxInt16
+		}
+	default:
+		return 0
+	}
+
+	var (
+		total float64
+		count int
+	)
+	for offset := 0; offset+sampleSize <= len(data); offset += sampleSize {
+		total += math.Abs(sample(data[offset : offset+sampleSize]))
+		count++
+	}
+
+	return total / float64(count)
+}
@@ -66,3 +66,36 @@ func InjectSystemPrompt(messages []llm.Message, systemPrompt string) []llm.Messa
 
 	return messages
 }
+
+// WithAttachments returns a copy of the message carrying the given
+// attachments instead of its own. Tool messages keep their identifier, tool
+// calls messages, which carry no attachments, are returned as is.
+func WithAttachments(message llm.Message, attachments []llm.Attachment) llm.Message {
+	return WithContentAndAttachments(message, message.Content(), attachments)
+}
+
+// WithContentAndAttachments returns a copy of the message with the given
+// content and attachments, as WithAttachments does. The cache hint and the
+// citations of the message are kept.
+func WithContentAndAttachments(message llm.Message, content string, attachments []llm.Attachment) llm.Message {
+	var rewritten llm.Message
+
+	switch m := message.(type) {
+	case llm.ToolCallsMessage:
+		return m
+	case llm.ToolMessage:
+		rewritten = llm.NewToolMessage(m.ID(), llm.NewToolResult(content, attachments...))
+	default:
+		rewritten = llm.NewMultimodalMessage(m.Role(), content, attachments...)
+	}
+
+	if cm, ok := message.(llm.CacheControlMessage); ok && cm.CacheControl() != nil {
+		rewritten, _ = llm.WithCacheControl(rewritten, cm.CacheControl())
+	}
+
+	if citations := llm.CitationsOf(message); len(citations) > 0 {
+		rewritten, _ = llm.WithCitations(rewritten, citations)
+	}
+
+	return rewritten
+}
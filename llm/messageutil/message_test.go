@@ -0,0 +1,49 @@
+package messageutil
+
+import (
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+)
+
+func TestWithContentAndAttachments_KeepsCacheControl(t *testing.T) {
+	cc := &llm.CacheControl{Type: "ephemeral"}
+
+	for _, tc := range []struct {
+		name    string
+		message llm.Message
+	}{
+		{"message", llm.NewMessageWithCacheControl(llm.RoleUser, "See the attached file", cc)},
+		{"tool message", mustWithCacheControl(t, llm.NewToolMessage("call-1", llm.NewToolResult("done")), cc)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rewritten := WithContentAndAttachments(tc.message, "See the extracted text", nil)
+
+			if rewritten.Content() != "See the extracted text" {
+				t.Errorf("unexpected content %q", rewritten.Content())
+			}
+
+			cm, ok := rewritten.(llm.CacheControlMessage)
+			if !ok || cm.CacheControl() != cc {
+				t.Errorf("expected the cache hint to be kept, got %+v", rewritten)
+			}
+
+			if _, ok := tc.message.(llm.ToolMessage); ok {
+				if tm, ok := rewritten.(llm.ToolMessage); !ok || tm.ID() != "call-1" {
+					t.Errorf("expected the tool message to keep its identifier, got %+v", rewritten)
+				}
+			}
+		})
+	}
+}
+
+func mustWithCacheControl(t *testing.T, message llm.Message, cc *llm.CacheControl) llm.Message {
+	t.Helper()
+
+	message, ok := llm.WithCacheControl(message, cc)
+	if !ok {
+		t.Fatalf("message %T cannot carry a cache hint", message)
+	}
+
+	return message
+}
@@ -0,0 +1,279 @@
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidInput     = errors.New("invalid prompt input")
+	ErrInvalidPrompt    = errors.New("invalid prompt")
+	ErrMultipleMessages = errors.New("prompt renders more than one message")
+)
+
+// Metadata is the YAML front matter of a prompt file
+type Metadata struct {
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description"`
+	// Models lists the models the prompt was written for, as a hint for
+	// callers selecting a client
+	Models      []string `yaml:"models"`
+	Temperature *float64 `yaml:"temperature"`
+	// Role is the role of the text preceding the first role marker of the
+	// body. Defaults to "user".
+	Role llm.Role `yaml:"role"`
+	// Input is the JSON schema the template data must conform to
+	Input map[string]any `yaml:"input"`
+	// Output is the JSON schema of the expected model response
+	Output map[string]any `yaml:"output"`
+}
+
+// Prompt is a versioned, possibly multi-message prompt template.
+//
+// A prompt file starts with a YAML front matter delimited by "---" lines,
+// followed by the template body. Lines made only of "system:", "user:" or
+// "assistant:" split the body into messages, allowing few-shot turns:
+//
+//	---
+//	name: summarize
+//	version: 1.0.0
+//	---
+//	system:
+//	You summarize texts in {{ .Language }}.
+//	user:
+//	{{ .Text }}
+//
+// Each message is rendered independently, so data injected in a template can
+// never introduce a new message.
+type Prompt struct {
+	Metadata Metadata
+	messages []messageTemplate
+	input    *jsonschema.Resolved
+}
+
+type messageTemplate struct {
+	role     llm.Role
+	template string
+}
+
+// Name returns the name of the prompt
+func (p *Prompt) Name() string {
+	return p.Metadata.Name
+}
+
+// Version returns the version of the prompt
+func (p *Prompt) Version() string {
+	return p.Metadata.Version
+}
+
+// Reference returns the registry reference of this prompt version
+func (p *Prompt) Reference() string {
+	return FormatReference(p.Metadata.Name, p.Metadata.Version)
+}
+
+// ValidateInput checks the data against the input schema of the prompt, if
+// any. The data is compared in its JSON form.
+func (p *Prompt) ValidateInput(data any) error {
+	if p.input == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var instance any
+	if err := json.Unmarshal(raw, &instance); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := p.input.Validate(instance); err != nil {
+		return errors.Wrapf(ErrInvalidInput, "%s: %s", p.Reference(), err)
+	}
+
+	return nil
+}
+
+// Render validates the data and renders the prompt into messages
+func (p *Prompt) Render(data any, funcs ...OptionFunc) ([]llm.Message, error) {
+	if err := p.ValidateInput(data); err != nil {
+		return nil, err
+	}
+
+	messages := make([]llm.Message, 0, len(p.messages))
+	for _, m := range p.messages {
+		content, err := Template(m.template, data, funcs...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not render prompt '%s'", p.Reference())
+		}
+
+		messages = append(messages, llm.NewMessage(m.role, strings.TrimSpace(content)))
+	}
+
+	return messages, nil
+}
+
+// RenderString renders a single-message prompt and returns its content,
+// whatever its role. It fails with ErrMultipleMessages otherwise.
+func (p *Prompt) RenderString(data any, funcs ...OptionFunc) (string, error) {
+	messages, err := p.Render(data, funcs...)
+	if err != nil {
+		return "", err
+	}
+
+	switch len(messages) {
+	case 0:
+		return "", nil
+	case 1:
+		return messages[0].Content(), nil
+	default:
+		return "", errors.Wrapf(ErrMultipleMessages, "%s", p.Reference())
+	}
+}
+
+// ResponseSchema returns the output schema of the prompt, or nil if it
+// declares none
+func (p *Prompt) ResponseSchema() llm.ResponseSchema {
+	if p.Metadata.Output == nil {
+		return nil
+	}
+
+	return llm.NewResponseSchema(p.Metadata.Name, p.Metadata.Description, p.Metadata.Output)
+}
+
+// ChatCompletionOptions renders the prompt and returns the options to use it
+// in a chat completion: messages, temperature and JSON response schema when
+// declared
+func (p *Prompt) ChatCompletionOptions(data any, funcs ...OptionFunc) ([]llm.ChatCompletionOptionFunc, error) {
+	messages, err := p.Render(data, funcs...)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []llm.ChatCompletionOptionFunc{
+		llm.WithMessages(messages...),
+	}
+
+	if p.Metadata.Temperature != nil {
+		opts = append(opts, llm.WithTemperature(*p.Metadata.Temperature))
+	}
+
+	if schema := p.ResponseSchema(); schema != nil {
+		opts = append(opts, llm.WithJSONResponse(schema))
+	}
+
+	return opts, nil
+}
+
+// Parse reads a prompt file
+func Parse(raw []byte) (*Prompt, error) {
+	raw = bytes.ReplaceAll(raw, []byte("\r\n"), []byte("\n"))
+
+	var metadata Metadata
+	body := string(raw)
+
+	if rest, found := strings.CutPrefix(body, "---\n"); found {
+		frontMatter, content, found := strings.Cut(rest, "\n---")
+		if !found {
+			return nil, errors.Wrap(ErrInvalidPrompt, "unterminated front matter")
+		}
+
+		if err := yaml.Unmarshal([]byte(frontMatter), &metadata); err != nil {
+			return nil, errors.Wrap(ErrInvalidPrompt, err.Error())
+		}
+
+		// Skip the remaining of the closing delimiter line
+		if _, after, found := strings.Cut(content, "\n"); found {
+			body = after
+		} else {
+			body = ""
+		}
+	}
+
+	if metadata.Role == "" {
+		metadata.Role = llm.RoleUser
+	}
+
+	p := &Prompt{
+		Metadata: metadata,
+		messages: splitMessages(body, metadata.Role),
+	}
+
+	if metadata.Input != nil {
+		resolved, err := resolveSchema(metadata.Input)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidPrompt, err.Error())
+		}
+		p.input = resolved
+	}
+
+	return p, nil
+}
+
+func resolveSchema(raw map[string]any) (*jsonschema.Resolved, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, errors.Wrap(err, "invalid input schema")
+	}
+
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid input schema")
+	}
+
+	return resolved, nil
+}
+
+func splitMessages(body string, defaultRole llm.Role) []messageTemplate {
+	messages := make([]messageTemplate, 0)
+
+	current := messageTemplate{role: defaultRole}
+	var sb strings.Builder
+
+	flush := func() {
+		current.template = sb.String()
+		if strings.TrimSpace(current.template) != "" {
+			messages = append(messages, current)
+		}
+		sb.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(body, "\n") {
+		if role, ok := roleMarker(line); ok {
+			flush()
+			current = messageTemplate{role: role}
+			continue
+		}
+		sb.WriteString(line)
+	}
+
+	flush()
+
+	return messages
+}
+
+func roleMarker(line string) (llm.Role, bool) {
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "system:":
+		return llm.RoleSystem, true
+	case "user:":
+		return llm.RoleUser, true
+	case "assistant:":
+		return llm.RoleAssistant, true
+	default:
+		return "", false
+	}
+}
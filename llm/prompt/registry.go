@@ -0,0 +1,222 @@
+package prompt
+
+import (
+	"cmp"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotFound = errors.New("prompt not found")
+)
+
+const (
+	// ReferencePrefix marks a string as a registry reference, as in
+	// "prompt:summarize@1.2.0"
+	ReferencePrefix = "prompt:"
+	// FileExtension is the extension of the prompt files loaded by
+	// Registry.LoadFS
+	FileExtension = ".prompt"
+)
+
+// DefaultRegistry is the registry used to resolve references passed to the
+// package level helpers
+var DefaultRegistry = NewRegistry()
+
+// Registry stores prompts by name and version
+type Registry struct {
+	mu      sync.RWMutex
+	prompts map[string]map[string]*Prompt
+}
+
+// Register adds the prompts to the registry, replacing any prompt with the
+// same name and version
+func (r *Registry) Register(prompts ...*Prompt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range prompts {
+		if p.Metadata.Name == "" {
+			return errors.Wrap(ErrInvalidPrompt, "prompt name is required")
+		}
+
+		versions, exists := r.prompts[p.Metadata.Name]
+		if !exists {
+			versions = make(map[string]*Prompt)
+			r.prompts[p.Metadata.Name] = versions
+		}
+
+		versions[p.Metadata.Version] = p
+	}
+
+	return nil
+}
+
+// Get returns the prompt matching the given reference. The reference is
+// "name", "name@latest" or "name@version", optionally prefixed by
+// ReferencePrefix. The version may be partial: "name@1" selects the latest
+// 1.x.y version.
+func (r *Registry) Get(ref string) (*Prompt, error) {
+	name, version := ParseReference(ref)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions, exists := r.prompts[name]
+	if !exists {
+		return nil, errors.Wrapf(ErrNotFound, "'%s'", ref)
+	}
+
+	if p, exists := versions[version]; exists {
+		return p, nil
+	}
+
+	var selected *Prompt
+	for v, p := range versions {
+		if version != "" && version != "latest" && !strings.HasPrefix(v, version+".") {
+			continue
+		}
+		if selected == nil || compareVersions(v, selected.Metadata.Version) > 0 {
+			selected = p
+		}
+	}
+
+	if selected == nil {
+		return nil, errors.Wrapf(ErrNotFound, "'%s'", ref)
+	}
+
+	return selected, nil
+}
+
+// Versions returns the known versions of the named prompt, oldest first
+func (r *Registry) Versions(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := make([]string, 0, len(r.prompts[name]))
+	for v := range r.prompts[name] {
+		versions = append(versions, v)
+	}
+
+	slices.SortFunc(versions, compareVersions)
+
+	return versions
+}
+
+// LoadFS registers every prompt file found under dir in fsys. Prompts
+// without a name in their front matter are named after their file.
+func (r *Registry) LoadFS(fsys fs.FS, dir string) error {
+	err := fs.WalkDir(fsys, dir, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if d.IsDir() || !strings.HasSuffix(d.Name(), FileExtension) {
+			return nil
+		}
+
+		raw, err := fs.ReadFile(fsys, filename)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		p, err := Parse(raw)
+		if err != nil {
+			return errors.Wrapf(err, "could not parse prompt file '%s'", filename)
+		}
+
+		if p.Metadata.Name == "" {
+			p.Metadata.Name = strings.TrimSuffix(path.Base(filename), FileExtension)
+		}
+
+		return r.Register(p)
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// LoadDir registers every prompt file found in the given directory
+func (r *Registry) LoadDir(dir string) error {
+	return r.LoadFS(os.DirFS(dir), ".")
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		prompts: make(map[string]map[string]*Prompt),
+	}
+}
+
+// IsReference reports whether s is a registry reference, i.e. starts with
+// ReferencePrefix
+func IsReference(s string) bool {
+	return strings.HasPrefix(s, ReferencePrefix)
+}
+
+// ParseReference splits a reference into the prompt name and version
+func ParseReference(ref string) (name string, version string) {
+	ref = strings.TrimPrefix(ref, ReferencePrefix)
+	name, version, _ = strings.Cut(ref, "@")
+	return name, version
+}
+
+// FormatReference returns the reference of the given prompt version
+func FormatReference(name, version string) string {
+	if version == "" {
+		return ReferencePrefix + name
+	}
+	return ReferencePrefix + name + "@" + version
+}
+
+// Render resolves the reference in DefaultRegistry and renders the prompt
+// as a single string
+func Render(ref string, data any, funcs ...OptionFunc) (string, error) {
+	p, err := DefaultRegistry.Get(ref)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return p.RenderString(data, funcs...)
+}
+
+// compareVersions compares dot separated versions, numerically when both
+// parts are numbers
+func compareVersions(a, b string) int {
+	partsA := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	partsB := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := 0; i < max(len(partsA), len(partsB)); i++ {
+		var pa, pb string
+		if i < len(partsA) {
+			pa = partsA[i]
+		}
+		if i < len(partsB) {
+			pb = partsB[i]
+		}
+
+		na, errA := strconv.Atoi(pa)
+		nb, errB := strconv.Atoi(pb)
+
+		var c int
+		if errA == nil && errB == nil {
+			c = cmp.Compare(na, nb)
+		} else {
+			c = cmp.Compare(pa, pb)
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
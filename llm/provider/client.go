@@ -12,6 +12,7 @@ type Client struct {
 	embeddings      llm.EmbeddingsClient
 	transcription   llm.TranscriptionClient
 	imageGeneration llm.ImageGenerationClient
+	completion      llm.CompletionClient
 }
 
 // ChatCompletion implements llm.Client.
@@ -89,6 +90,90 @@ func (c *Client) ImageGeneration(ctx context.Context, prompt string, funcs ...ll
 	return response, nil
 }
 
+// ImageEdit implements [llm.ImageEditClient], when the image generation
+// client supports it.
+func (c *Client) ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	editor, ok := c.imageGeneration.(llm.ImageEditClient)
+	if !ok {
+		return nil, errors.WithStack(llm.ErrUnavailable)
+	}
+
+	response, err := editor.ImageEdit(ctx, image, prompt, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return response, nil
+}
+
+// ImageVariation implements [llm.ImageEditClient], when the image generation
+// client supports it.
+func (c *Client) ImageVariation(ctx context.Context, image []byte, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	editor, ok := c.imageGeneration.(llm.ImageEditClient)
+	if !ok {
+		return nil, errors.WithStack(llm.ErrUnavailable)
+	}
+
+	response, err := editor.ImageVariation(ctx, image, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return response, nil
+}
+
+// Complete implements [llm.CompletionClient] with the completion client, or
+// the chat completion client when it supports raw text completions.
+func (c *Client) Complete(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	completer, ok := c.completer()
+	if !ok {
+		return nil, errors.WithStack(llm.ErrUnavailable)
+	}
+
+	response, err := completer.Complete(ctx, prompt, suffix, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return response, nil
+}
+
+// CompleteStream implements [llm.CompletionClient], like Complete.
+func (c *Client) CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	completer, ok := c.completer()
+	if !ok {
+		return nil, errors.WithStack(llm.ErrUnavailable)
+	}
+
+	stream, err := completer.CompleteStream(ctx, prompt, suffix, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return stream, nil
+}
+
+func (c *Client) completer() (llm.CompletionClient, bool) {
+	if c.completion != nil {
+		return c.completion, true
+	}
+
+	completer, ok := c.chatCompletion.(llm.CompletionClient)
+	return completer, ok
+}
+
+// ValidateAttachment implements [llm.AttachmentValidator], when the chat
+// completion client supports it. Attachments are otherwise left to the
+// provider.
+func (c *Client) ValidateAttachment(attachment llm.Attachment) error {
+	validator, ok := c.chatCompletion.(llm.AttachmentValidator)
+	if !ok {
+		return nil
+	}
+
+	return validator.ValidateAttachment(attachment)
+}
+
 func NewClient(chatCompletion llm.ChatCompletionClient, embeddings llm.EmbeddingsClient, transcription llm.TranscriptionClient) *Client {
 	return &Client{
 		chatCompletion: chatCompletion,
@@ -108,4 +193,7 @@ func NewClientWithImageGeneration(chatCompletion llm.ChatCompletionClient, embed
 var (
 	_ llm.Client                = &Client{}
 	_ llm.ImageGenerationClient = &Client{}
+	_ llm.ImageEditClient       = &Client{}
+	_ llm.CompletionClient      = &Client{}
+	_ llm.AttachmentValidator   = &Client{}
 )
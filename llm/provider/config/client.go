@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"time"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/circuitbreaker"
+	"github.com/bornholm/genai/llm/provider"
+	"github.com/bornholm/genai/llm/ratelimit"
+	"github.com/bornholm/genai/llm/retry"
+	"github.com/bornholm/genai/llm/tokenlimit"
+	"github.com/pkg/errors"
+)
+
+// Create creates the named client with its configured wrappers. An empty name
+// selects the default client.
+func (c *Config) Create(ctx context.Context, name string) (llm.Client, error) {
+	if name == "" {
+		name = c.Default
+	}
+
+	definition, exists := c.Clients[name]
+	if !exists {
+		return nil, errors.Wrapf(provider.ErrClientNotFound, "could not find client '%s'", name)
+	}
+
+	client, err := provider.Create(ctx, c.With(name))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create client '%s'", name)
+	}
+
+	return definition.wrap(client), nil
+}
+
+// Register registers every configured client in the given set
+func (c *Config) Register(clients *provider.Clients) {
+	for name := range c.Clients {
+		clients.Register(name, func(ctx context.Context) (llm.Client, error) {
+			return c.Create(ctx, name)
+		})
+	}
+}
+
+// wrap applies the configured wrappers, from the innermost to the outermost:
+// retry, circuit breaker, rate limit and token limit
+func (c ClientConfig) wrap(client llm.Client) llm.Client {
+	if c.Retry != nil {
+		delay := c.Retry.Delay
+		if delay <= 0 {
+			delay = 2 * time.Second
+		}
+		client = retry.NewClient(client, delay, c.Retry.MaxRetries)
+	}
+
+	if c.CircuitBreaker != nil {
+		resetTimeout := c.CircuitBreaker.ResetTimeout
+		if resetTimeout <= 0 {
+			resetTimeout = 30 * time.Second
+		}
+		client = circuitbreaker.NewClient(client, max(c.CircuitBreaker.MaxFailures, 1), resetTimeout)
+	}
+
+	if c.RateLimit != nil {
+		interval := c.RateLimit.Interval
+		burst := max(c.RateLimit.Burst, 1)
+		client = ratelimit.NewClient(client,
+			ratelimit.WithChatLimit(interval, burst),
+			ratelimit.WithEmbeddingsLimit(interval, burst),
+			ratelimit.WithTranscriptionLimit(interval, burst),
+		)
+	}
+
+	if c.TokenLimit != nil {
+		interval := c.TokenLimit.Interval
+		if interval <= 0 {
+			interval = time.Minute
+		}
+
+		funcs := make([]tokenlimit.OptionFunc, 0, 3)
+		if c.TokenLimit.ChatCompletion > 0 {
+			funcs = append(funcs, tokenlimit.WithChatCompletionLimit(c.TokenLimit.ChatCompletion, interval))
+		}
+		if c.TokenLimit.Embeddings > 0 {
+			funcs = append(funcs, tokenlimit.WithEmbeddingsLimit(c.TokenLimit.Embeddings, interval))
+		}
+		if c.TokenLimit.Transcription > 0 {
+			funcs = append(funcs, tokenlimit.WithTranscriptionLimit(c.TokenLimit.Transcription, interval))
+		}
+
+		client = tokenlimit.NewClient(client, funcs...)
+	}
+
+	return client
+}
@@ -0,0 +1,216 @@
+// Package config resolves named LLM clients from a YAML or JSON file.
+//
+// Each client declares the provider of its capabilities and the wrappers
+// applied around it:
+//
+//	default: fast
+//	clients:
+//	  fast:
+//	    provider: openai
+//	    model: gpt-4o-mini
+//	    apiKey: ${OPENAI_API_KEY}
+//	    embeddings:
+//	      model: text-embedding-3-small
+//	    retry:
+//	      delay: 2s
+//	      maxRetries: 5
+//	  local:
+//	    provider: yzma
+//	    options:
+//	      modelPath: /models/qwen.gguf
+//	      contextSize: 8192
+//
+// Strings are interpolated with the environment variables ("${VAR}",
+// "${VAR:-default}", "$$" for a literal dollar sign).
+package config
+
+import (
+	"os"
+	"slices"
+	"time"
+
+	"github.com/buildkite/interpolate"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidConfig = errors.New("invalid config")
+)
+
+// Config is a set of named client definitions
+type Config struct {
+	// Default is the name of the client to use when none is specified
+	Default string `yaml:"default"`
+	// Clients maps client names to their definition
+	Clients map[string]ClientConfig `yaml:"clients"`
+}
+
+// ClientConfig defines a named client.
+//
+// The embedded CapabilityConfig is inherited by every capability section.
+// Chat completion is configured as soon as a provider is given at the client
+// level, other capabilities only when their section is present.
+type ClientConfig struct {
+	CapabilityConfig `yaml:",inline"`
+
+	ChatCompletion  *CapabilityConfig `yaml:"chatCompletion"`
+	Embeddings      *CapabilityConfig `yaml:"embeddings"`
+	Transcription   *CapabilityConfig `yaml:"transcription"`
+	ImageGeneration *CapabilityConfig `yaml:"imageGeneration"`
+	Completion      *CapabilityConfig `yaml:"completion"`
+
+	Retry          *RetryConfig          `yaml:"retry"`
+	RateLimit      *RateLimitConfig      `yaml:"rateLimit"`
+	TokenLimit     *TokenLimitConfig     `yaml:"tokenLimit"`
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuitBreaker"`
+}
+
+// CapabilityConfig selects and configures the provider of a capability
+type CapabilityConfig struct {
+	Provider string `yaml:"provider"`
+	Model    string `yaml:"model"`
+	BaseURL  string `yaml:"baseURL"`
+	APIKey   string `yaml:"apiKey"`
+	// APIKeyEnv is the name of the environment variable holding the API key
+	APIKeyEnv string `yaml:"apiKeyEnv"`
+	// APIKeyFile is the path of the file holding the API key
+	APIKeyFile string `yaml:"apiKeyFile"`
+	// Options are the provider specific options. Keys are the suffixes of
+	// the provider environment variables, in camel or snake case
+	// (contextSize or context_size for CONTEXT_SIZE).
+	Options map[string]any `yaml:"options"`
+}
+
+// RetryConfig configures the retry wrapper
+type RetryConfig struct {
+	Delay      time.Duration `yaml:"delay"`
+	MaxRetries int           `yaml:"maxRetries"`
+}
+
+// RateLimitConfig configures the rate limiting wrapper
+type RateLimitConfig struct {
+	Interval time.Duration `yaml:"interval"`
+	Burst    int           `yaml:"burst"`
+}
+
+// TokenLimitConfig configures the token limiting wrapper. Limits are
+// expressed in tokens per interval (one minute by default), unset limits
+// keeping the tokenlimit package defaults.
+type TokenLimitConfig struct {
+	ChatCompletion int           `yaml:"chatCompletion"`
+	Embeddings     int           `yaml:"embeddings"`
+	Transcription  int           `yaml:"transcription"`
+	Interval       time.Duration `yaml:"interval"`
+}
+
+// CircuitBreakerConfig configures the circuit breaker wrapper
+type CircuitBreakerConfig struct {
+	MaxFailures  int           `yaml:"maxFailures"`
+	ResetTimeout time.Duration `yaml:"resetTimeout"`
+}
+
+// Names returns the sorted names of the configured clients
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Clients))
+	for name := range c.Clients {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Load reads the configuration file at the given path
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config file")
+	}
+
+	config, err := Parse(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse config file '%s'", path)
+	}
+
+	return config, nil
+}
+
+// Parse reads a YAML or JSON configuration. Unknown top level keys are
+// ignored so the clients can live in a larger configuration file.
+func Parse(data []byte) (*Config, error) {
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrap(ErrInvalidConfig, err.Error())
+	}
+
+	config.interpolate()
+
+	if config.Default != "" {
+		if _, exists := config.Clients[config.Default]; !exists {
+			return nil, errors.Wrapf(ErrInvalidConfig, "default client '%s' is not defined", config.Default)
+		}
+	}
+
+	return &config, nil
+}
+
+type osEnv struct{}
+
+func (osEnv) Get(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (c *Config) interpolate() {
+	c.Default = interpolateString(c.Default)
+
+	for name, client := range c.Clients {
+		for _, capability := range []*CapabilityConfig{&client.CapabilityConfig, client.ChatCompletion, client.Embeddings, client.Transcription, client.ImageGeneration, client.Completion} {
+			if capability != nil {
+				capability.interpolate()
+			}
+		}
+		c.Clients[name] = client
+	}
+}
+
+func (c *CapabilityConfig) interpolate() {
+	c.Provider = interpolateString(c.Provider)
+	c.Model = interpolateString(c.Model)
+	c.BaseURL = interpolateString(c.BaseURL)
+	c.APIKey = interpolateString(c.APIKey)
+	c.APIKeyEnv = interpolateString(c.APIKeyEnv)
+	c.APIKeyFile = interpolateString(c.APIKeyFile)
+
+	if c.Options != nil {
+		c.Options = interpolateAny(c.Options).(map[string]any)
+	}
+}
+
+func interpolateString(s string) string {
+	result, err := interpolate.Interpolate(osEnv{}, s)
+	if err != nil {
+		return s
+	}
+	return result
+}
+
+func interpolateAny(v any) any {
+	switch val := v.(type) {
+	case string:
+		return interpolateString(val)
+	case map[string]any:
+		result := make(map[string]any, len(val))
+		for k, v := range val {
+			result[k] = interpolateAny(v)
+		}
+		return result
+	case []any:
+		result := make([]any, len(val))
+		for i, v := range val {
+			result[i] = interpolateAny(v)
+		}
+		return result
+	default:
+		return val
+	}
+}
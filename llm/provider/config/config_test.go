@@ -0,0 +1,196 @@
+package config_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/provider"
+	"github.com/bornholm/genai/llm/provider/config"
+	"github.com/pkg/errors"
+)
+
+type configTestOptions struct {
+	provider.CommonOptions
+	ContextSize int               `env:"CONTEXT_SIZE"`
+	Stop        []string          `env:"STOP"`
+	Headers     map[string]string `env:"HEADERS"`
+	Timeout     int               `env:"TIMEOUT"`
+}
+
+func init() {
+	provider.RegisterChatCompletion(
+		"configtest",
+		func() *configTestOptions {
+			return &configTestOptions{Timeout: 10}
+		},
+		func(ctx context.Context, opts *configTestOptions) (llm.ChatCompletionClient, error) {
+			return &configTestClient{opts: opts}, nil
+		},
+	)
+	provider.RegisterEmbeddings(
+		"configtest",
+		func() *configTestOptions {
+			return &configTestOptions{}
+		},
+		func(ctx context.Context, opts *configTestOptions) (llm.EmbeddingsClient, error) {
+			return &configTestClient{opts: opts}, nil
+		},
+	)
+}
+
+const testConfig = `
+default: main
+clients:
+  main:
+    provider: configtest
+    model: chat-model
+    apiKey: ${CONFIG_TEST_API_KEY}
+    options:
+      contextSize: 4096
+      stop: ["END", "STOP"]
+      headers:
+        x-tenant: acme
+    embeddings:
+      model: embeddings-model
+      apiKeyFile: %s
+    retry:
+      delay: 10ms
+      maxRetries: 2
+  other:
+    provider: configtest
+    baseURL: http://other.example.com
+`
+
+func TestConfig_With(t *testing.T) {
+	t.Setenv("CONFIG_TEST_API_KEY", "chat-secret")
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("embeddings-secret\n"), 0o600); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	cfg, err := config.Parse([]byte(fmt.Sprintf(testConfig, keyFile)))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	opts, err := provider.NewOptions(cfg.With("main"))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	chat := opts.ChatCompletion.Specific.(*configTestOptions)
+	if chat.Model != "chat-model" || chat.APIKey != "chat-secret" {
+		t.Errorf("unexpected chat completion options %+v", chat)
+	}
+	if chat.ContextSize != 4096 || chat.Timeout != 10 {
+		t.Errorf("unexpected provider specific options %+v", chat)
+	}
+	if len(chat.Stop) != 2 || chat.Stop[1] != "STOP" || chat.Headers["x-tenant"] != "acme" {
+		t.Errorf("unexpected slice or map options %+v", chat)
+	}
+
+	embeddings := opts.Embeddings.Specific.(*configTestOptions)
+	if embeddings.Model != "embeddings-model" || embeddings.APIKey != "embeddings-secret" {
+		t.Errorf("unexpected embeddings options %+v", embeddings)
+	}
+	if embeddings.ContextSize != 4096 {
+		t.Errorf("expected options to be inherited, got %+v", embeddings)
+	}
+
+	if opts.Transcription != nil {
+		t.Errorf("expected transcription not to be configured")
+	}
+
+	// Without capability section, only chat completion is configured
+	opts, err = provider.NewOptions(cfg.With("other"))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if opts.Embeddings != nil {
+		t.Errorf("expected embeddings not to be configured")
+	}
+
+	if baseURL := opts.ChatCompletion.Specific.(*configTestOptions).BaseURL; baseURL != "http://other.example.com" {
+		t.Errorf("unexpected base url %q", baseURL)
+	}
+}
+
+func TestConfig_Register(t *testing.T) {
+	t.Setenv("CONFIG_TEST_API_KEY", "chat-secret")
+
+	cfg, err := config.Parse([]byte(fmt.Sprintf(testConfig, "/dev/null")))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	clients := provider.NewClients()
+	cfg.Register(clients)
+
+	if names := clients.Names(); len(names) != 2 || names[0] != "main" || names[1] != "other" {
+		t.Errorf("unexpected client names %v", names)
+	}
+
+	ctx := context.Background()
+
+	client, err := clients.Get(ctx, "other")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	res, err := client.ChatCompletion(ctx)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if res.Message().Content() != "http://other.example.com" {
+		t.Errorf("expected the 'other' client to be used, got %q", res.Message().Content())
+	}
+
+	if same, _ := clients.Get(ctx, "other"); same != client {
+		t.Errorf("expected Get to return the same instance")
+	}
+
+	if _, err := clients.Get(ctx, "unknown"); !errors.Is(err, provider.ErrClientNotFound) {
+		t.Errorf("expected ErrClientNotFound, got %v", err)
+	}
+
+	if _, err := cfg.Create(ctx, ""); err != nil {
+		t.Errorf("expected the default client to be created, got %+v", err)
+	}
+}
+
+func TestParse_UndefinedDefault(t *testing.T) {
+	_, err := config.Parse([]byte("default: missing\nclients: {}\n"))
+	if !errors.Is(err, config.ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestParse_JSON(t *testing.T) {
+	cfg, err := config.Parse([]byte(`{"clients": {"json": {"provider": "configtest", "model": "m"}}}`))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if cfg.Clients["json"].Model != "m" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
+
+type configTestClient struct {
+	opts *configTestOptions
+}
+
+func (c *configTestClient) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	return llm.NewChatCompletionResponse(llm.NewMessage(llm.RoleAssistant, c.opts.BaseURL), llm.NewChatCompletionUsage(0, 0, 0)), nil
+}
+
+func (c *configTestClient) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	return nil, errors.WithStack(llm.ErrUnavailable)
+}
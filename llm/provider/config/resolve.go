@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/provider"
+	"github.com/caarlos0/env/v11"
+	"github.com/pkg/errors"
+)
+
+// With returns a provider.OptionFunc configuring the capabilities of the
+// named client, as env.With does from environment variables
+func (c *Config) With(name string) provider.OptionFunc {
+	return func(opts *provider.Options) error {
+		client, exists := c.Clients[name]
+		if !exists {
+			return errors.Wrapf(provider.ErrClientNotFound, "could not find client '%s'", name)
+		}
+
+		sections := []*CapabilityConfig{client.ChatCompletion, client.Embeddings, client.Transcription, client.ImageGeneration, client.Completion}
+		if sections[0] == nil && client.Provider != "" {
+			sections[0] = &CapabilityConfig{}
+		}
+
+		targets := []struct {
+			name       string
+			resolved   **provider.ResolvedClientOptions
+			newOptions func(provider.Name) any
+		}{
+			{"chat completion", &opts.ChatCompletion, provider.NewChatCompletionProviderOptions},
+			{"embeddings", &opts.Embeddings, provider.NewEmbeddingsProviderOptions},
+			{"transcription", &opts.Transcription, provider.NewTranscriptionProviderOptions},
+			{"image generation", &opts.ImageGeneration, provider.NewImageGenerationProviderOptions},
+			{"completion", &opts.Completion, provider.NewCompletionProviderOptions},
+		}
+
+		for i, target := range targets {
+			if sections[i] == nil {
+				continue
+			}
+
+			resolved, err := resolveOptions(client.CapabilityConfig.merge(sections[i]), target.newOptions)
+			if err != nil {
+				return errors.Wrapf(err, "could not resolve %s options of client '%s'", target.name, name)
+			}
+
+			*target.resolved = resolved
+		}
+
+		return nil
+	}
+}
+
+// merge returns the capability configuration with the unset fields
+// inherited from c
+func (c CapabilityConfig) merge(override *CapabilityConfig) CapabilityConfig {
+	merged := c
+
+	if override.Provider != "" {
+		merged.Provider = override.Provider
+	}
+	if override.Model != "" {
+		merged.Model = override.Model
+	}
+	if override.BaseURL != "" {
+		merged.BaseURL = override.BaseURL
+	}
+	if override.APIKey != "" || override.APIKeyEnv != "" || override.APIKeyFile != "" {
+		merged.APIKey = override.APIKey
+		merged.APIKeyEnv = override.APIKeyEnv
+		merged.APIKeyFile = override.APIKeyFile
+	}
+
+	if len(override.Options) > 0 {
+		merged.Options = make(map[string]any, len(c.Options)+len(override.Options))
+		for k, v := range c.Options {
+			merged.Options[k] = v
+		}
+		for k, v := range override.Options {
+			merged.Options[k] = v
+		}
+	}
+
+	return merged
+}
+
+// resolveOptions populates the provider specific options the same way
+// env.With does, the configuration values standing for the environment
+// variables
+func resolveOptions(config CapabilityConfig, newProviderOptions func(provider.Name) any) (*provider.ResolvedClientOptions, error) {
+	if config.Provider == "" {
+		return nil, llm.NewValidationError("provider", "provider is required")
+	}
+
+	name := provider.Name(config.Provider)
+
+	// If the provider is not registered, the error is raised by
+	// provider.Create()
+	specificOpts := newProviderOptions(name)
+	if specificOpts == nil {
+		return &provider.ResolvedClientOptions{Provider: name}, nil
+	}
+
+	vars, err := config.variables()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(vars) > 0 {
+		if err := env.ParseWithOptions(specificOpts, env.Options{Environment: vars}); err != nil {
+			return nil, errors.Wrapf(err, "could not parse options for provider '%s'", name)
+		}
+	}
+
+	return &provider.ResolvedClientOptions{
+		Provider: name,
+		Specific: specificOpts,
+	}, nil
+}
+
+// variables returns the configuration as the provider environment variables
+// (without their prefix)
+func (c CapabilityConfig) variables() (map[string]string, error) {
+	vars := make(map[string]string, len(c.Options)+3)
+
+	for key, value := range c.Options {
+		vars[variableName(key)] = formatValue(value)
+	}
+
+	if c.Model != "" {
+		vars["MODEL"] = c.Model
+	}
+
+	if c.BaseURL != "" {
+		vars["BASE_URL"] = c.BaseURL
+	}
+
+	apiKey, err := c.apiKey()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if apiKey != "" {
+		vars["API_KEY"] = apiKey
+	}
+
+	return vars, nil
+}
+
+func (c CapabilityConfig) apiKey() (string, error) {
+	switch {
+	case c.APIKey != "":
+		return c.APIKey, nil
+
+	case c.APIKeyEnv != "":
+		apiKey, exists := os.LookupEnv(c.APIKeyEnv)
+		if !exists {
+			return "", errors.Wrapf(ErrInvalidConfig, "environment variable '%s' is not defined", c.APIKeyEnv)
+		}
+		return apiKey, nil
+
+	case c.APIKeyFile != "":
+		data, err := os.ReadFile(c.APIKeyFile)
+		if err != nil {
+			return "", errors.Wrap(err, "could not read api key file")
+		}
+		return strings.TrimSpace(string(data)), nil
+
+	default:
+		return "", nil
+	}
+}
+
+// variableName converts a camel, kebab or snake case key to the upper snake
+// case suffix of an environment variable (contextSize → CONTEXT_SIZE)
+func variableName(key string) string {
+	var sb strings.Builder
+
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == '.' || r == ' ':
+			sb.WriteRune('_')
+			continue
+		case unicode.IsUpper(r) && i > 0:
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+
+	return sb.String()
+}
+
+// formatValue formats a configuration value with the syntax expected by
+// github.com/caarlos0/env: comma separated slices and "key:value" maps
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = formatValue(item)
+		}
+		return strings.Join(parts, ",")
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = k + ":" + formatValue(v[k])
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
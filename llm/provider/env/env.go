@@ -12,7 +12,8 @@ import (
 
 // With retourne une provider.OptionFunc qui peuple les options depuis les variables d'environnement.
 // Le parsing s'effectue en deux passes :
-//  1. Identification du provider via {prefix}CHAT_COMPLETION_PROVIDER (ou EMBEDDINGS_PROVIDER)
+//  1. Identification du provider via {prefix}CHAT_COMPLETION_PROVIDER (ou EMBEDDINGS_PROVIDER,
+//     TRANSCRIPTION_PROVIDER, IMAGE_GENERATION_PROVIDER, COMPLETION_PROVIDER)
 //  2. Peuplement des options spécifiques au provider via {prefix}{TYPE}_{PROVIDER_UPPER}_*
 //
 // Si le provider n'est pas enregistré, Specific reste nil et l'erreur sera levée à Create().
@@ -56,6 +57,26 @@ func With(variableNamePrefix string, envFiles ...string) provider.OptionFunc {
 		}
 		opts.Transcription = transcriptionResolved
 
+		// Image generation
+		imageGenerationResolved, err := resolveOptions(
+			variableNamePrefix+"IMAGE_GENERATION_",
+			provider.NewImageGenerationProviderOptions,
+		)
+		if err != nil {
+			return errors.Wrap(err, "could not resolve image generation options")
+		}
+		opts.ImageGeneration = imageGenerationResolved
+
+		// Completion
+		completionResolved, err := resolveOptions(
+			variableNamePrefix+"COMPLETION_",
+			provider.NewCompletionProviderOptions,
+		)
+		if err != nil {
+			return errors.Wrap(err, "could not resolve completion options")
+		}
+		opts.Completion = completionResolved
+
 		return nil
 	}
 }
@@ -46,6 +46,24 @@ func init() {
 			return nil, nil
 		},
 	)
+	provider.RegisterImageGeneration(
+		"envtest",
+		func() *envTestOptions {
+			return &envTestOptions{BaseURL: "http://default-img.example.com"}
+		},
+		func(ctx context.Context, opts *envTestOptions) (llm.ImageGenerationClient, error) {
+			return nil, nil
+		},
+	)
+	provider.RegisterCompletion(
+		"envtest",
+		func() *envTestOptions {
+			return &envTestOptions{BaseURL: "http://default-fim.example.com"}
+		},
+		func(ctx context.Context, opts *envTestOptions) (llm.CompletionClient, error) {
+			return nil, nil
+		},
+	)
 }
 
 func TestWith_ParsesChatCompletionOptions(t *testing.T) {
@@ -192,3 +210,53 @@ func TestWith_ParsesTranscriptionOptions(t *testing.T) {
 		t.Errorf("expected default base URL, got %q", typed.BaseURL)
 	}
 }
+
+func TestWith_ParsesImageGenerationOptions(t *testing.T) {
+	t.Setenv("TEST6_IMAGE_GENERATION_PROVIDER", "envtest")
+	t.Setenv("TEST6_IMAGE_GENERATION_ENVTEST_MODEL", "img-model")
+
+	opts, err := provider.NewOptions(providerenv.With("TEST6_"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.ImageGeneration == nil {
+		t.Fatal("expected ImageGeneration to be set")
+	}
+
+	typed, ok := opts.ImageGeneration.Specific.(*envTestOptions)
+	if !ok {
+		t.Fatalf("expected *envTestOptions, got %T", opts.ImageGeneration.Specific)
+	}
+	if typed.Model != "img-model" {
+		t.Errorf("expected model 'img-model', got %q", typed.Model)
+	}
+	if typed.BaseURL != "http://default-img.example.com" {
+		t.Errorf("expected default base URL, got %q", typed.BaseURL)
+	}
+}
+
+func TestWith_ParsesCompletionOptions(t *testing.T) {
+	t.Setenv("TEST7_COMPLETION_PROVIDER", "envtest")
+	t.Setenv("TEST7_COMPLETION_ENVTEST_MODEL", "fim-model")
+
+	opts, err := provider.NewOptions(providerenv.With("TEST7_"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.Completion == nil {
+		t.Fatal("expected Completion to be set")
+	}
+
+	typed, ok := opts.Completion.Specific.(*envTestOptions)
+	if !ok {
+		t.Fatalf("expected *envTestOptions, got %T", opts.Completion.Specific)
+	}
+	if typed.Model != "fim-model" {
+		t.Errorf("expected model 'fim-model', got %q", typed.Model)
+	}
+	if typed.BaseURL != "http://default-fim.example.com" {
+		t.Errorf("expected default base URL, got %q", typed.BaseURL)
+	}
+}
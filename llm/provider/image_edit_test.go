@@ -0,0 +1,170 @@
+package provider_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/provider"
+	"github.com/bornholm/genai/llm/provider/openai"
+	"github.com/bornholm/genai/llm/provider/openrouter"
+)
+
+// OpenAI reçoit l'image et le masque en multipart ; dall-e-2 répond par une
+// URL, téléchargée avant de rendre la main et signalée par ImageSource.
+func TestImageEdit_OpenAI(t *testing.T) {
+	var (
+		server  *httptest.Server
+		gotMask bool
+	)
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/files/edited.png" {
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(pngPixel)
+			return
+		}
+
+		if !strings.HasSuffix(r.URL.Path, "/images/edits") {
+			t.Errorf("chemin = %q, attendu /images/edits", r.URL.Path)
+		}
+
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("ParseMultipartForm: %v", err)
+		}
+		if r.FormValue("prompt") != "add a hat" || r.FormValue("model") != "dall-e-2" {
+			t.Errorf("formulaire = %v", r.MultipartForm.Value)
+		}
+		if files := r.MultipartForm.File["image"]; len(files) != 1 || files[0].Header.Get("Content-Type") != "image/png" {
+			t.Errorf("image source absente ou sans type image")
+		}
+		gotMask = len(r.MultipartForm.File["mask"]) == 1
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"created": 1,
+			"data":    []map[string]any{{"url": server.URL + "/files/edited.png"}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := provider.Create(context.Background(), provider.WithImageGeneration(openai.Name, openai.Options{
+		CommonOptions: provider.CommonOptions{Model: "dall-e-2", APIKey: "sk-test", BaseURL: server.URL},
+	}))
+	if err != nil {
+		t.Fatalf("provider.Create: %v", err)
+	}
+
+	editor, ok := client.(llm.ImageEditClient)
+	if !ok {
+		t.Fatalf("le client %T n'expose pas ImageEdit", client)
+	}
+
+	resp, err := editor.ImageEdit(context.Background(), pngPixel, "add a hat", llm.WithImageMask(pngPixel))
+	if err != nil {
+		t.Fatalf("ImageEdit: %+v", err)
+	}
+
+	images := resp.Images()
+	if len(images) != 1 || string(images[0].Data()) != string(pngPixel) {
+		t.Fatalf("image téléchargée inattendue")
+	}
+	if format := llm.ImageFormatOf(images[0]); format != llm.ImageFormatURL {
+		t.Errorf("format = %q, attendu %q", format, llm.ImageFormatURL)
+	}
+	if source := images[0].(llm.ImageSource); source.URL() != server.URL+"/files/edited.png" {
+		t.Errorf("url = %q", source.URL())
+	}
+	if !gotMask {
+		t.Error("masque non transmis")
+	}
+
+	// Le masque est optionnel
+	if _, err := editor.ImageEdit(context.Background(), pngPixel, "add a hat"); err != nil {
+		t.Fatalf("ImageEdit sans masque: %+v", err)
+	}
+	if gotMask {
+		t.Error("masque transmis alors qu'aucun n'était fourni")
+	}
+}
+
+// OpenRouter édite via les modèles à sortie image de /chat/completions : le
+// masque part en seconde image, accompagné d'une consigne.
+func TestImageEdit_OpenRouter(t *testing.T) {
+	var requestBody struct {
+		Model      string   `json:"model"`
+		Modalities []string `json:"modalities"`
+		Messages   []struct {
+			Content []struct {
+				Type     string `json:"type"`
+				Text     string `json:"text"`
+				ImageURL *struct {
+					URL string `json:"url"`
+				} `json:"image_url"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			t.Errorf("chemin = %q, attendu /chat/completions", r.URL.Path)
+		}
+
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &requestBody)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{
+				"message": map[string]any{
+					"role":    "assistant",
+					"content": "A cat wearing a hat",
+					"images": []map[string]any{{
+						"type":      "image_url",
+						"image_url": map[string]any{"url": "data:image/png;base64," + pngBase64()},
+					}},
+				},
+			}},
+			"usage": map[string]any{"prompt_tokens": 10, "completion_tokens": 1290, "total_tokens": 1300, "cost": 0.039},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client := openrouter.NewImageGenerationClient(nil, server.URL, "sk-test", "google/gemini-2.5-flash-image")
+
+	resp, err := client.ImageEdit(context.Background(), pngPixel, "add a hat", llm.WithImageMask(pngPixel))
+	if err != nil {
+		t.Fatalf("ImageEdit: %+v", err)
+	}
+
+	if requestBody.Model != "google/gemini-2.5-flash-image" || len(requestBody.Modalities) != 2 {
+		t.Errorf("requête openrouter = %+v", requestBody)
+	}
+	if len(requestBody.Messages) != 1 || len(requestBody.Messages[0].Content) != 3 {
+		t.Fatalf("attendu un texte et deux images, reçu %+v", requestBody.Messages)
+	}
+	if !strings.HasPrefix(requestBody.Messages[0].Content[0].Text, "add a hat") {
+		t.Errorf("prompt = %q", requestBody.Messages[0].Content[0].Text)
+	}
+	if url := requestBody.Messages[0].Content[1].ImageURL.URL; !strings.HasPrefix(url, "data:image/png;base64,") {
+		t.Errorf("image source = %q", url)
+	}
+
+	images := resp.Images()
+	if len(images) != 1 || string(images[0].Data()) != string(pngPixel) {
+		t.Fatalf("image décodée inattendue")
+	}
+	if llm.ImageFormatOf(images[0]) != llm.ImageFormatBase64 {
+		t.Errorf("format = %q, attendu base64", llm.ImageFormatOf(images[0]))
+	}
+	if prompts := llm.RevisedPrompts(resp); len(prompts) != 1 || prompts[0] != "A cat wearing a hat" {
+		t.Errorf("prompts révisés = %v", prompts)
+	}
+	if _, _, ok := resp.Usage().(llm.CostReportingUsage).Cost(); !ok {
+		t.Error("coût non reporté")
+	}
+}
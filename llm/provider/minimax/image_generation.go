@@ -50,8 +50,13 @@ type imageGenerationResponse struct {
 func (c *ImageGenerationClient) ImageGeneration(ctx context.Context, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
 	opts := llm.NewImageGenerationOptions(funcs...)
 
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
 	payload := imageGenerationRequest{
-		Model:          c.model,
+		Model:          model,
 		Prompt:         prompt,
 		ResponseFormat: "base64",
 		AspectRatio:    opts.AspectRatio,
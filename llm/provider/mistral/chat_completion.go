@@ -2,6 +2,7 @@ package mistral
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -10,6 +11,8 @@ import (
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 	"github.com/pkg/errors"
+
+	genai "github.com/bornholm/genai/llm/provider/openai"
 )
 
 // ChatCompletionClient is a Mistral-specific chat completion client
@@ -54,9 +57,39 @@ func (c *ChatCompletionClient) ChatCompletion(ctx context.Context, funcs ...llm.
 		return nil, errors.WithStack(llm.ErrNoMessage)
 	}
 
-	openaiMessage := completion.Choices[0].Message
+	message, reasoning, reasoningDetails, toolCalls := convertMessage(completion.Choices[0].Message)
+
+	usage := llm.NewChatCompletionUsageWithCache(
+		completion.Usage.PromptTokens,
+		completion.Usage.CompletionTokens,
+		completion.Usage.TotalTokens,
+		completion.Usage.PromptTokensDetails.CachedTokens,
+	)
+
+	// Return response with reasoning if present
+	var res *llm.BaseChatCompletionResponse
+	if reasoning != "" || len(reasoningDetails) > 0 {
+		res = llm.NewChatCompletionResponseWithReasoning(message, usage, reasoning, reasoningDetails, toolCalls...)
+	} else {
+		res = llm.NewChatCompletionResponse(message, usage, toolCalls...)
+	}
+
+	if !genai.WantsCandidates(opts) {
+		return res, nil
+	}
+
+	candidates := make([]llm.Candidate, 0, len(completion.Choices))
+	for _, choice := range completion.Choices {
+		message, _, _, toolCalls := convertMessage(choice.Message)
+		candidates = append(candidates, llm.NewCandidate(int(choice.Index), message, llm.FinishReason(choice.FinishReason), genai.ConvertLogprobs(choice.Logprobs.Content), toolCalls...))
+	}
 
-	// Extract thinking from response content
+	return llm.NewCandidatesResponse(res, candidates...), nil
+}
+
+// convertMessage converts a message of the API, extracting the thinking
+// blocks from its content
+func convertMessage(openaiMessage openai.ChatCompletionMessage) (llm.Message, string, []llm.ReasoningDetail, []llm.ToolCall) {
 	textContent, reasoningDetails, reasoning := extractThinkingFromResponse(openaiMessage.Content)
 
 	// Build the response message. When reasoning is present, return a ReasoningMessage
@@ -74,19 +107,7 @@ func (c *ChatCompletionClient) ChatCompletion(ctx context.Context, funcs ...llm.
 		toolCalls = append(toolCalls, llm.NewToolCall(tc.ID, tc.Function.Name, tc.Function.Arguments))
 	}
 
-	usage := llm.NewChatCompletionUsageWithCache(
-		completion.Usage.PromptTokens,
-		completion.Usage.CompletionTokens,
-		completion.Usage.TotalTokens,
-		completion.Usage.PromptTokensDetails.CachedTokens,
-	)
-
-	// Return response with reasoning if present
-	if reasoning != "" || len(reasoningDetails) > 0 {
-		return llm.NewChatCompletionResponseWithReasoning(message, usage, reasoning, reasoningDetails, toolCalls...), nil
-	}
-
-	return llm.NewChatCompletionResponse(message, usage, toolCalls...), nil
+	return message, reasoning, reasoningDetails, toolCalls
 }
 
 // ChatCompletionStream implements llm.ChatCompletionStreamingClient.
@@ -110,6 +131,8 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 		return nil, errors.WithStack(err)
 	}
 
+	genai.ClearCandidates(params)
+
 	var httpRes *http.Response
 
 	stream := c.client.Chat.Completions.NewStreaming(ctx, *params, option.WithResponseInto(&httpRes))
@@ -213,6 +236,16 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 	return chunks, nil
 }
 
+// ValidateAttachment implements llm.AttachmentValidator: only images are
+// sent to Mistral, documents being rejected when converting the messages.
+func (c *ChatCompletionClient) ValidateAttachment(attachment llm.Attachment) error {
+	if attachment.Type() != llm.AttachmentTypeImage {
+		return llm.NewAttachmentError("provider", "type", fmt.Sprintf("%s attachments not yet supported for Mistral", attachment.Type()))
+	}
+
+	return nil
+}
+
 func NewChatCompletionClient(client openai.Client, params ParamsBuilder) *ChatCompletionClient {
 	return &ChatCompletionClient{
 		client: client,
@@ -222,3 +255,4 @@ func NewChatCompletionClient(client openai.Client, params ParamsBuilder) *ChatCo
 
 var _ llm.ChatCompletionClient = &ChatCompletionClient{}
 var _ llm.ChatCompletionStreamingClient = &ChatCompletionClient{}
+var _ llm.AttachmentValidator = &ChatCompletionClient{}
@@ -12,6 +12,15 @@ import (
 
 const Name provider.Name = "mistral"
 
+// ImageLimits are the constraints of Mistral vision models on input images,
+// to be passed to llm.WithImageLimits.
+var ImageLimits = llm.ImageLimits{
+	MaxWidth:  2048,
+	MaxHeight: 2048,
+	MaxBytes:  10 << 20,
+	MimeTypes: []string{"image/png", "image/jpeg", "image/webp", "image/gif"},
+}
+
 func init() {
 	provider.RegisterChatCompletion(
 		Name,
@@ -59,7 +68,25 @@ func init() {
 				options = append(options, option.WithAPIKey(opts.APIKey))
 			}
 			client := openaisdk.NewClient(options...)
-			return genai.NewTranscriptionClient(client, opts.Model), nil
+			return genai.NewTranscriptionClient(client, opts.Model, genai.WithTranscriptionDialect(genai.TranscriptionDialectMistral)), nil
+		},
+	)
+
+	// La complétion fill-in-the-middle de Codestral passe par l'endpoint
+	// /fim/completions, dont le dialecte est géré par le client openai.
+	provider.RegisterCompletion(
+		Name,
+		defaultOptions,
+		func(ctx context.Context, opts *Options) (llm.CompletionClient, error) {
+			options := []option.RequestOption{
+				option.WithBaseURL(opts.BaseURL),
+				option.WithMaxRetries(0), // genai's llmretry wrapper handles all retries
+			}
+			if opts.APIKey != "" {
+				options = append(options, option.WithAPIKey(opts.APIKey))
+			}
+			client := openaisdk.NewClient(options...)
+			return genai.NewCompletionClient(client, opts.Model, genai.WithCompletionDialect(genai.CompletionDialectMistral)), nil
 		},
 	)
 }
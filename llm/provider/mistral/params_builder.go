@@ -15,7 +15,12 @@ type paramsBuilder struct {
 }
 
 func (b *paramsBuilder) BuildParams(ctx context.Context, opts *llm.ChatCompletionOptions) (*openai.ChatCompletionNewParams, error) {
-	if b.model == "" {
+	model := b.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	if model == "" {
 		return nil, errors.WithStack(llm.ErrUnavailable)
 	}
 
@@ -29,13 +34,15 @@ func (b *paramsBuilder) BuildParams(ctx context.Context, opts *llm.ChatCompletio
 		configureRandomSeed,
 		genai.ConfigureReasoning,
 		configurePromptMode,
+		configureSampling,
+		genai.ConfigureCandidates,
 		genai.ConfigureExtraFields,
 	)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	params.Model = openai.ChatModel(b.model)
+	params.Model = openai.ChatModel(model)
 
 	return params, nil
 }
@@ -89,3 +96,7 @@ func configurePromptMode(ctx context.Context, opts *llm.ChatCompletionOptions, p
 
 	return nil
 }
+
+// configureSampling maps the sampling controls, Mistral supporting neither
+// top_k, min_p nor logit_bias
+var configureSampling = genai.NewSamplingConfigurator("mistral", llm.SamplingLogitBias)
@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+var defaultClients = NewClients()
+
+// ClientFactory crée un llm.Client configuré.
+type ClientFactory func(ctx context.Context) (llm.Client, error)
+
+// Clients est un ensemble de clients nommés. Les clients sont créés à la demande
+// par leur factory.
+type Clients struct {
+	mu        sync.Mutex
+	factories map[string]ClientFactory
+	instances map[string]llm.Client
+}
+
+// Register enregistre la factory du client nommé, en remplaçant une éventuelle
+// factory existante.
+func (c *Clients) Register(name string, factory ClientFactory) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.factories[name] = factory
+	delete(c.instances, name)
+}
+
+// Has indique si un client est enregistré sous ce nom.
+func (c *Clients) Has(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, exists := c.factories[name]
+	return exists
+}
+
+// Names retourne les noms des clients enregistrés, triés.
+func (c *Clients) Names() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	names := make([]string, 0, len(c.factories))
+	for name := range c.factories {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// Create crée une nouvelle instance du client nommé.
+func (c *Clients) Create(ctx context.Context, name string) (llm.Client, error) {
+	c.mu.Lock()
+	factory, exists := c.factories[name]
+	c.mu.Unlock()
+
+	if !exists {
+		return nil, errors.Wrapf(ErrClientNotFound, "could not find client '%s'", name)
+	}
+
+	client, err := factory(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create client '%s'", name)
+	}
+
+	return client, nil
+}
+
+// Get retourne l'instance partagée du client nommé, créée au premier appel.
+func (c *Clients) Get(ctx context.Context, name string) (llm.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, exists := c.instances[name]; exists {
+		return client, nil
+	}
+
+	factory, exists := c.factories[name]
+	if !exists {
+		return nil, errors.Wrapf(ErrClientNotFound, "could not find client '%s'", name)
+	}
+
+	client, err := factory(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create client '%s'", name)
+	}
+
+	c.instances[name] = client
+
+	return client, nil
+}
+
+func NewClients() *Clients {
+	return &Clients{
+		factories: map[string]ClientFactory{},
+		instances: map[string]llm.Client{},
+	}
+}
+
+// RegisterNamed enregistre un client nommé dans l'ensemble global.
+func RegisterNamed(name string, factory ClientFactory) {
+	defaultClients.Register(name, factory)
+}
+
+// CreateNamed crée le client nommé enregistré dans l'ensemble global.
+func CreateNamed(ctx context.Context, name string) (llm.Client, error) {
+	return defaultClients.Create(ctx, name)
+}
+
+// NamedClients retourne l'ensemble global des clients nommés.
+func NamedClients() *Clients {
+	return defaultClients
+}
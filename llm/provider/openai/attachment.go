@@ -10,6 +10,15 @@ import (
 	"github.com/pkg/errors"
 )
 
+// ImageLimits are the constraints of OpenAI on input images, to be passed to
+// llm.WithImageLimits: larger images are downscaled by the API anyway.
+var ImageLimits = llm.ImageLimits{
+	MaxWidth:  2048,
+	MaxHeight: 2048,
+	MaxBytes:  20 << 20,
+	MimeTypes: []string{"image/png", "image/jpeg", "image/webp", "image/gif"},
+}
+
 // OpenAIAttachmentValidator implements provider-specific validation for OpenAI
 type OpenAIAttachmentValidator struct {
 	model string
@@ -0,0 +1,60 @@
+package openai
+
+import (
+	"context"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/packages/param"
+)
+
+// ConfigureCandidates requests several choices and the log-probabilities of
+// the generated tokens
+func ConfigureCandidates(ctx context.Context, opts *llm.ChatCompletionOptions, params *openai.ChatCompletionNewParams) error {
+	if opts.Candidates > 1 {
+		params.N = openai.Int(int64(opts.Candidates))
+	}
+
+	if opts.Logprobs {
+		params.Logprobs = openai.Bool(true)
+		if opts.TopLogprobs > 0 {
+			params.TopLogprobs = openai.Int(int64(opts.TopLogprobs))
+		}
+	}
+
+	return nil
+}
+
+// ClearCandidates withdraws the request of several choices: streaming calls
+// only generate the first one.
+func ClearCandidates(params *openai.ChatCompletionNewParams) {
+	params.N = param.Opt[int64]{}
+}
+
+// WantsCandidates tells if the response must implement llm.CandidatesResponse
+func WantsCandidates(opts *llm.ChatCompletionOptions) bool {
+	return opts.Candidates > 1 || opts.Logprobs
+}
+
+// ConvertLogprobs converts the log-probabilities of the generated tokens
+func ConvertLogprobs(content []openai.ChatCompletionTokenLogprob) []llm.TokenLogprob {
+	if len(content) == 0 {
+		return nil
+	}
+
+	logprobs := make([]llm.TokenLogprob, 0, len(content))
+	for _, lp := range content {
+		var top []llm.TopLogprob
+		for _, t := range lp.TopLogprobs {
+			top = append(top, llm.TopLogprob{Token: t.Token, Logprob: t.Logprob})
+		}
+
+		logprobs = append(logprobs, llm.TokenLogprob{
+			Token:       lp.Token,
+			Logprob:     lp.Logprob,
+			TopLogprobs: top,
+		})
+	}
+
+	return logprobs
+}
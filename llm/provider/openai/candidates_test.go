@@ -0,0 +1,61 @@
+package openai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/openai/openai-go"
+)
+
+func TestConfigureCandidates(t *testing.T) {
+	t.Run("no candidates leaves params untouched", func(t *testing.T) {
+		params := &openai.ChatCompletionNewParams{}
+		if err := ConfigureCandidates(context.Background(), llm.NewChatCompletionOptions(), params); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if params.N.IsPresent() || params.Logprobs.IsPresent() || params.TopLogprobs.IsPresent() {
+			t.Fatalf("expected no candidates params, got %+v", params)
+		}
+	})
+
+	t.Run("candidates and logprobs are requested", func(t *testing.T) {
+		params := &openai.ChatCompletionNewParams{}
+		opts := llm.NewChatCompletionOptions(llm.WithCandidates(3), llm.WithLogprobs(2))
+		if err := ConfigureCandidates(context.Background(), opts, params); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if params.N.Value != 3 || !params.Logprobs.Value || params.TopLogprobs.Value != 2 {
+			t.Fatalf("unexpected params %+v", params)
+		}
+
+		ClearCandidates(params)
+		if params.N.IsPresent() {
+			t.Errorf("expected n to be cleared")
+		}
+	})
+}
+
+func TestConvertLogprobs(t *testing.T) {
+	if logprobs := ConvertLogprobs(nil); logprobs != nil {
+		t.Errorf("expected nil logprobs, got %v", logprobs)
+	}
+
+	logprobs := ConvertLogprobs([]openai.ChatCompletionTokenLogprob{
+		{
+			Token:   "Hi",
+			Logprob: -0.1,
+			TopLogprobs: []openai.ChatCompletionTokenLogprobTopLogprob{
+				{Token: "Hi", Logprob: -0.1},
+				{Token: "Hello", Logprob: -2.3},
+			},
+		},
+	})
+
+	if len(logprobs) != 1 || logprobs[0].Token != "Hi" || logprobs[0].Logprob != -0.1 {
+		t.Fatalf("unexpected logprobs %+v", logprobs)
+	}
+	if len(logprobs[0].TopLogprobs) != 2 || logprobs[0].TopLogprobs[1].Token != "Hello" {
+		t.Errorf("unexpected top logprobs %+v", logprobs[0].TopLogprobs)
+	}
+}
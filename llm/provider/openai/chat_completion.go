@@ -49,24 +49,11 @@ func (c *ChatCompletionClient) ChatCompletion(ctx context.Context, funcs ...llm.
 		return nil, errors.WithStack(llm.ErrNoMessage)
 	}
 
-	openaiMessage := completion.Choices[0].Message
+	message, reasoning, toolCalls := convertMessage(completion.Choices[0].Message)
 
-	var reasoning string
-	if rf, ok := openaiMessage.JSON.ExtraFields["reasoning_content"]; ok {
-		_ = json.Unmarshal([]byte(rf.Raw()), &reasoning)
-	}
-
-	var message llm.Message
-	if reasoning != "" {
-		message = llm.NewAssistantReasoningMessage(openaiMessage.Content, reasoning, nil)
-	} else {
-		message = llm.NewMessage(llm.RoleAssistant, openaiMessage.Content)
-	}
-
-	toolCalls := make([]llm.ToolCall, 0)
-
-	for _, tc := range openaiMessage.ToolCalls {
-		toolCalls = append(toolCalls, llm.NewToolCall(tc.ID, tc.Function.Name, tc.Function.Arguments))
+	// Perplexity lists the sources of the answer next to the choices
+	if sources := extraSources(completion.JSON.ExtraFields); len(sources) > 0 && len(llm.CitationsOf(message)) == 0 {
+		message, _ = llm.WithCitations(message, sources)
 	}
 
 	// OpenAI compatible gateways (OpenRouter, LiteLLM) report what the call
@@ -92,10 +79,52 @@ func (c *ChatCompletionClient) ChatCompletion(ctx context.Context, funcs ...llm.
 		)
 	}
 
+	var res *llm.BaseChatCompletionResponse
 	if reasoning != "" {
-		return llm.NewChatCompletionResponseWithReasoning(message, usage, reasoning, nil, toolCalls...), nil
+		res = llm.NewChatCompletionResponseWithReasoning(message, usage, reasoning, nil, toolCalls...)
+	} else {
+		res = llm.NewChatCompletionResponse(message, usage, toolCalls...)
+	}
+
+	if !WantsCandidates(opts) {
+		return res, nil
+	}
+
+	candidates := make([]llm.Candidate, 0, len(completion.Choices))
+	for _, choice := range completion.Choices {
+		message, _, toolCalls := convertMessage(choice.Message)
+		candidates = append(candidates, llm.NewCandidate(int(choice.Index), message, llm.FinishReason(choice.FinishReason), ConvertLogprobs(choice.Logprobs.Content), toolCalls...))
 	}
-	return llm.NewChatCompletionResponse(message, usage, toolCalls...), nil
+
+	return llm.NewCandidatesResponse(res, candidates...), nil
+}
+
+// convertMessage converts a message of the API, its reasoning being carried
+// by the non standard "reasoning_content" field
+func convertMessage(openaiMessage openai.ChatCompletionMessage) (llm.Message, string, []llm.ToolCall) {
+	var reasoning string
+	if rf, ok := openaiMessage.JSON.ExtraFields["reasoning_content"]; ok {
+		_ = json.Unmarshal([]byte(rf.Raw()), &reasoning)
+	}
+
+	var message llm.Message
+	if reasoning != "" {
+		message = llm.NewAssistantReasoningMessage(openaiMessage.Content, reasoning, nil)
+	} else {
+		message = llm.NewMessage(llm.RoleAssistant, openaiMessage.Content)
+	}
+
+	if citations := convertAnnotations(openaiMessage.Annotations); len(citations) > 0 {
+		message, _ = llm.WithCitations(message, citations)
+	}
+
+	toolCalls := make([]llm.ToolCall, 0)
+
+	for _, tc := range openaiMessage.ToolCalls {
+		toolCalls = append(toolCalls, llm.NewToolCall(tc.ID, tc.Function.Name, tc.Function.Arguments))
+	}
+
+	return message, reasoning, toolCalls
 }
 
 // ChatCompletionStream implements llm.ChatCompletionStreamingClient.
@@ -144,6 +173,9 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 		defer close(chunks)
 		defer stream.Close()
 
+		// Perplexity repeats the sources of the answer in every chunk
+		sourcesSent := false
+
 		for stream.Next() {
 			chunk := stream.Current()
 
@@ -203,6 +235,19 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 				)
 			}
 
+			citations := extraAnnotations(delta.JSON.ExtraFields)
+
+			if !sourcesSent {
+				if sources := extraSources(chunk.JSON.ExtraFields); len(sources) > 0 {
+					citations = append(citations, sources...)
+					sourcesSent = true
+				}
+			}
+
+			if len(citations) > 0 {
+				streamDelta, _ = llm.WithStreamDeltaCitations(streamDelta, citations)
+			}
+
 			chunks <- llm.NewStreamChunk(streamDelta)
 		}
 
@@ -240,6 +285,12 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 	return chunks, nil
 }
 
+// ValidateAttachment implements llm.AttachmentValidator, so wrappers can
+// tell which attachments the client sends natively.
+func (c *ChatCompletionClient) ValidateAttachment(attachment llm.Attachment) error {
+	return NewOpenAIAttachmentValidator("").ValidateAttachment(attachment)
+}
+
 func NewChatCompletionClient(client openai.Client, params ParamsBuilder) *ChatCompletionClient {
 	return &ChatCompletionClient{
 		client: client,
@@ -249,3 +300,4 @@ func NewChatCompletionClient(client openai.Client, params ParamsBuilder) *ChatCo
 
 var _ llm.ChatCompletionClient = &ChatCompletionClient{}
 var _ llm.ChatCompletionStreamingClient = &ChatCompletionClient{}
+var _ llm.AttachmentValidator = &ChatCompletionClient{}
@@ -0,0 +1,106 @@
+package openai
+
+import (
+	"encoding/json"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/packages/resp"
+)
+
+// urlCitationAnnotation is an "url_citation" annotation as returned in the
+// stream deltas, which the SDK does not model
+type urlCitationAnnotation struct {
+	Type        string `json:"type"`
+	URLCitation struct {
+		URL        string `json:"url"`
+		Title      string `json:"title"`
+		Content    string `json:"content"`
+		StartIndex int    `json:"start_index"`
+		EndIndex   int    `json:"end_index"`
+	} `json:"url_citation"`
+}
+
+// searchResult is a source of the non standard "search_results" field of
+// Perplexity
+type searchResult struct {
+	URL     string `json:"url"`
+	Title   string `json:"title"`
+	Snippet string `json:"snippet"`
+}
+
+// convertAnnotations converts the url_citation annotations of a message
+func convertAnnotations(annotations []openai.ChatCompletionMessageAnnotation) []llm.Citation {
+	citations := make([]llm.Citation, 0, len(annotations))
+
+	for _, a := range annotations {
+		citations = append(citations, llm.Citation{
+			URL:        a.URLCitation.URL,
+			Title:      a.URLCitation.Title,
+			StartIndex: int(a.URLCitation.StartIndex),
+			EndIndex:   int(a.URLCitation.EndIndex),
+		})
+	}
+
+	return citations
+}
+
+// extraAnnotations decodes the url_citation annotations of a stream delta
+func extraAnnotations(fields map[string]resp.Field) []llm.Citation {
+	field, found := fields["annotations"]
+	if !found {
+		return nil
+	}
+
+	var annotations []urlCitationAnnotation
+	if err := json.Unmarshal([]byte(field.Raw()), &annotations); err != nil {
+		return nil
+	}
+
+	citations := make([]llm.Citation, 0, len(annotations))
+
+	for _, a := range annotations {
+		if a.Type != "url_citation" {
+			continue
+		}
+
+		citations = append(citations, llm.Citation{
+			URL:        a.URLCitation.URL,
+			Title:      a.URLCitation.Title,
+			Content:    a.URLCitation.Content,
+			StartIndex: a.URLCitation.StartIndex,
+			EndIndex:   a.URLCitation.EndIndex,
+		})
+	}
+
+	return citations
+}
+
+// extraSources decodes the sources that Perplexity reports next to the
+// choices, in the "search_results" field or, for older models, as the bare
+// URLs of the "citations" field. They are not located in the content.
+func extraSources(fields map[string]resp.Field) []llm.Citation {
+	if field, found := fields["search_results"]; found {
+		var results []searchResult
+		if err := json.Unmarshal([]byte(field.Raw()), &results); err == nil && len(results) > 0 {
+			citations := make([]llm.Citation, 0, len(results))
+			for _, r := range results {
+				citations = append(citations, llm.Citation{URL: r.URL, Title: r.Title, Content: r.Snippet})
+			}
+			return citations
+		}
+	}
+
+	if field, found := fields["citations"]; found {
+		var urls []string
+		if err := json.Unmarshal([]byte(field.Raw()), &urls); err == nil && len(urls) > 0 {
+			citations := make([]llm.Citation, 0, len(urls))
+			for _, url := range urls {
+				citations = append(citations, llm.Citation{URL: url})
+			}
+			return citations
+		}
+	}
+
+	return nil
+}
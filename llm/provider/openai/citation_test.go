@@ -0,0 +1,125 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// newTestChatCompletionClient returns a client of a local server answering
+// the chat completion calls with the handler
+func newTestChatCompletionClient(t *testing.T, handler http.HandlerFunc) *ChatCompletionClient {
+	server := httptest.NewServer(handler)
+
+	t.Cleanup(server.Close)
+
+	client := openai.NewClient(
+		option.WithBaseURL(server.URL),
+		option.WithAPIKey("test"),
+		option.WithMaxRetries(0),
+	)
+
+	return NewChatCompletionClient(client, &paramsBuilder{model: "citation-test"})
+}
+
+func TestChatCompletion_Citations(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		body  string
+		want  []llm.Citation
+		first string
+	}{
+		{
+			name: "url_citation annotations",
+			body: `{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"finish_reason":"stop","message":{
+				"role":"assistant","content":"Go 1.25 is out.",
+				"annotations":[{"type":"url_citation","url_citation":{"url":"https://go.dev/blog","title":"The Go Blog","start_index":0,"end_index":15}}]
+			}}]}`,
+			want: []llm.Citation{{URL: "https://go.dev/blog", Title: "The Go Blog", EndIndex: 15}},
+		},
+		{
+			name: "perplexity search results",
+			body: `{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Go 1.25 is out [1]."}}],
+				"citations":["https://go.dev/blog"],
+				"search_results":[{"url":"https://go.dev/blog","title":"The Go Blog","snippet":"Go 1.25 is released"}]}`,
+			want: []llm.Citation{{URL: "https://go.dev/blog", Title: "The Go Blog", Content: "Go 1.25 is released"}},
+		},
+		{
+			name: "perplexity bare citations",
+			body: `{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Go 1.25 is out [1]."}}],
+				"citations":["https://go.dev/blog"]}`,
+			want: []llm.Citation{{URL: "https://go.dev/blog"}},
+		},
+		{
+			name: "no citations",
+			body: `{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hello"}}]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestChatCompletionClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, tc.body)
+			})
+
+			res, err := client.ChatCompletion(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, "What's new in Go?")))
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			if got := llm.CitationsOf(res.Message()); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("citations = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestChatCompletionStream_Citations(t *testing.T) {
+	chunks := []string{
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":"Go 1.25"}}],"citations":["https://go.dev/blog"]}`,
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":" is out.","annotations":[{"type":"url_citation","url_citation":{"url":"https://go.dev/doc","title":"Go docs","start_index":8,"end_index":15}}]},"finish_reason":"stop"}],"citations":["https://go.dev/blog"]}`,
+		`[DONE]`,
+	}
+
+	client := newTestChatCompletionClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+	})
+
+	stream, err := client.ChatCompletionStream(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, "What's new in Go?")))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	var citations []llm.Citation
+
+	for chunk := range stream {
+		if err := chunk.Error(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if chunk.IsComplete() {
+			continue
+		}
+		if ad, ok := chunk.Delta().(llm.AnnotatedStreamDelta); ok {
+			citations = append(citations, ad.Citations()...)
+		}
+	}
+
+	// The sources repeated in every chunk are reported only once
+	want := []llm.Citation{
+		{URL: "https://go.dev/blog"},
+		{URL: "https://go.dev/doc", Title: "Go docs", StartIndex: 8, EndIndex: 15},
+	}
+
+	if fmt.Sprint(citations) != fmt.Sprint(want) {
+		t.Errorf("citations = %+v, want %+v", citations, want)
+	}
+}
@@ -0,0 +1,284 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/packages/ssestream"
+	"github.com/pkg/errors"
+)
+
+// CompletionDialect désigne l'endpoint de complétion de texte brut d'une API
+// compatible OpenAI.
+type CompletionDialect string
+
+const (
+	// CompletionDialectOpenAI utilise l'endpoint historique /completions,
+	// également exposé par vLLM, llama.cpp server ou Ollama. Le texte généré
+	// est porté par choices[].text.
+	CompletionDialectOpenAI CompletionDialect = "openai"
+	// CompletionDialectMistral utilise l'endpoint /fim/completions de
+	// Codestral, le texte généré étant porté par un message comme pour le
+	// chat.
+	CompletionDialectMistral CompletionDialect = "mistral"
+)
+
+type CompletionClient struct {
+	client  openai.Client
+	model   string
+	dialect CompletionDialect
+}
+
+type CompletionClientOptionFunc func(c *CompletionClient)
+
+// WithCompletionDialect sélectionne le dialecte de l'API, OpenAI par défaut.
+func WithCompletionDialect(dialect CompletionDialect) CompletionClientOptionFunc {
+	return func(c *CompletionClient) {
+		c.dialect = dialect
+	}
+}
+
+// Complete implements llm.CompletionClient.
+func (c *CompletionClient) Complete(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	body, err := c.body(prompt, suffix, llm.NewCompletionOptions(funcs...), false)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var (
+		httpRes *http.Response
+		res     completionBody
+	)
+
+	slog.DebugContext(ctx, "starting completion")
+	before := time.Now()
+	err = c.client.Post(ctx, c.path(), body, &res, option.WithResponseInto(&httpRes))
+	slog.DebugContext(ctx, "completion completed", slog.Duration("duration", time.Since(before)))
+
+	if err != nil {
+		var apiErr *openai.Error
+		if errors.As(err, &apiErr) && httpRes != nil {
+			return nil, errors.WithStack(llm.RateLimitError(httpRes.StatusCode, apiErr.RawJSON()))
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	if len(res.Choices) == 0 {
+		return nil, errors.New("no completion returned")
+	}
+
+	choice := res.Choices[0]
+
+	return llm.NewCompletionResponse(choice.text(), llm.FinishReason(choice.FinishReason), res.usage()), nil
+}
+
+// CompleteStream implements llm.CompletionClient.
+func (c *CompletionClient) CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	body, err := c.body(prompt, suffix, llm.NewCompletionOptions(funcs...), true)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// The body of the response is left unread by the client when decoded
+	// into an *http.Response
+	var httpRes *http.Response
+
+	if err := c.client.Post(ctx, c.path(), body, &httpRes); err != nil {
+		// The status is taken from the API error, the response not being
+		// set on every failure path of the client
+		var apiErr *openai.Error
+		if errors.As(err, &apiErr) {
+			return nil, errors.WithStack(llm.RateLimitError(apiErr.StatusCode, apiErr.RawJSON()))
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	decoder := ssestream.NewDecoder(httpRes)
+	if decoder == nil {
+		return nil, errors.New("empty completion stream")
+	}
+
+	chunks := make(chan llm.StreamChunk, 10)
+
+	go func() {
+		defer close(chunks)
+		defer decoder.Close()
+
+		var usage llm.ChatCompletionUsage
+
+		for decoder.Next() {
+			data := decoder.Event().Data
+			if bytes.HasPrefix(data, []byte("[DONE]")) {
+				break
+			}
+
+			var chunk completionBody
+			if err := json.Unmarshal(data, &chunk); err != nil {
+				chunks <- llm.NewErrorStreamChunk(errors.Wrap(err, "could not decode completion chunk"))
+				return
+			}
+
+			if chunk.Error != nil {
+				chunks <- llm.NewErrorStreamChunk(errors.Errorf("received error while streaming: %v", chunk.Error))
+				return
+			}
+
+			if chunk.Usage != nil {
+				usage = chunk.usage()
+			}
+
+			if len(chunk.Choices) == 0 {
+				continue
+			}
+
+			if text := chunk.Choices[0].text(); text != "" {
+				chunks <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, text))
+			}
+		}
+
+		if err := decoder.Err(); err != nil && !errors.Is(err, io.EOF) {
+			chunks <- llm.NewErrorStreamChunk(errors.WithStack(err))
+			return
+		}
+
+		chunks <- llm.NewCompleteStreamChunk(usage)
+	}()
+
+	return chunks, nil
+}
+
+func (c *CompletionClient) path() string {
+	if c.dialect == CompletionDialectMistral {
+		return "fim/completions"
+	}
+
+	return "completions"
+}
+
+func (c *CompletionClient) body(prompt string, suffix string, opts *llm.CompletionOptions, stream bool) (map[string]any, error) {
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	if model == "" {
+		return nil, errors.WithStack(llm.ErrUnavailable)
+	}
+
+	body := map[string]any{
+		"model":  model,
+		"prompt": prompt,
+		"stream": stream,
+	}
+
+	if suffix != "" {
+		body["suffix"] = suffix
+	}
+
+	if opts.MaxTokens != nil {
+		body["max_tokens"] = *opts.MaxTokens
+	}
+
+	if opts.Temperature != nil {
+		body["temperature"] = *opts.Temperature
+	}
+
+	if opts.TopP != nil {
+		body["top_p"] = *opts.TopP
+	}
+
+	if len(opts.Stop) > 0 {
+		body["stop"] = opts.Stop
+	}
+
+	switch c.dialect {
+	case CompletionDialectMistral:
+		if opts.Seed != nil {
+			body["random_seed"] = *opts.Seed
+		}
+
+	default:
+		if opts.Seed != nil {
+			body["seed"] = *opts.Seed
+		}
+		if stream {
+			body["stream_options"] = map[string]any{"include_usage": true}
+		}
+	}
+
+	maps.Copy(body, opts.ExtraFields)
+
+	return body, nil
+}
+
+// completionBody is a response, or a stream chunk, of the completion
+// endpoints of both dialects
+type completionBody struct {
+	Choices []completionChoice `json:"choices"`
+	Usage   *struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+		TotalTokens      int64 `json:"total_tokens"`
+	} `json:"usage"`
+	Error any `json:"error"`
+}
+
+func (b *completionBody) usage() llm.ChatCompletionUsage {
+	if b.Usage == nil {
+		return llm.NewChatCompletionUsage(0, 0, 0)
+	}
+
+	return llm.NewChatCompletionUsage(b.Usage.PromptTokens, b.Usage.CompletionTokens, b.Usage.TotalTokens)
+}
+
+type completionChoice struct {
+	// Text is the generated text of the OpenAI dialect
+	Text string `json:"text"`
+	// Message and Delta carry the generated text of the Mistral dialect
+	Message *completionMessage `json:"message"`
+	Delta   *completionMessage `json:"delta"`
+
+	FinishReason string `json:"finish_reason"`
+}
+
+type completionMessage struct {
+	Content string `json:"content"`
+}
+
+func (c *completionChoice) text() string {
+	switch {
+	case c.Message != nil:
+		return c.Message.Content
+	case c.Delta != nil:
+		return c.Delta.Content
+	default:
+		return c.Text
+	}
+}
+
+func NewCompletionClient(client openai.Client, model string, funcs ...CompletionClientOptionFunc) *CompletionClient {
+	c := &CompletionClient{
+		client:  client,
+		model:   model,
+		dialect: CompletionDialectOpenAI,
+	}
+
+	for _, fn := range funcs {
+		fn(c)
+	}
+
+	return c
+}
+
+var _ llm.CompletionClient = &CompletionClient{}
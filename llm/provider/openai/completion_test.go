@@ -0,0 +1,168 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/pkg/errors"
+)
+
+// newTestCompletionClient returns a client of a local server answering the
+// completion calls on the path with the handler, the request bodies being
+// recorded
+func newTestCompletionClient(t *testing.T, path string, dialect CompletionDialect, handler func(w http.ResponseWriter, body map[string]any)) *CompletionClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("%+v", err)
+			return
+		}
+
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("%+v", err)
+			return
+		}
+
+		handler(w, body)
+	}))
+
+	t.Cleanup(server.Close)
+
+	client := openai.NewClient(
+		option.WithBaseURL(server.URL),
+		option.WithAPIKey("test"),
+		option.WithMaxRetries(0),
+	)
+
+	return NewCompletionClient(client, "completion-test", WithCompletionDialect(dialect))
+}
+
+func TestCompletionClient_Complete(t *testing.T) {
+	var request map[string]any
+
+	client := newTestCompletionClient(t, "/completions", CompletionDialectOpenAI, func(w http.ResponseWriter, body map[string]any) {
+		request = body
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{
+			"id": "cmpl_1",
+			"object": "text_completion",
+			"choices": [{"index": 0, "text": "return a + b", "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 4, "total_tokens": 16}
+		}`)
+	})
+
+	res, err := client.Complete(
+		context.Background(),
+		"func add(a, b int) int {\n\t",
+		"\n}",
+		llm.WithCompletionMaxTokens(32),
+		llm.WithCompletionStop("\n\n"),
+		llm.WithCompletionSeed(42),
+	)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if request["model"] != "completion-test" || request["suffix"] != "\n}" || request["max_tokens"] != float64(32) || request["seed"] != float64(42) {
+		t.Errorf("unexpected request %v", request)
+	}
+
+	if stop := fmt.Sprint(request["stop"]); stop != "[\n\n]" {
+		t.Errorf("unexpected stop sequences %q", stop)
+	}
+
+	if res.Text() != "return a + b" || res.FinishReason() != llm.FinishReasonStop {
+		t.Errorf("unexpected response %q (%s)", res.Text(), res.FinishReason())
+	}
+
+	if res.Usage().TotalTokens() != 16 {
+		t.Errorf("unexpected usage %+v", res.Usage())
+	}
+}
+
+func TestCompletionClient_CompleteStream(t *testing.T) {
+	var request map[string]any
+
+	chunks := []string{
+		`{"id":"fim_1","choices":[{"index":0,"delta":{"role":"assistant","content":"return"},"finish_reason":null}]}`,
+		`{"id":"fim_1","choices":[{"index":0,"delta":{"content":" a + b"},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":4,"total_tokens":16}}`,
+		`[DONE]`,
+	}
+
+	client := newTestCompletionClient(t, "/fim/completions", CompletionDialectMistral, func(w http.ResponseWriter, body map[string]any) {
+		request = body
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+	})
+
+	stream, err := client.CompleteStream(context.Background(), "func add(a, b int) int {\n\t", "\n}", llm.WithCompletionSeed(42))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	var (
+		text     string
+		complete llm.StreamChunk
+	)
+
+	for chunk := range stream {
+		if err := chunk.Error(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		if chunk.IsComplete() {
+			complete = chunk
+			continue
+		}
+
+		text += chunk.Delta().Content()
+	}
+
+	if request["stream"] != true || request["random_seed"] != float64(42) {
+		t.Errorf("unexpected request %v", request)
+	}
+
+	if _, exists := request["stream_options"]; exists {
+		t.Errorf("expected no stream options with the mistral dialect")
+	}
+
+	if text != "return a + b" {
+		t.Errorf("unexpected completion %q", text)
+	}
+
+	if complete == nil || complete.Usage().TotalTokens() != 16 {
+		t.Errorf("expected a complete chunk with usage, got %v", complete)
+	}
+}
+
+func TestCompletionClient_Error(t *testing.T) {
+	client := newTestCompletionClient(t, "/completions", CompletionDialectOpenAI, func(w http.ResponseWriter, body map[string]any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, `{"error": {"message": "slow down"}}`)
+	})
+
+	if _, err := client.Complete(context.Background(), "a", ""); !errors.Is(err, llm.ErrRateLimit) {
+		t.Errorf("expected a rate limit error, got %v", err)
+	}
+
+	if _, err := client.CompleteStream(context.Background(), "a", ""); !errors.Is(err, llm.ErrRateLimit) {
+		t.Errorf("expected a rate limit error, got %v", err)
+	}
+}
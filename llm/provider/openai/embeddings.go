@@ -20,17 +20,22 @@ type EmbeddingsClient struct {
 
 // Embeddings implements llm.Client.
 func (c *EmbeddingsClient) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
-	if c.model == "" {
-		return nil, errors.WithStack(llm.ErrUnavailable)
+	opts := llm.NewEmbeddingsOptions(funcs...)
+
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
 	}
 
-	opts := llm.NewEmbeddingsOptions(funcs...)
+	if model == "" {
+		return nil, errors.WithStack(llm.ErrUnavailable)
+	}
 
 	params := openai.EmbeddingNewParams{
 		Input: openai.EmbeddingNewParamsInputUnion{
 			OfArrayOfStrings: inputs,
 		},
-		Model: openai.EmbeddingModel(c.model),
+		Model: openai.EmbeddingModel(model),
 	}
 
 	if opts.Dimensions != nil {
@@ -0,0 +1,107 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/bornholm/genai/llm"
+
+	openaisdk "github.com/openai/openai-go"
+)
+
+// ImageEdit implements [llm.ImageEditClient] through /v1/images/edits.
+func (c *ImageGenerationClient) ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	opts := llm.NewImageGenerationOptions(funcs...)
+
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	// openaisdk.ImageEditParams panique à la sérialisation quand le masque
+	// (optionnel) est absent : le formulaire est construit ici. Pas de
+	// response_format : gpt-image-1 le refuse sur ce point d'entrée et renvoie
+	// toujours du base64, dall-e-2 renvoie alors une URL que decodeImages
+	// télécharge.
+	form := &multipartForm{}
+	form.AddFile("image", imageFilename("image", image), imageMediaType(image), image)
+	form.AddFile("mask", imageFilename("mask", opts.Mask), imageMediaType(opts.Mask), opts.Mask)
+	form.AddField("prompt", prompt)
+	form.AddField("model", model)
+	form.AddField("size", opts.Size)
+	if opts.Count > 0 {
+		form.AddField("n", strconv.Itoa(opts.Count))
+	}
+
+	var resp openaisdk.ImagesResponse
+	if err := c.client.Post(ctx, "images/edits", form, &resp); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	images, err := decodeImages(ctx, resp.Data)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return llm.NewImageGenerationResponse(images, nil), nil
+}
+
+// ImageVariation implements [llm.ImageEditClient] through
+// /v1/images/variations (dall-e-2 only).
+func (c *ImageGenerationClient) ImageVariation(ctx context.Context, image []byte, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	opts := llm.NewImageGenerationOptions(funcs...)
+
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	params := openaisdk.ImageNewVariationParams{
+		Image:          imageFile(image, "image"),
+		Model:          model,
+		ResponseFormat: openaisdk.ImageNewVariationParamsResponseFormatB64JSON,
+	}
+
+	if opts.Count > 0 {
+		params.N = openaisdk.Int(int64(opts.Count))
+	}
+	if opts.Size != "" {
+		params.Size = openaisdk.ImageNewVariationParamsSize(opts.Size)
+	}
+
+	resp, err := c.client.Images.NewVariation(ctx, params)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	images, err := decodeImages(ctx, resp.Data)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return llm.NewImageGenerationResponse(images, nil), nil
+}
+
+// imageFile nomme la partie multipart d'après le type détecté : l'API
+// rejette les fichiers sans type image reconnu.
+func imageFile(data []byte, name string) io.Reader {
+	return openaisdk.File(bytes.NewReader(data), imageFilename(name, data), imageMediaType(data))
+}
+
+func imageFilename(name string, data []byte) string {
+	return name + "." + strings.TrimPrefix(imageMediaType(data), "image/")
+}
+
+func imageMediaType(data []byte) string {
+	if mediaType := llm.DetectImageMediaType(data); mediaType != "" {
+		return mediaType
+	}
+	return "image/png"
+}
+
+var _ llm.ImageEditClient = &ImageGenerationClient{}
@@ -3,6 +3,8 @@ package openai
 import (
 	"context"
 	"encoding/base64"
+	"io"
+	"net/http"
 
 	"github.com/pkg/errors"
 
@@ -22,9 +24,14 @@ type ImageGenerationClient struct {
 func (c *ImageGenerationClient) ImageGeneration(ctx context.Context, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
 	opts := llm.NewImageGenerationOptions(funcs...)
 
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
 	params := openaisdk.ImageGenerateParams{
 		Prompt: prompt,
-		Model:  c.model,
+		Model:  model,
 		// b64_json plutôt que l'URL par défaut : l'appelant reçoit des
 		// octets, jamais une URL à durée de vie limitée (60 minutes côté
 		// OpenAI) qu'il faudrait aller chercher dans la foulée.
@@ -48,22 +55,76 @@ func (c *ImageGenerationClient) ImageGeneration(ctx context.Context, prompt stri
 		return nil, errors.WithStack(err)
 	}
 
-	images := make([]llm.GeneratedImage, 0, len(resp.Data))
-	for _, img := range resp.Data {
-		data, err := base64.StdEncoding.DecodeString(img.B64JSON)
+	images, err := decodeImages(ctx, resp.Data)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return llm.NewImageGenerationResponse(images, nil), nil
+}
+
+// decodeImages normalise les images de la réponse en octets. Les images
+// renvoyées sous forme d'URL (dall-e-2 sans response_format) sont
+// téléchargées immédiatement, ces URL expirant au bout d'une heure.
+func decodeImages(ctx context.Context, data []openaisdk.Image) ([]llm.GeneratedImage, error) {
+	images := make([]llm.GeneratedImage, 0, len(data))
+	for _, img := range data {
+		if img.B64JSON == "" && img.URL != "" {
+			raw, mediaType, err := fetchImage(ctx, img.URL)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			images = append(images, llm.NewGeneratedImageFromURL(raw, mediaType, img.RevisedPrompt, img.URL))
+			continue
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(img.B64JSON)
 		if err != nil {
 			return nil, errors.Wrap(err, "could not decode base64 image data")
 		}
 
-		mediaType := llm.DetectImageMediaType(data)
+		mediaType := llm.DetectImageMediaType(raw)
 		if mediaType == "" {
 			mediaType = "image/png"
 		}
 
-		images = append(images, llm.NewGeneratedImage(data, mediaType, img.RevisedPrompt))
+		images = append(images, llm.NewGeneratedImage(raw, mediaType, img.RevisedPrompt))
 	}
 
-	return llm.NewImageGenerationResponse(images, nil), nil
+	return images, nil
+}
+
+func fetchImage(ctx context.Context, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "could not fetch image")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", errors.Errorf("could not fetch image: unexpected HTTP status %d", res.StatusCode)
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(res.Body, 512<<20))
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+
+	mediaType := llm.DetectImageMediaType(raw)
+	if mediaType == "" {
+		mediaType = res.Header.Get("Content-Type")
+	}
+	if mediaType == "" {
+		mediaType = "image/png"
+	}
+
+	return raw, mediaType, nil
 }
 
 func NewImageGenerationClient(client openaisdk.Client, model string) *ImageGenerationClient {
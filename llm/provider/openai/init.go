@@ -24,6 +24,9 @@ func init() {
 				options = append(options, option.WithAPIKey(opts.APIKey))
 			}
 			client := openaisdk.NewClient(options...)
+			if opts.API == APIResponses {
+				return NewResponsesClient(client, opts.Model), nil
+			}
 			return NewChatCompletionClient(client, &paramsBuilder{model: opts.Model}), nil
 		},
 	)
@@ -75,4 +78,20 @@ func init() {
 			return NewImageGenerationClient(client, opts.Model), nil
 		},
 	)
+
+	provider.RegisterCompletion(
+		Name,
+		defaultOptions,
+		func(ctx context.Context, opts *Options) (llm.CompletionClient, error) {
+			options := []option.RequestOption{
+				option.WithBaseURL(opts.BaseURL),
+				option.WithMaxRetries(0), // genai's llmretry wrapper handles all retries
+			}
+			if opts.APIKey != "" {
+				options = append(options, option.WithAPIKey(opts.APIKey))
+			}
+			client := openaisdk.NewClient(options...)
+			return NewCompletionClient(client, opts.Model), nil
+		},
+	)
 }
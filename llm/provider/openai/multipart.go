@@ -0,0 +1,75 @@
+package openai
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+
+	"github.com/pkg/errors"
+)
+
+// multipartForm est un corps multipart construit à la main, pour les
+// requêtes que les paramètres du SDK ne savent pas sérialiser (champs
+// optionnels de type fichier, champs propres aux APIs compatibles).
+type multipartForm struct {
+	files  []multipartFile
+	fields [][2]string
+}
+
+type multipartFile struct {
+	field       string
+	filename    string
+	contentType string
+	data        []byte
+}
+
+// AddFile ajoute un fichier, ignoré s'il est vide.
+func (f *multipartForm) AddFile(field, filename, contentType string, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	f.files = append(f.files, multipartFile{field: field, filename: filename, contentType: contentType, data: data})
+}
+
+// AddField ajoute un champ texte, ignoré s'il est vide.
+func (f *multipartForm) AddField(name, value string) {
+	if value == "" {
+		return
+	}
+	f.fields = append(f.fields, [2]string{name, value})
+}
+
+// MarshalMultipart implements the multipart body serialization expected by
+// the openai client.
+func (f *multipartForm) MarshalMultipart() ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	for _, file := range f.files {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, file.field, file.filename))
+		header.Set("Content-Type", file.contentType)
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+
+		if _, err := part.Write(file.data); err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+	}
+
+	for _, field := range f.fields {
+		if err := writer.WriteField(field[0], field[1]); err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
@@ -1,10 +1,36 @@
 package openai
 
-import "github.com/bornholm/genai/llm/provider"
+import (
+	"github.com/bornholm/genai/llm/provider"
+	"github.com/pkg/errors"
+)
+
+// API identifie l'API OpenAI utilisée pour les chat completions.
+type API string
+
+const (
+	// APIChatCompletions utilise l'API /chat/completions (par défaut).
+	APIChatCompletions API = "chat_completions"
+	// APIResponses utilise l'API /responses, voir [ResponsesClient].
+	APIResponses API = "responses"
+)
 
 // Options contient les options de configuration du provider OpenAI.
 type Options struct {
 	provider.CommonOptions
+	// API sélectionne l'API utilisée pour les chat completions. Vide signifie
+	// APIChatCompletions.
+	API API `env:"API"`
+}
+
+// Validate vérifie que l'API sélectionnée est connue.
+func (o *Options) Validate() error {
+	switch o.API {
+	case "", APIChatCompletions, APIResponses:
+		return nil
+	default:
+		return errors.Errorf("field \"API\": unknown API '%s', expected '%s' or '%s'", o.API, APIChatCompletions, APIResponses)
+	}
 }
 
 func defaultOptions() *Options {
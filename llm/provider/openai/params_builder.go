@@ -25,7 +25,12 @@ type paramsBuilder struct {
 }
 
 func (b *paramsBuilder) BuildParams(ctx context.Context, opts *llm.ChatCompletionOptions) (*openai.ChatCompletionNewParams, error) {
-	if b.model == "" {
+	model := b.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	if model == "" {
 		return nil, errors.WithStack(llm.ErrUnavailable)
 	}
 
@@ -38,13 +43,15 @@ func (b *paramsBuilder) BuildParams(ctx context.Context, opts *llm.ChatCompletio
 		ConfigureMaxCompletionTokens,
 		ConfigureSeed,
 		ConfigureReasoning,
+		ConfigureSampling,
+		ConfigureCandidates,
 		ConfigureExtraFields,
 	)
 	if err != nil {
-		return nil, errors.WithStack(llm.ErrUnavailable)
+		return nil, errors.WithStack(err)
 	}
 
-	params.Model = openai.ChatModel(b.model)
+	params.Model = openai.ChatModel(model)
 
 	return params, nil
 }
@@ -325,5 +332,6 @@ func (b *paramsBuilder) BuildStreamingParams(ctx context.Context, opts *llm.Chat
 	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{
 		IncludeUsage: openai.Bool(true),
 	}
+	ClearCandidates(params)
 	return params, nil
 }
@@ -0,0 +1,49 @@
+package openai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+func TestParamsBuilderModel(t *testing.T) {
+	messages := []llm.Message{llm.NewMessage(llm.RoleUser, "hi")}
+
+	t.Run("uses the client model by default", func(t *testing.T) {
+		builder := &paramsBuilder{model: "gpt-4o-mini"}
+		params, err := builder.BuildParams(context.Background(), llm.NewChatCompletionOptions(llm.WithMessages(messages...)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if params.Model != "gpt-4o-mini" {
+			t.Fatalf("expected model gpt-4o-mini, got %q", params.Model)
+		}
+	})
+
+	t.Run("per-call model overrides the client model", func(t *testing.T) {
+		builder := &paramsBuilder{model: "gpt-4o-mini"}
+		params, err := builder.BuildParams(context.Background(), llm.NewChatCompletionOptions(llm.WithMessages(messages...), llm.WithModel("gpt-4o")))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if params.Model != "gpt-4o" {
+			t.Fatalf("expected model gpt-4o, got %q", params.Model)
+		}
+	})
+
+	t.Run("per-call model makes a client without model available", func(t *testing.T) {
+		builder := &paramsBuilder{}
+		if _, err := builder.BuildParams(context.Background(), llm.NewChatCompletionOptions(llm.WithMessages(messages...))); !errors.Is(err, llm.ErrUnavailable) {
+			t.Fatalf("expected ErrUnavailable, got %v", err)
+		}
+		params, err := builder.BuildParams(context.Background(), llm.NewChatCompletionOptions(llm.WithMessages(messages...), llm.WithModel("gpt-4o")))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if params.Model != "gpt-4o" {
+			t.Fatalf("expected model gpt-4o, got %q", params.Model)
+		}
+	})
+}
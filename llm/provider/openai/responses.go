@@ -0,0 +1,541 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bornholm/genai/llm"
+	llmcontext "github.com/bornholm/genai/llm/context"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/pkg/errors"
+)
+
+type contextKey string
+
+const contextKeyPreviousResponseID contextKey = "previousResponseID"
+
+// ContextPreviousResponseID returns the response the calls made with the
+// context are chained to
+func ContextPreviousResponseID(ctx context.Context) (string, error) {
+	return llmcontext.Value[string](ctx, contextKeyPreviousResponseID)
+}
+
+// WithPreviousResponseID chains the calls made with the context to a
+// previous response of the Responses API (see [StatefulResponse]): the
+// conversation up to that response being stored by OpenAI, only the messages
+// following the last assistant turn are sent.
+func WithPreviousResponseID(ctx context.Context, responseID string) context.Context {
+	return llmcontext.WithValue(ctx, contextKeyPreviousResponseID, responseID)
+}
+
+// StatefulResponse is implemented by the responses, and the complete stream
+// chunks, of the [ResponsesClient], identifying the response the next call
+// can be chained to with [WithPreviousResponseID].
+type StatefulResponse interface {
+	ResponseID() string
+}
+
+// ResponsesClient is a chat completion client using the OpenAI Responses API
+// instead of the chat completions one, enabled with the APIResponses option.
+type ResponsesClient struct {
+	client openai.Client
+	model  string
+}
+
+// ChatCompletion implements llm.ChatCompletionClient.
+func (c *ResponsesClient) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	opts := llm.NewChatCompletionOptions(funcs...)
+
+	if err := opts.Validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	params, err := c.buildParams(ctx, opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var httpRes *http.Response
+
+	response, err := c.client.Responses.New(ctx, *params, option.WithResponseInto(&httpRes))
+	if err != nil {
+		if httpRes != nil {
+			body, _ := io.ReadAll(httpRes.Body)
+			return nil, errors.WithStack(llm.RateLimitError(httpRes.StatusCode, string(body)))
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	var body responsesBody
+	if err := json.Unmarshal([]byte(response.RawJSON()), &body); err != nil {
+		return nil, errors.Wrap(err, "could not decode response")
+	}
+
+	if err := body.err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return body.toResponse(), nil
+}
+
+// ChatCompletionStream implements llm.ChatCompletionStreamingClient.
+func (c *ResponsesClient) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	opts := llm.NewChatCompletionOptions(funcs...)
+
+	if err := opts.Validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	params, err := c.buildParams(ctx, opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var httpRes *http.Response
+
+	stream := c.client.Responses.NewStreaming(ctx, *params, option.WithResponseInto(&httpRes))
+
+	chunks := make(chan llm.StreamChunk, 10)
+
+	go func() {
+		defer close(chunks)
+		defer stream.Close()
+
+		state := &responsesStreamState{
+			toolCalls: map[int]int{},
+		}
+
+		for stream.Next() {
+			event, err := decodeResponsesEvent(stream.Current().RawJSON())
+			if err != nil {
+				chunks <- llm.NewErrorStreamChunk(errors.WithStack(err))
+				return
+			}
+
+			chunk, done := state.handle(event)
+			if chunk != nil {
+				chunks <- chunk
+			}
+			if done {
+				return
+			}
+		}
+
+		if err := stream.Err(); err != nil {
+			if httpRes != nil && httpRes.StatusCode >= http.StatusBadRequest {
+				body, _ := io.ReadAll(httpRes.Body)
+				chunks <- llm.NewErrorStreamChunk(errors.WithStack(llm.RateLimitError(httpRes.StatusCode, string(body))))
+			} else {
+				chunks <- llm.NewErrorStreamChunk(errors.WithStack(err))
+			}
+			return
+		}
+
+		chunks <- llm.NewErrorStreamChunk(errors.New("stream ended before the response was completed"))
+	}()
+
+	return chunks, nil
+}
+
+// ValidateAttachment implements llm.AttachmentValidator.
+func (c *ResponsesClient) ValidateAttachment(attachment llm.Attachment) error {
+	return NewOpenAIAttachmentValidator("").ValidateAttachment(attachment)
+}
+
+// responsesBody is a response of the Responses API. It is decoded from the
+// raw JSON rather than the SDK types, which miss the encrypted content of
+// the reasoning items.
+type responsesBody struct {
+	ID                string          `json:"id"`
+	Status            string          `json:"status"`
+	Output            []responsesItem `json:"output"`
+	Usage             *responsesUsage `json:"usage"`
+	Error             *responsesError `json:"error"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+}
+
+type responsesItem struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	CallID    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Content   []struct {
+		Type        string                `json:"type"`
+		Text        string                `json:"text"`
+		Refusal     string                `json:"refusal"`
+		Annotations []responsesAnnotation `json:"annotations"`
+	} `json:"content"`
+	Summary []struct {
+		Text string `json:"text"`
+	} `json:"summary"`
+	EncryptedContent string `json:"encrypted_content"`
+}
+
+// responsesAnnotation is an annotation of an output text, the url_citation
+// ones being located relatively to the text
+type responsesAnnotation struct {
+	Type       string `json:"type"`
+	URL        string `json:"url"`
+	Title      string `json:"title"`
+	StartIndex int    `json:"start_index"`
+	EndIndex   int    `json:"end_index"`
+}
+
+// citation converts the annotation, offsetting its location by the length
+// of the content preceding its text
+func (a *responsesAnnotation) citation(offset int) (llm.Citation, bool) {
+	if a.Type != "url_citation" {
+		return llm.Citation{}, false
+	}
+
+	return llm.Citation{
+		URL:        a.URL,
+		Title:      a.Title,
+		StartIndex: a.StartIndex + offset,
+		EndIndex:   a.EndIndex + offset,
+	}, true
+}
+
+type responsesUsage struct {
+	InputTokens        int64 `json:"input_tokens"`
+	InputTokensDetails struct {
+		CachedTokens int64 `json:"cached_tokens"`
+	} `json:"input_tokens_details"`
+	OutputTokens int64 `json:"output_tokens"`
+	TotalTokens  int64 `json:"total_tokens"`
+}
+
+type responsesError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responsesError) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return e.Code + ": " + e.Message
+}
+
+// err returns the error of a failed response
+func (b *responsesBody) err() error {
+	if b.Status != "failed" {
+		return nil
+	}
+
+	return b.failure()
+}
+
+func (b *responsesBody) failure() error {
+	if b.Error == nil {
+		return errors.Errorf("response '%s' failed", b.ID)
+	}
+
+	return errors.Wrapf(b.Error, "response '%s' failed", b.ID)
+}
+
+// finishReason tells why the generation stopped, an incomplete response
+// having run out of output tokens unless filtered
+func (b *responsesBody) finishReason(toolCalls bool) llm.FinishReason {
+	switch {
+	case b.Status == "incomplete" && b.IncompleteDetails != nil && b.IncompleteDetails.Reason == "content_filter":
+		return llm.FinishReasonContentFilter
+	case b.Status == "incomplete":
+		return llm.FinishReasonLength
+	case toolCalls:
+		return llm.FinishReasonToolCalls
+	default:
+		return llm.FinishReasonStop
+	}
+}
+
+func (b *responsesBody) usage() llm.ChatCompletionUsage {
+	if b.Usage == nil {
+		return llm.NewChatCompletionUsage(0, 0, 0)
+	}
+
+	return llm.NewChatCompletionUsageWithCache(
+		b.Usage.InputTokens,
+		b.Usage.OutputTokens,
+		b.Usage.TotalTokens,
+		b.Usage.InputTokensDetails.CachedTokens,
+	)
+}
+
+func (b *responsesBody) toResponse() *ResponsesResponse {
+	var (
+		content   strings.Builder
+		citations []llm.Citation
+		reasoning []string
+		details   []llm.ReasoningDetail
+		toolCalls = make([]llm.ToolCall, 0)
+	)
+
+	for i, item := range b.Output {
+		switch item.Type {
+		case "message":
+			for _, part := range item.Content {
+				switch part.Type {
+				case "output_text":
+					offset := utf8.RuneCountInString(content.String())
+					for _, a := range part.Annotations {
+						if citation, ok := a.citation(offset); ok {
+							citations = append(citations, citation)
+						}
+					}
+					content.WriteString(part.Text)
+				case "refusal":
+					content.WriteString(part.Refusal)
+				}
+			}
+
+		case "function_call":
+			toolCalls = append(toolCalls, llm.NewToolCall(item.CallID, item.Name, item.Arguments))
+
+		case "reasoning":
+			detail := item.reasoningDetail(i)
+			if detail.Summary != "" {
+				reasoning = append(reasoning, detail.Summary)
+			}
+			details = append(details, detail)
+		}
+	}
+
+	var res *llm.BaseChatCompletionResponse
+	if len(details) > 0 {
+		text := strings.Join(reasoning, "\n\n")
+		var message llm.Message = llm.NewAssistantReasoningMessage(content.String(), text, details)
+		if len(citations) > 0 {
+			message, _ = llm.WithCitations(message, citations)
+		}
+		res = llm.NewChatCompletionResponseWithReasoning(message, b.usage(), text, details, toolCalls...)
+	} else {
+		var message llm.Message = llm.NewMessage(llm.RoleAssistant, content.String())
+		if len(citations) > 0 {
+			message, _ = llm.WithCitations(message, citations)
+		}
+		res = llm.NewChatCompletionResponse(message, b.usage(), toolCalls...)
+	}
+
+	return &ResponsesResponse{
+		BaseChatCompletionResponse: res,
+		id:                         b.ID,
+		finishReason:               b.finishReason(len(toolCalls) > 0),
+	}
+}
+
+// reasoningDetail converts the reasoning item at the index of the output, to
+// be replayed as is in the following calls
+func (i *responsesItem) reasoningDetail(index int) llm.ReasoningDetail {
+	summaries := make([]string, 0, len(i.Summary))
+	for _, s := range i.Summary {
+		summaries = append(summaries, s.Text)
+	}
+
+	detail := llm.ReasoningDetail{
+		ID:      i.ID,
+		Type:    llm.ReasoningDetailTypeSummary,
+		Summary: strings.Join(summaries, "\n\n"),
+		Format:  responsesReasoningFormat,
+		Index:   index,
+	}
+
+	if i.EncryptedContent != "" {
+		detail.Type = llm.ReasoningDetailTypeEncrypted
+		detail.Data = i.EncryptedContent
+	}
+
+	return detail
+}
+
+// ResponsesResponse is a chat completion response of the Responses API
+type ResponsesResponse struct {
+	*llm.BaseChatCompletionResponse
+	id           string
+	finishReason llm.FinishReason
+}
+
+// ResponseID implements StatefulResponse.
+func (r *ResponsesResponse) ResponseID() string {
+	return r.id
+}
+
+// Candidates implements llm.CandidatesResponse, the response being its own
+// single candidate carrying the finish reason.
+func (r *ResponsesResponse) Candidates() []llm.Candidate {
+	return []llm.Candidate{llm.NewCandidate(0, r.Message(), r.finishReason, nil, r.ToolCalls()...)}
+}
+
+// ResponsesCompleteStreamChunk is the complete chunk of a stream of the
+// Responses API
+type ResponsesCompleteStreamChunk struct {
+	*llm.BaseStreamChunk
+	id           string
+	finishReason llm.FinishReason
+}
+
+// ResponseID implements StatefulResponse.
+func (c *ResponsesCompleteStreamChunk) ResponseID() string {
+	return c.id
+}
+
+// FinishReason tells why the generation stopped
+func (c *ResponsesCompleteStreamChunk) FinishReason() llm.FinishReason {
+	return c.finishReason
+}
+
+// responsesEvent is a streaming event of the Responses API
+type responsesEvent struct {
+	Type         string               `json:"type"`
+	Delta        string               `json:"delta"`
+	OutputIndex  int                  `json:"output_index"`
+	SummaryIndex int                  `json:"summary_index"`
+	Item         *responsesItem       `json:"item"`
+	Annotation   *responsesAnnotation `json:"annotation"`
+	Response     *responsesBody       `json:"response"`
+	Code         string               `json:"code"`
+	Message      string               `json:"message"`
+	Event        string               `json:"event"`
+	Data         json.RawMessage      `json:"data"`
+}
+
+// decodeResponsesEvent decodes an event of the stream, the SDK wrapping the
+// events it does not know, such as "error", in an {"event", "data"} object
+func decodeResponsesEvent(raw string) (*responsesEvent, error) {
+	var event responsesEvent
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		return nil, errors.Wrap(err, "could not decode stream event")
+	}
+
+	if event.Type == "" && event.Event != "" && len(event.Data) > 0 {
+		var inner responsesEvent
+		if err := json.Unmarshal(event.Data, &inner); err != nil {
+			return nil, errors.Wrap(err, "could not decode stream event")
+		}
+
+		if inner.Type == "" {
+			inner.Type = event.Event
+		}
+
+		return &inner, nil
+	}
+
+	return &event, nil
+}
+
+// responsesStreamState converts the events of a stream to chunks
+type responsesStreamState struct {
+	// toolCalls maps the output index of the function calls to the index of
+	// their tool call deltas
+	toolCalls map[int]int
+}
+
+// handle returns the chunk of the event, if any, and whether the stream is
+// done
+func (s *responsesStreamState) handle(event *responsesEvent) (llm.StreamChunk, bool) {
+	switch event.Type {
+	case "response.output_text.delta", "response.refusal.delta":
+		return llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, event.Delta)), false
+
+	case "response.output_text.annotation.added":
+		if event.Annotation == nil {
+			return nil, false
+		}
+
+		citation, ok := event.Annotation.citation(0)
+		if !ok {
+			return nil, false
+		}
+
+		delta, _ := llm.WithStreamDeltaCitations(llm.NewStreamDelta(llm.RoleAssistant, ""), []llm.Citation{citation})
+		return llm.NewStreamChunk(delta), false
+
+	case "response.reasoning_summary_part.added":
+		// The summary parts are separated as in the non-streaming responses
+		if event.SummaryIndex > 0 {
+			return llm.NewStreamChunk(llm.NewReasoningStreamDelta(llm.RoleAssistant, "", "\n\n", nil)), false
+		}
+
+	case "response.reasoning_summary_text.delta":
+		return llm.NewStreamChunk(llm.NewReasoningStreamDelta(llm.RoleAssistant, "", event.Delta, nil)), false
+
+	case "response.output_item.added":
+		if event.Item == nil || event.Item.Type != "function_call" {
+			return nil, false
+		}
+
+		index := len(s.toolCalls)
+		s.toolCalls[event.OutputIndex] = index
+
+		delta := llm.NewToolCallDelta(index, event.Item.CallID, event.Item.Name, "")
+		return llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, "", delta)), false
+
+	case "response.function_call_arguments.delta":
+		index, ok := s.toolCalls[event.OutputIndex]
+		if !ok {
+			return nil, false
+		}
+
+		delta := llm.NewToolCallDelta(index, "", "", event.Delta)
+		return llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, "", delta)), false
+
+	case "response.output_item.done":
+		// The reasoning items are complete, with their encrypted content,
+		// once done
+		if event.Item == nil || event.Item.Type != "reasoning" {
+			return nil, false
+		}
+
+		details := []llm.ReasoningDetail{event.Item.reasoningDetail(event.OutputIndex)}
+		return llm.NewStreamChunk(llm.NewReasoningStreamDelta(llm.RoleAssistant, "", "", details)), false
+
+	case "response.completed", "response.incomplete":
+		if event.Response == nil {
+			return llm.NewErrorStreamChunk(errors.Errorf("event '%s' without response", event.Type)), true
+		}
+
+		return &ResponsesCompleteStreamChunk{
+			BaseStreamChunk: llm.NewCompleteStreamChunk(event.Response.usage()),
+			id:              event.Response.ID,
+			finishReason:    event.Response.finishReason(len(s.toolCalls) > 0),
+		}, true
+
+	case "response.failed":
+		if event.Response == nil {
+			return llm.NewErrorStreamChunk(errors.New("response failed")), true
+		}
+
+		return llm.NewErrorStreamChunk(event.Response.failure()), true
+
+	case "error":
+		return llm.NewErrorStreamChunk(errors.WithStack(&responsesError{Code: event.Code, Message: event.Message})), true
+	}
+
+	return nil, false
+}
+
+// NewResponsesClient creates a chat completion client using the Responses
+// API with the default model
+func NewResponsesClient(client openai.Client, model string) *ResponsesClient {
+	return &ResponsesClient{
+		client: client,
+		model:  model,
+	}
+}
+
+var _ llm.ChatCompletionClient = &ResponsesClient{}
+var _ llm.ChatCompletionStreamingClient = &ResponsesClient{}
+var _ llm.AttachmentValidator = &ResponsesClient{}
+var _ StatefulResponse = &ResponsesResponse{}
+var _ llm.CandidatesResponse = &ResponsesResponse{}
+var _ StatefulResponse = &ResponsesCompleteStreamChunk{}
+var _ llm.StreamChunk = &ResponsesCompleteStreamChunk{}
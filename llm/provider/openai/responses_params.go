@@ -0,0 +1,432 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/responses"
+	"github.com/openai/openai-go/shared"
+	"github.com/pkg/errors"
+)
+
+// responsesReasoningFormat identifies the reasoning details of the Responses
+// API, replayed as reasoning items in the following calls
+const responsesReasoningFormat = "openai-responses-v1"
+
+// includeEncryptedReasoning asks for the encrypted content of the reasoning
+// items, so they can be replayed without the response being stored. The SDK
+// does not declare it yet.
+const includeEncryptedReasoning responses.ResponseIncludable = "reasoning.encrypted_content"
+
+// buildParams maps the options to a Responses API request
+func (c *ResponsesClient) buildParams(ctx context.Context, opts *llm.ChatCompletionOptions) (*responses.ResponseNewParams, error) {
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	if model == "" {
+		return nil, errors.WithStack(llm.ErrUnavailable)
+	}
+
+	if WantsCandidates(opts) {
+		return nil, errors.Wrap(llm.ErrUnsupportedSetting, "the Responses API supports neither candidates nor logprobs")
+	}
+
+	unsupported := []llm.SamplingSetting{
+		llm.SamplingTopK, llm.SamplingMinP, llm.SamplingStop,
+		llm.SamplingFrequencyPenalty, llm.SamplingPresencePenalty, llm.SamplingLogitBias,
+	}
+
+	if err := opts.CheckSampling(ctx, "openai", unsupported...); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	params := &responses.ResponseNewParams{
+		Model: shared.ResponsesModel(model),
+	}
+
+	// The reasoning models reject the temperature, even without reasoning
+	// options
+	if opts.Reasoning == nil && !isReasoningModel(model) {
+		params.Temperature = openai.Float(opts.Temperature)
+	}
+
+	messages := opts.Messages
+
+	if previousResponseID, _ := ContextPreviousResponseID(ctx); previousResponseID != "" {
+		params.PreviousResponseID = openai.String(previousResponseID)
+		messages = messagesSinceLastTurn(messages)
+	}
+
+	input, err := responsesInput(model, messages)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	params.Input = responses.ResponseNewParamsInputUnion{OfInputItemList: input}
+
+	if opts.TopP != nil {
+		params.TopP = openai.Float(*opts.TopP)
+	}
+
+	if opts.MaxCompletionTokens != nil {
+		params.MaxOutputTokens = openai.Int(int64(*opts.MaxCompletionTokens))
+	}
+
+	configureResponsesTools(opts, params)
+
+	if err := configureResponsesFormat(opts, params); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	configureResponsesReasoning(opts, params)
+
+	// Caller-provided keys win on conflict, as with the chat completions
+	// (e.g. {"store": false} for stateless calls)
+	if len(opts.ExtraFields) > 0 {
+		merged := map[string]any{}
+		for k, v := range params.GetExtraFields() {
+			merged[k] = v
+		}
+		for k, v := range opts.ExtraFields {
+			merged[k] = v
+		}
+
+		params.WithExtraFields(merged)
+	}
+
+	return params, nil
+}
+
+// isReasoningModel reports whether the model is a reasoning one, the o-series
+// and the gpt-5 family but its chat variants
+func isReasoningModel(model string) bool {
+	model = strings.ToLower(model)
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+
+	switch {
+	case len(model) > 1 && model[0] == 'o' && model[1] >= '0' && model[1] <= '9':
+		return true
+	case strings.HasPrefix(model, "gpt-5"):
+		return !strings.Contains(model, "-chat")
+	default:
+		return false
+	}
+}
+
+// messagesSinceLastTurn returns the messages following the last answer of the
+// model, the previous ones being part of the state of the previous response
+func messagesSinceLastTurn(messages []llm.Message) []llm.Message {
+	for i := len(messages) - 1; i >= 0; i-- {
+		switch messages[i].Role() {
+		case llm.RoleAssistant, llm.RoleToolCalls:
+			return messages[i+1:]
+		}
+	}
+
+	return messages
+}
+
+// responsesInput converts the messages to the input items of the Responses
+// API. The reasoning of the assistant turns is replayed as reasoning items,
+// which the reasoning models expect before their tool calls.
+func responsesInput(model string, messages []llm.Message) (responses.ResponseInputParam, error) {
+	input := make(responses.ResponseInputParam, 0, len(messages))
+
+	validator := NewOpenAIAttachmentValidator(model)
+
+	for _, m := range messages {
+		for _, attachment := range m.Attachments() {
+			if err := validator.ValidateAttachment(attachment); err != nil {
+				return nil, errors.Wrapf(err, "attachment validation failed for message with role %s", m.Role())
+			}
+		}
+
+		switch m.Role() {
+		case llm.RoleSystem:
+			if len(m.Attachments()) > 0 {
+				return nil, errors.Errorf("system messages cannot have attachments")
+			}
+
+			input = append(input, easyInputMessage(responses.EasyInputMessageRoleSystem, m.Content()))
+
+		case llm.RoleUser:
+			if len(m.Attachments()) == 0 {
+				input = append(input, easyInputMessage(responses.EasyInputMessageRoleUser, m.Content()))
+				continue
+			}
+
+			item, err := userInputMessage(m.Content(), m.Attachments())
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			input = append(input, item)
+
+		case llm.RoleAssistant:
+			if len(m.Attachments()) > 0 {
+				return nil, errors.Errorf("assistant messages cannot have attachments")
+			}
+
+			input = append(input, reasoningItems(m)...)
+			input = append(input, easyInputMessage(responses.EasyInputMessageRoleAssistant, m.Content()))
+
+		case llm.RoleToolCalls:
+			if len(m.Attachments()) > 0 {
+				return nil, errors.Errorf("tool calls messages cannot have attachments")
+			}
+
+			toolCallsMessage, ok := m.(llm.ToolCallsMessage)
+			if !ok {
+				return nil, errors.Errorf("unexpected tool calls message type '%T'", m)
+			}
+
+			input = append(input, reasoningItems(m)...)
+
+			for _, tc := range toolCallsMessage.ToolCalls() {
+				// See ConfigureMessages: malformed arguments are rejected when
+				// replayed
+				args, _ := tc.Parameters().(string)
+				if !json.Valid([]byte(args)) {
+					args = "{}"
+				}
+
+				input = append(input, responses.ResponseInputItemUnionParam{
+					OfFunctionCall: &responses.ResponseFunctionToolCallParam{
+						CallID:    tc.ID(),
+						Name:      tc.Name(),
+						Arguments: args,
+					},
+				})
+			}
+
+		case llm.RoleTool:
+			toolMessage, ok := m.(llm.ToolMessage)
+			if !ok {
+				return nil, errors.Errorf("unexpected tool message type '%T'", m)
+			}
+
+			input = append(input, responses.ResponseInputItemUnionParam{
+				OfFunctionCallOutput: &responses.ResponseInputItemFunctionCallOutputParam{
+					CallID: toolMessage.ID(),
+					Output: toolMessage.Content(),
+				},
+			})
+
+			// Function call outputs are text only, the media of the result
+			// follow in a user message (see ConfigureMessages)
+			if len(m.Attachments()) > 0 {
+				item, err := userInputMessage("", m.Attachments())
+				if err != nil {
+					return nil, errors.Wrap(err, "failed to convert tool result attachments")
+				}
+
+				input = append(input, item)
+			}
+		}
+	}
+
+	return input, nil
+}
+
+func easyInputMessage(role responses.EasyInputMessageRole, content string) responses.ResponseInputItemUnionParam {
+	return responses.ResponseInputItemUnionParam{
+		OfMessage: &responses.EasyInputMessageParam{
+			Role: role,
+			Content: responses.EasyInputMessageContentUnionParam{
+				OfString: openai.String(content),
+			},
+		},
+	}
+}
+
+// userInputMessage converts a user message with attachments, reusing the
+// conversions of the chat completions
+func userInputMessage(content string, attachments []llm.Attachment) (responses.ResponseInputItemUnionParam, error) {
+	parts := make(responses.ResponseInputMessageContentListParam, 0, len(attachments)+1)
+
+	if content != "" {
+		parts = append(parts, responses.ResponseInputContentUnionParam{
+			OfInputText: &responses.ResponseInputTextParam{Text: content},
+		})
+	}
+
+	for _, attachment := range attachments {
+		part, err := ConvertAttachmentToContentPart(attachment)
+		if err != nil {
+			return responses.ResponseInputItemUnionParam{}, errors.Wrapf(err, "failed to convert attachment to content part")
+		}
+
+		switch {
+		case part.OfText != nil:
+			parts = append(parts, responses.ResponseInputContentUnionParam{
+				OfInputText: &responses.ResponseInputTextParam{Text: part.OfText.Text},
+			})
+		case part.OfImageURL != nil:
+			parts = append(parts, responses.ResponseInputContentUnionParam{
+				OfInputImage: &responses.ResponseInputImageParam{
+					ImageURL: openai.String(part.OfImageURL.ImageURL.URL),
+					Detail:   responses.ResponseInputImageDetailAuto,
+				},
+			})
+		default:
+			return responses.ResponseInputItemUnionParam{}, errors.Errorf("unsupported attachment type for conversion: %s", attachment.Type())
+		}
+	}
+
+	return responses.ResponseInputItemUnionParam{
+		OfMessage: &responses.EasyInputMessageParam{
+			Role: responses.EasyInputMessageRoleUser,
+			Content: responses.EasyInputMessageContentUnionParam{
+				OfInputItemContentList: parts,
+			},
+		},
+	}, nil
+}
+
+// reasoningItems returns the reasoning items of the Responses API carried by
+// the message, the reasoning details of other providers being ignored
+func reasoningItems(m llm.Message) []responses.ResponseInputItemUnionParam {
+	reasoningMessage, ok := m.(llm.ReasoningMessage)
+	if !ok {
+		return nil
+	}
+
+	items := make([]responses.ResponseInputItemUnionParam, 0)
+
+	for _, d := range reasoningMessage.ReasoningDetails() {
+		if d.Format != responsesReasoningFormat || d.ID == "" {
+			continue
+		}
+
+		item := &responses.ResponseReasoningItemParam{
+			ID:      d.ID,
+			Summary: []responses.ResponseReasoningItemSummaryParam{},
+		}
+
+		if d.Summary != "" {
+			item.Summary = append(item.Summary, responses.ResponseReasoningItemSummaryParam{Text: d.Summary})
+		}
+
+		if d.Data != "" {
+			item.WithExtraFields(map[string]any{
+				"encrypted_content": d.Data,
+			})
+		}
+
+		items = append(items, responses.ResponseInputItemUnionParam{OfReasoning: item})
+	}
+
+	return items
+}
+
+func configureResponsesTools(opts *llm.ChatCompletionOptions, params *responses.ResponseNewParams) {
+	if len(opts.Tools) > 0 {
+		tools := make([]responses.ToolUnionParam, 0, len(opts.Tools))
+
+		for _, t := range opts.Tools {
+			tools = append(tools, responses.ToolUnionParam{
+				OfFunction: &responses.FunctionToolParam{
+					Name:        t.Name(),
+					Description: openai.String(t.Description()),
+					Parameters:  t.Parameters(),
+				},
+			})
+		}
+
+		params.Tools = tools
+	}
+
+	var mode responses.ToolChoiceOptions
+
+	switch opts.ToolChoice {
+	case llm.ToolChoiceAuto:
+		mode = responses.ToolChoiceOptionsAuto
+	case llm.ToolChoiceRequired:
+		mode = responses.ToolChoiceOptionsRequired
+	case llm.ToolChoiceNone:
+		mode = responses.ToolChoiceOptionsNone
+	default:
+		return
+	}
+
+	params.ToolChoice = responses.ResponseNewParamsToolChoiceUnion{
+		OfToolChoiceMode: openai.Opt(mode),
+	}
+}
+
+func configureResponsesFormat(opts *llm.ChatCompletionOptions, params *responses.ResponseNewParams) error {
+	if opts.ResponseFormat != llm.ResponseFormatJSON {
+		return nil
+	}
+
+	if opts.ResponseSchema == nil {
+		params.Text.Format = responses.ResponseFormatTextConfigUnionParam{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+		}
+		return nil
+	}
+
+	// The SDK expects a map where the chat completions accept any value
+	schema, err := schemaMap(opts.ResponseSchema.Schema())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	params.Text.Format = responses.ResponseFormatTextConfigUnionParam{
+		OfJSONSchema: &responses.ResponseFormatTextJSONSchemaConfigParam{
+			Name:        opts.ResponseSchema.Name(),
+			Description: openai.Opt(opts.ResponseSchema.Description()),
+			Schema:      schema,
+			Strict:      openai.Bool(true),
+		},
+	}
+
+	return nil
+}
+
+func schemaMap(schema any) (map[string]any, error) {
+	if m, ok := schema.(map[string]any); ok {
+		return m, nil
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode response schema")
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, errors.Wrap(err, "response schema is not a JSON object")
+	}
+
+	return m, nil
+}
+
+// configureResponsesReasoning maps the reasoning options, the summaries of
+// the reasoning and its encrypted content being requested unless excluded
+func configureResponsesReasoning(opts *llm.ChatCompletionOptions, params *responses.ResponseNewParams) {
+	if opts.Reasoning == nil {
+		return
+	}
+
+	if opts.Reasoning.Effort != nil {
+		params.Reasoning.Effort = shared.ReasoningEffort(*opts.Reasoning.Effort)
+	}
+
+	if opts.Reasoning.Exclude {
+		return
+	}
+
+	params.Reasoning.WithExtraFields(map[string]any{
+		"summary": "auto",
+	})
+
+	params.Include = append(params.Include, includeEncryptedReasoning)
+}
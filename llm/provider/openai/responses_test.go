@@ -0,0 +1,494 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// newTestResponsesClient returns a client of a local server answering the
+// Responses API calls with the handler, the request bodies being recorded
+func newTestResponsesClient(t *testing.T, handler func(w http.ResponseWriter, body map[string]any)) *ResponsesClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/responses" {
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("%+v", err)
+			return
+		}
+
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("%+v", err)
+			return
+		}
+
+		handler(w, body)
+	}))
+
+	t.Cleanup(server.Close)
+
+	client := openai.NewClient(
+		option.WithBaseURL(server.URL),
+		option.WithAPIKey("test"),
+		option.WithMaxRetries(0),
+	)
+
+	return NewResponsesClient(client, "gpt-test")
+}
+
+const testResponse = `{
+	"id": "resp_2",
+	"object": "response",
+	"status": "completed",
+	"model": "gpt-test",
+	"output": [
+		{"type": "reasoning", "id": "rs_1", "summary": [{"type": "summary_text", "text": "Thinking"}], "encrypted_content": "enc"},
+		{"type": "function_call", "id": "fc_1", "call_id": "call_1", "name": "weather", "arguments": "{\"city\":\"Paris\"}", "status": "completed"}
+	],
+	"usage": {"input_tokens": 10, "input_tokens_details": {"cached_tokens": 4}, "output_tokens": 5, "output_tokens_details": {"reasoning_tokens": 2}, "total_tokens": 15}
+}`
+
+func TestResponsesClient_ChatCompletion(t *testing.T) {
+	var request map[string]any
+
+	client := newTestResponsesClient(t, func(w http.ResponseWriter, body map[string]any) {
+		request = body
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, testResponse)
+	})
+
+	tool := llm.NewFuncTool("weather", "Get the weather", map[string]any{"type": "object"}, nil)
+
+	res, err := client.ChatCompletion(
+		context.Background(),
+		llm.WithMessages(
+			llm.NewMessage(llm.RoleSystem, "Be brief"),
+			llm.NewMessage(llm.RoleUser, "Weather in Paris?"),
+		),
+		llm.WithTools(tool),
+		llm.WithToolChoice(llm.ToolChoiceAuto),
+		llm.WithReasoning(llm.NewReasoningOptions(llm.ReasoningEffortLow)),
+		llm.WithExtraFields(map[string]any{"store": false}),
+	)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if request["model"] != "gpt-test" || request["store"] != false || request["tool_choice"] != "auto" {
+		t.Errorf("unexpected request %v", request)
+	}
+
+	if reasoning, _ := request["reasoning"].(map[string]any); reasoning["effort"] != "low" || reasoning["summary"] != "auto" {
+		t.Errorf("unexpected reasoning %v", request["reasoning"])
+	}
+
+	if include := fmt.Sprint(request["include"]); include != "[reasoning.encrypted_content]" {
+		t.Errorf("unexpected include %s", include)
+	}
+
+	if input, _ := request["input"].([]any); len(input) != 2 {
+		t.Errorf("unexpected input %v", request["input"])
+	}
+
+	stateful, ok := res.(StatefulResponse)
+	if !ok || stateful.ResponseID() != "resp_2" {
+		t.Errorf("expected response id 'resp_2', got %v", res)
+	}
+
+	toolCalls := res.ToolCalls()
+	if len(toolCalls) != 1 || toolCalls[0].ID() != "call_1" || toolCalls[0].Name() != "weather" {
+		t.Fatalf("unexpected tool calls %v", toolCalls)
+	}
+
+	reasoning, ok := res.(llm.ReasoningChatCompletionResponse)
+	if !ok || reasoning.Reasoning() != "Thinking" {
+		t.Fatalf("expected reasoning, got %v", res)
+	}
+
+	details := reasoning.ReasoningDetails()
+	if len(details) != 1 || details[0].ID != "rs_1" || details[0].Data != "enc" || details[0].Type != llm.ReasoningDetailTypeEncrypted {
+		t.Errorf("unexpected reasoning details %+v", details)
+	}
+
+	usage := res.Usage()
+	if usage.PromptTokens() != 10 || usage.CompletionTokens() != 5 || usage.TotalTokens() != 15 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+}
+
+func TestResponsesClient_Temperature(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		funcs       []llm.ChatCompletionOptionFunc
+		temperature any
+	}{
+		{
+			name:        "default",
+			temperature: 0.6,
+		},
+		{
+			name:        "explicit",
+			funcs:       []llm.ChatCompletionOptionFunc{llm.WithTemperature(0.2)},
+			temperature: 0.2,
+		},
+		{
+			name:  "reasoning",
+			funcs: []llm.ChatCompletionOptionFunc{llm.WithReasoning(llm.NewReasoningOptions(llm.ReasoningEffortLow))},
+		},
+		{
+			name:  "reasoning model",
+			funcs: []llm.ChatCompletionOptionFunc{llm.WithModel("o4-mini")},
+		},
+		{
+			name:  "gpt-5",
+			funcs: []llm.ChatCompletionOptionFunc{llm.WithModel("gpt-5-mini")},
+		},
+		{
+			name:        "gpt-5 chat",
+			funcs:       []llm.ChatCompletionOptionFunc{llm.WithModel("gpt-5-chat-latest")},
+			temperature: 0.6,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var request map[string]any
+
+			client := newTestResponsesClient(t, func(w http.ResponseWriter, body map[string]any) {
+				request = body
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, testResponse)
+			})
+
+			funcs := append([]llm.ChatCompletionOptionFunc{
+				llm.WithMessages(llm.NewMessage(llm.RoleUser, "Weather in Paris?")),
+			}, tc.funcs...)
+
+			if _, err := client.ChatCompletion(context.Background(), funcs...); err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			if temperature, ok := request["temperature"]; temperature != tc.temperature || ok != (tc.temperature != nil) {
+				t.Errorf("temperature = %v, want %v", request["temperature"], tc.temperature)
+			}
+		})
+	}
+}
+
+func TestResponsesClient_Replay(t *testing.T) {
+	var request map[string]any
+
+	client := newTestResponsesClient(t, func(w http.ResponseWriter, body map[string]any) {
+		request = body
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, testResponse)
+	})
+
+	details := []llm.ReasoningDetail{
+		{ID: "rs_1", Type: llm.ReasoningDetailTypeEncrypted, Summary: "Thinking", Data: "enc", Format: responsesReasoningFormat},
+	}
+
+	messages := []llm.Message{
+		llm.NewMessage(llm.RoleUser, "Weather in Paris?"),
+		llm.NewReasoningToolCallsMessage("Thinking", details, llm.NewToolCall("call_1", "weather", `{"city":"Paris"}`)),
+		llm.NewToolMessage("call_1", llm.NewToolResult("Sunny")),
+	}
+
+	t.Run("full history", func(t *testing.T) {
+		if _, err := client.ChatCompletion(context.Background(), llm.WithMessages(messages...)); err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		input, _ := request["input"].([]any)
+		if len(input) != 4 {
+			t.Fatalf("expected 4 input items, got %v", input)
+		}
+
+		types := make([]any, 0, len(input))
+		for _, item := range input {
+			item := item.(map[string]any)
+			// The type of the messages is optional
+			if _, isMessage := item["role"]; isMessage {
+				item["type"] = "message"
+			}
+			types = append(types, item["type"])
+		}
+
+		if fmt.Sprint(types) != "[message reasoning function_call function_call_output]" {
+			t.Errorf("unexpected input item types %v", types)
+		}
+
+		reasoning := input[1].(map[string]any)
+		if reasoning["id"] != "rs_1" || reasoning["encrypted_content"] != "enc" {
+			t.Errorf("unexpected reasoning item %v", reasoning)
+		}
+
+		output := input[3].(map[string]any)
+		if output["call_id"] != "call_1" || output["output"] != "Sunny" {
+			t.Errorf("unexpected function call output %v", output)
+		}
+
+		if _, exists := request["previous_response_id"]; exists {
+			t.Errorf("expected no previous response id")
+		}
+	})
+
+	t.Run("chained", func(t *testing.T) {
+		ctx := WithPreviousResponseID(context.Background(), "resp_1")
+
+		if _, err := client.ChatCompletion(ctx, llm.WithMessages(messages...)); err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		if request["previous_response_id"] != "resp_1" {
+			t.Errorf("unexpected previous response id %v", request["previous_response_id"])
+		}
+
+		input, _ := request["input"].([]any)
+		if len(input) != 1 || input[0].(map[string]any)["type"] != "function_call_output" {
+			t.Errorf("expected the tool result only, got %v", input)
+		}
+	})
+}
+
+func TestResponsesClient_Citations(t *testing.T) {
+	client := newTestResponsesClient(t, func(w http.ResponseWriter, body map[string]any) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{
+			"id": "resp_4",
+			"object": "response",
+			"status": "completed",
+			"model": "gpt-test",
+			"output": [
+				{"type": "web_search_call", "id": "ws_1", "status": "completed"},
+				{"type": "message", "id": "msg_1", "role": "assistant", "content": [
+					{"type": "output_text", "text": "Été: ", "annotations": []},
+					{"type": "output_text", "text": "sunny.", "annotations": [{"type": "url_citation", "url": "https://meteo.fr", "title": "Météo", "start_index": 0, "end_index": 6}]}
+				]}
+			]
+		}`)
+	})
+
+	res, err := client.ChatCompletion(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, "Weather?")))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	// The citations are located in the whole content, in characters
+	citations := llm.CitationsOf(res.Message())
+	if len(citations) != 1 || citations[0].Title != "Météo" || citations[0].StartIndex != 5 || citations[0].EndIndex != 11 {
+		t.Errorf("unexpected citations %+v", citations)
+	}
+}
+
+func TestResponsesClient_ChatCompletionStream(t *testing.T) {
+	events := []string{
+		`{"type":"response.created","response":{"id":"resp_3","status":"in_progress","output":[]}}`,
+		`{"type":"response.reasoning_summary_text.delta","item_id":"rs_1","output_index":0,"summary_index":0,"delta":"Hmm"}`,
+		`{"type":"response.output_item.done","output_index":0,"item":{"type":"reasoning","id":"rs_1","summary":[{"type":"summary_text","text":"Hmm"}],"encrypted_content":"enc"}}`,
+		`{"type":"response.output_item.added","output_index":1,"item":{"type":"function_call","id":"fc_1","call_id":"call_1","name":"weather","arguments":""}}`,
+		`{"type":"response.function_call_arguments.delta","item_id":"fc_1","output_index":1,"delta":"{\"city\":"}`,
+		`{"type":"response.function_call_arguments.delta","item_id":"fc_1","output_index":1,"delta":"\"Paris\"}"}`,
+		`{"type":"response.output_text.delta","item_id":"msg_1","output_index":2,"content_index":0,"delta":"Sunny"}`,
+		`{"type":"response.output_text.annotation.added","item_id":"msg_1","output_index":2,"content_index":0,"annotation_index":0,"annotation":{"type":"url_citation","url":"https://meteo.fr","title":"Météo","start_index":0,"end_index":5}}`,
+		`{"type":"response.completed","response":{"id":"resp_3","status":"completed","output":[],"usage":{"input_tokens":8,"input_tokens_details":{"cached_tokens":0},"output_tokens":3,"output_tokens_details":{"reasoning_tokens":1},"total_tokens":11}}}`,
+	}
+
+	client := newTestResponsesClient(t, func(w http.ResponseWriter, body map[string]any) {
+		if body["stream"] != true {
+			t.Errorf("expected a streaming request, got %v", body)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			var e struct {
+				Type string `json:"type"`
+			}
+			json.Unmarshal([]byte(event), &e)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, event)
+		}
+	})
+
+	stream, err := client.ChatCompletionStream(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, "Weather?")))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	var (
+		content   string
+		reasoning string
+		details   []llm.ReasoningDetail
+		arguments string
+		toolCall  llm.ToolCallDelta
+		complete  llm.StreamChunk
+		citations []llm.Citation
+	)
+
+	for chunk := range stream {
+		if err := chunk.Error(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		if chunk.IsComplete() {
+			complete = chunk
+			continue
+		}
+
+		delta := chunk.Delta()
+		content += delta.Content()
+
+		if rd, ok := delta.(llm.ReasoningStreamDelta); ok {
+			reasoning += rd.Reasoning()
+			details = append(details, rd.ReasoningDetails()...)
+		}
+
+		if ad, ok := delta.(llm.AnnotatedStreamDelta); ok {
+			citations = append(citations, ad.Citations()...)
+		}
+
+		for _, tc := range delta.ToolCalls() {
+			if tc.ID() != "" {
+				toolCall = tc
+			}
+			arguments += tc.ParametersDelta()
+		}
+	}
+
+	if len(citations) != 1 || citations[0].URL != "https://meteo.fr" || citations[0].EndIndex != 5 {
+		t.Errorf("unexpected citations %+v", citations)
+	}
+
+	if content != "Sunny" || reasoning != "Hmm" {
+		t.Errorf("unexpected content %q and reasoning %q", content, reasoning)
+	}
+
+	if len(details) != 1 || details[0].Data != "enc" {
+		t.Errorf("unexpected reasoning details %+v", details)
+	}
+
+	if toolCall == nil || toolCall.ID() != "call_1" || toolCall.Name() != "weather" || toolCall.Index() != 0 || arguments != `{"city":"Paris"}` {
+		t.Errorf("unexpected tool call %v with arguments %q", toolCall, arguments)
+	}
+
+	if complete == nil {
+		t.Fatalf("expected a complete chunk")
+	}
+
+	if stateful, ok := complete.(StatefulResponse); !ok || stateful.ResponseID() != "resp_3" {
+		t.Errorf("expected response id 'resp_3', got %v", complete)
+	}
+
+	if complete.Usage().TotalTokens() != 11 {
+		t.Errorf("unexpected usage %+v", complete.Usage())
+	}
+}
+
+func TestResponsesClient_FinishReason(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		event    string
+		response string
+		want     llm.FinishReason
+	}{
+		{
+			name:     "completed",
+			event:    "response.completed",
+			response: `{"id":"resp_4","status":"completed","output":[{"type":"message","content":[{"type":"output_text","text":"Sunny"}]}]}`,
+			want:     llm.FinishReasonStop,
+		},
+		{
+			name:     "max output tokens",
+			event:    "response.incomplete",
+			response: `{"id":"resp_4","status":"incomplete","incomplete_details":{"reason":"max_output_tokens"},"output":[{"type":"message","content":[{"type":"output_text","text":"Sun"}]}]}`,
+			want:     llm.FinishReasonLength,
+		},
+		{
+			name:     "content filter",
+			event:    "response.incomplete",
+			response: `{"id":"resp_4","status":"incomplete","incomplete_details":{"reason":"content_filter"},"output":[]}`,
+			want:     llm.FinishReasonContentFilter,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestResponsesClient(t, func(w http.ResponseWriter, body map[string]any) {
+				if body["stream"] != true {
+					w.Header().Set("Content-Type", "application/json")
+					io.WriteString(w, tc.response)
+					return
+				}
+
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprintf(w, "event: %s\ndata: {\"type\":%q,\"response\":%s}\n\n", tc.event, tc.event, tc.response)
+			})
+
+			messages := llm.WithMessages(llm.NewMessage(llm.RoleUser, "Weather?"))
+
+			res, err := client.ChatCompletion(context.Background(), messages)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			if got := llm.CandidatesOf(res)[0].FinishReason(); got != tc.want {
+				t.Errorf("finish reason = %q, want %q", got, tc.want)
+			}
+
+			stream, err := client.ChatCompletionStream(context.Background(), messages)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			var complete *ResponsesCompleteStreamChunk
+			for chunk := range stream {
+				if err := chunk.Error(); err != nil {
+					t.Fatalf("%+v", err)
+				}
+
+				if c, ok := chunk.(*ResponsesCompleteStreamChunk); ok {
+					complete = c
+				}
+			}
+
+			if complete == nil {
+				t.Fatal("expected a complete chunk")
+			}
+
+			if got := complete.FinishReason(); got != tc.want {
+				t.Errorf("stream finish reason = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResponsesClient_Unsupported(t *testing.T) {
+	client := newTestResponsesClient(t, func(w http.ResponseWriter, body map[string]any) {
+		t.Errorf("unexpected request %v", body)
+	})
+
+	_, err := client.ChatCompletion(context.Background(), llm.WithMessages(llm.NewMessage(llm.RoleUser, "Hi")), llm.WithCandidates(2))
+	if err == nil {
+		t.Errorf("expected candidates to be rejected")
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	for _, api := range []API{"", APIChatCompletions, APIResponses} {
+		if err := (&Options{API: api}).Validate(); err != nil {
+			t.Errorf("unexpected error for API '%s': %v", api, err)
+		}
+	}
+
+	if err := (&Options{API: "assistants"}).Validate(); err == nil {
+		t.Errorf("expected unknown API to be rejected")
+	}
+}
@@ -0,0 +1,65 @@
+package openai
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/openai/openai-go"
+	"github.com/pkg/errors"
+)
+
+// ConfigureSampling maps the sampling controls to the request, the OpenAI API
+// supporting neither top_k nor min_p
+func ConfigureSampling(ctx context.Context, opts *llm.ChatCompletionOptions, params *openai.ChatCompletionNewParams) error {
+	return NewSamplingConfigurator("openai", llm.SamplingTopK, llm.SamplingMinP)(ctx, opts, params)
+}
+
+// NewSamplingConfigurator returns a ConfigureParamsFunc mapping the sampling
+// controls for an OpenAI compatible provider, the unsupported settings being
+// handled according to the strictness of the options. top_k and min_p, not
+// part of the OpenAI API, are always unsupported.
+func NewSamplingConfigurator(provider string, unsupported ...llm.SamplingSetting) ConfigureParamsFunc {
+	unsupported = append(slices.Clone(unsupported), llm.SamplingTopK, llm.SamplingMinP)
+	slices.Sort(unsupported)
+	unsupported = slices.Compact(unsupported)
+
+	return func(ctx context.Context, opts *llm.ChatCompletionOptions, params *openai.ChatCompletionNewParams) error {
+		if err := opts.CheckSampling(ctx, provider, unsupported...); err != nil {
+			return errors.WithStack(err)
+		}
+
+		supported := func(setting llm.SamplingSetting) bool {
+			return opts.IsSet(setting) && !slices.Contains(unsupported, setting)
+		}
+
+		if supported(llm.SamplingTopP) {
+			params.TopP = openai.Float(*opts.TopP)
+		}
+
+		if supported(llm.SamplingStop) {
+			params.Stop = openai.ChatCompletionNewParamsStopUnion{
+				OfChatCompletionNewsStopArray: opts.Stop,
+			}
+		}
+
+		if supported(llm.SamplingFrequencyPenalty) {
+			params.FrequencyPenalty = openai.Float(*opts.FrequencyPenalty)
+		}
+
+		if supported(llm.SamplingPresencePenalty) {
+			params.PresencePenalty = openai.Float(*opts.PresencePenalty)
+		}
+
+		if supported(llm.SamplingLogitBias) {
+			params.LogitBias = make(map[string]int64, len(opts.LogitBias))
+			for token, bias := range opts.LogitBias {
+				params.LogitBias[strconv.Itoa(token)] = int64(math.Round(bias))
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+package openai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/openai/openai-go"
+	"github.com/pkg/errors"
+)
+
+func TestConfigureSampling(t *testing.T) {
+	t.Run("supported settings are mapped", func(t *testing.T) {
+		params := &openai.ChatCompletionNewParams{}
+		opts := llm.NewChatCompletionOptions(
+			llm.WithTopP(0.9), llm.WithStop("END"), llm.WithFrequencyPenalty(0.5),
+			llm.WithPresencePenalty(0.25), llm.WithLogitBias(map[int]float64{42: -99.6}),
+		)
+		if err := ConfigureSampling(context.Background(), opts, params); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if params.TopP.Value != 0.9 || params.FrequencyPenalty.Value != 0.5 || params.PresencePenalty.Value != 0.25 {
+			t.Errorf("unexpected params %+v", params)
+		}
+		if len(params.Stop.OfChatCompletionNewsStopArray) != 1 || params.Stop.OfChatCompletionNewsStopArray[0] != "END" {
+			t.Errorf("unexpected stop %+v", params.Stop)
+		}
+		if params.LogitBias["42"] != -100 {
+			t.Errorf("unexpected logit bias %v", params.LogitBias)
+		}
+	})
+
+	t.Run("unsupported settings are ignored by default", func(t *testing.T) {
+		params := &openai.ChatCompletionNewParams{}
+		if err := ConfigureSampling(context.Background(), llm.NewChatCompletionOptions(llm.WithTopK(40)), params); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unsupported settings fail when strict", func(t *testing.T) {
+		configure := NewSamplingConfigurator("mistral", llm.SamplingLogitBias)
+		opts := llm.NewChatCompletionOptions(llm.WithLogitBias(map[int]float64{42: 1}), llm.WithStrictness(llm.StrictnessFail))
+
+		params := &openai.ChatCompletionNewParams{}
+		if err := configure(context.Background(), opts, params); !errors.Is(err, llm.ErrUnsupportedSetting) {
+			t.Fatalf("expected ErrUnsupportedSetting, got %v", err)
+		}
+		if params.LogitBias != nil {
+			t.Errorf("expected no logit bias, got %v", params.LogitBias)
+		}
+	})
+}
@@ -1,12 +1,12 @@
 package openai
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bornholm/genai/llm"
@@ -15,18 +15,47 @@ import (
 	"github.com/pkg/errors"
 )
 
+// TranscriptionDialect désigne la façon dont une API compatible OpenAI expose
+// les horodatages et la diarisation.
+type TranscriptionDialect string
+
+const (
+	// TranscriptionDialectOpenAI demande les horodatages via
+	// response_format=verbose_json (whisper-1) et la diarisation via
+	// response_format=diarized_json (gpt-4o-transcribe-diarize).
+	TranscriptionDialectOpenAI TranscriptionDialect = "openai"
+	// TranscriptionDialectMistral passe timestamp_granularities et diarize,
+	// les segments étant inclus dans la réponse JSON.
+	TranscriptionDialectMistral TranscriptionDialect = "mistral"
+)
+
 type TranscriptionClient struct {
-	client openai.Client
-	model  string
+	client  openai.Client
+	model   string
+	dialect TranscriptionDialect
+}
+
+type TranscriptionClientOptionFunc func(c *TranscriptionClient)
+
+// WithTranscriptionDialect sélectionne le dialecte de l'API, OpenAI par défaut.
+func WithTranscriptionDialect(dialect TranscriptionDialect) TranscriptionClientOptionFunc {
+	return func(c *TranscriptionClient) {
+		c.dialect = dialect
+	}
 }
 
 // Transcription implements llm.TranscriptionClient.
 func (c *TranscriptionClient) Transcription(ctx context.Context, audio []byte, funcs ...llm.TranscriptionOptionFunc) (llm.TranscriptionResponse, error) {
-	if c.model == "" {
-		return nil, errors.WithStack(llm.ErrUnavailable)
+	opts := llm.NewTranscriptionOptions(funcs...)
+
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
 	}
 
-	opts := llm.NewTranscriptionOptions(funcs...)
+	if model == "" {
+		return nil, errors.WithStack(llm.ErrUnavailable)
+	}
 
 	format := opts.Format
 	if format == "" {
@@ -36,42 +65,157 @@ func (c *TranscriptionClient) Transcription(ctx context.Context, audio []byte, f
 		format = llm.AudioFormatMP3
 	}
 
-	params := openai.AudioTranscriptionNewParams{
-		File:  openai.File(bytes.NewReader(audio), "audio."+string(format), audioMimeType(format)),
-		Model: openai.AudioModel(c.model),
-	}
+	// Le formulaire est construit ici plutôt qu'avec
+	// openai.AudioTranscriptionNewParams, qui ignore les champs propres aux
+	// APIs compatibles (diarize).
+	form := &multipartForm{}
+	form.AddFile("file", "audio."+string(format), audioMimeType(format), audio)
+	form.AddField("model", model)
+	form.AddField("language", opts.Language)
+	form.AddField("prompt", opts.Prompt)
 
-	if opts.Language != "" {
-		params.Language = openai.String(opts.Language)
-	}
-
-	if opts.Prompt != "" {
-		params.Prompt = openai.String(opts.Prompt)
+	if opts.Temperature != nil {
+		form.AddField("temperature", strconv.FormatFloat(*opts.Temperature, 'f', -1, 64))
 	}
 
-	if opts.Temperature != nil {
-		params.Temperature = openai.Float(*opts.Temperature)
+	timed := opts.HasTimestamps(llm.TimestampGranularitySegment) || opts.HasTimestamps(llm.TimestampGranularityWord)
+	if timed {
+		c.configureTimestamps(form, opts)
 	}
 
-	var httpRes *http.Response
+	var (
+		httpRes *http.Response
+		raw     json.RawMessage
+	)
 
 	slog.DebugContext(ctx, "starting transcription")
 	before := time.Now()
-	res, err := c.client.Audio.Transcriptions.New(ctx, params, option.WithResponseInto(&httpRes))
+	err := c.client.Post(ctx, "audio/transcriptions", form, &raw, option.WithResponseInto(&httpRes))
 	slog.DebugContext(ctx, "transcription completed", slog.Duration("duration", time.Since(before)))
 
 	if err != nil {
-		if httpRes != nil {
-			body, _ := io.ReadAll(httpRes.Body)
-			return nil, errors.WithStack(llm.RateLimitError(httpRes.StatusCode, string(body)))
+		var apiErr *openai.Error
+		if errors.As(err, &apiErr) && httpRes != nil {
+			return nil, errors.WithStack(llm.RateLimitError(httpRes.StatusCode, apiErr.RawJSON()))
 		}
 
 		return nil, errors.WithStack(err)
 	}
 
-	language, usage := parseTranscriptionMetadata([]byte(res.RawJSON()))
+	language, usage := parseTranscriptionMetadata(raw)
+
+	var transcription rawTranscription
+	if err := json.Unmarshal(raw, &transcription); err != nil {
+		return nil, errors.Wrap(err, "could not parse transcription response")
+	}
+
+	if !timed {
+		return llm.NewTranscriptionResponse(transcription.Text, language, usage), nil
+	}
+
+	segments, words := transcription.timestamps()
 
-	return llm.NewTranscriptionResponse(res.Text, language, usage), nil
+	return llm.NewTimedTranscriptionResponse(transcription.Text, language, usage, segments, words), nil
+}
+
+func (c *TranscriptionClient) configureTimestamps(form *multipartForm, opts *llm.TranscriptionOptions) {
+	granularities := make([]llm.TimestampGranularity, 0, 2)
+	for _, g := range []llm.TimestampGranularity{llm.TimestampGranularitySegment, llm.TimestampGranularityWord} {
+		if opts.HasTimestamps(g) {
+			granularities = append(granularities, g)
+		}
+	}
+
+	switch c.dialect {
+	case TranscriptionDialectMistral:
+		for _, g := range granularities {
+			form.AddField("timestamp_granularities", string(g))
+		}
+		if opts.Diarize {
+			form.AddField("diarize", "true")
+		}
+
+	default:
+		// Les modèles de diarisation n'acceptent que diarized_json, qui
+		// porte des segments attribués à un locuteur mais pas de mots.
+		if opts.Diarize {
+			form.AddField("response_format", "diarized_json")
+			form.AddField("chunking_strategy", "auto")
+			return
+		}
+
+		form.AddField("response_format", string(openai.AudioResponseFormatVerboseJSON))
+		for _, g := range granularities {
+			form.AddField("timestamp_granularities[]", string(g))
+		}
+	}
+}
+
+// rawTranscription couvre les formats verbose_json et diarized_json d'OpenAI
+// ainsi que la réponse de Mistral.
+type rawTranscription struct {
+	Text     string `json:"text"`
+	Segments []struct {
+		Start     float64         `json:"start"`
+		End       float64         `json:"end"`
+		Text      string          `json:"text"`
+		Speaker   json.RawMessage `json:"speaker"`
+		SpeakerID json.RawMessage `json:"speaker_id"`
+	} `json:"segments"`
+	Words []struct {
+		Start     float64         `json:"start"`
+		End       float64         `json:"end"`
+		Word      string          `json:"word"`
+		Speaker   json.RawMessage `json:"speaker"`
+		SpeakerID json.RawMessage `json:"speaker_id"`
+	} `json:"words"`
+}
+
+func (t rawTranscription) timestamps() ([]llm.TranscriptionSegment, []llm.TranscriptionWord) {
+	segments := make([]llm.TranscriptionSegment, 0, len(t.Segments))
+	for _, s := range t.Segments {
+		segments = append(segments, llm.TranscriptionSegment{
+			Start:   seconds(s.Start),
+			End:     seconds(s.End),
+			Text:    strings.TrimSpace(s.Text),
+			Speaker: speakerName(s.Speaker, s.SpeakerID),
+		})
+	}
+
+	words := make([]llm.TranscriptionWord, 0, len(t.Words))
+	for _, w := range t.Words {
+		words = append(words, llm.TranscriptionWord{
+			Start:   seconds(w.Start),
+			End:     seconds(w.End),
+			Word:    strings.TrimSpace(w.Word),
+			Speaker: speakerName(w.Speaker, w.SpeakerID),
+		})
+	}
+
+	return segments, words
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// speakerName retourne le premier identifiant de locuteur renseigné, chaîne
+// ou nombre selon les APIs.
+func speakerName(raws ...json.RawMessage) string {
+	for _, raw := range raws {
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+
+		var name string
+		if err := json.Unmarshal(raw, &name); err == nil {
+			return name
+		}
+
+		return string(raw)
+	}
+
+	return ""
 }
 
 // rawTranscriptionMetadata couvre les champs additionnels retournés par les
@@ -157,11 +301,18 @@ func audioMimeType(format llm.AudioFormat) string {
 	}
 }
 
-func NewTranscriptionClient(client openai.Client, model string) *TranscriptionClient {
-	return &TranscriptionClient{
-		client: client,
-		model:  model,
+func NewTranscriptionClient(client openai.Client, model string, funcs ...TranscriptionClientOptionFunc) *TranscriptionClient {
+	c := &TranscriptionClient{
+		client:  client,
+		model:   model,
+		dialect: TranscriptionDialectOpenAI,
 	}
+
+	for _, fn := range funcs {
+		fn(c)
+	}
+
+	return c
 }
 
 var _ llm.TranscriptionClient = &TranscriptionClient{}
@@ -1,9 +1,16 @@
 package openai
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/bornholm/genai/llm"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
 )
 
 func TestParseTranscriptionMetadata_OpenAI(t *testing.T) {
@@ -142,3 +149,103 @@ func TestParseTranscriptionMetadata_NoCostReported(t *testing.T) {
 		t.Error("a cost was reported although the payload carried none")
 	}
 }
+
+func TestTranscription_Timestamps(t *testing.T) {
+	testCases := []struct {
+		name           string
+		dialect        TranscriptionDialect
+		funcs          []llm.TranscriptionOptionFunc
+		response       string
+		expectedFields map[string][]string
+		expectedWords  int
+		expectedSpeak  string
+	}{
+		{
+			name:    "openai verbose json",
+			dialect: TranscriptionDialectOpenAI,
+			funcs:   []llm.TranscriptionOptionFunc{llm.WithTranscriptionTimestamps(llm.TimestampGranularitySegment, llm.TimestampGranularityWord)},
+			response: `{"text":"Hello there.","language":"english","duration":1.5,
+				"segments":[{"id":0,"start":0.0,"end":1.5,"text":" Hello there."}],
+				"words":[{"word":"Hello","start":0.0,"end":0.4},{"word":"there","start":0.5,"end":1.1}]}`,
+			expectedFields: map[string][]string{
+				"response_format":           {"verbose_json"},
+				"timestamp_granularities[]": {"segment", "word"},
+			},
+			expectedWords: 2,
+		},
+		{
+			name:    "openai diarization",
+			dialect: TranscriptionDialectOpenAI,
+			funcs:   []llm.TranscriptionOptionFunc{llm.WithTranscriptionDiarization()},
+			response: `{"text":"Hello there.",
+				"segments":[{"type":"transcript.text.segment","id":"seg_0","start":0.0,"end":1.5,"text":"Hello there.","speaker":"A"}]}`,
+			expectedFields: map[string][]string{
+				"response_format":   {"diarized_json"},
+				"chunking_strategy": {"auto"},
+			},
+			expectedSpeak: "A",
+		},
+		{
+			name:    "mistral",
+			dialect: TranscriptionDialectMistral,
+			funcs:   []llm.TranscriptionOptionFunc{llm.WithTranscriptionDiarization()},
+			response: `{"model":"voxtral-mini-latest","text":"Hello there.","language":"en",
+				"segments":[{"text":"Hello there.","start":0.0,"end":1.5,"speaker_id":1}]}`,
+			expectedFields: map[string][]string{
+				"timestamp_granularities": {"segment"},
+				"diarize":                 {"true"},
+			},
+			expectedSpeak: "1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var form map[string][]string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					t.Fatalf("could not parse form: %v", err)
+				}
+				form = r.MultipartForm.Value
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			t.Cleanup(server.Close)
+
+			client := NewTranscriptionClient(
+				openai.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("sk-test"), option.WithMaxRetries(0)),
+				"whisper-1",
+				WithTranscriptionDialect(tc.dialect),
+			)
+
+			res, err := client.Transcription(context.Background(), []byte("ID3 fake mp3 data"), tc.funcs...)
+			if err != nil {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+
+			for field, expected := range tc.expectedFields {
+				if !slices.Equal(form[field], expected) {
+					t.Errorf("expected field %q to be %v, got %v", field, expected, form[field])
+				}
+			}
+
+			timed, ok := res.(llm.TimedTranscriptionResponse)
+			if !ok {
+				t.Fatalf("expected a timed response, got %T", res)
+			}
+
+			segments := timed.Segments()
+			if len(segments) != 1 || segments[0].End != 1500*time.Millisecond || segments[0].Text != "Hello there." {
+				t.Errorf("unexpected segments %+v", segments)
+			}
+			if segments[0].Speaker != tc.expectedSpeak {
+				t.Errorf("expected speaker %q, got %q", tc.expectedSpeak, segments[0].Speaker)
+			}
+			if len(timed.Words()) != tc.expectedWords {
+				t.Errorf("expected %d words, got %d", tc.expectedWords, len(timed.Words()))
+			}
+		})
+	}
+}
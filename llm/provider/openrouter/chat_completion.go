@@ -2,7 +2,9 @@ package openrouter
 
 import (
 	"fmt"
+	"maps"
 	"math"
+	"strconv"
 	"strings"
 	"sync/atomic"
 
@@ -13,8 +15,28 @@ import (
 )
 
 type ChatCompletionClient struct {
-	client *openrouter.Client
-	model  string
+	client      *openrouter.Client
+	model       string
+	preferences ProviderPreferences
+	plugins     []Plugin
+}
+
+type ChatCompletionClientOptionFunc func(c *ChatCompletionClient)
+
+// WithDefaultProviderPreferences définit les préférences de routage des
+// appels, remplaçables par appel avec [WithProviderPreferences].
+func WithDefaultProviderPreferences(prefs ProviderPreferences) ChatCompletionClientOptionFunc {
+	return func(c *ChatCompletionClient) {
+		c.preferences = prefs
+	}
+}
+
+// WithDefaultPlugins définit les plugins des appels, remplaçables par appel
+// avec [WithPlugins].
+func WithDefaultPlugins(plugins ...Plugin) ChatCompletionClientOptionFunc {
+	return func(c *ChatCompletionClient) {
+		c.plugins = plugins
+	}
 }
 
 // toOpenRouterReasoning maps llm.ReasoningOptions to openrouter.ChatCompletionReasoning.
@@ -276,9 +298,7 @@ func buildMessages(msgs []llm.Message, model string) ([]openrouter.ChatCompletio
 				messages = append(messages, openrouter.ChatCompletionMessage{
 					Role:       openrouter.ChatMessageRoleTool,
 					ToolCallID: toolMessage.ID(),
-					Content: openrouter.Content{
-						Text: m.Content(),
-					},
+					Content:    textContent(m),
 				})
 			}
 		case llm.RoleToolCalls:
@@ -332,6 +352,39 @@ func buildMessages(msgs []llm.Message, model string) ([]openrouter.ChatCompletio
 	return messages, nil
 }
 
+// configureSampling maps the sampling controls, all supported by OpenRouter
+// which forwards them to the providers honouring them
+func configureSampling(opts *llm.ChatCompletionOptions, req *openrouter.ChatCompletionRequest) {
+	if opts.TopP != nil {
+		req.TopP = float32(*opts.TopP)
+	}
+
+	if opts.TopK != nil {
+		req.TopK = *opts.TopK
+	}
+
+	if opts.MinP != nil {
+		req.MinP = float32(*opts.MinP)
+	}
+
+	req.Stop = opts.Stop
+
+	if opts.FrequencyPenalty != nil {
+		req.FrequencyPenalty = float32(*opts.FrequencyPenalty)
+	}
+
+	if opts.PresencePenalty != nil {
+		req.PresencePenalty = float32(*opts.PresencePenalty)
+	}
+
+	if len(opts.LogitBias) > 0 {
+		req.LogitBias = make(map[string]int, len(opts.LogitBias))
+		for token, bias := range opts.LogitBias {
+			req.LogitBias[strconv.Itoa(token)] = int(math.Round(bias))
+		}
+	}
+}
+
 // ChatCompletion implements llm.Client.
 func (c *ChatCompletionClient) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
 	opts := llm.NewChatCompletionOptions(funcs...)
@@ -341,10 +394,15 @@ func (c *ChatCompletionClient) ChatCompletion(ctx context.Context, funcs ...llm.
 		return nil, errors.WithStack(err)
 	}
 
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
 	temperature := float32(opts.Temperature)
 
 	req := openrouter.ChatCompletionRequest{
-		Model:       c.model,
+		Model:       model,
 		Temperature: temperature,
 		Usage:       &openrouter.IncludeUsage{Include: true},
 	}
@@ -409,7 +467,7 @@ func (c *ChatCompletionClient) ChatCompletion(ctx context.Context, funcs ...llm.
 		}
 	}
 
-	messages, err := buildMessages(opts.Messages, c.model)
+	messages, err := buildMessages(opts.Messages, model)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -424,6 +482,17 @@ func (c *ChatCompletionClient) ChatCompletion(ctx context.Context, funcs ...llm.
 		req.MaxCompletionTokens = *opts.MaxCompletionTokens
 	}
 
+	configureSampling(opts, &req)
+
+	if opts.Candidates > 1 {
+		req.N = opts.Candidates
+	}
+
+	if opts.Logprobs {
+		req.LogProbs = true
+		req.TopLogProbs = opts.TopLogprobs
+	}
+
 	transforms, err := ContextTransforms(ctx)
 	if err != nil && !errors.Is(err, context.ErrNotFound) {
 		return nil, errors.WithStack(err)
@@ -442,7 +511,12 @@ func (c *ChatCompletionClient) ChatCompletion(ctx context.Context, funcs ...llm.
 		req.SessionId = opts.SessionID
 	}
 
-	res, err := c.client.CreateChatCompletion(ctx, req)
+	fields, err := c.bodyFields(ctx, opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res, err := c.client.CreateChatCompletion(withBodyFields(ctx, fields), req)
 	if err != nil {
 		var reqErr *openrouter.RequestError
 		if errors.As(err, &reqErr) {
@@ -456,11 +530,79 @@ func (c *ChatCompletionClient) ChatCompletion(ctx context.Context, funcs ...llm.
 		return nil, errors.WithStack(llm.ErrNoMessage)
 	}
 
-	openrouterMessage := res.Choices[0].Message
+	message, reasoning, reasoningDetails, toolCalls := convertMessage(res.Choices[0].Message)
+
+	usage := llm.NewChatCompletionUsageWithCost(
+		int64(res.Usage.PromptTokens),
+		int64(res.Usage.CompletionTokens),
+		int64(res.Usage.TotalTokens),
+		int64(res.Usage.PromptTokenDetails.CachedTokens),
+		res.Usage.Cost,
+		"USD", // OpenRouter always reports cost in USD
+	)
+
+	response := llm.NewChatCompletionResponseWithReasoning(message, usage, reasoning, reasoningDetails, toolCalls...)
+
+	metadata := Metadata{Provider: res.Provider}
+
+	if opts.Candidates <= 1 && !opts.Logprobs {
+		return &metadataResponse{llm.NewCandidatesResponse(response), metadata}, nil
+	}
+
+	candidates := make([]llm.Candidate, 0, len(res.Choices))
+	for _, choice := range res.Choices {
+		message, _, _, toolCalls := convertMessage(choice.Message)
+
+		var logprobs []llm.TokenLogprob
+		if choice.LogProbs != nil {
+			logprobs = toTokenLogprobs(choice.LogProbs.Content)
+		}
+
+		candidates = append(candidates, llm.NewCandidate(choice.Index, message, llm.FinishReason(choice.FinishReason), logprobs, toolCalls...))
+	}
+
+	return &metadataResponse{llm.NewCandidatesResponse(response, candidates...), metadata}, nil
+}
+
+// bodyFields returns the fields the SDK does not model: the routing
+// preferences and the plugins, of the context or else of the client, and the
+// extra fields of the call
+func (c *ChatCompletionClient) bodyFields(ctx context.Context, opts *llm.ChatCompletionOptions) (map[string]any, error) {
+	fields := make(map[string]any)
+
+	prefs, err := ContextProviderPreferences(ctx)
+	if err != nil {
+		if !errors.Is(err, context.ErrNotFound) {
+			return nil, errors.WithStack(err)
+		}
+		prefs = c.preferences
+	}
+
+	if !prefs.IsZero() {
+		fields["provider"] = prefs
+	}
+
+	plugins, err := ContextPlugins(ctx)
+	if err != nil {
+		if !errors.Is(err, context.ErrNotFound) {
+			return nil, errors.WithStack(err)
+		}
+		plugins = c.plugins
+	}
+
+	if len(plugins) > 0 {
+		fields["plugins"] = plugins
+	}
 
-	// Extract reasoning from the response message.
-	// Prefer the structured reasoning_details when available (supports encrypted blocks),
-	// fall back to the plain reasoning string.
+	maps.Copy(fields, opts.ExtraFields)
+
+	return fields, nil
+}
+
+// convertMessage converts a message of the API with its reasoning.
+// Prefer the structured reasoning_details when available (supports encrypted blocks),
+// fall back to the plain reasoning string.
+func convertMessage(openrouterMessage openrouter.ChatCompletionMessage) (llm.Message, string, []llm.ReasoningDetail, []llm.ToolCall) {
 	var (
 		reasoning        string
 		reasoningDetails []llm.ReasoningDetail
@@ -485,22 +627,36 @@ func (c *ChatCompletionClient) ChatCompletion(ctx context.Context, funcs ...llm.
 		message = llm.NewMessage(llm.RoleAssistant, openrouterMessage.Content.Text)
 	}
 
+	if citations := toCitations(openrouterMessage.Annotations); len(citations) > 0 {
+		message, _ = llm.WithCitations(message, citations)
+	}
+
 	toolCalls := make([]llm.ToolCall, 0)
 
 	for _, tc := range openrouterMessage.ToolCalls {
 		toolCalls = append(toolCalls, llm.NewToolCall(tc.ID, tc.Function.Name, tc.Function.Arguments))
 	}
 
-	usage := llm.NewChatCompletionUsageWithCost(
-		int64(res.Usage.PromptTokens),
-		int64(res.Usage.CompletionTokens),
-		int64(res.Usage.TotalTokens),
-		int64(res.Usage.PromptTokenDetails.CachedTokens),
-		res.Usage.Cost,
-		"USD", // OpenRouter always reports cost in USD
-	)
+	return message, reasoning, reasoningDetails, toolCalls
+}
+
+func toTokenLogprobs(content []openrouter.LogProb) []llm.TokenLogprob {
+	logprobs := make([]llm.TokenLogprob, 0, len(content))
+
+	for _, lp := range content {
+		var top []llm.TopLogprob
+		for _, t := range lp.TopLogProbs {
+			top = append(top, llm.TopLogprob{Token: t.Token, Logprob: t.LogProb})
+		}
+
+		logprobs = append(logprobs, llm.TokenLogprob{
+			Token:       lp.Token,
+			Logprob:     lp.LogProb,
+			TopLogprobs: top,
+		})
+	}
 
-	return llm.NewChatCompletionResponseWithReasoning(message, usage, reasoning, reasoningDetails, toolCalls...), nil
+	return logprobs
 }
 
 // ChatCompletionStream implements llm.ChatCompletionStreamingClient.
@@ -512,10 +668,15 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 		return nil, errors.WithStack(err)
 	}
 
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
 	temperature := float32(opts.Temperature)
 
 	req := openrouter.ChatCompletionRequest{
-		Model:         c.model,
+		Model:         model,
 		Temperature:   temperature,
 		Stream:        true, // Enable streaming
 		StreamOptions: &openrouter.StreamOptions{IncludeUsage: true},
@@ -582,7 +743,7 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 		}
 	}
 
-	messages, err := buildMessages(opts.Messages, c.model)
+	messages, err := buildMessages(opts.Messages, model)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -597,6 +758,8 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 		req.MaxCompletionTokens = *opts.MaxCompletionTokens
 	}
 
+	configureSampling(opts, &req)
+
 	transforms, err := ContextTransforms(ctx)
 	if err != nil && !errors.Is(err, context.ErrNotFound) {
 		return nil, errors.WithStack(err)
@@ -615,6 +778,13 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 		req.SessionId = opts.SessionID
 	}
 
+	fields, err := c.bodyFields(ctx, opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ctx = withBodyFields(ctx, fields)
+
 	// Create streaming channel
 	chunks := make(chan llm.StreamChunk, 10)
 
@@ -630,6 +800,8 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 	go func() {
 		defer close(chunks)
 
+		var metadata Metadata
+
 		stream, err := c.client.CreateChatCompletionStream(ctx, req)
 		if err != nil {
 			var reqErr *openrouter.RequestError
@@ -662,6 +834,10 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 				costReported.Store(true)
 			}
 
+			if response.Provider != "" {
+				metadata.Provider = response.Provider
+			}
+
 			if len(response.Choices) == 0 {
 				continue
 			}
@@ -710,32 +886,37 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 			}
 
 			// Emit audio stream delta if audio data or transcript is present
+			var streamDelta llm.StreamDelta
 			if audioData != "" || transcript != "" {
-				streamDelta := llm.NewAudioStreamDelta(
+				streamDelta = llm.NewAudioStreamDelta(
 					llm.RoleAssistant,
 					delta.Content,
 					audioData,
 					transcript,
 					toolCallDeltas...,
 				)
-				chunks <- llm.NewStreamChunk(streamDelta)
 			} else if deltaReasoning != "" || len(deltaReasoningDetails) > 0 {
-				streamDelta := llm.NewReasoningStreamDelta(
+				streamDelta = llm.NewReasoningStreamDelta(
 					llm.RoleAssistant,
 					delta.Content,
 					deltaReasoning,
 					deltaReasoningDetails,
 					toolCallDeltas...,
 				)
-				chunks <- llm.NewStreamChunk(streamDelta)
 			} else {
-				streamDelta := llm.NewStreamDelta(
+				streamDelta = llm.NewStreamDelta(
 					llm.RoleAssistant,
 					delta.Content,
 					toolCallDeltas...,
 				)
-				chunks <- llm.NewStreamChunk(streamDelta)
 			}
+
+			// Web search annotations usually come with the last chunks
+			if citations := toCitations(delta.Annotations); len(citations) > 0 {
+				streamDelta, _ = llm.WithStreamDeltaCitations(streamDelta, citations)
+			}
+
+			chunks <- llm.NewStreamChunk(streamDelta)
 		}
 
 		// Send completion chunk with usage if available
@@ -758,18 +939,34 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 			)
 		}
 
-		chunks <- llm.NewCompleteStreamChunk(usage)
+		chunks <- &metadataStreamChunk{llm.NewCompleteStreamChunk(usage), metadata}
 	}()
 
 	return chunks, nil
 }
 
-func NewChatCompletionClient(client *openrouter.Client, model string) *ChatCompletionClient {
-	return &ChatCompletionClient{
+// ValidateAttachment implements llm.AttachmentValidator, so wrappers can
+// tell which attachments the client sends natively.
+func (c *ChatCompletionClient) ValidateAttachment(attachment llm.Attachment) error {
+	return NewOpenRouterAttachmentValidator(c.model).ValidateAttachment(attachment)
+}
+
+// NewChatCompletionClient crée un client de chat completion. Les préférences
+// de routage, les plugins et les champs supplémentaires des appels ne sont
+// transmis que si client a été créé avec [NewClient].
+func NewChatCompletionClient(client *openrouter.Client, model string, funcs ...ChatCompletionClientOptionFunc) *ChatCompletionClient {
+	c := &ChatCompletionClient{
 		client: client,
 		model:  model,
 	}
+
+	for _, fn := range funcs {
+		fn(c)
+	}
+
+	return c
 }
 
 var _ llm.ChatCompletionClient = &ChatCompletionClient{}
 var _ llm.ChatCompletionStreamingClient = &ChatCompletionClient{}
+var _ llm.AttachmentValidator = &ChatCompletionClient{}
@@ -0,0 +1,198 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/caarlos0/env/v11"
+)
+
+// newTestChatCompletionClient returns a client of a local server answering
+// the chat completion calls with the handler, the request bodies being
+// decoded
+func newTestChatCompletionClient(t *testing.T, handler func(w http.ResponseWriter, body map[string]any), funcs ...ChatCompletionClientOptionFunc) *ChatCompletionClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("%+v", err)
+			return
+		}
+
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("%+v", err)
+			return
+		}
+
+		handler(w, body)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return NewChatCompletionClient(NewClient("test", server.URL), "openai/gpt-4o", funcs...)
+}
+
+func TestChatCompletion_PreferencesAndMetadata(t *testing.T) {
+	var request map[string]any
+
+	allowFallbacks := false
+
+	client := newTestChatCompletionClient(t, func(w http.ResponseWriter, body map[string]any) {
+		request = body
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{
+			"id": "gen-1",
+			"provider": "DeepInfra",
+			"choices": [{"index": 0, "finish_reason": "stop", "message": {
+				"role": "assistant",
+				"content": "Go 1.25 is out.",
+				"annotations": [{"type": "url_citation", "url_citation": {"url": "https://go.dev/blog", "title": "The Go Blog", "start_index": 0, "end_index": 15}}]
+			}}],
+			"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+		}`)
+	}, WithDefaultProviderPreferences(ProviderPreferences{
+		Order:          []string{"deepinfra", "together"},
+		AllowFallbacks: &allowFallbacks,
+		DataCollection: DataCollectionDeny,
+		MaxPrice:       MaxPrice{Prompt: 1, Completion: 2},
+	}))
+
+	ctx := WithPlugins(context.Background(), Plugin{ID: PluginIDWeb, MaxResults: 3})
+
+	res, err := client.ChatCompletion(ctx,
+		llm.WithMessages(llm.NewMessage(llm.RoleUser, "What's new in Go?")),
+		llm.WithExtraFields(map[string]any{"user": "tester"}),
+	)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	provider, _ := request["provider"].(map[string]any)
+	if fmt.Sprint(provider["order"]) != "[deepinfra together]" || provider["allow_fallbacks"] != false || provider["data_collection"] != "deny" {
+		t.Errorf("unexpected provider preferences %v", request["provider"])
+	}
+
+	if maxPrice := fmt.Sprint(provider["max_price"]); maxPrice != "map[completion:2 prompt:1]" {
+		t.Errorf("unexpected max price %s", maxPrice)
+	}
+
+	if plugins := fmt.Sprint(request["plugins"]); plugins != "[map[id:web max_results:3]]" {
+		t.Errorf("unexpected plugins %s", plugins)
+	}
+
+	if request["user"] != "tester" || request["model"] != "openai/gpt-4o" {
+		t.Errorf("unexpected request %v", request)
+	}
+
+	metadata, ok := MetadataOf(res)
+	if !ok {
+		t.Fatalf("expected the response to carry metadata")
+	}
+
+	if metadata.Provider != "DeepInfra" {
+		t.Errorf("unexpected provider %q", metadata.Provider)
+	}
+
+	citations := llm.CitationsOf(res.Message())
+	if len(citations) != 1 || citations[0].URL != "https://go.dev/blog" || citations[0].EndIndex != 15 {
+		t.Errorf("unexpected citations %+v", citations)
+	}
+
+	if res.Message().Content() != "Go 1.25 is out." || len(llm.CandidatesOf(res)) != 1 {
+		t.Errorf("unexpected response %+v", res)
+	}
+}
+
+func TestChatCompletionStream_MetadataAndCitations(t *testing.T) {
+	var request map[string]any
+
+	chunks := []string{
+		`{"id":"gen-1","provider":"Together","choices":[{"index":0,"delta":{"role":"assistant","content":"Go 1.25"}}]}`,
+		`{"id":"gen-1","provider":"Together","choices":[{"index":0,"delta":{"content":" is out.","annotations":[{"type":"url_citation","url_citation":{"url":"https://go.dev/blog","title":"The Go Blog"}}]},"finish_reason":"stop"}]}`,
+		`[DONE]`,
+	}
+
+	client := newTestChatCompletionClient(t, func(w http.ResponseWriter, body map[string]any) {
+		request = body
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+	}, WithDefaultPlugins(Plugin{ID: PluginIDWeb}))
+
+	// Plugins of the context replace the ones of the client
+	ctx := WithPlugins(context.Background())
+
+	stream, err := client.ChatCompletionStream(ctx, llm.WithMessages(llm.NewMessage(llm.RoleUser, "What's new in Go?")))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	var (
+		complete  llm.StreamChunk
+		citations []llm.Citation
+	)
+
+	for chunk := range stream {
+		if err := chunk.Error(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if chunk.IsComplete() {
+			complete = chunk
+			continue
+		}
+		if ad, ok := chunk.Delta().(llm.AnnotatedStreamDelta); ok {
+			citations = append(citations, ad.Citations()...)
+		}
+	}
+
+	if _, exists := request["plugins"]; exists {
+		t.Errorf("expected no plugins, got %v", request["plugins"])
+	}
+
+	metadata, ok := MetadataOf(complete)
+	if !ok {
+		t.Fatalf("expected the complete chunk to carry metadata")
+	}
+
+	if metadata.Provider != "Together" {
+		t.Errorf("unexpected metadata %+v", metadata)
+	}
+
+	if len(citations) != 1 || citations[0].Title != "The Go Blog" {
+		t.Errorf("unexpected citations %+v", citations)
+	}
+}
+
+func TestOptions_Env(t *testing.T) {
+	opts := defaultOptions()
+
+	err := env.ParseWithOptions(opts, env.Options{Environment: map[string]string{
+		"PROVIDER_ONLY":             "anthropic,google-vertex",
+		"PROVIDER_ZDR":              "true",
+		"PROVIDER_SORT":             "throughput",
+		"PROVIDER_MAX_PRICE_PROMPT": "0.5",
+		"PLUGINS":                   "web,file-parser",
+		"WEB_MAX_RESULTS":           "5",
+		"PDF_ENGINE":                "pdf-text",
+	}})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	prefs := opts.Provider
+	if len(prefs.Only) != 2 || prefs.ZDR == nil || !*prefs.ZDR || prefs.Sort != ProviderSortThroughput || prefs.MaxPrice.Prompt != 0.5 {
+		t.Errorf("unexpected provider preferences %+v", prefs)
+	}
+
+	plugins := opts.plugins()
+	if len(plugins) != 2 || plugins[0].MaxResults != 5 || plugins[1].PDF == nil || plugins[1].PDF.Engine != "pdf-text" {
+		t.Errorf("unexpected plugins %+v", plugins)
+	}
+}
@@ -0,0 +1,91 @@
+package openrouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/bornholm/genai/llm/context"
+	"github.com/pkg/errors"
+	"github.com/revrost/go-openrouter"
+)
+
+// NewClient crée un client du SDK OpenRouter capable de transmettre les
+// champs que le SDK ne modélise pas (plafonds de prix, options des plugins,
+// champs supplémentaires des appels). Les clients de chat completion
+// construits sur un client du SDK créé autrement ignorent ces champs.
+func NewClient(apiKey string, baseURL string) *openrouter.Client {
+	config := openrouter.DefaultConfig(apiKey)
+
+	if baseURL != "" {
+		config.BaseURL = baseURL
+	}
+
+	config.HTTPClient = &bodyFieldsDoer{doer: config.HTTPClient}
+
+	return openrouter.NewClientWithConfig(*config)
+}
+
+// withBodyFields attache au contexte des champs à fusionner dans le corps JSON
+// des requêtes envoyées par un client créé avec NewClient
+func withBodyFields(ctx context.Context, fields map[string]any) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, contextKeyBodyFields, fields)
+}
+
+// bodyFieldsDoer fusionne les champs attachés au contexte d'une requête dans
+// son corps JSON, les champs existants étant remplacés
+type bodyFieldsDoer struct {
+	doer openrouter.HTTPDoer
+}
+
+// Do implements openrouter.HTTPDoer.
+func (d *bodyFieldsDoer) Do(req *http.Request) (*http.Response, error) {
+	fields, err := context.Value[map[string]any](req.Context(), contextKeyBodyFields)
+	if err != nil || req.Body == nil {
+		return d.doer.Do(req)
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := req.Body.Close(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Raw values keep the numbers and the schemas of the body untouched
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, errors.Wrap(err, "could not decode request body")
+	}
+
+	for key, value := range fields {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not encode field '%s'", key)
+		}
+
+		body[key] = raw
+	}
+
+	data, err = json.Marshal(body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	return d.doer.Do(req)
+}
+
+var _ openrouter.HTTPDoer = &bodyFieldsDoer{}
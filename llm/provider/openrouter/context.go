@@ -7,6 +7,9 @@ type contextKey string
 const (
 	contextKeyTransforms contextKey = "transforms"
 	contextKeyModels     contextKey = "models"
+	contextKeyProvider   contextKey = "provider"
+	contextKeyPlugins    contextKey = "plugins"
+	contextKeyBodyFields contextKey = "bodyFields"
 )
 
 func ContextTransforms(ctx context.Context) ([]string, error) {
@@ -24,3 +27,23 @@ func ContextModels(ctx context.Context) ([]string, error) {
 func WithModels(ctx context.Context, models ...string) context.Context {
 	return context.WithValue(ctx, contextKeyModels, models)
 }
+
+func ContextProviderPreferences(ctx context.Context) (ProviderPreferences, error) {
+	return context.Value[ProviderPreferences](ctx, contextKeyProvider)
+}
+
+// WithProviderPreferences remplace, pour les appels faits avec le contexte,
+// les préférences de routage du client.
+func WithProviderPreferences(ctx context.Context, prefs ProviderPreferences) context.Context {
+	return context.WithValue(ctx, contextKeyProvider, prefs)
+}
+
+func ContextPlugins(ctx context.Context) ([]Plugin, error) {
+	return context.Value[[]Plugin](ctx, contextKeyPlugins)
+}
+
+// WithPlugins remplace, pour les appels faits avec le contexte, les plugins
+// du client. Aucun plugin n'est activé si la liste est vide.
+func WithPlugins(ctx context.Context, plugins ...Plugin) context.Context {
+	return context.WithValue(ctx, contextKeyPlugins, plugins)
+}
@@ -17,15 +17,20 @@ type EmbeddingsClient struct {
 
 // Embeddings implements [llm.EmbeddingsClient].
 func (c *EmbeddingsClient) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
-	if c.model == "" {
-		return nil, errors.WithStack(llm.ErrUnavailable)
+	opts := llm.NewEmbeddingsOptions(funcs...)
+
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
 	}
 
-	opts := llm.NewEmbeddingsOptions(funcs...)
+	if model == "" {
+		return nil, errors.WithStack(llm.ErrUnavailable)
+	}
 
 	req := openrouter.EmbeddingsRequest{
 		Input: inputs,
-		Model: c.model,
+		Model: model,
 	}
 
 	if opts.Dimensions != nil {
@@ -0,0 +1,173 @@
+package openrouter
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/bornholm/genai/llm"
+)
+
+// imageEditEndpoint : le point d'entrée /images d'OpenRouter n'accepte pas
+// d'image source, l'édition passe par les modèles à sortie image de
+// /chat/completions (modalities ["image", "text"]).
+const imageEditEndpoint = "https://openrouter.ai/api/v1/chat/completions"
+
+// variationPrompt remplace le prompt des variations, que l'API chat ne sait
+// pas produire sans instruction.
+const variationPrompt = "Create a variation of this image, keeping its subject, composition and style."
+
+// maskInstruction accompagne le masque, transmis comme seconde image : les
+// modèles de chat n'ont pas de paramètre dédié à l'inpainting.
+const maskInstruction = "The second image is a mask: only modify the areas that are transparent in the mask, leave the rest of the first image unchanged."
+
+type imageEditRequest struct {
+	Model       string             `json:"model"`
+	Messages    []imageEditMessage `json:"messages"`
+	Modalities  []string           `json:"modalities"`
+	ImageConfig *imageConfig       `json:"image_config,omitempty"`
+}
+
+type imageConfig struct {
+	AspectRatio string `json:"aspect_ratio,omitempty"`
+}
+
+type imageEditMessage struct {
+	Role    string             `json:"role"`
+	Content []imageEditContent `json:"content"`
+}
+
+type imageEditContent struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+type imageEditResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+			Images  []struct {
+				ImageURL imageURL `json:"image_url"`
+			} `json:"images"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage imageUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+		Code    any    `json:"code"`
+	} `json:"error"`
+}
+
+// ImageEdit implements [llm.ImageEditClient].
+func (c *ImageGenerationClient) ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	opts := llm.NewImageGenerationOptions(funcs...)
+
+	images := [][]byte{image}
+	if len(opts.Mask) > 0 {
+		images = append(images, opts.Mask)
+		prompt += "\n\n" + maskInstruction
+	}
+
+	return c.editImages(ctx, prompt, images, opts)
+}
+
+// ImageVariation implements [llm.ImageEditClient].
+func (c *ImageGenerationClient) ImageVariation(ctx context.Context, image []byte, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	opts := llm.NewImageGenerationOptions(funcs...)
+	return c.editImages(ctx, variationPrompt, [][]byte{image}, opts)
+}
+
+func (c *ImageGenerationClient) editImages(ctx context.Context, prompt string, images [][]byte, opts *llm.ImageGenerationOptions) (llm.ImageGenerationResponse, error) {
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	content := []imageEditContent{{Type: "text", Text: prompt}}
+	for _, img := range images {
+		mediaType := llm.DetectImageMediaType(img)
+		if mediaType == "" {
+			mediaType = "image/png"
+		}
+
+		content = append(content, imageEditContent{
+			Type:     "image_url",
+			ImageURL: &imageURL{URL: "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(img)},
+		})
+	}
+
+	payload := imageEditRequest{
+		Model:      model,
+		Messages:   []imageEditMessage{{Role: "user", Content: content}},
+		Modalities: []string{"image", "text"},
+	}
+	if opts.AspectRatio != "" {
+		payload.ImageConfig = &imageConfig{AspectRatio: opts.AspectRatio}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.chatEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 512<<20))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var parsed imageEditResponse
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, errors.Wrapf(err, "unexpected response (HTTP %d)", resp.StatusCode)
+	}
+
+	if parsed.Error != nil {
+		return nil, errors.Errorf("openrouter error (HTTP %d): %s", resp.StatusCode, parsed.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected HTTP status %d", resp.StatusCode)
+	}
+
+	generated := make([]llm.GeneratedImage, 0)
+	for _, choice := range parsed.Choices {
+		for _, img := range choice.Message.Images {
+			data, mediaType, err := decodeImage(img.ImageURL.URL, "")
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			// Le texte accompagnant l'image tient lieu de prompt révisé.
+			generated = append(generated, llm.NewGeneratedImage(data, mediaType, choice.Message.Content))
+		}
+	}
+
+	if len(generated) == 0 {
+		return nil, errors.New("no image in response")
+	}
+
+	return llm.NewImageGenerationResponse(generated, newImageGenerationUsage(parsed.Usage)), nil
+}
+
+var _ llm.ImageEditClient = &ImageGenerationClient{}
@@ -25,10 +25,11 @@ const imageGenerationEndpoint = "https://openrouter.ai/api/v1/images"
 // d'entrée : le client HTTP est écrit ici directement, sur le format
 // documenté (data[].b64_json + media_type).
 type ImageGenerationClient struct {
-	httpClient *http.Client
-	endpoint   string
-	apiKey     string
-	model      string
+	httpClient   *http.Client
+	endpoint     string
+	chatEndpoint string
+	apiKey       string
+	model        string
 }
 
 type imageGenerationRequest struct {
@@ -44,26 +45,33 @@ type imageGenerationResponse struct {
 		MediaType     string `json:"media_type"`
 		RevisedPrompt string `json:"revised_prompt"`
 	} `json:"data"`
-	Usage struct {
-		PromptTokens     int64 `json:"prompt_tokens"`
-		CompletionTokens int64 `json:"completion_tokens"`
-		TotalTokens      int64 `json:"total_tokens"`
-		// Cost is what OpenRouter charged for the generation, in USD. A
-		// pointer tells "not reported" from "free".
-		Cost *float64 `json:"cost"`
-	} `json:"usage"`
+	Usage imageUsage `json:"usage"`
 	Error *struct {
 		Message string `json:"message"`
 		Code    any    `json:"code"`
 	} `json:"error"`
 }
 
+type imageUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+	// Cost is what OpenRouter charged for the generation, in USD. A
+	// pointer tells "not reported" from "free".
+	Cost *float64 `json:"cost"`
+}
+
 // ImageGeneration implements [llm.ImageGenerationClient].
 func (c *ImageGenerationClient) ImageGeneration(ctx context.Context, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
 	opts := llm.NewImageGenerationOptions(funcs...)
 
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
 	payload := imageGenerationRequest{
-		Model:  c.model,
+		Model:  model,
 		Prompt: prompt,
 		N:      opts.Count,
 		Size:   opts.Size,
@@ -109,57 +117,68 @@ func (c *ImageGenerationClient) ImageGeneration(ctx context.Context, prompt stri
 
 	images := make([]llm.GeneratedImage, 0, len(parsed.Data))
 	for _, img := range parsed.Data {
-		// Certains modèles renvoient un data: URI complet plutôt que du
-		// base64 nu ; les deux formes sont acceptées.
-		b64 := img.B64JSON
-		mediaType := img.MediaType
-		if strings.HasPrefix(b64, "data:") {
-			if idx := strings.Index(b64, ";base64,"); idx > 0 {
-				if mediaType == "" {
-					mediaType = b64[len("data:"):idx]
-				}
-				b64 = b64[idx+len(";base64,"):]
-			}
-		}
-
-		data, err := base64.StdEncoding.DecodeString(b64)
+		data, mediaType, err := decodeImage(img.B64JSON, img.MediaType)
 		if err != nil {
-			return nil, errors.Wrap(err, "could not decode base64 image data")
+			return nil, errors.WithStack(err)
 		}
 
-		if mediaType == "" {
-			mediaType = llm.DetectImageMediaType(data)
-		}
-		if mediaType == "" {
-			mediaType = "image/png"
+		images = append(images, llm.NewGeneratedImage(data, mediaType, img.RevisedPrompt))
+	}
+
+	return llm.NewImageGenerationResponse(images, newImageGenerationUsage(parsed.Usage)), nil
+}
+
+// decodeImage décode une image base64. Certains modèles renvoient un data:
+// URI complet plutôt que du base64 nu ; les deux formes sont acceptées.
+func decodeImage(b64 string, mediaType string) ([]byte, string, error) {
+	if strings.HasPrefix(b64, "data:") {
+		if idx := strings.Index(b64, ";base64,"); idx > 0 {
+			if mediaType == "" {
+				mediaType = b64[len("data:"):idx]
+			}
+			b64 = b64[idx+len(";base64,"):]
 		}
+	}
 
-		images = append(images, llm.NewGeneratedImage(data, mediaType, img.RevisedPrompt))
+	data, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "could not decode base64 image data")
 	}
 
-	var usage llm.ImageGenerationUsage = llm.NewImageGenerationUsage(parsed.Usage.PromptTokens, parsed.Usage.CompletionTokens, parsed.Usage.TotalTokens)
-	if parsed.Usage.Cost != nil && *parsed.Usage.Cost >= 0 {
-		usage = llm.NewImageGenerationUsageWithCost(
-			parsed.Usage.PromptTokens, parsed.Usage.CompletionTokens, parsed.Usage.TotalTokens,
-			*parsed.Usage.Cost, "USD", // OpenRouter always reports cost in USD
+	if mediaType == "" {
+		mediaType = llm.DetectImageMediaType(data)
+	}
+	if mediaType == "" {
+		mediaType = "image/png"
+	}
+
+	return data, mediaType, nil
+}
+
+func newImageGenerationUsage(usage imageUsage) llm.ImageGenerationUsage {
+	if usage.Cost != nil && *usage.Cost >= 0 {
+		return llm.NewImageGenerationUsageWithCost(
+			usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens,
+			*usage.Cost, "USD", // OpenRouter always reports cost in USD
 		)
 	}
 
-	return llm.NewImageGenerationResponse(images, usage), nil
+	return llm.NewImageGenerationUsage(usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens)
 }
 
 // NewImageGenerationClient construit le client. baseURL vide vaut le service
 // public ; sinon elle doit pointer la racine de l'API (".../api/v1"), le
-// suffixe "/images" est ajouté ici.
+// suffixe "/images" (et "/chat/completions" pour l'édition) est ajouté ici.
 func NewImageGenerationClient(httpClient *http.Client, baseURL, apiKey, model string) *ImageGenerationClient {
-	endpoint := imageGenerationEndpoint
+	endpoint, chatEndpoint := imageGenerationEndpoint, imageEditEndpoint
 	if baseURL != "" {
 		endpoint = strings.TrimSuffix(baseURL, "/") + "/images"
+		chatEndpoint = strings.TrimSuffix(baseURL, "/") + "/chat/completions"
 	}
 	if httpClient == nil {
 		httpClient = http.DefaultClient
 	}
-	return &ImageGenerationClient{httpClient: httpClient, endpoint: endpoint, apiKey: apiKey, model: model}
+	return &ImageGenerationClient{httpClient: httpClient, endpoint: endpoint, chatEndpoint: chatEndpoint, apiKey: apiKey, model: model}
 }
 
 var _ llm.ImageGenerationClient = &ImageGenerationClient{}
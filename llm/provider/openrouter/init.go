@@ -15,8 +15,12 @@ func init() {
 		Name,
 		defaultOptions,
 		func(ctx context.Context, opts *Options) (llm.ChatCompletionClient, error) {
-			client := openrouter.NewClient(opts.APIKey)
-			return NewChatCompletionClient(client, opts.Model), nil
+			client := NewClient(opts.APIKey, opts.BaseURL)
+			return NewChatCompletionClient(
+				client, opts.Model,
+				WithDefaultProviderPreferences(opts.Provider),
+				WithDefaultPlugins(opts.plugins()...),
+			), nil
 		},
 	)
 
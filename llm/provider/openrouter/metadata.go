@@ -0,0 +1,82 @@
+package openrouter
+
+import (
+	"github.com/bornholm/genai/llm"
+	"github.com/revrost/go-openrouter"
+)
+
+// Metadata décrit comment OpenRouter a servi un appel. Les sources citées
+// par la réponse, notamment avec le plugin web ou les modèles ":online", sont
+// portées par son message, voir [llm.CitationsOf].
+type Metadata struct {
+	// Provider est le fournisseur amont ayant servi l'appel (ex: "DeepInfra").
+	Provider string
+}
+
+// MetadataReporter est implémentée par les réponses du client de chat
+// completion et par le dernier chunk de ses streams.
+type MetadataReporter interface {
+	Metadata() Metadata
+}
+
+// MetadataOf retourne les métadonnées OpenRouter d'une réponse ou d'un chunk
+// de stream, ok valant false s'ils n'en portent pas (autre provider, ou
+// réponse reconstruite par un wrapper).
+func MetadataOf(v any) (Metadata, bool) {
+	reporter, ok := v.(MetadataReporter)
+	if !ok {
+		return Metadata{}, false
+	}
+
+	return reporter.Metadata(), true
+}
+
+type metadataResponse struct {
+	*llm.BaseCandidatesResponse
+	metadata Metadata
+}
+
+// Metadata implements MetadataReporter.
+func (r *metadataResponse) Metadata() Metadata {
+	return r.metadata
+}
+
+type metadataStreamChunk struct {
+	*llm.BaseStreamChunk
+	metadata Metadata
+}
+
+// Metadata implements MetadataReporter.
+func (c *metadataStreamChunk) Metadata() Metadata {
+	return c.metadata
+}
+
+var (
+	_ MetadataReporter           = &metadataResponse{}
+	_ llm.CandidatesResponse     = &metadataResponse{}
+	_ MetadataReporter           = &metadataStreamChunk{}
+	_ llm.StreamChunk            = &metadataStreamChunk{}
+	_ llm.ChatCompletionResponse = &metadataResponse{}
+)
+
+// toCitations extrait les annotations url_citation d'un message ou d'un
+// delta
+func toCitations(annotations []openrouter.Annotation) []llm.Citation {
+	citations := make([]llm.Citation, 0, len(annotations))
+
+	for _, a := range annotations {
+		if a.Type != openrouter.AnnotationTypeUrlCitation {
+			continue
+		}
+
+		citations = append(citations, llm.Citation{
+			URL:        a.URLCitation.URL,
+			Title:      a.URLCitation.Title,
+			Content:    a.URLCitation.Content,
+			StartIndex: a.URLCitation.StartIndex,
+			EndIndex:   a.URLCitation.EndIndex,
+		})
+	}
+
+	return citations
+}
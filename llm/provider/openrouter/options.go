@@ -5,6 +5,42 @@ import "github.com/bornholm/genai/llm/provider"
 // Options contient les options de configuration du provider OpenRouter.
 type Options struct {
 	provider.CommonOptions
+	// Provider contient les préférences de routage appliquées aux appels de
+	// chat completion (ex: PROVIDER_ORDER, PROVIDER_MAX_PRICE_PROMPT).
+	Provider ProviderPreferences `envPrefix:"PROVIDER_"`
+	// Plugins liste les plugins activés pour les appels de chat completion
+	// (ex: "web,file-parser").
+	Plugins []PluginID `env:"PLUGINS"`
+	// WebMaxResults, WebSearchPrompt et WebEngine configurent le plugin web.
+	WebMaxResults   int    `env:"WEB_MAX_RESULTS"`
+	WebSearchPrompt string `env:"WEB_SEARCH_PROMPT"`
+	WebEngine       string `env:"WEB_ENGINE"`
+	// PDFEngine configure le plugin file-parser.
+	PDFEngine string `env:"PDF_ENGINE"`
+}
+
+// plugins retourne la configuration des plugins activés
+func (o *Options) plugins() []Plugin {
+	plugins := make([]Plugin, 0, len(o.Plugins))
+
+	for _, id := range o.Plugins {
+		plugin := Plugin{ID: id}
+
+		switch id {
+		case PluginIDWeb:
+			plugin.MaxResults = o.WebMaxResults
+			plugin.SearchPrompt = o.WebSearchPrompt
+			plugin.Engine = o.WebEngine
+		case PluginIDFileParser:
+			if o.PDFEngine != "" {
+				plugin.PDF = &PDFPluginOptions{Engine: o.PDFEngine}
+			}
+		}
+
+		plugins = append(plugins, plugin)
+	}
+
+	return plugins
 }
 
 func defaultOptions() *Options {
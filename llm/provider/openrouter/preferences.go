@@ -0,0 +1,95 @@
+package openrouter
+
+// ProviderPreferences décrit le routage d'un appel entre les fournisseurs
+// amont d'un modèle, voir https://openrouter.ai/docs/features/provider-routing.
+//
+// Les champs sont ceux de l'objet "provider" de l'API ; les tags env
+// permettent de les renseigner par client (préfixe PROVIDER_, voir [Options]).
+type ProviderPreferences struct {
+	// Order liste les fournisseurs à essayer en priorité, dans l'ordre.
+	Order []string `json:"order,omitempty" env:"ORDER"`
+	// AllowFallbacks autorise le repli sur les autres fournisseurs (vrai par
+	// défaut côté OpenRouter).
+	AllowFallbacks *bool `json:"allow_fallbacks,omitempty" env:"ALLOW_FALLBACKS"`
+	// RequireParameters restreint le routage aux fournisseurs supportant tous
+	// les paramètres de la requête.
+	RequireParameters *bool `json:"require_parameters,omitempty" env:"REQUIRE_PARAMETERS"`
+	// DataCollection exclut, avec DataCollectionDeny, les fournisseurs
+	// conservant les données des requêtes.
+	DataCollection DataCollection `json:"data_collection,omitempty" env:"DATA_COLLECTION"`
+	// ZDR restreint le routage aux fournisseurs sans rétention de données.
+	ZDR *bool `json:"zdr,omitempty" env:"ZDR"`
+	// Only liste les seuls fournisseurs autorisés.
+	Only []string `json:"only,omitempty" env:"ONLY"`
+	// Ignore liste les fournisseurs à ne pas utiliser.
+	Ignore []string `json:"ignore,omitempty" env:"IGNORE"`
+	// Quantizations restreint le routage aux niveaux de quantification
+	// donnés (ex: "fp8", "int4").
+	Quantizations []string `json:"quantizations,omitempty" env:"QUANTIZATIONS"`
+	// Sort trie les fournisseurs par prix, débit ou latence, désactivant
+	// l'équilibrage de charge.
+	Sort ProviderSort `json:"sort,omitempty" env:"SORT"`
+	// MaxPrice plafonne le prix accepté, en USD par million de tokens.
+	MaxPrice MaxPrice `json:"max_price,omitzero" envPrefix:"MAX_PRICE_"`
+}
+
+// IsZero indique si aucune préférence n'est définie.
+func (p ProviderPreferences) IsZero() bool {
+	return len(p.Order) == 0 && p.AllowFallbacks == nil && p.RequireParameters == nil &&
+		p.DataCollection == "" && p.ZDR == nil && len(p.Only) == 0 && len(p.Ignore) == 0 &&
+		len(p.Quantizations) == 0 && p.Sort == "" && p.MaxPrice == (MaxPrice{})
+}
+
+type DataCollection string
+
+const (
+	DataCollectionAllow DataCollection = "allow"
+	DataCollectionDeny  DataCollection = "deny"
+)
+
+type ProviderSort string
+
+const (
+	ProviderSortPrice      ProviderSort = "price"
+	ProviderSortThroughput ProviderSort = "throughput"
+	ProviderSortLatency    ProviderSort = "latency"
+)
+
+// MaxPrice plafonne le prix d'un appel, en USD par million de tokens pour
+// Prompt et Completion, par requête ou par image pour Request et Image. Une
+// valeur nulle n'impose pas de plafond.
+type MaxPrice struct {
+	Prompt     float64 `json:"prompt,omitempty" env:"PROMPT"`
+	Completion float64 `json:"completion,omitempty" env:"COMPLETION"`
+	Request    float64 `json:"request,omitempty" env:"REQUEST"`
+	Image      float64 `json:"image,omitempty" env:"IMAGE"`
+}
+
+// PluginID identifie un plugin OpenRouter.
+type PluginID string
+
+const (
+	// PluginIDWeb ajoute au prompt des résultats de recherche web, cités par
+	// des annotations url_citation dans la réponse.
+	PluginIDWeb PluginID = "web"
+	// PluginIDFileParser convertit les PDF joints aux messages.
+	PluginIDFileParser PluginID = "file-parser"
+)
+
+// Plugin active un plugin pour un appel, voir
+// https://openrouter.ai/docs/features/web-search.
+type Plugin struct {
+	ID PluginID `json:"id"`
+	// MaxResults, SearchPrompt et Engine configurent le plugin web.
+	MaxResults   int    `json:"max_results,omitempty"`
+	SearchPrompt string `json:"search_prompt,omitempty"`
+	Engine       string `json:"engine,omitempty"`
+	// PDF configure le plugin file-parser.
+	PDF *PDFPluginOptions `json:"pdf,omitempty"`
+}
+
+// PDFPluginOptions sélectionne le moteur d'extraction des PDF ("pdf-text",
+// "mistral-ocr" ou "native").
+type PDFPluginOptions struct {
+	Engine string `json:"engine"`
+}
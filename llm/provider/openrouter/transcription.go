@@ -17,11 +17,16 @@ type TranscriptionClient struct {
 
 // Transcription implements [llm.TranscriptionClient].
 func (c *TranscriptionClient) Transcription(ctx context.Context, audio []byte, funcs ...llm.TranscriptionOptionFunc) (llm.TranscriptionResponse, error) {
-	if c.model == "" {
-		return nil, errors.WithStack(llm.ErrUnavailable)
+	opts := llm.NewTranscriptionOptions(funcs...)
+
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
 	}
 
-	opts := llm.NewTranscriptionOptions(funcs...)
+	if model == "" {
+		return nil, errors.WithStack(llm.ErrUnavailable)
+	}
 
 	format := opts.Format
 	if format == "" {
@@ -32,7 +37,7 @@ func (c *TranscriptionClient) Transcription(ctx context.Context, audio []byte, f
 	}
 
 	req := openrouter.TranscriptionRequest{
-		Model:      c.model,
+		Model:      model,
 		InputAudio: openrouter.NewTranscriptionInputAudio(audio, openrouter.AudioFormat(format)),
 	}
 
@@ -28,6 +28,7 @@ type Options struct {
 	Embeddings      *ResolvedClientOptions
 	Transcription   *ResolvedClientOptions
 	ImageGeneration *ResolvedClientOptions
+	Completion      *ResolvedClientOptions
 }
 
 // Validator est une interface optionnelle que les structs d'options peuvent implémenter.
@@ -134,3 +135,24 @@ func WithImageGeneration[T any](name Name, opts T) OptionFunc {
 		return nil
 	}
 }
+
+// WithCompletion returns an OptionFunc that configures raw text completion
+// (fill-in-the-middle) options for a specific provider. The opts value is
+// copied to ensure immutability of the original.
+//
+// Example:
+//
+//	client, err := provider.Create(ctx,
+//	    provider.WithCompletion("mistral", mistral.Options{
+//	        Model: "codestral-latest",
+//	    }),
+//	)
+func WithCompletion[T any](name Name, opts T) OptionFunc {
+	return func(o *Options) error {
+		o.Completion = &ResolvedClientOptions{
+			Provider: name,
+			Specific: &opts,
+		}
+		return nil
+	}
+}
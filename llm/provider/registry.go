@@ -31,6 +31,7 @@ type Registry struct {
 	embeddingsEntries      map[Name]providerEntry
 	transcriptionEntries   map[Name]providerEntry
 	imageGenerationEntries map[Name]providerEntry
+	completionEntries      map[Name]providerEntry
 }
 
 // RegisterChatCompletion enregistre un provider de chat completion dans le registry global.
@@ -91,6 +92,21 @@ func RegisterImageGeneration[T any](
 	}
 }
 
+// RegisterCompletion enregistre un provider de complétion de texte brut
+// (fill-in-the-middle) dans le registry global.
+func RegisterCompletion[T any](
+	name Name,
+	newOptions func() *T,
+	factory func(ctx context.Context, opts *T) (llm.CompletionClient, error),
+) {
+	defaultRegistry.completionEntries[name] = providerEntry{
+		newOptions: func() any { return newOptions() },
+		createClient: func(ctx context.Context, opts any) (any, error) {
+			return factory(ctx, opts.(*T))
+		},
+	}
+}
+
 // NewImageGenerationProviderOptions retourne une instance d'options (avec les defaults)
 // pour le provider de génération d'images donné, ou nil si le provider n'est pas enregistré.
 func NewImageGenerationProviderOptions(name Name) any {
@@ -127,6 +143,15 @@ func NewTranscriptionProviderOptions(name Name) any {
 	return nil
 }
 
+// NewCompletionProviderOptions retourne une instance d'options (avec les defaults)
+// pour le provider de complétion donné, ou nil si le provider n'est pas enregistré.
+func NewCompletionProviderOptions(name Name) any {
+	if entry, ok := defaultRegistry.completionEntries[name]; ok {
+		return entry.newOptions()
+	}
+	return nil
+}
+
 // Create crée un llm.Client à partir des options résolues.
 func (r *Registry) Create(ctx context.Context, funcs ...OptionFunc) (llm.Client, error) {
 	opts, err := NewOptions(funcs...)
@@ -154,11 +179,19 @@ func (r *Registry) Create(ctx context.Context, funcs ...OptionFunc) (llm.Client,
 		return nil, errors.WithStack(err)
 	}
 
-	if chatCompletion == nil && embeddings == nil && transcription == nil && imageGeneration == nil {
+	completion, err := createClientFromResolved[llm.CompletionClient](ctx, opts.Completion, r.completionEntries)
+	if err != nil && !errors.Is(err, ErrNotConfigured) {
+		return nil, errors.WithStack(err)
+	}
+
+	if chatCompletion == nil && embeddings == nil && transcription == nil && imageGeneration == nil && completion == nil {
 		return nil, errors.WithStack(ErrNotConfigured)
 	}
 
-	return NewClientWithImageGeneration(chatCompletion, embeddings, transcription, imageGeneration), nil
+	client := NewClientWithImageGeneration(chatCompletion, embeddings, transcription, imageGeneration)
+	client.completion = completion
+
+	return client, nil
 }
 
 // createClientFromResolved crée un client T à partir des options résolues.
@@ -204,6 +237,7 @@ func newRegistry() *Registry {
 		embeddingsEntries:      map[Name]providerEntry{},
 		transcriptionEntries:   map[Name]providerEntry{},
 		imageGenerationEntries: map[Name]providerEntry{},
+		completionEntries:      map[Name]providerEntry{},
 	}
 }
 
@@ -1,6 +1,7 @@
 package yzma
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -11,9 +12,11 @@ import (
 	"sync"
 
 	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/provider/yzma/models"
 	"github.com/hybridgroup/yzma/pkg/download"
 	"github.com/hybridgroup/yzma/pkg/llama"
 	"github.com/hybridgroup/yzma/pkg/message"
+	"github.com/hybridgroup/yzma/pkg/mtmd"
 	"github.com/hybridgroup/yzma/pkg/template"
 	"github.com/pkg/errors"
 )
@@ -21,6 +24,8 @@ import (
 type ChatCompletionClient struct {
 	modelPath       string
 	modelURL        string
+	mmprojPath      string
+	mmprojURL       string
 	libPath         string
 	processor       string
 	version         string
@@ -36,13 +41,17 @@ type ChatCompletionClient struct {
 	predictSize     int
 	template        string
 	verbose         bool
+	slotCount       int
 
 	// Runtime state
 	mu     sync.Mutex
 	model  llama.Model
 	vocab  llama.Vocab
 	lctx   llama.Context
+	mctx   mtmd.Context
 	loaded bool
+	slots  []*slot
+	uses   uint64
 }
 
 // ChatCompletion implements llm.ChatCompletionClient.
@@ -59,97 +68,176 @@ func (c *ChatCompletionClient) ChatCompletion(ctx context.Context, funcs ...llm.
 		return nil, errors.WithStack(err)
 	}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	// Clear KV cache before starting new generation
-	if err := c.clearMemory(); err != nil {
+	images, err := c.promptImages(opts.Messages)
+	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
+	session, _ := ContextSession(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	// Build prompt with tools support
 	prompt, err := c.buildPrompt(opts)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	// Tokenize
-	tokens := llama.Tokenize(c.vocab, prompt, true, true)
-
-	// Decode prompt tokens in batches to handle large prompts
-	if err := c.decodePromptTokens(tokens); err != nil {
+	slot, err := c.acquireSlot(ctx, session)
+	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	// Create sampler
-	sp := llama.DefaultSamplerParams()
-	sp.Temp = float32(opts.Temperature)
-	sp.TopK = int32(c.topK)
-	sp.TopP = float32(c.topP)
-	sp.MinP = float32(c.minP)
-	sp.PenaltyPresent = float32(c.presencePenalty)
-	sp.PenaltyLastN = int32(c.penaltyLastN)
-
-	samplers := []llama.SamplerType{
-		llama.SamplerTypePenalties,
-		llama.SamplerTypeTopK,
-		llama.SamplerTypeTopP,
-		llama.SamplerTypeMinP,
-		llama.SamplerTypeTemperature,
+	// Tokenize
+	input, err := c.tokenize(prompt, images)
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
-	sampler := llama.NewSampler(c.model, samplers, sp)
+	defer input.Free()
 
-	// Generate response
-	response := ""
 	maxTokens := c.predictSize
 	if opts.MaxCompletionTokens != nil {
 		maxTokens = *opts.MaxCompletionTokens
 	}
 
+	count := max(opts.Candidates, 1)
+	candidates := make([]llm.Candidate, 0, count)
+
+	var completionTokens int64
+
+	for index := range count {
+		// Only the tokens following the prefix already in the KV cache are
+		// decoded, the candidates after the first one reusing the whole prompt
+		if err := c.prefillInput(ctx, slot, input); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		response, logprobs, finishReason, err := c.generate(ctx, opts, slot, maxTokens, nil)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		// Strip think blocks before parsing tool calls so we only see real tool calls
+		responseWithoutThink := stripThinkBlocks(response)
+
+		// Parse tool calls from response
+		toolCalls := c.parseToolCalls(responseWithoutThink)
+		if len(toolCalls) > 0 {
+			finishReason = llm.FinishReasonToolCalls
+		}
+
+		// Clean response content (remove tool call markers for the message content)
+		cleanResponse := c.cleanResponse(response)
+
+		// Create response message
+		message := llm.NewMessage(llm.RoleAssistant, cleanResponse)
+
+		candidates = append(candidates, llm.NewCandidate(index, message, finishReason, logprobs, toolCalls...))
+
+		completionTokens += int64(len(response) / 4) // Rough estimate
+	}
+
+	// Note: yzma doesn't provide token usage directly, so we estimate
+	promptTokens := input.promptTokens
+	totalTokens := promptTokens + completionTokens
+
+	usage := llm.NewChatCompletionUsageWithImages(promptTokens, completionTokens, totalTokens, input.imageTokens)
+
+	first := candidates[0]
+	res := llm.NewChatCompletionResponse(first.Message(), usage, first.ToolCalls()...)
+
+	if opts.Candidates <= 1 && !opts.Logprobs {
+		return res, nil
+	}
+
+	return llm.NewCandidatesResponse(res, candidates...), nil
+}
+
+// generate samples tokens from the prompt decoded in the slot until the end
+// of generation or maxTokens, computing their log-probabilities when requested.
+// The generated text is passed to emit, when not nil, as it is generated.
+func (c *ChatCompletionClient) generate(ctx context.Context, opts *llm.ChatCompletionOptions, slot *slot, maxTokens int, emit func(text string)) (string, []llm.TokenLogprob, llm.FinishReason, error) {
+	sampler, err := c.newConstrainedSampler(opts)
+	if err != nil {
+		return "", nil, "", errors.WithStack(err)
+	}
+	defer sampler.Free()
+
+	nVocab := int(llama.VocabNTokens(c.vocab))
+	stop := &stopMatcher{sequences: opts.Stop}
+
+	var (
+		response string
+		logprobs []llm.TokenLogprob
+	)
+
+	push := func(text string) {
+		response += text
+		if emit != nil && text != "" {
+			emit(text)
+		}
+	}
+
 	for pos := int32(0); pos < int32(maxTokens); pos++ {
 		select {
 		case <-ctx.Done():
-			return nil, errors.WithStack(ctx.Err())
+			return "", nil, "", errors.WithStack(ctx.Err())
 		default:
 		}
 
-		token := llama.SamplerSample(sampler, c.lctx, -1)
+		// The logits must be read before sampling, the sampler modifying them
+		var logits []float32
+		if opts.Logprobs {
+			raw, err := llama.GetLogitsIth(c.lctx, -1, nVocab)
+			if err != nil {
+				return "", nil, "", errors.Wrap(err, "failed to get logits")
+			}
 
-		if llama.VocabIsEOG(c.vocab, token) {
-			break
+			logits = append(logits, raw...)
 		}
 
-		tokenBuf := make([]byte, 256)
-		l := llama.TokenToPiece(c.vocab, token, tokenBuf, 0, false)
-		response += string(tokenBuf[:l])
+		token := sampler.Sample(c.lctx)
 
-		// Decode the generated token
-		batch := llama.BatchGetOne([]llama.Token{token})
-		if _, err := llama.Decode(c.lctx, batch); err != nil {
-			return nil, errors.Wrap(err, "failed to decode token")
+		if llama.VocabIsEOG(c.vocab, token) {
+			push(stop.Flush())
+			return response, logprobs, llm.FinishReasonStop, nil
 		}
-	}
 
-	// Strip think blocks before parsing tool calls so we only see real tool calls
-	responseWithoutThink := stripThinkBlocks(response)
+		raw := c.tokenPiece(token)
+		if err := sampler.Push(raw); err != nil {
+			return "", nil, "", errors.WithStack(err)
+		}
 
-	// Parse tool calls from response
-	toolCalls := c.parseToolCalls(responseWithoutThink)
+		piece, stopped := stop.Push(raw)
+		push(piece)
 
-	// Clean response content (remove tool call markers for the message content)
-	cleanResponse := c.cleanResponse(response)
+		if opts.Logprobs {
+			logprobs = append(logprobs, computeLogprob(logits, int32(token), opts.TopLogprobs, func(t int32) string {
+				return c.tokenPiece(llama.Token(t))
+			}))
+		}
 
-	// Create response message
-	message := llm.NewMessage(llm.RoleAssistant, cleanResponse)
+		if stopped {
+			return response, logprobs, llm.FinishReasonStop, nil
+		}
 
-	// Note: yzma doesn't provide token usage directly, so we estimate
-	promptTokens := int64(len(tokens))
-	completionTokens := int64(len(response) / 4) // Rough estimate
-	totalTokens := promptTokens + completionTokens
+		// Decode the generated token
+		if err := c.decode(slot, []llama.Token{token}); err != nil {
+			return "", nil, "", errors.WithStack(err)
+		}
+	}
 
-	usage := llm.NewChatCompletionUsage(promptTokens, completionTokens, totalTokens)
+	push(stop.Flush())
 
-	return llm.NewChatCompletionResponse(message, usage, toolCalls...), nil
+	return response, logprobs, llm.FinishReasonLength, nil
+}
+
+// tokenPiece returns the text of the token
+func (c *ChatCompletionClient) tokenPiece(token llama.Token) string {
+	tokenBuf := make([]byte, 256)
+	l := llama.TokenToPiece(c.vocab, token, tokenBuf, 0, false)
+	return string(tokenBuf[:l])
 }
 
 // ChatCompletionStream implements llm.ChatCompletionStreamingClient.
@@ -166,6 +254,13 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 		return nil, errors.WithStack(err)
 	}
 
+	images, err := c.promptImages(opts.Messages)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	session, _ := ContextSession(ctx)
+
 	chunks := make(chan llm.StreamChunk, 10)
 
 	go func() {
@@ -174,8 +269,8 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 		c.mu.Lock()
 		defer c.mu.Unlock()
 
-		// Clear KV cache before starting new generation
-		if err := c.clearMemory(); err != nil {
+		slot, err := c.acquireSlot(ctx, session)
+		if err != nil {
 			chunks <- llm.NewErrorStreamChunk(errors.WithStack(err))
 			return
 		}
@@ -188,31 +283,28 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 		}
 
 		// Tokenize
-		tokens := llama.Tokenize(c.vocab, prompt, true, true)
-
-		// Decode prompt tokens in batches to handle large prompts
-		if err := c.decodePromptTokens(tokens); err != nil {
+		input, err := c.tokenize(prompt, images)
+		if err != nil {
 			chunks <- llm.NewErrorStreamChunk(errors.WithStack(err))
 			return
 		}
+		defer input.Free()
 
-		// Create sampler
-		sp := llama.DefaultSamplerParams()
-		sp.Temp = float32(opts.Temperature)
-		sp.TopK = int32(c.topK)
-		sp.TopP = float32(c.topP)
-		sp.MinP = float32(c.minP)
-		sp.PenaltyPresent = float32(c.presencePenalty)
-		sp.PenaltyLastN = int32(c.penaltyLastN)
+		// Only the tokens following the prefix already in the KV cache are
+		// decoded
+		if err := c.prefillInput(ctx, slot, input); err != nil {
+			chunks <- llm.NewErrorStreamChunk(errors.WithStack(err))
+			return
+		}
 
-		samplers := []llama.SamplerType{
-			llama.SamplerTypePenalties,
-			llama.SamplerTypeTopK,
-			llama.SamplerTypeTopP,
-			llama.SamplerTypeMinP,
-			llama.SamplerTypeTemperature,
+		sampler, err := c.newConstrainedSampler(opts)
+		if err != nil {
+			chunks <- llm.NewErrorStreamChunk(errors.WithStack(err))
+			return
 		}
-		sampler := llama.NewSampler(c.model, samplers, sp)
+		defer sampler.Free()
+
+		stop := &stopMatcher{sequences: opts.Stop}
 
 		// Generate response with streaming
 		maxTokens := c.predictSize
@@ -234,15 +326,19 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 			default:
 			}
 
-			token := llama.SamplerSample(sampler, c.lctx, -1)
+			token := sampler.Sample(c.lctx)
 
 			if llama.VocabIsEOG(c.vocab, token) {
 				break
 			}
 
-			tokenBuf := make([]byte, 256)
-			l := llama.TokenToPiece(c.vocab, token, tokenBuf, 0, false)
-			content := string(tokenBuf[:l])
+			raw := c.tokenPiece(token)
+			if err := sampler.Push(raw); err != nil {
+				chunks <- llm.NewErrorStreamChunk(errors.WithStack(err))
+				return
+			}
+
+			content, stopped := stop.Push(raw)
 			completionTokens++
 
 			// Suppress think blocks from the stream.
@@ -267,22 +363,30 @@ func (c *ChatCompletionClient) ChatCompletionStream(ctx context.Context, funcs .
 						inThink = false
 						thinkBuf = ""
 					}
-				} else {
+				} else if pending != "" {
 					chunks <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, pending))
 				}
 			}
 
+			if stopped {
+				break
+			}
+
 			// Decode the generated token
-			batch := llama.BatchGetOne([]llama.Token{token})
-			if _, err := llama.Decode(c.lctx, batch); err != nil {
-				chunks <- llm.NewErrorStreamChunk(errors.Wrap(err, "failed to decode token"))
+			if err := c.decode(slot, []llama.Token{token}); err != nil {
+				chunks <- llm.NewErrorStreamChunk(errors.WithStack(err))
 				return
 			}
 		}
 
+		// Emit the text held back by the stop sequences detection
+		if rest := stop.Flush(); rest != "" && !inThink {
+			chunks <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, rest))
+		}
+
 		// Send completion chunk
-		promptTokens = int64(len(tokens))
-		usage := llm.NewChatCompletionUsage(promptTokens, completionTokens, promptTokens+completionTokens)
+		promptTokens = input.promptTokens
+		usage := llm.NewChatCompletionUsageWithImages(promptTokens, completionTokens, promptTokens+completionTokens, input.imageTokens)
 		chunks <- llm.NewCompleteStreamChunk(usage)
 	}()
 
@@ -332,69 +436,39 @@ func (c *ChatCompletionClient) ensureLoaded() error {
 	ctxParams.NBatch = uint32(c.batchSize)
 	ctxParams.NUbatch = uint32(c.uBatchSize)
 
+	// Each slot is a sequence of the context, sharing its whole size
+	ctxParams.NSeqMax = uint32(c.slotCount)
+	if c.slotCount > 1 {
+		ctxParams.KVUnified = 1
+	}
+
 	lctx, err := llama.InitFromModel(model, ctxParams)
 	if err != nil {
 		llama.ModelFree(model)
 		return errors.Wrap(err, "unable to initialize context from model")
 	}
 
-	c.lctx = lctx
-	c.loaded = true
-
-	return nil
-}
-
-// clearMemory clears the KV cache memory
-func (c *ChatCompletionClient) clearMemory() error {
-	if c.lctx == 0 {
-		return nil
-	}
-
-	mem, err := llama.GetMemory(c.lctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to get memory")
-	}
-
-	if mem != 0 {
-		if err := llama.MemoryClear(mem, true); err != nil {
-			return errors.Wrap(err, "failed to clear memory")
+	// Load the multimodal projector of the vision models
+	if c.mmprojPath != "" {
+		mctx, err := c.loadProjector(model)
+		if err != nil {
+			llama.Free(lctx)
+			llama.ModelFree(model)
+			return errors.WithStack(err)
 		}
-	}
 
-	return nil
-}
-
-// decodePromptTokens decodes prompt tokens in batches to handle large prompts
-func (c *ChatCompletionClient) decodePromptTokens(tokens []llama.Token) error {
-	// Handle encoder models
-	if llama.ModelHasEncoder(c.model) {
-		batch := llama.BatchGetOne(tokens)
-		llama.Encode(c.lctx, batch)
-		start := llama.ModelDecoderStartToken(c.model)
-		if start == llama.TokenNull {
-			start = llama.VocabBOS(c.vocab)
-		}
-		batch = llama.BatchGetOne([]llama.Token{start})
-		_, err := llama.Decode(c.lctx, batch)
-		return err
+		c.mctx = mctx
 	}
 
-	// Split tokens into chunks that fit within batch size
-	batchSize := int32(c.batchSize)
-	for i := int32(0); i < int32(len(tokens)); i += batchSize {
-		end := i + batchSize
-		if end > int32(len(tokens)) {
-			end = int32(len(tokens))
-		}
-
-		chunk := tokens[i:end]
-		batch := llama.BatchGetOne(chunk)
+	c.lctx = lctx
 
-		if _, err := llama.Decode(c.lctx, batch); err != nil {
-			return errors.Wrapf(err, "failed to decode prompt tokens at position %d", i)
-		}
+	c.slots = make([]*slot, c.slotCount)
+	for i := range c.slots {
+		c.slots[i] = &slot{id: llama.SeqId(i)}
 	}
 
+	c.loaded = true
+
 	return nil
 }
 
@@ -407,6 +481,11 @@ func (c *ChatCompletionClient) Close() {
 		return
 	}
 
+	if c.mctx != 0 {
+		mtmd.Free(c.mctx)
+		c.mctx = 0
+	}
+
 	if c.lctx != 0 {
 		llama.Free(c.lctx)
 		c.lctx = 0
@@ -417,6 +496,7 @@ func (c *ChatCompletionClient) Close() {
 		c.model = 0
 	}
 
+	c.slots = nil
 	c.loaded = false
 }
 
@@ -596,6 +676,9 @@ func (c *ChatCompletionClient) convertToYzmaMessages(messages []llm.Message) []m
 			continue
 		}
 
+		// The images are inserted at the media markers of the prompt
+		content = withMediaMarkers(content, len(msg.Attachments()))
+
 		// Handle tool message
 		if msg.Role() == llm.RoleTool {
 			result = append(result, message.ToolResponse{
@@ -640,6 +723,10 @@ func (c *ChatCompletionClient) convertTools(tools []llm.Tool) []map[string]any {
 
 // parseToolCalls parses tool calls from the model response
 func (c *ChatCompletionClient) parseToolCalls(response string) []llm.ToolCall {
+	if toolCalls, ok := decodeToolCalls(response); ok {
+		return toolCalls
+	}
+
 	toolCalls := message.ParseToolCalls(response)
 
 	result := make([]llm.ToolCall, 0, len(toolCalls))
@@ -675,6 +762,47 @@ func (c *ChatCompletionClient) parseToolCalls(response string) []llm.ToolCall {
 	return result
 }
 
+// decodeToolCalls decodes the JSON objects enclosed by the tool call
+// markers, as generated under the tool call grammar. ok is false when the
+// response holds no tool call or one of them is not valid JSON.
+func decodeToolCalls(response string) ([]llm.ToolCall, bool) {
+	var result []llm.ToolCall
+
+	for {
+		start := strings.Index(response, toolCallOpen)
+		if start == -1 {
+			break
+		}
+
+		response = response[start+len(toolCallOpen):]
+
+		end := strings.Index(response, toolCallClose)
+		if end == -1 {
+			return nil, false
+		}
+
+		var call struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal([]byte(response[:end]), &call); err != nil || call.Name == "" {
+			return nil, false
+		}
+
+		arguments := "{}"
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, call.Arguments); err == nil && compact.String() != "null" {
+			arguments = compact.String()
+		}
+
+		result = append(result, llm.NewToolCall(fmt.Sprintf("call_%d", len(result)), call.Name, arguments))
+
+		response = response[end+len(toolCallClose):]
+	}
+
+	return result, len(result) > 0
+}
+
 // stripThinkBlocks removes <think>...</think> reasoning blocks from a response.
 func stripThinkBlocks(s string) string {
 	for {
@@ -730,6 +858,24 @@ func WithModelURL(url string) OptionFunc {
 	}
 }
 
+// WithMMProjPath sets the path to the GGUF multimodal projector file of
+// vision models (LLaVA, Qwen-VL, Gemma 3...), enabling image attachments
+func WithMMProjPath(path string) OptionFunc {
+	return func(c *ChatCompletionClient) error {
+		c.mmprojPath = path
+		return nil
+	}
+}
+
+// WithMMProjURL sets a URL to download the GGUF multimodal projector file from
+// if not already present locally.
+func WithMMProjURL(url string) OptionFunc {
+	return func(c *ChatCompletionClient) error {
+		c.mmprojURL = url
+		return nil
+	}
+}
+
 // WithLibPath sets the path to the llama.cpp library directory
 func WithLibPath(path string) OptionFunc {
 	return func(c *ChatCompletionClient) error {
@@ -842,6 +988,18 @@ func WithTemplate(template string) OptionFunc {
 	}
 }
 
+// WithSlots sets the number of KV cache slots, the sessions (see
+// [WithSession]) beyond it evicting the least recently used one
+func WithSlots(slots int) OptionFunc {
+	return func(c *ChatCompletionClient) error {
+		if slots < 1 {
+			return errors.Errorf("invalid number of slots %d", slots)
+		}
+		c.slotCount = slots
+		return nil
+	}
+}
+
 // WithVerbose enables verbose logging
 func WithVerbose(verbose bool) OptionFunc {
 	return func(c *ChatCompletionClient) error {
@@ -864,6 +1022,7 @@ func NewChatCompletionClient(funcs ...OptionFunc) (*ChatCompletionClient, error)
 		penaltyLastN:    64,
 		predictSize:     32768,
 		verbose:         false,
+		slotCount:       1,
 	}
 
 	for _, fn := range funcs {
@@ -885,40 +1044,51 @@ func NewChatCompletionClient(funcs ...OptionFunc) (*ChatCompletionClient, error)
 	return client, nil
 }
 
-// ensureModel downloads the model file from modelURL if modelPath is not set or does not exist.
+// ensureModel downloads the model file from modelURL, and the multimodal
+// projector file from mmprojURL, if their paths are not set or do not exist.
 func (c *ChatCompletionClient) ensureModel() error {
-	if c.modelURL == "" {
-		return nil
+	modelPath, err := downloadFile(c.modelURL, c.modelPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to download model")
 	}
 
-	// Strip query params to get clean filename
-	cleanURL := strings.SplitN(c.modelURL, "?", 2)[0]
-	filename := filepath.Base(cleanURL)
+	c.modelPath = modelPath
 
-	// Derive local path from URL filename if not set
-	if c.modelPath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return errors.Wrap(err, "failed to get user home directory")
-		}
-		c.modelPath = filepath.Join(homeDir, ".yzma", "models", filename)
+	mmprojPath, err := downloadFile(c.mmprojURL, c.mmprojPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to download multimodal projector")
 	}
 
-	// Download only if the file doesn't already exist
-	if _, err := os.Stat(c.modelPath); err == nil {
-		return nil
+	c.mmprojPath = mmprojPath
+
+	return nil
+}
+
+// downloadFile pulls the GGUF file of the URL, or Hugging Face reference (see
+// [models.Manager.Resolve]), in the models cache if the path is empty or
+// does not exist, the cache being the directory of the path when set. It
+// returns the local path of the file.
+func downloadFile(url string, path string) (string, error) {
+	if url == "" {
+		return path, nil
 	}
 
-	modelsDir := filepath.Dir(c.modelPath)
-	if err := os.MkdirAll(modelsDir, 0755); err != nil {
-		return errors.Wrap(err, "failed to create model directory")
+	funcs := []models.OptionFunc{}
+
+	if path != "" {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+
+		funcs = append(funcs, models.WithDir(filepath.Dir(path)))
 	}
 
-	if err := download.GetModel(c.modelURL, modelsDir); err != nil {
-		return errors.Wrap(err, "failed to download model")
+	model, err := models.NewManager(funcs...).Pull(context.Background(), url)
+	if err != nil {
+		return "", errors.WithStack(err)
 	}
 
-	return nil
+	return model.Path, nil
 }
 
 // ensureBinaries downloads llama.cpp binaries if not already present
@@ -973,3 +1143,4 @@ func (c *ChatCompletionClient) ensureBinaries() error {
 
 var _ llm.ChatCompletionClient = &ChatCompletionClient{}
 var _ llm.ChatCompletionStreamingClient = &ChatCompletionClient{}
+var _ llm.AttachmentValidator = &ChatCompletionClient{}
@@ -0,0 +1,158 @@
+package yzma
+
+import (
+	"context"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/hybridgroup/yzma/pkg/llama"
+	"github.com/pkg/errors"
+)
+
+// Complete implements llm.CompletionClient. The prompt is decoded without
+// chat template or, with a suffix, wrapped in the fill-in-the-middle tokens
+// of the model, as the infill endpoint of llama.cpp does.
+func (c *ChatCompletionClient) Complete(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	opts := llm.NewCompletionOptions(funcs...)
+
+	if err := c.ensureLoaded(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	session, _ := ContextSession(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	slot, tokens, err := c.prefillCompletion(ctx, session, prompt, suffix)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	text, _, finishReason, err := c.generate(ctx, c.completionOptions(opts), slot, c.completionMaxTokens(opts), nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return llm.NewCompletionResponse(text, finishReason, c.completionUsage(tokens, text)), nil
+}
+
+// CompleteStream implements llm.CompletionClient.
+func (c *ChatCompletionClient) CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	opts := llm.NewCompletionOptions(funcs...)
+
+	if err := c.ensureLoaded(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	session, _ := ContextSession(ctx)
+
+	chunks := make(chan llm.StreamChunk, 10)
+
+	go func() {
+		defer close(chunks)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		slot, tokens, err := c.prefillCompletion(ctx, session, prompt, suffix)
+		if err != nil {
+			chunks <- llm.NewErrorStreamChunk(errors.WithStack(err))
+			return
+		}
+
+		text, _, _, err := c.generate(ctx, c.completionOptions(opts), slot, c.completionMaxTokens(opts), func(text string) {
+			chunks <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, text))
+		})
+		if err != nil {
+			chunks <- llm.NewErrorStreamChunk(errors.WithStack(err))
+			return
+		}
+
+		chunks <- llm.NewCompleteStreamChunk(c.completionUsage(tokens, text))
+	}()
+
+	return chunks, nil
+}
+
+// prefillCompletion decodes the completion prompt in a slot of the session,
+// returning the slot and the prompt tokens
+func (c *ChatCompletionClient) prefillCompletion(ctx context.Context, session string, prompt string, suffix string) (*slot, []llama.Token, error) {
+	tokens, err := c.completionTokens(prompt, suffix)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	slot, err := c.acquireSlot(ctx, session)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	if err := c.prefill(ctx, slot, tokens); err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	return slot, tokens, nil
+}
+
+// completionTokens tokenizes the prompt or, with a suffix, the
+// prefix-suffix-middle sequence of the fill-in-the-middle models
+func (c *ChatCompletionClient) completionTokens(prompt string, suffix string) ([]llama.Token, error) {
+	if suffix == "" {
+		return llama.Tokenize(c.vocab, prompt, true, true), nil
+	}
+
+	pre, suf, mid := llama.VocabFIMPre(c.vocab), llama.VocabFIMSuf(c.vocab), llama.VocabFIMMid(c.vocab)
+	if pre == llama.TokenNull || suf == llama.TokenNull || mid == llama.TokenNull {
+		return nil, errors.Wrap(llm.ErrUnsupportedSetting, "the model has no fill-in-the-middle tokens")
+	}
+
+	tokens := make([]llama.Token, 0)
+
+	if llama.VocabGetAddBOS(c.vocab) {
+		tokens = append(tokens, llama.VocabBOS(c.vocab))
+	}
+
+	tokens = append(tokens, pre)
+	tokens = append(tokens, llama.Tokenize(c.vocab, prompt, false, false)...)
+	tokens = append(tokens, suf)
+	tokens = append(tokens, llama.Tokenize(c.vocab, suffix, false, false)...)
+	tokens = append(tokens, mid)
+
+	return tokens, nil
+}
+
+// completionOptions converts the completion options to the chat completion
+// options the sampling is built from, the temperature defaulting to the one
+// of the client
+func (c *ChatCompletionClient) completionOptions(opts *llm.CompletionOptions) *llm.ChatCompletionOptions {
+	chatOpts := llm.NewChatCompletionOptions(
+		llm.WithTemperature(c.temperature),
+		llm.WithStop(opts.Stop...),
+	)
+
+	if opts.Temperature != nil {
+		chatOpts.Temperature = *opts.Temperature
+	}
+
+	chatOpts.TopP = opts.TopP
+	chatOpts.Seed = opts.Seed
+
+	return chatOpts
+}
+
+func (c *ChatCompletionClient) completionMaxTokens(opts *llm.CompletionOptions) int {
+	if opts.MaxTokens != nil {
+		return *opts.MaxTokens
+	}
+
+	return c.predictSize
+}
+
+func (c *ChatCompletionClient) completionUsage(prompt []llama.Token, text string) llm.ChatCompletionUsage {
+	promptTokens := int64(len(prompt))
+	completionTokens := int64(len(llama.Tokenize(c.vocab, text, false, false)))
+
+	return llm.NewChatCompletionUsage(promptTokens, completionTokens, promptTokens+completionTokens)
+}
+
+var _ llm.CompletionClient = &ChatCompletionClient{}
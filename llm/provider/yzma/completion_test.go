@@ -0,0 +1,39 @@
+package yzma
+
+import (
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+)
+
+func TestCompletionOptions(t *testing.T) {
+	client := &ChatCompletionClient{temperature: 0.2, predictSize: 64}
+
+	defaults := llm.NewCompletionOptions()
+
+	opts := client.completionOptions(defaults)
+	if opts.Temperature != 0.2 || opts.TopP != nil || opts.Seed != nil {
+		t.Errorf("expected the client defaults, got %+v", opts)
+	}
+
+	if maxTokens := client.completionMaxTokens(defaults); maxTokens != 64 {
+		t.Errorf("expected 64 max tokens, got %d", maxTokens)
+	}
+
+	overridden := llm.NewCompletionOptions(
+		llm.WithCompletionTemperature(0.8),
+		llm.WithCompletionTopP(0.9),
+		llm.WithCompletionSeed(7),
+		llm.WithCompletionStop("\n\n"),
+		llm.WithCompletionMaxTokens(16),
+	)
+
+	opts = client.completionOptions(overridden)
+	if opts.Temperature != 0.8 || *opts.TopP != 0.9 || *opts.Seed != 7 || len(opts.Stop) != 1 {
+		t.Errorf("expected the completion options, got %+v", opts)
+	}
+
+	if maxTokens := client.completionMaxTokens(overridden); maxTokens != 16 {
+		t.Errorf("expected 16 max tokens, got %d", maxTokens)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
-	"strings"
 	"sync"
 
 	"github.com/bornholm/genai/llm"
@@ -320,37 +319,13 @@ func NewEmbeddingsClient(funcs ...EmbeddingsOptionFunc) (*EmbeddingsClient, erro
 
 // ensureModel downloads the model file from modelURL if modelPath is not set or does not exist.
 func (c *EmbeddingsClient) ensureModel() error {
-	if c.modelURL == "" {
-		return nil
-	}
-
-	// Strip query params to get clean filename
-	cleanURL := strings.SplitN(c.modelURL, "?", 2)[0]
-	filename := filepath.Base(cleanURL)
-
-	// Derive local path from URL filename if not set
-	if c.modelPath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return errors.Wrap(err, "failed to get user home directory")
-		}
-		c.modelPath = filepath.Join(homeDir, ".yzma", "models", filename)
-	}
-
-	// Download only if the file doesn't already exist
-	if _, err := os.Stat(c.modelPath); err == nil {
-		return nil
-	}
-
-	modelsDir := filepath.Dir(c.modelPath)
-	if err := os.MkdirAll(modelsDir, 0755); err != nil {
-		return errors.Wrap(err, "failed to create model directory")
-	}
-
-	if err := download.GetModel(c.modelURL, modelsDir); err != nil {
+	modelPath, err := downloadFile(c.modelURL, c.modelPath)
+	if err != nil {
 		return errors.Wrap(err, "failed to download model")
 	}
 
+	c.modelPath = modelPath
+
 	return nil
 }
 
@@ -0,0 +1,617 @@
+package yzma
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+// The grammars are written in GBNF, the grammar format of llama.cpp. Every
+// rule of a JSON value consumes the whitespace following it.
+
+// primitiveRules are the rules of the JSON primitives, with the rules they
+// depend upon
+var primitiveRules = map[string]struct {
+	body string
+	deps []string
+}{
+	"ws":      {body: `| " " | "\n" [ \t]{0,20}`},
+	"boolean": {body: `("true" | "false") ws`, deps: []string{"ws"}},
+	"null":    {body: `"null" ws`, deps: []string{"ws"}},
+	"integer": {body: `("-"? ([0-9] | [1-9] [0-9]{0,15})) ws`, deps: []string{"ws"}},
+	"number":  {body: `("-"? ([0-9] | [1-9] [0-9]{0,15})) ("." [0-9]+)? ([eE] [-+]? [0-9]{1,3})? ws`, deps: []string{"ws"}},
+	"char":    {body: `[^"\\\x7F\x00-\x1F] | [\\] (["\\/bfnrt] | "u" [0-9a-fA-F]{4})`},
+	"string":  {body: `"\"" char* "\"" ws`, deps: []string{"char", "ws"}},
+	"value":   {body: `object | array | string | number | boolean | null`, deps: []string{"object", "array", "string", "number", "boolean", "null"}},
+	"object":  {body: `"{" ws ( string ":" ws value ( "," ws string ":" ws value )* )? "}" ws`, deps: []string{"ws", "string", "value"}},
+	"array":   {body: `"[" ws ( value ( "," ws value )* )? "]" ws`, deps: []string{"ws", "value"}},
+}
+
+var invalidRuleChars = regexp.MustCompile(`[^a-zA-Z0-9-]+`)
+
+// grammarBuilder converts JSON schemas to the rules of a GBNF grammar
+type grammarBuilder struct {
+	rules map[string]string
+	names []string
+
+	// root and refs are those of the schema being converted, its $ref being
+	// resolved against it
+	root any
+	refs map[string]string
+}
+
+// Schema adds the rules of the JSON schema, the value being matched by the
+// rule of the given name. Unsupported keywords (pattern, format, numeric
+// bounds...) are ignored, the value being only constrained by its type.
+func (b *grammarBuilder) Schema(name string, schema any) (string, error) {
+	normalized, err := normalizeSchema(schema)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	b.root = normalized
+	b.refs = map[string]string{}
+
+	return b.visit(normalized, name)
+}
+
+// Rule adds a rule, its name being made unique, and returns the name
+func (b *grammarBuilder) Rule(name string, body string) string {
+	name = b.reserve(name)
+	b.rules[name] = body
+	return name
+}
+
+// String returns the grammar, its rules in the order they were added
+func (b *grammarBuilder) String() string {
+	var sb strings.Builder
+	for _, name := range b.names {
+		fmt.Fprintf(&sb, "%s ::= %s\n", name, b.rules[name])
+	}
+	return sb.String()
+}
+
+func (b *grammarBuilder) reserve(name string) string {
+	name = strings.Trim(invalidRuleChars.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		name = "rule"
+	}
+
+	unique := name
+	for i := 1; ; i++ {
+		if _, exists := b.rules[unique]; !exists {
+			break
+		}
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+
+	b.rules[unique] = ""
+	b.names = append(b.names, unique)
+
+	return unique
+}
+
+// primitive adds the rule of the JSON primitive and its dependencies
+func (b *grammarBuilder) primitive(name string) string {
+	if _, exists := b.rules[name]; exists {
+		return name
+	}
+
+	rule := primitiveRules[name]
+	b.rules[name] = rule.body
+	b.names = append(b.names, name)
+
+	for _, dep := range rule.deps {
+		b.primitive(dep)
+	}
+
+	return name
+}
+
+func (b *grammarBuilder) visit(schema any, name string) (string, error) {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			return "", errors.Errorf("schema '%s' matches no value", name)
+		}
+		return b.primitive("value"), nil
+	case map[string]any:
+		return b.visitObjectSchema(s, name)
+	default:
+		return "", errors.Errorf("unexpected schema type '%T' for '%s'", schema, name)
+	}
+}
+
+func (b *grammarBuilder) visitObjectSchema(schema map[string]any, name string) (string, error) {
+	if ref, ok := schema["$ref"].(string); ok {
+		return b.visitRef(ref)
+	}
+
+	if value, ok := schema["const"]; ok {
+		literal, err := jsonLiteral(value)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		return b.Rule(name, literal+" "+b.primitive("ws")), nil
+	}
+
+	if values, ok := schema["enum"].([]any); ok {
+		alternatives := make([]string, 0, len(values))
+		for _, v := range values {
+			literal, err := jsonLiteral(v)
+			if err != nil {
+				return "", errors.WithStack(err)
+			}
+			alternatives = append(alternatives, literal)
+		}
+		return b.Rule(name, "("+strings.Join(alternatives, " | ")+") "+b.primitive("ws")), nil
+	}
+
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		if variants, ok := schema[keyword].([]any); ok {
+			return b.visitAlternatives(variants, name)
+		}
+	}
+
+	if all, ok := schema["allOf"].([]any); ok {
+		merged, err := mergeSchemas(schema, all)
+		if err != nil {
+			return "", errors.Wrapf(err, "could not merge allOf of '%s'", name)
+		}
+		return b.visitObjectSchema(merged, name)
+	}
+
+	switch t := schema["type"].(type) {
+	case []any:
+		variants := make([]any, 0, len(t))
+		for _, typ := range t {
+			variant := cloneSchema(schema)
+			variant["type"] = typ
+			variants = append(variants, variant)
+		}
+		return b.visitAlternatives(variants, name)
+
+	case string:
+		return b.visitType(schema, t, name)
+
+	default:
+		if _, ok := schema["properties"]; ok {
+			return b.visitType(schema, "object", name)
+		}
+		if _, ok := schema["items"]; ok {
+			return b.visitType(schema, "array", name)
+		}
+		return b.primitive("value"), nil
+	}
+}
+
+func (b *grammarBuilder) visitType(schema map[string]any, typ string, name string) (string, error) {
+	switch typ {
+	case "object":
+		return b.visitObject(schema, name)
+	case "array":
+		return b.visitArray(schema, name)
+	case "string":
+		minLength, maxLength := intKeyword(schema, "minLength"), intKeyword(schema, "maxLength")
+		if minLength <= 0 && maxLength < 0 {
+			return b.primitive("string"), nil
+		}
+		return b.Rule(name, `"\"" `+b.primitive("char")+repetition(max(minLength, 0), maxLength)+` "\"" `+b.primitive("ws")), nil
+	case "integer", "number", "boolean", "null":
+		return b.primitive(typ), nil
+	default:
+		return "", errors.Errorf("unsupported type '%s' for '%s'", typ, name)
+	}
+}
+
+func (b *grammarBuilder) visitObject(schema map[string]any, name string) (string, error) {
+	properties, _ := schema["properties"].(map[string]any)
+
+	if len(properties) == 0 {
+		if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+			return b.Rule(name, `"{" `+b.primitive("ws")+` "}" `+b.primitive("ws")), nil
+		}
+		return b.primitive("object"), nil
+	}
+
+	// Required properties come first, in the order of their declaration, the
+	// optional ones following in alphabetical order
+	var required, optional []string
+	if names, ok := schema["required"].([]any); ok {
+		for _, n := range names {
+			if s, ok := n.(string); ok {
+				if _, exists := properties[s]; exists && !slices.Contains(required, s) {
+					required = append(required, s)
+				}
+			}
+		}
+	}
+	for property := range properties {
+		if !slices.Contains(required, property) {
+			optional = append(optional, property)
+		}
+	}
+	sort.Strings(optional)
+
+	pairs := map[string]string{}
+	for _, property := range append(slices.Clone(required), optional...) {
+		rule, err := b.visit(properties[property], name+"-"+property)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		key, err := jsonLiteral(property)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		pairs[property] = b.Rule(name+"-"+property+"-kv", key+" "+b.primitive("ws")+` ":" `+b.primitive("ws")+" "+rule)
+	}
+
+	elements := make([]string, 0, len(required)*2+1)
+	for i, property := range required {
+		if i > 0 {
+			elements = append(elements, `"," `+b.primitive("ws"))
+		}
+		elements = append(elements, pairs[property])
+	}
+
+	if len(optional) > 0 {
+		rest := b.optionalProperties(name, optional, pairs)
+		if len(required) > 0 {
+			elements = append(elements, `( "," `+b.primitive("ws")+" "+rest+" )?")
+		} else {
+			elements = append(elements, rest+"?")
+		}
+	}
+
+	return b.Rule(name, `"{" `+b.primitive("ws")+" "+strings.Join(elements, " ")+` "}" `+b.primitive("ws")), nil
+}
+
+// optionalProperties adds the rules of any ordered selection of the optional
+// properties, at least one of them being present
+func (b *grammarBuilder) optionalProperties(name string, optional []string, pairs map[string]string) string {
+	rules := make([]string, len(optional))
+
+	// Each rule matches a selection starting with its property, the
+	// selections of the following properties being built first
+	for i := len(optional) - 1; i >= 0; i-- {
+		body := pairs[optional[i]]
+		if i < len(optional)-1 {
+			alternatives := make([]string, 0, len(optional)-i-1)
+			for j := i + 1; j < len(optional); j++ {
+				alternatives = append(alternatives, rules[j])
+			}
+			body += ` ( "," ` + b.primitive("ws") + " ( " + strings.Join(alternatives, " | ") + " ) )?"
+		}
+		rules[i] = b.Rule(name+"-"+optional[i]+"-rest", body)
+	}
+
+	return "( " + strings.Join(rules, " | ") + " )"
+}
+
+func (b *grammarBuilder) visitArray(schema map[string]any, name string) (string, error) {
+	var item string
+	if items, ok := schema["items"]; ok {
+		rule, err := b.visit(items, name+"-item")
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		item = rule
+	} else {
+		item = b.primitive("value")
+	}
+
+	minItems, maxItems := max(intKeyword(schema, "minItems"), 0), intKeyword(schema, "maxItems")
+	ws := b.primitive("ws")
+
+	if maxItems == 0 {
+		return b.Rule(name, `"[" `+ws+` "]" `+ws), nil
+	}
+
+	var body string
+	switch {
+	case minItems == 0:
+		others := -1
+		if maxItems > 0 {
+			others = maxItems - 1
+		}
+		body = `"[" ` + ws + " ( " + item + ` ( "," ` + ws + " " + item + " )" + repetition(0, others) + ` )? "]" ` + ws
+	default:
+		others := -1
+		if maxItems > 0 {
+			others = maxItems - 1
+		}
+		body = `"[" ` + ws + " " + item + ` ( "," ` + ws + " " + item + " )" + repetition(minItems-1, others) + ` "]" ` + ws
+	}
+
+	return b.Rule(name, body), nil
+}
+
+func (b *grammarBuilder) visitAlternatives(variants []any, name string) (string, error) {
+	alternatives := make([]string, 0, len(variants))
+	for i, variant := range variants {
+		rule, err := b.visit(variant, fmt.Sprintf("%s-%d", name, i))
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		alternatives = append(alternatives, rule)
+	}
+
+	return b.Rule(name, strings.Join(alternatives, " | ")), nil
+}
+
+// visitRef resolves a local reference (#/$defs/..., #/definitions/...)
+// against the root schema. The rule is named before its schema is visited so
+// that recursive schemas terminate.
+func (b *grammarBuilder) visitRef(ref string) (string, error) {
+	if rule, exists := b.refs[ref]; exists {
+		return rule, nil
+	}
+
+	if !strings.HasPrefix(ref, "#") {
+		return "", errors.Errorf("unsupported remote reference '%s'", ref)
+	}
+
+	target := b.root
+	for _, segment := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if segment == "" {
+			continue
+		}
+
+		segment = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+
+		object, ok := target.(map[string]any)
+		if !ok {
+			return "", errors.Errorf("could not resolve reference '%s'", ref)
+		}
+
+		if target, ok = object[segment]; !ok {
+			return "", errors.Errorf("could not resolve reference '%s'", ref)
+		}
+	}
+
+	name := b.reserve("ref-" + ref[strings.LastIndex(ref, "/")+1:])
+	b.refs[ref] = name
+
+	rule, err := b.visit(target, name+"-value")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	b.rules[name] = rule
+
+	return name, nil
+}
+
+// normalizeSchema returns the schema as decoded JSON, the schemas being given
+// as maps or as structs
+func normalizeSchema(schema any) (any, error) {
+	if schema == nil {
+		return map[string]any{}, nil
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal schema")
+	}
+
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal schema")
+	}
+
+	return normalized, nil
+}
+
+// mergeSchemas merges the subschemas of allOf into the schema, their
+// properties and required lists being combined
+func mergeSchemas(schema map[string]any, all []any) (map[string]any, error) {
+	merged := cloneSchema(schema)
+	delete(merged, "allOf")
+
+	properties := map[string]any{}
+	if p, ok := merged["properties"].(map[string]any); ok {
+		for k, v := range p {
+			properties[k] = v
+		}
+	}
+	required, _ := merged["required"].([]any)
+
+	for _, sub := range all {
+		subSchema, ok := sub.(map[string]any)
+		if !ok {
+			return nil, errors.Errorf("unexpected subschema type '%T'", sub)
+		}
+
+		for k, v := range subSchema {
+			switch k {
+			case "properties":
+				if p, ok := v.(map[string]any); ok {
+					for name, property := range p {
+						properties[name] = property
+					}
+				}
+			case "required":
+				if r, ok := v.([]any); ok {
+					required = append(required, r...)
+				}
+			default:
+				if _, exists := merged[k]; !exists {
+					merged[k] = v
+				}
+			}
+		}
+	}
+
+	if len(properties) > 0 {
+		merged["properties"] = properties
+	}
+	if len(required) > 0 {
+		merged["required"] = required
+	}
+
+	return merged, nil
+}
+
+func cloneSchema(schema map[string]any) map[string]any {
+	clone := make(map[string]any, len(schema))
+	for k, v := range schema {
+		clone[k] = v
+	}
+	return clone
+}
+
+// jsonLiteral returns the GBNF literal matching the JSON encoding of the value
+func jsonLiteral(value any) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not marshal value '%v'", value)
+	}
+
+	return gbnfLiteral(string(data)), nil
+}
+
+// gbnfLiteral returns the GBNF literal matching the text
+func gbnfLiteral(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(text) + `"`
+}
+
+// repetition returns the GBNF repetition operator of min to max occurrences,
+// max being unbounded when negative
+func repetition(min, max int) string {
+	switch {
+	case max < 0 && min == 0:
+		return "*"
+	case max < 0 && min == 1:
+		return "+"
+	case max < 0:
+		return fmt.Sprintf("{%d,}", min)
+	case min == max:
+		return fmt.Sprintf("{%d}", min)
+	default:
+		return fmt.Sprintf("{%d,%d}", min, max)
+	}
+}
+
+// intKeyword returns the integer value of the keyword, -1 when absent
+func intKeyword(schema map[string]any, keyword string) int {
+	if v, ok := schema[keyword].(float64); ok {
+		return int(v)
+	}
+	return -1
+}
+
+// responseGrammar returns the grammar of the JSON response matching the
+// schema, any JSON object when nil
+func responseGrammar(schema llm.ResponseSchema) (string, error) {
+	b := &grammarBuilder{rules: map[string]string{}}
+
+	response, err := b.response(schema)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	b.rules["root"] = response
+	b.names = append([]string{"root"}, b.names...)
+
+	return b.String(), nil
+}
+
+// response adds the rules of the JSON response matching the schema, any JSON
+// object when nil, and returns the name of its rule
+func (b *grammarBuilder) response(schema llm.ResponseSchema) (string, error) {
+	var definition any = map[string]any{"type": "object"}
+	if schema != nil && schema.Schema() != nil {
+		definition = schema.Schema()
+	}
+
+	rule, err := b.Schema("response", definition)
+	if err != nil {
+		return "", errors.Wrap(err, "could not convert response schema to grammar")
+	}
+
+	return rule, nil
+}
+
+const (
+	toolCallOpen  = "<tool_call>"
+	toolCallClose = "</tool_call>"
+)
+
+// toolCallGrammar returns the grammar of a tool call, the arguments matching
+// the parameters of the tool. The grammar matches what follows the opening
+// marker; when required, it matches whole responses made of tool calls.
+func toolCallGrammar(tools []llm.Tool, required bool) (string, error) {
+	b := &grammarBuilder{rules: map[string]string{}}
+
+	call, err := b.toolCall(tools)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	root := call
+	if required {
+		root = toolCalls(call)
+	}
+
+	b.rules["root"] = root
+	b.names = append([]string{"root"}, b.names...)
+
+	return b.String(), nil
+}
+
+// responseOrToolCallGrammar returns the grammar of whole responses made
+// either of the JSON response matching the schema, or of tool calls
+func responseOrToolCallGrammar(schema llm.ResponseSchema, tools []llm.Tool) (string, error) {
+	b := &grammarBuilder{rules: map[string]string{}}
+
+	response, err := b.response(schema)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	call, err := b.toolCall(tools)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	b.rules["root"] = response + " | " + toolCalls(call)
+	b.names = append([]string{"root"}, b.names...)
+
+	return b.String(), nil
+}
+
+// toolCall adds the rules of a tool call, from what follows the opening
+// marker, and returns the name of its rule
+func (b *grammarBuilder) toolCall(tools []llm.Tool) (string, error) {
+	calls := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		arguments, err := b.Schema("tool-"+tool.Name()+"-arguments", tool.Parameters())
+		if err != nil {
+			return "", errors.Wrapf(err, "could not convert parameters of tool '%s' to grammar", tool.Name())
+		}
+
+		name, err := jsonLiteral(tool.Name())
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		ws := b.primitive("ws")
+		calls = append(calls, b.Rule("tool-"+tool.Name(), `"{" `+ws+` "\"name\"" `+ws+` ":" `+ws+" "+name+" "+ws+` "," `+ws+` "\"arguments\"" `+ws+` ":" `+ws+" "+arguments+` "}" `+ws))
+	}
+
+	return b.Rule("tool-call", b.primitive("ws")+" ( "+strings.Join(calls, " | ")+" ) "+gbnfLiteral(toolCallClose)), nil
+}
+
+// toolCalls returns the expression of a sequence of tool calls, markers
+// included
+func toolCalls(call string) string {
+	return "( " + gbnfLiteral(toolCallOpen) + " " + call + ` [ \n]* )+`
+}
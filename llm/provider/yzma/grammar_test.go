@@ -0,0 +1,590 @@
+package yzma
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+)
+
+func TestResponseGrammar(t *testing.T) {
+	type testCase struct {
+		Name     string
+		Schema   any
+		Accepted []string
+		Rejected []string
+	}
+
+	testCases := []testCase{
+		{
+			Name:     "any object",
+			Schema:   nil,
+			Accepted: []string{`{}`, `{"a": [1, true, null], "b": {"c": "d"}}`},
+			Rejected: []string{`[]`, `"text"`, `{"a": }`, `Sure! {"a": 1}`},
+		},
+		{
+			Name: "required and optional properties",
+			Schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name": map[string]any{"type": "string"},
+					"age":  map[string]any{"type": "integer"},
+					"tags": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "maxItems": 2},
+				},
+				"required": []any{"name"},
+			},
+			Accepted: []string{
+				`{"name": "Jane"}`,
+				`{"name": "Jane", "age": 42}`,
+				`{"name": "Jane", "tags": ["a", "b"]}`,
+				"{\n  \"name\": \"Jane\",\n  \"age\": -3,\n  \"tags\": []\n}",
+			},
+			Rejected: []string{
+				`{}`,
+				`{"age": 42}`,
+				`{"name": 42}`,
+				`{"name": "Jane", "age": 4.2}`,
+				`{"name": "Jane", "tags": ["a", "b", "c"]}`,
+				`{"name": "Jane", "other": 1}`,
+				`{"name": "Jane", "age": 42, "age": 42}`,
+			},
+		},
+		{
+			Name: "enum, const and nullable",
+			Schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"kind":    map[string]any{"enum": []any{"cat", "dog"}},
+					"version": map[string]any{"const": 2},
+					"comment": map[string]any{"type": []any{"string", "null"}},
+				},
+				"required": []any{"kind", "version", "comment"},
+			},
+			Accepted: []string{
+				`{"kind": "cat", "version": 2, "comment": null}`,
+				`{"kind": "dog", "version": 2, "comment": "good \"boy\""}`,
+			},
+			Rejected: []string{
+				`{"kind": "bird", "version": 2, "comment": null}`,
+				`{"kind": "cat", "version": 3, "comment": null}`,
+				`{"kind": "cat", "version": 2, "comment": 1}`,
+			},
+		},
+		{
+			Name: "recursive reference",
+			Schema: map[string]any{
+				"$ref": "#/$defs/node",
+				"$defs": map[string]any{
+					"node": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"value":    map[string]any{"type": "number"},
+							"children": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/node"}},
+						},
+						"required": []any{"value"},
+					},
+				},
+			},
+			Accepted: []string{
+				`{"value": 1.5}`,
+				`{"value": 1, "children": [{"value": 2, "children": [{"value": 3e2}]}]}`,
+			},
+			Rejected: []string{
+				`{"value": 1, "children": [{"children": []}]}`,
+			},
+		},
+		{
+			Name: "all of",
+			Schema: map[string]any{
+				"allOf": []any{
+					map[string]any{"type": "object", "properties": map[string]any{"a": map[string]any{"type": "boolean"}}, "required": []any{"a"}},
+					map[string]any{"properties": map[string]any{"b": map[string]any{"type": "string", "minLength": 1}}, "required": []any{"b"}},
+				},
+			},
+			Accepted: []string{`{"a": true, "b": "x"}`},
+			Rejected: []string{`{"a": true}`, `{"a": true, "b": ""}`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var schema llm.ResponseSchema
+			if tc.Schema != nil {
+				schema = llm.NewResponseSchema("test", "", tc.Schema)
+			}
+
+			grammar, err := responseGrammar(schema)
+			if err != nil {
+				t.Fatalf("responseGrammar: %+v", err)
+			}
+
+			matcher := parseTestGrammar(t, grammar)
+
+			for _, input := range tc.Accepted {
+				if !matcher.Match(input) {
+					t.Errorf("expected %q to be accepted by the grammar:\n%s", input, grammar)
+				}
+			}
+
+			for _, input := range tc.Rejected {
+				if matcher.Match(input) {
+					t.Errorf("expected %q to be rejected by the grammar:\n%s", input, grammar)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseGrammar_UnresolvedReference(t *testing.T) {
+	_, err := responseGrammar(llm.NewResponseSchema("test", "", map[string]any{"$ref": "#/$defs/missing"}))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestToolCallGrammar(t *testing.T) {
+	tools := []llm.Tool{
+		llm.NewFuncTool("search", "Search the web", map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query": map[string]any{"type": "string"},
+				"limit": map[string]any{"type": "integer"},
+			},
+			"required": []any{"query"},
+		}, nil),
+		llm.NewFuncTool("get_time", "Get the time", map[string]any{"type": "object", "properties": map[string]any{}}, nil),
+	}
+
+	grammar, err := toolCallGrammar(tools, false)
+	if err != nil {
+		t.Fatalf("toolCallGrammar: %+v", err)
+	}
+
+	matcher := parseTestGrammar(t, grammar)
+
+	accepted := []string{
+		"\n{\"name\": \"search\", \"arguments\": {\"query\": \"go\", \"limit\": 3}}\n</tool_call>",
+		`{"name": "get_time", "arguments": {}}</tool_call>`,
+	}
+	for _, input := range accepted {
+		if !matcher.Match(input) {
+			t.Errorf("expected %q to be accepted by the grammar:\n%s", input, grammar)
+		}
+	}
+
+	rejected := []string{
+		`{"name": "search", "arguments": {}}</tool_call>`,
+		`{"name": "unknown", "arguments": {}}</tool_call>`,
+		`{"name": "search", "arguments": {"query": "go"}}`,
+	}
+	for _, input := range rejected {
+		if matcher.Match(input) {
+			t.Errorf("expected %q to be rejected by the grammar:\n%s", input, grammar)
+		}
+	}
+
+	required, err := toolCallGrammar(tools, true)
+	if err != nil {
+		t.Fatalf("toolCallGrammar: %+v", err)
+	}
+
+	matcher = parseTestGrammar(t, required)
+
+	if input := "<tool_call>\n{\"name\": \"get_time\", \"arguments\": {}}\n</tool_call>\n<tool_call>{\"name\": \"search\", \"arguments\": {\"query\": \"go\"}}</tool_call>"; !matcher.Match(input) {
+		t.Errorf("expected %q to be accepted by the grammar:\n%s", input, required)
+	}
+	if input := "The time is noon."; matcher.Match(input) {
+		t.Errorf("expected %q to be rejected by the grammar:\n%s", input, required)
+	}
+}
+
+func TestResponseOrToolCallGrammar(t *testing.T) {
+	tools := []llm.Tool{
+		llm.NewFuncTool("get_time", "Get the time", map[string]any{"type": "object", "properties": map[string]any{}}, nil),
+	}
+
+	schema := llm.NewResponseSchema("answer", "", map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"answer": map[string]any{"type": "string"}},
+		"required":   []any{"answer"},
+	})
+
+	grammar, err := responseOrToolCallGrammar(schema, tools)
+	if err != nil {
+		t.Fatalf("responseOrToolCallGrammar: %+v", err)
+	}
+
+	matcher := parseTestGrammar(t, grammar)
+
+	accepted := []string{
+		`{"answer": "noon"}`,
+		"<tool_call>\n{\"name\": \"get_time\", \"arguments\": {}}\n</tool_call>",
+	}
+	for _, input := range accepted {
+		if !matcher.Match(input) {
+			t.Errorf("expected %q to be accepted by the grammar:\n%s", input, grammar)
+		}
+	}
+
+	rejected := []string{
+		`{"time": "noon"}`,
+		"The time is noon.",
+		`{"answer": "noon"}<tool_call>{"name": "get_time", "arguments": {}}</tool_call>`,
+	}
+	for _, input := range rejected {
+		if matcher.Match(input) {
+			t.Errorf("expected %q to be rejected by the grammar:\n%s", input, grammar)
+		}
+	}
+}
+
+func TestDecodeToolCalls(t *testing.T) {
+	response := "<tool_call>\n{\"name\": \"search\", \"arguments\": {\"query\": \"go\", \"filters\": {\"lang\": [\"en\"]}}}\n</tool_call>\n<tool_call>{\"name\": \"get_time\"}</tool_call>"
+
+	toolCalls, ok := decodeToolCalls(response)
+	if !ok {
+		t.Fatal("expected the tool calls to be decoded")
+	}
+
+	if len(toolCalls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %d", len(toolCalls))
+	}
+
+	if toolCalls[0].Name() != "search" || toolCalls[0].Parameters() != `{"query":"go","filters":{"lang":["en"]}}` {
+		t.Errorf("unexpected tool call %s %v", toolCalls[0].Name(), toolCalls[0].Parameters())
+	}
+
+	if toolCalls[1].Name() != "get_time" || toolCalls[1].Parameters() != `{}` || toolCalls[1].ID() != "call_1" {
+		t.Errorf("unexpected tool call %s %s %v", toolCalls[1].ID(), toolCalls[1].Name(), toolCalls[1].Parameters())
+	}
+
+	if _, ok := decodeToolCalls(`<tool_call>{"name": "search", "arguments": {</tool_call>`); ok {
+		t.Error("expected invalid JSON not to be decoded")
+	}
+}
+
+// testGrammar matches texts against a GBNF grammar, supporting the subset of
+// the format produced by the converter
+type testGrammar struct {
+	rules map[string]gbnfNode
+}
+
+type gbnfNode struct {
+	kind     string // alt, seq, literal, class, ref, repeat
+	children []gbnfNode
+	literal  []rune
+	negated  bool
+	ranges   [][2]rune
+	ref      string
+	min, max int // max < 0 for unbounded
+}
+
+func (g *testGrammar) Match(input string) bool {
+	runes := []rune(input)
+	for _, end := range g.match(g.rules["root"], runes, 0) {
+		if end == len(runes) {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the positions where the node can end when starting at pos
+func (g *testGrammar) match(n gbnfNode, input []rune, pos int) []int {
+	switch n.kind {
+	case "alt":
+		var ends []int
+		for _, child := range n.children {
+			ends = appendUnique(ends, g.match(child, input, pos)...)
+		}
+		return ends
+	case "seq":
+		ends := []int{pos}
+		for _, child := range n.children {
+			var next []int
+			for _, p := range ends {
+				next = appendUnique(next, g.match(child, input, p)...)
+			}
+			ends = next
+		}
+		return ends
+	case "literal":
+		if pos+len(n.literal) > len(input) || string(input[pos:pos+len(n.literal)]) != string(n.literal) {
+			return nil
+		}
+		return []int{pos + len(n.literal)}
+	case "class":
+		if pos >= len(input) {
+			return nil
+		}
+		in := false
+		for _, r := range n.ranges {
+			if input[pos] >= r[0] && input[pos] <= r[1] {
+				in = true
+			}
+		}
+		if in == n.negated {
+			return nil
+		}
+		return []int{pos + 1}
+	case "ref":
+		return g.match(g.rules[n.ref], input, pos)
+	case "repeat":
+		var ends []int
+		current := []int{pos}
+		if n.min == 0 {
+			ends = append(ends, pos)
+		}
+		for count := 1; (n.max < 0 || count <= n.max) && len(current) > 0; count++ {
+			var next []int
+			for _, p := range current {
+				for _, e := range g.match(n.children[0], input, p) {
+					// Repetitions consuming nothing would loop forever
+					if e != p {
+						next = appendUnique(next, e)
+					}
+				}
+			}
+			current = next
+			if count >= n.min {
+				ends = appendUnique(ends, current...)
+			}
+		}
+		return ends
+	}
+	return nil
+}
+
+func appendUnique(ends []int, values ...int) []int {
+	for _, v := range values {
+		found := false
+		for _, e := range ends {
+			if e == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ends = append(ends, v)
+		}
+	}
+	return ends
+}
+
+func parseTestGrammar(t *testing.T, grammar string) *testGrammar {
+	t.Helper()
+
+	g := &testGrammar{rules: map[string]gbnfNode{}}
+
+	for _, line := range strings.Split(strings.TrimSpace(grammar), "\n") {
+		name, body, ok := strings.Cut(line, " ::= ")
+		if !ok {
+			t.Fatalf("invalid rule %q", line)
+		}
+
+		p := &gbnfParser{input: []rune(body)}
+		node := p.alternatives()
+		if p.err != "" || p.pos != len(p.input) {
+			t.Fatalf("could not parse rule %q at %d: %s", line, p.pos, p.err)
+		}
+
+		g.rules[name] = node
+	}
+
+	// Every referenced rule must be defined
+	var check func(n gbnfNode)
+	check = func(n gbnfNode) {
+		if n.kind == "ref" {
+			if _, exists := g.rules[n.ref]; !exists {
+				t.Fatalf("undefined rule '%s' in grammar:\n%s", n.ref, grammar)
+			}
+		}
+		for _, child := range n.children {
+			check(child)
+		}
+	}
+	for _, node := range g.rules {
+		check(node)
+	}
+
+	if _, exists := g.rules["root"]; !exists {
+		t.Fatalf("missing root rule in grammar:\n%s", grammar)
+	}
+
+	return g
+}
+
+type gbnfParser struct {
+	input []rune
+	pos   int
+	err   string
+}
+
+func (p *gbnfParser) fail(err string) gbnfNode {
+	if p.err == "" {
+		p.err = err
+	}
+	p.pos = len(p.input)
+	return gbnfNode{}
+}
+
+func (p *gbnfParser) skipSpaces() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *gbnfParser) alternatives() gbnfNode {
+	alt := gbnfNode{kind: "alt"}
+	for {
+		alt.children = append(alt.children, p.sequence())
+		p.skipSpaces()
+		if p.pos < len(p.input) && p.input[p.pos] == '|' {
+			p.pos++
+			continue
+		}
+		return alt
+	}
+}
+
+func (p *gbnfParser) sequence() gbnfNode {
+	seq := gbnfNode{kind: "seq"}
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.input) || p.input[p.pos] == '|' || p.input[p.pos] == ')' {
+			return seq
+		}
+
+		item := p.item()
+		seq.children = append(seq.children, p.postfix(item))
+	}
+}
+
+func (p *gbnfParser) item() gbnfNode {
+	switch c := p.input[p.pos]; {
+	case c == '"':
+		p.pos++
+		var literal []rune
+		for p.pos < len(p.input) && p.input[p.pos] != '"' {
+			literal = append(literal, p.char())
+		}
+		p.pos++
+		return gbnfNode{kind: "literal", literal: literal}
+	case c == '[':
+		p.pos++
+		class := gbnfNode{kind: "class"}
+		if p.input[p.pos] == '^' {
+			class.negated = true
+			p.pos++
+		}
+		for p.pos < len(p.input) && p.input[p.pos] != ']' {
+			start := p.char()
+			end := start
+			if p.pos+1 < len(p.input) && p.input[p.pos] == '-' && p.input[p.pos+1] != ']' {
+				p.pos++
+				end = p.char()
+			}
+			class.ranges = append(class.ranges, [2]rune{start, end})
+		}
+		p.pos++
+		return class
+	case c == '(':
+		p.pos++
+		node := p.alternatives()
+		p.skipSpaces()
+		if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+			return p.fail("unclosed group")
+		}
+		p.pos++
+		return node
+	case c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9':
+		start := p.pos
+		for p.pos < len(p.input) {
+			c := p.input[p.pos]
+			if c != '-' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') {
+				break
+			}
+			p.pos++
+		}
+		return gbnfNode{kind: "ref", ref: string(p.input[start:p.pos])}
+	default:
+		return p.fail("unexpected character " + string(c))
+	}
+}
+
+func (p *gbnfParser) postfix(item gbnfNode) gbnfNode {
+	if p.pos >= len(p.input) {
+		return item
+	}
+
+	switch p.input[p.pos] {
+	case '?':
+		p.pos++
+		return gbnfNode{kind: "repeat", children: []gbnfNode{item}, min: 0, max: 1}
+	case '*':
+		p.pos++
+		return gbnfNode{kind: "repeat", children: []gbnfNode{item}, min: 0, max: -1}
+	case '+':
+		p.pos++
+		return gbnfNode{kind: "repeat", children: []gbnfNode{item}, min: 1, max: -1}
+	case '{':
+		start := p.pos + 1
+		for p.pos < len(p.input) && p.input[p.pos] != '}' {
+			p.pos++
+		}
+		if p.pos >= len(p.input) {
+			return p.fail("unclosed repetition")
+		}
+		bounds := string(p.input[start:p.pos])
+		p.pos++
+
+		minText, maxText, ranged := strings.Cut(bounds, ",")
+		min, err := strconv.Atoi(minText)
+		if err != nil {
+			return p.fail("invalid repetition " + bounds)
+		}
+		max := min
+		if ranged {
+			max = -1
+			if maxText != "" {
+				if max, err = strconv.Atoi(maxText); err != nil {
+					return p.fail("invalid repetition " + bounds)
+				}
+			}
+		}
+		return gbnfNode{kind: "repeat", children: []gbnfNode{item}, min: min, max: max}
+	}
+
+	return item
+}
+
+// char reads a character of a literal or a class, with its escape sequence
+func (p *gbnfParser) char() rune {
+	c := p.input[p.pos]
+	p.pos++
+	if c != '\\' {
+		return c
+	}
+
+	c = p.input[p.pos]
+	p.pos++
+	switch c {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'x':
+		value, err := strconv.ParseUint(string(p.input[p.pos:p.pos+2]), 16, 8)
+		if err != nil {
+			p.fail("invalid escape sequence")
+			return 0
+		}
+		p.pos += 2
+		return rune(value)
+	default:
+		return c
+	}
+}
@@ -16,29 +16,17 @@ func init() {
 		Name,
 		defaultChatCompletionOptions,
 		func(ctx context.Context, opts *ChatCompletionOptions) (llm.ChatCompletionClient, error) {
-			client, err := NewChatCompletionClient(
-				WithModelPath(opts.ModelPath),
-				WithModelURL(opts.ModelURL),
-				WithLibPath(opts.LibPath),
-				WithProcessor(opts.Processor),
-				WithVersion(opts.Version),
-				WithContextSize(opts.ContextSize),
-				WithBatchSize(opts.BatchSize),
-				WithUBatchSize(opts.UBatchSize),
-				WithTemperature(opts.Temperature),
-				WithTopK(opts.TopK),
-				WithTopP(opts.TopP),
-				WithMinP(opts.MinP),
-				WithPresencePenalty(opts.PresencePenalty),
-				WithPenaltyLastN(opts.PenaltyLastN),
-				WithPredictSize(opts.PredictSize),
-				WithTemplate(opts.Template),
-				WithVerbose(opts.Verbose),
-			)
-			if err != nil {
-				return nil, errors.WithStack(err)
-			}
-			return client, nil
+			return newChatCompletionClient(opts)
+		},
+	)
+
+	// The chat completion client also completes raw text, possibly with a
+	// dedicated fill-in-the-middle model
+	provider.RegisterCompletion(
+		Name,
+		defaultChatCompletionOptions,
+		func(ctx context.Context, opts *ChatCompletionOptions) (llm.CompletionClient, error) {
+			return newChatCompletionClient(opts)
 		},
 	)
 
@@ -65,3 +53,32 @@ func init() {
 		},
 	)
 }
+
+func newChatCompletionClient(opts *ChatCompletionOptions) (*ChatCompletionClient, error) {
+	client, err := NewChatCompletionClient(
+		WithModelPath(opts.ModelPath),
+		WithModelURL(opts.ModelURL),
+		WithMMProjPath(opts.MMProjPath),
+		WithMMProjURL(opts.MMProjURL),
+		WithLibPath(opts.LibPath),
+		WithProcessor(opts.Processor),
+		WithVersion(opts.Version),
+		WithContextSize(opts.ContextSize),
+		WithBatchSize(opts.BatchSize),
+		WithUBatchSize(opts.UBatchSize),
+		WithTemperature(opts.Temperature),
+		WithTopK(opts.TopK),
+		WithTopP(opts.TopP),
+		WithMinP(opts.MinP),
+		WithPresencePenalty(opts.PresencePenalty),
+		WithPenaltyLastN(opts.PenaltyLastN),
+		WithPredictSize(opts.PredictSize),
+		WithTemplate(opts.Template),
+		WithVerbose(opts.Verbose),
+		WithSlots(opts.Slots),
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return client, nil
+}
@@ -0,0 +1,67 @@
+package yzma
+
+import (
+	"math"
+	"sort"
+
+	"github.com/bornholm/genai/llm"
+)
+
+// computeLogprob returns the log-probability of the sampled token and of the
+// topK most likely ones, computed with a log-softmax over the raw logits of
+// the model.
+func computeLogprob(logits []float32, token int32, topK int, piece func(token int32) string) llm.TokenLogprob {
+	maxLogit := math.Inf(-1)
+	for _, l := range logits {
+		maxLogit = math.Max(maxLogit, float64(l))
+	}
+
+	var sum float64
+	for _, l := range logits {
+		sum += math.Exp(float64(l) - maxLogit)
+	}
+
+	logNorm := maxLogit + math.Log(sum)
+
+	logprob := llm.TokenLogprob{
+		Token: piece(token),
+	}
+
+	if int(token) < len(logits) {
+		logprob.Logprob = float64(logits[token]) - logNorm
+	}
+
+	if topK <= 0 {
+		return logprob
+	}
+
+	// Keep the topK highest logits, highest first
+	top := make([]int32, 0, topK+1)
+	for i, l := range logits {
+		if len(top) == topK && l <= logits[top[len(top)-1]] {
+			continue
+		}
+
+		idx := sort.Search(len(top), func(j int) bool {
+			return logits[top[j]] < l
+		})
+
+		top = append(top, 0)
+		copy(top[idx+1:], top[idx:])
+		top[idx] = int32(i)
+
+		if len(top) > topK {
+			top = top[:topK]
+		}
+	}
+
+	logprob.TopLogprobs = make([]llm.TopLogprob, 0, len(top))
+	for _, t := range top {
+		logprob.TopLogprobs = append(logprob.TopLogprobs, llm.TopLogprob{
+			Token:   piece(t),
+			Logprob: float64(logits[t]) - logNorm,
+		})
+	}
+
+	return logprob
+}
@@ -0,0 +1,44 @@
+package yzma
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestComputeLogprob(t *testing.T) {
+	logits := []float32{1, 3, 2, 0}
+	piece := func(token int32) string {
+		return fmt.Sprintf("t%d", token)
+	}
+
+	logprob := computeLogprob(logits, 2, 2, piece)
+
+	norm := math.Log(math.Exp(1) + math.Exp(3) + math.Exp(2) + math.Exp(0))
+
+	if logprob.Token != "t2" {
+		t.Errorf("logprob.Token: expected 't2', got '%s'", logprob.Token)
+	}
+
+	if e, g := 2-norm, logprob.Logprob; math.Abs(e-g) > 1e-9 {
+		t.Errorf("logprob.Logprob: expected %v, got %v", e, g)
+	}
+
+	if e, g := 2, len(logprob.TopLogprobs); e != g {
+		t.Fatalf("len(logprob.TopLogprobs): expected %d, got %d", e, g)
+	}
+
+	for i, expected := range []string{"t1", "t2"} {
+		if g := logprob.TopLogprobs[i].Token; g != expected {
+			t.Errorf("logprob.TopLogprobs[%d].Token: expected '%s', got '%s'", i, expected, g)
+		}
+	}
+
+	if e, g := 3-norm, logprob.TopLogprobs[0].Logprob; math.Abs(e-g) > 1e-9 {
+		t.Errorf("logprob.TopLogprobs[0].Logprob: expected %v, got %v", e, g)
+	}
+
+	if logprob := computeLogprob(logits, 1, 0, piece); logprob.TopLogprobs != nil {
+		t.Errorf("expected no top logprobs, got %v", logprob.TopLogprobs)
+	}
+}
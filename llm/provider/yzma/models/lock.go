@@ -0,0 +1,153 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// lock takes the lockfile of the model, shared by the processes using the
+// cache, waiting for the current holder to release it. The lockfile is kept
+// fresh until it is released, and the lockfile of a holder not refreshing it
+// for the stale lock duration is taken over.
+func (m *Manager) lock(ctx context.Context, path string) (unlock func(), err error) {
+	lockPath := path + lockSuffix
+
+	owner, err := newLockOwner()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = file.WriteString(owner)
+			file.Close()
+			if err != nil {
+				os.Remove(lockPath)
+				return nil, errors.Wrap(err, "could not write lockfile")
+			}
+
+			return m.keepLock(lockPath, owner), nil
+		}
+
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, errors.Wrap(err, "could not create lockfile")
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > m.opts.StaleLock {
+			// The holder is gone, or stuck
+			if err := m.takeOver(lockPath, owner); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.WithStack(ctx.Err())
+		case <-time.After(m.opts.PollInterval):
+		}
+	}
+}
+
+// keepLock refreshes the lockfile until the returned function releases it.
+// The lockfile is only refreshed, and removed, while it is still owned.
+func (m *Manager) keepLock(lockPath, owner string) func() {
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(max(m.opts.StaleLock/4, time.Millisecond))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				if !ownsLock(lockPath, owner) {
+					return
+				}
+				// A lock failing to be refreshed is taken over by another
+				// process
+				_ = os.Chtimes(lockPath, now, now)
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+
+			if ownsLock(lockPath, owner) {
+				os.Remove(lockPath)
+			}
+		})
+	}
+}
+
+// takeOver removes a stale lockfile. It is moved away atomically, so that of
+// several processes taking it over only one succeeds, and put back when it
+// turns out to have been refreshed, or replaced, in the meantime.
+func (m *Manager) takeOver(lockPath, owner string) error {
+	moved := lockPath + "." + owner
+
+	if err := os.Rename(lockPath, moved); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// Another process took it over first
+			return nil
+		}
+		return errors.Wrap(err, "could not take over stale lockfile")
+	}
+
+	defer os.Remove(moved)
+
+	info, err := os.Stat(moved)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if time.Since(info.ModTime()) > m.opts.StaleLock {
+		return nil
+	}
+
+	// The lockfile is alive: it is restored unless another process
+	// locked the model since
+	if err := os.Link(moved, lockPath); err != nil && !errors.Is(err, fs.ErrExist) {
+		return errors.Wrap(err, "could not restore lockfile")
+	}
+
+	return nil
+}
+
+// ownsLock tells if the lockfile was written by the owner
+func ownsLock(lockPath, owner string) bool {
+	data, err := os.ReadFile(lockPath)
+	return err == nil && string(data) == owner
+}
+
+// newLockOwner returns an identifier of the lock holder, unique among the
+// processes and the goroutines using the cache
+func newLockOwner() (string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return fmt.Sprintf("%d-%s", os.Getpid(), hex.EncodeToString(nonce)), nil
+}
@@ -0,0 +1,455 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotFound         = errors.New("model not found")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+const (
+	metadataSuffix = ".json"
+	partialSuffix  = ".part"
+	lockSuffix     = ".lock"
+)
+
+// Model is a model file of the cache
+type Model struct {
+	// Name is the file name of the model in the cache
+	Name string `json:"name"`
+	Path string `json:"-"`
+	Size int64  `json:"size"`
+	// SHA256 is the checksum of the file, empty for the files not pulled by
+	// the manager
+	SHA256 string `json:"sha256,omitempty"`
+	// Source is the reference the model was pulled from
+	Source   string    `json:"source,omitempty"`
+	PulledAt time.Time `json:"pulledAt"`
+}
+
+// Manager manages a cache directory of GGUF model files, shared by the
+// processes using it: concurrent pulls of a model download it once, and
+// interrupted downloads are resumed.
+type Manager struct {
+	opts *Options
+}
+
+// Dir returns the cache directory
+func (m *Manager) Dir() string {
+	return m.opts.Dir
+}
+
+// Pull downloads the model of the reference (see [Manager.Resolve]) to the
+// cache, verifying its checksum when known, and returns it. A model already
+// pulled from the reference is returned as is.
+func (m *Manager) Pull(ctx context.Context, ref string) (*Model, error) {
+	r, err := parseReference(ref, m.opts.Endpoint)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if model, err := m.cached(r.name()); err != nil || model != nil {
+		return model, errors.WithStack(err)
+	}
+
+	source, err := m.Resolve(ctx, ref)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := os.MkdirAll(m.opts.Dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "could not create cache directory")
+	}
+
+	target, err := m.path(source.Name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	unlock, err := m.lock(ctx, target)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer unlock()
+
+	// Another process may have pulled the model while waiting for the lock
+	if model, err := m.cached(source.Name); err != nil || model != nil {
+		return model, errors.WithStack(err)
+	}
+
+	partial := target + partialSuffix
+
+	if err := m.fetch(ctx, source, partial); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sum, err := fileSHA256(partial)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if source.SHA256 != "" && sum != source.SHA256 {
+		// The partial file can not be resumed
+		if err := os.Remove(partial); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return nil, errors.Wrapf(ErrChecksumMismatch, "model '%s' has checksum %s, expected %s", source.Name, sum, source.SHA256)
+	}
+
+	info, err := os.Stat(partial)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	model := &Model{
+		Name:     source.Name,
+		Path:     target,
+		Size:     info.Size(),
+		SHA256:   sum,
+		Source:   ref,
+		PulledAt: time.Now().UTC(),
+	}
+
+	if err := m.writeMetadata(model); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := os.Rename(partial, target); err != nil {
+		return nil, errors.Wrap(err, "could not move downloaded model to the cache")
+	}
+
+	return model, nil
+}
+
+// cached returns the model when it is in the cache, nil otherwise. The name
+// of the model identifies its source, whatever the reference it was pulled
+// from.
+func (m *Manager) cached(name string) (*Model, error) {
+	model, err := m.Get(name)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return model, nil
+}
+
+// fetch downloads the source to the partial file, resuming it when it
+// already holds the beginning of the file
+func (m *Manager) fetch(ctx context.Context, source *Source, partial string) error {
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "could not open partial download")
+	}
+
+	defer file.Close()
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.URL, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	m.authorize(req)
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := m.opts.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "could not download '%s'", source.URL)
+	}
+
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// The server ignored the range, the download starts over
+		if err := file.Truncate(0); err != nil {
+			return errors.WithStack(err)
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return errors.WithStack(err)
+		}
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		file.Close()
+
+		// The partial file may hold the whole source already
+		complete, err := isCompleteDownload(partial, offset, source, res)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if complete {
+			return nil
+		}
+
+		// The partial file is not the beginning of the source anymore
+		if err := os.Remove(partial); err != nil {
+			return errors.WithStack(err)
+		}
+		return m.fetch(ctx, source, partial)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errors.Errorf("access to '%s' denied, a token may be required", source.URL)
+	default:
+		return errors.Errorf("unexpected status '%s' downloading '%s'", res.Status, source.URL)
+	}
+
+	total := source.Size
+	if total == 0 && res.ContentLength > 0 {
+		total = offset + res.ContentLength
+	}
+
+	progress := &progressWriter{
+		downloaded: offset,
+		total:      total,
+		fn:         m.opts.Progress,
+	}
+
+	if _, err := io.Copy(io.MultiWriter(file, progress), res.Body); err != nil {
+		return errors.Wrapf(err, "could not download '%s'", source.URL)
+	}
+
+	if err := file.Sync(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// isCompleteDownload tells if the partial file, of the given size, holds the
+// whole source: its size is the expected one, or the one reported by the
+// server, and so is its checksum when known.
+func isCompleteDownload(partial string, size int64, source *Source, res *http.Response) (bool, error) {
+	total := source.Size
+	if total == 0 {
+		// The server reports the size of the source as "bytes */<size>"
+		if _, reported, ok := strings.Cut(res.Header.Get("Content-Range"), "/"); ok {
+			total, _ = strconv.ParseInt(reported, 10, 64)
+		}
+	}
+
+	if total <= 0 || size != total {
+		return false, nil
+	}
+
+	if source.SHA256 == "" {
+		return true, nil
+	}
+
+	sum, err := fileSHA256(partial)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return sum == source.SHA256, nil
+}
+
+// List returns the models of the cache, sorted by name
+func (m *Manager) List(ctx context.Context) ([]*Model, error) {
+	entries, err := os.ReadDir(m.opts.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read cache directory")
+	}
+
+	models := make([]*Model, 0, len(entries))
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if entry.IsDir() || strings.HasPrefix(name, ".") || hasAnySuffix(name, metadataSuffix, partialSuffix, lockSuffix) {
+			continue
+		}
+
+		model, err := m.Get(name)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		models = append(models, model)
+	}
+
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Name < models[j].Name
+	})
+
+	return models, nil
+}
+
+// Get returns the model of the cache with the name
+func (m *Manager) Get(name string) (*Model, error) {
+	path, err := m.path(name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errors.Wrapf(ErrNotFound, "model '%s'", name)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	model := &Model{
+		Name:     name,
+		PulledAt: info.ModTime().UTC(),
+	}
+
+	// The files downloaded before the manager, or copied in the cache, have
+	// no metadata
+	data, err := os.ReadFile(path + metadataSuffix)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, errors.WithStack(err)
+	}
+
+	if err == nil {
+		if err := json.Unmarshal(data, model); err != nil {
+			return nil, errors.Wrapf(err, "could not decode metadata of model '%s'", name)
+		}
+	}
+
+	model.Path = path
+	model.Size = info.Size()
+
+	return model, nil
+}
+
+// Remove removes the model from the cache, with its partial download
+func (m *Manager) Remove(ctx context.Context, name string) error {
+	path, err := m.path(name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := os.Stat(m.opts.Dir); errors.Is(err, fs.ErrNotExist) {
+		return errors.Wrapf(ErrNotFound, "model '%s'", name)
+	}
+
+	unlock, err := m.lock(ctx, path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer unlock()
+
+	removed := false
+	for _, p := range []string{path, path + metadataSuffix, path + partialSuffix} {
+		err := os.Remove(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not remove model '%s'", name)
+		}
+		removed = true
+	}
+
+	if !removed {
+		return errors.Wrapf(ErrNotFound, "model '%s'", name)
+	}
+
+	return nil
+}
+
+// path returns the path of the model in the cache
+func (m *Manager) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", errors.Errorf("invalid model name '%s'", name)
+	}
+
+	return filepath.Join(m.opts.Dir, name), nil
+}
+
+func (m *Manager) writeMetadata(model *Model) error {
+	data, err := json.MarshalIndent(model, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := os.WriteFile(model.Path+metadataSuffix, data, 0644); err != nil {
+		return errors.Wrapf(err, "could not write metadata of model '%s'", model.Name)
+	}
+
+	return nil
+}
+
+// progressWriter reports the progress of a download
+type progressWriter struct {
+	downloaded int64
+	total      int64
+	fn         ProgressFunc
+}
+
+// Write implements io.Writer.
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.downloaded += int64(len(p))
+
+	if w.fn != nil {
+		w.fn(w.downloaded, w.total)
+	}
+
+	return len(p), nil
+}
+
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", errors.Wrapf(err, "could not compute checksum of '%s'", path)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewManager creates a manager of the cache directory
+func NewManager(funcs ...OptionFunc) *Manager {
+	return &Manager{
+		opts: NewOptions(funcs...),
+	}
+}
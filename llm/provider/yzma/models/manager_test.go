@@ -0,0 +1,450 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// hub is a local stand-in of the Hugging Face Hub serving a single file
+type hub struct {
+	content   []byte
+	token     string
+	delay     time.Duration
+	downloads atomic.Int32
+	mu        sync.Mutex
+	ranges    []string
+}
+
+func (h *hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.token != "" && r.Header.Get("Authorization") != "Bearer "+h.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	sum := sha256.Sum256(h.content)
+
+	switch r.URL.Path {
+	case "/api/models/owner/repo/tree/main/gguf":
+		json.NewEncoder(w).Encode([]map[string]any{
+			{"type": "file", "path": "gguf/README.md", "size": 12},
+			{
+				"type": "file", "path": "gguf/model.gguf", "size": 134,
+				"lfs": map[string]any{"oid": hex.EncodeToString(sum[:]), "size": len(h.content)},
+			},
+		})
+
+	case "/owner/repo/resolve/main/gguf/model.gguf", "/files/model.gguf":
+		h.downloads.Add(1)
+
+		h.mu.Lock()
+		h.ranges = append(h.ranges, r.Header.Get("Range"))
+		h.mu.Unlock()
+
+		time.Sleep(h.delay)
+
+		http.ServeContent(w, r, "model.gguf", time.Time{}, bytes.NewReader(h.content))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestManager(t *testing.T, h *hub) (*Manager, *httptest.Server) {
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+
+	manager := NewManager(
+		WithDir(t.TempDir()),
+		WithEndpoint(server.URL),
+		WithToken(h.token),
+		WithPollInterval(10*time.Millisecond),
+	)
+
+	return manager, server
+}
+
+func TestParseReference(t *testing.T) {
+	type testCase struct {
+		Ref      string
+		Expected reference
+		Name     string
+		Error    bool
+	}
+
+	testCases := []testCase{
+		{
+			Ref:      "Qwen/Qwen3-0.6B-GGUF/Qwen3-0.6B-Q8_0.gguf",
+			Expected: reference{repo: "Qwen/Qwen3-0.6B-GGUF", revision: "main", file: "Qwen3-0.6B-Q8_0.gguf"},
+			Name:     "Qwen--Qwen3-0.6B-GGUF--main--Qwen3-0.6B-Q8_0.gguf",
+		},
+		{
+			Ref:      "hf://owner/repo@v1.0/quants/model-Q4_K_M.gguf",
+			Expected: reference{repo: "owner/repo", revision: "v1.0", file: "quants/model-Q4_K_M.gguf"},
+			Name:     "owner--repo--v1.0--quants--model-Q4_K_M.gguf",
+		},
+		{
+			Ref:      "https://huggingface.co/owner/repo/resolve/main/model.gguf?download=true",
+			Expected: reference{repo: "owner/repo", revision: "main", file: "model.gguf"},
+			Name:     "owner--repo--main--model.gguf",
+		},
+		{
+			Ref:      "https://example.com/files/model.gguf#sha256=ABCD",
+			Expected: reference{url: "https://example.com/files/model.gguf", sha256: "abcd"},
+			Name:     "bd5d51a73722--model.gguf",
+		},
+		{Ref: "owner/repo", Error: true},
+		{Ref: "https://example.com/", Error: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Ref, func(t *testing.T) {
+			r, err := parseReference(tc.Ref, DefaultEndpoint)
+			if tc.Error {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", r)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			if *r != tc.Expected {
+				t.Errorf("expected %+v, got %+v", tc.Expected, *r)
+			}
+
+			if name := r.name(); name != tc.Name {
+				t.Errorf("expected name '%s', got '%s'", tc.Name, name)
+			}
+		})
+	}
+}
+
+func TestManagerPull(t *testing.T) {
+	h := &hub{content: []byte("GGUF model weights"), token: "secret"}
+	manager, _ := newTestManager(t, h)
+
+	ctx := context.Background()
+
+	model, err := manager.Pull(ctx, "owner/repo/gguf/model.gguf")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	sum := sha256.Sum256(h.content)
+	if model.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected checksum %s", model.SHA256)
+	}
+
+	data, err := os.ReadFile(model.Path)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if !bytes.Equal(data, h.content) {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	// The cached model is not downloaded again
+	if _, err := manager.Pull(ctx, "owner/repo/gguf/model.gguf"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if downloads := h.downloads.Load(); downloads != 1 {
+		t.Errorf("expected 1 download, got %d", downloads)
+	}
+
+	models, err := manager.List(ctx)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if len(models) != 1 || models[0].Name != "owner--repo--main--gguf--model.gguf" || models[0].Source != "owner/repo/gguf/model.gguf" || models[0].Size != int64(len(h.content)) {
+		t.Errorf("unexpected models %+v", models)
+	}
+}
+
+func TestManagerPull_SameFileName(t *testing.T) {
+	h := &hub{content: []byte("GGUF model weights")}
+	manager, server := newTestManager(t, h)
+
+	ctx := context.Background()
+
+	// The files of the same name from different sources are both cached
+	for _, ref := range []string{"owner/repo/gguf/model.gguf", server.URL + "/files/model.gguf"} {
+		if _, err := manager.Pull(ctx, ref); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
+	// As are the references of the same file
+	if _, err := manager.Pull(ctx, "hf://owner/repo@main/gguf/model.gguf"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	models, err := manager.List(ctx)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if len(models) != 2 {
+		t.Errorf("expected 2 models, got %+v", models)
+	}
+
+	if downloads := h.downloads.Load(); downloads != 2 {
+		t.Errorf("expected 2 downloads, got %d", downloads)
+	}
+}
+
+func TestManagerPull_Resume(t *testing.T) {
+	h := &hub{content: []byte("GGUF model weights, resumed")}
+	manager, server := newTestManager(t, h)
+
+	if err := os.MkdirAll(manager.Dir(), 0755); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	sum := sha256.Sum256(h.content)
+	ref := fmt.Sprintf("%s/files/model.gguf#sha256=%x", server.URL, sum)
+
+	if err := os.WriteFile(filepath.Join(manager.Dir(), modelName(t, ref)+partialSuffix), h.content[:10], 0644); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	model, err := manager.Pull(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if len(h.ranges) != 1 || h.ranges[0] != "bytes=10-" {
+		t.Errorf("expected the download to be resumed, got ranges %v", h.ranges)
+	}
+
+	data, err := os.ReadFile(model.Path)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if !bytes.Equal(data, h.content) {
+		t.Errorf("unexpected content %q", data)
+	}
+}
+
+func TestManagerPull_ResumeComplete(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		partial   []byte
+		checksum  bool
+		downloads int32
+	}{
+		{name: "complete", partial: []byte("GGUF model weights"), checksum: true, downloads: 1},
+		{name: "complete without checksum", partial: []byte("GGUF model weights"), downloads: 1},
+		{name: "corrupted", partial: []byte("GGUF model weighs!"), checksum: true, downloads: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &hub{content: []byte("GGUF model weights")}
+			manager, server := newTestManager(t, h)
+
+			if err := os.MkdirAll(manager.Dir(), 0755); err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			ref := server.URL + "/files/model.gguf"
+			if tc.checksum {
+				ref += fmt.Sprintf("#sha256=%x", sha256.Sum256(h.content))
+			}
+
+			if err := os.WriteFile(filepath.Join(manager.Dir(), modelName(t, ref)+partialSuffix), tc.partial, 0644); err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			model, err := manager.Pull(context.Background(), ref)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			// The server answers the resumption of a complete file with a 416
+			if downloads := h.downloads.Load(); downloads != tc.downloads {
+				t.Errorf("expected %d downloads, got %d (ranges %v)", tc.downloads, downloads, h.ranges)
+			}
+
+			data, err := os.ReadFile(model.Path)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			if !bytes.Equal(data, h.content) {
+				t.Errorf("unexpected content %q", data)
+			}
+		})
+	}
+}
+
+func TestManagerPull_ChecksumMismatch(t *testing.T) {
+	h := &hub{content: []byte("corrupted weights")}
+	manager, server := newTestManager(t, h)
+
+	ref := server.URL + "/files/model.gguf#sha256=0000"
+
+	_, err := manager.Pull(context.Background(), ref)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+
+	if _, err := manager.Get(modelName(t, ref)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the model not to be cached, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(manager.Dir(), modelName(t, ref)+partialSuffix)); err == nil {
+		t.Errorf("expected the partial download to be removed")
+	}
+}
+
+func TestManagerPull_Concurrent(t *testing.T) {
+	h := &hub{content: []byte("GGUF model weights"), delay: 100 * time.Millisecond}
+	manager, server := newTestManager(t, h)
+
+	ref := server.URL + "/files/model.gguf"
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Each manager stands for a process sharing the cache
+			other := NewManager(WithDir(manager.Dir()), WithPollInterval(10*time.Millisecond))
+			if _, err := other.Pull(context.Background(), ref); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("%+v", err)
+	}
+
+	if downloads := h.downloads.Load(); downloads != 1 {
+		t.Errorf("expected 1 download, got %d", downloads)
+	}
+}
+
+func TestManagerRemove(t *testing.T) {
+	h := &hub{content: []byte("GGUF model weights")}
+	manager, server := newTestManager(t, h)
+
+	ctx := context.Background()
+
+	ref := server.URL + "/files/model.gguf"
+
+	if err := manager.Remove(ctx, modelName(t, ref)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+
+	model, err := manager.Pull(ctx, ref)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if err := manager.Remove(ctx, model.Name); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	models, err := manager.List(ctx)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if len(models) != 0 {
+		t.Errorf("expected no model, got %+v", models)
+	}
+
+	if err := manager.Remove(ctx, "../model.gguf"); err == nil {
+		t.Errorf("expected invalid name error")
+	}
+}
+
+func TestManagerLock(t *testing.T) {
+	stale := 200 * time.Millisecond
+	manager := NewManager(WithDir(t.TempDir()), WithStaleLock(stale), WithPollInterval(10*time.Millisecond))
+
+	path := filepath.Join(manager.Dir(), "model.gguf")
+	ctx := context.Background()
+
+	unlock, err := manager.lock(ctx, path)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	// A held lock is kept fresh, and so not taken over
+	time.Sleep(2 * stale)
+
+	waitCtx, cancel := context.WithTimeout(ctx, stale)
+	defer cancel()
+
+	if _, err := manager.lock(waitCtx, path); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the held lock to be waited for, got %+v", err)
+	}
+
+	// A lock not refreshed anymore, its holder being gone, is taken over
+	lockPath := path + lockSuffix
+	past := time.Now().Add(-2 * stale)
+
+	if err := os.WriteFile(lockPath, []byte("gone"), 0644); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if err := os.Chtimes(lockPath, past, past); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	unlockOther, err := manager.lock(ctx, path)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	// The lock taken over is not removed by its previous holder
+	unlock()
+
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Fatalf("expected the lock to be kept, got %+v", err)
+	}
+
+	unlockOther()
+
+	if _, err := os.Stat(lockPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the lock to be released, got %+v", err)
+	}
+}
+
+// modelName returns the name in the cache of the model of the reference
+func modelName(t *testing.T, ref string) string {
+	t.Helper()
+
+	r, err := parseReference(ref, DefaultEndpoint)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	return r.name()
+}
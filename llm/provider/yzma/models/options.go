@@ -0,0 +1,113 @@
+package models
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultEndpoint is the Hugging Face Hub the repository references are
+// resolved against
+const DefaultEndpoint = "https://huggingface.co"
+
+// ProgressFunc is called while downloading a model with the bytes received
+// so far, resumed ones included, and the expected size, 0 when unknown
+type ProgressFunc func(downloaded int64, total int64)
+
+type Options struct {
+	// Dir is the cache directory of the models
+	Dir        string
+	HTTPClient *http.Client
+	// Endpoint is the Hugging Face Hub base URL
+	Endpoint string
+	// Token authenticates the requests to the Hugging Face Hub, for gated
+	// and private repositories
+	Token    string
+	Progress ProgressFunc
+	// StaleLock is the time after which the lock of a download not making
+	// progress is considered abandoned by its process
+	StaleLock time.Duration
+	// PollInterval is the interval at which a locked model is checked
+	PollInterval time.Duration
+}
+
+type OptionFunc func(opts *Options)
+
+func NewOptions(funcs ...OptionFunc) *Options {
+	opts := &Options{
+		Dir:          DefaultDir(),
+		HTTPClient:   http.DefaultClient,
+		Endpoint:     DefaultEndpoint,
+		StaleLock:    time.Minute,
+		PollInterval: 500 * time.Millisecond,
+	}
+
+	for _, fn := range funcs {
+		fn(opts)
+	}
+
+	return opts
+}
+
+// DefaultDir returns the default cache directory, ~/.yzma/models, where the
+// yzma provider downloads the models of MODEL_URL
+func DefaultDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "yzma", "models")
+	}
+
+	return filepath.Join(homeDir, ".yzma", "models")
+}
+
+// WithDir sets the cache directory
+func WithDir(dir string) OptionFunc {
+	return func(opts *Options) {
+		opts.Dir = dir
+	}
+}
+
+// WithHTTPClient sets the HTTP client of the downloads
+func WithHTTPClient(client *http.Client) OptionFunc {
+	return func(opts *Options) {
+		opts.HTTPClient = client
+	}
+}
+
+// WithEndpoint sets the Hugging Face Hub base URL, for mirrors
+func WithEndpoint(endpoint string) OptionFunc {
+	return func(opts *Options) {
+		opts.Endpoint = endpoint
+	}
+}
+
+// WithToken sets the Hugging Face Hub access token
+func WithToken(token string) OptionFunc {
+	return func(opts *Options) {
+		opts.Token = token
+	}
+}
+
+// WithProgress sets the function following the downloads
+func WithProgress(fn ProgressFunc) OptionFunc {
+	return func(opts *Options) {
+		opts.Progress = fn
+	}
+}
+
+// WithStaleLock sets the time after which the lock of a download not making
+// progress is taken over
+func WithStaleLock(stale time.Duration) OptionFunc {
+	return func(opts *Options) {
+		opts.StaleLock = stale
+	}
+}
+
+// WithPollInterval sets the interval at which a model locked by another
+// download is checked
+func WithPollInterval(interval time.Duration) OptionFunc {
+	return func(opts *Options) {
+		opts.PollInterval = interval
+	}
+}
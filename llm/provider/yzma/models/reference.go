@@ -0,0 +1,253 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Source is a model file to download
+type Source struct {
+	// Ref is the reference the source is resolved from
+	Ref string
+	URL string
+	// Name is the file name of the model in the cache
+	Name string
+	// SHA256 is the expected checksum of the file, empty when unknown
+	SHA256 string
+	// Size is the expected size of the file, 0 when unknown
+	Size int64
+}
+
+// reference is a parsed model reference, either a file of a Hugging Face
+// repository or a plain URL
+type reference struct {
+	repo     string
+	revision string
+	file     string
+	url      string
+	sha256   string
+}
+
+// nameSeparator separates the parts of the name of a model in the cache
+const nameSeparator = "--"
+
+// name returns the file name of the model in the cache, namespaced so that
+// the files of the same name from different sources do not collide:
+// "<owner>--<repo>--<revision>--<file>" for a repository file,
+// "<hash>--<file>" for a URL, the hash being the one of the URL.
+func (r *reference) name() string {
+	if r.repo != "" {
+		parts := []string{r.repo, r.revision, r.file}
+		return strings.ReplaceAll(strings.Join(parts, "/"), "/", nameSeparator)
+	}
+
+	u, err := url.Parse(r.url)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(r.url))
+
+	return hex.EncodeToString(sum[:6]) + nameSeparator + path.Base(u.Path)
+}
+
+// parseReference parses a model reference, the URLs of the endpoint being
+// parsed as repository files
+func parseReference(ref string, endpoint string) (*reference, error) {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		return parseURLReference(ref, endpoint)
+	}
+
+	return parseRepoReference(strings.TrimPrefix(ref, "hf://"))
+}
+
+// parseRepoReference parses a "<owner>/<repo>[@<revision>]/<file>" reference
+func parseRepoReference(ref string) (*reference, error) {
+	parts := strings.SplitN(ref, "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, errors.Errorf("invalid model reference '%s', expected '<owner>/<repo>[@<revision>]/<file>' or a URL", ref)
+	}
+
+	repo, revision, found := strings.Cut(parts[1], "@")
+	if !found || revision == "" {
+		revision = "main"
+	}
+
+	return &reference{
+		repo:     parts[0] + "/" + repo,
+		revision: revision,
+		file:     parts[2],
+	}, nil
+}
+
+// parseURLReference parses a URL reference, with an optional
+// "#sha256=<hex>" fragment. The resolve and blob URLs of the endpoint are
+// parsed as repository files.
+func parseURLReference(ref string, endpoint string) (*reference, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid model URL '%s'", ref)
+	}
+
+	var sha256 string
+	if u.Fragment != "" {
+		values, err := url.ParseQuery(u.Fragment)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid fragment of model URL '%s'", ref)
+		}
+
+		sha256 = strings.ToLower(values.Get("sha256"))
+		u.Fragment = ""
+	}
+
+	if base, err := url.Parse(endpoint); err == nil && u.Host == base.Host {
+		// /<owner>/<repo>/(resolve|blob)/<revision>/<file>
+		parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 5)
+		if len(parts) == 5 && (parts[2] == "resolve" || parts[2] == "blob") {
+			return &reference{
+				repo:     parts[0] + "/" + parts[1],
+				revision: parts[3],
+				file:     parts[4],
+				sha256:   sha256,
+			}, nil
+		}
+	}
+
+	if path.Base(u.Path) == "" || path.Base(u.Path) == "/" || path.Base(u.Path) == "." {
+		return nil, errors.Errorf("model URL '%s' has no file name", ref)
+	}
+
+	return &reference{url: u.String(), sha256: sha256}, nil
+}
+
+// Resolve resolves the model reference to the file to download:
+//
+//   - "<owner>/<repo>[@<revision>]/<file>", optionally prefixed with "hf://",
+//     is a file of a Hugging Face repository, the revision defaulting to main;
+//   - the resolve and blob URLs of the Hugging Face Hub are files of their
+//     repository;
+//   - any other URL is downloaded as is.
+//
+// The checksum of the repository files stored with Git LFS, as GGUF files
+// are, is fetched from the Hub. The checksum of a URL can be given with a
+// "#sha256=<hex>" fragment.
+func (m *Manager) Resolve(ctx context.Context, ref string) (*Source, error) {
+	r, err := parseReference(ref, m.opts.Endpoint)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if r.repo == "" {
+		return &Source{Ref: ref, URL: r.url, Name: r.name(), SHA256: r.sha256}, nil
+	}
+
+	source, err := m.resolveRepoFile(ctx, r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	source.Ref = ref
+
+	if r.sha256 != "" {
+		source.SHA256 = r.sha256
+	}
+
+	return source, nil
+}
+
+// treeEntry is an entry of the Hugging Face Hub tree API
+type treeEntry struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	LFS  *struct {
+		OID  string `json:"oid"`
+		Size int64  `json:"size"`
+	} `json:"lfs"`
+}
+
+// resolveRepoFile looks the file up in the tree of its repository directory
+func (m *Manager) resolveRepoFile(ctx context.Context, r *reference) (*Source, error) {
+	endpoint := strings.TrimSuffix(m.opts.Endpoint, "/")
+
+	treeURL := fmt.Sprintf("%s/api/models/%s/tree/%s", endpoint, r.repo, url.PathEscape(r.revision))
+	if dir := path.Dir(r.file); dir != "." {
+		treeURL += "/" + dir
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, treeURL, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	m.authorize(req)
+
+	res, err := m.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not fetch tree of repository '%s'", r.repo)
+	}
+
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, errors.Errorf("access to repository '%s' denied, a token may be required", r.repo)
+	case http.StatusNotFound:
+		return nil, errors.Errorf("repository '%s' or revision '%s' not found", r.repo, r.revision)
+	default:
+		return nil, errors.Errorf("unexpected status '%s' fetching tree of repository '%s'", res.Status, r.repo)
+	}
+
+	var entries []treeEntry
+	if err := json.NewDecoder(res.Body).Decode(&entries); err != nil {
+		return nil, errors.Wrapf(err, "could not decode tree of repository '%s'", r.repo)
+	}
+
+	for _, entry := range entries {
+		if entry.Type != "file" || entry.Path != r.file {
+			continue
+		}
+
+		source := &Source{
+			URL:  fmt.Sprintf("%s/%s/resolve/%s/%s", endpoint, r.repo, url.PathEscape(r.revision), r.file),
+			Name: r.name(),
+			Size: entry.Size,
+		}
+
+		// The object id of the files not stored with Git LFS is their Git
+		// hash, not a SHA256 checksum
+		if entry.LFS != nil {
+			source.SHA256 = strings.ToLower(entry.LFS.OID)
+			source.Size = entry.LFS.Size
+		}
+
+		return source, nil
+	}
+
+	return nil, errors.Errorf("file '%s' not found in repository '%s' at revision '%s'", r.file, r.repo, r.revision)
+}
+
+// authorize authenticates the requests to the Hugging Face Hub, the token
+// not being sent to other hosts
+func (m *Manager) authorize(req *http.Request) {
+	if m.opts.Token == "" {
+		return
+	}
+
+	base, err := url.Parse(m.opts.Endpoint)
+	if err != nil || req.URL.Host != base.Host {
+		return
+	}
+
+	req.Header.Set("Authorization", "Bearer "+m.opts.Token)
+}
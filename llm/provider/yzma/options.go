@@ -6,6 +6,8 @@ import "github.com/pkg/errors"
 type ChatCompletionOptions struct {
 	ModelPath       string  `env:"MODEL_PATH"`
 	ModelURL        string  `env:"MODEL_URL"`
+	MMProjPath      string  `env:"MMPROJ_PATH"`
+	MMProjURL       string  `env:"MMPROJ_URL"`
 	LibPath         string  `env:"LIB_PATH"`
 	Processor       string  `env:"PROCESSOR"`
 	Version         string  `env:"VERSION"`
@@ -21,6 +23,7 @@ type ChatCompletionOptions struct {
 	PredictSize     int     `env:"PREDICT_SIZE"`
 	Template        string  `env:"TEMPLATE"`
 	Verbose         bool    `env:"VERBOSE"`
+	Slots           int     `env:"SLOTS"`
 }
 
 func defaultChatCompletionOptions() *ChatCompletionOptions {
@@ -35,6 +38,7 @@ func defaultChatCompletionOptions() *ChatCompletionOptions {
 		PresencePenalty: 2.0,
 		PenaltyLastN:    64,
 		PredictSize:     32768,
+		Slots:           1,
 	}
 }
 
@@ -0,0 +1,265 @@
+package yzma
+
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/hybridgroup/yzma/pkg/llama"
+	"github.com/pkg/errors"
+)
+
+// newSampler creates the sampling chain, the sampling controls of the options
+// overriding the defaults of the client
+func (c *ChatCompletionClient) newSampler(opts *llm.ChatCompletionOptions) llama.Sampler {
+	sp := llama.DefaultSamplerParams()
+	sp.Temp = float32(opts.Temperature)
+	sp.TopK = int32(c.topK)
+	sp.TopP = float32(c.topP)
+	sp.MinP = float32(c.minP)
+	sp.PenaltyPresent = float32(c.presencePenalty)
+	sp.PenaltyLastN = int32(c.penaltyLastN)
+
+	if opts.TopK != nil {
+		sp.TopK = int32(*opts.TopK)
+	}
+	if opts.TopP != nil {
+		sp.TopP = float32(*opts.TopP)
+	}
+	if opts.MinP != nil {
+		sp.MinP = float32(*opts.MinP)
+	}
+	if opts.PresencePenalty != nil {
+		sp.PenaltyPresent = float32(*opts.PresencePenalty)
+	}
+	if opts.FrequencyPenalty != nil {
+		sp.PenaltyFreq = float32(*opts.FrequencyPenalty)
+	}
+	if opts.Seed != nil {
+		sp.Seed = uint32(*opts.Seed)
+	}
+
+	samplers := []llama.SamplerType{
+		llama.SamplerTypePenalties,
+		llama.SamplerTypeTopK,
+		llama.SamplerTypeTopP,
+		llama.SamplerTypeMinP,
+		llama.SamplerTypeTemperature,
+	}
+	sampler := llama.NewSampler(c.model, samplers, sp)
+
+	if len(opts.LogitBias) == 0 {
+		return sampler
+	}
+
+	// The biases must apply before the other samplers, the chain created by
+	// llama.NewSampler is therefore nested in a chain starting with them
+	biases := make([]llama.LogitBias, 0, len(opts.LogitBias))
+	for token, bias := range opts.LogitBias {
+		biases = append(biases, llama.LogitBias{Token: llama.Token(token), Bias: float32(bias)})
+	}
+
+	chain := llama.SamplerChainInit(llama.SamplerChainDefaultParams())
+	llama.SamplerChainAdd(chain, llama.SamplerInitLogitBias(llama.VocabNTokens(c.vocab), int32(len(biases)), unsafe.SliceData(biases)))
+	llama.SamplerChainAdd(chain, sampler)
+
+	return chain
+}
+
+// stopMatcher detects the stop sequences in the generated text, holding back
+// the text which may be the beginning of one of them
+type stopMatcher struct {
+	sequences []string
+	pending   string
+}
+
+// Push adds a piece of generated text, returning the text which can be
+// emitted and whether a stop sequence was reached, the sequence and what
+// follows being discarded
+func (m *stopMatcher) Push(piece string) (string, bool) {
+	if len(m.sequences) == 0 {
+		return piece, false
+	}
+
+	m.pending += piece
+
+	stop := -1
+	for _, s := range m.sequences {
+		if idx := strings.Index(m.pending, s); idx != -1 && (stop == -1 || idx < stop) {
+			stop = idx
+		}
+	}
+
+	if stop != -1 {
+		emit := m.pending[:stop]
+		m.pending = ""
+		return emit, true
+	}
+
+	// Hold back the longest suffix beginning a stop sequence
+	hold := 0
+	for _, s := range m.sequences {
+		for n := min(len(s)-1, len(m.pending)); n > hold; n-- {
+			if strings.HasSuffix(m.pending, s[:n]) {
+				hold = n
+				break
+			}
+		}
+	}
+
+	emit := m.pending[:len(m.pending)-hold]
+	m.pending = m.pending[len(m.pending)-hold:]
+
+	return emit, false
+}
+
+// Flush returns the text held back
+func (m *stopMatcher) Flush() string {
+	pending := m.pending
+	m.pending = ""
+	return pending
+}
+
+// newGrammarSampler creates a sampling chain constraining the tokens with the
+// grammar before the other samplers
+func (c *ChatCompletionClient) newGrammarSampler(opts *llm.ChatCompletionOptions, grammar string) (llama.Sampler, error) {
+	constraint := llama.SamplerInitGrammar(c.vocab, grammar, "root")
+	if constraint == 0 {
+		return 0, errors.Errorf("invalid grammar:\n%s", grammar)
+	}
+
+	chain := llama.SamplerChainInit(llama.SamplerChainDefaultParams())
+	llama.SamplerChainAdd(chain, constraint)
+	llama.SamplerChainAdd(chain, c.newSampler(opts))
+
+	return chain, nil
+}
+
+// constrainedSampler samples the generated tokens under the grammars of the
+// request. A response schema, or required tool calls, constrain the whole
+// response. Otherwise, the tool calls grammar constrains what follows the
+// opening marker of a call, the model remaining free to answer in text.
+type constrainedSampler struct {
+	client *ChatCompletionClient
+	opts   *llm.ChatCompletionOptions
+
+	sampler llama.Sampler
+
+	// toolCall is the grammar of a tool call, empty when the tool calls are
+	// not constrained
+	toolCall string
+	// call is the sampler of the tool call being generated, 0 outside of one
+	call llama.Sampler
+	// text is the text generated since the last marker
+	text string
+}
+
+// newConstrainedSampler creates the sampler of the request
+func (c *ChatCompletionClient) newConstrainedSampler(opts *llm.ChatCompletionOptions) (*constrainedSampler, error) {
+	s := &constrainedSampler{
+		client: c,
+		opts:   opts,
+	}
+
+	hasTools := len(opts.Tools) > 0 && opts.ToolChoice != llm.ToolChoiceNone
+
+	var (
+		grammar string
+		err     error
+	)
+
+	switch {
+	case hasTools && opts.ToolChoice == llm.ToolChoiceRequired:
+		grammar, err = toolCallGrammar(opts.Tools, true)
+	case hasTools && opts.ResponseFormat == llm.ResponseFormatJSON:
+		// The model either answers in JSON, or calls tools
+		grammar, err = responseOrToolCallGrammar(opts.ResponseSchema, opts.Tools)
+	case opts.ResponseFormat == llm.ResponseFormatJSON:
+		grammar, err = responseGrammar(opts.ResponseSchema)
+	case hasTools:
+		s.toolCall, err = toolCallGrammar(opts.Tools, false)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if grammar == "" {
+		s.sampler = c.newSampler(opts)
+		return s, nil
+	}
+
+	if s.sampler, err = c.newGrammarSampler(opts, grammar); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return s, nil
+}
+
+// Sample samples the next token
+func (s *constrainedSampler) Sample(lctx llama.Context) llama.Token {
+	if s.call != 0 {
+		return llama.SamplerSample(s.call, lctx, -1)
+	}
+
+	return llama.SamplerSample(s.sampler, lctx, -1)
+}
+
+// Push adds the text of the sampled token, switching to the tool call grammar
+// when the model opens a call and back when it closes it
+func (s *constrainedSampler) Push(piece string) error {
+	if s.toolCall == "" {
+		return nil
+	}
+
+	s.text += piece
+
+	if s.call != 0 {
+		idx := strings.Index(s.text, toolCallClose)
+		if idx == -1 {
+			return nil
+		}
+
+		llama.SamplerFree(s.call)
+		s.call = 0
+		s.text = s.text[idx+len(toolCallClose):]
+
+		return nil
+	}
+
+	idx := strings.Index(s.text, toolCallOpen)
+	if idx == -1 {
+		// Only keep what may be the beginning of a marker
+		if keep := len(toolCallOpen) - 1; len(s.text) > keep {
+			s.text = s.text[len(s.text)-keep:]
+		}
+		return nil
+	}
+
+	s.text = s.text[idx+len(toolCallOpen):]
+
+	// The grammar can not be fed the text already generated: a token
+	// spanning the beginning of the call leaves it unconstrained, the call
+	// being parsed as is
+	if strings.TrimSpace(s.text) != "" {
+		return nil
+	}
+
+	call, err := s.client.newGrammarSampler(s.opts, s.toolCall)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.call = call
+
+	return nil
+}
+
+// Free releases the samplers
+func (s *constrainedSampler) Free() {
+	if s.call != 0 {
+		llama.SamplerFree(s.call)
+		s.call = 0
+	}
+
+	llama.SamplerFree(s.sampler)
+}
@@ -0,0 +1,70 @@
+package yzma
+
+import "testing"
+
+func TestStopMatcher(t *testing.T) {
+	type testCase struct {
+		Name      string
+		Sequences []string
+		Pieces    []string
+		Expected  string
+		Stopped   bool
+	}
+
+	testCases := []testCase{
+		{
+			Name:     "no sequences",
+			Pieces:   []string{"Hello", " world"},
+			Expected: "Hello world",
+		},
+		{
+			Name:      "sequence in a piece",
+			Sequences: []string{"END"},
+			Pieces:    []string{"Hello", " worldEND", " ignored"},
+			Expected:  "Hello world",
+			Stopped:   true,
+		},
+		{
+			Name:      "sequence across pieces",
+			Sequences: []string{"\n\n", "STOP"},
+			Pieces:    []string{"Hello", " S", "T", "OP", " ignored"},
+			Expected:  "Hello ",
+			Stopped:   true,
+		},
+		{
+			Name:      "held back text is flushed",
+			Sequences: []string{"STOP"},
+			Pieces:    []string{"Hello", " ST"},
+			Expected:  "Hello ST",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			m := &stopMatcher{sequences: tc.Sequences}
+
+			var (
+				got     string
+				stopped bool
+			)
+			for _, p := range tc.Pieces {
+				var emit string
+				emit, stopped = m.Push(p)
+				got += emit
+				if stopped {
+					break
+				}
+			}
+			if !stopped {
+				got += m.Flush()
+			}
+
+			if got != tc.Expected {
+				t.Errorf("expected '%s', got '%s'", tc.Expected, got)
+			}
+			if stopped != tc.Stopped {
+				t.Errorf("expected stopped %v, got %v", tc.Stopped, stopped)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package yzma
+
+import (
+	"log/slog"
+
+	"github.com/bornholm/genai/llm/context"
+	"github.com/hybridgroup/yzma/pkg/llama"
+	"github.com/pkg/errors"
+)
+
+type contextKey string
+
+const contextKeySession contextKey = "session"
+
+// ContextSession returns the session of the calls made with the context
+func ContextSession(ctx context.Context) (string, error) {
+	return context.Value[string](ctx, contextKeySession)
+}
+
+// WithSession assigns the calls made with the context to the session, each
+// session keeping its own KV cache slot (see [WithSlots]). The calls without
+// session share the default one.
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, contextKeySession, session)
+}
+
+// slot is a sequence of the KV cache, holding the tokens decoded for a
+// session. The prompt of the next call of the session only decodes the
+// tokens following the prefix it has in common with them.
+type slot struct {
+	id       llama.SeqId
+	session  string
+	assigned bool
+	// tokens are the tokens decoded in the sequence, prompt and generated
+	// ones, at the positions of their index
+	tokens []llama.Token
+	// pos is the position following the decoded tokens, past their count when
+	// the prompt holds images (see [ChatCompletionClient.evalChunks])
+	pos llama.Pos
+	// stale slots can not be reused and are cleared before their next use
+	stale bool
+	// used is the order of the last use of the slot, the least recently used
+	// being evicted when a session needs a slot
+	used uint64
+}
+
+// acquireSlot returns the slot of the session, assigning it the least
+// recently used one when it has none
+func (c *ChatCompletionClient) acquireSlot(ctx context.Context, session string) (*slot, error) {
+	c.uses++
+
+	s, found := pickSlot(c.slots, session)
+	if found {
+		s.used = c.uses
+		return s, nil
+	}
+
+	if s.assigned {
+		slog.DebugContext(ctx, "evicting kv cache slot", slog.String("session", s.session), slog.Int("slot", int(s.id)))
+	}
+
+	if err := c.clearSlot(s); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	s.session = session
+	s.assigned = true
+	s.used = c.uses
+
+	return s, nil
+}
+
+// pickSlot returns the slot assigned to the session, found being true, or
+// else the slot to assign it: a free one or the least recently used
+func pickSlot(slots []*slot, session string) (s *slot, found bool) {
+	var lru *slot
+	for _, s := range slots {
+		if s.assigned && s.session == session {
+			return s, true
+		}
+
+		switch {
+		case lru == nil:
+			lru = s
+		case lru.assigned && !s.assigned:
+			lru = s
+		case lru.assigned == s.assigned && s.used < lru.used:
+			lru = s
+		}
+	}
+
+	return lru, false
+}
+
+// clearSlot removes the tokens of the slot from the KV cache
+func (c *ChatCompletionClient) clearSlot(s *slot) error {
+	s.tokens = nil
+	s.pos = 0
+	s.stale = false
+
+	mem, err := llama.GetMemory(c.lctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get memory")
+	}
+
+	if _, err := llama.MemorySeqRm(mem, s.id, -1, -1); err != nil {
+		return errors.Wrap(err, "failed to clear sequence")
+	}
+
+	return nil
+}
+
+// prefill decodes the prompt tokens in the slot, reusing the prefix they
+// have in common with the tokens already decoded
+func (c *ChatCompletionClient) prefill(ctx context.Context, s *slot, tokens []llama.Token) error {
+	if len(tokens) == 0 {
+		return errors.New("empty prompt")
+	}
+
+	// Encoder-decoder models decode from the encoded prompt, which can not
+	// be reused
+	if llama.ModelHasEncoder(c.model) {
+		if err := c.clearSlot(s); err != nil {
+			return errors.WithStack(err)
+		}
+
+		s.stale = true
+
+		llama.Encode(c.lctx, llama.BatchGetOne(tokens))
+
+		start := llama.ModelDecoderStartToken(c.model)
+		if start == llama.TokenNull {
+			start = llama.VocabBOS(c.vocab)
+		}
+
+		return c.decode(s, []llama.Token{start})
+	}
+
+	if s.stale {
+		if err := c.clearSlot(s); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// The logits of the last prompt token are needed to sample the first
+	// generated one, it is therefore decoded again when already in the cache
+	reused := min(commonPrefix(s.tokens, tokens), len(tokens)-1)
+
+	if reused < len(s.tokens) {
+		mem, err := llama.GetMemory(c.lctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to get memory")
+		}
+
+		removed, err := llama.MemorySeqRm(mem, s.id, llama.Pos(reused), -1)
+		if err != nil {
+			return errors.Wrap(err, "failed to remove divergent tokens")
+		}
+
+		// Some models (recurrent ones) can not remove part of a sequence
+		if !removed {
+			if err := c.clearSlot(s); err != nil {
+				return errors.WithStack(err)
+			}
+			reused = 0
+		}
+
+		s.tokens = s.tokens[:reused]
+		s.pos = llama.Pos(reused)
+	}
+
+	slog.DebugContext(ctx, "reusing kv cache prefix",
+		slog.String("session", s.session),
+		slog.Int("reused", reused),
+		slog.Int("decoded", len(tokens)-reused),
+	)
+
+	return c.decode(s, tokens[reused:])
+}
+
+// decode decodes the tokens in the slot, after the tokens it holds, in
+// batches to handle large prompts. Only the logits of the last token are
+// computed.
+func (c *ChatCompletionClient) decode(s *slot, tokens []llama.Token) error {
+	batch := llama.BatchInit(int32(min(c.batchSize, len(tokens))), 0, 1)
+	defer llama.BatchFree(batch)
+
+	for start := 0; start < len(tokens); start += c.batchSize {
+		chunk := tokens[start:min(start+c.batchSize, len(tokens))]
+
+		batch.Clear()
+		for i, token := range chunk {
+			batch.Add(token, s.pos+llama.Pos(i), []llama.SeqId{s.id}, start+i == len(tokens)-1)
+		}
+
+		if _, err := llama.Decode(c.lctx, batch); err != nil {
+			// The state of the sequence is unknown
+			s.stale = true
+			return errors.Wrapf(err, "failed to decode tokens at position %d", s.pos)
+		}
+
+		s.tokens = append(s.tokens, chunk...)
+		s.pos += llama.Pos(len(chunk))
+	}
+
+	return nil
+}
+
+// SaveSession saves the KV cache of the session to the file, to be loaded
+// back with LoadSession, in this process or another one using the same
+// model, for a warm start.
+func (c *ChatCompletionClient) SaveSession(ctx context.Context, session string, path string) error {
+	if err := c.ensureLoaded(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, found := pickSlot(c.slots, session)
+	if !found || s.stale {
+		return errors.Errorf("no kv cache for session '%s'", session)
+	}
+
+	if written := llama.StateSeqSaveFile(c.lctx, path, s.id, s.tokens); written == 0 {
+		return errors.Errorf("could not save kv cache of session '%s' to '%s'", session, path)
+	}
+
+	return nil
+}
+
+// LoadSession loads the KV cache of the session from a file written by
+// SaveSession, the next call of the session only decoding what follows.
+func (c *ChatCompletionClient) LoadSession(ctx context.Context, session string, path string) error {
+	if err := c.ensureLoaded(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, err := c.acquireSlot(ctx, session)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := c.clearSlot(s); err != nil {
+		return errors.WithStack(err)
+	}
+
+	tokens := make([]llama.Token, c.contextSize)
+	var count uint64
+
+	if read := llama.StateSeqLoadFile(c.lctx, path, s.id, tokens, uint64(len(tokens)), &count); read == 0 {
+		return errors.Errorf("could not load kv cache of session '%s' from '%s'", session, path)
+	}
+
+	s.tokens = tokens[:count]
+	s.pos = llama.Pos(count)
+
+	return nil
+}
+
+// commonPrefix returns the length of the prefix common to both sequences
+func commonPrefix(a, b []llama.Token) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,52 @@
+package yzma
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/yzma/pkg/llama"
+)
+
+func TestCommonPrefix(t *testing.T) {
+	type testCase struct {
+		Name     string
+		A, B     []llama.Token
+		Expected int
+	}
+
+	testCases := []testCase{
+		{Name: "empty cache", A: nil, B: []llama.Token{1, 2}, Expected: 0},
+		{Name: "appended turn", A: []llama.Token{1, 2, 3}, B: []llama.Token{1, 2, 3, 4, 5}, Expected: 3},
+		{Name: "divergent history", A: []llama.Token{1, 2, 3, 4}, B: []llama.Token{1, 2, 5}, Expected: 2},
+		{Name: "same prompt", A: []llama.Token{1, 2, 3}, B: []llama.Token{1, 2, 3}, Expected: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if got := commonPrefix(tc.A, tc.B); got != tc.Expected {
+				t.Errorf("expected %d, got %d", tc.Expected, got)
+			}
+		})
+	}
+}
+
+func TestPickSlot(t *testing.T) {
+	slots := []*slot{
+		{id: 0, session: "a", assigned: true, used: 3},
+		{id: 1, session: "b", assigned: true, used: 1},
+		{id: 2},
+	}
+
+	if s, found := pickSlot(slots, "a"); !found || s.id != 0 {
+		t.Errorf("expected the slot of the session, got %d (found: %v)", s.id, found)
+	}
+
+	if s, found := pickSlot(slots, "c"); found || s.id != 2 {
+		t.Errorf("expected the free slot, got %d (found: %v)", s.id, found)
+	}
+
+	slots[2].session, slots[2].assigned, slots[2].used = "c", true, 2
+
+	if s, found := pickSlot(slots, "d"); found || s.id != 1 {
+		t.Errorf("expected the least recently used slot, got %d (found: %v)", s.id, found)
+	}
+}
@@ -0,0 +1,221 @@
+package yzma
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/context"
+	"github.com/hybridgroup/yzma/pkg/llama"
+	"github.com/hybridgroup/yzma/pkg/mtmd"
+	"github.com/pkg/errors"
+)
+
+// mediaMarker is the default marker of mtmd, replaced by the chunks of the
+// image when tokenizing the prompt
+const mediaMarker = "<__media__>"
+
+// ImageLimits are the constraints of the multimodal projectors on input
+// images, to be passed to llm.WithImageLimits: the projector resizes the
+// images itself, only their type must be decodable by llama.cpp.
+var ImageLimits = llm.ImageLimits{
+	MimeTypes: []string{"image/png", "image/jpeg", "image/bmp", "image/gif"},
+}
+
+// ValidateAttachment implements llm.AttachmentValidator.
+func (c *ChatCompletionClient) ValidateAttachment(attachment llm.Attachment) error {
+	if attachment.Type() != llm.AttachmentTypeImage {
+		return llm.NewAttachmentError("provider", "type", fmt.Sprintf("unsupported attachment type: %s", attachment.Type()))
+	}
+
+	if c.mmprojPath == "" {
+		return llm.NewAttachmentError("provider", "type", "image attachments require a multimodal projector (see WithMMProjPath)")
+	}
+
+	if attachment.Source() != llm.AttachmentSourceBase64 {
+		return llm.NewAttachmentError("provider", "source", "image attachments must be base64 encoded, use llm.AttachmentFromURL to fetch them")
+	}
+
+	if !slices.Contains(ImageLimits.MimeTypes, strings.ToLower(attachment.MimeType())) {
+		return llm.NewAttachmentError("provider", "mime_type", fmt.Sprintf("unsupported image MIME type: %s (supported: %v)", attachment.MimeType(), ImageLimits.MimeTypes))
+	}
+
+	return nil
+}
+
+// loadProjector loads the multimodal projector of the model
+func (c *ChatCompletionClient) loadProjector(model llama.Model) (mtmd.Context, error) {
+	if err := mtmd.Load(c.libPath); err != nil {
+		return 0, errors.Wrap(err, "unable to load mtmd library")
+	}
+
+	if !c.verbose {
+		mtmd.LogSet(llama.LogSilent())
+	}
+
+	mctx, err := mtmd.InitFromFile(c.mmprojPath, model, mtmd.ContextParamsDefault())
+	if err != nil {
+		return 0, errors.Wrapf(err, "unable to load multimodal projector from file: %s", c.mmprojPath)
+	}
+
+	if !mtmd.SupportVision(mctx) {
+		mtmd.Free(mctx)
+		return 0, errors.Errorf("multimodal projector does not support vision: %s", c.mmprojPath)
+	}
+
+	return mctx, nil
+}
+
+// withMediaMarkers prepends a media marker to the content for each of its
+// images, in the order of the attachments
+func withMediaMarkers(content string, images int) string {
+	if images == 0 {
+		return content
+	}
+
+	return strings.Repeat(mediaMarker+"\n", images) + content
+}
+
+// promptImages validates the attachments of the messages and decodes their
+// images, in the order of their markers in the prompt
+func (c *ChatCompletionClient) promptImages(messages []llm.Message) ([][]byte, error) {
+	var images [][]byte
+
+	for _, msg := range messages {
+		for _, attachment := range msg.Attachments() {
+			if err := c.ValidateAttachment(attachment); err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			data := attachment.Data()
+			if strings.HasPrefix(data, "data:") {
+				_, data, _ = strings.Cut(data, ",")
+			}
+
+			image, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				return nil, llm.NewAttachmentErrorWithCause("format", "data", "invalid base64 encoding", err)
+			}
+
+			if len(image) == 0 {
+				return nil, errors.WithStack(llm.ErrEmptyData)
+			}
+
+			images = append(images, image)
+		}
+	}
+
+	return images, nil
+}
+
+// promptInput is a tokenized prompt: text tokens, or mtmd chunks when the
+// prompt holds images
+type promptInput struct {
+	tokens []llama.Token
+	chunks mtmd.InputChunks
+	// promptTokens is the number of tokens of the prompt, imageTokens of them
+	// being the tokens of its images
+	promptTokens int64
+	imageTokens  int64
+}
+
+// Free releases the chunks of the prompt
+func (p *promptInput) Free() {
+	if p.chunks != 0 {
+		mtmd.InputChunksFree(p.chunks)
+		p.chunks = 0
+	}
+}
+
+// tokenize tokenizes the prompt, its images being preprocessed by the
+// multimodal projector
+func (c *ChatCompletionClient) tokenize(prompt string, images [][]byte) (*promptInput, error) {
+	if len(images) == 0 {
+		tokens := llama.Tokenize(c.vocab, prompt, true, true)
+		return &promptInput{tokens: tokens, promptTokens: int64(len(tokens))}, nil
+	}
+
+	if c.mctx == 0 {
+		return nil, errors.New("no multimodal projector loaded")
+	}
+
+	bitmaps := make([]mtmd.Bitmap, 0, len(images))
+	defer func() {
+		for _, bitmap := range bitmaps {
+			mtmd.BitmapFree(bitmap)
+		}
+	}()
+
+	for i, image := range images {
+		bitmap := mtmd.BitmapInitFromBuf(c.mctx, &image[0], uint64(len(image)))
+		if bitmap == 0 {
+			return nil, llm.NewAttachmentError("format", "data", fmt.Sprintf("could not decode image #%d", i))
+		}
+
+		bitmaps = append(bitmaps, bitmap)
+	}
+
+	chunks := mtmd.InputChunksInit()
+	input := &promptInput{chunks: chunks}
+
+	switch res := mtmd.Tokenize(c.mctx, chunks, mtmd.NewInputText(prompt, true, true), bitmaps); res {
+	case 0:
+	case 1:
+		input.Free()
+		return nil, errors.Errorf("the prompt holds a number of media markers different from its %d images", len(images))
+	default:
+		input.Free()
+		return nil, errors.Errorf("failed to preprocess images (code %d)", res)
+	}
+
+	for i := range mtmd.InputChunksSize(chunks) {
+		chunk := mtmd.InputChunksGet(chunks, i)
+		count := int64(mtmd.InputChunkGetNTokens(chunk))
+
+		input.promptTokens += count
+		if mtmd.InputChunkGetType(chunk) == mtmd.InputChunkTypeImage {
+			input.imageTokens += count
+		}
+	}
+
+	return input, nil
+}
+
+// prefillInput decodes the tokenized prompt in the slot
+func (c *ChatCompletionClient) prefillInput(ctx context.Context, s *slot, input *promptInput) error {
+	if input.chunks == 0 {
+		return c.prefill(ctx, s, input.tokens)
+	}
+
+	return c.evalChunks(ctx, s, input)
+}
+
+// evalChunks decodes the prompt with images in the slot, text chunks being
+// decoded and image chunks encoded by the projector. The positions of the
+// image tokens not following their count, the prompt is decoded from its
+// start and the slot is not reused.
+func (c *ChatCompletionClient) evalChunks(ctx context.Context, s *slot, input *promptInput) error {
+	if err := c.clearSlot(s); err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.stale = true
+
+	var pos llama.Pos
+	if res := mtmd.HelperEvalChunks(c.mctx, c.lctx, input.chunks, 0, s.id, int32(c.batchSize), true, &pos); res != 0 {
+		return errors.Errorf("failed to evaluate multimodal prompt (code %d)", res)
+	}
+
+	s.pos = pos
+
+	slog.DebugContext(ctx, "evaluated multimodal prompt",
+		slog.String("session", s.session),
+		slog.Int64("tokens", input.promptTokens),
+		slog.Int64("images", input.imageTokens),
+	)
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package yzma
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/hybridgroup/yzma/pkg/message"
+)
+
+func TestPromptImages(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nfake")
+
+	image, err := llm.NewImageAttachment("image/png", base64.StdEncoding.EncodeToString(png), false)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	url, err := llm.NewImageAttachment("image/png", "https://example.com/cat.png", true)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	webp, err := llm.NewImageAttachment("image/webp", base64.StdEncoding.EncodeToString([]byte("RIFF")), false)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	document, err := llm.NewDocumentAttachment("application/pdf", base64.StdEncoding.EncodeToString([]byte("%PDF")), false)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	type testCase struct {
+		Name       string
+		MMProjPath string
+		Attachment llm.Attachment
+		Error      string
+	}
+
+	testCases := []testCase{
+		{Name: "image", MMProjPath: "mmproj.gguf", Attachment: image},
+		{Name: "without projector", Attachment: image, Error: "multimodal projector"},
+		{Name: "url", MMProjPath: "mmproj.gguf", Attachment: url, Error: "base64"},
+		{Name: "unsupported mime type", MMProjPath: "mmproj.gguf", Attachment: webp, Error: "image/webp"},
+		{Name: "document", MMProjPath: "mmproj.gguf", Attachment: document, Error: "unsupported attachment type"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client := &ChatCompletionClient{mmprojPath: tc.MMProjPath}
+
+			messages := []llm.Message{
+				llm.NewMessage(llm.RoleSystem, "You are a helpful assistant."),
+				llm.NewMultimodalMessage(llm.RoleUser, "Describe the image.", tc.Attachment),
+			}
+
+			images, err := client.promptImages(messages)
+
+			if tc.Error != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.Error) {
+					t.Fatalf("expected error containing '%s', got %v", tc.Error, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			if len(images) != 1 || !bytes.Equal(images[0], png) {
+				t.Errorf("expected the decoded image, got %v", images)
+			}
+
+			converted := client.convertToYzmaMessages(messages)
+			if chat, ok := converted[1].(message.Chat); !ok || !strings.HasPrefix(chat.Content, mediaMarker+"\n") {
+				t.Errorf("expected the media marker in the message, got %v", converted[1])
+			}
+		})
+	}
+}
+
+func TestWithMediaMarkers(t *testing.T) {
+	if got := withMediaMarkers("Describe them.", 2); got != mediaMarker+"\n"+mediaMarker+"\nDescribe them." {
+		t.Errorf("unexpected content %q", got)
+	}
+
+	if got := withMediaMarkers("Hello", 0); got != "Hello" {
+		t.Errorf("unexpected content %q", got)
+	}
+}
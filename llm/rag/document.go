@@ -0,0 +1,94 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bornholm/genai/llm/vectorstore"
+)
+
+// Metadata keys set on every chunk stored by the knowledge base
+const (
+	MetadataSource   = "source"
+	MetadataChunk    = "chunk"
+	MetadataHeadings = "headings"
+	MetadataStart    = "start"
+	MetadataEnd      = "end"
+)
+
+// Document is a source document ingested in the knowledge base
+type Document struct {
+	ID       string
+	Text     string
+	Metadata map[string]any
+}
+
+// Passage is a chunk of a source document retrieved by a query
+type Passage struct {
+	ID       string
+	Source   string
+	Text     string
+	Headings []string
+	Start    int
+	End      int
+	Score    float64
+	Metadata map[string]any
+}
+
+// Citation returns a short human readable reference to the passage, made of
+// its source and heading breadcrumb.
+func (p Passage) Citation() string {
+	parts := append([]string{p.Source}, p.Headings...)
+	return strings.Join(parts, " › ")
+}
+
+func chunkID(source string, index int) string {
+	return fmt.Sprintf("%s#%d", source, index)
+}
+
+func passageFromDocument(doc vectorstore.Document, score float64) Passage {
+	passage := Passage{
+		ID:       doc.ID,
+		Text:     doc.Content,
+		Score:    score,
+		Metadata: doc.Metadata,
+	}
+
+	if source, ok := doc.Metadata[MetadataSource].(string); ok {
+		passage.Source = source
+	}
+
+	switch headings := doc.Metadata[MetadataHeadings].(type) {
+	case []string:
+		passage.Headings = headings
+	case []any:
+		// Metadata restored from disk is decoded from JSON
+		for _, h := range headings {
+			if s, ok := h.(string); ok {
+				passage.Headings = append(passage.Headings, s)
+			}
+		}
+	}
+
+	if start, ok := toInt(doc.Metadata[MetadataStart]); ok {
+		passage.Start = start
+	}
+	if end, ok := toInt(doc.Metadata[MetadataEnd]); ok {
+		passage.End = end
+	}
+
+	return passage
+}
+
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,247 @@
+package rag
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/bornholm/genai/llm/vectorstore"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultBM25K1 = 1.2
+	DefaultBM25B  = 0.75
+)
+
+// KeywordIndex is an in-memory full text index ranking documents with the
+// Okapi BM25 function
+type KeywordIndex struct {
+	k1 float64
+	b  float64
+
+	mu          sync.RWMutex
+	docs        map[string]*keywordDocument
+	frequencies map[string]int
+	totalLength int
+}
+
+type keywordDocument struct {
+	doc    vectorstore.Document
+	terms  map[string]int
+	length int
+}
+
+// Upsert indexes the content of the documents, replacing existing ones with
+// the same ID. Document vectors are ignored.
+func (i *KeywordIndex) Upsert(docs ...vectorstore.Document) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, doc := range docs {
+		i.remove(doc.ID)
+
+		terms := make(map[string]int)
+		tokens := tokenize(doc.Content)
+		for _, t := range tokens {
+			terms[t]++
+		}
+
+		for t := range terms {
+			i.frequencies[t]++
+		}
+
+		doc.Vector = nil
+		i.docs[doc.ID] = &keywordDocument{
+			doc:    doc,
+			terms:  terms,
+			length: len(tokens),
+		}
+		i.totalLength += len(tokens)
+	}
+}
+
+// Delete removes the documents with the given IDs
+func (i *KeywordIndex) Delete(ids ...string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, id := range ids {
+		i.remove(id)
+	}
+}
+
+func (i *KeywordIndex) remove(id string) {
+	existing, exists := i.docs[id]
+	if !exists {
+		return
+	}
+
+	for t := range existing.terms {
+		i.frequencies[t]--
+		if i.frequencies[t] == 0 {
+			delete(i.frequencies, t)
+		}
+	}
+
+	i.totalLength -= existing.length
+	delete(i.docs, id)
+}
+
+// Search returns at most topK documents matching the query, best first.
+// Documents sharing no term with the query are never returned.
+func (i *KeywordIndex) Search(query string, topK int, filter vectorstore.Filter) []vectorstore.Result {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	results := make([]vectorstore.Result, 0)
+	if len(i.docs) == 0 {
+		return results
+	}
+
+	terms := tokenize(query)
+	total := float64(len(i.docs))
+	avgLength := float64(i.totalLength) / total
+
+	for _, d := range i.docs {
+		var score float64
+		for _, t := range terms {
+			tf := float64(d.terms[t])
+			if tf == 0 {
+				continue
+			}
+			df := float64(i.frequencies[t])
+			idf := math.Log(1 + (total-df+0.5)/(df+0.5))
+			score += idf * tf * (i.k1 + 1) / (tf + i.k1*(1-i.b+i.b*float64(d.length)/avgLength))
+		}
+
+		if score <= 0 {
+			continue
+		}
+
+		if filter != nil && !filter(d.doc.Metadata) {
+			continue
+		}
+
+		results = append(results, vectorstore.Result{Document: d.doc, Score: score})
+	}
+
+	sort.Slice(results, func(a, b int) bool {
+		if results[a].Score != results[b].Score {
+			return results[a].Score > results[b].Score
+		}
+		return results[a].Document.ID < results[b].Document.ID
+	})
+
+	if topK > 0 && len(results) > topK {
+		results = results[:topK]
+	}
+
+	return results
+}
+
+// Len returns the number of documents in the index
+func (i *KeywordIndex) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.docs)
+}
+
+type keywordSnapshot struct {
+	K1   float64
+	B    float64
+	Docs []keywordDocumentSnapshot
+}
+
+type keywordDocumentSnapshot struct {
+	ID       string
+	Content  string
+	Metadata []byte
+}
+
+// Save writes the indexed documents to w. As for the vector store, metadata
+// values are encoded as JSON.
+func (i *KeywordIndex) Save(w io.Writer) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	snapshot := keywordSnapshot{
+		K1:   i.k1,
+		B:    i.b,
+		Docs: make([]keywordDocumentSnapshot, 0, len(i.docs)),
+	}
+
+	for _, d := range i.docs {
+		var metadata []byte
+		if d.doc.Metadata != nil {
+			var err error
+			metadata, err = json.Marshal(d.doc.Metadata)
+			if err != nil {
+				return errors.Wrapf(err, "could not encode metadata of document %q", d.doc.ID)
+			}
+		}
+
+		snapshot.Docs = append(snapshot.Docs, keywordDocumentSnapshot{
+			ID:       d.doc.ID,
+			Content:  d.doc.Content,
+			Metadata: metadata,
+		})
+	}
+
+	if err := gob.NewEncoder(w).Encode(&snapshot); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// LoadKeywordIndex reads an index previously written with Save
+func LoadKeywordIndex(r io.Reader) (*KeywordIndex, error) {
+	var snapshot keywordSnapshot
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	index := NewKeywordIndex(snapshot.K1, snapshot.B)
+
+	docs := make([]vectorstore.Document, 0, len(snapshot.Docs))
+	for _, d := range snapshot.Docs {
+		doc := vectorstore.Document{
+			ID:      d.ID,
+			Content: d.Content,
+		}
+
+		if len(d.Metadata) > 0 {
+			if err := json.Unmarshal(d.Metadata, &doc.Metadata); err != nil {
+				return nil, errors.Wrapf(err, "could not decode metadata of document %q", d.ID)
+			}
+		}
+
+		docs = append(docs, doc)
+	}
+
+	index.Upsert(docs...)
+
+	return index, nil
+}
+
+// NewKeywordIndex returns an empty index using the given BM25 parameters
+func NewKeywordIndex(k1, b float64) *KeywordIndex {
+	return &KeywordIndex{
+		k1:          k1,
+		b:           b,
+		docs:        make(map[string]*keywordDocument),
+		frequencies: make(map[string]int),
+	}
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
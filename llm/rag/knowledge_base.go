@@ -0,0 +1,310 @@
+package rag
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/bornholm/genai/extract"
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/vectorstore"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrMissingLoader = errors.New("missing loader")
+	ErrEmptyDocument = errors.New("document is empty")
+)
+
+// KnowledgeBase chunks, embeds and indexes documents, and retrieves the
+// passages relevant to a query by combining vector and keyword search
+type KnowledgeBase struct {
+	embedder *vectorstore.Embedder
+	// lister lists the chunks of a document from the index, when it
+	// supports it
+	lister  vectorstore.Lister
+	options *Options
+
+	mu     sync.Mutex
+	chunks map[string][]string
+}
+
+// Ingest splits the documents into chunks and indexes them. Chunks of a
+// document previously ingested with the same ID are replaced.
+func (kb *KnowledgeBase) Ingest(ctx context.Context, docs ...Document) error {
+	for _, doc := range docs {
+		if err := kb.ingest(ctx, doc); err != nil {
+			return errors.Wrapf(err, "could not ingest document '%s'", doc.ID)
+		}
+	}
+
+	return nil
+}
+
+func (kb *KnowledgeBase) ingest(ctx context.Context, doc Document) error {
+	chunks := kb.options.Splitter.Split(doc.Text)
+	if len(chunks) == 0 {
+		return errors.WithStack(ErrEmptyDocument)
+	}
+
+	entries := make([]vectorstore.Document, 0, len(chunks))
+	ids := make([]string, 0, len(chunks))
+
+	for idx, chunk := range chunks {
+		metadata := make(map[string]any, len(doc.Metadata)+5)
+		for k, v := range doc.Metadata {
+			metadata[k] = v
+		}
+
+		metadata[MetadataSource] = doc.ID
+		metadata[MetadataChunk] = idx
+		metadata[MetadataStart] = chunk.Start
+		metadata[MetadataEnd] = chunk.End
+		if len(chunk.Headings) > 0 {
+			metadata[MetadataHeadings] = chunk.Headings
+		}
+
+		id := chunkID(doc.ID, idx)
+		ids = append(ids, id)
+		entries = append(entries, vectorstore.Document{
+			ID:       id,
+			Content:  chunk.Text,
+			Metadata: metadata,
+		})
+	}
+
+	previous, err := kb.chunkIDs(ctx, doc.ID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// The new chunks are indexed first so that a failure keeps the previous
+	// version of the document
+	if err := kb.embedder.Upsert(ctx, entries...); err != nil {
+		return errors.WithStack(err)
+	}
+
+	kb.options.KeywordIndex.Upsert(entries...)
+
+	kb.mu.Lock()
+	kb.chunks[doc.ID] = ids
+	kb.mu.Unlock()
+
+	stale := make([]string, 0, len(previous))
+	for _, id := range previous {
+		if !slices.Contains(ids, id) {
+			stale = append(stale, id)
+		}
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+
+	if err := kb.embedder.Delete(ctx, stale...); err != nil {
+		return errors.WithStack(err)
+	}
+
+	kb.options.KeywordIndex.Delete(stale...)
+
+	return nil
+}
+
+// chunkIDs returns the identifiers of the indexed chunks of the document,
+// listed from the index when it supports it so that the documents ingested
+// before the knowledge base was reopened are found too
+func (kb *KnowledgeBase) chunkIDs(ctx context.Context, id string) ([]string, error) {
+	kb.mu.Lock()
+	ids := slices.Clone(kb.chunks[id])
+	kb.mu.Unlock()
+
+	if kb.lister == nil {
+		return ids, nil
+	}
+
+	docs, err := kb.lister.List(ctx, vectorstore.Eq(MetadataSource, id))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, doc := range docs {
+		if !slices.Contains(ids, doc.ID) {
+			ids = append(ids, doc.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+// Load extracts the text of a document with the configured loader and
+// ingests it under the given ID
+func (kb *KnowledgeBase) Load(ctx context.Context, id string, metadata map[string]any, funcs ...extract.TextOptionFunc) error {
+	if kb.options.Loader == nil {
+		return errors.WithStack(ErrMissingLoader)
+	}
+
+	res, err := kb.options.Loader.Text(ctx, funcs...)
+	if err != nil {
+		return errors.Wrapf(err, "could not extract text of document '%s'", id)
+	}
+
+	data, err := io.ReadAll(res.Output())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return kb.Ingest(ctx, Document{
+		ID:       id,
+		Text:     string(data),
+		Metadata: metadata,
+	})
+}
+
+// Remove deletes the chunks of the document ingested with the given ID. When
+// the index does not implement vectorstore.Lister, only the chunks ingested
+// during the lifetime of the knowledge base are known.
+func (kb *KnowledgeBase) Remove(ctx context.Context, id string) error {
+	ids, err := kb.chunkIDs(ctx, id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	kb.mu.Lock()
+	delete(kb.chunks, id)
+	kb.mu.Unlock()
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := kb.embedder.Delete(ctx, ids...); err != nil {
+		return errors.WithStack(err)
+	}
+
+	kb.options.KeywordIndex.Delete(ids...)
+
+	return nil
+}
+
+// Search returns the passages most relevant to the query, best first
+func (kb *KnowledgeBase) Search(ctx context.Context, query string, funcs ...SearchOptionFunc) ([]Passage, error) {
+	opts := NewSearchOptions(funcs...)
+
+	queries := []string{query}
+	if kb.options.QueryRewriter != nil {
+		rewritten, err := kb.options.QueryRewriter.Rewrite(ctx, query)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not rewrite query")
+		}
+		if len(rewritten) > 0 {
+			queries = rewritten
+		}
+	}
+
+	candidates := opts.TopK * max(kb.options.CandidatesFactor, 1)
+	weight := min(max(kb.options.HybridWeight, 0), 1)
+
+	passages := make(map[string]*Passage)
+	for _, q := range queries {
+		scores := make(map[string]float64)
+		documents := make(map[string]vectorstore.Document)
+
+		if weight > 0 {
+			results, err := kb.embedder.QueryText(ctx, q,
+				vectorstore.WithTopK(candidates),
+				vectorstore.WithFilter(opts.Filter),
+			)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			fuse(scores, documents, results, weight)
+		}
+
+		if weight < 1 {
+			results := kb.options.KeywordIndex.Search(q, candidates, opts.Filter)
+			fuse(scores, documents, results, 1-weight)
+		}
+
+		// A passage matching several queries keeps its best score
+		for id, score := range scores {
+			if existing, exists := passages[id]; exists && existing.Score >= score {
+				continue
+			}
+			passage := passageFromDocument(documents[id], score)
+			passages[id] = &passage
+		}
+	}
+
+	ranked := make([]Passage, 0, len(passages))
+	for _, p := range passages {
+		ranked = append(ranked, *p)
+	}
+
+	sort.Slice(ranked, func(a, b int) bool {
+		if ranked[a].Score != ranked[b].Score {
+			return ranked[a].Score > ranked[b].Score
+		}
+		return ranked[a].ID < ranked[b].ID
+	})
+
+	if candidates > 0 && len(ranked) > candidates {
+		ranked = ranked[:candidates]
+	}
+
+	if kb.options.Reranker != nil {
+		reranked, err := kb.options.Reranker.Rerank(ctx, query, ranked)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not rerank passages")
+		}
+		ranked = reranked
+	}
+
+	if opts.TopK > 0 && len(ranked) > opts.TopK {
+		ranked = ranked[:opts.TopK]
+	}
+
+	return ranked, nil
+}
+
+// fuse adds the min-max normalized scores of the results, weighted, to the
+// given scores
+func fuse(scores map[string]float64, documents map[string]vectorstore.Document, results []vectorstore.Result, weight float64) {
+	if len(results) == 0 {
+		return
+	}
+
+	lowest, highest := results[0].Score, results[0].Score
+	for _, r := range results {
+		lowest = min(lowest, r.Score)
+		highest = max(highest, r.Score)
+	}
+
+	for _, r := range results {
+		normalized := 1.0
+		if highest > lowest {
+			normalized = (r.Score - lowest) / (highest - lowest)
+		}
+		scores[r.Document.ID] += weight * normalized
+		if _, exists := documents[r.Document.ID]; !exists {
+			documents[r.Document.ID] = r.Document
+		}
+	}
+}
+
+// New returns a knowledge base storing its chunks in index, embedded with the
+// given client
+func New(index vectorstore.Index, embeddings llm.EmbeddingsClient, funcs ...OptionFunc) *KnowledgeBase {
+	opts := NewOptions(funcs...)
+
+	lister, _ := index.(vectorstore.Lister)
+
+	return &KnowledgeBase{
+		embedder: vectorstore.NewEmbedder(index, embeddings, opts.Embedder...),
+		lister:   lister,
+		options:  opts,
+		chunks:   make(map[string][]string),
+	}
+}
@@ -0,0 +1,148 @@
+package rag
+
+import (
+	"github.com/bornholm/genai/extract"
+	"github.com/bornholm/genai/llm/vectorstore"
+	"github.com/bornholm/genai/text"
+)
+
+const (
+	DefaultTopK = 5
+	// DefaultMaxTopK caps the number of passages a model may request from
+	// the search tool
+	DefaultMaxTopK = 20
+	// DefaultHybridWeight balances vector and keyword scores equally
+	DefaultHybridWeight = 0.5
+	// DefaultCandidatesFactor is the number of candidates fetched from each
+	// retriever, per requested passage, before fusion and reranking
+	DefaultCandidatesFactor = 4
+)
+
+type Options struct {
+	Splitter      text.Splitter
+	Loader        extract.Client
+	QueryRewriter QueryRewriter
+	Reranker      Reranker
+	KeywordIndex  *KeywordIndex
+	// HybridWeight is the weight of the vector score in the final score, the
+	// keyword score accounting for the rest: 1 disables keyword search and 0
+	// disables vector search.
+	HybridWeight     float64
+	CandidatesFactor int
+	Embedder         []vectorstore.EmbedderOptionFunc
+}
+
+type OptionFunc func(opts *Options)
+
+func NewOptions(funcs ...OptionFunc) *Options {
+	opts := &Options{
+		Splitter:         text.NewMarkdownSplitter(),
+		HybridWeight:     DefaultHybridWeight,
+		CandidatesFactor: DefaultCandidatesFactor,
+	}
+	for _, fn := range funcs {
+		fn(opts)
+	}
+	if opts.KeywordIndex == nil {
+		opts.KeywordIndex = NewKeywordIndex(DefaultBM25K1, DefaultBM25B)
+	}
+	return opts
+}
+
+// WithSplitter sets the splitter used to chunk ingested documents. Defaults
+// to a markdown splitter, matching the output of extract clients.
+func WithSplitter(splitter text.Splitter) OptionFunc {
+	return func(opts *Options) {
+		opts.Splitter = splitter
+	}
+}
+
+// WithLoader sets the extract client used by KnowledgeBase.Load
+func WithLoader(loader extract.Client) OptionFunc {
+	return func(opts *Options) {
+		opts.Loader = loader
+	}
+}
+
+func WithQueryRewriter(rewriter QueryRewriter) OptionFunc {
+	return func(opts *Options) {
+		opts.QueryRewriter = rewriter
+	}
+}
+
+func WithReranker(reranker Reranker) OptionFunc {
+	return func(opts *Options) {
+		opts.Reranker = reranker
+	}
+}
+
+// WithKeywordIndex sets the keyword index used for hybrid scoring, for
+// example one restored with LoadKeywordIndex
+func WithKeywordIndex(index *KeywordIndex) OptionFunc {
+	return func(opts *Options) {
+		opts.KeywordIndex = index
+	}
+}
+
+func WithHybridWeight(weight float64) OptionFunc {
+	return func(opts *Options) {
+		opts.HybridWeight = weight
+	}
+}
+
+func WithCandidatesFactor(factor int) OptionFunc {
+	return func(opts *Options) {
+		opts.CandidatesFactor = factor
+	}
+}
+
+// WithEmbedderOptions configures the embedding of chunks and queries
+func WithEmbedderOptions(funcs ...vectorstore.EmbedderOptionFunc) OptionFunc {
+	return func(opts *Options) {
+		opts.Embedder = funcs
+	}
+}
+
+type SearchOptions struct {
+	// TopK is the maximum number of passages returned, 0 meaning no limit
+	TopK   int
+	Filter vectorstore.Filter
+	// MaxTopK caps the number of passages a model may request through the
+	// search tool, 0 meaning no limit
+	MaxTopK int
+}
+
+type SearchOptionFunc func(opts *SearchOptions)
+
+func NewSearchOptions(funcs ...SearchOptionFunc) *SearchOptions {
+	opts := &SearchOptions{
+		TopK:    DefaultTopK,
+		MaxTopK: DefaultMaxTopK,
+	}
+	for _, fn := range funcs {
+		fn(opts)
+	}
+	return opts
+}
+
+// WithTopK sets the maximum number of passages returned, 0 meaning no limit
+func WithTopK(k int) SearchOptionFunc {
+	return func(opts *SearchOptions) {
+		opts.TopK = k
+	}
+}
+
+// WithMaxTopK caps the number of passages a model may request through the
+// search tool, 0 meaning no limit
+func WithMaxTopK(k int) SearchOptionFunc {
+	return func(opts *SearchOptions) {
+		opts.MaxTopK = k
+	}
+}
+
+// WithFilter restricts the search to chunks whose metadata match the filter
+func WithFilter(filter vectorstore.Filter) SearchOptionFunc {
+	return func(opts *SearchOptions) {
+		opts.Filter = filter
+	}
+}
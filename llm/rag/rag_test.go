@@ -0,0 +1,373 @@
+package rag
+
+import (
+	"context"
+	"hash/fnv"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/genai/extract"
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/vectorstore"
+	"github.com/bornholm/genai/text"
+	"github.com/pkg/errors"
+)
+
+const corpus = `# Cats
+
+Cats are small carnivorous mammals. The cat purrs when it is happy.
+
+# Cars
+
+Cars have four wheels and an engine. Electric cars use a battery.
+
+# Cooking
+
+Pasta is boiled in salted water for ten minutes.
+`
+
+func newTestKnowledgeBase(t *testing.T, funcs ...OptionFunc) *KnowledgeBase {
+	t.Helper()
+
+	index, err := vectorstore.NewMemoryIndex(vectorstore.MetricCosine)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	funcs = append([]OptionFunc{WithSplitter(text.NewMarkdownSplitter(text.WithChunkSize(32)))}, funcs...)
+
+	kb := New(index, &bagOfWordsClient{}, funcs...)
+
+	err = kb.Ingest(context.Background(), Document{ID: "animals.md", Text: corpus, Metadata: map[string]any{"lang": "en"}})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	return kb
+}
+
+func TestKnowledgeBase_Search(t *testing.T) {
+	ctx := context.Background()
+
+	for _, weight := range []float64{0, 0.5, 1} {
+		kb := newTestKnowledgeBase(t, WithHybridWeight(weight))
+
+		passages, err := kb.Search(ctx, "battery of electric cars", WithTopK(1))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		if len(passages) != 1 {
+			t.Fatalf("weight %v: expected 1 passage, got %d", weight, len(passages))
+		}
+
+		p := passages[0]
+		if !strings.Contains(p.Text, "battery") {
+			t.Errorf("weight %v: unexpected passage %q", weight, p.Text)
+		}
+		if p.Source != "animals.md" {
+			t.Errorf("weight %v: expected source 'animals.md', got %q", weight, p.Source)
+		}
+		if p.Citation() != "animals.md › Cars" {
+			t.Errorf("weight %v: unexpected citation %q", weight, p.Citation())
+		}
+		if corpus[p.Start:p.End] != p.Text {
+			t.Errorf("weight %v: offsets do not match the passage", weight)
+		}
+	}
+}
+
+func TestKnowledgeBase_SearchWithoutLimit(t *testing.T) {
+	ctx := context.Background()
+	kb := newTestKnowledgeBase(t)
+
+	all, err := kb.Search(ctx, "cars", WithTopK(100))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	passages, err := kb.Search(ctx, "cars", WithTopK(0))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if len(passages) < 2 || len(passages) != len(all) {
+		t.Errorf("expected every passage with no limit, got %d of %d", len(passages), len(all))
+	}
+}
+
+func TestKnowledgeBase_Filter(t *testing.T) {
+	kb := newTestKnowledgeBase(t)
+
+	passages, err := kb.Search(context.Background(), "cats", WithFilter(vectorstore.Eq("lang", "fr")))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if len(passages) != 0 {
+		t.Errorf("expected no passage, got %d", len(passages))
+	}
+}
+
+func TestKnowledgeBase_RewriteAndRerank(t *testing.T) {
+	var rewritten, reranked bool
+
+	kb := newTestKnowledgeBase(t,
+		WithQueryRewriter(QueryRewriterFunc(func(ctx context.Context, query string) ([]string, error) {
+			rewritten = true
+			return []string{query, "pasta water"}, nil
+		})),
+		WithReranker(RerankerFunc(func(ctx context.Context, query string, passages []Passage) ([]Passage, error) {
+			reranked = true
+			// Reverse the order
+			for i, j := 0, len(passages)-1; i < j; i, j = i+1, j-1 {
+				passages[i], passages[j] = passages[j], passages[i]
+			}
+			return passages, nil
+		})),
+	)
+
+	passages, err := kb.Search(context.Background(), "purring cat", WithTopK(10))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if !rewritten || !reranked {
+		t.Fatalf("expected rewriter and reranker to be called")
+	}
+
+	var foundPasta bool
+	for _, p := range passages {
+		if strings.Contains(p.Text, "Pasta") {
+			foundPasta = true
+		}
+	}
+
+	if !foundPasta {
+		t.Errorf("expected the rewritten query to retrieve the cooking passage")
+	}
+}
+
+func TestKnowledgeBase_Reingest(t *testing.T) {
+	ctx := context.Background()
+	kb := newTestKnowledgeBase(t)
+
+	if err := kb.Ingest(ctx, Document{ID: "animals.md", Text: "# Dogs\n\nDogs bark."}); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	passages, err := kb.Search(ctx, "battery cars")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	for _, p := range passages {
+		if strings.Contains(p.Text, "battery") {
+			t.Errorf("expected previous chunks to be removed, got %q", p.Text)
+		}
+	}
+
+	if kb.options.KeywordIndex.Len() != 1 {
+		t.Errorf("expected 1 indexed chunk, got %d", kb.options.KeywordIndex.Len())
+	}
+}
+
+// A knowledge base reopened over a persisted index must still replace the
+// chunks ingested before, and keep them when the re-ingestion fails.
+func TestKnowledgeBase_ReingestReopened(t *testing.T) {
+	ctx := context.Background()
+
+	index, err := vectorstore.NewMemoryIndex(vectorstore.MetricCosine)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	splitter := WithSplitter(text.NewMarkdownSplitter(text.WithChunkSize(32)))
+
+	if err := New(index, &bagOfWordsClient{}, splitter).Ingest(ctx, Document{ID: "animals.md", Text: corpus}); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	previous := index.Len()
+
+	failing := New(index, &failingEmbeddingsClient{}, splitter)
+	if err := failing.Ingest(ctx, Document{ID: "animals.md", Text: "# Dogs\n\nDogs bark."}); err == nil {
+		t.Fatal("expected the ingestion to fail")
+	}
+
+	if index.Len() != previous {
+		t.Fatalf("expected the previous %d chunks to be kept, got %d", previous, index.Len())
+	}
+
+	reopened := New(index, &bagOfWordsClient{}, splitter)
+	if err := reopened.Ingest(ctx, Document{ID: "animals.md", Text: "# Dogs\n\nDogs bark."}); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if index.Len() != 1 {
+		t.Errorf("expected 1 indexed chunk, got %d", index.Len())
+	}
+
+	if err := reopened.Remove(ctx, "animals.md"); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if index.Len() != 0 {
+		t.Errorf("expected no indexed chunk, got %d", index.Len())
+	}
+}
+
+func TestKnowledgeBase_Load(t *testing.T) {
+	ctx := context.Background()
+
+	index, err := vectorstore.NewMemoryIndex(vectorstore.MetricCosine)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	kb := New(index, &bagOfWordsClient{})
+	if err := kb.Load(ctx, "doc", nil); !errors.Is(err, ErrMissingLoader) {
+		t.Fatalf("expected ErrMissingLoader, got %v", err)
+	}
+
+	kb = New(index, &bagOfWordsClient{}, WithLoader(&fakeExtractClient{text: corpus}))
+	if err := kb.Load(ctx, "report.pdf", nil, extract.WithFilename("report.pdf")); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	passages, err := kb.Search(ctx, "salted water")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if len(passages) == 0 || passages[0].Source != "report.pdf" {
+		t.Errorf("expected passages from 'report.pdf', got %v", passages)
+	}
+}
+
+func TestSearchTool(t *testing.T) {
+	kb := newTestKnowledgeBase(t)
+	tool := NewSearchTool(kb)
+
+	if tool.Name() != SearchToolName {
+		t.Errorf("expected tool name %q, got %q", SearchToolName, tool.Name())
+	}
+
+	result, err := tool.Execute(context.Background(), map[string]any{"query": "electric cars battery", "top_k": float64(1)})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	expected := "[1] Source: animals.md › Cars\n"
+	if !strings.HasPrefix(result.Text(), expected) {
+		t.Errorf("expected result to start with %q, got %q", expected, result.Text())
+	}
+
+	// The passages requested by the model are capped
+	capped := NewSearchTool(kb, WithMaxTopK(1))
+
+	result, err = capped.Execute(context.Background(), map[string]any{"query": "cars", "top_k": float64(1e18)})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if !strings.HasPrefix(result.Text(), "[1]") || strings.Contains(result.Text(), "[2]") {
+		t.Errorf("expected a single passage, got %q", result.Text())
+	}
+
+	result, err = tool.Execute(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if !strings.HasPrefix(result.Text(), "Error:") {
+		t.Errorf("expected an error message, got %q", result.Text())
+	}
+}
+
+func TestKeywordIndex(t *testing.T) {
+	index := NewKeywordIndex(DefaultBM25K1, DefaultBM25B)
+	index.Upsert(
+		vectorstore.Document{ID: "1", Content: "the quick brown fox"},
+		vectorstore.Document{ID: "2", Content: "the lazy dog"},
+		vectorstore.Document{ID: "3", Content: "the fox and the dog, the fox"},
+	)
+
+	results := index.Search("fox", 0, nil)
+	if len(results) != 2 || results[0].Document.ID != "3" {
+		t.Errorf("unexpected results %v", results)
+	}
+
+	var buf strings.Builder
+	if err := index.Save(&buf); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	loaded, err := LoadKeywordIndex(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	loaded.Delete("3")
+	results = loaded.Search("fox", 0, nil)
+	if len(results) != 1 || results[0].Document.ID != "1" {
+		t.Errorf("unexpected results after reload %v", results)
+	}
+}
+
+// bagOfWordsClient embeds texts as hashed bags of words
+type bagOfWordsClient struct{}
+
+func (c *bagOfWordsClient) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	embeddings := make([][]float64, len(inputs))
+	for i, input := range inputs {
+		vector := make([]float64, 64)
+		for _, token := range tokenize(input) {
+			h := fnv.New32a()
+			h.Write([]byte(strings.TrimSuffix(token, "s")))
+			vector[h.Sum32()%64]++
+		}
+		embeddings[i] = vector
+	}
+	return &fakeEmbeddingsResponse{embeddings: embeddings}, nil
+}
+
+// failingEmbeddingsClient fails every embeddings call
+type failingEmbeddingsClient struct{}
+
+func (c *failingEmbeddingsClient) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	return nil, errors.New("embeddings unavailable")
+}
+
+type fakeEmbeddingsResponse struct {
+	embeddings [][]float64
+}
+
+func (r *fakeEmbeddingsResponse) Embeddings() [][]float64 {
+	return r.embeddings
+}
+
+func (r *fakeEmbeddingsResponse) Usage() llm.EmbeddingsUsage {
+	return llm.NewEmbeddingsUsage(0, 0)
+}
+
+type fakeExtractClient struct {
+	text string
+}
+
+func (c *fakeExtractClient) Text(ctx context.Context, funcs ...extract.TextOptionFunc) (extract.TextResponse, error) {
+	return &fakeTextResponse{text: c.text}, nil
+}
+
+type fakeTextResponse struct {
+	text string
+}
+
+func (r *fakeTextResponse) Output() io.Reader {
+	return strings.NewReader(r.text)
+}
+
+func (r *fakeTextResponse) Format() extract.TextFormat {
+	return extract.TextFormatMarkdown
+}
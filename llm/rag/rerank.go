@@ -0,0 +1,128 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+// Reranker reorders the passages retrieved for a query, most relevant first.
+// It may update their scores and drop irrelevant ones.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, passages []Passage) ([]Passage, error)
+}
+
+type RerankerFunc func(ctx context.Context, query string, passages []Passage) ([]Passage, error)
+
+// Rerank implements Reranker.
+func (fn RerankerFunc) Rerank(ctx context.Context, query string, passages []Passage) ([]Passage, error) {
+	return fn(ctx, query, passages)
+}
+
+var _ Reranker = RerankerFunc(nil)
+
+const defaultRerankPrompt = `You evaluate the relevance of passages to a search query.
+Give each passage a score from 0 (irrelevant) to 10 (answers the query directly).
+
+Respond with a JSON object of the form {"scores": [{"passage": <passage number>, "score": <score>}]}.
+
+Query: %s
+
+Passages:
+%s`
+
+var rerankSchema = llm.NewResponseSchema(
+	"passage_scores",
+	"Relevance score of each passage",
+	map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"scores": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"passage": map[string]any{"type": "integer"},
+						"score":   map[string]any{"type": "number"},
+					},
+					"required":             []string{"passage", "score"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"scores"},
+		"additionalProperties": false,
+	},
+)
+
+type rerankPayload struct {
+	Scores []struct {
+		Passage int     `json:"passage"`
+		Score   float64 `json:"score"`
+	} `json:"scores"`
+}
+
+// NewLLMReranker returns a Reranker asking the given model to grade every
+// passage. Passages scored at or below minScore (on the 0-10 scale) are
+// dropped; scores are normalized to 0-1.
+func NewLLMReranker(client llm.ChatCompletionClient, minScore float64) Reranker {
+	return RerankerFunc(func(ctx context.Context, query string, passages []Passage) ([]Passage, error) {
+		if len(passages) == 0 {
+			return passages, nil
+		}
+
+		var sb strings.Builder
+		for i, p := range passages {
+			fmt.Fprintf(&sb, "[%d] %s\n\n", i+1, p.Text)
+		}
+
+		res, err := client.ChatCompletion(ctx,
+			llm.WithMessages(llm.NewMessage(llm.RoleUser, fmt.Sprintf(defaultRerankPrompt, query, sb.String()))),
+			llm.WithJSONResponse(rerankSchema),
+		)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		parsed, err := llm.ParseJSON[rerankPayload](res.Message())
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse passage scores")
+		}
+
+		var payload rerankPayload
+		if len(parsed) > 0 {
+			payload = parsed[0]
+		}
+
+		// Keep the retrieval order rather than dropping every passage when
+		// the model did not grade anything
+		if len(payload.Scores) == 0 {
+			return passages, nil
+		}
+
+		scores := make(map[int]float64, len(payload.Scores))
+		for _, s := range payload.Scores {
+			scores[s.Passage-1] = s.Score
+		}
+
+		reranked := make([]Passage, 0, len(passages))
+		for i, p := range passages {
+			score, exists := scores[i]
+			if !exists || score <= minScore {
+				continue
+			}
+			p.Score = score / 10
+			reranked = append(reranked, p)
+		}
+
+		sort.SliceStable(reranked, func(a, b int) bool {
+			return reranked[a].Score > reranked[b].Score
+		})
+
+		return reranked, nil
+	})
+}
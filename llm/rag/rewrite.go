@@ -0,0 +1,89 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+// QueryRewriter turns a user query into one or more search queries
+type QueryRewriter interface {
+	Rewrite(ctx context.Context, query string) ([]string, error)
+}
+
+type QueryRewriterFunc func(ctx context.Context, query string) ([]string, error)
+
+// Rewrite implements QueryRewriter.
+func (fn QueryRewriterFunc) Rewrite(ctx context.Context, query string) ([]string, error) {
+	return fn(ctx, query)
+}
+
+var _ QueryRewriter = QueryRewriterFunc(nil)
+
+const defaultRewritePrompt = `You rewrite search queries for a document retrieval system.
+Given the user query below, produce up to %d alternative search queries that are self-contained, use precise keywords and cover the different aspects of the question.
+
+Respond with a JSON object of the form {"queries": ["..."]}.
+
+User query: %s`
+
+var rewriteSchema = llm.NewResponseSchema(
+	"search_queries",
+	"Alternative search queries",
+	map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"queries": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "string"},
+			},
+		},
+		"required":             []string{"queries"},
+		"additionalProperties": false,
+	},
+)
+
+type rewritePayload struct {
+	Queries []string `json:"queries"`
+}
+
+// NewLLMQueryRewriter returns a QueryRewriter asking the given model for up
+// to max alternative queries. The original query is always searched as well.
+func NewLLMQueryRewriter(client llm.ChatCompletionClient, max int) QueryRewriter {
+	return QueryRewriterFunc(func(ctx context.Context, query string) ([]string, error) {
+		res, err := client.ChatCompletion(ctx,
+			llm.WithMessages(llm.NewMessage(llm.RoleUser, fmt.Sprintf(defaultRewritePrompt, max, query))),
+			llm.WithJSONResponse(rewriteSchema),
+		)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		parsed, err := llm.ParseJSON[rewritePayload](res.Message())
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse rewritten queries")
+		}
+
+		var rewritten []string
+		if len(parsed) > 0 {
+			rewritten = parsed[0].Queries
+		}
+
+		queries := []string{query}
+		for _, q := range rewritten {
+			q = strings.TrimSpace(q)
+			if q == "" || q == query {
+				continue
+			}
+			queries = append(queries, q)
+			if len(queries) > max {
+				break
+			}
+		}
+
+		return queries, nil
+	})
+}
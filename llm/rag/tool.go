@@ -0,0 +1,75 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+const SearchToolName = "search_knowledge_base"
+
+// NewSearchTool exposes the knowledge base to a model as the
+// "search_knowledge_base" tool. Passages are returned numbered with their
+// source so the model can cite them.
+func NewSearchTool(kb *KnowledgeBase, funcs ...SearchOptionFunc) llm.Tool {
+	defaults := NewSearchOptions(funcs...)
+
+	parameters := llm.NewJSONSchema().
+		RequiredProperty("query", "The search query, in natural language or keywords", "string").
+		Property("top_k", topKDescription(defaults), "integer")
+
+	return llm.NewFuncTool(
+		SearchToolName,
+		"Search the knowledge base for passages relevant to a query. Each passage is numbered and comes with its source: cite the passages you rely on using their [n] marker and source.",
+		parameters,
+		func(ctx context.Context, params map[string]any) (llm.ToolResult, error) {
+			query, err := llm.ToolParam[string](params, "query")
+			if err != nil || strings.TrimSpace(query) == "" {
+				return llm.NewToolResult("Error: missing 'query' parameter"), nil
+			}
+
+			searchFuncs := append([]SearchOptionFunc{}, funcs...)
+			if topK, ok := params["top_k"].(float64); ok && topK >= 1 {
+				if defaults.MaxTopK > 0 {
+					topK = min(topK, float64(defaults.MaxTopK))
+				}
+				searchFuncs = append(searchFuncs, WithTopK(int(topK)))
+			}
+
+			passages, err := kb.Search(ctx, query, searchFuncs...)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			return llm.NewToolResult(FormatPassages(passages)), nil
+		},
+	).WithReadOnlyHint(true)
+}
+
+func topKDescription(opts *SearchOptions) string {
+	if opts.MaxTopK > 0 {
+		return fmt.Sprintf("Maximum number of passages to return (default %d, at most %d)", opts.TopK, opts.MaxTopK)
+	}
+
+	return fmt.Sprintf("Maximum number of passages to return (default %d)", opts.TopK)
+}
+
+// FormatPassages renders the passages as a numbered list of citations
+func FormatPassages(passages []Passage) string {
+	if len(passages) == 0 {
+		return "No relevant passage found."
+	}
+
+	var sb strings.Builder
+	for i, p := range passages {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		fmt.Fprintf(&sb, "[%d] Source: %s\n%s", i+1, p.Citation(), p.Text)
+	}
+
+	return sb.String()
+}
@@ -13,6 +13,7 @@ type Client struct {
 	chatLimiter          *rate.Limiter
 	embeddingsLimiter    *rate.Limiter
 	transcriptionLimiter *rate.Limiter
+	imageLimiter         *rate.Limiter
 	client               llm.Client
 }
 
@@ -48,6 +49,52 @@ func (c *Client) Transcription(ctx context.Context, audio []byte, funcs ...llm.T
 	return c.client.Transcription(ctx, audio, funcs...)
 }
 
+// ImageGeneration implements llm.ImageGenerationClient.
+func (c *Client) ImageGeneration(ctx context.Context, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	if err := c.imageLimiter.Wait(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return llm.DelegateImageGeneration(ctx, c.client, prompt, funcs...)
+}
+
+// ImageEdit implements llm.ImageEditClient.
+func (c *Client) ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	if err := c.imageLimiter.Wait(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return llm.DelegateImageEdit(ctx, c.client, image, prompt, funcs...)
+}
+
+// ImageVariation implements llm.ImageEditClient.
+func (c *Client) ImageVariation(ctx context.Context, image []byte, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	if err := c.imageLimiter.Wait(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return llm.DelegateImageVariation(ctx, c.client, image, funcs...)
+}
+
+// Complete implements llm.CompletionClient. Completions share the chat
+// completions limit.
+func (c *Client) Complete(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	if err := c.chatLimiter.Wait(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return llm.DelegateComplete(ctx, c.client, prompt, suffix, funcs...)
+}
+
+// CompleteStream implements llm.CompletionClient.
+func (c *Client) CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	if err := c.chatLimiter.Wait(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return llm.DelegateCompleteStream(ctx, c.client, prompt, suffix, funcs...)
+}
+
+// ValidateAttachment implements llm.AttachmentValidator.
+func (c *Client) ValidateAttachment(attachment llm.Attachment) error {
+	return llm.DelegateValidateAttachment(c.client, attachment)
+}
+
 type Options struct {
 	ChatMinInterval          time.Duration
 	ChatMaxBurst             int
@@ -55,6 +102,8 @@ type Options struct {
 	EmbeddingsMaxBurst       int
 	TranscriptionMinInterval time.Duration
 	TranscriptionMaxBurst    int
+	ImageMinInterval         time.Duration
+	ImageMaxBurst            int
 }
 
 type OptionFunc func(*Options)
@@ -80,6 +129,14 @@ func WithTranscriptionLimit(minInterval time.Duration, maxBurst int) OptionFunc
 	}
 }
 
+// WithImageLimit limits the image generations, edits and variations
+func WithImageLimit(minInterval time.Duration, maxBurst int) OptionFunc {
+	return func(o *Options) {
+		o.ImageMinInterval = minInterval
+		o.ImageMaxBurst = maxBurst
+	}
+}
+
 func NewClient(client llm.Client, funcs ...OptionFunc) *Client {
 	opts := &Options{
 		ChatMinInterval:          time.Second,
@@ -88,6 +145,8 @@ func NewClient(client llm.Client, funcs ...OptionFunc) *Client {
 		EmbeddingsMaxBurst:       1,
 		TranscriptionMinInterval: time.Second,
 		TranscriptionMaxBurst:    1,
+		ImageMinInterval:         time.Second,
+		ImageMaxBurst:            1,
 	}
 	for _, fn := range funcs {
 		fn(opts)
@@ -96,8 +155,15 @@ func NewClient(client llm.Client, funcs ...OptionFunc) *Client {
 		chatLimiter:          rate.NewLimiter(rate.Every(opts.ChatMinInterval), opts.ChatMaxBurst),
 		embeddingsLimiter:    rate.NewLimiter(rate.Every(opts.EmbeddingsMinInterval), opts.EmbeddingsMaxBurst),
 		transcriptionLimiter: rate.NewLimiter(rate.Every(opts.TranscriptionMinInterval), opts.TranscriptionMaxBurst),
+		imageLimiter:         rate.NewLimiter(rate.Every(opts.ImageMinInterval), opts.ImageMaxBurst),
 		client:               client,
 	}
 }
 
-var _ llm.Client = &Client{}
+var (
+	_ llm.Client                = &Client{}
+	_ llm.ImageGenerationClient = &Client{}
+	_ llm.ImageEditClient       = &Client{}
+	_ llm.CompletionClient      = &Client{}
+	_ llm.AttachmentValidator   = &Client{}
+)
@@ -107,6 +107,14 @@ func (c *Client) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletion
 // All retries and stream reading happen inside a goroutine; the returned channel
 // carries both data chunks and any eventual non-retryable error chunk.
 func (c *Client) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	return c.streamWithRetries(ctx, func() (<-chan llm.StreamChunk, error) {
+		return c.client.ChatCompletionStream(ctx, funcs...)
+	}), nil
+}
+
+// streamWithRetries reads the streams opened by open, opening a fresh one on
+// retryable errors
+func (c *Client) streamWithRetries(ctx context.Context, open func() (<-chan llm.StreamChunk, error)) <-chan llm.StreamChunk {
 	outCh := make(chan llm.StreamChunk, 10)
 
 	go func() {
@@ -116,7 +124,7 @@ func (c *Client) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatComp
 		retries := 0
 
 		for {
-			stream, err := c.client.ChatCompletionStream(ctx, funcs...)
+			stream, err := open()
 			if err != nil {
 				if retries < c.maxRetries && llm.IsRetryable(err) {
 					slog.DebugContext(ctx, "stream open failed, will retry", slog.Int("retries", retries), slog.Duration("backoff", backoff), slog.Any("error", err))
@@ -176,7 +184,93 @@ func (c *Client) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatComp
 		}
 	}()
 
-	return outCh, nil
+	return outCh
+}
+
+// ImageGeneration implements llm.ImageGenerationClient.
+func (c *Client) ImageGeneration(ctx context.Context, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return withRetries(ctx, c, func() (llm.ImageGenerationResponse, error) {
+		return llm.DelegateImageGeneration(ctx, c.client, prompt, funcs...)
+	})
+}
+
+// ImageEdit implements llm.ImageEditClient.
+func (c *Client) ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return withRetries(ctx, c, func() (llm.ImageGenerationResponse, error) {
+		return llm.DelegateImageEdit(ctx, c.client, image, prompt, funcs...)
+	})
+}
+
+// ImageVariation implements llm.ImageEditClient.
+func (c *Client) ImageVariation(ctx context.Context, image []byte, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	return withRetries(ctx, c, func() (llm.ImageGenerationResponse, error) {
+		return llm.DelegateImageVariation(ctx, c.client, image, funcs...)
+	})
+}
+
+// Complete implements llm.CompletionClient.
+func (c *Client) Complete(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	return withRetries(ctx, c, func() (llm.CompletionResponse, error) {
+		return llm.DelegateComplete(ctx, c.client, prompt, suffix, funcs...)
+	})
+}
+
+// CompleteStream implements llm.CompletionClient. Like ChatCompletionStream,
+// retryable errors trigger a full retry of the call. The first stream is
+// opened before returning, so that a non retryable error (e.g.
+// llm.ErrUnavailable) is returned as is.
+func (c *Client) CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	stream, err := llm.DelegateCompleteStream(ctx, c.client, prompt, suffix, funcs...)
+	if err != nil && !llm.IsRetryable(err) {
+		return nil, errors.WithStack(err)
+	}
+
+	opened := false
+
+	return c.streamWithRetries(ctx, func() (<-chan llm.StreamChunk, error) {
+		if !opened {
+			opened = true
+			return stream, err
+		}
+
+		return llm.DelegateCompleteStream(ctx, c.client, prompt, suffix, funcs...)
+	}), nil
+}
+
+// ValidateAttachment implements llm.AttachmentValidator.
+func (c *Client) ValidateAttachment(attachment llm.Attachment) error {
+	return llm.DelegateValidateAttachment(c.client, attachment)
+}
+
+// withRetries calls fn until it succeeds, fails with a non retryable error or
+// exhausts the retries
+func withRetries[T any](ctx context.Context, c *Client, fn func() (T, error)) (T, error) {
+	backoff := c.baseDelay
+	retries := 0
+
+	for {
+		res, err := fn()
+		if err != nil {
+			var zero T
+
+			if retries >= c.maxRetries || !llm.IsRetryable(err) {
+				return zero, errors.WithStack(err)
+			}
+
+			slog.DebugContext(ctx, "request failed, will retry", slog.Int("retries", retries), slog.Duration("backoff", backoff), slog.Any("error", errors.WithStack(err)))
+
+			retries++
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+			case <-ctx.Done():
+				return zero, errors.WithStack(ctx.Err())
+			}
+			continue
+		}
+
+		return res, nil
+	}
 }
 
 func NewClient(client llm.Client, baseDelay time.Duration, maxRetries int) *Client {
@@ -187,4 +281,10 @@ func NewClient(client llm.Client, baseDelay time.Duration, maxRetries int) *Clie
 	}
 }
 
-var _ llm.Client = &Client{}
+var (
+	_ llm.Client                = &Client{}
+	_ llm.ImageGenerationClient = &Client{}
+	_ llm.ImageEditClient       = &Client{}
+	_ llm.CompletionClient      = &Client{}
+	_ llm.AttachmentValidator   = &Client{}
+)
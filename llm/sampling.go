@@ -0,0 +1,181 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SamplingSetting names a sampling control of the chat completion options
+type SamplingSetting string
+
+const (
+	SamplingTopP             SamplingSetting = "top_p"
+	SamplingTopK             SamplingSetting = "top_k"
+	SamplingMinP             SamplingSetting = "min_p"
+	SamplingStop             SamplingSetting = "stop"
+	SamplingFrequencyPenalty SamplingSetting = "frequency_penalty"
+	SamplingPresencePenalty  SamplingSetting = "presence_penalty"
+	SamplingLogitBias        SamplingSetting = "logit_bias"
+)
+
+// Strictness tells how providers handle the sampling settings they cannot
+// honour
+type Strictness string
+
+const (
+	// StrictnessWarn logs a warning and ignores the setting
+	StrictnessWarn Strictness = "warn"
+	// StrictnessFail fails the call with ErrUnsupportedSetting
+	StrictnessFail Strictness = "fail"
+)
+
+// IsSet reports whether the sampling setting is set on the options
+func (opts *ChatCompletionOptions) IsSet(setting SamplingSetting) bool {
+	switch setting {
+	case SamplingTopP:
+		return opts.TopP != nil
+	case SamplingTopK:
+		return opts.TopK != nil
+	case SamplingMinP:
+		return opts.MinP != nil
+	case SamplingStop:
+		return len(opts.Stop) > 0
+	case SamplingFrequencyPenalty:
+		return opts.FrequencyPenalty != nil
+	case SamplingPresencePenalty:
+		return opts.PresencePenalty != nil
+	case SamplingLogitBias:
+		return len(opts.LogitBias) > 0
+	default:
+		return false
+	}
+}
+
+// CheckSampling handles the sampling settings the provider cannot honour:
+// with StrictnessFail it returns ErrUnsupportedSetting if one of them is set,
+// otherwise it logs a warning and the provider ignores them.
+func (opts *ChatCompletionOptions) CheckSampling(ctx context.Context, provider string, unsupported ...SamplingSetting) error {
+	var settings []string
+	for _, s := range unsupported {
+		if opts.IsSet(s) {
+			settings = append(settings, string(s))
+		}
+	}
+
+	if len(settings) == 0 {
+		return nil
+	}
+
+	if opts.Strictness == StrictnessFail {
+		return errors.Wrapf(ErrUnsupportedSetting, "provider '%s' cannot honour %s", provider, strings.Join(settings, ", "))
+	}
+
+	slog.WarnContext(ctx, "provider cannot honour sampling settings, ignoring them", slog.String("provider", provider), slog.Any("settings", settings))
+
+	return nil
+}
+
+func (opts *ChatCompletionOptions) validateSampling() error {
+	if opts.TopP != nil && (*opts.TopP < 0 || *opts.TopP > 1) {
+		return NewValidationError("top_p", "top p must be between 0 and 1")
+	}
+	if opts.TopK != nil && *opts.TopK < 0 {
+		return NewValidationError("top_k", "top k must be positive")
+	}
+	if opts.MinP != nil && (*opts.MinP < 0 || *opts.MinP > 1) {
+		return NewValidationError("min_p", "min p must be between 0 and 1")
+	}
+	for _, s := range opts.Stop {
+		if s == "" {
+			return NewValidationError("stop", "stop sequences must not be empty")
+		}
+	}
+	if opts.FrequencyPenalty != nil && (*opts.FrequencyPenalty < -2 || *opts.FrequencyPenalty > 2) {
+		return NewValidationError("frequency_penalty", "frequency penalty must be between -2 and 2")
+	}
+	if opts.PresencePenalty != nil && (*opts.PresencePenalty < -2 || *opts.PresencePenalty > 2) {
+		return NewValidationError("presence_penalty", "presence penalty must be between -2 and 2")
+	}
+	for token, bias := range opts.LogitBias {
+		if token < 0 {
+			return NewValidationError("logit_bias", fmt.Sprintf("invalid token id %d", token))
+		}
+		if bias < -100 || bias > 100 {
+			return NewValidationError("logit_bias", fmt.Sprintf("bias of token %d must be between -100 and 100", token))
+		}
+	}
+	switch opts.Strictness {
+	case "", StrictnessWarn, StrictnessFail:
+	default:
+		return NewValidationError("strictness", fmt.Sprintf("unknown strictness '%s'", opts.Strictness))
+	}
+
+	return nil
+}
+
+// WithTopP restricts the sampling to the most likely tokens whose cumulative
+// probability reaches p (nucleus sampling)
+func WithTopP(p float64) ChatCompletionOptionFunc {
+	return func(opts *ChatCompletionOptions) {
+		opts.TopP = &p
+	}
+}
+
+// WithTopK restricts the sampling to the k most likely tokens
+func WithTopK(k int) ChatCompletionOptionFunc {
+	return func(opts *ChatCompletionOptions) {
+		opts.TopK = &k
+	}
+}
+
+// WithMinP discards the tokens whose probability is lower than p times the
+// probability of the most likely one
+func WithMinP(p float64) ChatCompletionOptionFunc {
+	return func(opts *ChatCompletionOptions) {
+		opts.MinP = &p
+	}
+}
+
+// WithStop stops the generation at the first of the sequences, which is not
+// included in the response
+func WithStop(sequences ...string) ChatCompletionOptionFunc {
+	return func(opts *ChatCompletionOptions) {
+		opts.Stop = sequences
+	}
+}
+
+// WithFrequencyPenalty penalizes the tokens according to their frequency in
+// the generated text (-2 to 2)
+func WithFrequencyPenalty(penalty float64) ChatCompletionOptionFunc {
+	return func(opts *ChatCompletionOptions) {
+		opts.FrequencyPenalty = &penalty
+	}
+}
+
+// WithPresencePenalty penalizes the tokens already present in the generated
+// text (-2 to 2)
+func WithPresencePenalty(penalty float64) ChatCompletionOptionFunc {
+	return func(opts *ChatCompletionOptions) {
+		opts.PresencePenalty = &penalty
+	}
+}
+
+// WithLogitBias adds the biases (-100 to 100) to the logits of the tokens,
+// identified by their ID in the tokenizer of the model
+func WithLogitBias(bias map[int]float64) ChatCompletionOptionFunc {
+	return func(opts *ChatCompletionOptions) {
+		opts.LogitBias = bias
+	}
+}
+
+// WithStrictness sets how providers handle the sampling settings they cannot
+// honour
+func WithStrictness(strictness Strictness) ChatCompletionOptionFunc {
+	return func(opts *ChatCompletionOptions) {
+		opts.Strictness = strictness
+	}
+}
@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSamplingValidate(t *testing.T) {
+	messages := WithMessages(NewMessage(RoleUser, "hi"))
+
+	valid := NewChatCompletionOptions(messages,
+		WithTopP(0.9), WithTopK(40), WithMinP(0.05), WithStop("\n\n"),
+		WithFrequencyPenalty(0.5), WithPresencePenalty(-0.5),
+		WithLogitBias(map[int]float64{42: -100}), WithStrictness(StrictnessFail),
+	)
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := map[string]ChatCompletionOptionFunc{
+		"top_p":             WithTopP(1.5),
+		"top_k":             WithTopK(-1),
+		"min_p":             WithMinP(-0.1),
+		"stop":              WithStop(""),
+		"frequency_penalty": WithFrequencyPenalty(3),
+		"presence_penalty":  WithPresencePenalty(-3),
+		"logit_bias":        WithLogitBias(map[int]float64{42: 150}),
+		"strictness":        WithStrictness("lenient"),
+	}
+
+	for field, fn := range invalid {
+		err := NewChatCompletionOptions(messages, fn).Validate()
+
+		var validationErr ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != field {
+			t.Errorf("%s: expected a validation error, got %v", field, err)
+		}
+	}
+}
+
+func TestCheckSampling(t *testing.T) {
+	ctx := context.Background()
+
+	opts := NewChatCompletionOptions(WithTopK(40), WithTopP(0.9))
+	if err := opts.CheckSampling(ctx, "test", SamplingTopK); err != nil {
+		t.Errorf("expected the unsupported setting to be ignored, got %v", err)
+	}
+
+	opts.Strictness = StrictnessFail
+	if err := opts.CheckSampling(ctx, "test", SamplingMinP, SamplingLogitBias); err != nil {
+		t.Errorf("expected no error for unset settings, got %v", err)
+	}
+	if err := opts.CheckSampling(ctx, "test", SamplingTopK); !errors.Is(err, ErrUnsupportedSetting) {
+		t.Errorf("expected ErrUnsupportedSetting, got %v", err)
+	}
+}
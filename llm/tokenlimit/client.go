@@ -12,6 +12,7 @@ type Client struct {
 	chatCompletionLimiter *rate.Limiter
 	embeddingsLimiter     *rate.Limiter
 	transcriptionLimiter  *rate.Limiter
+	imageLimiter          *rate.Limiter
 	client                llm.Client
 }
 
@@ -118,17 +119,100 @@ func (c *Client) Transcription(ctx context.Context, audio []byte, funcs ...llm.T
 	return response, nil
 }
 
+// ImageGeneration implements llm.ImageGenerationClient.
+//
+// NOTE: Same post-request rate limiting approach as ChatCompletion.
+func (c *Client) ImageGeneration(ctx context.Context, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	response, err := llm.DelegateImageGeneration(ctx, c.client, prompt, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c.limitImage(ctx, response)
+}
+
+// ImageEdit implements llm.ImageEditClient.
+func (c *Client) ImageEdit(ctx context.Context, image []byte, prompt string, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	response, err := llm.DelegateImageEdit(ctx, c.client, image, prompt, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c.limitImage(ctx, response)
+}
+
+// ImageVariation implements llm.ImageEditClient.
+func (c *Client) ImageVariation(ctx context.Context, image []byte, funcs ...llm.ImageGenerationOptionFunc) (llm.ImageGenerationResponse, error) {
+	response, err := llm.DelegateImageVariation(ctx, c.client, image, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c.limitImage(ctx, response)
+}
+
+// Complete implements llm.CompletionClient. Completions share the chat
+// completions limiter.
+//
+// NOTE: Same post-request rate limiting approach as ChatCompletion.
+func (c *Client) Complete(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	response, err := llm.DelegateComplete(ctx, c.client, prompt, suffix, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if c.chatCompletionLimiter != nil && response.Usage() != nil && response.Usage().TotalTokens() > 0 {
+		if err := waitN(ctx, c.chatCompletionLimiter, int(response.Usage().TotalTokens())); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return response, nil
+}
+
+// CompleteStream implements llm.CompletionClient.
+func (c *Client) CompleteStream(ctx context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	stream, err := llm.DelegateCompleteStream(ctx, c.client, prompt, suffix, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c.wrapStreamWithTokenTracking(ctx, stream), nil
+}
+
+func (c *Client) limitImage(ctx context.Context, response llm.ImageGenerationResponse) (llm.ImageGenerationResponse, error) {
+	if c.imageLimiter != nil && response.Usage() != nil && response.Usage().TotalTokens() > 0 {
+		if err := waitN(ctx, c.imageLimiter, int(response.Usage().TotalTokens())); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return response, nil
+}
+
+// ValidateAttachment implements llm.AttachmentValidator.
+func (c *Client) ValidateAttachment(attachment llm.Attachment) error {
+	return llm.DelegateValidateAttachment(c.client, attachment)
+}
+
 func NewClient(client llm.Client, funcs ...OptionFunc) *Client {
 	opts := NewOptions(funcs...)
 	return &Client{
 		chatCompletionLimiter: opts.ChatCompletionLimiter,
 		embeddingsLimiter:     opts.EmbeddingsLimiter,
 		transcriptionLimiter:  opts.TranscriptionLimiter,
+		imageLimiter:          opts.ImageLimiter,
 		client:                client,
 	}
 }
 
-var _ llm.Client = &Client{}
+var (
+	_ llm.Client                = &Client{}
+	_ llm.ImageGenerationClient = &Client{}
+	_ llm.ImageEditClient       = &Client{}
+	_ llm.CompletionClient      = &Client{}
+	_ llm.AttachmentValidator   = &Client{}
+)
 
 // waitN splits the wait into chunks no larger than the limiter's burst size
 // to avoid "WaitN(n) exceeds limiter's burst" errors.
@@ -10,6 +10,7 @@ type Options struct {
 	ChatCompletionLimiter *rate.Limiter
 	EmbeddingsLimiter     *rate.Limiter
 	TranscriptionLimiter  *rate.Limiter
+	ImageLimiter          *rate.Limiter
 }
 
 type OptionFunc func(opts *Options)
@@ -46,3 +47,9 @@ func WithTranscriptionLimit(max int, interval time.Duration) OptionFunc {
 		opts.TranscriptionLimiter = rate.NewLimiter(rate.Limit(float64(max)/interval.Seconds()), max)
 	}
 }
+
+func WithImageLimit(max int, interval time.Duration) OptionFunc {
+	return func(opts *Options) {
+		opts.ImageLimiter = rate.NewLimiter(rate.Limit(float64(max)/interval.Seconds()), max)
+	}
+}
@@ -0,0 +1,168 @@
+// Package transcript writes timestamped transcriptions as subtitles (SRT,
+// WebVTT) or JSON.
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoTimestamps      = errors.New("transcription has no timestamps")
+	ErrUnsupportedFormat = errors.New("unsupported format")
+)
+
+// Format is an output format of a transcription
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatSRT  Format = "srt"
+	FormatVTT  Format = "vtt"
+	FormatJSON Format = "json"
+)
+
+// Formats lists the supported output formats
+var Formats = []Format{FormatText, FormatSRT, FormatVTT, FormatJSON}
+
+// Write writes the transcription in the given format. SRT and WebVTT require a
+// llm.TimedTranscriptionResponse with segments.
+func Write(w io.Writer, format Format, res llm.TranscriptionResponse) error {
+	switch format {
+	case FormatText, "":
+		_, err := io.WriteString(w, res.Text())
+		return errors.WithStack(err)
+
+	case FormatJSON:
+		return WriteJSON(w, res)
+
+	case FormatSRT, FormatVTT:
+		timed, ok := res.(llm.TimedTranscriptionResponse)
+		if !ok || len(timed.Segments()) == 0 {
+			return errors.WithStack(ErrNoTimestamps)
+		}
+
+		if format == FormatSRT {
+			return WriteSRT(w, timed.Segments())
+		}
+
+		return WriteVTT(w, timed.Segments())
+
+	default:
+		return errors.Wrapf(ErrUnsupportedFormat, "unknown transcription format '%s'", format)
+	}
+}
+
+// WriteSRT writes the segments as SubRip subtitles. The speaker, when known,
+// prefixes the cue text.
+func WriteSRT(w io.Writer, segments []llm.TranscriptionSegment) error {
+	for i, segment := range segments {
+		text := segment.Text
+		if segment.Speaker != "" {
+			text = segment.Speaker + ": " + text
+		}
+
+		if _, err := fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(segment.Start, ','), timestamp(segment.End, ','), text); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// WriteVTT writes the segments as WebVTT subtitles. The speaker, when known,
+// is set with a voice span.
+func WriteVTT(w io.Writer, segments []llm.TranscriptionSegment) error {
+	if _, err := io.WriteString(w, "WEBVTT\n\n"); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, segment := range segments {
+		text := segment.Text
+		if segment.Speaker != "" {
+			text = "<v " + strings.ReplaceAll(segment.Speaker, ">", "") + ">" + text
+		}
+
+		if _, err := fmt.Fprintf(w, "%s --> %s\n%s\n\n", timestamp(segment.Start, '.'), timestamp(segment.End, '.'), text); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+type jsonTranscription struct {
+	Text     string        `json:"text"`
+	Language string        `json:"language,omitempty"`
+	Segments []jsonSegment `json:"segments,omitempty"`
+	Words    []jsonWord    `json:"words,omitempty"`
+}
+
+type jsonSegment struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	Speaker string  `json:"speaker,omitempty"`
+}
+
+type jsonWord struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Word    string  `json:"word"`
+	Speaker string  `json:"speaker,omitempty"`
+}
+
+// WriteJSON writes the transcription as JSON, timestamps being expressed in
+// seconds
+func WriteJSON(w io.Writer, res llm.TranscriptionResponse) error {
+	transcription := jsonTranscription{
+		Text:     res.Text(),
+		Language: res.Language(),
+	}
+
+	if timed, ok := res.(llm.TimedTranscriptionResponse); ok {
+		for _, s := range timed.Segments() {
+			transcription.Segments = append(transcription.Segments, jsonSegment{
+				Start:   s.Start.Seconds(),
+				End:     s.End.Seconds(),
+				Text:    s.Text,
+				Speaker: s.Speaker,
+			})
+		}
+
+		for _, word := range timed.Words() {
+			transcription.Words = append(transcription.Words, jsonWord{
+				Start:   word.Start.Seconds(),
+				End:     word.End.Seconds(),
+				Word:    word.Word,
+				Speaker: word.Speaker,
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(transcription); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// timestamp formats d as HH:MM:SS,mmm (SRT) or HH:MM:SS.mmm (WebVTT)
+func timestamp(d time.Duration, separator rune) string {
+	if d < 0 {
+		d = 0
+	}
+
+	ms := d.Milliseconds()
+
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3_600_000, (ms/60_000)%60, (ms/1000)%60, separator, ms%1000)
+}
@@ -0,0 +1,83 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+func testTranscription() llm.TranscriptionResponse {
+	return llm.NewTimedTranscriptionResponse("Hello there. General Kenobi!", "en", nil,
+		[]llm.TranscriptionSegment{
+			{Start: 0, End: 1500 * time.Millisecond, Text: "Hello there.", Speaker: "A"},
+			{Start: 3723*time.Second + 45*time.Millisecond, End: 3725 * time.Second, Text: "General Kenobi!"},
+		},
+		[]llm.TranscriptionWord{
+			{Start: 0, End: 500 * time.Millisecond, Word: "Hello", Speaker: "A"},
+		},
+	)
+}
+
+func TestWrite(t *testing.T) {
+	testCases := []struct {
+		format   Format
+		expected string
+	}{
+		{
+			format:   FormatSRT,
+			expected: "1\n00:00:00,000 --> 00:00:01,500\nA: Hello there.\n\n2\n01:02:03,045 --> 01:02:05,000\nGeneral Kenobi!\n\n",
+		},
+		{
+			format:   FormatVTT,
+			expected: "WEBVTT\n\n00:00:00.000 --> 00:00:01.500\n<v A>Hello there.\n\n01:02:03.045 --> 01:02:05.000\nGeneral Kenobi!\n\n",
+		},
+		{
+			format:   FormatText,
+			expected: "Hello there. General Kenobi!",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tc.format, testTranscription()); err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if buf.String() != tc.expected {
+				t.Errorf("unexpected output:\n%q\nexpected:\n%q", buf.String(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, testTranscription()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	var decoded jsonTranscription
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if len(decoded.Segments) != 2 || decoded.Segments[1].Start != 3723.045 || decoded.Segments[0].Speaker != "A" {
+		t.Errorf("unexpected segments %+v", decoded.Segments)
+	}
+	if len(decoded.Words) != 1 || decoded.Words[0].Word != "Hello" {
+		t.Errorf("unexpected words %+v", decoded.Words)
+	}
+}
+
+func TestWrite_NoTimestamps(t *testing.T) {
+	res := llm.NewTranscriptionResponse("Hello", "en", nil)
+
+	if err := Write(&bytes.Buffer{}, FormatSRT, res); !errors.Is(err, ErrNoTimestamps) {
+		t.Errorf("expected ErrNoTimestamps, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package llm
 import (
 	"bytes"
 	"context"
+	"time"
 )
 
 // AudioFormat identifie le format d'un fichier audio soumis à la transcription.
@@ -23,6 +24,8 @@ type TranscriptionClient interface {
 }
 
 type TranscriptionOptions struct {
+	// Model remplace, pour cet appel, le modèle configuré sur le client.
+	Model string
 	// Format indique le format de l'audio. S'il est vide, les providers
 	// tentent de le détecter via DetectAudioFormat.
 	Format AudioFormat
@@ -32,6 +35,12 @@ type TranscriptionOptions struct {
 	Prompt string
 	// Temperature contrôle l'échantillonnage, entre 0 et 1.
 	Temperature *float64
+	// Timestamps demande des horodatages à la granularité donnée. La réponse
+	// implémente alors TimedTranscriptionResponse.
+	Timestamps []TimestampGranularity
+	// Diarize demande l'identification des locuteurs (non supporté par tous
+	// les providers).
+	Diarize bool
 }
 
 func NewTranscriptionOptions(funcs ...TranscriptionOptionFunc) *TranscriptionOptions {
@@ -44,6 +53,14 @@ func NewTranscriptionOptions(funcs ...TranscriptionOptionFunc) *TranscriptionOpt
 
 type TranscriptionOptionFunc func(opts *TranscriptionOptions)
 
+// WithTranscriptionModel remplace, pour cet appel, le modèle configuré sur le
+// client.
+func WithTranscriptionModel(model string) TranscriptionOptionFunc {
+	return func(opts *TranscriptionOptions) {
+		opts.Model = model
+	}
+}
+
 func WithAudioFormat(format AudioFormat) TranscriptionOptionFunc {
 	return func(opts *TranscriptionOptions) {
 		opts.Format = format
@@ -68,6 +85,49 @@ func WithTranscriptionTemperature(temperature float64) TranscriptionOptionFunc {
 	}
 }
 
+// WithTranscriptionTimestamps demande des horodatages par segment et/ou par
+// mot. Sans argument, seuls les segments sont horodatés.
+func WithTranscriptionTimestamps(granularities ...TimestampGranularity) TranscriptionOptionFunc {
+	return func(opts *TranscriptionOptions) {
+		if len(granularities) == 0 {
+			granularities = []TimestampGranularity{TimestampGranularitySegment}
+		}
+		opts.Timestamps = granularities
+	}
+}
+
+// WithTranscriptionDiarization demande l'identification des locuteurs. Elle
+// implique l'horodatage des segments.
+func WithTranscriptionDiarization() TranscriptionOptionFunc {
+	return func(opts *TranscriptionOptions) {
+		opts.Diarize = true
+	}
+}
+
+// TimestampGranularity est la granularité des horodatages d'une transcription.
+type TimestampGranularity string
+
+const (
+	TimestampGranularitySegment TimestampGranularity = "segment"
+	TimestampGranularityWord    TimestampGranularity = "word"
+)
+
+// HasTimestamps indique si des horodatages à la granularité donnée sont
+// demandés.
+func (o *TranscriptionOptions) HasTimestamps(granularity TimestampGranularity) bool {
+	if granularity == TimestampGranularitySegment && o.Diarize {
+		return true
+	}
+
+	for _, g := range o.Timestamps {
+		if g == granularity {
+			return true
+		}
+	}
+
+	return false
+}
+
 type TranscriptionResponse interface {
 	Text() string
 	// Language est le code de la langue détectée, vide si le provider ne le fournit pas.
@@ -180,6 +240,65 @@ func NewTranscriptionResponse(text, language string, usage TranscriptionUsage) *
 
 var _ TranscriptionResponse = &BaseTranscriptionResponse{}
 
+// TranscriptionSegment est un passage horodaté de la transcription.
+type TranscriptionSegment struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+	// Speaker identifie le locuteur, vide sans diarisation.
+	Speaker string
+}
+
+// TranscriptionWord est un mot horodaté de la transcription.
+type TranscriptionWord struct {
+	Start time.Duration
+	End   time.Duration
+	Word  string
+	// Speaker identifie le locuteur, vide sans diarisation.
+	Speaker string
+}
+
+// TimedTranscriptionResponse est implémentée par les réponses horodatées,
+// obtenues avec WithTranscriptionTimestamps lorsque le provider les supporte.
+// Les appelants la découvrent par assertion de type :
+//
+//	if timed, ok := res.(llm.TimedTranscriptionResponse); ok {
+//	    // ...
+//	}
+type TimedTranscriptionResponse interface {
+	TranscriptionResponse
+	// Segments retourne les segments horodatés, vide si non demandés.
+	Segments() []TranscriptionSegment
+	// Words retourne les mots horodatés, vide si non demandés.
+	Words() []TranscriptionWord
+}
+
+type BaseTimedTranscriptionResponse struct {
+	*BaseTranscriptionResponse
+	segments []TranscriptionSegment
+	words    []TranscriptionWord
+}
+
+// Segments implements TimedTranscriptionResponse.
+func (r *BaseTimedTranscriptionResponse) Segments() []TranscriptionSegment {
+	return r.segments
+}
+
+// Words implements TimedTranscriptionResponse.
+func (r *BaseTimedTranscriptionResponse) Words() []TranscriptionWord {
+	return r.words
+}
+
+func NewTimedTranscriptionResponse(text, language string, usage TranscriptionUsage, segments []TranscriptionSegment, words []TranscriptionWord) *BaseTimedTranscriptionResponse {
+	return &BaseTimedTranscriptionResponse{
+		BaseTranscriptionResponse: NewTranscriptionResponse(text, language, usage),
+		segments:                  segments,
+		words:                     words,
+	}
+}
+
+var _ TimedTranscriptionResponse = &BaseTimedTranscriptionResponse{}
+
 // DetectAudioFormat détecte le format d'un flux audio à partir de ses premiers
 // octets (magic numbers). Retourne "" si le format n'est pas reconnu.
 func DetectAudioFormat(audio []byte) AudioFormat {
@@ -0,0 +1,138 @@
+package vectorstore
+
+import (
+	"context"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+const DefaultEmbedderBatchSize = 32
+
+// Embedder is an Index computing the vectors of the documents it receives
+// from their content, using an llm.EmbeddingsClient, before storing them in
+// the wrapped index.
+type Embedder struct {
+	index   Index
+	client  llm.EmbeddingsClient
+	options *EmbedderOptions
+}
+
+type EmbedderOptions struct {
+	// BatchSize is the maximum number of inputs sent in a single embeddings
+	// request.
+	BatchSize         int
+	EmbeddingsOptions []llm.EmbeddingsOptionFunc
+}
+
+type EmbedderOptionFunc func(opts *EmbedderOptions)
+
+func NewEmbedderOptions(funcs ...EmbedderOptionFunc) *EmbedderOptions {
+	opts := &EmbedderOptions{
+		BatchSize: DefaultEmbedderBatchSize,
+	}
+	for _, fn := range funcs {
+		fn(opts)
+	}
+	return opts
+}
+
+func WithBatchSize(size int) EmbedderOptionFunc {
+	return func(opts *EmbedderOptions) {
+		opts.BatchSize = size
+	}
+}
+
+func WithEmbeddingsOptions(funcs ...llm.EmbeddingsOptionFunc) EmbedderOptionFunc {
+	return func(opts *EmbedderOptions) {
+		opts.EmbeddingsOptions = funcs
+	}
+}
+
+// Upsert implements Index. Documents without a vector are embedded from their
+// content; documents already carrying a vector are stored as is.
+func (e *Embedder) Upsert(ctx context.Context, docs ...Document) error {
+	pending := make([]int, 0, len(docs))
+	for idx, doc := range docs {
+		if len(doc.Vector) == 0 {
+			pending = append(pending, idx)
+		}
+	}
+
+	if len(pending) > 0 {
+		docs = append([]Document(nil), docs...)
+
+		inputs := make([]string, len(pending))
+		for idx, docIdx := range pending {
+			inputs[idx] = docs[docIdx].Content
+		}
+
+		vectors, err := e.embed(ctx, inputs)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		for idx, docIdx := range pending {
+			docs[docIdx].Vector = vectors[idx]
+		}
+	}
+
+	return e.index.Upsert(ctx, docs...)
+}
+
+// Delete implements Index.
+func (e *Embedder) Delete(ctx context.Context, ids ...string) error {
+	return e.index.Delete(ctx, ids...)
+}
+
+// Query implements Index.
+func (e *Embedder) Query(ctx context.Context, vector []float64, funcs ...QueryOptionFunc) ([]Result, error) {
+	return e.index.Query(ctx, vector, funcs...)
+}
+
+// QueryText embeds the given text and returns the closest documents.
+func (e *Embedder) QueryText(ctx context.Context, text string, funcs ...QueryOptionFunc) ([]Result, error) {
+	vectors, err := e.embed(ctx, []string{text})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return e.index.Query(ctx, vectors[0], funcs...)
+}
+
+func (e *Embedder) embed(ctx context.Context, inputs []string) ([][]float64, error) {
+	batchSize := e.options.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(inputs)
+	}
+
+	vectors := make([][]float64, 0, len(inputs))
+	for start := 0; start < len(inputs); start += batchSize {
+		end := min(start+batchSize, len(inputs))
+
+		res, err := e.client.Embeddings(ctx, inputs[start:end], e.options.EmbeddingsOptions...)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		embeddings := res.Embeddings()
+		if len(embeddings) != end-start {
+			return nil, errors.Errorf("expected %d embeddings, got %d", end-start, len(embeddings))
+		}
+
+		vectors = append(vectors, embeddings...)
+	}
+
+	return vectors, nil
+}
+
+// NewEmbedder returns an Embedder storing its documents in index.
+func NewEmbedder(index Index, client llm.EmbeddingsClient, funcs ...EmbedderOptionFunc) *Embedder {
+	return &Embedder{
+		index:   index,
+		client:  client,
+		options: NewEmbedderOptions(funcs...),
+	}
+}
+
+var _ Index = &Embedder{}
@@ -0,0 +1,114 @@
+package vectorstore
+
+import "reflect"
+
+// Filter reports whether a document, given its metadata, should be part of
+// the query results.
+type Filter func(metadata map[string]any) bool
+
+// Eq matches documents whose metadata value for key equals value. Numeric
+// values are compared by value regardless of their Go type, so that
+// metadata restored from disk still matches.
+func Eq(key string, value any) Filter {
+	return func(metadata map[string]any) bool {
+		v, exists := metadata[key]
+		if !exists {
+			return false
+		}
+		return equal(v, value)
+	}
+}
+
+// In matches documents whose metadata value for key equals one of values.
+func In(key string, values ...any) Filter {
+	return func(metadata map[string]any) bool {
+		v, exists := metadata[key]
+		if !exists {
+			return false
+		}
+		for _, candidate := range values {
+			if equal(v, candidate) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Exists matches documents having a metadata value for key.
+func Exists(key string) Filter {
+	return func(metadata map[string]any) bool {
+		_, exists := metadata[key]
+		return exists
+	}
+}
+
+// And matches documents matched by every filter.
+func And(filters ...Filter) Filter {
+	return func(metadata map[string]any) bool {
+		for _, f := range filters {
+			if !f(metadata) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches documents matched by at least one filter.
+func Or(filters ...Filter) Filter {
+	return func(metadata map[string]any) bool {
+		for _, f := range filters {
+			if f(metadata) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches documents not matched by the filter.
+func Not(filter Filter) Filter {
+	return func(metadata map[string]any) bool {
+		return !filter(metadata)
+	}
+}
+
+func equal(a, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,486 @@
+package vectorstore
+
+import (
+	"container/heap"
+	"context"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// HNSWIndex is an approximate nearest neighbor index based on Hierarchical
+// Navigable Small World graphs. It can be persisted to a single file with
+// SaveFile and restored with OpenHNSWIndex.
+type HNSWIndex struct {
+	options *HNSWOptions
+	levelMu float64
+
+	mu         sync.RWMutex
+	rng        *rand.Rand
+	dimensions int
+	nodes      []*hnswNode
+	// free lists the slots of the removed nodes, reused by the next ones
+	free     []int
+	ids      map[string]int
+	entry    int
+	maxLevel int
+}
+
+type hnswNode struct {
+	doc       Document
+	level     int
+	neighbors [][]int
+	// referrers counts the links to the node by node, so that it can be
+	// unlinked without scanning the graph
+	referrers map[int]int
+}
+
+// Upsert implements Index.
+func (i *HNSWIndex) Upsert(ctx context.Context, docs ...Document) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	dimensions := i.dimensions
+	for idx := range docs {
+		if dimensions == 0 {
+			dimensions = len(docs[idx].Vector)
+		}
+		if err := validateDocument(&docs[idx], dimensions); err != nil {
+			return err
+		}
+	}
+
+	i.dimensions = dimensions
+	for _, doc := range docs {
+		if err := ctx.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+		if existing, exists := i.ids[doc.ID]; exists {
+			i.remove(existing)
+		}
+		i.insert(cloneDocument(doc))
+	}
+
+	return nil
+}
+
+// Delete implements Index.
+func (i *HNSWIndex) Delete(ctx context.Context, ids ...string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, id := range ids {
+		if idx, exists := i.ids[id]; exists {
+			i.remove(idx)
+		}
+	}
+
+	return nil
+}
+
+// List implements Lister.
+func (i *HNSWIndex) List(ctx context.Context, filter Filter) ([]Document, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	docs := make([]Document, 0)
+	for _, node := range i.nodes {
+		if node == nil || (filter != nil && !filter(node.doc.Metadata)) {
+			continue
+		}
+		docs = append(docs, cloneDocument(node.doc))
+	}
+
+	return docs, nil
+}
+
+// Query implements Index.
+func (i *HNSWIndex) Query(ctx context.Context, vector []float64, funcs ...QueryOptionFunc) ([]Result, error) {
+	opts := NewQueryOptions(funcs...)
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if err := checkQueryVector(vector, i.dimensions); err != nil {
+		return nil, err
+	}
+
+	if i.entry < 0 {
+		return []Result{}, nil
+	}
+
+	total := len(i.ids)
+	k := opts.TopK
+	if k <= 0 || k > total {
+		k = total
+	}
+
+	ef := max(i.options.EfSearch, k)
+
+	ep := i.entry
+	for level := i.maxLevel; level > 0; level-- {
+		ep = i.greedy(vector, ep, level)
+	}
+
+	// Filters are applied after the graph search: widen the candidate list
+	// until enough documents pass them or the whole graph has been visited.
+	for {
+		candidates := i.searchLayer(vector, ep, ef, 0)
+
+		results := make([]Result, 0, len(candidates))
+		for _, c := range candidates {
+			doc := i.nodes[c.id].doc
+			score := -c.distance
+			if !opts.accept(&doc, score) {
+				continue
+			}
+			results = append(results, Result{Document: doc, Score: score})
+		}
+
+		if len(results) >= k || ef >= total {
+			return topResults(results, k), nil
+		}
+
+		ef *= 2
+	}
+}
+
+// Len returns the number of documents in the index.
+func (i *HNSWIndex) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.ids)
+}
+
+func (i *HNSWIndex) distance(a, b []float64) float64 {
+	return -i.options.Metric.Score(a, b)
+}
+
+func (i *HNSWIndex) maxNeighbors(level int) int {
+	if level == 0 {
+		return 2 * i.options.MaxConnections
+	}
+	return i.options.MaxConnections
+}
+
+func (i *HNSWIndex) randomLevel() int {
+	return int(math.Floor(-math.Log(1-i.rng.Float64()) * i.levelMu))
+}
+
+func (i *HNSWIndex) insert(doc Document) {
+	level := i.randomLevel()
+
+	node := &hnswNode{
+		doc:       doc,
+		level:     level,
+		neighbors: make([][]int, level+1),
+		referrers: make(map[int]int),
+	}
+
+	idx := len(i.nodes)
+	if free := len(i.free); free > 0 {
+		idx = i.free[free-1]
+		i.free = i.free[:free-1]
+		i.nodes[idx] = node
+	} else {
+		i.nodes = append(i.nodes, node)
+	}
+	i.ids[doc.ID] = idx
+
+	if i.entry < 0 {
+		i.entry = idx
+		i.maxLevel = level
+		return
+	}
+
+	ep := i.entry
+	for l := i.maxLevel; l > level; l-- {
+		ep = i.greedy(doc.Vector, ep, l)
+	}
+
+	for l := min(level, i.maxLevel); l >= 0; l-- {
+		candidates := i.searchLayer(doc.Vector, ep, i.options.EfConstruction, l)
+
+		limit := i.maxNeighbors(l)
+		neighbors := make([]int, 0, limit)
+		for _, c := range candidates {
+			if c.id == idx {
+				continue
+			}
+			neighbors = append(neighbors, c.id)
+			if len(neighbors) == limit {
+				break
+			}
+		}
+		i.setNeighbors(idx, l, neighbors)
+
+		for _, n := range neighbors {
+			i.link(n, idx, l)
+		}
+
+		if len(candidates) > 0 {
+			ep = candidates[0].id
+		}
+	}
+
+	if level > i.maxLevel {
+		i.entry = idx
+		i.maxLevel = level
+	}
+}
+
+// link adds target to the neighbors of source on the given level, pruning the
+// farthest neighbors when the level capacity is exceeded.
+func (i *HNSWIndex) link(source, target, level int) {
+	node := i.nodes[source]
+	node.neighbors[level] = append(node.neighbors[level], target)
+	i.nodes[target].referrers[source]++
+
+	if len(node.neighbors[level]) > i.maxNeighbors(level) {
+		i.setNeighbors(source, level, i.closest(node.doc.Vector, node.neighbors[level], i.maxNeighbors(level)))
+	}
+}
+
+// setNeighbors replaces the neighbors of source on the given level, keeping
+// the referrers of the former and new neighbors up to date.
+func (i *HNSWIndex) setNeighbors(source, level int, neighbors []int) {
+	node := i.nodes[source]
+	previous := node.neighbors[level]
+
+	// The lists are short, and mostly unchanged when pruned
+	for _, n := range previous {
+		if slices.Contains(neighbors, n) {
+			continue
+		}
+		target := i.nodes[n]
+		if target.referrers[source]--; target.referrers[source] <= 0 {
+			delete(target.referrers, source)
+		}
+	}
+
+	for _, n := range neighbors {
+		if !slices.Contains(previous, n) {
+			i.nodes[n].referrers[source]++
+		}
+	}
+
+	node.neighbors[level] = neighbors
+}
+
+// closest returns at most limit node indexes from candidates, ordered by
+// distance to vector.
+func (i *HNSWIndex) closest(vector []float64, candidates []int, limit int) []int {
+	scored := make([]hnswCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		scored = append(scored, hnswCandidate{id: c, distance: i.distance(vector, i.nodes[c].doc.Vector)})
+	}
+	sort.Slice(scored, func(a, b int) bool { return scored[a].distance < scored[b].distance })
+
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+
+	ids := make([]int, len(scored))
+	for idx, c := range scored {
+		ids[idx] = c.id
+	}
+	return ids
+}
+
+// remove unlinks the node from the graph and reconnects the nodes linking to
+// it with its neighbors so that the graph stays navigable. Its slot is reused
+// by the next inserted node.
+func (i *HNSWIndex) remove(idx int) {
+	node := i.nodes[idx]
+
+	referrers := slices.Sorted(maps.Keys(node.referrers))
+	for _, r := range referrers {
+		referrer := i.nodes[r]
+
+		for level, neighbors := range referrer.neighbors {
+			if !slices.Contains(neighbors, idx) {
+				continue
+			}
+
+			seen := map[int]struct{}{r: {}, idx: {}}
+			candidates := make([]int, 0, len(neighbors)+i.maxNeighbors(level))
+
+			others := neighbors
+			if level < len(node.neighbors) {
+				others = append(slices.Clip(others), node.neighbors[level]...)
+			}
+
+			for _, c := range others {
+				if _, exists := seen[c]; exists {
+					continue
+				}
+				seen[c] = struct{}{}
+				candidates = append(candidates, c)
+			}
+
+			i.setNeighbors(r, level, i.closest(referrer.doc.Vector, candidates, i.maxNeighbors(level)))
+		}
+	}
+
+	for level := range node.neighbors {
+		i.setNeighbors(idx, level, nil)
+	}
+
+	i.nodes[idx] = nil
+	i.free = append(i.free, idx)
+	delete(i.ids, node.doc.ID)
+
+	if i.entry != idx {
+		return
+	}
+
+	i.entry = -1
+	i.maxLevel = 0
+	for candidate, other := range i.nodes {
+		if other == nil {
+			continue
+		}
+		if i.entry < 0 || other.level > i.maxLevel {
+			i.entry = candidate
+			i.maxLevel = other.level
+		}
+	}
+}
+
+// greedy walks the given level from ep towards the node closest to vector.
+func (i *HNSWIndex) greedy(vector []float64, ep, level int) int {
+	current := ep
+	currentDistance := i.distance(vector, i.nodes[current].doc.Vector)
+
+	for changed := true; changed; {
+		changed = false
+		for _, n := range i.nodes[current].neighbors[level] {
+			d := i.distance(vector, i.nodes[n].doc.Vector)
+			if d < currentDistance {
+				current, currentDistance, changed = n, d, true
+			}
+		}
+	}
+
+	return current
+}
+
+// searchLayer returns up to ef nodes of the given level close to vector,
+// sorted by ascending distance.
+func (i *HNSWIndex) searchLayer(vector []float64, ep, ef, level int) []hnswCandidate {
+	visited := map[int]struct{}{ep: {}}
+
+	start := hnswCandidate{id: ep, distance: i.distance(vector, i.nodes[ep].doc.Vector)}
+	candidates := &hnswMinHeap{start}
+	results := &hnswMaxHeap{start}
+
+	for candidates.Len() > 0 {
+		current := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && current.distance > (*results)[0].distance {
+			break
+		}
+
+		node := i.nodes[current.id]
+		if level >= len(node.neighbors) {
+			continue
+		}
+
+		for _, n := range node.neighbors[level] {
+			if _, seen := visited[n]; seen {
+				continue
+			}
+			visited[n] = struct{}{}
+
+			d := i.distance(vector, i.nodes[n].doc.Vector)
+			if results.Len() < ef || d < (*results)[0].distance {
+				heap.Push(candidates, hnswCandidate{id: n, distance: d})
+				heap.Push(results, hnswCandidate{id: n, distance: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	sorted := make([]hnswCandidate, results.Len())
+	for idx := len(sorted) - 1; idx >= 0; idx-- {
+		sorted[idx] = heap.Pop(results).(hnswCandidate)
+	}
+
+	return sorted
+}
+
+// NewHNSWIndex returns an empty HNSW index.
+func NewHNSWIndex(funcs ...HNSWOptionFunc) (*HNSWIndex, error) {
+	opts := NewHNSWOptions(funcs...)
+	return newHNSWIndex(opts)
+}
+
+func newHNSWIndex(opts *HNSWOptions) (*HNSWIndex, error) {
+	if err := opts.Metric.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.MaxConnections < 2 {
+		return nil, errors.Errorf("max connections must be at least 2, got %d", opts.MaxConnections)
+	}
+	if opts.EfConstruction < 1 {
+		return nil, errors.Errorf("ef construction must be positive, got %d", opts.EfConstruction)
+	}
+	if opts.EfSearch < 1 {
+		return nil, errors.Errorf("ef search must be positive, got %d", opts.EfSearch)
+	}
+
+	seed := rand.Uint64()
+	if opts.Seed != nil {
+		seed = *opts.Seed
+	}
+
+	return &HNSWIndex{
+		options: opts,
+		levelMu: 1 / math.Log(float64(opts.MaxConnections)),
+		rng:     rand.New(rand.NewPCG(seed, seed)),
+		ids:     make(map[string]int),
+		entry:   -1,
+	}, nil
+}
+
+var (
+	_ Index  = &HNSWIndex{}
+	_ Lister = &HNSWIndex{}
+)
+
+type hnswCandidate struct {
+	id       int
+	distance float64
+}
+
+type hnswMinHeap []hnswCandidate
+
+func (h hnswMinHeap) Len() int           { return len(h) }
+func (h hnswMinHeap) Less(a, b int) bool { return h[a].distance < h[b].distance }
+func (h hnswMinHeap) Swap(a, b int)      { h[a], h[b] = h[b], h[a] }
+func (h *hnswMinHeap) Push(x any)        { *h = append(*h, x.(hnswCandidate)) }
+func (h *hnswMinHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+type hnswMaxHeap []hnswCandidate
+
+func (h hnswMaxHeap) Len() int           { return len(h) }
+func (h hnswMaxHeap) Less(a, b int) bool { return h[a].distance > h[b].distance }
+func (h hnswMaxHeap) Swap(a, b int)      { h[a], h[b] = h[b], h[a] }
+func (h *hnswMaxHeap) Push(x any)        { *h = append(*h, x.(hnswCandidate)) }
+func (h *hnswMaxHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
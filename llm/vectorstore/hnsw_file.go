@@ -0,0 +1,252 @@
+package vectorstore
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const hnswFormatVersion = 1
+
+type hnswSnapshot struct {
+	Version        int
+	Metric         Metric
+	MaxConnections int
+	EfConstruction int
+	Dimensions     int
+	Entry          int
+	MaxLevel       int
+	Nodes          []hnswNodeSnapshot
+}
+
+type hnswNodeSnapshot struct {
+	ID        string
+	Vector    []float64
+	Content   string
+	Metadata  []byte
+	Level     int
+	Neighbors [][]int
+}
+
+// Save writes the index to w. Metadata values are encoded as JSON, so numbers
+// are restored as float64 when the index is loaded back.
+func (i *HNSWIndex) Save(w io.Writer) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	// Deleted nodes leave holes in the node slice: compact the indexes while
+	// taking the snapshot.
+	remap := make(map[int]int, len(i.ids))
+	for idx, node := range i.nodes {
+		if node != nil {
+			remap[idx] = len(remap)
+		}
+	}
+
+	snapshot := hnswSnapshot{
+		Version:        hnswFormatVersion,
+		Metric:         i.options.Metric,
+		MaxConnections: i.options.MaxConnections,
+		EfConstruction: i.options.EfConstruction,
+		Dimensions:     i.dimensions,
+		Entry:          -1,
+		MaxLevel:       i.maxLevel,
+		Nodes:          make([]hnswNodeSnapshot, 0, len(remap)),
+	}
+
+	if i.entry >= 0 {
+		snapshot.Entry = remap[i.entry]
+	}
+
+	for _, node := range i.nodes {
+		if node == nil {
+			continue
+		}
+
+		var metadata []byte
+		if node.doc.Metadata != nil {
+			var err error
+			metadata, err = json.Marshal(node.doc.Metadata)
+			if err != nil {
+				return errors.Wrapf(err, "could not encode metadata of document %q", node.doc.ID)
+			}
+		}
+
+		neighbors := make([][]int, len(node.neighbors))
+		for level, ids := range node.neighbors {
+			neighbors[level] = make([]int, len(ids))
+			for idx, id := range ids {
+				neighbors[level][idx] = remap[id]
+			}
+		}
+
+		snapshot.Nodes = append(snapshot.Nodes, hnswNodeSnapshot{
+			ID:        node.doc.ID,
+			Vector:    node.doc.Vector,
+			Content:   node.doc.Content,
+			Metadata:  metadata,
+			Level:     node.level,
+			Neighbors: neighbors,
+		})
+	}
+
+	if err := gob.NewEncoder(w).Encode(&snapshot); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// SaveFile atomically writes the index to the file at path.
+func (i *HNSWIndex) SaveFile(path string) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	tmp := file.Name()
+	defer os.Remove(tmp)
+
+	w := bufio.NewWriter(file)
+
+	if err := i.Save(w); err != nil {
+		file.Close()
+		return errors.WithStack(err)
+	}
+
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return errors.WithStack(err)
+	}
+
+	if err := file.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// LoadHNSWIndex reads an index previously written with Save. The metric and
+// graph parameters stored in the snapshot take precedence over the given
+// options; the search parameters and seed are taken from the options.
+func LoadHNSWIndex(r io.Reader, funcs ...HNSWOptionFunc) (*HNSWIndex, error) {
+	var snapshot hnswSnapshot
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, errors.Wrap(ErrInvalidIndexFormat, err.Error())
+	}
+
+	if snapshot.Version != hnswFormatVersion {
+		return nil, errors.Wrapf(ErrInvalidIndexFormat, "unsupported version %d", snapshot.Version)
+	}
+
+	opts := NewHNSWOptions(funcs...)
+	opts.Metric = snapshot.Metric
+	opts.MaxConnections = snapshot.MaxConnections
+	opts.EfConstruction = snapshot.EfConstruction
+
+	index, err := newHNSWIndex(opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	total := len(snapshot.Nodes)
+	if snapshot.Entry >= total || (snapshot.Entry < 0 && total > 0) {
+		return nil, errors.Wrapf(ErrInvalidIndexFormat, "invalid entry point %d", snapshot.Entry)
+	}
+
+	if total > 0 && snapshot.Nodes[snapshot.Entry].Level != snapshot.MaxLevel {
+		return nil, errors.Wrapf(ErrInvalidIndexFormat, "entry point level %d does not match the maximum level %d", snapshot.Nodes[snapshot.Entry].Level, snapshot.MaxLevel)
+	}
+
+	index.dimensions = snapshot.Dimensions
+	index.entry = snapshot.Entry
+	index.maxLevel = snapshot.MaxLevel
+	index.nodes = make([]*hnswNode, 0, total)
+
+	for _, n := range snapshot.Nodes {
+		if _, exists := index.ids[n.ID]; exists {
+			return nil, errors.Wrapf(ErrInvalidIndexFormat, "duplicate document %q", n.ID)
+		}
+
+		if len(n.Vector) == 0 || len(n.Vector) != snapshot.Dimensions {
+			return nil, errors.Wrapf(ErrInvalidIndexFormat, "document %q has %d dimensions, expected %d", n.ID, len(n.Vector), snapshot.Dimensions)
+		}
+
+		if n.Level < 0 || n.Level > snapshot.MaxLevel || len(n.Neighbors) != n.Level+1 {
+			return nil, errors.Wrapf(ErrInvalidIndexFormat, "node %q has %d levels, expected %d", n.ID, len(n.Neighbors), n.Level+1)
+		}
+
+		// The neighbors of a level must exist on that level, which the
+		// search walks without checking
+		for level, ids := range n.Neighbors {
+			for _, id := range ids {
+				if id < 0 || id >= total {
+					return nil, errors.Wrapf(ErrInvalidIndexFormat, "node %q references unknown node %d", n.ID, id)
+				}
+				if snapshot.Nodes[id].Level < level {
+					return nil, errors.Wrapf(ErrInvalidIndexFormat, "node %q references node %d on level %d above its own", n.ID, id, level)
+				}
+			}
+		}
+
+		doc := Document{
+			ID:      n.ID,
+			Vector:  n.Vector,
+			Content: n.Content,
+		}
+
+		if len(n.Metadata) > 0 {
+			if err := json.Unmarshal(n.Metadata, &doc.Metadata); err != nil {
+				return nil, errors.Wrapf(ErrInvalidIndexFormat, "could not decode metadata of document %q: %s", n.ID, err)
+			}
+		}
+
+		index.ids[n.ID] = len(index.nodes)
+		index.nodes = append(index.nodes, &hnswNode{
+			doc:       doc,
+			level:     n.Level,
+			neighbors: n.Neighbors,
+			referrers: make(map[int]int),
+		})
+	}
+
+	for idx, node := range index.nodes {
+		for _, ids := range node.neighbors {
+			for _, id := range ids {
+				index.nodes[id].referrers[idx]++
+			}
+		}
+	}
+
+	return index, nil
+}
+
+// OpenHNSWIndex loads the index stored in the file at path, or returns an
+// empty index if the file does not exist yet.
+func OpenHNSWIndex(path string, funcs ...HNSWOptionFunc) (*HNSWIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return NewHNSWIndex(funcs...)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	defer file.Close()
+
+	index, err := LoadHNSWIndex(bufio.NewReader(file), funcs...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load index '%s'", path)
+	}
+
+	return index, nil
+}
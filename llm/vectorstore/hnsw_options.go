@@ -0,0 +1,67 @@
+package vectorstore
+
+const (
+	DefaultHNSWMaxConnections = 16
+	DefaultHNSWEfConstruction = 200
+	DefaultHNSWEfSearch       = 64
+)
+
+type HNSWOptions struct {
+	Metric Metric
+	// MaxConnections is the number of neighbors kept per node on upper layers
+	// (the "M" parameter of the HNSW paper). The ground layer keeps twice as
+	// many.
+	MaxConnections int
+	// EfConstruction is the size of the candidate list used when inserting.
+	EfConstruction int
+	// EfSearch is the minimum size of the candidate list used when querying.
+	// It is raised to the requested top-k when smaller.
+	EfSearch int
+	// Seed makes the level assignment deterministic when set.
+	Seed *uint64
+}
+
+type HNSWOptionFunc func(opts *HNSWOptions)
+
+func NewHNSWOptions(funcs ...HNSWOptionFunc) *HNSWOptions {
+	opts := &HNSWOptions{
+		Metric:         MetricCosine,
+		MaxConnections: DefaultHNSWMaxConnections,
+		EfConstruction: DefaultHNSWEfConstruction,
+		EfSearch:       DefaultHNSWEfSearch,
+	}
+	for _, fn := range funcs {
+		fn(opts)
+	}
+	return opts
+}
+
+func WithMetric(metric Metric) HNSWOptionFunc {
+	return func(opts *HNSWOptions) {
+		opts.Metric = metric
+	}
+}
+
+func WithMaxConnections(m int) HNSWOptionFunc {
+	return func(opts *HNSWOptions) {
+		opts.MaxConnections = m
+	}
+}
+
+func WithEfConstruction(ef int) HNSWOptionFunc {
+	return func(opts *HNSWOptions) {
+		opts.EfConstruction = ef
+	}
+}
+
+func WithEfSearch(ef int) HNSWOptionFunc {
+	return func(opts *HNSWOptions) {
+		opts.EfSearch = ef
+	}
+}
+
+func WithSeed(seed uint64) HNSWOptionFunc {
+	return func(opts *HNSWOptions) {
+		opts.Seed = &seed
+	}
+}
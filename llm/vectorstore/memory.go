@@ -0,0 +1,149 @@
+package vectorstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// MemoryIndex is an exact, brute-force index kept in memory. Every query
+// scores all stored documents, which makes it the reference implementation
+// for small collections and tests.
+type MemoryIndex struct {
+	metric     Metric
+	mu         sync.RWMutex
+	dimensions int
+	docs       map[string]Document
+}
+
+// Upsert implements Index.
+func (i *MemoryIndex) Upsert(ctx context.Context, docs ...Document) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	dimensions := i.dimensions
+	for idx := range docs {
+		if dimensions == 0 {
+			dimensions = len(docs[idx].Vector)
+		}
+		if err := validateDocument(&docs[idx], dimensions); err != nil {
+			return err
+		}
+	}
+
+	i.dimensions = dimensions
+	for _, doc := range docs {
+		i.docs[doc.ID] = cloneDocument(doc)
+	}
+
+	return nil
+}
+
+// Delete implements Index.
+func (i *MemoryIndex) Delete(ctx context.Context, ids ...string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, id := range ids {
+		delete(i.docs, id)
+	}
+
+	return nil
+}
+
+// List implements Lister.
+func (i *MemoryIndex) List(ctx context.Context, filter Filter) ([]Document, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	docs := make([]Document, 0)
+	for _, doc := range i.docs {
+		if filter != nil && !filter(doc.Metadata) {
+			continue
+		}
+		docs = append(docs, cloneDocument(doc))
+	}
+
+	return docs, nil
+}
+
+// Query implements Index.
+func (i *MemoryIndex) Query(ctx context.Context, vector []float64, funcs ...QueryOptionFunc) ([]Result, error) {
+	opts := NewQueryOptions(funcs...)
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if err := checkQueryVector(vector, i.dimensions); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(i.docs))
+	for _, doc := range i.docs {
+		score := i.metric.Score(vector, doc.Vector)
+		if !opts.accept(&doc, score) {
+			continue
+		}
+		results = append(results, Result{Document: doc, Score: score})
+	}
+
+	return topResults(results, opts.TopK), nil
+}
+
+// Len returns the number of documents in the index.
+func (i *MemoryIndex) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.docs)
+}
+
+// NewMemoryIndex returns an empty exact index using the given metric.
+func NewMemoryIndex(metric Metric) (*MemoryIndex, error) {
+	if err := metric.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &MemoryIndex{
+		metric: metric,
+		docs:   make(map[string]Document),
+	}, nil
+}
+
+var (
+	_ Index  = &MemoryIndex{}
+	_ Lister = &MemoryIndex{}
+)
+
+func checkQueryVector(vector []float64, dimensions int) error {
+	if len(vector) == 0 {
+		return errors.WithStack(ErrEmptyVector)
+	}
+	if dimensions != 0 && len(vector) != dimensions {
+		return errors.Wrapf(ErrDimensionMismatch, "query has %d dimensions, expected %d", len(vector), dimensions)
+	}
+	return nil
+}
+
+// topResults sorts the results by descending score, breaking ties on the
+// document ID for stable output, and keeps at most k of them. The kept
+// documents are copied, so that callers cannot alter the index.
+func topResults(results []Result, k int) []Result {
+	sort.Slice(results, func(a, b int) bool {
+		if results[a].Score != results[b].Score {
+			return results[a].Score > results[b].Score
+		}
+		return results[a].Document.ID < results[b].Document.ID
+	})
+
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+
+	for idx := range results {
+		results[idx].Document = cloneDocument(results[idx].Document)
+	}
+
+	return results
+}
@@ -0,0 +1,77 @@
+package vectorstore
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// Metric identifies the similarity function used to compare vectors.
+type Metric string
+
+const (
+	// MetricCosine compares vectors by the cosine of their angle.
+	MetricCosine Metric = "cosine"
+	// MetricDot compares vectors by their inner product.
+	MetricDot Metric = "dot"
+	// MetricL2 compares vectors by their euclidean distance.
+	MetricL2 Metric = "l2"
+)
+
+// Score returns the similarity of a and b for the metric. Scores are always
+// ordered so that a higher value means a closer match: for MetricL2 the score
+// is the negated euclidean distance.
+func (m Metric) Score(a, b []float64) float64 {
+	switch m {
+	case MetricDot:
+		return Dot(a, b)
+	case MetricL2:
+		return -L2(a, b)
+	default:
+		return Cosine(a, b)
+	}
+}
+
+// Validate returns an error if the metric is not supported.
+func (m Metric) Validate() error {
+	switch m {
+	case MetricCosine, MetricDot, MetricL2:
+		return nil
+	default:
+		return errors.Wrapf(ErrUnsupportedMetric, "metric %q", m)
+	}
+}
+
+// Cosine returns the cosine similarity of a and b, or 0 if either vector has
+// a zero norm.
+func Cosine(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Dot returns the inner product of a and b.
+func Dot(a, b []float64) float64 {
+	var dot float64
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return dot
+}
+
+// L2 returns the euclidean distance between a and b.
+func L2(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
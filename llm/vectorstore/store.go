@@ -0,0 +1,124 @@
+package vectorstore
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrEmptyID            = errors.New("document id is empty")
+	ErrEmptyVector        = errors.New("document vector is empty")
+	ErrDimensionMismatch  = errors.New("vector dimension mismatch")
+	ErrUnsupportedMetric  = errors.New("unsupported metric")
+	ErrInvalidIndexFormat = errors.New("invalid index format")
+)
+
+// Document is an entry of a vector index.
+type Document struct {
+	ID       string
+	Vector   []float64
+	Content  string
+	Metadata map[string]any
+}
+
+// Result is a document matched by a query, along with its score for the
+// index metric. Higher scores are better.
+type Result struct {
+	Document Document
+	Score    float64
+}
+
+// Index stores documents and retrieves the nearest ones to a query vector.
+type Index interface {
+	// Upsert inserts the documents, replacing existing ones with the same ID.
+	Upsert(ctx context.Context, docs ...Document) error
+	// Delete removes the documents with the given IDs. Unknown IDs are ignored.
+	Delete(ctx context.Context, ids ...string) error
+	// Query returns the documents closest to the given vector, best match first.
+	Query(ctx context.Context, vector []float64, funcs ...QueryOptionFunc) ([]Result, error)
+}
+
+// Lister is implemented by the indexes able to list their documents without
+// a query vector.
+type Lister interface {
+	// List returns the documents matched by the filter, all of them when the
+	// filter is nil.
+	List(ctx context.Context, filter Filter) ([]Document, error)
+}
+
+const DefaultTopK = 10
+
+type QueryOptions struct {
+	TopK     int
+	Filter   Filter
+	MinScore *float64
+}
+
+type QueryOptionFunc func(opts *QueryOptions)
+
+func NewQueryOptions(funcs ...QueryOptionFunc) *QueryOptions {
+	opts := &QueryOptions{
+		TopK: DefaultTopK,
+	}
+	for _, fn := range funcs {
+		fn(opts)
+	}
+	return opts
+}
+
+// WithTopK sets the maximum number of results returned by a query.
+func WithTopK(k int) QueryOptionFunc {
+	return func(opts *QueryOptions) {
+		opts.TopK = k
+	}
+}
+
+// WithFilter restricts the query to documents whose metadata match the filter.
+func WithFilter(filter Filter) QueryOptionFunc {
+	return func(opts *QueryOptions) {
+		opts.Filter = filter
+	}
+}
+
+// WithMinScore discards results scoring below the given value.
+func WithMinScore(score float64) QueryOptionFunc {
+	return func(opts *QueryOptions) {
+		opts.MinScore = &score
+	}
+}
+
+func (o *QueryOptions) accept(doc *Document, score float64) bool {
+	if o.MinScore != nil && score < *o.MinScore {
+		return false
+	}
+	if o.Filter != nil && !o.Filter(doc.Metadata) {
+		return false
+	}
+	return true
+}
+
+func validateDocument(doc *Document, dimensions int) error {
+	if doc.ID == "" {
+		return errors.WithStack(ErrEmptyID)
+	}
+	if len(doc.Vector) == 0 {
+		return errors.Wrapf(ErrEmptyVector, "document %q", doc.ID)
+	}
+	if dimensions != 0 && len(doc.Vector) != dimensions {
+		return errors.Wrapf(ErrDimensionMismatch, "document %q has %d dimensions, expected %d", doc.ID, len(doc.Vector), dimensions)
+	}
+	return nil
+}
+
+func cloneDocument(doc Document) Document {
+	clone := doc
+	clone.Vector = append([]float64(nil), doc.Vector...)
+	if doc.Metadata != nil {
+		clone.Metadata = make(map[string]any, len(doc.Metadata))
+		for k, v := range doc.Metadata {
+			clone.Metadata[k] = v
+		}
+	}
+	return clone
+}
@@ -0,0 +1,504 @@
+package vectorstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+func TestMetrics(t *testing.T) {
+	a := []float64{1, 0}
+	b := []float64{0, 1}
+	c := []float64{2, 0}
+
+	if got := MetricCosine.Score(a, c); math.Abs(got-1) > 1e-9 {
+		t.Errorf("cosine(a, c): expected 1, got %f", got)
+	}
+	if got := MetricCosine.Score(a, b); math.Abs(got) > 1e-9 {
+		t.Errorf("cosine(a, b): expected 0, got %f", got)
+	}
+	if got := MetricDot.Score(a, c); got != 2 {
+		t.Errorf("dot(a, c): expected 2, got %f", got)
+	}
+	if got := MetricL2.Score(a, c); got != -1 {
+		t.Errorf("l2(a, c): expected -1, got %f", got)
+	}
+	if got := Cosine([]float64{0, 0}, a); got != 0 {
+		t.Errorf("cosine with zero vector: expected 0, got %f", got)
+	}
+	if err := Metric("manhattan").Validate(); !errors.Is(err, ErrUnsupportedMetric) {
+		t.Errorf("expected ErrUnsupportedMetric, got %v", err)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	metadata := map[string]any{"lang": "fr", "page": float64(3)}
+
+	testCases := []struct {
+		Name     string
+		Filter   Filter
+		Expected bool
+	}{
+		{Name: "eq", Filter: Eq("lang", "fr"), Expected: true},
+		{Name: "eq numeric across types", Filter: Eq("page", 3), Expected: true},
+		{Name: "eq missing key", Filter: Eq("author", "x"), Expected: false},
+		{Name: "in", Filter: In("lang", "en", "fr"), Expected: true},
+		{Name: "exists", Filter: Exists("page"), Expected: true},
+		{Name: "and", Filter: And(Eq("lang", "fr"), Eq("page", 4)), Expected: false},
+		{Name: "or", Filter: Or(Eq("lang", "en"), Eq("page", 3)), Expected: true},
+		{Name: "not", Filter: Not(Eq("lang", "fr")), Expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if got := tc.Filter(metadata); got != tc.Expected {
+				t.Errorf("expected %v, got %v", tc.Expected, got)
+			}
+		})
+	}
+}
+
+func TestIndexes(t *testing.T) {
+	factories := map[string]func(t *testing.T) Index{
+		"memory": func(t *testing.T) Index {
+			index, err := NewMemoryIndex(MetricCosine)
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+			return index
+		},
+		"hnsw": func(t *testing.T) Index {
+			index, err := NewHNSWIndex(WithSeed(42))
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+			return index
+		},
+	}
+
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			index := factory(t)
+
+			err := index.Upsert(ctx,
+				Document{ID: "a", Vector: []float64{1, 0, 0}, Metadata: map[string]any{"kind": "x"}},
+				Document{ID: "b", Vector: []float64{0.9, 0.1, 0}, Metadata: map[string]any{"kind": "y"}},
+				Document{ID: "c", Vector: []float64{0, 1, 0}, Metadata: map[string]any{"kind": "x"}},
+			)
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			results, err := index.Query(ctx, []float64{1, 0, 0}, WithTopK(2))
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+			assertIDs(t, results, "a", "b")
+
+			// The results are copies of the indexed documents
+			results[0].Document.Vector[0] = 0
+			results[0].Document.Metadata["kind"] = "z"
+
+			results, err = index.Query(ctx, []float64{1, 0, 0}, WithTopK(1))
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+			if doc := results[0].Document; doc.ID != "a" || doc.Vector[0] != 1 || doc.Metadata["kind"] != "x" {
+				t.Errorf("the index was altered through a result: %+v", doc)
+			}
+
+			results, err = index.Query(ctx, []float64{1, 0, 0}, WithFilter(Eq("kind", "x")))
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+			assertIDs(t, results, "a", "c")
+
+			results, err = index.Query(ctx, []float64{1, 0, 0}, WithMinScore(0.5))
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+			assertIDs(t, results, "a", "b")
+
+			// Replacing a document moves it in the results
+			if err := index.Upsert(ctx, Document{ID: "c", Vector: []float64{1, 0, 0.01}}); err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if err := index.Delete(ctx, "a", "unknown"); err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			results, err = index.Query(ctx, []float64{1, 0, 0})
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+			assertIDs(t, results, "c", "b")
+
+			if err := index.Upsert(ctx, Document{ID: "d", Vector: []float64{1, 0}}); !errors.Is(err, ErrDimensionMismatch) {
+				t.Errorf("expected ErrDimensionMismatch, got %v", err)
+			}
+
+			if err := index.Upsert(ctx, Document{Vector: []float64{1, 0, 0}}); !errors.Is(err, ErrEmptyID) {
+				t.Errorf("expected ErrEmptyID, got %v", err)
+			}
+		})
+	}
+}
+
+func TestHNSWRecall(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	const (
+		total      = 1000
+		dimensions = 16
+		queries    = 50
+		k          = 10
+	)
+
+	exact, err := NewMemoryIndex(MetricL2)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	approx, err := NewHNSWIndex(WithMetric(MetricL2), WithSeed(1))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	docs := make([]Document, total)
+	for i := range docs {
+		docs[i] = Document{ID: fmt.Sprintf("doc-%d", i), Vector: randomVector(rng, dimensions)}
+	}
+
+	for _, index := range []Index{exact, approx} {
+		if err := index.Upsert(ctx, docs...); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+
+	// Delete a slice of the documents to exercise graph repair
+	deleted := make([]string, 0, total/10)
+	for i := 0; i < total; i += 10 {
+		deleted = append(deleted, docs[i].ID)
+	}
+	for _, index := range []Index{exact, approx} {
+		if err := index.Delete(ctx, deleted...); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+
+	var found int
+	for range queries {
+		query := randomVector(rng, dimensions)
+
+		expected, err := exact.Query(ctx, query, WithTopK(k))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		actual, err := approx.Query(ctx, query, WithTopK(k))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		ids := make(map[string]struct{}, len(actual))
+		for _, r := range actual {
+			ids[r.Document.ID] = struct{}{}
+		}
+		for _, r := range expected {
+			if _, exists := ids[r.Document.ID]; exists {
+				found++
+			}
+		}
+	}
+
+	recall := float64(found) / float64(queries*k)
+	if recall < 0.9 {
+		t.Errorf("expected recall >= 0.9, got %.2f", recall)
+	}
+}
+
+func TestHNSWChurn(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	const (
+		total      = 200
+		dimensions = 8
+	)
+
+	index, err := NewHNSWIndex(WithMetric(MetricL2), WithSeed(3))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	docs := make([]Document, total)
+	for round := range 5 {
+		for i := range docs {
+			docs[i] = Document{ID: fmt.Sprintf("doc-%d", i), Vector: randomVector(rng, dimensions)}
+		}
+
+		if err := index.Upsert(ctx, docs...); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		// The slots of the replaced nodes are reused
+		if len(index.nodes) != total {
+			t.Fatalf("round %d: expected %d slots, got %d", round, total, len(index.nodes))
+		}
+	}
+
+	// The links and their referrers match, without dangling links
+	referrers := make([]map[int]int, len(index.nodes))
+	for idx, node := range index.nodes {
+		for _, ids := range node.neighbors {
+			for _, id := range ids {
+				if index.nodes[id] == nil {
+					t.Fatalf("node %d links to removed node %d", idx, id)
+				}
+				if referrers[id] == nil {
+					referrers[id] = map[int]int{}
+				}
+				referrers[id][idx]++
+			}
+		}
+	}
+
+	for idx, node := range index.nodes {
+		if len(node.referrers) != len(referrers[idx]) {
+			t.Fatalf("node %d has referrers %v, expected %v", idx, node.referrers, referrers[idx])
+		}
+		for id, count := range referrers[idx] {
+			if node.referrers[id] != count {
+				t.Fatalf("node %d has referrers %v, expected %v", idx, node.referrers, referrers[idx])
+			}
+		}
+	}
+
+	for _, doc := range docs[:20] {
+		results, err := index.Query(ctx, doc.Vector, WithTopK(1))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+		if len(results) != 1 || results[0].Document.ID != doc.ID {
+			t.Errorf("expected %s to be its own nearest neighbor, got %+v", doc.ID, results)
+		}
+	}
+}
+
+func TestHNSWPersistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "index.hnsw")
+
+	index, err := OpenHNSWIndex(path, WithMetric(MetricDot), WithSeed(7))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if index.Len() != 0 {
+		t.Fatalf("expected empty index, got %d documents", index.Len())
+	}
+
+	err = index.Upsert(ctx,
+		Document{ID: "a", Vector: []float64{1, 0}, Content: "first", Metadata: map[string]any{"page": 1}},
+		Document{ID: "b", Vector: []float64{0, 1}, Content: "second", Metadata: map[string]any{"page": 2}},
+		Document{ID: "c", Vector: []float64{0.5, 0.5}, Content: "third"},
+	)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := index.Delete(ctx, "c"); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := index.SaveFile(path); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	loaded, err := OpenHNSWIndex(path)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if loaded.Len() != 2 {
+		t.Fatalf("expected 2 documents, got %d", loaded.Len())
+	}
+
+	results, err := loaded.Query(ctx, []float64{0, 1}, WithFilter(Eq("page", 2)))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+	assertIDs(t, results, "b")
+
+	if results[0].Document.Content != "second" {
+		t.Errorf("expected content 'second', got %q", results[0].Document.Content)
+	}
+	if results[0].Score != 1 {
+		t.Errorf("expected dot product score 1, got %f", results[0].Score)
+	}
+
+	// The restored graph must remain writable
+	if err := loaded.Upsert(ctx, Document{ID: "d", Vector: []float64{1, 1}}); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if _, err := LoadHNSWIndex(bytes.NewBufferString("garbage")); !errors.Is(err, ErrInvalidIndexFormat) {
+		t.Errorf("expected ErrInvalidIndexFormat, got %v", err)
+	}
+}
+
+// A corrupt or hand-edited file must be rejected on load rather than make
+// the search panic.
+func TestLoadHNSWIndex_Corrupt(t *testing.T) {
+	valid := func() hnswSnapshot {
+		return hnswSnapshot{
+			Version:        hnswFormatVersion,
+			Metric:         MetricCosine,
+			MaxConnections: DefaultHNSWMaxConnections,
+			EfConstruction: DefaultHNSWEfConstruction,
+			Dimensions:     2,
+			Entry:          0,
+			MaxLevel:       1,
+			Nodes: []hnswNodeSnapshot{
+				{ID: "a", Vector: []float64{1, 0}, Level: 1, Neighbors: [][]int{{1}, {}}},
+				{ID: "b", Vector: []float64{0, 1}, Level: 0, Neighbors: [][]int{{0}}},
+			},
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		corrupt func(s *hnswSnapshot)
+	}{
+		{"valid", nil},
+		{"neighbor below the level", func(s *hnswSnapshot) { s.Nodes[0].Neighbors[1] = []int{1} }},
+		{"entry point below the maximum level", func(s *hnswSnapshot) { s.MaxLevel = 2 }},
+		{"duplicate documents", func(s *hnswSnapshot) { s.Nodes[1].ID = "a" }},
+		{"dimension mismatch", func(s *hnswSnapshot) { s.Nodes[1].Vector = []float64{0, 1, 0} }},
+		{"unknown neighbor", func(s *hnswSnapshot) { s.Nodes[1].Neighbors[0] = []int{2} }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			snapshot := valid()
+			if tc.corrupt != nil {
+				tc.corrupt(&snapshot)
+			}
+
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(&snapshot); err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			index, err := LoadHNSWIndex(&buf)
+			if tc.corrupt == nil {
+				if err != nil {
+					t.Fatalf("%+v", errors.WithStack(err))
+				}
+				if _, err := index.Query(context.Background(), []float64{0, 1}); err != nil {
+					t.Fatalf("%+v", errors.WithStack(err))
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidIndexFormat) {
+				t.Errorf("expected ErrInvalidIndexFormat, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEmbedder(t *testing.T) {
+	ctx := context.Background()
+
+	index, err := NewMemoryIndex(MetricCosine)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	client := &fakeEmbeddingsClient{
+		vectors: map[string][]float64{
+			"cat":    {1, 0},
+			"kitten": {0.9, 0.1},
+			"car":    {0, 1},
+		},
+	}
+
+	embedder := NewEmbedder(index, client, WithBatchSize(2))
+
+	err = embedder.Upsert(ctx,
+		Document{ID: "1", Content: "cat"},
+		Document{ID: "2", Content: "car"},
+		Document{ID: "3", Content: "ignored", Vector: []float64{-1, 0}},
+	)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if client.calls != 1 {
+		t.Errorf("expected 1 embeddings call, got %d", client.calls)
+	}
+
+	results, err := embedder.QueryText(ctx, "kitten", WithTopK(1))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+	assertIDs(t, results, "1")
+}
+
+func assertIDs(t *testing.T, results []Result, ids ...string) {
+	t.Helper()
+
+	if len(results) != len(ids) {
+		t.Fatalf("expected %d results, got %d: %v", len(ids), len(results), results)
+	}
+
+	for i, id := range ids {
+		if results[i].Document.ID != id {
+			t.Errorf("result #%d: expected document %q, got %q", i, id, results[i].Document.ID)
+		}
+	}
+}
+
+func randomVector(rng *rand.Rand, dimensions int) []float64 {
+	vector := make([]float64, dimensions)
+	for i := range vector {
+		vector[i] = rng.Float64()*2 - 1
+	}
+	return vector
+}
+
+type fakeEmbeddingsClient struct {
+	vectors map[string][]float64
+	calls   int
+}
+
+func (c *fakeEmbeddingsClient) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	c.calls++
+	embeddings := make([][]float64, len(inputs))
+	for i, input := range inputs {
+		embeddings[i] = c.vectors[input]
+	}
+	return &fakeEmbeddingsResponse{embeddings: embeddings}, nil
+}
+
+type fakeEmbeddingsResponse struct {
+	embeddings [][]float64
+}
+
+func (r *fakeEmbeddingsResponse) Embeddings() [][]float64 {
+	return r.embeddings
+}
+
+func (r *fakeEmbeddingsResponse) Usage() llm.EmbeddingsUsage {
+	return llm.NewEmbeddingsUsage(0, 0)
+}
@@ -3,6 +3,7 @@ package proxy
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,16 +16,26 @@ import (
 
 // openAIChatRequest mirrors the OpenAI /v1/chat/completions request body.
 type openAIChatRequest struct {
-	Model           string             `json:"model"`
-	Messages        []openAIMessage    `json:"messages"`
-	Tools           []openAITool       `json:"tools,omitempty"`
-	ToolChoice      any                `json:"tool_choice,omitempty"`
-	Temperature     *float64           `json:"temperature,omitempty"`
-	MaxTokens       *int               `json:"max_tokens,omitempty"`
-	Stream          bool               `json:"stream"`
-	Seed            *int               `json:"seed,omitempty"`
-	ResponseFmt     *openAIResponseFmt `json:"response_format,omitempty"`
-	ReasoningEffort string             `json:"reasoning_effort,omitempty"` // e.g. "low","medium","high"
+	Model            string             `json:"model"`
+	Messages         []openAIMessage    `json:"messages"`
+	Tools            []openAITool       `json:"tools,omitempty"`
+	ToolChoice       any                `json:"tool_choice,omitempty"`
+	Temperature      *float64           `json:"temperature,omitempty"`
+	MaxTokens        *int               `json:"max_tokens,omitempty"`
+	Stream           bool               `json:"stream"`
+	Seed             *int               `json:"seed,omitempty"`
+	ResponseFmt      *openAIResponseFmt `json:"response_format,omitempty"`
+	ReasoningEffort  string             `json:"reasoning_effort,omitempty"` // e.g. "low","medium","high"
+	N                *int               `json:"n,omitempty"`
+	Logprobs         bool               `json:"logprobs,omitempty"`
+	TopLogprobs      *int               `json:"top_logprobs,omitempty"`
+	TopP             *float64           `json:"top_p,omitempty"`
+	TopK             *int               `json:"top_k,omitempty"` // non standard, honoured by local providers
+	MinP             *float64           `json:"min_p,omitempty"` // non standard, honoured by local providers
+	Stop             any                `json:"stop,omitempty"`  // string or array of strings
+	FrequencyPenalty *float64           `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64           `json:"presence_penalty,omitempty"`
+	LogitBias        map[string]float64 `json:"logit_bias,omitempty"`
 }
 
 type openAIResponseFmt struct {
@@ -32,12 +43,13 @@ type openAIResponseFmt struct {
 }
 
 type openAIMessage struct {
-	Role             string           `json:"role"`
-	Content          any              `json:"content"` // string or array of content parts
-	ToolCalls        []openAIToolCall `json:"tool_calls,omitempty"`
-	ToolCallID       string           `json:"tool_call_id,omitempty"`
-	Name             string           `json:"name,omitempty"`
-	ReasoningContent string           `json:"reasoning_content,omitempty"` // reasoning tokens in response / multi-turn
+	Role             string             `json:"role"`
+	Content          any                `json:"content"` // string or array of content parts
+	ToolCalls        []openAIToolCall   `json:"tool_calls,omitempty"`
+	ToolCallID       string             `json:"tool_call_id,omitempty"`
+	Name             string             `json:"name,omitempty"`
+	ReasoningContent string             `json:"reasoning_content,omitempty"` // reasoning tokens in response / multi-turn
+	Annotations      []openAIAnnotation `json:"annotations,omitempty"`       // sources cited by the response
 }
 
 type openAITool struct {
@@ -74,9 +86,39 @@ type openAIChatResponse struct {
 }
 
 type openAIChoice struct {
-	Index        int           `json:"index"`
-	Message      openAIMessage `json:"message"`
-	FinishReason string        `json:"finish_reason"`
+	Index        int             `json:"index"`
+	Message      openAIMessage   `json:"message"`
+	FinishReason string          `json:"finish_reason"`
+	Logprobs     *openAILogprobs `json:"logprobs,omitempty"`
+}
+
+// openAIAnnotation is an url_citation annotation of a message
+type openAIAnnotation struct {
+	Type        string            `json:"type"` // "url_citation"
+	URLCitation openAIURLCitation `json:"url_citation"`
+}
+
+type openAIURLCitation struct {
+	URL        string `json:"url"`
+	Title      string `json:"title,omitempty"`
+	Content    string `json:"content,omitempty"`
+	StartIndex int    `json:"start_index"`
+	EndIndex   int    `json:"end_index"`
+}
+
+type openAILogprobs struct {
+	Content []openAITokenLogprob `json:"content"`
+}
+
+type openAITokenLogprob struct {
+	Token       string             `json:"token"`
+	Logprob     float64            `json:"logprob"`
+	TopLogprobs []openAITopLogprob `json:"top_logprobs"`
+}
+
+type openAITopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
 }
 
 type openAIUsage struct {
@@ -106,6 +148,7 @@ type openAIStreamDelta struct {
 	Content          string                 `json:"content,omitempty"`
 	ToolCalls        []openAIStreamToolCall `json:"tool_calls,omitempty"`
 	ReasoningContent string                 `json:"reasoning_content,omitempty"`
+	Annotations      []openAIAnnotation     `json:"annotations,omitempty"`
 }
 
 // openAIStreamToolCall is the tool call format used inside streaming deltas.
@@ -184,6 +227,57 @@ func ParseChatCompletionRequest(body json.RawMessage) (model string, stream bool
 	if req.Seed != nil {
 		opts = append(opts, llm.WithSeed(*req.Seed))
 	}
+	if req.N != nil {
+		opts = append(opts, llm.WithCandidates(*req.N))
+	}
+	if req.Logprobs {
+		topK := 0
+		if req.TopLogprobs != nil {
+			topK = *req.TopLogprobs
+		}
+		opts = append(opts, llm.WithLogprobs(topK))
+	}
+
+	if req.TopP != nil {
+		opts = append(opts, llm.WithTopP(*req.TopP))
+	}
+	if req.TopK != nil {
+		opts = append(opts, llm.WithTopK(*req.TopK))
+	}
+	if req.MinP != nil {
+		opts = append(opts, llm.WithMinP(*req.MinP))
+	}
+	if req.FrequencyPenalty != nil {
+		opts = append(opts, llm.WithFrequencyPenalty(*req.FrequencyPenalty))
+	}
+	if req.PresencePenalty != nil {
+		opts = append(opts, llm.WithPresencePenalty(*req.PresencePenalty))
+	}
+
+	switch v := req.Stop.(type) {
+	case string:
+		opts = append(opts, llm.WithStop(v))
+	case []any:
+		stop := make([]string, 0, len(v))
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				stop = append(stop, str)
+			}
+		}
+		opts = append(opts, llm.WithStop(stop...))
+	}
+
+	if len(req.LogitBias) > 0 {
+		bias := make(map[int]float64, len(req.LogitBias))
+		for token, b := range req.LogitBias {
+			id, err := strconv.Atoi(token)
+			if err != nil {
+				return "", false, nil, errors.Errorf("invalid token id '%s' in logit_bias", token)
+			}
+			bias[id] = b
+		}
+		opts = append(opts, llm.WithLogitBias(bias))
+	}
 
 	if req.ReasoningEffort != "" {
 		effort := llm.ReasoningEffort(req.ReasoningEffort)
@@ -443,24 +537,68 @@ func normalizeMIMEType(mimeType string) string {
 }
 
 // FormatChatCompletionResponse converts a llm.ChatCompletionResponse to OpenAI JSON.
+// Every candidate of a llm.CandidatesResponse becomes a choice.
 func FormatChatCompletionResponse(res llm.ChatCompletionResponse, model string) any {
-	msg := res.Message()
+	var choices []openAIChoice
+
+	if cr, ok := res.(llm.CandidatesResponse); ok && len(cr.Candidates()) > 0 {
+		choices = make([]openAIChoice, 0, len(cr.Candidates()))
+		for _, c := range cr.Candidates() {
+			var reasoning string
+			if rm, ok := c.Message().(llm.ReasoningMessage); ok {
+				reasoning = rm.Reasoning()
+			}
 
-	oMsg := openAIMessage{
-		Role:    string(msg.Role()),
-		Content: msg.Content(),
+			choice := formatChoice(c.Index(), c.Message(), reasoning, c.ToolCalls())
+			if c.FinishReason() != "" {
+				choice.FinishReason = string(c.FinishReason())
+			}
+
+			if c.Logprobs() != nil {
+				choice.Logprobs = formatLogprobs(c.Logprobs())
+			}
+
+			choices = append(choices, choice)
+		}
+	} else {
+		var reasoning string
+		if rr, ok := res.(llm.ReasoningChatCompletionResponse); ok {
+			reasoning = rr.Reasoning()
+		}
+
+		choices = []openAIChoice{formatChoice(0, res.Message(), reasoning, res.ToolCalls())}
 	}
 
-	if rr, ok := res.(llm.ReasoningChatCompletionResponse); ok {
-		oMsg.ReasoningContent = rr.Reasoning()
+	usage := res.Usage()
+
+	return openAIChatResponse{
+		ID:      "chatcmpl-" + uuid.New().String(),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: choices,
+		Usage: openAIUsage{
+			PromptTokens:     usage.PromptTokens(),
+			CompletionTokens: usage.CompletionTokens(),
+			TotalTokens:      usage.TotalTokens(),
+		},
+	}
+}
+
+func formatChoice(index int, msg llm.Message, reasoning string, toolCalls []llm.ToolCall) openAIChoice {
+	oMsg := openAIMessage{
+		Role:             string(msg.Role()),
+		Content:          msg.Content(),
+		ReasoningContent: reasoning,
+		Annotations:      formatAnnotations(llm.CitationsOf(msg)),
 	}
 
 	finishReason := "stop"
 
-	if len(res.ToolCalls()) > 0 {
+	if len(toolCalls) > 0 {
 		finishReason = "tool_calls"
-		tcs := make([]openAIToolCall, 0, len(res.ToolCalls()))
-		for _, tc := range res.ToolCalls() {
+		tcs := make([]openAIToolCall, 0, len(toolCalls))
+		for _, tc := range toolCalls {
 			args := ""
 			switch p := tc.Parameters().(type) {
 			case string:
@@ -482,26 +620,53 @@ func FormatChatCompletionResponse(res llm.ChatCompletionResponse, model string)
 		oMsg.Content = nil
 	}
 
-	usage := res.Usage()
+	return openAIChoice{
+		Index:        index,
+		Message:      oMsg,
+		FinishReason: finishReason,
+	}
+}
 
-	return openAIChatResponse{
-		ID:      "chatcmpl-" + uuid.New().String(),
-		Object:  "chat.completion",
-		Created: time.Now().Unix(),
-		Model:   model,
-		Choices: []openAIChoice{
-			{
-				Index:        0,
-				Message:      oMsg,
-				FinishReason: finishReason,
+// formatAnnotations converts the citations to url_citation annotations
+func formatAnnotations(citations []llm.Citation) []openAIAnnotation {
+	if len(citations) == 0 {
+		return nil
+	}
+
+	annotations := make([]openAIAnnotation, 0, len(citations))
+	for _, c := range citations {
+		annotations = append(annotations, openAIAnnotation{
+			Type: "url_citation",
+			URLCitation: openAIURLCitation{
+				URL:        c.URL,
+				Title:      c.Title,
+				Content:    c.Content,
+				StartIndex: c.StartIndex,
+				EndIndex:   c.EndIndex,
 			},
-		},
-		Usage: openAIUsage{
-			PromptTokens:     usage.PromptTokens(),
-			CompletionTokens: usage.CompletionTokens(),
-			TotalTokens:      usage.TotalTokens(),
-		},
+		})
 	}
+
+	return annotations
+}
+
+func formatLogprobs(logprobs []llm.TokenLogprob) *openAILogprobs {
+	content := make([]openAITokenLogprob, 0, len(logprobs))
+
+	for _, lp := range logprobs {
+		top := make([]openAITopLogprob, 0, len(lp.TopLogprobs))
+		for _, t := range lp.TopLogprobs {
+			top = append(top, openAITopLogprob{Token: t.Token, Logprob: t.Logprob})
+		}
+
+		content = append(content, openAITokenLogprob{
+			Token:       lp.Token,
+			Logprob:     lp.Logprob,
+			TopLogprobs: top,
+		})
+	}
+
+	return &openAILogprobs{Content: content}
 }
 
 // FormatStreamChunk converts a llm.StreamChunk to an OpenAI SSE data payload.
@@ -553,6 +718,10 @@ func FormatStreamChunk(chunk llm.StreamChunk, id, model string, sawToolCalls boo
 			delta.ReasoningContent = rd.Reasoning()
 		}
 
+		if ad, ok := d.(llm.AnnotatedStreamDelta); ok {
+			delta.Annotations = formatAnnotations(ad.Citations())
+		}
+
 		if len(d.ToolCalls()) > 0 {
 			tcs := make([]openAIStreamToolCall, 0, len(d.ToolCalls()))
 			for i, tc := range d.ToolCalls() {
@@ -536,10 +536,18 @@ func FormatMessagesResponse(res llm.ChatCompletionResponse, model string) any {
 	}
 
 	if content := res.Message().Content(); content != "" {
-		blocks = append(blocks, map[string]any{
+		block := map[string]any{
 			"type": "text",
 			"text": content,
-		})
+		}
+		if citations := llm.CitationsOf(res.Message()); len(citations) > 0 {
+			cited := make([]map[string]any, 0, len(citations))
+			for _, c := range citations {
+				cited = append(cited, formatAnthropicCitation(c))
+			}
+			block["citations"] = cited
+		}
+		blocks = append(blocks, block)
 	}
 
 	stopReason := "end_turn"
@@ -581,6 +589,17 @@ func FormatMessagesResponse(res llm.ChatCompletionResponse, model string) any {
 	}
 }
 
+// formatAnthropicCitation converts a citation to the web_search_result_location
+// citation of an Anthropic text block.
+func formatAnthropicCitation(c llm.Citation) map[string]any {
+	return map[string]any{
+		"type":       "web_search_result_location",
+		"url":        c.URL,
+		"title":      c.Title,
+		"cited_text": c.Content,
+	}
+}
+
 // parseToolCallInput decodes a llm.ToolCall's Parameters() (typically a JSON
 // string) into a JSON object suitable for the "input" field of a tool_use block.
 func parseToolCallInput(params any) any {
@@ -168,6 +168,21 @@ func (e *anthropicStreamEmitter) process(w io.Writer, chunk llm.StreamChunk) err
 			}
 		}
 
+		if ad, ok := delta.(llm.AnnotatedStreamDelta); ok {
+			for _, c := range ad.Citations() {
+				if err := e.ensureBlock(w, "text"); err != nil {
+					return err
+				}
+				if err := writeAnthropicSSEEvent(w, "content_block_delta", map[string]any{
+					"type":  "content_block_delta",
+					"index": e.blockIndex,
+					"delta": map[string]any{"type": "citations_delta", "citation": formatAnthropicCitation(c)},
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
 		for _, tc := range delta.ToolCalls() {
 			e.sawToolCalls = true
 
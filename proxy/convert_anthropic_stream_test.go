@@ -119,6 +119,36 @@ func TestAnthropicStreamEmitter_TextOnly(t *testing.T) {
 	}
 }
 
+func TestAnthropicStreamEmitter_Citations(t *testing.T) {
+	emitter := newAnthropicStreamEmitter("claude-sonnet-4-5")
+	var buf bytes.Buffer
+
+	delta, _ := llm.WithStreamDeltaCitations(llm.NewStreamDelta(llm.RoleAssistant, "Go 1.25"), []llm.Citation{
+		{URL: "https://go.dev/blog", Title: "The Go Blog"},
+	})
+
+	if err := emitter.EmitFirst(&buf, llm.NewStreamChunk(delta)); err != nil {
+		t.Fatalf("EmitFirst: %v", err)
+	}
+
+	events := parseSSEEvents(t, buf.String())
+
+	// message_start, content_block_start, text_delta, citations_delta
+	if len(events) != 4 {
+		t.Fatalf("events = %d, want 4: %+v", len(events), events)
+	}
+
+	citationDelta := events[3].Data["delta"].(map[string]any)
+	if citationDelta["type"] != "citations_delta" || events[3].Data["index"] != float64(0) {
+		t.Fatalf("delta = %+v", events[3].Data)
+	}
+
+	citation := citationDelta["citation"].(map[string]any)
+	if citation["type"] != "web_search_result_location" || citation["url"] != "https://go.dev/blog" || citation["title"] != "The Go Blog" {
+		t.Errorf("citation = %+v", citation)
+	}
+}
+
 func TestAnthropicStreamEmitter_ToolUse(t *testing.T) {
 	emitter := newAnthropicStreamEmitter("claude-3-5-sonnet-20241022")
 	var buf bytes.Buffer
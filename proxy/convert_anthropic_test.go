@@ -453,6 +453,34 @@ func TestFormatMessagesResponse_Text(t *testing.T) {
 	}
 }
 
+func TestFormatMessagesResponse_Citations(t *testing.T) {
+	msg, _ := llm.WithCitations(llm.NewMessage(llm.RoleAssistant, "Go 1.25 is out."), []llm.Citation{
+		{URL: "https://go.dev/blog", Title: "The Go Blog", Content: "Go 1.25 is released"},
+	})
+	res := llm.NewChatCompletionResponse(msg, llm.NewChatCompletionUsage(10, 5, 15))
+
+	raw, err := json.Marshal(FormatMessagesResponse(res, "claude-sonnet-4-5"))
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	block := m["content"].([]any)[0].(map[string]any)
+	citations, _ := block["citations"].([]any)
+	if len(citations) != 1 {
+		t.Fatalf("citations = %+v", block["citations"])
+	}
+
+	citation := citations[0].(map[string]any)
+	if citation["type"] != "web_search_result_location" || citation["url"] != "https://go.dev/blog" || citation["cited_text"] != "Go 1.25 is released" {
+		t.Errorf("citation = %+v", citation)
+	}
+}
+
 func TestFormatMessagesResponse_ToolCalls(t *testing.T) {
 	msg := llm.NewMessage(llm.RoleAssistant, "")
 	usage := llm.NewChatCompletionUsage(10, 5, 15)
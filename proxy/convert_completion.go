@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// ---- Completion wire types ----------------------------------------------
+
+// openAICompletionRequest mirrors both the OpenAI /v1/completions and the
+// Mistral /v1/fim/completions request bodies.
+type openAICompletionRequest struct {
+	Model       string   `json:"model"`
+	Prompt      any      `json:"prompt"` // string or array of a single string
+	Suffix      string   `json:"suffix,omitempty"`
+	MaxTokens   *int     `json:"max_tokens,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Stop        any      `json:"stop,omitempty"` // string or array of strings
+	Seed        *int     `json:"seed,omitempty"`
+	RandomSeed  *int     `json:"random_seed,omitempty"` // Mistral flavour of seed
+	Stream      bool     `json:"stream"`
+}
+
+// openAICompletionResponse is the OpenAI "text_completion" object, used for
+// both the responses and the stream chunks.
+type openAICompletionResponse struct {
+	ID      string                   `json:"id"`
+	Object  string                   `json:"object"`
+	Created int64                    `json:"created"`
+	Model   string                   `json:"model"`
+	Choices []openAICompletionChoice `json:"choices"`
+	Usage   *openAIUsage             `json:"usage,omitempty"`
+}
+
+type openAICompletionChoice struct {
+	Index        int     `json:"index"`
+	Text         string  `json:"text"`
+	Logprobs     any     `json:"logprobs"`
+	FinishReason *string `json:"finish_reason"`
+}
+
+// ---- Conversion helpers -------------------------------------------------
+
+// ParseCompletionRequest converts an OpenAI or Mistral FIM completion body to
+// llm options.
+func ParseCompletionRequest(body json.RawMessage) (model string, stream bool, prompt string, suffix string, opts []llm.CompletionOptionFunc, err error) {
+	var req openAICompletionRequest
+	if err = json.Unmarshal(body, &req); err != nil {
+		return "", false, "", "", nil, errors.Wrap(err, "could not parse completion request")
+	}
+
+	switch v := req.Prompt.(type) {
+	case string:
+		prompt = v
+	case []any:
+		if len(v) != 1 {
+			return "", false, "", "", nil, errors.New("only a single prompt is supported")
+		}
+		str, ok := v[0].(string)
+		if !ok {
+			return "", false, "", "", nil, errors.New("invalid prompt type in completion request")
+		}
+		prompt = str
+	case nil:
+	default:
+		return "", false, "", "", nil, errors.New("invalid prompt type in completion request")
+	}
+
+	if req.MaxTokens != nil {
+		opts = append(opts, llm.WithCompletionMaxTokens(*req.MaxTokens))
+	}
+	if req.Temperature != nil {
+		opts = append(opts, llm.WithCompletionTemperature(*req.Temperature))
+	}
+	if req.TopP != nil {
+		opts = append(opts, llm.WithCompletionTopP(*req.TopP))
+	}
+
+	switch {
+	case req.Seed != nil:
+		opts = append(opts, llm.WithCompletionSeed(*req.Seed))
+	case req.RandomSeed != nil:
+		opts = append(opts, llm.WithCompletionSeed(*req.RandomSeed))
+	}
+
+	switch v := req.Stop.(type) {
+	case string:
+		opts = append(opts, llm.WithCompletionStop(v))
+	case []any:
+		stop := make([]string, 0, len(v))
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				stop = append(stop, str)
+			}
+		}
+		opts = append(opts, llm.WithCompletionStop(stop...))
+	}
+
+	return req.Model, req.Stream, prompt, req.Suffix, opts, nil
+}
+
+// FormatCompletionResponse converts a llm.CompletionResponse to an OpenAI
+// "text_completion" JSON object.
+func FormatCompletionResponse(res llm.CompletionResponse, model string) any {
+	return openAICompletionResponse{
+		ID:      "cmpl-" + uuid.New().String(),
+		Object:  "text_completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []openAICompletionChoice{
+			{Index: 0, Text: res.Text(), FinishReason: completionFinishReason(res.FinishReason())},
+		},
+		Usage: formatCompletionUsage(res.Usage()),
+	}
+}
+
+// FormatFIMCompletionResponse converts a llm.CompletionResponse to a Mistral
+// FIM JSON object, which has the shape of a chat completion.
+func FormatFIMCompletionResponse(res llm.CompletionResponse, model string) any {
+	usage := formatCompletionUsage(res.Usage())
+	if usage == nil {
+		usage = &openAIUsage{}
+	}
+
+	return openAIChatResponse{
+		ID:      "cmpl-" + uuid.New().String(),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []openAIChoice{
+			{
+				Index:        0,
+				Message:      openAIMessage{Role: string(llm.RoleAssistant), Content: res.Text()},
+				FinishReason: *completionFinishReason(res.FinishReason()),
+			},
+		},
+		Usage: *usage,
+	}
+}
+
+// FormatCompletionStreamChunk converts a llm.StreamChunk to an OpenAI
+// "text_completion" SSE data payload.
+func FormatCompletionStreamChunk(chunk llm.StreamChunk, id, model string) any {
+	c := openAICompletionResponse{
+		ID:      id,
+		Object:  "text_completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Usage:   formatCompletionUsage(chunk.Usage()),
+	}
+
+	if chunk.IsComplete() {
+		c.Choices = []openAICompletionChoice{
+			{Index: 0, FinishReason: completionFinishReason("")},
+		}
+		return c
+	}
+
+	var text string
+	if d := chunk.Delta(); d != nil {
+		text = d.Content()
+	}
+
+	c.Choices = []openAICompletionChoice{{Index: 0, Text: text}}
+
+	return c
+}
+
+func completionFinishReason(reason llm.FinishReason) *string {
+	finishReason := string(reason)
+	if finishReason == "" {
+		finishReason = string(llm.FinishReasonStop)
+	}
+	return &finishReason
+}
+
+func formatCompletionUsage(usage llm.ChatCompletionUsage) *openAIUsage {
+	if usage == nil {
+		return nil
+	}
+
+	return &openAIUsage{
+		PromptTokens:     usage.PromptTokens(),
+		CompletionTokens: usage.CompletionTokens(),
+		TotalTokens:      usage.TotalTokens(),
+	}
+}
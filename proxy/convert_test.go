@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/bornholm/genai/llm"
@@ -218,6 +219,135 @@ func TestFormatChatCompletionResponse(t *testing.T) {
 	}
 }
 
+func TestFormatChatCompletionResponse_Citations(t *testing.T) {
+	citations := []llm.Citation{{URL: "https://go.dev/blog", Title: "The Go Blog", StartIndex: 0, EndIndex: 7}}
+
+	msg, _ := llm.WithCitations(llm.NewMessage(llm.RoleAssistant, "Go 1.25 is out."), citations)
+	res := llm.NewChatCompletionResponse(msg, llm.NewChatCompletionUsage(10, 5, 15))
+
+	raw, err := json.Marshal(FormatChatCompletionResponse(res, "gpt-4o-search-preview"))
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	want := `"annotations":[{"type":"url_citation","url_citation":{"url":"https://go.dev/blog","title":"The Go Blog","start_index":0,"end_index":7}}]`
+	if !strings.Contains(string(raw), want) {
+		t.Errorf("expected %s in %s", want, raw)
+	}
+
+	delta, _ := llm.WithStreamDeltaCitations(llm.NewStreamDelta(llm.RoleAssistant, "Go"), citations)
+
+	raw, err = json.Marshal(FormatStreamChunk(llm.NewStreamChunk(delta), "chatcmpl-1", "gpt-4o-search-preview", false))
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	if !strings.Contains(string(raw), want) {
+		t.Errorf("expected %s in %s", want, raw)
+	}
+}
+
+func TestParseChatCompletionRequest_Candidates(t *testing.T) {
+	body := json.RawMessage(`{
+		"model": "gpt-4",
+		"messages": [{"role": "user", "content": "Hi"}],
+		"n": 3,
+		"logprobs": true,
+		"top_logprobs": 2
+	}`)
+
+	_, _, opts, err := ParseChatCompletionRequest(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	compiled := llm.NewChatCompletionOptions(opts...)
+	if compiled.Candidates != 3 {
+		t.Errorf("candidates = %d, want 3", compiled.Candidates)
+	}
+	if !compiled.Logprobs || compiled.TopLogprobs != 2 {
+		t.Errorf("logprobs = %v, top logprobs = %d, want true, 2", compiled.Logprobs, compiled.TopLogprobs)
+	}
+}
+
+func TestParseChatCompletionRequest_Sampling(t *testing.T) {
+	body := json.RawMessage(`{
+		"model": "gpt-4",
+		"messages": [{"role": "user", "content": "Hi"}],
+		"top_p": 0.9,
+		"top_k": 40,
+		"stop": ["END", "\n\n"],
+		"frequency_penalty": 0.5,
+		"presence_penalty": 0.25,
+		"logit_bias": {"42": -100}
+	}`)
+
+	_, _, opts, err := ParseChatCompletionRequest(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	compiled := llm.NewChatCompletionOptions(opts...)
+	if compiled.TopP == nil || *compiled.TopP != 0.9 || compiled.TopK == nil || *compiled.TopK != 40 {
+		t.Errorf("top p = %v, top k = %v", compiled.TopP, compiled.TopK)
+	}
+	if len(compiled.Stop) != 2 || compiled.Stop[1] != "\n\n" {
+		t.Errorf("stop = %q", compiled.Stop)
+	}
+	if compiled.FrequencyPenalty == nil || *compiled.FrequencyPenalty != 0.5 || compiled.PresencePenalty == nil || *compiled.PresencePenalty != 0.25 {
+		t.Errorf("frequency penalty = %v, presence penalty = %v", compiled.FrequencyPenalty, compiled.PresencePenalty)
+	}
+	if compiled.LogitBias[42] != -100 {
+		t.Errorf("logit bias = %v", compiled.LogitBias)
+	}
+
+	if _, _, _, err := ParseChatCompletionRequest(json.RawMessage(`{"model":"m","messages":[],"logit_bias":{"a":1}}`)); err == nil {
+		t.Error("expected error for an invalid token id")
+	}
+}
+
+func TestFormatChatCompletionResponse_Candidates(t *testing.T) {
+	usage := llm.NewChatCompletionUsage(10, 5, 15)
+	first := llm.NewCandidate(0, llm.NewMessage(llm.RoleAssistant, "Hello!"), llm.FinishReasonStop, []llm.TokenLogprob{
+		{Token: "Hello", Logprob: -0.1, TopLogprobs: []llm.TopLogprob{{Token: "Hello", Logprob: -0.1}, {Token: "Hi", Logprob: -2.5}}},
+		{Token: "!", Logprob: -0.2},
+	})
+	second := llm.NewCandidate(1, llm.NewMessage(llm.RoleAssistant, "Hi"), llm.FinishReasonLength, nil)
+	res := llm.NewCandidatesResponse(llm.NewChatCompletionResponse(first.Message(), usage), first, second)
+
+	raw, err := json.Marshal(FormatChatCompletionResponse(res, "gpt-4"))
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	var m openAIChatResponse
+	if err := json.Unmarshal(raw, &m); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if len(m.Choices) != 2 {
+		t.Fatalf("choices = %d, want 2", len(m.Choices))
+	}
+
+	if m.Choices[1].Index != 1 || m.Choices[1].FinishReason != "length" || m.Choices[1].Message.Content != "Hi" {
+		t.Errorf("choices[1] = %+v", m.Choices[1])
+	}
+	if m.Choices[1].Logprobs != nil {
+		t.Errorf("choices[1].logprobs = %+v, want none", m.Choices[1].Logprobs)
+	}
+
+	logprobs := m.Choices[0].Logprobs
+	if logprobs == nil || len(logprobs.Content) != 2 {
+		t.Fatalf("choices[0].logprobs = %+v", logprobs)
+	}
+	if logprobs.Content[0].Token != "Hello" || len(logprobs.Content[0].TopLogprobs) != 2 {
+		t.Errorf("choices[0].logprobs.content[0] = %+v", logprobs.Content[0])
+	}
+	if logprobs.Content[0].TopLogprobs[1].Token != "Hi" || logprobs.Content[0].TopLogprobs[1].Logprob != -2.5 {
+		t.Errorf("choices[0].logprobs.content[0].top_logprobs[1] = %+v", logprobs.Content[0].TopLogprobs[1])
+	}
+}
+
 func TestFormatModelsResponse(t *testing.T) {
 	models := []ModelInfo{
 		{ID: "gpt-4", OwnedBy: "proxy"},
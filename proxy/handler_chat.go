@@ -72,6 +72,9 @@ func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 
 	// 7. Use potentially updated options from req
 	opts := req.ChatOptions
+	if isRerouted(req, resolvedModel) {
+		opts = append(opts, llm.WithModel(resolvedModel))
+	}
 
 	if stream {
 		streamingClient, ok := rawClient.(llm.ChatCompletionStreamingClient)
@@ -104,26 +107,10 @@ func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 
 	// 9. Build ProxyResponse
 	body := FormatChatCompletionResponse(llmRes, resolvedModel)
-	usage := llmRes.Usage()
-	tokensUsed := &TokenUsage{
-		PromptTokens:     int(usage.PromptTokens()),
-		CompletionTokens: int(usage.CompletionTokens()),
-		TotalTokens:      int(usage.TotalTokens()),
-	}
-	type cachedUsage interface{ CachedTokens() int64 }
-	if cu, ok := usage.(cachedUsage); ok {
-		tokensUsed.CachedTokens = int(cu.CachedTokens())
-	}
-	if cr, ok := usage.(llm.CostReportingUsage); ok {
-		if amount, currency, ok := cr.Cost(); ok {
-			tokensUsed.Cost = &amount
-			tokensUsed.CostCurrency = currency
-		}
-	}
 	proxyRes := &ProxyResponse{
 		StatusCode: http.StatusOK,
 		Body:       body,
-		TokensUsed: tokensUsed,
+		TokensUsed: newTokenUsage(llmRes.Usage()),
 	}
 
 	// 10. Post-response hooks
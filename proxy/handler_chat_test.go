@@ -15,9 +15,12 @@ import (
 type mockChatClient struct {
 	response llm.ChatCompletionResponse
 	err      error
+	// model records the per-call model override of the last call
+	model string
 }
 
-func (m *mockChatClient) ChatCompletion(_ context.Context, _ ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+func (m *mockChatClient) ChatCompletion(_ context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	m.model = llm.NewChatCompletionOptions(funcs...).Model
 	return m.response, m.err
 }
 
@@ -79,6 +82,37 @@ func TestHandleChatCompletions_Success(t *testing.T) {
 	}
 }
 
+func TestHandleChatCompletions_ModelOverride(t *testing.T) {
+	mockRes := llm.NewChatCompletionResponse(llm.NewMessage(llm.RoleAssistant, "Hello!"), llm.NewChatCompletionUsage(1, 1, 2))
+
+	testCases := []struct {
+		name          string
+		resolvedModel string
+		expectedModel string
+	}{
+		{name: "rerouted", resolvedModel: "provider-model", expectedModel: "provider-model"},
+		{name: "same model", resolvedModel: "gpt-4", expectedModel: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &mockChatClient{response: mockRes}
+			server := NewServer(WithHook(&resolverHook{client: client, model: tc.resolvedModel}))
+
+			reqBody := `{"model":"gpt-4","messages":[{"role":"user","content":"hi"}]}`
+			w := httptest.NewRecorder()
+			server.handleChatCompletions(w, buildChatRequest(t, reqBody))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			if client.model != tc.expectedModel {
+				t.Errorf("model override = %q, want %q", client.model, tc.expectedModel)
+			}
+		})
+	}
+}
+
 func TestHandleChatCompletions_InvalidJSON(t *testing.T) {
 	server := NewServer()
 	w := httptest.NewRecorder()
@@ -0,0 +1,191 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// completionFormat selects the wire format of a raw text completion endpoint.
+type completionFormat int
+
+const (
+	// completionFormatOpenAI is the OpenAI /v1/completions format.
+	completionFormatOpenAI completionFormat = iota
+	// completionFormatFIM is the Mistral /v1/fim/completions format, whose
+	// responses have the shape of chat completions.
+	completionFormatFIM
+)
+
+func (s *Server) handleCompletions(w http.ResponseWriter, r *http.Request) {
+	s.handleCompletionRequest(w, r, completionFormatOpenAI)
+}
+
+func (s *Server) handleFIMCompletions(w http.ResponseWriter, r *http.Request) {
+	s.handleCompletionRequest(w, r, completionFormatFIM)
+}
+
+func (s *Server) handleCompletionRequest(w http.ResponseWriter, r *http.Request, format completionFormat) {
+	ctx := r.Context()
+
+	rawBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeAPIError(w, NewBadRequestError("could not read request body"))
+		return
+	}
+
+	model, stream, prompt, suffix, completionOpts, err := ParseCompletionRequest(json.RawMessage(rawBody))
+	if err != nil {
+		writeAPIError(w, NewBadRequestError(err.Error()))
+		return
+	}
+
+	req := &ProxyRequest{
+		Type:              RequestTypeCompletion,
+		Model:             model,
+		Headers:           r.Header,
+		Body:              json.RawMessage(rawBody),
+		CompletionOptions: completionOpts,
+		Prompt:            prompt,
+		Suffix:            suffix,
+		Metadata:          make(map[string]any),
+	}
+
+	if s.options.AuthExtractor != nil {
+		userID, err := s.options.AuthExtractor(r)
+		if err != nil {
+			writeAPIError(w, NewUnauthorizedError(err.Error()))
+			return
+		}
+		req.UserID = userID
+		ctx = r.Context()
+	}
+
+	shortCircuit, err := s.chain.RunPreRequest(ctx, req)
+	if err != nil {
+		writeAPIError(w, NewInternalError(err.Error()))
+		return
+	}
+	if shortCircuit != nil {
+		writeProxyResponse(w, shortCircuit)
+		return
+	}
+
+	rawClient, resolvedModel, apiErr := s.resolveClient(r, req)
+	if apiErr != nil {
+		writeAPIError(w, apiErr)
+		return
+	}
+
+	completionClient, ok := rawClient.(llm.CompletionClient)
+	if !ok {
+		writeAPIError(w, NewInternalError("provider does not implement CompletionClient"))
+		return
+	}
+
+	opts := req.CompletionOptions
+	prompt, suffix = req.Prompt, req.Suffix
+	if isRerouted(req, resolvedModel) {
+		opts = append(opts, llm.WithCompletionModel(resolvedModel))
+	}
+
+	if stream {
+		chunks, err := completionClient.CompleteStream(ctx, prompt, suffix, opts...)
+		if err != nil {
+			slog.ErrorContext(ctx, "stream completion error", slog.Any("error", err))
+			errRes, _ := s.chain.RunOnError(ctx, req, err)
+			if errRes != nil {
+				writeProxyResponse(w, errRes)
+			} else {
+				writeAPIError(w, apiErrorFromErr(err))
+			}
+			return
+		}
+
+		var emitter streamEmitter
+		if format == completionFormatFIM {
+			emitter = newOpenAIStreamEmitter(resolvedModel)
+		} else {
+			emitter = newTextCompletionStreamEmitter(resolvedModel)
+		}
+
+		s.writeStream(w, r, req, chunks, emitter)
+		return
+	}
+
+	llmRes, err := completionClient.Complete(ctx, prompt, suffix, opts...)
+	if err != nil {
+		slog.ErrorContext(ctx, "completion error", slog.Any("error", err))
+		errRes, _ := s.chain.RunOnError(ctx, req, err)
+		if errRes != nil {
+			writeProxyResponse(w, errRes)
+		} else {
+			writeAPIError(w, apiErrorFromErr(err))
+		}
+		return
+	}
+
+	var body any
+	if format == completionFormatFIM {
+		body = FormatFIMCompletionResponse(llmRes, resolvedModel)
+	} else {
+		body = FormatCompletionResponse(llmRes, resolvedModel)
+	}
+
+	proxyRes := &ProxyResponse{
+		StatusCode: http.StatusOK,
+		Body:       body,
+		TokensUsed: newTokenUsage(llmRes.Usage()),
+	}
+
+	if err := s.chain.RunPostResponse(ctx, req, proxyRes); err != nil {
+		slog.WarnContext(ctx, "post-response hook error", slog.Any("error", err))
+	}
+
+	writeProxyResponse(w, proxyRes)
+}
+
+// textCompletionStreamEmitter encodes llm.StreamChunk values as OpenAI
+// "text_completion" SSE events. Errors and the closing "[DONE]" are shared
+// with the chat completion emitter.
+type textCompletionStreamEmitter struct {
+	*openAIStreamEmitter
+}
+
+func newTextCompletionStreamEmitter(model string) *textCompletionStreamEmitter {
+	emitter := newOpenAIStreamEmitter(model)
+	emitter.streamID = "cmpl-" + uuid.New().String()
+
+	return &textCompletionStreamEmitter{emitter}
+}
+
+func (e *textCompletionStreamEmitter) write(w io.Writer, chunk llm.StreamChunk) error {
+	payload := FormatCompletionStreamChunk(chunk, e.streamID, e.model)
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// EmitFirst implements streamEmitter.
+func (e *textCompletionStreamEmitter) EmitFirst(w io.Writer, chunk llm.StreamChunk) error {
+	return e.write(w, chunk)
+}
+
+// Emit implements streamEmitter.
+func (e *textCompletionStreamEmitter) Emit(w io.Writer, chunk llm.StreamChunk) error {
+	return e.write(w, chunk)
+}
+
+var _ streamEmitter = &textCompletionStreamEmitter{}
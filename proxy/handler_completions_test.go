@@ -0,0 +1,135 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+)
+
+// mockCompletionClient implements llm.CompletionClient for testing.
+type mockCompletionClient struct {
+	mockChatClient
+	// prompt, suffix and opts record the last call
+	prompt string
+	suffix string
+	opts   *llm.CompletionOptions
+}
+
+func (m *mockCompletionClient) Complete(_ context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (llm.CompletionResponse, error) {
+	m.prompt, m.suffix, m.opts = prompt, suffix, llm.NewCompletionOptions(funcs...)
+	return llm.NewCompletionResponse("return a + b", llm.FinishReasonStop, llm.NewChatCompletionUsage(12, 4, 16)), nil
+}
+
+func (m *mockCompletionClient) CompleteStream(_ context.Context, prompt string, suffix string, funcs ...llm.CompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	m.prompt, m.suffix, m.opts = prompt, suffix, llm.NewCompletionOptions(funcs...)
+
+	chunks := make(chan llm.StreamChunk, 3)
+	chunks <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, "return"))
+	chunks <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, " a + b"))
+	chunks <- llm.NewCompleteStreamChunk(llm.NewChatCompletionUsage(12, 4, 16))
+	close(chunks)
+
+	return chunks, nil
+}
+
+func buildCompletionRequest(t *testing.T, path string, body string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestHandleCompletions_Success(t *testing.T) {
+	client := &mockCompletionClient{}
+	server := NewServer(WithHook(&resolverHook{client: client, model: "codestral-latest"}))
+
+	reqBody := `{"model":"codestral","prompt":["func add(a, b int) int {"],"suffix":"}","max_tokens":32,"stop":"\n\n","seed":42}`
+	w := httptest.NewRecorder()
+	server.handleCompletions(w, buildCompletionRequest(t, "/v1/completions", reqBody))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	if client.prompt != "func add(a, b int) int {" || client.suffix != "}" {
+		t.Errorf("unexpected prompt %q and suffix %q", client.prompt, client.suffix)
+	}
+	if client.opts.Model != "codestral-latest" || *client.opts.MaxTokens != 32 || *client.opts.Seed != 42 || len(client.opts.Stop) != 1 {
+		t.Errorf("unexpected options %+v", client.opts)
+	}
+
+	var resp openAICompletionResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if resp.Object != "text_completion" || len(resp.Choices) != 1 || resp.Choices[0].Text != "return a + b" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 16 {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+}
+
+func TestHandleFIMCompletions_Success(t *testing.T) {
+	client := &mockCompletionClient{}
+	server := NewServer(WithHook(&resolverHook{client: client, model: "codestral"}))
+
+	reqBody := `{"model":"codestral","prompt":"func add(a, b int) int {","suffix":"}","random_seed":7}`
+	w := httptest.NewRecorder()
+	server.handleFIMCompletions(w, buildCompletionRequest(t, "/v1/fim/completions", reqBody))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	if client.opts.Model != "" || *client.opts.Seed != 7 {
+		t.Errorf("unexpected options %+v", client.opts)
+	}
+
+	var resp openAIChatResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if resp.Object != "chat.completion" || len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "return a + b" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestHandleCompletions_Stream(t *testing.T) {
+	client := &mockCompletionClient{}
+	server := NewServer(WithHook(&resolverHook{client: client, model: "codestral"}))
+
+	reqBody := `{"model":"codestral","prompt":"func add(a, b int) int {","stream":true}`
+	w := httptest.NewRecorder()
+	server.handleCompletions(w, buildCompletionRequest(t, "/v1/completions", reqBody))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, `"object":"text_completion"`) || !strings.Contains(body, `"text":" a + b"`) {
+		t.Errorf("expected text completion chunks, got %s", body)
+	}
+	if !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Errorf("expected the stream to end with [DONE], got %s", body)
+	}
+}
+
+func TestHandleCompletions_Unsupported(t *testing.T) {
+	server := NewServer(WithHook(&resolverHook{client: &mockChatClient{}, model: "gpt-4"}))
+
+	reqBody := `{"model":"gpt-4","prompt":"a"}`
+	w := httptest.NewRecorder()
+	server.handleCompletions(w, buildCompletionRequest(t, "/v1/completions", reqBody))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
@@ -65,7 +65,12 @@ func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	llmRes, err := embClient.Embeddings(ctx, inputs, req.EmbeddingOptions...)
+	embOpts = req.EmbeddingOptions
+	if isRerouted(req, resolvedModel) {
+		embOpts = append(embOpts, llm.WithEmbeddingsModel(resolvedModel))
+	}
+
+	llmRes, err := embClient.Embeddings(ctx, inputs, embOpts...)
 	if err != nil {
 		slog.ErrorContext(ctx, "embeddings error", slog.Any("error", err))
 		errRes, _ := s.chain.RunOnError(ctx, req, err)
@@ -68,6 +68,9 @@ func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
 
 	// 7. Use potentially updated options from req
 	opts := req.ChatOptions
+	if isRerouted(req, resolvedModel) {
+		opts = append(opts, llm.WithModel(resolvedModel))
+	}
 
 	if stream {
 		streamingClient, ok := rawClient.(llm.ChatCompletionStreamingClient)
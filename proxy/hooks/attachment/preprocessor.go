@@ -0,0 +1,97 @@
+package attachment
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/messageutil"
+	"github.com/bornholm/genai/proxy"
+	"github.com/pkg/errors"
+)
+
+// Preprocessor is a PreRequestHook fitting the images attached to chat
+// messages to the limits of the backend: oversized images are downscaled
+// and re-encoded, multi-page ones split.
+type Preprocessor struct {
+	funcs    []llm.AttachmentOptionFunc
+	priority int
+}
+
+// Name implements proxy.Hook.
+func (p *Preprocessor) Name() string { return "attachment.preprocessor" }
+
+// Priority implements proxy.Hook.
+func (p *Preprocessor) Priority() int { return p.priority }
+
+// PreRequest implements proxy.PreRequestHook.
+func (p *Preprocessor) PreRequest(ctx context.Context, req *proxy.ProxyRequest) (*proxy.HookResult, error) {
+	if req.Type != proxy.RequestTypeChatCompletion && req.Type != proxy.RequestTypeMessage {
+		return nil, nil
+	}
+
+	messages := llm.NewChatCompletionOptions(req.ChatOptions...).Messages
+
+	var (
+		updated  = make([]llm.Message, len(messages))
+		modified bool
+	)
+
+	for i, message := range messages {
+		updated[i] = message
+
+		if len(message.Attachments()) == 0 {
+			continue
+		}
+
+		var (
+			attachments = make([]llm.Attachment, 0, len(message.Attachments()))
+			changed     bool
+		)
+		for _, a := range message.Attachments() {
+			prepared, err := llm.PreprocessAttachment(a, p.funcs...)
+			if err != nil {
+				var attachmentErr *llm.AttachmentError
+				if errors.As(err, &attachmentErr) {
+					apiErr := proxy.NewBadRequestError(fmt.Sprintf("invalid attachment: %s", attachmentErr.Error()))
+					return &proxy.HookResult{
+						Response: &proxy.ProxyResponse{
+							StatusCode: http.StatusBadRequest,
+							Body:       proxy.ErrorResponse{Error: *apiErr},
+						},
+					}, nil
+				}
+
+				return nil, errors.WithStack(err)
+			}
+
+			if len(prepared) != 1 || prepared[0] != a {
+				changed = true
+			}
+
+			attachments = append(attachments, prepared...)
+		}
+
+		if changed {
+			updated[i] = messageutil.WithAttachments(message, attachments)
+			modified = true
+		}
+	}
+
+	if !modified {
+		return nil, nil
+	}
+
+	req.ChatOptions = append(req.ChatOptions, llm.WithMessages(updated...))
+
+	return &proxy.HookResult{Request: req}, nil
+}
+
+// NewPreprocessor creates a Preprocessor applying the given options, the
+// image limits among them.
+func NewPreprocessor(priority int, funcs ...llm.AttachmentOptionFunc) *Preprocessor {
+	return &Preprocessor{funcs: funcs, priority: priority}
+}
+
+var _ proxy.PreRequestHook = &Preprocessor{}
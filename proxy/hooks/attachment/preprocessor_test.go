@@ -0,0 +1,97 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"net/http"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/proxy"
+)
+
+func chatReq(msgs ...llm.Message) *proxy.ProxyRequest {
+	return &proxy.ProxyRequest{
+		Type:        proxy.RequestTypeChatCompletion,
+		ChatOptions: []llm.ChatCompletionOptionFunc{llm.WithMessages(msgs...)},
+		Metadata:    map[string]any{},
+	}
+}
+
+func imageAttachment(t *testing.T, width, height int) llm.Attachment {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	att, err := llm.NewImageAttachment("image/png", base64.StdEncoding.EncodeToString(buf.Bytes()), false)
+	if err != nil {
+		t.Fatalf("NewImageAttachment: %v", err)
+	}
+
+	return att
+}
+
+func TestPreprocessor_DownscalesImages(t *testing.T) {
+	p := NewPreprocessor(30, llm.WithImageLimits(llm.ImageLimits{MaxWidth: 100, MimeTypes: []string{"image/png"}}))
+	req := chatReq(
+		llm.NewMessage(llm.RoleSystem, "You describe images"),
+		llm.NewMultimodalMessage(llm.RoleUser, "What is this?", imageAttachment(t, 400, 200)),
+	)
+
+	result, err := p.PreRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || result.Request == nil {
+		t.Fatal("expected an updated request")
+	}
+
+	messages := llm.NewChatCompletionOptions(result.Request.ChatOptions...).Messages
+	if len(messages) != 2 || messages[1].Content() != "What is this?" {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+
+	data, _ := base64.StdEncoding.DecodeString(messages[1].Attachments()[0].Data())
+	config, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.DecodeConfig: %v", err)
+	}
+	if config.Width != 100 || config.Height != 50 {
+		t.Errorf("image resized to %dx%d, expected 100x50", config.Width, config.Height)
+	}
+}
+
+func TestPreprocessor_KeepsFittingRequest(t *testing.T) {
+	p := NewPreprocessor(30)
+	req := chatReq(llm.NewMultimodalMessage(llm.RoleUser, "What is this?", imageAttachment(t, 10, 10)))
+
+	result, err := p.PreRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != nil {
+		t.Error("a request within the limits should be left untouched")
+	}
+}
+
+func TestPreprocessor_RejectsInvalidImages(t *testing.T) {
+	att, err := llm.NewImageAttachment("image/png", base64.StdEncoding.EncodeToString([]byte("hello")), false)
+	if err != nil {
+		t.Fatalf("NewImageAttachment: %v", err)
+	}
+
+	p := NewPreprocessor(30)
+	result, err := p.PreRequest(context.Background(), chatReq(llm.NewMultimodalMessage(llm.RoleUser, "What is this?", att)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || result.Response == nil || result.Response.StatusCode != http.StatusBadRequest {
+		t.Fatal("expected a bad request response")
+	}
+}
@@ -13,7 +13,8 @@ import (
 )
 
 // ContentFilter is a PreRequestHook that applies a set of FilterRules to
-// the messages in a chat completion request.
+// the messages in a chat completion request, and to the prompt and suffix of
+// a raw text completion request.
 type ContentFilter struct {
 	rules    []FilterRule
 	priority int
@@ -27,13 +28,18 @@ func (f *ContentFilter) Priority() int { return f.priority }
 
 // PreRequest implements proxy.PreRequestHook.
 func (f *ContentFilter) PreRequest(ctx context.Context, req *proxy.ProxyRequest) (*proxy.HookResult, error) {
-	if req.Type != proxy.RequestTypeChatCompletion {
+	var messages []llm.Message
+
+	switch req.Type {
+	case proxy.RequestTypeChatCompletion:
+		// Extract messages from ChatOptions
+		messages = extractMessages(req.ChatOptions)
+	case proxy.RequestTypeCompletion:
+		messages = completionMessages(req.Prompt, req.Suffix)
+	default:
 		return nil, nil
 	}
 
-	// Extract messages from ChatOptions
-	messages := extractMessages(req.ChatOptions)
-
 	for _, rule := range f.rules {
 		if err := rule.Check(ctx, messages); err != nil {
 			apiErr := proxy.NewBadRequestError(fmt.Sprintf("content policy violation: %s", err.Error()))
@@ -55,6 +61,18 @@ func extractMessages(funcs []llm.ChatCompletionOptionFunc) []llm.Message {
 	return opts.Messages
 }
 
+// completionMessages returns the prompt and suffix of a raw text completion
+// as user messages, for the rules to check them as they check a chat.
+func completionMessages(prompt, suffix string) []llm.Message {
+	messages := make([]llm.Message, 0, 2)
+	for _, text := range []string{prompt, suffix} {
+		if text != "" {
+			messages = append(messages, llm.NewMessage(llm.RoleUser, text))
+		}
+	}
+	return messages
+}
+
 // NewContentFilter creates a ContentFilter with the given rules.
 func NewContentFilter(priority int, rules ...FilterRule) *ContentFilter {
 	return &ContentFilter{rules: rules, priority: priority}
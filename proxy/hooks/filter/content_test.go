@@ -121,6 +121,33 @@ func TestContentFilter_AllowsRequest(t *testing.T) {
 	}
 }
 
+func TestContentFilter_BlocksCompletionRequest(t *testing.T) {
+	f := NewContentFilter(1, NewKeywordRule("forbidden"))
+
+	for _, req := range []*proxy.ProxyRequest{
+		{Type: proxy.RequestTypeCompletion, Prompt: "this is forbidden content", Metadata: map[string]any{}},
+		{Type: proxy.RequestTypeCompletion, Prompt: "def main():", Suffix: "# forbidden", Metadata: map[string]any{}},
+	} {
+		result, err := f.PreRequest(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result == nil || result.Response == nil {
+			t.Fatalf("expected blocked response for prompt %q and suffix %q", req.Prompt, req.Suffix)
+		}
+	}
+
+	req := &proxy.ProxyRequest{Type: proxy.RequestTypeCompletion, Prompt: "def main():", Metadata: map[string]any{}}
+
+	result, err := f.PreRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != nil && result.Response != nil {
+		t.Error("should not have blocked request")
+	}
+}
+
 func TestContentFilter_IgnoresNonChatRequest(t *testing.T) {
 	f := NewContentFilter(1, NewKeywordRule("forbidden"))
 	req := &proxy.ProxyRequest{
@@ -15,6 +15,7 @@ const (
 	RequestTypeModels         RequestType = "models"
 	RequestTypeMessage        RequestType = "message"
 	RequestTypeCountTokens    RequestType = "count_tokens"
+	RequestTypeCompletion     RequestType = "completion"
 )
 
 // ProxyRequest encapsulates any request transiting through the proxy.
@@ -31,6 +32,11 @@ type ProxyRequest struct {
 	// For embeddings — populated after parsing
 	EmbeddingOptions []llm.EmbeddingsOptionFunc
 
+	// For raw text completions — populated after parsing
+	CompletionOptions []llm.CompletionOptionFunc
+	Prompt            string
+	Suffix            string
+
 	// Mutable metadata hooks can enrich
 	Metadata map[string]any
 }
@@ -51,3 +57,31 @@ type TokenUsage struct {
 	Cost             *float64 // provider-reported cost, nil if not available
 	CostCurrency     string
 }
+
+// newTokenUsage converts the usage reported by the provider to the usage
+// passed to the post-response hooks
+func newTokenUsage(usage llm.ChatCompletionUsage) *TokenUsage {
+	if usage == nil {
+		return &TokenUsage{}
+	}
+
+	tokensUsed := &TokenUsage{
+		PromptTokens:     int(usage.PromptTokens()),
+		CompletionTokens: int(usage.CompletionTokens()),
+		TotalTokens:      int(usage.TotalTokens()),
+	}
+
+	type cachedUsage interface{ CachedTokens() int64 }
+	if cu, ok := usage.(cachedUsage); ok {
+		tokensUsed.CachedTokens = int(cu.CachedTokens())
+	}
+
+	if cr, ok := usage.(llm.CostReportingUsage); ok {
+		if amount, currency, ok := cr.Cost(); ok {
+			tokensUsed.Cost = &amount
+			tokensUsed.CostCurrency = currency
+		}
+	}
+
+	return tokensUsed
+}
@@ -27,6 +27,8 @@ func NewServer(funcs ...OptionFunc) *Server {
 	}
 
 	s.mux.HandleFunc("POST /chat/completions", s.handleChatCompletions)
+	s.mux.HandleFunc("POST /completions", s.handleCompletions)
+	s.mux.HandleFunc("POST /fim/completions", s.handleFIMCompletions)
 	s.mux.HandleFunc("POST /embeddings", s.handleEmbeddings)
 	s.mux.HandleFunc("GET /models", s.handleModels)
 	s.mux.HandleFunc("POST /messages", s.handleMessages)
@@ -87,3 +89,10 @@ func (s *Server) resolveClient(r *http.Request, req *ProxyRequest) (client inter
 
 	return nil, "", NewModelNotFoundError(req.Model)
 }
+
+// isRerouted reports whether the resolver mapped req to another model than the
+// requested one. The resolved model is then forwarded to the client as a
+// per-call override, so a single client can serve several routes.
+func isRerouted(req *ProxyRequest, resolvedModel string) bool {
+	return resolvedModel != "" && resolvedModel != req.Model
+}
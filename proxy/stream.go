@@ -53,6 +53,20 @@ func (s *Server) streamChatCompletion(
 		return
 	}
 
+	s.writeStream(w, r, req, chunks, emitter)
+}
+
+// writeStream encodes the chunks of a stream opened by the caller via
+// emitter, as described by streamChatCompletion.
+func (s *Server) writeStream(
+	w http.ResponseWriter,
+	r *http.Request,
+	req *ProxyRequest,
+	chunks <-chan llm.StreamChunk,
+	emitter streamEmitter,
+) {
+	ctx := r.Context()
+
 	// Peek at the first chunk before committing to SSE headers.
 	// This lets us return a proper HTTP error status when the backend
 	// immediately rejects the request (e.g. invalid model parameters).
@@ -131,26 +145,10 @@ func (s *Server) streamChatCompletion(
 	}
 	flush()
 
-	usage := tracker.Usage()
-	streamTokensUsed := &TokenUsage{
-		PromptTokens:     int(usage.PromptTokens()),
-		CompletionTokens: int(usage.CompletionTokens()),
-		TotalTokens:      int(usage.TotalTokens()),
-	}
-	type cachedUsageStream interface{ CachedTokens() int64 }
-	if cu, ok := usage.(cachedUsageStream); ok {
-		streamTokensUsed.CachedTokens = int(cu.CachedTokens())
-	}
-	if cr, ok := usage.(llm.CostReportingUsage); ok {
-		if amount, currency, ok := cr.Cost(); ok {
-			streamTokensUsed.Cost = &amount
-			streamTokensUsed.CostCurrency = currency
-		}
-	}
 	proxyRes := &ProxyResponse{
 		StatusCode: http.StatusOK,
 		Body:       nil,
-		TokensUsed: streamTokensUsed,
+		TokensUsed: newTokenUsage(tracker.Usage()),
 	}
 	if err := s.chain.RunPostResponse(ctx, req, proxyRes); err != nil {
 		slog.WarnContext(ctx, "post-response hook error", slog.Any("error", err))
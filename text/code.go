@@ -0,0 +1,70 @@
+package text
+
+// Language identifies a programming language for the code splitter
+type Language string
+
+const (
+	LanguageGo         Language = "go"
+	LanguagePython     Language = "python"
+	LanguageJavaScript Language = "javascript"
+	LanguageTypeScript Language = "typescript"
+	LanguageJava       Language = "java"
+	LanguageRust       Language = "rust"
+	LanguageGeneric    Language = ""
+)
+
+var codeSeparators = map[Language][]string{
+	LanguageGo: {
+		"\nfunc ", "\ntype ", "\nvar ", "\nconst ",
+		"\n\n", "\n", " ", "",
+	},
+	LanguagePython: {
+		"\nclass ", "\ndef ", "\nasync def ", "\n    def ", "\n    async def ",
+		"\n\n", "\n", " ", "",
+	},
+	LanguageJavaScript: {
+		"\nexport ", "\nfunction ", "\nasync function ", "\nclass ", "\nconst ", "\nlet ",
+		"\n\n", "\n", " ", "",
+	},
+	LanguageTypeScript: {
+		"\nexport ", "\nfunction ", "\nasync function ", "\nclass ", "\ninterface ", "\ntype ", "\nenum ", "\nconst ", "\nlet ",
+		"\n\n", "\n", " ", "",
+	},
+	LanguageJava: {
+		"\nclass ", "\ninterface ", "\nenum ", "\npublic ", "\nprotected ", "\nprivate ", "\n    public ", "\n    protected ", "\n    private ",
+		"\n\n", "\n", " ", "",
+	},
+	LanguageRust: {
+		"\nfn ", "\npub fn ", "\nstruct ", "\npub struct ", "\nenum ", "\npub enum ", "\nimpl ", "\ntrait ", "\npub trait ", "\nmod ",
+		"\n\n", "\n", " ", "",
+	},
+	LanguageGeneric: {
+		"\n\n", "\n", " ", "",
+	},
+}
+
+// CodeSeparators returns the separators used to split source code of the
+// given language, top-level declarations first. Unknown languages fall back
+// on blank lines and line breaks.
+func CodeSeparators(lang Language) []string {
+	separators, exists := codeSeparators[lang]
+	if !exists {
+		separators = codeSeparators[LanguageGeneric]
+	}
+	return separators
+}
+
+// CodeSplitter splits source code on declaration boundaries of the given
+// language, so that functions and types are kept whole whenever they fit in
+// a chunk
+type CodeSplitter struct {
+	*RecursiveSplitter
+}
+
+func NewCodeSplitter(lang Language, funcs ...SplitterOptionFunc) *CodeSplitter {
+	return &CodeSplitter{
+		RecursiveSplitter: NewRecursiveSplitter(CodeSeparators(lang), funcs...),
+	}
+}
+
+var _ Splitter = &CodeSplitter{}
@@ -0,0 +1,108 @@
+package text
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MarkdownSeparators are the separators used inside a markdown section
+var MarkdownSeparators = []string{"\n\n", "\n", ". ", " ", ""}
+
+var markdownHeading = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+
+// MarkdownSplitter splits a markdown document along its headings. Chunks
+// never span two sections and carry the breadcrumb of their enclosing
+// headings. Sections larger than ChunkSize are split recursively.
+type MarkdownSplitter struct {
+	options *SplitterOptions
+}
+
+// Split implements Splitter.
+func (s *MarkdownSplitter) Split(text string) []Chunk {
+	chunks := make([]Chunk, 0)
+
+	for _, section := range markdownSections(text) {
+		pieces := splitRecursive(text, section.Start, section.End, MarkdownSeparators, s.options)
+		chunks = append(chunks, mergePieces(text, pieces, section.Headings, s.options)...)
+	}
+
+	return chunks
+}
+
+func NewMarkdownSplitter(funcs ...SplitterOptionFunc) *MarkdownSplitter {
+	return &MarkdownSplitter{
+		options: NewSplitterOptions(funcs...),
+	}
+}
+
+var _ Splitter = &MarkdownSplitter{}
+
+type markdownSection struct {
+	span
+	Headings []string
+}
+
+// markdownSections cuts the document before each heading found outside of a
+// fenced code block
+func markdownSections(text string) []markdownSection {
+	sections := make([]markdownSection, 0)
+	breadcrumb := make([]string, 0, 6)
+	levels := make([]int, 0, 6)
+
+	current := markdownSection{span: span{Start: 0}}
+	var fence string
+
+	offset := 0
+	for offset < len(text) {
+		lineEnd := strings.IndexByte(text[offset:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(text)
+		} else {
+			lineEnd += offset + 1
+		}
+
+		line := strings.TrimRight(text[offset:lineEnd], "\r\n")
+		trimmed := strings.TrimLeft(line, " ")
+
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+		default:
+			match := markdownHeading.FindStringSubmatch(line)
+			if match == nil {
+				break
+			}
+
+			current.End = offset
+			if current.End > current.Start {
+				sections = append(sections, current)
+			}
+
+			level := len(match[1])
+			for len(levels) > 0 && levels[len(levels)-1] >= level {
+				levels = levels[:len(levels)-1]
+				breadcrumb = breadcrumb[:len(breadcrumb)-1]
+			}
+			levels = append(levels, level)
+			breadcrumb = append(breadcrumb, match[2])
+
+			current = markdownSection{
+				span:     span{Start: offset},
+				Headings: append([]string(nil), breadcrumb...),
+			}
+		}
+
+		offset = lineEnd
+	}
+
+	current.End = len(text)
+	if current.End > current.Start {
+		sections = append(sections, current)
+	}
+
+	return sections
+}
@@ -0,0 +1,143 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Sentence struct {
+	Start int
+	End   int
+}
+
+// abbreviations are the words whose trailing period does not end a sentence
+var abbreviations = map[string]struct{}{
+	"mr": {}, "mrs": {}, "ms": {}, "dr": {}, "prof": {}, "sr": {}, "jr": {}, "st": {},
+	"vs": {}, "cf": {}, "fig": {}, "mme": {}, "mlle": {}, "env": {},
+}
+
+// SplitBySentences returns the sentences of the given text. A sentence ends
+// with a terminal punctuation mark followed by a whitespace, or with a blank
+// line.
+func SplitBySentences(text string) []*Sentence {
+	sentences := make([]*Sentence, 0)
+
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		trimmed := strings.TrimRightFunc(text[start:end], unicode.IsSpace)
+		if trimmed != "" {
+			sentences = append(sentences, &Sentence{Start: start, End: start + len(trimmed)})
+		}
+		start = -1
+	}
+
+	for idx := 0; idx < len(text); {
+		r, size := utf8.DecodeRuneInString(text[idx:])
+
+		if start < 0 {
+			if !unicode.IsSpace(r) {
+				start = idx
+			}
+			idx += size
+			continue
+		}
+
+		if r == '\n' && strings.HasPrefix(strings.TrimLeft(text[idx+size:], " \t\r"), "\n") {
+			flush(idx)
+			idx += size
+			continue
+		}
+
+		if isTerminal(r) {
+			end := idx + size
+			for end < len(text) {
+				next, nextSize := utf8.DecodeRuneInString(text[end:])
+				if !isTerminal(next) && !isClosing(next) {
+					break
+				}
+				end += nextSize
+			}
+
+			next, _ := utf8.DecodeRuneInString(text[end:])
+			if (end == len(text) || unicode.IsSpace(next)) && !(r == '.' && isAbbreviation(text[start:idx])) {
+				flush(end)
+			}
+
+			idx = end
+			continue
+		}
+
+		idx += size
+	}
+
+	flush(len(text))
+
+	return sentences
+}
+
+func isTerminal(r rune) bool {
+	switch r {
+	case '.', '!', '?', '…', '。', '！', '？':
+		return true
+	default:
+		return false
+	}
+}
+
+func isClosing(r rune) bool {
+	switch r {
+	case '"', '\'', ')', ']', '»', '”', '’':
+		return true
+	default:
+		return false
+	}
+}
+
+func isAbbreviation(prefix string) bool {
+	idx := strings.LastIndexFunc(prefix, unicode.IsSpace)
+	word := strings.ToLower(strings.TrimLeft(prefix[idx+1:], "(\"'«“‘"))
+	if word == "" {
+		return false
+	}
+
+	if _, exists := abbreviations[word]; exists {
+		return true
+	}
+
+	// Dotted abbreviations, as in "e.g." or "p.m."
+	if strings.Contains(word, ".") {
+		return true
+	}
+
+	// Single letter initials, as in "J. R. R. Tolkien"
+	r, size := utf8.DecodeRuneInString(word)
+	return size == len(word) && unicode.IsLetter(r)
+}
+
+// SentenceSplitter packs whole sentences into chunks. Sentences larger than
+// ChunkSize are split on words.
+type SentenceSplitter struct {
+	options *SplitterOptions
+}
+
+// Split implements Splitter.
+func (s *SentenceSplitter) Split(text string) []Chunk {
+	pieces := make([]span, 0)
+	for _, sentence := range SplitBySentences(text) {
+		pieces = append(pieces, splitRecursive(text, sentence.Start, sentence.End, []string{" ", ""}, s.options)...)
+	}
+
+	return mergePieces(text, pieces, nil, s.options)
+}
+
+func NewSentenceSplitter(funcs ...SplitterOptionFunc) *SentenceSplitter {
+	return &SentenceSplitter{
+		options: NewSplitterOptions(funcs...),
+	}
+}
+
+var _ Splitter = &SentenceSplitter{}
@@ -0,0 +1,277 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunk is a fragment of a source text produced by a Splitter
+type Chunk struct {
+	// Text is the content of the chunk, with surrounding whitespaces trimmed
+	Text string
+	// Start and End are the byte offsets of Text in the source text
+	Start int
+	End   int
+	// Headings holds the breadcrumb of the headings enclosing the chunk,
+	// outermost first, when the splitter knows about the document structure
+	Headings []string
+	// Tokens is the estimated size of Text
+	Tokens int
+}
+
+// Splitter splits a text into chunks
+type Splitter interface {
+	Split(text string) []Chunk
+}
+
+// TokenEstimator returns the estimated number of tokens of the given text
+type TokenEstimator func(text string) int
+
+// DefaultTokenEstimator provides a simple heuristic for token estimation,
+// assuming ~4 bytes per token
+func DefaultTokenEstimator(text string) int {
+	return (len(text) + 3) / 4
+}
+
+const (
+	DefaultChunkSize    = 512
+	DefaultChunkOverlap = 64
+)
+
+type SplitterOptions struct {
+	// ChunkSize is the maximum size of a chunk, in tokens
+	ChunkSize int
+	// ChunkOverlap is the number of tokens shared by consecutive chunks
+	ChunkOverlap   int
+	TokenEstimator TokenEstimator
+}
+
+type SplitterOptionFunc func(opts *SplitterOptions)
+
+func NewSplitterOptions(funcs ...SplitterOptionFunc) *SplitterOptions {
+	opts := &SplitterOptions{
+		ChunkSize:      DefaultChunkSize,
+		ChunkOverlap:   DefaultChunkOverlap,
+		TokenEstimator: DefaultTokenEstimator,
+	}
+	for _, fn := range funcs {
+		fn(opts)
+	}
+
+	if opts.ChunkSize < 1 {
+		opts.ChunkSize = 1
+	}
+	if opts.ChunkOverlap < 0 {
+		opts.ChunkOverlap = 0
+	}
+	if opts.ChunkOverlap >= opts.ChunkSize {
+		opts.ChunkOverlap = opts.ChunkSize - 1
+	}
+	if opts.TokenEstimator == nil {
+		opts.TokenEstimator = DefaultTokenEstimator
+	}
+
+	return opts
+}
+
+func WithChunkSize(size int) SplitterOptionFunc {
+	return func(opts *SplitterOptions) {
+		opts.ChunkSize = size
+	}
+}
+
+func WithChunkOverlap(overlap int) SplitterOptionFunc {
+	return func(opts *SplitterOptions) {
+		opts.ChunkOverlap = overlap
+	}
+}
+
+func WithTokenEstimator(estimator TokenEstimator) SplitterOptionFunc {
+	return func(opts *SplitterOptions) {
+		opts.TokenEstimator = estimator
+	}
+}
+
+// DefaultSeparators are the separators used by the recursive splitter,
+// from the coarsest to the finest
+var DefaultSeparators = []string{"\n\n", "\n", ". ", " ", ""}
+
+// RecursiveSplitter splits a text on the coarsest separator yielding pieces
+// small enough, falling back on finer separators for oversized pieces, then
+// merges consecutive pieces into chunks of at most ChunkSize tokens
+type RecursiveSplitter struct {
+	options    *SplitterOptions
+	separators []string
+}
+
+// Split implements Splitter.
+func (s *RecursiveSplitter) Split(text string) []Chunk {
+	pieces := splitRecursive(text, 0, len(text), s.separators, s.options)
+	return mergePieces(text, pieces, nil, s.options)
+}
+
+func NewRecursiveSplitter(separators []string, funcs ...SplitterOptionFunc) *RecursiveSplitter {
+	if len(separators) == 0 {
+		separators = DefaultSeparators
+	}
+	return &RecursiveSplitter{
+		options:    NewSplitterOptions(funcs...),
+		separators: separators,
+	}
+}
+
+var _ Splitter = &RecursiveSplitter{}
+
+// span is a [Start, End) byte range of a source text
+type span struct {
+	Start int
+	End   int
+}
+
+// splitRecursive cuts text[start:end] into contiguous spans each fitting in
+// ChunkSize tokens when possible
+func splitRecursive(text string, start, end int, separators []string, opts *SplitterOptions) []span {
+	if opts.TokenEstimator(text[start:end]) <= opts.ChunkSize {
+		return []span{{start, end}}
+	}
+
+	for idx, sep := range separators {
+		if sep == "" {
+			return splitRunes(text, start, end, opts)
+		}
+
+		cuts := cutPositions(text[start:end], sep)
+		if len(cuts) == 0 {
+			continue
+		}
+
+		pieces := make([]span, 0, len(cuts)+1)
+		from := start
+		for _, cut := range append(cuts, end-start) {
+			to := start + cut
+			if to <= from {
+				continue
+			}
+			if opts.TokenEstimator(text[from:to]) > opts.ChunkSize {
+				pieces = append(pieces, splitRecursive(text, from, to, separators[idx+1:], opts)...)
+			} else {
+				pieces = append(pieces, span{from, to})
+			}
+			from = to
+		}
+
+		return pieces
+	}
+
+	return []span{{start, end}}
+}
+
+// cutPositions returns the offsets at which text should be cut for the given
+// separator. The leading whitespaces and punctuation of the separator stay
+// with the preceding piece, so that "\nfunc " starts a new piece with the
+// keyword while ". " ends a sentence.
+func cutPositions(text, sep string) []int {
+	keep := len(sep)
+	for i, r := range sep {
+		if !unicode.IsSpace(r) && !unicode.IsPunct(r) {
+			keep = i
+			break
+		}
+	}
+
+	cuts := make([]int, 0)
+	offset := 0
+	for {
+		idx := strings.Index(text[offset:], sep)
+		if idx < 0 {
+			break
+		}
+		cut := offset + idx + keep
+		if cut > 0 && cut < len(text) {
+			cuts = append(cuts, cut)
+		}
+		offset += idx + len(sep)
+	}
+
+	return cuts
+}
+
+// splitRunes cuts text[start:end] at rune boundaries into spans of at most
+// ChunkSize tokens
+func splitRunes(text string, start, end int, opts *SplitterOptions) []span {
+	pieces := make([]span, 0)
+	from := start
+	for from < end {
+		to := from
+		for to < end {
+			_, size := utf8.DecodeRuneInString(text[to:end])
+			if to > from && opts.TokenEstimator(text[from:to+size]) > opts.ChunkSize {
+				break
+			}
+			to += size
+		}
+		pieces = append(pieces, span{from, to})
+		from = to
+	}
+	return pieces
+}
+
+// mergePieces packs consecutive pieces into chunks of at most ChunkSize
+// tokens, repeating the trailing pieces of a chunk, up to ChunkOverlap
+// tokens, at the start of the next one
+func mergePieces(text string, pieces []span, headings []string, opts *SplitterOptions) []Chunk {
+	chunks := make([]Chunk, 0)
+
+	tokens := make([]int, len(pieces))
+	for i, p := range pieces {
+		tokens[i] = opts.TokenEstimator(text[p.Start:p.End])
+	}
+
+	start := 0
+	for start < len(pieces) {
+		end := start
+		total := 0
+		for end < len(pieces) && (end == start || total+tokens[end] <= opts.ChunkSize) {
+			total += tokens[end]
+			end++
+		}
+
+		if chunk, ok := newChunk(text, pieces[start].Start, pieces[end-1].End, headings, opts); ok {
+			chunks = append(chunks, chunk)
+		}
+
+		if end == len(pieces) {
+			break
+		}
+
+		next := end
+		overlap := 0
+		for next > start+1 && overlap+tokens[next-1] <= opts.ChunkOverlap {
+			next--
+			overlap += tokens[next]
+		}
+		start = next
+	}
+
+	return chunks
+}
+
+func newChunk(text string, start, end int, headings []string, opts *SplitterOptions) (Chunk, bool) {
+	raw := text[start:end]
+	trimmedLeft := strings.TrimLeftFunc(raw, unicode.IsSpace)
+	trimmed := strings.TrimRightFunc(trimmedLeft, unicode.IsSpace)
+	if trimmed == "" {
+		return Chunk{}, false
+	}
+
+	start += len(raw) - len(trimmedLeft)
+
+	return Chunk{
+		Text:     trimmed,
+		Start:    start,
+		End:      start + len(trimmed),
+		Headings: headings,
+		Tokens:   opts.TokenEstimator(trimmed),
+	}, true
+}
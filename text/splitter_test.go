@@ -0,0 +1,203 @@
+package text
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func wordEstimator(text string) int {
+	return len(strings.Fields(text))
+}
+
+func checkChunks(t *testing.T, source string, chunks []Chunk, maxTokens int) {
+	t.Helper()
+
+	if len(chunks) == 0 {
+		t.Fatal("expected at least one chunk")
+	}
+
+	for i, c := range chunks {
+		if source[c.Start:c.End] != c.Text {
+			t.Errorf("chunk #%d: offsets [%d:%d] do not match text %q", i, c.Start, c.End, c.Text)
+		}
+		if c.Tokens > maxTokens {
+			t.Errorf("chunk #%d: expected at most %d tokens, got %d (%q)", i, maxTokens, c.Tokens, c.Text)
+		}
+	}
+}
+
+func TestRecursiveSplitter(t *testing.T) {
+	source := "First paragraph has some words.\n\nSecond paragraph is here. It has two sentences.\n\nThird one."
+
+	splitter := NewRecursiveSplitter(nil, WithChunkSize(6), WithChunkOverlap(0), WithTokenEstimator(wordEstimator))
+	chunks := splitter.Split(source)
+
+	checkChunks(t, source, chunks, 6)
+
+	expected := []string{
+		"First paragraph has some words.",
+		"Second paragraph is here.",
+		"It has two sentences.\n\nThird one.",
+	}
+
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+
+	if !reflect.DeepEqual(texts, expected) {
+		t.Errorf("expected %q, got %q", expected, texts)
+	}
+}
+
+func TestRecursiveSplitter_Runes(t *testing.T) {
+	source := strings.Repeat("é", 50)
+
+	chunks := NewRecursiveSplitter(nil, WithChunkSize(10), WithChunkOverlap(0)).Split(source)
+	checkChunks(t, source, chunks, 10)
+
+	var rebuilt strings.Builder
+	for _, c := range chunks {
+		rebuilt.WriteString(c.Text)
+	}
+
+	if rebuilt.String() != source {
+		t.Errorf("expected chunks to cover the source without overlap")
+	}
+}
+
+func TestSlidingWindowSplitter(t *testing.T) {
+	source := "one two three four five six seven eight nine ten"
+
+	chunks := NewSlidingWindowSplitter(WithChunkSize(4), WithChunkOverlap(2), WithTokenEstimator(wordEstimator)).Split(source)
+	checkChunks(t, source, chunks, 4)
+
+	expected := []string{
+		"one two three four",
+		"three four five six",
+		"five six seven eight",
+		"seven eight nine ten",
+	}
+
+	for i, c := range chunks {
+		if i >= len(expected) || c.Text != expected[i] {
+			t.Errorf("chunk #%d: unexpected text %q", i, c.Text)
+		}
+	}
+
+	if len(chunks) != len(expected) {
+		t.Errorf("expected %d chunks, got %d", len(expected), len(chunks))
+	}
+}
+
+func TestSplitBySentences(t *testing.T) {
+	source := `Dr. Smith arrived at 3.30 p.m. today! Did he bring "the book"? Yes.
+A new line without punctuation
+
+Another paragraph`
+
+	expected := []string{
+		"Dr. Smith arrived at 3.30 p.m. today!",
+		`Did he bring "the book"?`,
+		"Yes.",
+		"A new line without punctuation",
+		"Another paragraph",
+	}
+
+	sentences := SplitBySentences(source)
+
+	texts := make([]string, len(sentences))
+	for i, s := range sentences {
+		texts[i] = source[s.Start:s.End]
+	}
+
+	if !reflect.DeepEqual(texts, expected) {
+		t.Errorf("expected %q, got %q", expected, texts)
+	}
+}
+
+func TestSentenceSplitter(t *testing.T) {
+	source := "The first sentence is short. The second sentence is a bit longer than the first. Third."
+
+	chunks := NewSentenceSplitter(WithChunkSize(10), WithChunkOverlap(0), WithTokenEstimator(wordEstimator)).Split(source)
+	checkChunks(t, source, chunks, 10)
+
+	for _, c := range chunks {
+		if !strings.HasSuffix(c.Text, ".") {
+			t.Errorf("expected chunk to end on a sentence boundary, got %q", c.Text)
+		}
+	}
+}
+
+func TestMarkdownSplitter(t *testing.T) {
+	source := `Intro text.
+
+# Guide
+
+Some guide text.
+
+## Install
+
+Run the installer.
+
+` + "```sh\n# not a heading\nmake install\n```" + `
+
+## Usage ##
+
+Use it.
+
+# Appendix
+
+The end.
+`
+
+	chunks := NewMarkdownSplitter(WithChunkSize(100), WithTokenEstimator(wordEstimator)).Split(source)
+	checkChunks(t, source, chunks, 100)
+
+	expected := [][]string{
+		nil,
+		{"Guide"},
+		{"Guide", "Install"},
+		{"Guide", "Usage"},
+		{"Appendix"},
+	}
+
+	if len(chunks) != len(expected) {
+		t.Fatalf("expected %d chunks, got %d", len(expected), len(chunks))
+	}
+
+	for i, c := range chunks {
+		if !reflect.DeepEqual(c.Headings, expected[i]) {
+			t.Errorf("chunk #%d: expected headings %q, got %q", i, expected[i], c.Headings)
+		}
+	}
+
+	if !strings.Contains(chunks[2].Text, "# not a heading") {
+		t.Errorf("expected fenced code block to stay in the Install section, got %q", chunks[2].Text)
+	}
+}
+
+func TestCodeSplitter(t *testing.T) {
+	source := `package main
+
+import "fmt"
+
+func a() {
+	fmt.Println("a")
+}
+
+func b() {
+	fmt.Println("b")
+}
+`
+
+	chunks := NewCodeSplitter(LanguageGo, WithChunkSize(12), WithChunkOverlap(0)).Split(source)
+	checkChunks(t, source, chunks, 12)
+
+	for _, c := range chunks[1:] {
+		if !strings.HasPrefix(c.Text, "func ") {
+			t.Errorf("expected chunk to start on a declaration, got %q", c.Text)
+		}
+	}
+}
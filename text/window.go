@@ -0,0 +1,45 @@
+package text
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// SlidingWindowSplitter ignores the structure of the text and slides a
+// window of ChunkSize tokens over its words, each window overlapping the
+// previous one by ChunkOverlap tokens
+type SlidingWindowSplitter struct {
+	options *SplitterOptions
+}
+
+// Split implements Splitter.
+func (s *SlidingWindowSplitter) Split(text string) []Chunk {
+	pieces := make([]span, 0)
+
+	start := -1
+	for idx := 0; idx <= len(text); {
+		r, size := utf8.DecodeRuneInString(text[idx:])
+		if idx == len(text) || unicode.IsSpace(r) {
+			if start >= 0 {
+				pieces = append(pieces, splitRunes(text, start, idx, s.options)...)
+				start = -1
+			}
+			if idx == len(text) {
+				break
+			}
+		} else if start < 0 {
+			start = idx
+		}
+		idx += size
+	}
+
+	return mergePieces(text, pieces, nil, s.options)
+}
+
+func NewSlidingWindowSplitter(funcs ...SplitterOptionFunc) *SlidingWindowSplitter {
+	return &SlidingWindowSplitter{
+		options: NewSplitterOptions(funcs...),
+	}
+}
+
+var _ Splitter = &SlidingWindowSplitter{}
@@ -175,14 +175,24 @@ func makeRunFunc(handler *loop.Handler) func(js.Value, []js.Value) any {
 			onEvent = args[1]
 		}
 
-		var attachments []llm.Attachment
+		var jsAttachments js.Value
 		if len(args) >= 3 && args[2].Type() == js.TypeObject && !args[2].IsNull() {
-			attachments = jsToAttachments(args[2])
+			jsAttachments = args[2]
 		}
 
 		ctx, cancel := context.WithCancel(context.Background())
 
 		promise := promisify(func() (js.Value, error) {
+			// Un attachement invalide rejette la Promise, comme le hook
+			// d'attachements du proxy répond par une erreur
+			var attachments []llm.Attachment
+			if !jsAttachments.IsUndefined() {
+				var err error
+				if attachments, err = jsToAttachments(jsAttachments); err != nil {
+					return js.Undefined(), errors.WithStack(err)
+				}
+			}
+
 			runner := agent.NewRunner(handler)
 			input := agent.NewInput(message, attachments...)
 
@@ -281,12 +291,14 @@ func jsApprovalFunc(fn js.Value) loop.ApprovalFunc {
 	}
 }
 
-// jsToAttachments convertit un tableau JS d'attachements en []llm.Attachment.
+// jsToAttachments convertit un tableau JS d'attachements en []llm.Attachment,
+// prétraités aux limites des providers. Un attachement invalide produit une
+// erreur.
 //
 // Format attendu pour chaque élément :
 //
 //	{ type: "image"|"audio"|"video"|"document", mimeType: string, source: "base64"|"url", data: string }
-func jsToAttachments(arr js.Value) []llm.Attachment {
+func jsToAttachments(arr js.Value) ([]llm.Attachment, error) {
 	length := arr.Length()
 	result := make([]llm.Attachment, 0, length)
 	for i := range length {
@@ -308,12 +320,21 @@ func jsToAttachments(arr js.Value) []llm.Attachment {
 			att, err = llm.NewVideoAttachment(mimeType, data, isURL)
 		case "document":
 			att, err = llm.NewDocumentAttachment(mimeType, data, isURL)
+		default:
+			return nil, errors.Errorf("attachement %d : type inconnu : %s (supportés : image, audio, video, document)", i, attachType)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "attachement %d invalide", i)
 		}
-		if err == nil && att != nil {
-			result = append(result, att)
+		// Les images sont redimensionnées aux limites des providers, les
+		// images multipages découpées
+		prepared, err := llm.PreprocessAttachment(att)
+		if err != nil {
+			return nil, errors.Wrapf(err, "attachement %d invalide", i)
 		}
+		result = append(result, prepared...)
 	}
-	return result
+	return result, nil
 }
 
 // marshalEventData sérialise la donnée d'un événement en objet JS.